@@ -54,6 +54,11 @@ Flags:
 		Allow references to Go symbols in shared libraries (experimental).
 	-e
 		Remove the limit on the number of errors reported (default limit is 10).
+	-embedcfg file
+		Read go:embed configuration from file.
+		This is required if any //go:embed directives are used.
+		The file is a JSON file mapping patterns to lists of filenames
+		and filenames to full path names.
 	-h
 		Halt with a stack trace at the first error detected.
 	-importmap old=new
@@ -170,5 +175,14 @@ The //go:linkname directive instructs the compiler to use ``importpath.name'' as
 object file symbol name for the variable or function declared as ``localname'' in the
 source code. Because this directive can subvert the type system and package
 modularity, it is only enabled in files that have imported "unsafe".
+
+	//go:embed pattern...
+
+The //go:embed directive initializes the package-level variable declared
+immediately after it with the contents of the files matched by the patterns,
+as resolved by the go command and passed to the compiler with -embedcfg.
+Unlike the other directives, it may be indented, so that it can be used
+inside a parenthesized var declaration. It is only enabled in files that
+have imported "embed". See the embed package documentation for details.
 */
 package main
@@ -0,0 +1,373 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gc
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"path"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"cmd/compile/internal/syntax"
+	"cmd/compile/internal/types"
+	"cmd/internal/obj"
+	"cmd/internal/src"
+)
+
+// embedCfg is the configuration read from the -embedcfg file,
+// which the go command writes for packages using //go:embed.
+var embedCfg struct {
+	// Patterns maps each //go:embed pattern to the list of
+	// slash-separated file names, relative to the package
+	// directory, that it matches.
+	Patterns map[string][]string
+
+	// Files maps each of those file names to the
+	// path of the file on disk.
+	Files map[string]string
+}
+
+func readEmbedCfg(file string) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		log.Fatalf("-embedcfg: %v", err)
+	}
+	if err := json.Unmarshal(data, &embedCfg); err != nil {
+		log.Fatalf("%s: %v", file, err)
+	}
+	if embedCfg.Patterns == nil {
+		log.Fatalf("%s: invalid embedcfg: missing Patterns", file)
+	}
+	if embedCfg.Files == nil {
+		log.Fatalf("%s: invalid embedcfg: missing Files", file)
+	}
+}
+
+// A pragmaEmbed is a //go:embed directive.
+type pragmaEmbed struct {
+	pos      syntax.Pos
+	patterns []string
+}
+
+// An embedVar is a variable initialized by //go:embed directives.
+type embedVar struct {
+	pos      src.XPos // position of the first directive
+	n        *Node    // the variable
+	patterns []string
+}
+
+// embedlist lists the variables initialized by //go:embed directives,
+// in source order.
+var embedlist []*embedVar
+
+// takeEmbeds removes and returns the pending //go:embed directives
+// that appear before pos in the file.
+func (p *noder) takeEmbeds(pos syntax.Pos) []pragmaEmbed {
+	i := 0
+	for i < len(p.embeds) && posBefore(p.embeds[i].pos, pos) {
+		i++
+	}
+	list := p.embeds[:i:i]
+	p.embeds = p.embeds[i:]
+	return list
+}
+
+// posBefore reports whether x comes before y in the same source file.
+func posBefore(x, y syntax.Pos) bool {
+	return x.Line() < y.Line() || x.Line() == y.Line() && x.Col() < y.Col()
+}
+
+// parseGoEmbed parses the text following "//go:embed" to extract the glob patterns.
+// It accepts unquoted space-separated patterns as well as double-quoted and back-quoted Go strings.
+// go/build/read.go also processes these strings and contains similar logic.
+func parseGoEmbed(args string) ([]string, error) {
+	var list []string
+	for args = strings.TrimSpace(args); args != ""; args = strings.TrimSpace(args) {
+		var path string
+	Switch:
+		switch args[0] {
+		default:
+			i := len(args)
+			for j, c := range args {
+				if unicode.IsSpace(c) {
+					i = j
+					break
+				}
+			}
+			path = args[:i]
+			args = args[i:]
+
+		case '`':
+			i := strings.Index(args[1:], "`")
+			if i < 0 {
+				return nil, fmt.Errorf("invalid quoted string in //go:embed: %s", args)
+			}
+			path = args[1 : 1+i]
+			args = args[1+i+1:]
+
+		case '"':
+			i := 1
+			for ; i < len(args); i++ {
+				if args[i] == '\\' {
+					i++
+					continue
+				}
+				if args[i] == '"' {
+					q, err := strconv.Unquote(args[:i+1])
+					if err != nil {
+						return nil, fmt.Errorf("invalid quoted string in //go:embed: %s", args[:i+1])
+					}
+					path = q
+					args = args[i+1:]
+					break Switch
+				}
+			}
+			if i >= len(args) {
+				return nil, fmt.Errorf("invalid quoted string in //go:embed: %s", args)
+			}
+		}
+
+		if args != "" {
+			r, _ := utf8.DecodeRuneInString(args)
+			if !unicode.IsSpace(r) {
+				return nil, fmt.Errorf("invalid quoted string in //go:embed: %s", args)
+			}
+		}
+		list = append(list, path)
+	}
+	return list, nil
+}
+
+// varEmbed records that the variable declared by names is
+// initialized by the //go:embed directives in embeds.
+func (p *noder) varEmbed(names []*Node, exprs []*Node, embeds []pragmaEmbed) {
+	pos := embeds[0].pos
+	if !p.importedEmbed {
+		p.yyerrorpos(pos, "//go:embed only allowed in Go files that import \"embed\"")
+		return
+	}
+	if len(names) > 1 {
+		p.yyerrorpos(pos, "//go:embed cannot apply to multiple vars")
+		return
+	}
+	if len(exprs) > 0 {
+		p.yyerrorpos(pos, "//go:embed cannot apply to var with initializer")
+		return
+	}
+	if dclcontext != PEXTERN {
+		p.yyerrorpos(pos, "//go:embed cannot apply to var inside func")
+		return
+	}
+	if embedCfg.Patterns == nil {
+		p.yyerrorpos(pos, "invalid //go:embed: build system did not supply embed configuration")
+		return
+	}
+
+	var patterns []string
+	for _, e := range embeds {
+		for _, pattern := range e.patterns {
+			if _, ok := embedCfg.Patterns[pattern]; !ok {
+				p.yyerrorpos(e.pos, "invalid //go:embed: build system did not map pattern: %s", pattern)
+			}
+			patterns = append(patterns, pattern)
+		}
+	}
+	embedlist = append(embedlist, &embedVar{pos: p.makeXPos(pos), n: names[0], patterns: patterns})
+}
+
+const (
+	embedUnknown = iota
+	embedBytes
+	embedString
+	embedFiles
+)
+
+// embedKind reports how a variable of type typ is initialized
+// by //go:embed.
+func embedKind(typ *types.Type) int {
+	if typ.Sym != nil && typ.Sym.Name == "FS" && (typ.Sym.Pkg.Path == "embed" || (typ.Sym.Pkg == localpkg && myimportpath == "embed")) {
+		return embedFiles
+	}
+	if typ.Etype == TSTRING {
+		return embedString
+	}
+	if typ.IsSlice() && typ.Elem().Etype == TUINT8 {
+		return embedBytes
+	}
+	return embedUnknown
+}
+
+func embedFileNameSplit(name string) (dir, elem string, isDir bool) {
+	if name[len(name)-1] == '/' {
+		isDir = true
+		name = name[:len(name)-1]
+	}
+	i := len(name) - 1
+	for i >= 0 && name[i] != '/' {
+		i--
+	}
+	if i < 0 {
+		return ".", name, isDir
+	}
+	return name[:i], name[i+1:], isDir
+}
+
+// embedFileLess implements the sort order for a list of embedded files.
+// See the comment inside ../../../../embed/embed.go's FS struct for rationale.
+func embedFileLess(x, y string) bool {
+	xdir, xelem, _ := embedFileNameSplit(x)
+	ydir, yelem, _ := embedFileNameSplit(y)
+	return xdir < ydir || xdir == ydir && xelem < yelem
+}
+
+// embedFileList returns the sorted list of files to store in e.
+// For an FS, the list includes an entry, ending in a slash,
+// for each directory containing one of the files.
+func embedFileList(e *embedVar, kind int) []string {
+	have := make(map[string]bool)
+	var list []string
+	for _, pattern := range e.patterns {
+		for _, file := range embedCfg.Patterns[pattern] {
+			if embedCfg.Files[file] == "" {
+				yyerrorl(e.pos, "invalid //go:embed: build system did not map file: %s", file)
+				continue
+			}
+			if !have[file] {
+				have[file] = true
+				list = append(list, file)
+			}
+			if kind == embedFiles {
+				for dir := path.Dir(file); dir != "." && !have[dir]; dir = path.Dir(dir) {
+					have[dir] = true
+					list = append(list, dir+"/")
+				}
+			}
+		}
+	}
+	obj.SortSlice(list, func(i, j int) bool {
+		return embedFileLess(list[i], list[j])
+	})
+
+	if kind == embedString || kind == embedBytes {
+		if len(list) > 1 {
+			yyerrorl(e.pos, "invalid //go:embed: multiple files for type %v", e.n.Type)
+			return nil
+		}
+	}
+	return list
+}
+
+// dumpembeds writes the data for the variables initialized
+// by //go:embed directives.
+func dumpembeds() {
+	for _, e := range embedlist {
+		initEmbed(e)
+	}
+}
+
+// initEmbed emits the init data for a //go:embed variable,
+// which is either a string, a []byte, or an embed.FS.
+func initEmbed(e *embedVar) {
+	v := e.n
+	kind := embedKind(v.Type)
+	if kind == embedUnknown {
+		yyerrorl(v.Pos, "//go:embed cannot apply to var of type %v", v.Type)
+		return
+	}
+
+	files := embedFileList(e, kind)
+	switch kind {
+	case embedString, embedBytes:
+		if len(files) == 0 {
+			return
+		}
+		file := files[0]
+		fsym, size, err := fileStringSym(v, embedCfg.Files[file], kind == embedString, nil)
+		if err != nil {
+			yyerrorl(v.Pos, "embed %s: %v", file, err)
+			return
+		}
+		sym := v.Sym.Linksym()
+		off := 0
+		off = dsymptr(sym, off, fsym, 0)       // data string
+		off = duintptr(sym, off, uint64(size)) // len
+		if kind == embedBytes {
+			duintptr(sym, off, uint64(size)) // cap for slice
+		}
+
+	case embedFiles:
+		slicedata := Ctxt.Lookup(`"".` + v.Sym.Name + `.files`)
+		off := 0
+		// []files pointed at by Files
+		off = dsymptr(slicedata, off, slicedata, 3*Widthptr) // []file, pointing just past slice
+		off = duintptr(slicedata, off, uint64(len(files)))
+		off = duintptr(slicedata, off, uint64(len(files)))
+
+		// embed/embed.go type file is:
+		//	name string
+		//	data string
+		//	hash [16]byte
+		// Emit one of these per file in the set.
+		const hashSize = 16
+		hash := make([]byte, hashSize)
+		for _, file := range files {
+			off = dsymptr(slicedata, off, stringsym(v.Pos, file), 0) // file string
+			off = duintptr(slicedata, off, uint64(len(file)))
+			if strings.HasSuffix(file, "/") {
+				// entry for directory - no data
+				off = duintptr(slicedata, off, 0)
+				off = duintptr(slicedata, off, 0)
+				off += hashSize
+			} else {
+				fsym, size, err := fileStringSym(v, embedCfg.Files[file], true, hash)
+				if err != nil {
+					yyerrorl(v.Pos, "embed %s: %v", file, err)
+					return
+				}
+				off = dsymptr(slicedata, off, fsym, 0) // data string
+				off = duintptr(slicedata, off, uint64(size))
+				off = int(slicedata.WriteBytes(Ctxt, int64(off), hash))
+			}
+		}
+		ggloblsym(slicedata, int32(off), obj.RODATA|obj.LOCAL)
+		sym := v.Sym.Linksym()
+		dsymptr(sym, 0, slicedata, 0)
+	}
+}
+
+// fileStringSym returns a symbol for the contents and the size of file.
+// If readonly is true, the symbol shares storage with any literal string
+// or other file with the same content and is placed in a read-only section.
+// If readonly is false, the symbol is a read-write copy separate from any other,
+// for use as the backing store of a []byte.
+// The content hash of file is copied into hash. (If hash is nil, nothing is copied.)
+// The returned symbol contains the data itself, not a string header.
+func fileStringSym(v *Node, file string, readonly bool, hash []byte) (*obj.LSym, int64, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, 0, err
+	}
+	if hash != nil {
+		sum := sha256.Sum256(data)
+		copy(hash, sum[:])
+	}
+	if readonly {
+		return stringsym(v.Pos, string(data)), int64(len(data)), nil
+	}
+
+	slicebytes_gen++
+	sym := localpkg.Lookup(fmt.Sprintf(".gobytes.%d", slicebytes_gen))
+	sym.Def = asTypesNode(newname(sym))
+	lsym := sym.Linksym()
+	off := dsname(lsym, 0, string(data), v.Pos, "slice")
+	ggloblsym(lsym, int32(off), obj.NOPTR|obj.LOCAL)
+	return lsym, int64(len(data)), nil
+}
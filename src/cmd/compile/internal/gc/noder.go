@@ -125,10 +125,13 @@ type noder struct {
 
 	file       *syntax.File
 	linknames  []linkname
+	embeds     []pragmaEmbed // //go:embed directives not yet attached to a var
 	pragcgobuf string
 	err        chan syntax.Error
 	scope      ScopeID
 
+	importedEmbed bool // file imports "embed"
+
 	// scopeVars is a stack tracking the number of variables declared in the
 	// current function at the moment each open scope was opened.
 	scopeVars []int
@@ -236,6 +239,10 @@ func (p *noder) node() {
 
 	xtop = append(xtop, p.decls(p.file.DeclList)...)
 
+	for _, e := range p.embeds {
+		p.yyerrorpos(e.pos, "misplaced //go:embed directive")
+	}
+
 	for _, n := range p.linknames {
 		if imported_unsafe {
 			lookup(n.local).Linkname = n.remote
@@ -254,12 +261,19 @@ func (p *noder) decls(decls []syntax.Decl) (l []*Node) {
 
 	for _, decl := range decls {
 		p.lineno(decl)
+		embeds := p.takeEmbeds(decl.Pos())
+		if _, ok := decl.(*syntax.VarDecl); !ok {
+			for _, e := range embeds {
+				p.yyerrorpos(e.pos, "misplaced //go:embed directive")
+			}
+		}
+
 		switch decl := decl.(type) {
 		case *syntax.ImportDecl:
 			p.importDecl(decl)
 
 		case *syntax.VarDecl:
-			l = append(l, p.varDecl(decl)...)
+			l = append(l, p.varDecl(decl, embeds)...)
 
 		case *syntax.ConstDecl:
 			l = append(l, p.constDecl(decl, &cs)...)
@@ -290,6 +304,9 @@ func (p *noder) importDecl(imp *syntax.ImportDecl) {
 	}
 
 	ipkg.Direct = true
+	if ipkg.Path == "embed" {
+		p.importedEmbed = true
+	}
 
 	var my *types.Sym
 	if imp.LocalPkgName != nil {
@@ -321,7 +338,7 @@ func (p *noder) importDecl(imp *syntax.ImportDecl) {
 	my.Block = 1 // at top level
 }
 
-func (p *noder) varDecl(decl *syntax.VarDecl) []*Node {
+func (p *noder) varDecl(decl *syntax.VarDecl, embeds []pragmaEmbed) []*Node {
 	names := p.declNames(decl.NameList)
 	typ := p.typeExprOrNil(decl.Type)
 
@@ -330,6 +347,10 @@ func (p *noder) varDecl(decl *syntax.VarDecl) []*Node {
 		exprs = p.exprList(decl.Values)
 	}
 
+	if len(embeds) > 0 {
+		p.varEmbed(names, exprs, embeds)
+	}
+
 	p.lineno(decl)
 	return variter(names, typ, exprs)
 }
@@ -1414,6 +1435,18 @@ func (p *noder) pragma(pos syntax.Pos, text string) syntax.Pragma {
 		}
 		p.linknames = append(p.linknames, linkname{pos, f[1], f[2]})
 
+	case text == "go:embed", strings.HasPrefix(text, "go:embed "), strings.HasPrefix(text, "go:embed\t"):
+		args, err := parseGoEmbed(text[len("go:embed"):])
+		if err != nil {
+			p.error(syntax.Error{Pos: pos, Msg: err.Error()})
+			break
+		}
+		if len(args) == 0 {
+			p.error(syntax.Error{Pos: pos, Msg: "usage: //go:embed pattern..."})
+			break
+		}
+		p.embeds = append(p.embeds, pragmaEmbed{pos, args})
+
 	case strings.HasPrefix(text, "go:cgo_import_dynamic "):
 		// This is permitted for general use because Solaris
 		// code relies on it in golang.org/x/sys/unix and others.
@@ -134,6 +134,7 @@ func dumpobj1(outfile string, mode int) {
 	externs := len(externdcl)
 
 	dumpglobls()
+	dumpembeds()
 	addptabs()
 	addsignats(externdcl)
 	dumpsignats()
@@ -31,6 +31,7 @@ type scanner struct {
 	source
 	mode   uint
 	nlsemi bool // if set '\n' and EOF translate to ';'
+	bol    bool // if set only white space has been seen on the current line
 
 	// current token, valid after calling next()
 	line, col uint
@@ -45,6 +46,7 @@ func (s *scanner) init(src io.Reader, errh func(line, col uint, msg string), mod
 	s.source.init(src, errh)
 	s.mode = mode
 	s.nlsemi = false
+	s.bol = true
 }
 
 // next advances the scanner by reading the next token.
@@ -65,7 +67,8 @@ func (s *scanner) init(src io.Reader, errh func(line, col uint, msg string), mod
 // flag, only comments containing a //line, /*line, or //go: directive
 // are reported, in the same way as regular comments. Directives in
 // //-style comments are only recognized if they are at the beginning
-// of a line.
+// of a line, except for //go:embed, which may also be preceded by
+// white space.
 //
 func (s *scanner) next() {
 	nlsemi := s.nlsemi
@@ -75,11 +78,16 @@ redo:
 	// skip white space
 	c := s.getr()
 	for c == ' ' || c == '\t' || c == '\n' && !nlsemi || c == '\r' {
+		if c == '\n' {
+			s.bol = true
+		}
 		c = s.getr()
 	}
 
 	// token start
 	s.line, s.col = s.source.line0, s.source.col0
+	bol := s.bol
+	s.bol = false
 
 	if isLetter(c) || c >= utf8.RuneSelf && s.isIdentRune(c, true) {
 		s.ident()
@@ -98,6 +106,7 @@ redo:
 	case '\n':
 		s.lit = "newline"
 		s.tok = _Semi
+		s.bol = true
 
 	case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
 		s.number(c)
@@ -196,7 +205,7 @@ redo:
 	case '/':
 		c = s.getr()
 		if c == '/' {
-			s.lineComment()
+			s.lineComment(bol)
 			goto redo
 		}
 		if c == '*' {
@@ -596,7 +605,7 @@ func (s *scanner) skipLine(r rune) {
 	}
 }
 
-func (s *scanner) lineComment() {
+func (s *scanner) lineComment(bol bool) {
 	r := s.getr()
 
 	if s.mode&comments != 0 {
@@ -606,15 +615,19 @@ func (s *scanner) lineComment() {
 		return
 	}
 
-	// directives must start at the beginning of the line (s.col == colbase)
-	if s.mode&directives == 0 || s.col != colbase || (r != 'g' && r != 'l') {
+	// directives must start at the beginning of the line (s.col == colbase);
+	// only //go:embed may be indented
+	indented := s.col != colbase
+	if s.mode&directives == 0 || indented && (!bol || r != 'g') || (r != 'g' && r != 'l') {
 		s.skipLine(r)
 		return
 	}
 
 	// recognize go: or line directives
 	prefix := "go:"
-	if r == 'l' {
+	if indented {
+		prefix = "go:embed"
+	} else if r == 'l' {
 		prefix = "line "
 	}
 	for _, m := range prefix {
@@ -323,6 +323,45 @@ func TestComments(t *testing.T) {
 	}
 }
 
+func TestDirectives(t *testing.T) {
+	for _, test := range []struct {
+		src  string
+		want string
+	}{
+		{"//go:noinline\n", "//go:noinline"},
+		{"\t//go:noinline\n", ""},
+		{"//line x.go:1\n", "//line x.go:1"},
+		{" //line x.go:1\n", ""},
+		{"//go:embed x\n", "//go:embed x"},
+		{"var (\n\t//go:embed x y\n\tv T\n)\n", "//go:embed x y"},
+		{"var v T //go:embed x\n", ""},
+		{"/* c */ //go:embed x\n", ""},
+		{"// //go:embed x\n", ""},
+	} {
+		var s scanner
+		var got string
+		s.init(strings.NewReader(test.src),
+			func(line, col uint, msg string) {
+				if msg[0] != '/' {
+					t.Errorf("%q: %s", test.src, msg)
+					return
+				}
+				got = msg
+			}, directives)
+
+		for {
+			s.next()
+			if s.tok == _EOF {
+				break
+			}
+		}
+
+		if got != test.want {
+			t.Errorf("%q: got directive %q; want %q", test.src, got, test.want)
+		}
+	}
+}
+
 func TestScanErrors(t *testing.T) {
 	for _, test := range []struct {
 		src, msg  string
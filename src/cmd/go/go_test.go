@@ -5943,3 +5943,118 @@ func TestFilepathUnderCwdFormat(t *testing.T) {
 	tg.run("test", "-x", "-cover", "log")
 	tg.grepStderrNot(`\.log\.cover\.go`, "-x output should contain correctly formatted filepath under cwd")
 }
+
+func TestGoTestFuzz(t *testing.T) {
+	tooSlow(t)
+	tg := testgo(t)
+	defer tg.cleanup()
+	tg.tempFile("src/fuzzme/fuzzme.go", `package fuzzme
+
+		func Check(b []byte) {
+			if len(b) >= 3 && b[0] == 'b' {
+				if b[1] == 'u' {
+					if b[2] == 'g' {
+						panic("found it")
+					}
+				}
+			}
+		}
+	`)
+	tg.tempFile("src/fuzzme/fuzzme_test.go", `package fuzzme
+
+		import "testing"
+
+		func FuzzCheck(f *testing.F) {
+			f.Add([]byte("seed"))
+			f.Fuzz(func(t *testing.T, b []byte) {
+				Check(b)
+			})
+		}
+	`)
+	tg.setenv("GOPATH", tg.path("."))
+	tg.cd(tg.path("src/fuzzme"))
+
+	// Without -fuzz, only the seed corpus is run.
+	tg.run("test", "-v")
+	tg.grepStdout(`--- PASS: FuzzCheck/seed#0`, "did not run seed corpus")
+
+	tg.runFail("test", "-fuzz=FuzzCheck", "-fuzztime=1000000x")
+	tg.grepStdout(`found it`, "fuzzing did not find the failing input")
+	tg.grepStdout(`Failing input written to testdata/fuzz/FuzzCheck/`, "did not report failing input")
+	dir := tg.path("src/fuzzme/testdata/fuzz/FuzzCheck")
+	files, err := ioutil.ReadDir(dir)
+	tg.must(err)
+	if len(files) != 1 {
+		t.Fatalf("found %d files in %s, want 1", len(files), dir)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, files[0].Name()))
+	tg.must(err)
+	if want := "go test fuzz v1\n[]byte(\"bug\")\n"; string(data) != want {
+		t.Errorf("failing input not minimized: got %q, want %q", data, want)
+	}
+
+	// The failing input is now part of the seed corpus.
+	tg.runFail("test", "-run=FuzzCheck/"+files[0].Name())
+	tg.grepBoth(`found it`, "failing input not replayed")
+
+	tg.runFail("test", "-fuzz=.", "fuzzme", "errors")
+	tg.grepStderr("cannot use -fuzz flag with multiple packages", "did not reject multiple packages")
+}
+
+func TestEmbed(t *testing.T) {
+	tg := testgo(t)
+	defer tg.cleanup()
+	tg.parallel()
+	tg.makeTempdir()
+	tg.setenv("GOPATH", tg.path("."))
+	tg.tempFile("src/m/m.go", `package main
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+)
+
+//go:embed x.txt
+var x string
+
+//go:embed dir
+var dir embed.FS
+
+func main() {
+	names, _ := fs.Glob(dir, "dir/*")
+	fmt.Printf("x=%q %v\n", x, names)
+}
+`)
+	tg.tempFile("src/m/x.txt", "hello")
+	tg.tempFile("src/m/dir/a.txt", "a")
+	tg.tempFile("src/m/dir/.hidden", "hidden")
+	tg.tempFile("src/m/dir/_ignored", "ignored")
+
+	tg.run("list", "-f", "{{.EmbedPatterns}} {{.EmbedFiles}}", "m")
+	tg.grepStdout(`^\[dir x.txt\] \[dir/a.txt x.txt\]$`, "go list reported wrong embed patterns or files")
+
+	tg.run("run", tg.path("src/m/m.go"))
+	tg.grepStdout(`^x="hello" \[dir/a.txt\]$`, "embedded data not found")
+
+	// Changing an embedded file must cause a rebuild.
+	tg.run("install", "m")
+	tg.wantNotStale("m", "", "m stale after install")
+	tg.sleep()
+	tg.tempFile("src/m/x.txt", "changed")
+	tg.wantStale("m", "build ID mismatch", "m not stale after changing embedded file")
+	tg.run("run", tg.path("src/m/m.go"))
+	tg.grepStdout(`^x="changed" \[dir/a.txt\]$`, "changed embedded data not found")
+
+	// A pattern matching nothing is an error reported at the directive.
+	tg.tempFile("src/bad/bad.go", "package bad\n\nimport _ \"embed\"\n\n//go:embed missing.txt\nvar s string\n")
+	tg.runFail("build", "bad")
+	tg.grepStderr(`bad.go:5:12: pattern missing.txt: no matching files found`, "missing embed pattern not reported")
+
+	// Files in a nested module cannot be embedded.
+	tg.tempFile("src/nested/nested.go", "package nested\n\nimport _ \"embed\"\n\n//go:embed sub/x.txt\nvar s string\n")
+	tg.tempFile("src/nested/sub/go.mod", "module sub\n")
+	tg.tempFile("src/nested/sub/x.txt", "x")
+	tg.runFail("build", "nested")
+	tg.grepStderr(`cannot embed file sub/x.txt: in different module`, "nested module not reported")
+}
@@ -40,6 +40,8 @@ var (
 	CmdName string // "build", "install", "list", etc.
 
 	DebugActiongraph string // -debug-actiongraph flag (undocumented, unstable)
+
+	ModulesEnabled bool // whether the go command is in module-aware mode
 )
 
 func init() {
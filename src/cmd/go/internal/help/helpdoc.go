@@ -466,6 +466,8 @@ General-purpose environment variables:
 		Examples are linux, darwin, windows, netbsd.
 	GOPATH
 		For more details see: 'go help gopath'.
+	GOPROXY
+		URL of Go module proxy. See 'go help modules'.
 	GORACE
 		Options for the race detector.
 		See https://golang.org/doc/articles/race_detector.html.
@@ -477,6 +479,10 @@ General-purpose environment variables:
 	GOCACHE
 		The directory where the go command will store
 		cached information for reuse in future builds.
+	GO111MODULE
+		Controls whether the go command runs in module-aware mode.
+		Either off, on, or auto (the default).
+		See 'go help modules'.
 
 Environment variables for use with cgo:
 
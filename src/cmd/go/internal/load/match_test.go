@@ -65,8 +65,8 @@ var matchPatternTests = `
 `
 
 func TestMatchPattern(t *testing.T) {
-	testPatterns(t, "matchPattern", matchPatternTests, func(pattern, name string) bool {
-		return matchPattern(pattern)(name)
+	testPatterns(t, "MatchPattern", matchPatternTests, func(pattern, name string) bool {
+		return MatchPattern(pattern)(name)
 	})
 }
 
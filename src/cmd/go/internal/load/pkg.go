@@ -6,9 +6,11 @@
 package load
 
 import (
+	"errors"
 	"fmt"
 	"go/build"
 	"go/token"
+	"io/fs"
 	"io/ioutil"
 	"os"
 	pathpkg "path"
@@ -20,9 +22,21 @@ import (
 
 	"cmd/go/internal/base"
 	"cmd/go/internal/cfg"
+	"cmd/go/internal/modinfo"
 	"cmd/go/internal/str"
 )
 
+var (
+	// module initialization hook; never nil, no-op if module use is disabled
+	ModInit = func() {}
+
+	// module hooks; nil if module use is disabled
+	ModBinDir            func() string                                       // return effective bin directory
+	ModLookup            func(path string) (dir, realPath string, err error) // lookup effective meaning of import
+	ModPackageModuleInfo func(path string) *modinfo.ModulePublic             // return module info for Package struct
+	ModImportPaths       func(args []string) []string                        // expand import paths
+)
+
 var IgnoreImports bool // control whether we ignore imports in packages
 
 // A Package describes a single package found in a directory.
@@ -48,6 +62,8 @@ type PackagePublic struct {
 	ConflictDir   string `json:",omitempty"` // Dir is hidden by this other directory
 	BinaryOnly    bool   `json:",omitempty"` // package cannot be recompiled
 
+	Module *modinfo.ModulePublic `json:",omitempty"` // info about package's containing module, if any
+
 	// Stale and StaleReason remain here *only* for the list command.
 	// They are only initialized in preparation for list execution.
 	// The regular build determines staleness on the fly during action execution.
@@ -70,6 +86,10 @@ type PackagePublic struct {
 	SwigCXXFiles   []string `json:",omitempty"` // .swigcxx files
 	SysoFiles      []string `json:",omitempty"` // .syso system object files added to package
 
+	// Embedded files
+	EmbedPatterns []string `json:",omitempty"` // //go:embed patterns
+	EmbedFiles    []string `json:",omitempty"` // files matched by EmbedPatterns
+
 	// Cgo directives
 	CgoCFLAGS    []string `json:",omitempty"` // cgo: flags for C compiler
 	CgoCPPFLAGS  []string `json:",omitempty"` // cgo: flags for C preprocessor
@@ -94,6 +114,11 @@ type PackagePublic struct {
 	TestImports  []string `json:",omitempty"` // imports from TestGoFiles
 	XTestGoFiles []string `json:",omitempty"` // _test.go files outside package
 	XTestImports []string `json:",omitempty"` // imports from XTestGoFiles
+
+	TestEmbedPatterns  []string `json:",omitempty"` // //go:embed patterns in TestGoFiles
+	TestEmbedFiles     []string `json:",omitempty"` // files matched by TestEmbedPatterns
+	XTestEmbedPatterns []string `json:",omitempty"` // //go:embed patterns in XTestGoFiles
+	XTestEmbedFiles    []string `json:",omitempty"` // files matched by XTestEmbedPatterns
 }
 
 // AllFiles returns the names of all the files considered for the package.
@@ -135,6 +160,9 @@ type PackageInternal struct {
 	CoverVars    map[string]*CoverVar // variables created by coverage analysis
 	OmitDebug    bool                 // tell linker not to write debug information
 	GobinSubdir  bool                 // install target would be subdir of GOBIN
+	Embed        map[string][]string  // //go:embed comment mapping
+	TestEmbed    map[string][]string  // //go:embed comment mapping for TestGoFiles
+	XTestEmbed   map[string][]string  // //go:embed comment mapping for XTestGoFiles
 
 	Asmflags   []string // -asmflags for this package
 	Gcflags    []string // -gcflags for this package
@@ -249,6 +277,9 @@ func (p *Package) copyBuild(pp *build.Package) {
 	p.TestImports = pp.TestImports
 	p.XTestGoFiles = pp.XTestGoFiles
 	p.XTestImports = pp.XTestImports
+	p.EmbedPatterns = pp.EmbedPatterns
+	p.TestEmbedPatterns = pp.TestEmbedPatterns
+	p.XTestEmbedPatterns = pp.XTestEmbedPatterns
 	if IgnoreImports {
 		p.Imports = nil
 		p.TestImports = nil
@@ -412,9 +443,22 @@ func LoadImport(path, srcDir string, parent *Package, stk *ImportStack, importPo
 	importPath := path
 	origPath := path
 	isLocal := build.IsLocalImport(path)
-	var debugDeprecatedImportcfgDir string
+	var (
+		debugDeprecatedImportcfgDir string
+		modDir                      string
+		modErr                      error
+	)
 	if isLocal {
 		importPath = dirToImportPath(filepath.Join(srcDir, path))
+	} else if cfg.ModulesEnabled && (parent == nil || !parent.Standard) {
+		// In module mode, imports from outside the standard library
+		// are resolved using the module build list, not vendor
+		// directories or $GOPATH/src.
+		var realPath string
+		modDir, realPath, modErr = ModLookup(path)
+		if modErr == nil {
+			importPath = realPath
+		}
 	} else if DebugDeprecatedImportcfg.enabled {
 		if d, i := DebugDeprecatedImportcfg.lookup(parent, path); d != "" {
 			debugDeprecatedImportcfgDir = d
@@ -445,6 +489,11 @@ func LoadImport(path, srcDir string, parent *Package, stk *ImportStack, importPo
 		var err error
 		if debugDeprecatedImportcfgDir != "" {
 			bp, err = cfg.BuildContext.ImportDir(debugDeprecatedImportcfgDir, 0)
+		} else if modErr != nil {
+			bp = new(build.Package)
+			err = modErr
+		} else if modDir != "" {
+			bp, err = cfg.BuildContext.ImportDir(modDir, build.IgnoreVendor)
 		} else if DebugDeprecatedImportcfg.enabled {
 			bp = new(build.Package)
 			err = fmt.Errorf("unknown import path %q: not in import cfg", importPath)
@@ -459,11 +508,16 @@ func LoadImport(path, srcDir string, parent *Package, stk *ImportStack, importPo
 		bp.ImportPath = importPath
 		if cfg.GOBIN != "" {
 			bp.BinDir = cfg.GOBIN
+		} else if cfg.ModulesEnabled && !bp.Goroot {
+			bp.BinDir = ModBinDir()
 		}
-		if debugDeprecatedImportcfgDir == "" && err == nil && !isLocal && bp.ImportComment != "" && bp.ImportComment != path &&
+		if modDir == "" && debugDeprecatedImportcfgDir == "" && err == nil && !isLocal && bp.ImportComment != "" && bp.ImportComment != path &&
 			!strings.Contains(path, "/vendor/") && !strings.HasPrefix(path, "vendor/") {
 			err = fmt.Errorf("code in directory %s expects import %q", bp.Dir, bp.ImportComment)
 		}
+		if modDir != "" {
+			p.Module = ModPackageModuleInfo(importPath)
+		}
 		p.load(stk, bp, err)
 		if p.Error != nil && p.Error.Pos == "" {
 			p = setErrorPos(p, importPos)
@@ -546,6 +600,10 @@ func isDir(path string) bool {
 // x/vendor/path, vendor/path, or else stay path if none of those exist.
 // VendoredImportPath returns the expanded path or, if no expansion is found, the original.
 func VendoredImportPath(parent *Package, path string) (found string) {
+	if cfg.ModulesEnabled && (parent == nil || !parent.Standard) {
+		// Vendor directories are ignored in module mode.
+		return path
+	}
 	if DebugDeprecatedImportcfg.enabled {
 		if d, i := DebugDeprecatedImportcfg.lookup(parent, path); d != "" {
 			return i
@@ -708,6 +766,22 @@ func disallowInternal(srcDir string, p *Package, stk *ImportStack) *Package {
 	if i > 0 {
 		i-- // rewind over slash in ".../internal"
 	}
+
+	if p.Module != nil {
+		// p is in a module, so its directory need not mirror its import path.
+		// Make it available based on the importer's import path instead.
+		if str.HasPathPrefix((*stk)[len(*stk)-2], p.ImportPath[:i]) {
+			return p
+		}
+		perr := *p
+		perr.Error = &PackageError{
+			ImportStack: stk.Copy(),
+			Err:         "use of internal package not allowed",
+		}
+		perr.Incomplete = true
+		return &perr
+	}
+
 	parent := p.Dir[:i+len(p.Dir)-len(p.ImportPath)]
 	if str.HasFilePathPrefix(filepath.Clean(srcDir), filepath.Clean(parent)) {
 		return p
@@ -947,6 +1021,11 @@ func (p *Package) load(stk *ImportStack, bp *build.Package, err error) {
 			return
 		}
 		_, elem := filepath.Split(p.Dir)
+		if p.Module != nil {
+			// Module directories in the module cache carry an @version suffix;
+			// name the binary after the import path instead.
+			elem = pathpkg.Base(p.ImportPath)
+		}
 		full := cfg.BuildContext.GOOS + "_" + cfg.BuildContext.GOARCH + "/" + elem
 		if cfg.BuildContext.GOOS != base.ToolGOOS || cfg.BuildContext.GOARCH != base.ToolGOARCH {
 			// Install cross-compiled binaries to subdirectories of bin.
@@ -1075,6 +1154,29 @@ func (p *Package) load(stk *ImportStack, bp *build.Package, err error) {
 		return
 	}
 
+	// Resolve the //go:embed patterns to the files they match.
+	embedErr := func(err error, posMap map[string][]token.Position) {
+		p.Error = &PackageError{
+			ImportStack: stk.Copy(),
+			Err:         err.Error(),
+		}
+		if e, ok := err.(*EmbedError); ok {
+			setErrorPos(p, posMap[e.Pattern])
+		}
+	}
+	if p.EmbedFiles, p.Internal.Embed, err = resolveEmbed(p.Dir, p.EmbedPatterns); err != nil {
+		embedErr(err, bp.EmbedPatternPos)
+		return
+	}
+	if p.TestEmbedFiles, p.Internal.TestEmbed, err = resolveEmbed(p.Dir, p.TestEmbedPatterns); err != nil {
+		embedErr(err, bp.TestEmbedPatternPos)
+		return
+	}
+	if p.XTestEmbedFiles, p.Internal.XTestEmbed, err = resolveEmbed(p.Dir, p.XTestEmbedPatterns); err != nil {
+		embedErr(err, bp.XTestEmbedPatternPos)
+		return
+	}
+
 	// Build list of imported packages and full dependency list.
 	imports := make([]*Package, 0, len(p.Imports))
 	for i, path := range importPaths {
@@ -1198,6 +1300,163 @@ func (p *Package) load(stk *ImportStack, bp *build.Package, err error) {
 	}
 }
 
+// An EmbedError indicates a problem with a //go:embed pattern.
+type EmbedError struct {
+	Pattern string
+	Err     error
+}
+
+func (e *EmbedError) Error() string {
+	return fmt.Sprintf("pattern %s: %v", e.Pattern, e.Err)
+}
+
+// resolveEmbed resolves //go:embed patterns to precise file lists.
+// It sets files to the list of unique files matched (for go list),
+// and it sets pmap to the more precise mapping from
+// patterns to files.
+func resolveEmbed(pkgdir string, patterns []string) (files []string, pmap map[string][]string, err error) {
+	if len(patterns) == 0 {
+		return nil, nil, nil
+	}
+	pid := 0
+	have := make(map[string]int)
+	dirOK := make(map[string]bool)
+	pmap = make(map[string][]string)
+	for _, pattern := range patterns {
+		pid++
+
+		// Check pattern is valid for //go:embed.
+		if _, err := pathpkg.Match(pattern, ""); err != nil || !validEmbedPattern(pattern) {
+			return nil, nil, &EmbedError{Pattern: pattern, Err: errors.New("invalid pattern syntax")}
+		}
+
+		// Glob to find matches.
+		match, err := filepath.Glob(filepath.Join(pkgdir, filepath.FromSlash(pattern)))
+		if err != nil {
+			return nil, nil, &EmbedError{Pattern: pattern, Err: err}
+		}
+
+		// Filter list of matches down to the ones that will still exist when
+		// the directory is packaged up as a module. (If p.Dir is in the module cache,
+		// only those files exist already, but if p.Dir is in the current module,
+		// then there may be other things lying around, like symbolic links or .git directories.)
+		var list []string
+		for _, file := range match {
+			rel := filepath.ToSlash(file[len(pkgdir)+1:]) // file, relative to p.Dir
+
+			what := "file"
+			info, err := os.Lstat(file)
+			if err != nil {
+				return nil, nil, &EmbedError{Pattern: pattern, Err: err}
+			}
+			if info.IsDir() {
+				what = "directory"
+			}
+
+			// Check that directories along path do not begin a new module
+			// (do not contain a go.mod).
+			for dir := file; len(dir) > len(pkgdir)+1 && !dirOK[dir]; dir = filepath.Dir(dir) {
+				if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+					return nil, nil, &EmbedError{Pattern: pattern, Err: fmt.Errorf("cannot embed %s %s: in different module", what, rel)}
+				}
+				if dir != file {
+					if info, err := os.Lstat(dir); err == nil && !info.IsDir() {
+						return nil, nil, &EmbedError{Pattern: pattern, Err: fmt.Errorf("cannot embed %s %s: in non-directory %s", what, rel, dir[len(pkgdir)+1:])}
+					}
+				}
+				dirOK[dir] = true
+			}
+
+			switch {
+			default:
+				return nil, nil, &EmbedError{Pattern: pattern, Err: fmt.Errorf("cannot embed irregular file %s", rel)}
+
+			case info.Mode().IsRegular():
+				if have[rel] != pid {
+					have[rel] = pid
+					list = append(list, rel)
+				}
+
+			case info.IsDir():
+				// Gather all files in the named directory, stopping at module boundaries
+				// and ignoring files that wouldn't be packaged into a module.
+				err := filepath.Walk(file, func(path string, info os.FileInfo, err error) error {
+					if err != nil {
+						return err
+					}
+					rel := filepath.ToSlash(path[len(pkgdir)+1:])
+					name := info.Name()
+					if path != file && (isBadEmbedName(name) || name[0] == '.' || name[0] == '_') {
+						// Ignore bad names, assuming they won't go into modules.
+						// Also avoid hidden files that user may not know about.
+						if info.IsDir() {
+							return filepath.SkipDir
+						}
+						return nil
+					}
+					if info.IsDir() {
+						if _, err := os.Stat(filepath.Join(path, "go.mod")); err == nil {
+							return filepath.SkipDir
+						}
+						return nil
+					}
+					if !info.Mode().IsRegular() {
+						return nil
+					}
+					if have[rel] != pid {
+						have[rel] = pid
+						list = append(list, rel)
+					}
+					return nil
+				})
+				if err != nil {
+					return nil, nil, &EmbedError{Pattern: pattern, Err: err}
+				}
+			}
+		}
+
+		if len(list) == 0 {
+			return nil, nil, &EmbedError{Pattern: pattern, Err: errors.New("no matching files found")}
+		}
+		sort.Strings(list)
+		pmap[pattern] = list
+	}
+
+	for file := range have {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	return files, pmap, nil
+}
+
+// validEmbedPattern reports whether pattern is a valid //go:embed pattern:
+// an unrooted, slash-separated path with no empty, "." or ".." elements.
+func validEmbedPattern(pattern string) bool {
+	return pattern != "." && fs.ValidPath(pattern)
+}
+
+// isBadEmbedName reports whether name is the base name of a file that
+// can't or won't be included in modules and therefore shouldn't be treated
+// as existing for embedding.
+func isBadEmbedName(name string) bool {
+	for _, r := range name {
+		// Control characters and characters that are special to
+		// some file systems cannot appear in module file names.
+		if r < ' ' || r == 0x7F || strings.ContainsRune("\"*:<>?\\`|", r) {
+			return true
+		}
+	}
+	switch name {
+	// Empty string should be impossible but make it bad.
+	case "":
+		return true
+	// Version control directories won't be present in module.
+	case ".bzr", ".hg", ".git", ".svn":
+		return true
+	}
+	return false
+}
+
 // SafeArg reports whether arg is a "safe" command-line argument,
 // meaning that when it appears in a command-line, it probably
 // doesn't have some special meaning other than its own name.
@@ -1436,6 +1695,7 @@ func Packages(args []string) []*Package {
 // cannot be loaded at all.
 // The packages that fail to load will have p.Error != nil.
 func PackagesAndErrors(args []string) []*Package {
+	ModInit()
 	if len(args) > 0 && strings.HasSuffix(args[0], ".go") {
 		return []*Package{GoFilesPackage(args)}
 	}
@@ -1671,6 +1931,17 @@ func TestPackagesFor(p *Package, forceTest bool) (ptest, pxtest *Package, err er
 			m[k] = append(m[k], v...)
 		}
 		ptest.Internal.Build.ImportPos = m
+		if len(p.Internal.TestEmbed) > 0 {
+			ptest.Internal.Embed = make(map[string][]string)
+			for k, v := range p.Internal.Embed {
+				ptest.Internal.Embed[k] = v
+			}
+			for k, v := range p.Internal.TestEmbed {
+				ptest.Internal.Embed[k] = v
+			}
+			ptest.EmbedPatterns = str.StringList(p.EmbedPatterns, p.TestEmbedPatterns)
+			ptest.EmbedFiles = str.StringList(p.EmbedFiles, p.TestEmbedFiles)
+		}
 	} else {
 		ptest = p
 	}
@@ -1685,6 +1956,9 @@ func TestPackagesFor(p *Package, forceTest bool) (ptest, pxtest *Package, err er
 				Dir:        p.Dir,
 				GoFiles:    p.XTestGoFiles,
 				Imports:    p.XTestImports,
+
+				EmbedPatterns: p.XTestEmbedPatterns,
+				EmbedFiles:    p.XTestEmbedFiles,
 			},
 			Internal: PackageInternal{
 				LocalPrefix: p.Internal.LocalPrefix,
@@ -1693,6 +1967,7 @@ func TestPackagesFor(p *Package, forceTest bool) (ptest, pxtest *Package, err er
 				},
 				Imports:    ximports,
 				RawImports: rawXTestImports,
+				Embed:      p.Internal.XTestEmbed,
 
 				Asmflags:   p.Internal.Asmflags,
 				Gcflags:    p.Internal.Gcflags,
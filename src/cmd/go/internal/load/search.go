@@ -45,7 +45,7 @@ func MatchPackages(pattern string) []string {
 	match := func(string) bool { return true }
 	treeCanMatch := func(string) bool { return true }
 	if !IsMetaPackage(pattern) {
-		match = matchPattern(pattern)
+		match = MatchPattern(pattern)
 		treeCanMatch = treeCanMatchPattern(pattern)
 	}
 
@@ -148,7 +148,7 @@ func MatchPackagesInFS(pattern string) []string {
 	if strings.HasPrefix(pattern, "./") {
 		prefix = "./"
 	}
-	match := matchPattern(pattern)
+	match := MatchPattern(pattern)
 
 	var pkgs []string
 	filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
@@ -199,7 +199,7 @@ func MatchPackagesInFS(pattern string) []string {
 
 // treeCanMatchPattern(pattern)(name) reports whether
 // name or children of name can possibly match pattern.
-// Pattern is the same limited glob accepted by matchPattern.
+// Pattern is the same limited glob accepted by MatchPattern.
 func treeCanMatchPattern(pattern string) func(name string) bool {
 	wildCard := false
 	if i := strings.Index(pattern, "..."); i >= 0 {
@@ -212,7 +212,7 @@ func treeCanMatchPattern(pattern string) func(name string) bool {
 	}
 }
 
-// matchPattern(pattern)(name) reports whether
+// MatchPattern(pattern)(name) reports whether
 // name matches pattern. Pattern is a limited glob
 // pattern in which '...' means 'any string' and there
 // is no other special syntax.
@@ -227,7 +227,7 @@ func treeCanMatchPattern(pattern string) func(name string) bool {
 // Note, however, that a directory named vendor that itself contains code
 // is not a vendored package: cmd/vendor would be a command named vendor,
 // and the pattern cmd/... matches it.
-func matchPattern(pattern string) func(name string) bool {
+func MatchPattern(pattern string) func(name string) bool {
 	// Convert pattern to regular expression.
 	// The strategy for the trailing /... is to nest it in an explicit ? expression.
 	// The strategy for the vendor exclusion is to change the unmatchable
@@ -284,7 +284,7 @@ func MatchPackage(pattern, cwd string) func(*Package) bool {
 		if pattern == "" {
 			return func(p *Package) bool { return p.Dir == dir }
 		}
-		matchPath := matchPattern(pattern)
+		matchPath := MatchPattern(pattern)
 		return func(p *Package) bool {
 			// Compute relative path to dir and see if it matches the pattern.
 			rel, err := filepath.Rel(dir, p.Dir)
@@ -305,7 +305,7 @@ func MatchPackage(pattern, cwd string) func(*Package) bool {
 	case pattern == "cmd":
 		return func(p *Package) bool { return p.Standard && strings.HasPrefix(p.ImportPath, "cmd/") }
 	default:
-		matchPath := matchPattern(pattern)
+		matchPath := MatchPattern(pattern)
 		return func(p *Package) bool { return matchPath(p.ImportPath) }
 	}
 }
@@ -327,6 +327,12 @@ func replaceVendor(x, repl string) string {
 
 // ImportPaths returns the import paths to use for the given command line.
 func ImportPaths(args []string) []string {
+	if ModInit(); cfg.ModulesEnabled {
+		if cmdlineMatchers == nil {
+			SetCmdlinePatterns(args)
+		}
+		return ModImportPaths(args)
+	}
 	args = ImportPathsNoDotExpansion(args)
 	var out []string
 	for _, a := range args {
@@ -0,0 +1,92 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// go mod download
+
+package modcmd
+
+import (
+	"encoding/json"
+	"os"
+
+	"cmd/go/internal/base"
+	"cmd/go/internal/modfetch"
+	"cmd/go/internal/modload"
+	"cmd/go/internal/module"
+)
+
+var cmdDownload = &base.Command{
+	UsageLine: "download [-json] [modules]",
+	Short:     "download modules to local cache",
+}
+
+var downloadJSON = cmdDownload.Flag.Bool("json", false, "")
+
+func init() {
+	cmdDownload.Run = runDownload // break init cycle
+}
+
+type moduleJSON struct {
+	Path    string `json:",omitempty"`
+	Version string `json:",omitempty"`
+	Error   string `json:",omitempty"`
+	Info    string `json:",omitempty"`
+	GoMod   string `json:",omitempty"`
+	Zip     string `json:",omitempty"`
+	Dir     string `json:",omitempty"`
+	Sum     string `json:",omitempty"`
+}
+
+func runDownload(cmd *base.Command, args []string) {
+	if len(args) == 0 {
+		args = []string{"all"}
+	}
+
+	var mods []*moduleJSON
+	for _, info := range modload.ListModules(args) {
+		if info.Replace != nil {
+			info = info.Replace
+		}
+		if info.Version == "" && info.Error == nil {
+			// The main module or a local directory replacement:
+			// nothing to download.
+			continue
+		}
+		m := &moduleJSON{
+			Path:    info.Path,
+			Version: info.Version,
+		}
+		mods = append(mods, m)
+		if info.Error != nil {
+			m.Error = info.Error.Err
+			continue
+		}
+		mod := module.Version{Path: m.Path, Version: m.Version}
+		var err error
+		if m.Dir, err = modfetch.Download(mod); err != nil {
+			m.Error = err.Error()
+			continue
+		}
+		m.Info, _ = modfetch.CacheFile(mod, ".info")
+		m.GoMod, _ = modfetch.CacheFile(mod, ".mod")
+		m.Zip, _ = modfetch.CacheFile(mod, ".zip")
+		m.Sum = modfetch.Sum(mod)
+	}
+
+	if *downloadJSON {
+		for _, m := range mods {
+			b, err := json.MarshalIndent(m, "", "\t")
+			if err != nil {
+				base.Fatalf("%v", err)
+			}
+			os.Stdout.Write(append(b, '\n'))
+		}
+	} else {
+		for _, m := range mods {
+			if m.Error != "" {
+				base.Errorf("%s@%s: %s", m.Path, m.Version, m.Error)
+			}
+		}
+	}
+}
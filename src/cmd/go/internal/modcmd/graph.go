@@ -0,0 +1,70 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// go mod graph
+
+package modcmd
+
+import (
+	"bufio"
+	"os"
+	"sort"
+
+	"cmd/go/internal/base"
+	"cmd/go/internal/modload"
+	"cmd/go/internal/module"
+)
+
+var cmdGraph = &base.Command{
+	UsageLine: "graph",
+	Short:     "print module requirement graph",
+}
+
+func init() {
+	cmdGraph.Run = runGraph // break init cycle
+}
+
+func runGraph(cmd *base.Command, args []string) {
+	if len(args) > 0 {
+		usage(cmd)
+	}
+	modload.LoadBuildList()
+	reqs := modload.Reqs()
+
+	format := func(m module.Version) string {
+		if m.Version == "" {
+			return m.Path
+		}
+		return m.Path + "@" + m.Version
+	}
+
+	// Walk the full requirement graph from the main module,
+	// including versions not selected in the build list.
+	var out []string
+	seen := map[module.Version]bool{modload.Target: true}
+	queue := []module.Version{modload.Target}
+	for len(queue) > 0 {
+		m := queue[0]
+		queue = queue[1:]
+		list, err := reqs.Required(m)
+		if err != nil {
+			base.Errorf("go: %v", err)
+			continue
+		}
+		for _, r := range list {
+			out = append(out, format(m)+" "+format(r)+"\n")
+			if !seen[r] {
+				seen[r] = true
+				queue = append(queue, r)
+			}
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i] < out[j] })
+
+	w := bufio.NewWriter(os.Stdout)
+	for _, line := range out {
+		w.WriteString(line)
+	}
+	w.Flush()
+}
@@ -0,0 +1,36 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// go mod init
+
+package modcmd
+
+import (
+	"cmd/go/internal/base"
+	"cmd/go/internal/modload"
+)
+
+var cmdInit = &base.Command{
+	UsageLine: "init [module]",
+	Short:     "initialize new module in current directory",
+}
+
+func init() {
+	cmdInit.Run = runInit // break init cycle
+}
+
+func runInit(cmd *base.Command, args []string) {
+	if len(args) > 1 {
+		usage(cmd)
+	}
+	modload.CmdModInit = true
+	if len(args) == 1 {
+		modload.CmdModModule = args[0]
+	}
+	if modload.Init(); !modload.Enabled() {
+		base.Fatalf("go mod init: modules disabled by GO111MODULE=off; see 'go help modules'")
+	}
+	modload.InitMod() // does all the hard work
+	modload.WriteGoMod()
+}
@@ -0,0 +1,138 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package modcmd implements the ``go mod'' command.
+package modcmd
+
+import (
+	"fmt"
+	"os"
+
+	"cmd/go/internal/base"
+	"cmd/go/internal/modload"
+)
+
+var CmdMod = &base.Command{
+	UsageLine: "mod <command> [arguments]",
+	Short:     "module maintenance",
+	Long: `
+Go mod provides access to operations on modules.
+
+Note that support for modules is built into all the go commands,
+not just 'go mod'. For example, day-to-day adding, removing, upgrading,
+and downgrading of dependencies should be done using 'go get'.
+See 'go help modules' for an overview of module functionality.
+
+Usage:
+
+	go mod <command> [arguments]
+
+The commands are:
+
+	download    download modules to local cache
+	graph       print module requirement graph
+	init        initialize new module in current directory
+	verify      verify dependencies have expected content
+
+Download
+
+Usage: go mod download [-json] [modules]
+
+Download downloads the named modules, which can be module patterns selecting
+dependencies of the main module or module queries of the form path@version.
+With no arguments, download applies to all dependencies of the main module.
+
+The go command will automatically download modules as needed during ordinary
+execution. The "go mod download" command is useful mainly for pre-filling
+the local cache or to compute the answers for a Go module proxy.
+
+By default, download reports errors to standard error but is otherwise silent.
+The -json flag causes download to print a sequence of JSON objects
+to standard output, describing each downloaded module (or failure),
+corresponding to this Go struct:
+
+    type Module struct {
+        Path     string // module path
+        Version  string // module version
+        Error    string // error loading module
+        Info     string // absolute path to cached .info file
+        GoMod    string // absolute path to cached .mod file
+        Zip      string // absolute path to cached .zip file
+        Dir      string // absolute path to cached source root directory
+        Sum      string // checksum for path, version (as in go.sum)
+    }
+
+Graph
+
+Usage: go mod graph
+
+Graph prints the module requirement graph (with replacements applied)
+in text form. Each line in the output has two space-separated fields: a module
+and one of its requirements. Each module is identified as a string of the form
+path@version, except for the main module, which has no @version suffix.
+
+Init
+
+Usage: go mod init [module]
+
+Init initializes and writes a new go.mod to the current directory,
+in effect creating a new module rooted at the current directory.
+The file go.mod must not already exist.
+If possible, init will guess the module path from the directory's
+location in GOPATH, but that guess may fail, in which case the module
+path must be given as the argument.
+
+Verify
+
+Usage: go mod verify
+
+Verify checks that the dependencies of the current module,
+which are stored in a local downloaded source cache, have not been
+modified since being downloaded. If all the modules are unmodified,
+verify prints "all modules verified." Otherwise it reports which
+modules have been changed and causes 'go mod' to exit with a
+non-zero status.
+	`,
+	CustomFlags: true,
+}
+
+var commands = []*base.Command{
+	cmdDownload,
+	cmdGraph,
+	cmdInit,
+	cmdVerify,
+}
+
+func init() {
+	CmdMod.Run = runMod // break init loop
+}
+
+func runMod(cmd *base.Command, args []string) {
+	if len(args) == 0 {
+		cmd.Usage()
+	}
+	for _, sub := range commands {
+		if sub.Name() != args[0] {
+			continue
+		}
+		sub.Flag.Usage = func() { usage(sub) }
+		sub.Flag.Parse(args[1:])
+		if sub.Name() != "init" {
+			if modload.Init(); !modload.Enabled() {
+				base.Fatalf("go mod %s: modules disabled; see 'go help modules'", sub.Name())
+			}
+		}
+		sub.Run(sub, sub.Flag.Args())
+		return
+	}
+	fmt.Fprintf(os.Stderr, "go mod %s: unknown command\nRun 'go help mod' for usage.\n", args[0])
+	base.SetExitStatus(2)
+}
+
+// usage prints the usage message for the go mod subcommand sub and exits.
+func usage(sub *base.Command) {
+	fmt.Fprintf(os.Stderr, "usage: go mod %s\n", sub.UsageLine)
+	fmt.Fprintf(os.Stderr, "Run 'go help mod' for details.\n")
+	os.Exit(2)
+}
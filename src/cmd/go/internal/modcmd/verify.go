@@ -0,0 +1,90 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// go mod verify
+
+package modcmd
+
+import (
+	"fmt"
+	"os"
+
+	"cmd/go/internal/base"
+	"cmd/go/internal/dirhash"
+	"cmd/go/internal/modfetch"
+	"cmd/go/internal/modload"
+	"cmd/go/internal/module"
+)
+
+var cmdVerify = &base.Command{
+	UsageLine: "verify",
+	Short:     "verify dependencies have expected content",
+}
+
+func init() {
+	cmdVerify.Run = runVerify // break init cycle
+}
+
+func runVerify(cmd *base.Command, args []string) {
+	if len(args) > 0 {
+		usage(cmd)
+	}
+	ok := true
+	for _, mod := range modload.LoadBuildList()[1:] {
+		ok = verifyMod(mod) && ok
+	}
+	if ok {
+		fmt.Printf("all modules verified\n")
+	}
+}
+
+func verifyMod(mod module.Version) bool {
+	if r := modload.Replacement(mod); r.Path != "" {
+		if r.Version == "" {
+			// Local directory replacements are not verified.
+			return true
+		}
+		mod = r
+	}
+	ok := true
+	zip, zipErr := modfetch.CacheFile(mod, ".zip")
+	if zipErr == nil {
+		_, zipErr = os.Stat(zip)
+	}
+	dir, dirErr := modfetch.DownloadDir(mod)
+	if dirErr == nil {
+		_, dirErr = os.Stat(dir)
+	}
+	data := modfetch.Sum(mod)
+	if data == "" {
+		if zipErr != nil && dirErr != nil {
+			// Nothing downloaded yet. Nothing to verify.
+			return true
+		}
+		base.Errorf("%s %s: missing ziphash", mod.Path, mod.Version)
+		return false
+	}
+	if zipErr == nil {
+		hZ, err := dirhash.HashZip(zip, dirhash.DefaultHash)
+		if err != nil {
+			base.Errorf("%s %s: %v", mod.Path, mod.Version, err)
+			return false
+		} else if hZ != data {
+			base.Errorf("%s %s: zip has been modified (%v)", mod.Path, mod.Version, zip)
+			ok = false
+		}
+	}
+	if dirErr == nil {
+		hD, err := dirhash.HashDir(dir, mod.Path+"@"+mod.Version, dirhash.DefaultHash)
+		if err != nil {
+			base.Errorf("%s %s: %v", mod.Path, mod.Version, err)
+			return false
+		}
+		if hD != data {
+			base.Errorf("%s %s: dir has been modified (%v)", mod.Path, mod.Version, dir)
+			ok = false
+		}
+	}
+	return ok
+}
@@ -0,0 +1,193 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package modfetch fetches module versions from a module proxy
+// and maintains the local module cache and go.sum checksums.
+package modfetch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"cmd/go/internal/module"
+	"cmd/go/internal/semver"
+)
+
+// PkgMod is the root of the module cache, $GOPATH/pkg/mod.
+// It is set by package modload before any module is fetched.
+var PkgMod string
+
+// A RevInfo describes a single module version.
+type RevInfo struct {
+	Version string    // version string
+	Time    time.Time // commit time
+}
+
+// CachePath returns the directory holding the downloaded
+// files (.info, .mod, .zip) for the module path.
+func CachePath(path string) (string, error) {
+	if PkgMod == "" {
+		return "", errors.New("internal error: modfetch.PkgMod not set")
+	}
+	enc, err := module.EncodePath(path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(PkgMod, "cache/download", enc, "@v"), nil
+}
+
+// CacheFile returns the name of the file in the download cache
+// with the given suffix (".info", ".mod", ".zip") for mod.
+// The file need not exist.
+func CacheFile(mod module.Version, suffix string) (string, error) {
+	dir, err := CachePath(mod.Path)
+	if err != nil {
+		return "", err
+	}
+	encVer, err := module.EncodeVersion(mod.Version)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, encVer+suffix), nil
+}
+
+// fetch returns the contents of the file with the given suffix for mod,
+// reading it from the module cache if present and otherwise
+// downloading it from the proxy and saving it in the cache.
+func fetch(mod module.Version, suffix string) (data []byte, file string, err error) {
+	file, err = CacheFile(mod, suffix)
+	if err != nil {
+		return nil, "", err
+	}
+	if data, err := ioutil.ReadFile(file); err == nil {
+		return data, file, nil
+	}
+	rel, err := proxyPath(mod.Path, mod.Version, suffix)
+	if err != nil {
+		return nil, "", err
+	}
+	data, err = proxyGet(rel)
+	if err != nil {
+		if isNotExist(err) {
+			return nil, "", fmt.Errorf("unknown revision %s", mod.Version)
+		}
+		return nil, "", err
+	}
+	if err := writeFileAtomic(file, data); err != nil {
+		return nil, "", err
+	}
+	return data, file, nil
+}
+
+// writeFileAtomic writes data to file, creating the parent directory
+// if needed. Readers never observe a partially written file.
+func writeFileAtomic(file string, data []byte) error {
+	dir := filepath.Dir(file)
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(dir, filepath.Base(file)+".tmp-")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), file)
+}
+
+// Versions returns the known versions of the module path,
+// sorted in semantic version order. Invalid and non-canonical
+// versions are omitted.
+//
+// If GOPROXY is unset, Versions lists the versions already
+// present in the module cache.
+func Versions(path string) ([]string, error) {
+	var list []string
+	if os.Getenv("GOPROXY") == "" {
+		dir, err := CachePath(path)
+		if err != nil {
+			return nil, err
+		}
+		infos, _ := filepath.Glob(filepath.Join(dir, "*.info"))
+		for _, info := range infos {
+			v, err := module.DecodeVersion(strings.TrimSuffix(filepath.Base(info), ".info"))
+			if err == nil {
+				list = append(list, v)
+			}
+		}
+	} else {
+		rel, err := proxyPath(path, "", "")
+		if err != nil {
+			return nil, err
+		}
+		data, err := proxyGet(rel)
+		if err != nil {
+			if isNotExist(err) {
+				return nil, nil
+			}
+			return nil, err
+		}
+		list = strings.Fields(string(data))
+	}
+
+	var versions []string
+	for _, v := range list {
+		if semver.IsValid(v) && semver.Canonical(v) == strings.TrimSuffix(v, "+incompatible") {
+			versions = append(versions, v)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return semver.Compare(versions[i], versions[j]) < 0
+	})
+	return versions, nil
+}
+
+// Stat returns information about the given version of the module path.
+func Stat(path, version string) (*RevInfo, error) {
+	data, _, err := fetch(module.Version{Path: path, Version: version}, ".info")
+	if err != nil {
+		return nil, err
+	}
+	info := new(RevInfo)
+	if err := json.Unmarshal(data, info); err != nil {
+		return nil, fmt.Errorf("invalid response from proxy for %s@%s: %v", path, version, err)
+	}
+	if info.Version != version {
+		return nil, fmt.Errorf("proxy returned info for version %s instead of requested version %s", info.Version, version)
+	}
+	return info, nil
+}
+
+// GoMod returns the go.mod file for the given version of the module path,
+// checking it against go.sum.
+func GoMod(path, version string) ([]byte, error) {
+	data, _, err := fetch(module.Version{Path: path, Version: version}, ".mod")
+	if err != nil {
+		return nil, err
+	}
+	checkGoMod(path, version, data)
+	return data, nil
+}
+
+// GoModFile returns the name of the cached go.mod file
+// for the given version of the module path, downloading it if needed.
+func GoModFile(path, version string) (string, error) {
+	if _, err := GoMod(path, version); err != nil {
+		return "", err
+	}
+	return CacheFile(module.Version{Path: path, Version: version}, ".mod")
+}
@@ -0,0 +1,286 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package modfetch
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"cmd/go/internal/base"
+	"cmd/go/internal/dirhash"
+	"cmd/go/internal/module"
+)
+
+// DownloadDir returns the directory in the module cache
+// holding the extracted files of mod.
+func DownloadDir(mod module.Version) (string, error) {
+	if PkgMod == "" {
+		return "", fmt.Errorf("internal error: modfetch.PkgMod not set")
+	}
+	enc, err := module.EncodePath(mod.Path)
+	if err != nil {
+		return "", err
+	}
+	encVer, err := module.EncodeVersion(mod.Version)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(PkgMod, enc+"@"+encVer), nil
+}
+
+// Download downloads the zip file for mod, checks it against go.sum,
+// and extracts it into the module cache.
+// It returns the directory holding the extracted module files.
+func Download(mod module.Version) (dir string, err error) {
+	dir, err = DownloadDir(mod)
+	if err != nil {
+		return "", err
+	}
+	if fi, err := os.Stat(dir); err == nil && fi.IsDir() {
+		// Already extracted. Make sure the recorded hash still matches go.sum.
+		if data, err := ioutil.ReadFile(dir + ".ziphash"); err == nil {
+			checkModSum(mod, strings.TrimSpace(string(data)))
+		}
+		return dir, nil
+	}
+
+	if file, err := CacheFile(mod, ".zip"); err == nil {
+		if _, err := os.Stat(file); err != nil {
+			fmt.Fprintf(os.Stderr, "go: downloading %s %s\n", mod.Path, mod.Version)
+		}
+	}
+	_, zipfile, err := fetch(mod, ".zip")
+	if err != nil {
+		return "", err
+	}
+	hash, err := dirhash.HashZip(zipfile, dirhash.DefaultHash)
+	if err != nil {
+		return "", fmt.Errorf("%s: %v", mod, err)
+	}
+	checkModSum(mod, hash)
+	if err := unzip(dir, zipfile, mod.Path+"@"+mod.Version+"/"); err != nil {
+		return "", fmt.Errorf("%s: %v", mod, err)
+	}
+	if err := writeFileAtomic(dir+".ziphash", []byte(hash+"\n")); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// unzip extracts the zip file into dir, removing prefix from
+// each file name. Every file in the archive must begin with prefix.
+// The files are extracted into a temporary directory that is
+// renamed to dir once complete.
+func unzip(dir, zipfile, prefix string) error {
+	z, err := zip.OpenReader(zipfile)
+	if err != nil {
+		return err
+	}
+	defer z.Close()
+
+	for _, zf := range z.File {
+		name := zf.Name
+		if !strings.HasPrefix(name, prefix) {
+			return fmt.Errorf("unexpected file name %s in zip (want prefix %s)", name, prefix)
+		}
+		rel := name[len(prefix):]
+		if strings.Contains(rel, "\\") || strings.HasPrefix(rel, "/") || rel == ".." || strings.HasPrefix(rel, "../") || strings.Contains(rel, "/../") || strings.HasSuffix(rel, "/..") {
+			return fmt.Errorf("invalid file name %s in zip", name)
+		}
+	}
+
+	parent := filepath.Dir(dir)
+	if err := os.MkdirAll(parent, 0777); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempDir(parent, filepath.Base(dir)+".tmp-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	for _, zf := range z.File {
+		rel := zf.Name[len(prefix):]
+		if rel == "" || strings.HasSuffix(rel, "/") {
+			continue
+		}
+		target := filepath.Join(tmp, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(target), 0777); err != nil {
+			return err
+		}
+		if err := extractFile(target, zf); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(tmp, dir); err != nil {
+		if _, serr := os.Stat(dir); serr == nil {
+			// Someone else extracted the module concurrently.
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+func extractFile(target string, zf *zip.File) error {
+	r, err := zf.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	w, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, r)
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// GoSumFile is the name of the go.sum file of the main module.
+// If it is empty, checksums are neither verified nor recorded.
+var GoSumFile string
+
+var goSum struct {
+	mu     sync.Mutex
+	m      map[module.Version][]string // content of go.sum file
+	loaded bool
+	dirty  bool // whether we added any new hashes to m
+}
+
+// initGoSum loads GoSumFile into goSum.m.
+// It reports whether go.sum checking is enabled.
+// goSum.mu must be locked.
+func initGoSum() (bool, error) {
+	if GoSumFile == "" {
+		return false, nil
+	}
+	if goSum.loaded {
+		return true, nil
+	}
+	goSum.loaded = true
+	goSum.m = make(map[module.Version][]string)
+	data, err := ioutil.ReadFile(GoSumFile)
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	lineno := 0
+	for len(data) > 0 {
+		var line []byte
+		lineno++
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			line, data = data, nil
+		} else {
+			line, data = data[:i], data[i+1:]
+		}
+		f := strings.Fields(string(line))
+		if len(f) == 0 {
+			// blank line; skip it
+			continue
+		}
+		if len(f) != 3 {
+			return false, fmt.Errorf("malformed go.sum:\n%s:%d: wrong number of fields %v", GoSumFile, lineno, len(f))
+		}
+		mod := module.Version{Path: f[0], Version: f[1]}
+		goSum.m[mod] = append(goSum.m[mod], f[2])
+	}
+	return true, nil
+}
+
+// checkGoMod checks the given module's go.mod checksum;
+// data is the go.mod content.
+func checkGoMod(path, version string, data []byte) {
+	h, err := dirhash.Hash1([]string{"go.mod"}, func(string) (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader(data)), nil
+	})
+	if err != nil {
+		base.Fatalf("go: verifying %s %s go.mod: %v", path, version, err)
+	}
+	checkModSum(module.Version{Path: path, Version: version + "/go.mod"}, h)
+}
+
+// checkModSum checks that the recorded checksum for mod is h,
+// recording h in go.sum if no checksum is known yet.
+func checkModSum(mod module.Version, h string) {
+	// Fatalf runs the exit hooks, which write go.sum,
+	// so it must not be called with goSum.mu held.
+	if err := addModSum(mod, h); err != nil {
+		base.Fatalf("go: %v", err)
+	}
+}
+
+// addModSum records h as the checksum for mod,
+// returning an error if a different checksum is already recorded.
+func addModSum(mod module.Version, h string) error {
+	goSum.mu.Lock()
+	defer goSum.mu.Unlock()
+	ok, err := initGoSum()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return nil
+	}
+	for _, vh := range goSum.m[mod] {
+		if h == vh {
+			return nil
+		}
+	}
+	if len(goSum.m[mod]) > 0 {
+		return fmt.Errorf("verifying %s@%s: checksum mismatch\n\tdownloaded: %v\n\tgo.sum:     %v", mod.Path, mod.Version, h, strings.Join(goSum.m[mod], ", "))
+	}
+	goSum.m[mod] = append(goSum.m[mod], h)
+	goSum.dirty = true
+	return nil
+}
+
+// Sum returns the checksum for the downloaded copy of the given module,
+// if present in the download cache.
+func Sum(mod module.Version) string {
+	dir, err := DownloadDir(mod)
+	if err != nil {
+		return ""
+	}
+	data, err := ioutil.ReadFile(dir + ".ziphash")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// WriteGoSum writes the go.sum file if it needs to be updated.
+func WriteGoSum() {
+	goSum.mu.Lock()
+	defer goSum.mu.Unlock()
+	if !goSum.dirty {
+		return
+	}
+
+	var mods []module.Version
+	for m := range goSum.m {
+		mods = append(mods, m)
+	}
+	module.Sort(mods)
+	var buf bytes.Buffer
+	for _, m := range mods {
+		for _, h := range goSum.m[m] {
+			fmt.Fprintf(&buf, "%s %s %s\n", m.Path, m.Version, h)
+		}
+	}
+	goSum.dirty = false
+	if err := ioutil.WriteFile(GoSumFile, buf.Bytes(), 0666); err != nil {
+		base.Errorf("go: writing go.sum: %v", err)
+	}
+}
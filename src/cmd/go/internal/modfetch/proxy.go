@@ -0,0 +1,155 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package modfetch
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"cmd/go/internal/module"
+	"cmd/go/internal/web"
+)
+
+var HelpGoproxy = `
+The go command downloads modules from a module proxy, named by the
+GOPROXY environment variable, and saves them in the module cache in
+$GOPATH/pkg/mod. GOPROXY may be:
+
+	an http:// or https:// URL,
+	a file:// URL naming a local directory,
+	an absolute path naming a local directory, or
+	"off", to disallow any downloads.
+
+If GOPROXY is unset, the go command uses only modules already present
+in the module cache. The go command never downloads modules directly
+from version control repositories; a module must be served by a proxy
+or already be in the module cache.
+
+A module proxy is a server that responds to GET requests for URLs
+of a specified form. The requests have no query parameters, so even
+a site serving from a fixed file system (including a file:/// URL)
+can be a module proxy.
+
+The GET requests sent to a Go module proxy are:
+
+GET $GOPROXY/<module>/@v/list returns a list of all known versions of the
+given module, one per line.
+
+GET $GOPROXY/<module>/@v/<version>.info returns JSON-formatted metadata
+about that version of the given module.
+
+GET $GOPROXY/<module>/@v/<version>.mod returns the go.mod file
+for that version of the given module.
+
+GET $GOPROXY/<module>/@v/<version>.zip returns the zip archive
+for that version of the given module.
+
+To avoid problems when serving from case-sensitive file systems,
+the <module> and <version> elements are case-encoded, replacing every
+uppercase letter with an exclamation mark followed by the corresponding
+lower-case letter: github.com/Azure encodes as github.com/!azure.
+
+The JSON-formatted metadata about a given module corresponds to
+this Go data structure, which may be expanded in the future:
+
+    type Info struct {
+        Version string    // version string
+        Time    time.Time // commit time
+    }
+
+The zip archive for a specific version of a given module is a
+standard zip file that contains the file tree corresponding
+to the module's source code and related files. The archive uses
+slash-separated paths, and every file path in the archive must
+begin with <module>@<version>/, where the module and version are
+substituted directly, not case-encoded. The root of the module
+file tree corresponds to the <module>@<version>/ prefix in the
+archive.
+
+Because the module cache in $GOPATH/pkg/mod/cache/download uses
+the same layout, it can itself be served as a module proxy:
+setting GOPROXY=file:///$GOPATH/pkg/mod/cache/download makes
+the go command use only modules already downloaded.
+`
+
+// A notExistError reports that the proxy has no such file.
+type notExistError struct {
+	path string
+}
+
+func (e *notExistError) Error() string {
+	return fmt.Sprintf("%s: not found", e.path)
+}
+
+func isNotExist(err error) bool {
+	_, ok := err.(*notExistError)
+	return ok
+}
+
+// proxyGet fetches the file named by the slash-separated path rel
+// from the module proxy named by $GOPROXY.
+func proxyGet(rel string) ([]byte, error) {
+	proxy := os.Getenv("GOPROXY")
+	switch {
+	case proxy == "":
+		return nil, errors.New("module lookup requires a module proxy, but GOPROXY is not set")
+	case proxy == "off":
+		return nil, errors.New("module lookup disabled by GOPROXY=off")
+	case strings.HasPrefix(proxy, "file://"):
+		u, err := url.Parse(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid GOPROXY %q: %v", proxy, err)
+		}
+		dir := filepath.FromSlash(u.Path)
+		if len(dir) >= 3 && dir[0] == filepath.Separator && dir[2] == ':' {
+			// file:///C:/dir on Windows.
+			dir = dir[1:]
+		}
+		return readProxyFile(dir, rel)
+	case filepath.IsAbs(proxy):
+		return readProxyFile(proxy, rel)
+	case strings.HasPrefix(proxy, "http://"), strings.HasPrefix(proxy, "https://"):
+		data, err := web.Get(strings.TrimSuffix(proxy, "/") + "/" + rel)
+		if err != nil {
+			if e, ok := err.(*web.HTTPError); ok && (e.StatusCode == 404 || e.StatusCode == 410) {
+				return nil, &notExistError{rel}
+			}
+			return nil, err
+		}
+		return data, nil
+	}
+	return nil, fmt.Errorf("invalid GOPROXY %q: must be URL, absolute directory, or \"off\"", proxy)
+}
+
+func readProxyFile(dir, rel string) ([]byte, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(rel)))
+	if os.IsNotExist(err) {
+		return nil, &notExistError{rel}
+	}
+	return data, err
+}
+
+// proxyPath returns the slash-separated path, relative to the proxy root,
+// of the file for the given module path and version with the given suffix.
+// If version is empty, proxyPath returns the path of the version list.
+func proxyPath(path, version, suffix string) (string, error) {
+	enc, err := module.EncodePath(path)
+	if err != nil {
+		return "", err
+	}
+	if version == "" {
+		return enc + "/@v/list", nil
+	}
+	encVer, err := module.EncodeVersion(version)
+	if err != nil {
+		return "", err
+	}
+	return enc + "/@v/" + encVer + suffix, nil
+}
@@ -0,0 +1,321 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package modfile
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// A Position describes a line in a go.mod file.
+type Position struct {
+	Line int // line in input (starting at 1)
+}
+
+// A Comment represents a single // comment.
+type Comment struct {
+	Start Position
+	Token string // without trailing newline
+}
+
+// Comments collects the comments associated with an expression.
+type Comments struct {
+	Before []Comment // whole-line comments before this expression
+	Suffix []Comment // end-of-line comments after this expression
+
+	// For top-level expressions only, After lists whole-line
+	// comments following the expression.
+	After []Comment
+}
+
+// Comment returns the receiver. This isn't useful by itself, but
+// a Comments struct is embedded into all the expression
+// implementation types, and this gives each of those a Comment
+// method to satisfy the Expr interface.
+func (c *Comments) Comment() *Comments {
+	return c
+}
+
+// An Expr represents an input element.
+type Expr interface {
+	// Comment returns the comments attached to the expression.
+	// This method would normally be named 'Comments' but that
+	// would interfere with embedding a type of the same name.
+	Comment() *Comments
+}
+
+// A FileSyntax represents an entire go.mod file.
+type FileSyntax struct {
+	Name string // file path
+	Comments
+	Stmt []Expr
+}
+
+// A CommentBlock represents a top-level block of comments separate
+// from any rule.
+type CommentBlock struct {
+	Comments
+	Start Position
+}
+
+// A Line is a single line of tokens.
+type Line struct {
+	Comments
+	Start   Position
+	Token   []string
+	InBlock bool
+}
+
+// A LineBlock is a factored block of lines, like
+//
+//	require (
+//		"x"
+//		"y"
+//	)
+//
+type LineBlock struct {
+	Comments
+	Start Position
+	Token []string
+	Line  []*Line
+}
+
+// parse parses data, the contents of the go.mod file named file,
+// into its syntax tree.
+func parse(file string, data []byte) (*FileSyntax, error) {
+	f := &FileSyntax{Name: file}
+	var (
+		block   *LineBlock
+		pending []Comment
+	)
+	for i, text := range strings.Split(string(data), "\n") {
+		pos := Position{Line: i + 1}
+		toks, com, err := lex(text)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", file, pos.Line, err)
+		}
+		if len(toks) == 0 {
+			if com != "" {
+				pending = append(pending, Comment{Start: pos, Token: com})
+			} else if block == nil && len(pending) > 0 {
+				// A blank line ends a run of free-standing comments.
+				f.Stmt = append(f.Stmt, &CommentBlock{Comments: Comments{Before: pending}, Start: pending[0].Start})
+				pending = nil
+			}
+			continue
+		}
+		var suffix []Comment
+		if com != "" {
+			suffix = []Comment{{Start: pos, Token: com}}
+		}
+		if block != nil {
+			if len(toks) == 1 && toks[0] == ")" {
+				block.After = append(pending, suffix...)
+				pending = nil
+				f.Stmt = append(f.Stmt, block)
+				block = nil
+				continue
+			}
+			if err := checkParens(toks); err != nil {
+				return nil, fmt.Errorf("%s:%d: %v", file, pos.Line, err)
+			}
+			block.Line = append(block.Line, &Line{
+				Comments: Comments{Before: pending, Suffix: suffix},
+				Start:    pos,
+				Token:    toks,
+				InBlock:  true,
+			})
+			pending = nil
+			continue
+		}
+		if toks[len(toks)-1] == "(" {
+			if len(toks) != 2 {
+				return nil, fmt.Errorf("%s:%d: unexpected (", file, pos.Line)
+			}
+			block = &LineBlock{
+				Comments: Comments{Before: pending, Suffix: suffix},
+				Start:    pos,
+				Token:    toks[:1],
+			}
+			pending = nil
+			continue
+		}
+		if err := checkParens(toks); err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", file, pos.Line, err)
+		}
+		f.Stmt = append(f.Stmt, &Line{
+			Comments: Comments{Before: pending, Suffix: suffix},
+			Start:    pos,
+			Token:    toks,
+		})
+		pending = nil
+	}
+	if block != nil {
+		return nil, fmt.Errorf("%s:%d: missing ) for block starting here", file, block.Start.Line)
+	}
+	f.After = pending
+	return f, nil
+}
+
+// checkParens reports an error if toks contains a parenthesis,
+// which may only begin or end a block.
+func checkParens(toks []string) error {
+	for _, t := range toks {
+		if t == "(" || t == ")" {
+			return fmt.Errorf("unexpected %s", t)
+		}
+	}
+	return nil
+}
+
+// lex splits a single line of input into tokens and a trailing comment.
+// Quoted strings are unquoted; the comment, if any, includes the leading //.
+func lex(text string) (toks []string, comment string, err error) {
+	text = strings.TrimRight(text, " \t\r")
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case strings.HasPrefix(text[i:], "//"):
+			return toks, text[i:], nil
+		case c == '(' || c == ')':
+			toks = append(toks, text[i:i+1])
+			i++
+		case strings.HasPrefix(text[i:], "=>"):
+			toks = append(toks, "=>")
+			i += 2
+		case c == '"' || c == '`':
+			j := i + 1
+			for ; j < len(text) && text[j] != c; j++ {
+				if c == '"' && text[j] == '\\' {
+					j++
+				}
+			}
+			if j >= len(text) {
+				return nil, "", errors.New("unterminated quoted string")
+			}
+			s, err := strconv.Unquote(text[i : j+1])
+			if err != nil {
+				return nil, "", fmt.Errorf("invalid quoted string %s", text[i:j+1])
+			}
+			toks = append(toks, s)
+			i = j + 1
+		default:
+			j := i
+			for j < len(text) && !isTokenEnd(text[j:]) {
+				j++
+			}
+			toks = append(toks, text[i:j])
+			i = j
+		}
+	}
+	return toks, "", nil
+}
+
+// isTokenEnd reports whether s begins with text that ends an unquoted token.
+func isTokenEnd(s string) bool {
+	switch s[0] {
+	case ' ', '\t', '\r', '(', ')', '"', '`':
+		return true
+	}
+	return strings.HasPrefix(s, "//") || strings.HasPrefix(s, "=>")
+}
+
+// MustQuote reports whether s must be quoted in order to appear as
+// a single token in a go.mod line.
+func MustQuote(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if unicode.IsSpace(r) || r == '"' || r == '`' || r == '(' || r == ')' || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+	return strings.Contains(s, "//") || strings.Contains(s, "=>")
+}
+
+// AutoQuote returns s or, if quoting is required for s to appear in a go.mod,
+// the quotation of s.
+func AutoQuote(s string) string {
+	if MustQuote(s) {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// Format returns a go.mod file as a byte slice, formatted in standard style.
+func Format(f *FileSyntax) []byte {
+	var buf bytes.Buffer
+	printComments := func(indent string, list []Comment) {
+		for _, com := range list {
+			buf.WriteString(indent)
+			buf.WriteString(com.Token)
+			buf.WriteString("\n")
+		}
+	}
+	printLine := func(indent string, toks []string, c *Comments) {
+		printComments(indent, c.Before)
+		buf.WriteString(indent)
+		for i, tok := range toks {
+			if i > 0 {
+				buf.WriteString(" ")
+			}
+			if tok == "=>" || tok == "(" {
+				buf.WriteString(tok)
+			} else {
+				buf.WriteString(AutoQuote(tok))
+			}
+		}
+		for _, com := range c.Suffix {
+			buf.WriteString(" ")
+			buf.WriteString(com.Token)
+		}
+	}
+
+	var prev Expr
+	for _, stmt := range f.Stmt {
+		if prev != nil && !sameVerb(prev, stmt) {
+			buf.WriteString("\n")
+		}
+		prev = stmt
+		switch x := stmt.(type) {
+		case *CommentBlock:
+			printComments("", x.Before)
+		case *Line:
+			printLine("", x.Token, &x.Comments)
+			buf.WriteString("\n")
+		case *LineBlock:
+			printLine("", append(x.Token[:len(x.Token):len(x.Token)], "("), &x.Comments)
+			buf.WriteString("\n")
+			for _, l := range x.Line {
+				printLine("\t", l.Token, &l.Comments)
+				buf.WriteString("\n")
+			}
+			printComments("\t", x.After)
+			buf.WriteString(")\n")
+		}
+	}
+	if len(f.After) > 0 {
+		if prev != nil {
+			buf.WriteString("\n")
+		}
+		printComments("", f.After)
+	}
+	return buf.Bytes()
+}
+
+// sameVerb reports whether x and y are single lines using the same verb,
+// in which case Format does not separate them with a blank line.
+func sameVerb(x, y Expr) bool {
+	lx, ok1 := x.(*Line)
+	ly, ok2 := y.(*Line)
+	return ok1 && ok2 && len(ly.Before) == 0 && lx.Token[0] == ly.Token[0]
+}
@@ -0,0 +1,447 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package modfile implements parsing and formatting for go.mod files.
+//
+// A go.mod file is a sequence of lines, each a verb followed by arguments.
+// Lines sharing a verb may be factored into a parenthesized block:
+//
+//	module example.com/hello
+//
+//	require (
+//		example.com/greeting v1.2.0
+//		example.com/lib v0.3.1 // indirect
+//	)
+//
+//	exclude example.com/lib v0.3.0
+//	replace example.com/greeting => ../greeting
+//
+// Comments are preserved when a parsed file is edited and reformatted.
+package modfile
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"cmd/go/internal/module"
+	"cmd/go/internal/semver"
+)
+
+// A File is the parsed, interpreted form of a go.mod file.
+type File struct {
+	Module  *Module
+	Require []*Require
+	Exclude []*Exclude
+	Replace []*Replace
+
+	Syntax *FileSyntax
+}
+
+// A Module is the module statement.
+type Module struct {
+	Mod    module.Version
+	Syntax *Line
+}
+
+// A Require is a single require statement.
+type Require struct {
+	Mod      module.Version
+	Indirect bool // has "// indirect" comment
+	Syntax   *Line
+}
+
+// An Exclude is a single exclude statement.
+type Exclude struct {
+	Mod    module.Version
+	Syntax *Line
+}
+
+// A Replace is a single replace statement.
+type Replace struct {
+	Old    module.Version
+	New    module.Version
+	Syntax *Line
+}
+
+// A VersionFixer is consulted for every version found in a go.mod file.
+// It may rewrite a version that is not a canonical semantic version,
+// for example by resolving a branch name to the corresponding version.
+type VersionFixer func(path, version string) (string, error)
+
+// Parse parses the data, reported in errors as being from file,
+// into a File struct. It applies fix, if non-nil, to canonicalize
+// all module versions found in the file.
+func Parse(file string, data []byte, fix VersionFixer) (*File, error) {
+	fs, err := parse(file, data)
+	if err != nil {
+		return nil, err
+	}
+	f := &File{Syntax: fs}
+
+	var errs []string
+	add := func(line *Line, verb string, args []string) {
+		if err := f.add(line, verb, args, fix); err != nil {
+			errs = append(errs, fmt.Sprintf("%s:%d: %v", file, line.Start.Line, err))
+		}
+	}
+	for _, x := range fs.Stmt {
+		switch x := x.(type) {
+		case *Line:
+			add(x, x.Token[0], x.Token[1:])
+		case *LineBlock:
+			verb := x.Token[0]
+			switch verb {
+			case "module", "require", "exclude", "replace":
+				for _, l := range x.Line {
+					add(l, verb, l.Token)
+				}
+			default:
+				errs = append(errs, fmt.Sprintf("%s:%d: unknown block type: %s", file, x.Start.Line, verb))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.New(strings.Join(errs, "\n"))
+	}
+	return f, nil
+}
+
+func (f *File) add(line *Line, verb string, args []string, fix VersionFixer) error {
+	switch verb {
+	default:
+		return fmt.Errorf("unknown directive: %s", verb)
+
+	case "module":
+		if f.Module != nil {
+			return errors.New("repeated module statement")
+		}
+		if len(args) != 1 {
+			return errors.New("usage: module module/path")
+		}
+		f.Module = &Module{Mod: module.Version{Path: args[0]}, Syntax: line}
+
+	case "require", "exclude":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: %s module/path v1.2.3", verb)
+		}
+		v, err := parseVersion(args[0], args[1], fix)
+		if err != nil {
+			return err
+		}
+		args[1] = v
+		mod := module.Version{Path: args[0], Version: v}
+		if err := module.Check(mod.Path, mod.Version); err != nil {
+			return err
+		}
+		if verb == "require" {
+			f.Require = append(f.Require, &Require{Mod: mod, Indirect: isIndirect(line), Syntax: line})
+		} else {
+			f.Exclude = append(f.Exclude, &Exclude{Mod: mod, Syntax: line})
+		}
+
+	case "replace":
+		arrow := 2
+		if len(args) >= 2 && args[1] == "=>" {
+			arrow = 1
+		}
+		if len(args) < arrow+2 || len(args) > arrow+3 || args[arrow] != "=>" {
+			return errors.New("usage: replace module/path [v1.2.3] => other/module v1.4\n\t or replace module/path [v1.2.3] => ../local/directory")
+		}
+		old := module.Version{Path: args[0]}
+		if arrow == 2 {
+			v, err := parseVersion(args[0], args[1], fix)
+			if err != nil {
+				return err
+			}
+			args[1] = v
+			old.Version = v
+		}
+		nw := module.Version{Path: args[arrow+1]}
+		if len(args) == arrow+2 {
+			if !IsDirectoryPath(nw.Path) {
+				return errors.New("replacement module without version must be directory path (rooted or starting with ./ or ../)")
+			}
+		} else {
+			if IsDirectoryPath(nw.Path) {
+				return errors.New("replacement module directory path cannot have version")
+			}
+			v, err := parseVersion(nw.Path, args[arrow+2], fix)
+			if err != nil {
+				return err
+			}
+			args[arrow+2] = v
+			nw.Version = v
+			if err := module.Check(nw.Path, nw.Version); err != nil {
+				return err
+			}
+		}
+		f.Replace = append(f.Replace, &Replace{Old: old, New: nw, Syntax: line})
+	}
+	return nil
+}
+
+// IsDirectoryPath reports whether the given path should be interpreted
+// as a directory path. Just like on the go command line, relative paths
+// and rooted paths are directory paths; the rest are module paths.
+func IsDirectoryPath(ns string) bool {
+	return strings.HasPrefix(ns, "./") || strings.HasPrefix(ns, "../") || ns == "." || ns == ".." ||
+		strings.HasPrefix(ns, "/") || filepath.IsAbs(ns)
+}
+
+func parseVersion(path, s string, fix VersionFixer) (string, error) {
+	if fix != nil {
+		var err error
+		s, err = fix(path, s)
+		if err != nil {
+			return "", err
+		}
+	}
+	cv := semver.Canonical(s)
+	if cv == "" {
+		return "", fmt.Errorf("invalid module version %q for %s", s, path)
+	}
+	if semver.Build(s) == "+incompatible" {
+		cv += "+incompatible"
+	}
+	return cv, nil
+}
+
+const indirectComment = "// indirect"
+
+// isIndirect reports whether line has a "// indirect" suffix comment.
+func isIndirect(line *Line) bool {
+	if len(line.Suffix) == 0 {
+		return false
+	}
+	com := strings.TrimSpace(line.Suffix[0].Token)
+	return com == indirectComment || strings.HasPrefix(com, indirectComment+";")
+}
+
+// setIndirect adds or removes the "// indirect" suffix comment on line.
+func setIndirect(line *Line, indirect bool) {
+	if isIndirect(line) == indirect {
+		return
+	}
+	if indirect {
+		line.Suffix = append([]Comment{{Token: indirectComment}}, line.Suffix...)
+	} else {
+		line.Suffix = line.Suffix[1:]
+	}
+}
+
+// Format returns the formatted contents of f.
+func (f *File) Format() ([]byte, error) {
+	return Format(f.Syntax), nil
+}
+
+// AddModuleStmt sets the module path, adding a module statement
+// at the top of the file if there is none.
+func (f *File) AddModuleStmt(path string) error {
+	if f.Syntax == nil {
+		f.Syntax = new(FileSyntax)
+	}
+	if f.Module == nil {
+		line := &Line{Token: []string{"module", path}}
+		f.Syntax.Stmt = append([]Expr{line}, f.Syntax.Stmt...)
+		f.Module = &Module{Mod: module.Version{Path: path}, Syntax: line}
+	} else {
+		f.Module.Mod.Path = path
+		f.Module.Syntax.Token = []string{"module", path}
+	}
+	return nil
+}
+
+// AddRequire adds a requirement on path at version vers,
+// replacing any existing requirement on path.
+func (f *File) AddRequire(path, vers string) error {
+	var found bool
+	for _, r := range f.Require {
+		if r.Mod.Path != path {
+			continue
+		}
+		if found {
+			// Duplicate requirement: drop it.
+			r.Syntax.Token = nil
+			continue
+		}
+		found = true
+		r.Mod.Version = vers
+		setRequireTokens(r.Syntax, path, vers)
+	}
+	if !found {
+		f.addNewRequire(path, vers, false)
+	}
+	f.Cleanup()
+	return nil
+}
+
+// DropRequire removes the requirement on path, if any.
+func (f *File) DropRequire(path string) error {
+	for _, r := range f.Require {
+		if r.Mod.Path == path {
+			r.Syntax.Token = nil
+		}
+	}
+	f.Cleanup()
+	return nil
+}
+
+// SetRequire replaces the requirements in f with req,
+// preserving the placement and comments of requirements that remain.
+func (f *File) SetRequire(req []*Require) {
+	need := make(map[string]*Require)
+	for _, r := range req {
+		need[r.Mod.Path] = r
+	}
+	for _, r := range f.Require {
+		if n := need[r.Mod.Path]; n != nil {
+			setRequireTokens(r.Syntax, n.Mod.Path, n.Mod.Version)
+			setIndirect(r.Syntax, n.Indirect)
+			delete(need, r.Mod.Path)
+		} else {
+			r.Syntax.Token = nil
+		}
+	}
+	var add []string
+	for path := range need {
+		add = append(add, path)
+	}
+	sort.Strings(add)
+	for _, path := range add {
+		f.addNewRequire(path, need[path].Mod.Version, need[path].Indirect)
+	}
+	f.Cleanup()
+	f.gatherRequire()
+	f.sortRequire()
+}
+
+// setRequireTokens sets the tokens of a require line to path and vers.
+func setRequireTokens(line *Line, path, vers string) {
+	if line.InBlock {
+		line.Token = []string{path, vers}
+	} else {
+		line.Token = []string{"require", path, vers}
+	}
+}
+
+// addNewRequire adds a new requirement line to the last require block
+// or, if there is no block, after the last require line.
+func (f *File) addNewRequire(path, vers string, indirect bool) {
+	line := &Line{Token: []string{path, vers}}
+	setIndirect(line, indirect)
+	stmts := f.Syntax.Stmt
+	for i := len(stmts) - 1; i >= 0; i-- {
+		if b, ok := stmts[i].(*LineBlock); ok && b.Token[0] == "require" {
+			line.InBlock = true
+			b.Line = append(b.Line, line)
+			f.Require = append(f.Require, &Require{Mod: module.Version{Path: path, Version: vers}, Indirect: indirect, Syntax: line})
+			return
+		}
+	}
+	line.Token = []string{"require", path, vers}
+	where := len(stmts)
+	for i := len(stmts) - 1; i >= 0; i-- {
+		if l, ok := stmts[i].(*Line); ok && len(l.Token) > 0 && l.Token[0] == "require" {
+			where = i + 1
+			break
+		}
+	}
+	f.Syntax.Stmt = append(stmts[:where:where], append([]Expr{line}, stmts[where:]...)...)
+	f.Require = append(f.Require, &Require{Mod: module.Version{Path: path, Version: vers}, Indirect: indirect, Syntax: line})
+}
+
+// gatherRequire collects multiple single-line require statements
+// into a single block, placed where the first of them appeared.
+// It does nothing if the file already has a require block.
+func (f *File) gatherRequire() {
+	var lines []*Line
+	first := -1
+	for i, stmt := range f.Syntax.Stmt {
+		switch x := stmt.(type) {
+		case *LineBlock:
+			if x.Token[0] == "require" {
+				return
+			}
+		case *Line:
+			if x.Token[0] == "require" {
+				if first < 0 {
+					first = i
+				}
+				lines = append(lines, x)
+			}
+		}
+	}
+	if len(lines) < 2 {
+		return
+	}
+	block := &LineBlock{Token: []string{"require"}}
+	var stmts []Expr
+	for i, stmt := range f.Syntax.Stmt {
+		if i == first {
+			stmts = append(stmts, block)
+		}
+		if l, ok := stmt.(*Line); ok && l.Token[0] == "require" {
+			l.Token = l.Token[1:]
+			l.InBlock = true
+			block.Line = append(block.Line, l)
+			continue
+		}
+		stmts = append(stmts, stmt)
+	}
+	f.Syntax.Stmt = stmts
+}
+
+// sortRequire sorts the lines of require blocks by module path.
+func (f *File) sortRequire() {
+	for _, stmt := range f.Syntax.Stmt {
+		if b, ok := stmt.(*LineBlock); ok && b.Token[0] == "require" {
+			sort.SliceStable(b.Line, func(i, j int) bool {
+				return b.Line[i].Token[0] < b.Line[j].Token[0]
+			})
+		}
+	}
+}
+
+// Cleanup cleans up the file f after any edit operations.
+// To avoid quadratic behavior, modifications like DropRequire
+// clear the entry but do not remove it from the slice.
+// Cleanup cleans out all the cleared entries.
+func (f *File) Cleanup() {
+	w := 0
+	for _, r := range f.Require {
+		if r.Syntax.Token != nil {
+			f.Require[w] = r
+			w++
+		}
+	}
+	f.Require = f.Require[:w]
+
+	w = 0
+	for _, stmt := range f.Syntax.Stmt {
+		switch x := stmt.(type) {
+		case *Line:
+			if x.Token == nil {
+				continue
+			}
+		case *LineBlock:
+			ww := 0
+			for _, l := range x.Line {
+				if l.Token != nil {
+					x.Line[ww] = l
+					ww++
+				}
+			}
+			if ww == 0 {
+				continue
+			}
+			x.Line = x.Line[:ww]
+		}
+		f.Syntax.Stmt[w] = stmt
+		w++
+	}
+	f.Syntax.Stmt = f.Syntax.Stmt[:w]
+}
@@ -0,0 +1,153 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package modfile
+
+import (
+	"strings"
+	"testing"
+
+	"cmd/go/internal/module"
+)
+
+var formatTests = []struct {
+	in  string
+	out string
+}{
+	{
+		`module "x.y/z"`,
+		"module x.y/z\n",
+	},
+	{
+		`// head comment
+
+module x.y/z // trailing
+require x.y/a v1.2
+require (
+	// about b
+	x.y/b v0.1.0 // indirect
+
+	x.y/c v2.0.0+incompatible
+	// end of block
+)
+replace x.y/a v1.2.0 => ../a
+`,
+		`// head comment
+
+module x.y/z // trailing
+
+require x.y/a v1.2.0
+
+require (
+	// about b
+	x.y/b v0.1.0 // indirect
+	x.y/c v2.0.0+incompatible
+	// end of block
+)
+
+replace x.y/a v1.2.0 => ../a
+`,
+	},
+}
+
+func TestFormat(t *testing.T) {
+	for _, tt := range formatTests {
+		f, err := Parse("go.mod", []byte(tt.in), nil)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.in, err)
+			continue
+		}
+		out, _ := f.Format()
+		if string(out) != tt.out {
+			t.Errorf("Format(%q):\nhave:\n%s\nwant:\n%s", tt.in, out, tt.out)
+		}
+	}
+}
+
+func TestParseInterpret(t *testing.T) {
+	f, err := Parse("go.mod", []byte(formatTests[1].in), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Module == nil || f.Module.Mod.Path != "x.y/z" {
+		t.Fatalf("module = %+v, want x.y/z", f.Module)
+	}
+	var reqs []string
+	for _, r := range f.Require {
+		s := r.Mod.String()
+		if r.Indirect {
+			s += " indirect"
+		}
+		reqs = append(reqs, s)
+	}
+	if have, want := strings.Join(reqs, ","), "x.y/a@v1.2.0,x.y/b@v0.1.0 indirect,x.y/c@v2.0.0+incompatible"; have != want {
+		t.Errorf("requirements = %s, want %s", have, want)
+	}
+	if len(f.Replace) != 1 || f.Replace[0].Old != (module.Version{Path: "x.y/a", Version: "v1.2.0"}) || f.Replace[0].New.Path != "../a" {
+		t.Errorf("replace = %+v", f.Replace)
+	}
+}
+
+var parseErrorTests = []struct {
+	in  string
+	err string
+}{
+	{"module x.y/z\nmodule x.y/w", "go.mod:2: repeated module statement"},
+	{"require x.y/a", "go.mod:1: usage: require module/path v1.2.3"},
+	{"require x.y/a master", `go.mod:1: invalid module version "master" for x.y/a`},
+	{"require x.y/a/v2 v1.0.0", "go.mod:1: mismatched module path x.y/a/v2 and version v1.0.0 (want v2)"},
+	{"require (\nx.y/a v1.0.0", "go.mod:1: missing ) for block starting here"},
+	{"replace x.y/a => x.y/b", "go.mod:1: replacement module without version must be directory path (rooted or starting with ./ or ../)"},
+	{"retract v1.0.0", "go.mod:1: unknown directive: retract"},
+	{`module "x.y`, "go.mod:1: unterminated quoted string"},
+}
+
+func TestParseError(t *testing.T) {
+	for _, tt := range parseErrorTests {
+		_, err := Parse("go.mod", []byte(tt.in), nil)
+		if err == nil || err.Error() != tt.err {
+			t.Errorf("Parse(%q) = %v, want %q", tt.in, err, tt.err)
+		}
+	}
+}
+
+var setRequireTests = []struct {
+	in  string
+	req []module.Version
+	out string
+}{
+	{
+		"module x.y/z\n",
+		[]module.Version{{Path: "x.y/b", Version: "v1.0.0"}},
+		"module x.y/z\n\nrequire x.y/b v1.0.0\n",
+	},
+	{
+		"module x.y/z\n\nrequire x.y/b v1.0.0 // keep me\n",
+		[]module.Version{{Path: "x.y/b", Version: "v1.1.0"}, {Path: "x.y/a", Version: "v0.1.0"}},
+		"module x.y/z\n\nrequire (\n\tx.y/a v0.1.0\n\tx.y/b v1.1.0 // keep me\n)\n",
+	},
+	{
+		"module x.y/z\n\nrequire (\n\tx.y/a v0.1.0\n\tx.y/b v1.1.0\n)\n",
+		[]module.Version{{Path: "x.y/b", Version: "v1.1.0"}},
+		"module x.y/z\n\nrequire (\n\tx.y/b v1.1.0\n)\n",
+	},
+}
+
+func TestSetRequire(t *testing.T) {
+	for _, tt := range setRequireTests {
+		f, err := Parse("go.mod", []byte(tt.in), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var req []*Require
+		for _, m := range tt.req {
+			req = append(req, &Require{Mod: m})
+		}
+		f.SetRequire(req)
+		out, _ := f.Format()
+		if string(out) != tt.out {
+			t.Errorf("SetRequire on %q:\nhave:\n%s\nwant:\n%s", tt.in, out, tt.out)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package modget implements the module-aware ``go get'' command.
+package modget
+
+import (
+	"strings"
+
+	"cmd/go/internal/base"
+	"cmd/go/internal/modload"
+	"cmd/go/internal/module"
+	"cmd/go/internal/mvs"
+	"cmd/go/internal/semver"
+	"cmd/go/internal/work"
+)
+
+var CmdGet = &base.Command{
+	UsageLine: "get [-d] [-u] [build flags] [packages]",
+	Short:     "add dependencies to current module and install them",
+	Long: `
+Get resolves and adds dependencies to the current development module
+and then builds and installs them.
+
+The first step is to resolve which dependencies to add.
+
+For each named package or package pattern, get must decide which version of
+the corresponding module to use. By default, get chooses the latest tagged
+release version, such as v0.4.5 or v1.2.3. If there are no tagged release
+versions, get chooses the latest tagged prerelease version, such as
+v0.0.1-pre1.
+
+This default version selection can be overridden by adding an @version
+suffix to the package argument, as in 'go get golang.org/x/text@v0.3.0'.
+The version suffix can be a full semantic version, such as v0.3.0,
+or an abbreviated one, such as v0 or v0.3, denoting the latest release
+with that prefix.
+The special version suffix @latest is the same as the default.
+The special version suffix @none indicates that the dependency should
+be removed entirely.
+
+Although get defaults to using the latest version of the module containing
+a named package, it does not use the latest version of that module's
+dependencies. Instead it prefers to use the specific dependency versions
+requested by that module. For example, if the latest A requires module
+B v1.2.3, while B v1.2.4 and v1.3.1 are also available, then 'go get A'
+will use the latest A but then use B v1.2.3, as requested by A.
+
+The -u flag instructs get to update dependencies to use the latest
+versions as well: in the example, 'go get -u A' will use the latest A
+with B v1.3.1. With no package arguments, 'go get -u' updates all
+the dependencies of the main module.
+
+Get refuses to downgrade a module below the version required by another
+module in the build list, and it refuses to remove a module that is
+still required by another module.
+
+The second step is to download (if needed), build, and install
+the named packages.
+
+If an argument names a module but not a package (because there is no
+Go source code in the module's root directory), then the install step
+is skipped for that argument, instead of causing a build failure.
+
+The -d flag instructs get to download the source code needed to build
+the named packages, including downloading necessary dependencies,
+but not to build and install them.
+
+With no package arguments, 'go get' applies to the main module.
+
+For more about modules, see 'go help modules'.
+
+For more about specifying packages, see 'go help packages'.
+
+This text describes the behavior of get using modules to manage source
+code and dependencies. If instead the go command is running in GOPATH
+mode, the details of get's flags and effects change, as does 'go help get'.
+See 'go help modules' and 'go help gopath-get'.
+
+See also: go build, go install, go clean, go mod.
+	`,
+}
+
+// Note that this help text is a stopgap to make the module-aware get help text
+// available even in non-module settings. It should be deleted when the old get
+// is deleted. It should NOT be considered to set a precedent of having hierarchical
+// help names with dashes.
+var HelpModuleGet = &base.Command{
+	UsageLine: "module-get",
+	Short:     "module-aware go get",
+	Long: `
+The 'go get' command changes behavior depending on whether the
+go command is running in module-aware mode or legacy GOPATH mode.
+This help text, accessible as 'go help module-get' even in legacy GOPATH mode,
+describes 'go get' as it operates in module-aware mode.
+
+Usage: ` + CmdGet.UsageLine + `
+` + CmdGet.Long,
+}
+
+var (
+	getD = CmdGet.Flag.Bool("d", false, "")
+	getU = CmdGet.Flag.Bool("u", false, "")
+)
+
+func init() {
+	work.AddBuildFlags(CmdGet)
+	CmdGet.Run = runGet // break init loop
+}
+
+func runGet(cmd *base.Command, args []string) {
+	if !*getD {
+		work.BuildInit()
+	}
+	modload.LoadBuildList()
+
+	var (
+		pkgs  []string         // packages to install
+		named []module.Version // modules named on the command line
+	)
+	for _, arg := range args {
+		path, vers := arg, "latest"
+		if i := strings.Index(arg, "@"); i >= 0 {
+			path, vers = arg[:i], arg[i+1:]
+		}
+		if strings.Contains(path, "...") || strings.HasPrefix(path, ".") || strings.HasPrefix(path, "/") {
+			base.Errorf("go get %s: patterns and local paths are not supported in module mode", arg)
+			continue
+		}
+		if vers == "none" {
+			if err := modload.DropRequire(path); err != nil {
+				base.Errorf("go get %s: %v", arg, err)
+			}
+			continue
+		}
+		mod, isPkg, err := modload.QueryPackage(path, vers)
+		if err != nil {
+			base.Errorf("go get %s: %v", arg, err)
+			continue
+		}
+		if err := modload.AddRequire(mod.Path, mod.Version); err != nil {
+			base.Errorf("go get %s: %v", arg, err)
+			continue
+		}
+		named = append(named, mod)
+		if isPkg {
+			pkgs = append(pkgs, path)
+		}
+	}
+	base.ExitIfErrors()
+
+	if *getU {
+		upgrade(named)
+	}
+	modload.WriteGoMod()
+
+	if *getD {
+		return
+	}
+	if len(args) == 0 {
+		pkgs = []string{"."}
+	}
+	if len(pkgs) > 0 {
+		work.InstallPackages(pkgs, true)
+	}
+}
+
+// upgrade upgrades the dependencies of the named modules,
+// or of the main module if none are named, to their latest versions.
+func upgrade(named []module.Version) {
+	var deps []module.Version
+	if len(named) == 0 {
+		deps = modload.BuildList()[1:]
+	} else {
+		seen := make(map[module.Version]bool)
+		for _, m := range named {
+			list, err := mvs.BuildList(m, modload.Reqs())
+			if err != nil {
+				base.Fatalf("go get: %v", err)
+			}
+			for _, dep := range list[1:] {
+				if !seen[dep] {
+					seen[dep] = true
+					deps = append(deps, dep)
+				}
+			}
+		}
+	}
+	for _, dep := range deps {
+		info, err := modload.Query(dep.Path, "latest", nil)
+		if err != nil {
+			base.Errorf("go get: upgrading %s: %v", dep.Path, err)
+			continue
+		}
+		if semver.Compare(info.Version, dep.Version) > 0 {
+			if err := modload.AddRequire(dep.Path, info.Version); err != nil {
+				base.Errorf("go get: %v", err)
+			}
+		}
+	}
+	base.ExitIfErrors()
+}
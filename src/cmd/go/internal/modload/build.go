@@ -0,0 +1,146 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package modload
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"cmd/go/internal/load"
+	"cmd/go/internal/modfetch"
+	"cmd/go/internal/modinfo"
+	"cmd/go/internal/module"
+)
+
+// PackageModuleInfo returns information about the module
+// providing the package with the given import path,
+// or nil if the package is in the standard library.
+func PackageModuleInfo(pkgpath string) *modinfo.ModulePublic {
+	if isStandardImportPath(pkgpath) {
+		return nil
+	}
+	r, ok := lookupCache[pkgpath]
+	if !ok || r.err != nil {
+		return nil
+	}
+	return moduleInfo(r.mod)
+}
+
+// ListModules returns information about the modules named by args:
+// no arguments means the main module, "all" means the entire build list,
+// a path pattern matches module paths in the build list, and
+// path@version queries for a specific version of a module.
+func ListModules(args []string) []*modinfo.ModulePublic {
+	LoadBuildList()
+	if len(args) == 0 {
+		return []*modinfo.ModulePublic{moduleInfo(Target)}
+	}
+
+	var mods []*modinfo.ModulePublic
+	for _, arg := range args {
+		if i := strings.Index(arg, "@"); i >= 0 {
+			path, vers := arg[:i], arg[i+1:]
+			info, err := Query(path, vers, nil)
+			if err != nil {
+				mods = append(mods, &modinfo.ModulePublic{
+					Path:    path,
+					Version: vers,
+					Error:   &modinfo.ModuleError{Err: err.Error()},
+				})
+				continue
+			}
+			mods = append(mods, moduleInfo(module.Version{Path: path, Version: info.Version}))
+			continue
+		}
+
+		match := func(p string) bool { return p == arg }
+		if arg == "all" {
+			match = func(string) bool { return true }
+		} else if strings.Contains(arg, "...") {
+			match = load.MatchPattern(arg)
+		}
+		matched := false
+		for _, m := range buildList {
+			if match(m.Path) {
+				matched = true
+				mods = append(mods, moduleInfo(m))
+			}
+		}
+		if !matched {
+			if strings.Contains(arg, "...") {
+				fmt.Fprintf(os.Stderr, "warning: pattern %q matched no module dependencies\n", arg)
+				continue
+			}
+			mods = append(mods, &modinfo.ModulePublic{
+				Path:  arg,
+				Error: &modinfo.ModuleError{Err: "module not in current build"},
+			})
+		}
+	}
+	return mods
+}
+
+// moduleInfo returns information about the module m.
+func moduleInfo(m module.Version) *modinfo.ModulePublic {
+	if m == Target {
+		return &modinfo.ModulePublic{
+			Path:  m.Path,
+			Main:  true,
+			Dir:   modRoot,
+			GoMod: filepath.Join(modRoot, "go.mod"),
+		}
+	}
+
+	info := &modinfo.ModulePublic{
+		Path:    m.Path,
+		Version: m.Version,
+	}
+	for _, r := range modFile.Require {
+		if r.Mod.Path == m.Path {
+			info.Indirect = r.Indirect
+		}
+	}
+
+	// complete fills in the extra fields in m.
+	complete := func(m *modinfo.ModulePublic) {
+		if m.Version != "" {
+			if q, err := modfetch.Stat(m.Path, m.Version); err != nil {
+				m.Error = &modinfo.ModuleError{Err: err.Error()}
+			} else {
+				m.Time = &q.Time
+			}
+			mod := module.Version{Path: m.Path, Version: m.Version}
+			if dir, err := modfetch.DownloadDir(mod); err == nil {
+				if fi, err := os.Stat(dir); err == nil && fi.IsDir() {
+					m.Dir = dir
+				}
+			}
+			if gomod, err := modfetch.GoModFile(m.Path, m.Version); err == nil {
+				m.GoMod = gomod
+			}
+		}
+	}
+
+	if r := Replacement(m); r.Path != "" {
+		info.Replace = &modinfo.ModulePublic{
+			Path:    r.Path,
+			Version: r.Version,
+		}
+		if r.Version == "" {
+			info.Replace.Dir = replaceDir(r.Path)
+			info.Replace.GoMod = filepath.Join(info.Replace.Dir, "go.mod")
+		} else {
+			complete(info.Replace)
+		}
+		info.Dir = info.Replace.Dir
+		info.GoMod = info.Replace.GoMod
+		return info
+	}
+
+	complete(info)
+	return info
+}
@@ -0,0 +1,200 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package modload
+
+import (
+	"cmd/go/internal/base"
+	"cmd/go/internal/modfetch"
+)
+
+var HelpModules = &base.Command{
+	UsageLine: "modules",
+	Short:     "modules, module versions, and more",
+	Long: `
+A module is a collection of related Go packages.
+Modules are the unit of source code interchange and versioning.
+The go command has direct support for working with modules,
+including recording and resolving dependencies on other modules.
+Modules replace the old GOPATH-based approach to specifying
+which source files are used in a given build.
+
+Preliminary module support
+
+The go command can run in module-aware mode or in GOPATH mode,
+controlled by the GO111MODULE environment variable.
+
+If GO111MODULE=on, the go command requires the use of modules,
+never consulting GOPATH for packages. We refer to this as the command
+being module-aware or running in "module-aware mode".
+
+If GO111MODULE=off, the go command never uses module support.
+Instead it looks in vendor directories and GOPATH to find dependencies;
+we now refer to this as "GOPATH mode."
+
+If GO111MODULE=auto or is unset, the go command enables module support
+when the current directory is outside GOPATH/src and either the
+current directory or one of its parents contains a go.mod file.
+
+In module-aware mode, GOPATH no longer defines the meaning of imports
+during a build, but it still stores downloaded dependencies (in
+GOPATH/pkg/mod) and installed commands (in GOPATH/bin, unless GOBIN
+is set).
+
+Defining a module
+
+A module is defined by a tree of Go source files with a go.mod file
+in the tree's root directory. The directory containing the go.mod file
+is called the module root. Typically the module root will also correspond
+to a source code repository root (but in general it need not).
+The module is the set of all Go packages in the module root and its
+subdirectories, but excluding subtrees with their own go.mod files.
+
+The "module path" is the import path prefix corresponding to the module root.
+The go.mod file defines the module path and lists the specific versions
+of other modules that should be used when resolving imports during a build,
+by giving their module paths and versions.
+
+For example, this go.mod declares that the directory containing it is the root
+of the module with path example.com/m, and it also declares that the module
+depends on specific versions of golang.org/x/text and gopkg.in/yaml.v2:
+
+	module example.com/m
+
+	require (
+		golang.org/x/text v0.3.0
+		gopkg.in/yaml.v2 v2.1.0
+	)
+
+The go.mod file can also specify replacements and excluded versions
+that only apply when building the module directly; they are ignored
+when the module is incorporated into a larger build.
+For more about the go.mod file, see 'go help go.mod'.
+
+To start a new module, simply create a go.mod file in the root of the
+module's directory tree, containing only a module statement.
+The 'go mod init' command can be used to do this:
+
+	go mod init example.com/m
+
+The main module and the build list
+
+The "main module" is the module containing the directory where the go command
+is run. The go command finds the module root by looking for a go.mod in the
+current directory, or else the current directory's parent directory,
+or else the parent's parent directory, and so on.
+
+The main module's go.mod file defines the precise set of packages available
+for use by the go command, through require, replace, and exclude statements.
+Dependency modules, found by following require statements, also contribute
+to the definition of that set of packages, but only through their go.mod
+files' require statements: any replace and exclude statements in dependency
+modules are ignored.
+
+The set of modules providing packages to builds is called the "build list".
+The build list initially contains only the main module. Then the go command
+adds to the list the exact module versions required by modules already
+on the list, recursively, until there is nothing left to add to the list.
+If multiple versions of a particular module are added to the list,
+then at the end only the latest version (according to semantic version
+ordering) is kept for use in the build. This is minimal version selection:
+the build uses the oldest versions that satisfy every requirement.
+
+The 'go list' command provides information about the main module
+and the build list. For example:
+
+	go list -m       # print path of main module
+	go list -m all   # print build list
+
+Maintaining module requirements
+
+The go.mod file is meant to be readable and editable by both
+programmers and tools. The go command itself automatically updates the go.mod
+file to maintain a standard formatting and the accuracy of require statements.
+
+Any go command that finds an unfamiliar import will look up the module
+containing that import, using the module proxy or, if GOPROXY is unset,
+the module cache, and add the latest version of that module
+to go.mod automatically. In most cases, therefore, it suffices to
+add an import to source code and run 'go build', 'go test', or even 'go list':
+as part of analyzing the package, the go command will discover
+and resolve the import and update the go.mod file.
+
+Any go command can determine that a module requirement is
+missing and must be added, even when considering only a single
+package from the module. The go command also drops requirements
+that are implied by other requirements, except those the go.mod
+file already lists as direct requirements.
+
+The 'go get' command updates go.mod to change the module versions used in a
+build. See 'go help module-get'.
+
+Module downloading and verification
+` + modfetch.HelpGoproxy + `
+The go command maintains, in the main module's root directory alongside
+go.mod, a file named go.sum containing the expected cryptographic checksums
+of the content of specific module versions. Each time a dependency is
+used, its checksum is added to go.sum if missing or else required to match
+the existing entry in go.sum.
+
+The 'go mod verify' command checks that the cached copies of module
+downloads still match both their recorded checksums and the entries in go.sum.
+
+Modules and vendoring
+
+When using modules, the go command ignores vendor directories.
+`,
+}
+
+var HelpGoMod = &base.Command{
+	UsageLine: "go.mod",
+	Short:     "the go.mod file",
+	Long: `
+A module version is defined by a tree of source files, with a go.mod
+file in its root. When the go command is run, it looks in the current
+directory and then successive parent directories to find the go.mod
+marking the root of the main (current) module.
+
+The go.mod file itself is line-oriented, with // comments but
+no /* */ comments. Each line holds a single directive, made up of a
+verb followed by arguments. For example:
+
+	module my/thing
+	require other/thing v1.0.2
+	require new/thing v2.3.4
+	exclude old/thing v1.2.3
+	replace bad/thing v1.4.5 => good/thing v1.4.5
+
+The verbs are module, to define the module path; require, to require
+a particular module at a given version or later; exclude, to exclude
+a particular module version from use; and replace, to replace a module
+version with a different module version. Exclude and replace apply only
+in the main module's go.mod and are ignored in dependencies.
+
+The leading verb can be factored out of adjacent lines to create a block,
+like in Go imports:
+
+	require (
+		new/thing v2.3.4
+		old/thing v1.2.3
+	)
+
+The replacement in a replace directive may also be a directory,
+named by an absolute path or a path beginning with ./ or ../,
+holding the module's source tree:
+
+	replace example.com/lib => ../lib
+
+A requirement on a module that the main module does not use directly
+is marked with an "// indirect" comment.
+
+The go.mod file is designed both to be edited directly and to be
+easily updated by tools. The go command automatically updates go.mod
+each time it uses the module graph, to make sure go.mod always accurately
+reflects reality and is properly formatted. Because the module graph
+defines the meaning of import statements, any commands that load packages
+also use and therefore update go.mod, including go build, go get,
+go install, go list, go test, and go mod.
+`,
+}
@@ -0,0 +1,442 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package modload implements module-aware package loading:
+// finding the main module, computing the build list with
+// minimal version selection, and resolving import paths
+// against the modules in the build list.
+package modload
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"cmd/go/internal/base"
+	"cmd/go/internal/cfg"
+	"cmd/go/internal/load"
+	"cmd/go/internal/modfetch"
+	"cmd/go/internal/modfile"
+	"cmd/go/internal/module"
+	"cmd/go/internal/mvs"
+	"cmd/go/internal/str"
+)
+
+var (
+	cwd            string
+	MustUseModules bool
+	initialized    bool
+
+	modRoot     string
+	modFile     *modfile.File
+	modFileData []byte
+	excluded    map[module.Version]bool
+	Target      module.Version
+
+	gopath string
+
+	CmdModInit   bool   // running 'go mod init'
+	CmdModModule string // module argument for 'go mod init'
+)
+
+func init() {
+	load.ModInit = Init
+}
+
+// Init determines whether module mode is enabled, locates the main module,
+// and installs the module hooks in package load.
+// It does not read go.mod; that happens lazily in InitMod.
+func Init() {
+	if initialized {
+		return
+	}
+	initialized = true
+
+	env := os.Getenv("GO111MODULE")
+	switch env {
+	default:
+		base.Fatalf("go: unknown environment setting GO111MODULE=%s", env)
+	case "", "auto":
+		// leave MustUseModules alone
+	case "on":
+		MustUseModules = true
+	case "off":
+		return
+	}
+
+	var err error
+	cwd, err = os.Getwd()
+	if err != nil {
+		base.Fatalf("go: %v", err)
+	}
+
+	inGOPATH := false
+	for _, gopath := range filepath.SplitList(cfg.BuildContext.GOPATH) {
+		if gopath == "" {
+			continue
+		}
+		if str.HasFilePathPrefix(cwd, filepath.Join(gopath, "src")) {
+			inGOPATH = true
+			break
+		}
+	}
+	if inGOPATH && !MustUseModules && !CmdModInit {
+		// No automatic enabling in GOPATH.
+		return
+	}
+
+	if CmdModInit {
+		// Running 'go mod init': go.mod will be created in current directory.
+		modRoot = cwd
+	} else {
+		modRoot = findModuleRoot(cwd)
+		if modRoot == "" && !MustUseModules {
+			// No go.mod found and not forced: stay in GOPATH mode.
+			return
+		}
+	}
+
+	list := filepath.SplitList(cfg.BuildContext.GOPATH)
+	if len(list) == 0 || list[0] == "" {
+		base.Fatalf("go: module mode requires $GOPATH to hold the module cache")
+	}
+	gopath = list[0]
+	if _, err := os.Stat(filepath.Join(gopath, "go.mod")); err == nil {
+		base.Fatalf("$GOPATH/go.mod exists but should not")
+	}
+
+	cfg.ModulesEnabled = true
+	load.ModBinDir = BinDir
+	load.ModLookup = Lookup
+	load.ModPackageModuleInfo = PackageModuleInfo
+	load.ModImportPaths = ImportPaths
+
+	modfetch.PkgMod = filepath.Join(gopath, "pkg/mod")
+	if modRoot != "" {
+		modfetch.GoSumFile = filepath.Join(modRoot, "go.sum")
+	}
+}
+
+// Enabled reports whether modules are (or must be) enabled.
+// If modules must be enabled but are not, Enabled returns true
+// and then the first use of module information will call die
+// (usually through InitMod and ModRoot).
+func Enabled() bool {
+	if !initialized {
+		panic("go: Enabled called before Init")
+	}
+	return cfg.ModulesEnabled
+}
+
+// ModRoot returns the root of the main module.
+// It calls base.Fatalf if there is no main module.
+func ModRoot() string {
+	if !HasModRoot() {
+		die()
+	}
+	return modRoot
+}
+
+// HasModRoot reports whether a main module is present.
+func HasModRoot() bool {
+	Init()
+	return modRoot != ""
+}
+
+// ModFilePath returns the name of the main module's go.mod file,
+// or the empty string if there is no main module.
+func ModFilePath() string {
+	if !HasModRoot() {
+		return ""
+	}
+	return filepath.Join(modRoot, "go.mod")
+}
+
+func die() {
+	if os.Getenv("GO111MODULE") == "off" {
+		base.Fatalf("go: modules disabled by GO111MODULE=off; see 'go help modules'")
+	}
+	base.Fatalf("go: cannot find main module; see 'go help modules'")
+}
+
+// InitMod reads the main module's go.mod file, creating it first
+// when running 'go mod init'. It arranges for go.mod and go.sum
+// to be updated when the go command exits.
+func InitMod() {
+	if Init(); !Enabled() || modFile != nil {
+		return
+	}
+	if modRoot == "" {
+		die()
+	}
+
+	base.AtExit(WriteGoMod)
+
+	gomod := filepath.Join(modRoot, "go.mod")
+	if CmdModInit {
+		if _, err := os.Stat(gomod); err == nil {
+			base.Fatalf("go: %s already exists", gomod)
+		}
+		path := CmdModModule
+		if path == "" {
+			path = findModulePath(modRoot)
+		}
+		modFile = new(modfile.File)
+		modFile.AddModuleStmt(path)
+		fmt.Fprintf(os.Stderr, "go: creating new go.mod: module %s\n", path)
+		Target = modFile.Module.Mod
+		excluded = make(map[module.Version]bool)
+		return
+	}
+
+	data, err := ioutil.ReadFile(gomod)
+	if err != nil {
+		if os.IsNotExist(err) {
+			die()
+		}
+		base.Fatalf("go: %v", err)
+	}
+	f, err := modfile.Parse(gomod, data, nil)
+	if err != nil {
+		// Errors returned by modfile.Parse begin with file:line.
+		base.Fatalf("go: errors parsing go.mod:\n%s\n", err)
+	}
+	if f.Module == nil {
+		base.Fatalf("go: no module declaration in go.mod.\n\tTo specify the module path, add a line like this to go.mod:\n\tmodule example.com/mymodule")
+	}
+	modFile = f
+	modFileData = data
+	Target = f.Module.Mod
+	excluded = make(map[module.Version]bool)
+	for _, x := range f.Exclude {
+		excluded[x.Mod] = true
+	}
+}
+
+// findModuleRoot returns the nearest directory at or above dir
+// that contains a go.mod file, or "" if there is none.
+func findModuleRoot(dir string) string {
+	dir = filepath.Clean(dir)
+	for {
+		if fi, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil && !fi.IsDir() {
+			return dir
+		}
+		d := filepath.Dir(dir)
+		if d == dir {
+			break
+		}
+		dir = d
+	}
+	return ""
+}
+
+// findModulePath guesses the module path for a new go.mod in dir,
+// using the directory's location in $GOPATH/src if possible.
+func findModulePath(dir string) string {
+	for _, gpdir := range filepath.SplitList(cfg.BuildContext.GOPATH) {
+		if gpdir == "" {
+			continue
+		}
+		src := filepath.Join(gpdir, "src")
+		if str.HasFilePathPrefix(dir, src) && dir != src {
+			return filepath.ToSlash(dir[len(src)+1:])
+		}
+	}
+	base.Fatalf("go: cannot determine module path for source directory %s (outside GOPATH, no import comments)\n\n\tUse 'go mod init <module path>' to specify it.", dir)
+	panic("unreachable")
+}
+
+// BinDir returns the directory where 'go install' places binaries
+// in module mode.
+func BinDir() string {
+	if cfg.GOBIN != "" {
+		return cfg.GOBIN
+	}
+	return filepath.Join(gopath, "bin")
+}
+
+var buildList []module.Version
+
+// LoadBuildList loads and returns the build list from go.mod.
+// The loading of the build list happens automatically in ImportPaths:
+// LoadBuildList need only be called if ImportPaths is not
+// (typically in commands that care about the module but
+// no particular package).
+func LoadBuildList() []module.Version {
+	InitMod()
+	if buildList == nil {
+		list, err := mvs.BuildList(Target, Reqs())
+		if err != nil {
+			base.Fatalf("go: %v", err)
+		}
+		buildList = list
+	}
+	return buildList
+}
+
+// BuildList returns the module build list,
+// typically constructed by a previous call to
+// LoadBuildList or ImportPaths.
+// The caller must not modify the returned list.
+func BuildList() []module.Version {
+	return LoadBuildList()
+}
+
+// selected returns the version of the module path
+// selected in the build list, or "" if it is not in the build list.
+func selected(path string) string {
+	for _, m := range LoadBuildList() {
+		if m.Path == path {
+			return m.Version
+		}
+	}
+	return ""
+}
+
+// AddRequire adds a requirement on path at version vers to go.mod
+// and recomputes the build list. If the build list then selects a
+// different version of path, because some other module requires
+// a newer one, AddRequire restores the previous requirement
+// and returns an error.
+func AddRequire(path, vers string) error {
+	InitMod()
+	old := requirement(path)
+	modFile.AddRequire(path, vers)
+	resetBuildList()
+	if v := selected(path); v != vers {
+		restoreRequire(path, old)
+		return fmt.Errorf("%s@%s: build list selects %s, required by another module", path, vers, v)
+	}
+	return nil
+}
+
+// DropRequire removes the requirement on path from go.mod
+// and recomputes the build list. If path remains in the build list
+// because other modules require it, DropRequire restores the
+// previous requirement and returns an error.
+func DropRequire(path string) error {
+	InitMod()
+	old := requirement(path)
+	modFile.DropRequire(path)
+	resetBuildList()
+	if v := selected(path); v != "" {
+		restoreRequire(path, old)
+		return fmt.Errorf("%s@%s still required by other modules", path, v)
+	}
+	return nil
+}
+
+// requirement returns the version of path required in go.mod, or "".
+func requirement(path string) string {
+	for _, r := range modFile.Require {
+		if r.Mod.Path == path {
+			return r.Mod.Version
+		}
+	}
+	return ""
+}
+
+// restoreRequire resets the go.mod requirement on path to version old,
+// dropping it if old is empty.
+func restoreRequire(path, old string) {
+	if old == "" {
+		modFile.DropRequire(path)
+	} else {
+		modFile.AddRequire(path, old)
+	}
+	resetBuildList()
+}
+
+// resetBuildList discards the current build list and the lookup cache
+// after a change to the main module's requirements.
+func resetBuildList() {
+	buildList = nil
+	lookupCache = make(map[string]lookupResult)
+}
+
+var writingGoMod bool
+
+// WriteGoMod writes the current build list back to go.mod
+// and writes go.sum if there are new checksums to record.
+func WriteGoMod() {
+	if modFile == nil || writingGoMod {
+		return
+	}
+	writingGoMod = true
+	defer func() { writingGoMod = false }()
+
+	if buildList != nil {
+		indirect := make(map[string]bool)
+		var direct []string
+		for _, r := range modFile.Require {
+			if r.Indirect {
+				indirect[r.Mod.Path] = true
+			} else if selected(r.Mod.Path) != "" {
+				direct = append(direct, r.Mod.Path)
+			}
+		}
+		min, err := mvs.Req(Target, buildList, direct, Reqs())
+		if err != nil {
+			base.Fatalf("go: %v", err)
+		}
+		var list []*modfile.Require
+		for _, m := range min {
+			list = append(list, &modfile.Require{Mod: m, Indirect: indirect[m.Path]})
+		}
+		modFile.SetRequire(list)
+	}
+
+	data, err := modFile.Format()
+	if err != nil {
+		base.Fatalf("go: %v", err)
+	}
+	if !bytes.Equal(data, modFileData) {
+		if err := ioutil.WriteFile(filepath.Join(modRoot, "go.mod"), data, 0666); err != nil {
+			base.Fatalf("go: %v", err)
+		}
+		modFileData = data
+	}
+	modfetch.WriteGoSum()
+}
+
+// Replacement returns the replacement for mod, if any, from go.mod.
+// If there is no replacement for mod, Replacement returns
+// a module.Version with Path == "".
+func Replacement(mod module.Version) module.Version {
+	if modFile == nil {
+		return module.Version{}
+	}
+	var found *modfile.Replace
+	for _, r := range modFile.Replace {
+		if r.Old.Path == mod.Path && (r.Old.Version == "" || r.Old.Version == mod.Version) {
+			found = r // keep going
+		}
+	}
+	if found == nil {
+		return module.Version{}
+	}
+	return found.New
+}
+
+// replaceDir returns the absolute directory named by a
+// directory replacement in go.mod.
+func replaceDir(dir string) string {
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(modRoot, dir)
+	}
+	return dir
+}
+
+// isStandardImportPath reports whether path is in the standard library:
+// the first path element of every other import path contains a dot.
+func isStandardImportPath(path string) bool {
+	i := strings.Index(path, "/")
+	if i < 0 {
+		i = len(path)
+	}
+	return !strings.Contains(path[:i], ".")
+}
@@ -0,0 +1,471 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package modload
+
+import (
+	"errors"
+	"fmt"
+	"go/build"
+	"os"
+	pathpkg "path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"cmd/go/internal/base"
+	"cmd/go/internal/cfg"
+	"cmd/go/internal/load"
+	"cmd/go/internal/modfetch"
+	"cmd/go/internal/module"
+	"cmd/go/internal/str"
+)
+
+type lookupResult struct {
+	mod module.Version
+	dir string
+	err error
+}
+
+// lookupCache caches the results of Lookup for the current build list.
+var lookupCache = make(map[string]lookupResult)
+
+// usedModules records, for each module path that Lookup has used to
+// resolve a package, the version that was selected at the time.
+var usedModules = make(map[string]string)
+
+// Lookup returns the source directory and import path of the package
+// with the given import path, using the main module's build list.
+// If no module in the build list provides the package, Lookup looks
+// for the latest version of a module that does, adds it to the
+// requirements in go.mod, and recomputes the build list.
+func Lookup(path string) (dir, realPath string, err error) {
+	if isStandardImportPath(path) {
+		dir := filepath.Join(cfg.GOROOTsrc, path)
+		if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+			return "", "", fmt.Errorf("cannot find package %q in:\n\t%s", path, dir)
+		}
+		return dir, path, nil
+	}
+
+	if r, ok := lookupCache[path]; ok {
+		return r.dir, path, r.err
+	}
+	mod, dir, err := findPackage(path)
+	if err == errMissing {
+		mod, _, err = queryPackage(path, "latest", true)
+		if err == nil {
+			fmt.Fprintf(os.Stderr, "go: adding %s %s\n", mod.Path, mod.Version)
+			modFile.AddRequire(mod.Path, mod.Version)
+			resetBuildList()
+			checkUsedModules(mod)
+			mod, dir, err = findPackage(path)
+		}
+	}
+	if err == errMissing {
+		err = fmt.Errorf("cannot find module providing package %s", path)
+	}
+	if err == nil {
+		usedModules[mod.Path] = mod.Version
+	}
+	lookupCache[path] = lookupResult{mod, dir, err}
+	return dir, path, err
+}
+
+// checkUsedModules reports a fatal error if adding the requirement
+// on added changed the selected version of any module already used
+// to resolve packages. Those packages have been loaded from the old
+// version, so the build would otherwise mix versions.
+func checkUsedModules(added module.Version) {
+	for path, v := range usedModules {
+		if nv := selected(path); nv != v {
+			WriteGoMod()
+			base.Fatalf("go: adding %s %s changed selected version of %s from %s to %s; run the command again", added.Path, added.Version, path, v, nv)
+		}
+	}
+}
+
+var errMissing = errors.New("cannot find module providing package")
+
+// findPackage returns the module in the build list providing the
+// package with the given import path, along with the package directory.
+// It returns errMissing if no module provides the package.
+func findPackage(path string) (module.Version, string, error) {
+	var mods []module.Version
+	var dirs []string
+	for _, m := range LoadBuildList() {
+		if !str.HasPathPrefix(path, m.Path) {
+			continue
+		}
+		root, err := fetch(m)
+		if err != nil {
+			return module.Version{}, "", err
+		}
+		if dir, ok := dirInModule(path, m.Path, root); ok {
+			mods = append(mods, m)
+			dirs = append(dirs, dir)
+		}
+	}
+	switch len(mods) {
+	case 0:
+		return module.Version{}, "", errMissing
+	case 1:
+		return mods[0], dirs[0], nil
+	}
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "ambiguous import: found %s in multiple modules:", path)
+	for i, m := range mods {
+		fmt.Fprintf(&buf, "\n\t%s", m.Path)
+		if m.Version != "" {
+			fmt.Fprintf(&buf, " %s", m.Version)
+		}
+		fmt.Fprintf(&buf, " (%s)", dirs[i])
+	}
+	return module.Version{}, "", errors.New(buf.String())
+}
+
+// dirInModule locates the directory that would hold the package named by
+// the import path in the module with path mpath and root directory mdir.
+// It reports whether that directory exists, contains Go source files,
+// and is not part of a nested module.
+func dirInModule(path, mpath, mdir string) (dir string, ok bool) {
+	if path == mpath {
+		dir = mdir
+	} else {
+		dir = filepath.Join(mdir, filepath.FromSlash(path[len(mpath)+1:]))
+	}
+	if !hasGoFiles(dir) {
+		return dir, false
+	}
+	for d := dir; len(d) > len(mdir); d = filepath.Dir(d) {
+		if _, err := os.Stat(filepath.Join(d, "go.mod")); err == nil {
+			// Directory belongs to a nested module.
+			return dir, false
+		}
+	}
+	return dir, true
+}
+
+// hasGoFiles reports whether dir contains any .go files.
+func hasGoFiles(dir string) bool {
+	f, err := os.Open(dir)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	names, _ := f.Readdirnames(-1)
+	for _, name := range names {
+		if strings.HasSuffix(name, ".go") {
+			if fi, err := os.Stat(filepath.Join(dir, name)); err == nil && fi.Mode().IsRegular() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var fetchCache = make(map[module.Version]string)
+
+// fetch returns the root directory of the source tree for mod,
+// downloading it into the module cache if needed.
+func fetch(mod module.Version) (dir string, err error) {
+	if mod == Target {
+		return modRoot, nil
+	}
+	if dir, ok := fetchCache[mod]; ok {
+		return dir, nil
+	}
+	if r := Replacement(mod); r.Path != "" {
+		if r.Version == "" {
+			dir = replaceDir(r.Path)
+			if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+				return "", fmt.Errorf("replacement directory %s does not exist", r.Path)
+			}
+			fetchCache[mod] = dir
+			return dir, nil
+		}
+		mod = r
+	}
+	dir, err = modfetch.Download(mod)
+	if err != nil {
+		return "", err
+	}
+	fetchCache[mod] = dir
+	return dir, nil
+}
+
+// QueryPackage looks for a module providing the package or module
+// with the given path at the version given by query (see Query).
+// It tries the longest possible module path first.
+// The result isPkg reports whether the module's directory for path
+// contains a package; it is false when path names a module whose
+// root directory holds no Go files.
+func QueryPackage(path, query string) (mod module.Version, isPkg bool, err error) {
+	return queryPackage(path, query, false)
+}
+
+func queryPackage(path, query string, needPkg bool) (mod module.Version, isPkg bool, err error) {
+	var pathErr error
+	for p := path; p != "." && p != "/"; p = pathpkg.Dir(p) {
+		if module.CheckPath(p) != nil {
+			continue
+		}
+		info, err := Query(p, query, allowed)
+		if err != nil {
+			if p == path {
+				pathErr = err
+			}
+			continue
+		}
+		m := module.Version{Path: p, Version: info.Version}
+		root, err := fetch(m)
+		if err != nil {
+			return module.Version{}, false, err
+		}
+		if _, ok := dirInModule(path, m.Path, root); ok {
+			return m, true, nil
+		}
+		if p == path && !needPkg {
+			return m, false, nil
+		}
+	}
+	if pathErr != nil && !needPkg {
+		return module.Version{}, false, pathErr
+	}
+	return module.Version{}, false, errMissing
+}
+
+// allowed reports whether m may be used: it must not be excluded in go.mod.
+func allowed(m module.Version) bool {
+	return !excluded[m]
+}
+
+// ImportPaths returns the import paths named by the command line
+// arguments, expanding patterns against the main module, the
+// other modules in the build list, and the standard library.
+func ImportPaths(args []string) []string {
+	InitMod()
+	LoadBuildList()
+
+	if len(args) == 0 {
+		args = []string{"."}
+	}
+	var out []string
+	for _, a := range args {
+		// Arguments are supposed to be import paths, but
+		// as a courtesy to Windows developers, rewrite \ to /
+		// in command-line arguments. Handles .\... and so on.
+		if filepath.Separator == '\\' {
+			a = strings.Replace(a, `\`, `/`, -1)
+		}
+
+		switch {
+		case build.IsLocalImport(a) || filepath.IsAbs(a):
+			dir := filepath.Clean(a)
+			if !filepath.IsAbs(dir) {
+				dir = filepath.Join(cwd, dir)
+			}
+			if strings.Contains(a, "...") {
+				i := strings.Index(dir, "...")
+				pkgs := matchLocal(dir[:i], load.MatchPattern(filepath.ToSlash(dir)))
+				if len(pkgs) == 0 {
+					fmt.Fprintf(os.Stderr, "warning: %q matched no packages\n", a)
+				}
+				out = append(out, pkgs...)
+				continue
+			}
+			pkg := dirImportPath(dir)
+			if pkg == "" {
+				base.Errorf("go: directory %s outside available modules", base.ShortPath(dir))
+				continue
+			}
+			out = append(out, pkg)
+
+		case a == "all":
+			out = append(out, allPackages()...)
+
+		case a == "std" || a == "cmd":
+			out = append(out, load.MatchPackages(a)...)
+
+		case strings.Contains(a, "..."):
+			pkgs := matchPackages(a)
+			if len(pkgs) == 0 {
+				fmt.Fprintf(os.Stderr, "warning: %q matched no packages\n", a)
+			}
+			out = append(out, pkgs...)
+
+		default:
+			out = append(out, pathpkg.Clean(a))
+		}
+	}
+	base.ExitIfErrors()
+	return out
+}
+
+// dirImportPath returns the import path of the package in the
+// absolute directory dir, or "" if dir is outside the main module
+// and the standard library.
+func dirImportPath(dir string) string {
+	if dir == modRoot {
+		return Target.Path
+	}
+	if str.HasFilePathPrefix(dir, modRoot) {
+		return pathpkg.Join(Target.Path, filepath.ToSlash(dir[len(modRoot)+1:]))
+	}
+	if str.HasFilePathPrefix(dir, cfg.GOROOTsrc) && dir != cfg.GOROOTsrc {
+		return filepath.ToSlash(dir[len(cfg.GOROOTsrc)+1:])
+	}
+	return ""
+}
+
+// walkModule calls fn for the import path of each package in the tree
+// rooted at dir, which belongs to the module with path mpath.
+// It skips the same directories as GOPATH-mode pattern matching,
+// along with vendor directories and nested modules.
+func walkModule(mpath, root, dir string, fn func(importPath string)) {
+	filepath.Walk(dir, func(path string, fi os.FileInfo, err error) error {
+		if err != nil || !fi.IsDir() {
+			return nil
+		}
+		if path != root {
+			elem := fi.Name()
+			if strings.HasPrefix(elem, ".") || strings.HasPrefix(elem, "_") || elem == "testdata" || elem == "vendor" {
+				return filepath.SkipDir
+			}
+			if _, err := os.Stat(filepath.Join(path, "go.mod")); err == nil {
+				// Nested module.
+				return filepath.SkipDir
+			}
+		}
+		if !hasGoFiles(path) {
+			return nil
+		}
+		if path == root {
+			fn(mpath)
+		} else {
+			fn(pathpkg.Join(mpath, filepath.ToSlash(path[len(root)+1:])))
+		}
+		return nil
+	})
+}
+
+// matchLocal returns the import paths of packages in the main module
+// in or below the directory prefix dir whose file system paths match.
+func matchLocal(dir string, match func(string) bool) []string {
+	dir = filepath.Clean(dir)
+	if !str.HasFilePathPrefix(dir, modRoot) {
+		if str.HasFilePathPrefix(modRoot, dir) {
+			dir = modRoot
+		} else {
+			return nil
+		}
+	}
+	var pkgs []string
+	walkModule(Target.Path, modRoot, dir, func(importPath string) {
+		rel := strings.TrimPrefix(strings.TrimPrefix(importPath, Target.Path), "/")
+		if match(filepath.ToSlash(filepath.Join(modRoot, rel))) {
+			pkgs = append(pkgs, importPath)
+		}
+	})
+	return pkgs
+}
+
+// matchPackages returns the import paths of packages matching the
+// pattern in the modules of the build list and in the standard library.
+func matchPackages(pattern string) []string {
+	match := load.MatchPattern(pattern)
+	prefix := pattern[:strings.Index(pattern, "...")]
+	var pkgs []string
+	if isStandardImportPath(pattern) {
+		for _, p := range load.MatchPackages("std") {
+			if match(p) {
+				pkgs = append(pkgs, p)
+			}
+		}
+	}
+	for _, m := range LoadBuildList() {
+		if !strings.HasPrefix(m.Path, prefix) && !strings.HasPrefix(prefix, m.Path) {
+			continue
+		}
+		root, err := fetch(m)
+		if err != nil {
+			base.Errorf("go: %v", err)
+			continue
+		}
+		walkModule(m.Path, root, root, func(importPath string) {
+			if match(importPath) {
+				pkgs = append(pkgs, importPath)
+			}
+		})
+	}
+	return pkgs
+}
+
+// allPackages returns the packages in the main module
+// and, recursively, all the packages they import,
+// including imports needed only by tests of main module packages.
+func allPackages() []string {
+	var roots []string
+	walkModule(Target.Path, modRoot, modRoot, func(importPath string) {
+		roots = append(roots, importPath)
+	})
+
+	type item struct {
+		path  string
+		dir   string // importing directory, for std vendor resolution
+		tests bool
+	}
+	seen := make(map[string]bool)
+	var queue []item
+	for _, p := range roots {
+		seen[p] = true
+		queue = append(queue, item{path: p, tests: true})
+	}
+	for len(queue) > 0 {
+		it := queue[0]
+		queue = queue[1:]
+
+		var bp *build.Package
+		var err error
+		if isStandardImportPath(it.path) {
+			bp, err = cfg.BuildContext.Import(it.path, it.dir, 0)
+		} else {
+			var dir string
+			dir, _, err = Lookup(it.path)
+			if err == nil {
+				bp, err = cfg.BuildContext.ImportDir(dir, 0)
+			}
+		}
+		if err != nil {
+			// The error is reported when the package itself is loaded.
+			continue
+		}
+		imports := bp.Imports
+		if it.tests {
+			imports = append(append(imports[:len(imports):len(imports)], bp.TestImports...), bp.XTestImports...)
+		}
+		for _, imp := range imports {
+			if imp == "C" {
+				continue
+			}
+			if isStandardImportPath(imp) && isStandardImportPath(it.path) {
+				// Resolve vendored standard library imports.
+				if dep, err := cfg.BuildContext.Import(imp, bp.Dir, build.FindOnly); err == nil {
+					imp = dep.ImportPath
+				}
+			}
+			if !seen[imp] {
+				seen[imp] = true
+				queue = append(queue, item{path: imp, dir: bp.Dir})
+			}
+		}
+	}
+
+	var all []string
+	for p := range seen {
+		all = append(all, p)
+	}
+	sort.Strings(all)
+	return all
+}
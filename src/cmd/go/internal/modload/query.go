@@ -0,0 +1,74 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package modload
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"cmd/go/internal/modfetch"
+	"cmd/go/internal/module"
+	"cmd/go/internal/semver"
+)
+
+// Query looks up a revision of a given module given a version query string.
+// The module must be a complete module path.
+// The version must take one of the following forms:
+//
+//	- the literal string "latest", denoting the latest available,
+//	  allowed release version, or the latest pre-release version
+//	  if there are no releases;
+//	- v1.2.3, a semantic version string denoting that revision;
+//	- v1 or v1.2, an abbreviated semantic version string denoting
+//	  the latest available, allowed release with that prefix.
+//
+// If the allowed function is non-nil, Query excludes any versions
+// for which allowed returns false.
+func Query(path, query string, allowed func(module.Version) bool) (*modfetch.RevInfo, error) {
+	if allowed == nil {
+		allowed = func(module.Version) bool { return true }
+	}
+	ok := func(v string) bool {
+		return allowed(module.Version{Path: path, Version: v})
+	}
+
+	var prefix string
+	switch {
+	case query == "latest":
+		fmt.Fprintf(os.Stderr, "go: finding %s latest\n", path)
+	case semver.IsValid(query) && semver.Canonical(query) == strings.TrimSuffix(query, "+incompatible"):
+		if !ok(query) {
+			return nil, fmt.Errorf("%s@%s excluded", path, query)
+		}
+		if err := module.Check(path, query); err != nil {
+			return nil, err
+		}
+		return modfetch.Stat(path, query)
+	case semver.IsValid(query) && semver.Build(query) == "" && semver.Prerelease(query) == "":
+		prefix = query + "."
+	default:
+		return nil, fmt.Errorf("invalid module version query %q", query)
+	}
+
+	versions, err := modfetch.Versions(path)
+	if err != nil {
+		return nil, err
+	}
+	// Prefer the latest release; fall back to the latest pre-release.
+	for _, release := range []bool{true, false} {
+		for i := len(versions) - 1; i >= 0; i-- {
+			v := versions[i]
+			if release != (semver.Prerelease(v) == "") || !strings.HasPrefix(v, prefix) || !ok(v) {
+				continue
+			}
+			if module.Check(path, v) != nil {
+				continue
+			}
+			return modfetch.Stat(path, v)
+		}
+	}
+	return nil, fmt.Errorf("no matching versions for query %q", query)
+}
@@ -0,0 +1,137 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package modload
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"cmd/go/internal/modfetch"
+	"cmd/go/internal/modfile"
+	"cmd/go/internal/module"
+	"cmd/go/internal/mvs"
+	"cmd/go/internal/semver"
+)
+
+// mvsReqs implements mvs.Reqs for module semantic versions,
+// with any exclusions or replacements applied internally.
+type mvsReqs struct {
+	cache map[module.Version][]module.Version
+}
+
+var reqsCache = &mvsReqs{cache: make(map[module.Version][]module.Version)}
+
+// Reqs returns the module requirement graph rooted at the main module.
+// The requirements of the main module are read from go.mod on each call
+// to Required, so changes made by AddRequire and DropRequire are visible.
+func Reqs() mvs.Reqs {
+	return reqsCache
+}
+
+func (r *mvsReqs) Required(mod module.Version) ([]module.Version, error) {
+	if mod == Target {
+		var list []module.Version
+		for _, req := range modFile.Require {
+			list = append(list, req.Mod)
+		}
+		return list, nil
+	}
+	if list, ok := r.cache[mod]; ok {
+		return list, nil
+	}
+
+	list, err := r.required(mod)
+	if err != nil {
+		return nil, err
+	}
+	for i, mv := range list {
+		for excluded[mv] {
+			mv1, err := r.next(mv)
+			if err != nil {
+				return nil, err
+			}
+			if mv1.Version == "none" {
+				return nil, fmt.Errorf("%s(%s) depends on excluded %s(%s) with no newer version available", mod.Path, mod.Version, mv.Path, mv.Version)
+			}
+			mv = mv1
+		}
+		list[i] = mv
+	}
+	r.cache[mod] = list
+	return list, nil
+}
+
+// required returns the requirements listed in the go.mod file of mod,
+// taking replacements into account.
+func (r *mvsReqs) required(mod module.Version) ([]module.Version, error) {
+	var data []byte
+	var err error
+	origPath := mod.Path
+	if repl := Replacement(mod); repl.Path != "" {
+		if repl.Version == "" {
+			dir := replaceDir(repl.Path)
+			gomod := filepath.Join(dir, "go.mod")
+			data, err = ioutil.ReadFile(gomod)
+			if os.IsNotExist(err) {
+				// A local directory without go.mod has no requirements.
+				return nil, nil
+			}
+			if err != nil {
+				return nil, fmt.Errorf("parsing %s: %v", gomod, err)
+			}
+		} else {
+			data, err = modfetch.GoMod(repl.Path, repl.Version)
+			if err != nil {
+				return nil, err
+			}
+		}
+		mod = repl
+	} else {
+		data, err = modfetch.GoMod(mod.Path, mod.Version)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := modfile.Parse("go.mod", data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("parsing go.mod: %v", err)
+	}
+	if f.Module == nil {
+		return nil, fmt.Errorf("parsing go.mod: missing module line")
+	}
+	if mpath := f.Module.Mod.Path; mpath != origPath && mpath != mod.Path {
+		return nil, fmt.Errorf("parsing go.mod: unexpected module path %q", mpath)
+	}
+	var list []module.Version
+	for _, req := range f.Require {
+		list = append(list, req.Mod)
+	}
+	return list, nil
+}
+
+func (*mvsReqs) Max(v1, v2 string) string {
+	if v1 != "" && semver.Compare(v1, v2) == -1 {
+		return v2
+	}
+	return v1
+}
+
+// next returns the next version of m after m.Version,
+// or the version "none" if there is none.
+func (*mvsReqs) next(m module.Version) (module.Version, error) {
+	list, err := modfetch.Versions(m.Path)
+	if err != nil {
+		return module.Version{}, err
+	}
+	for _, v := range list {
+		if semver.Compare(v, m.Version) > 0 {
+			return module.Version{Path: m.Path, Version: v}, nil
+		}
+	}
+	return module.Version{Path: m.Path, Version: "none"}, nil
+}
@@ -0,0 +1,217 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package mvs implements Minimal Version Selection.
+// See https://research.swtch.com/vgo-mvs.
+package mvs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"cmd/go/internal/module"
+)
+
+// A Reqs is the requirement graph on which Minimal Version Selection (MVS) operates.
+//
+// The version strings are opaque except for the special version "none"
+// (see the documentation for module.Version). In particular, MVS does not
+// assume that the version strings are semantic versions; instead, the Max method
+// gives access to the comparison operation.
+type Reqs interface {
+	// Required returns the module versions explicitly required by m itself.
+	// The caller must not modify the returned list.
+	Required(m module.Version) ([]module.Version, error)
+
+	// Max returns the maximum of v1 and v2 (it returns either v1 or v2).
+	//
+	// For all versions v, Max(v, "none") must be v,
+	// and for the target passed as the first argument to MVS functions,
+	// Max(target, v) must be target.
+	//
+	// Note that v1 < v2 can be written Max(v1, v2) != v1
+	// and similarly v1 <= v2 can be written Max(v1, v2) == v2.
+	Max(v1, v2 string) string
+}
+
+// A BuildListError reports an error loading the requirements
+// of a module encountered while computing a build list.
+type BuildListError struct {
+	Err   error
+	Stack []module.Version
+}
+
+func (e *BuildListError) Error() string {
+	var b strings.Builder
+	for _, m := range e.Stack[:len(e.Stack)-1] {
+		fmt.Fprintf(&b, "%s ->\n\t", m)
+	}
+	fmt.Fprintf(&b, "%s: %v", e.Stack[len(e.Stack)-1], e.Err)
+	return b.String()
+}
+
+// BuildList returns the build list for the target module.
+// The first element is the target itself, followed by the other
+// modules in the build list, sorted by path.
+func BuildList(target module.Version, reqs Reqs) ([]module.Version, error) {
+	return buildList(target, reqs, nil)
+}
+
+func buildList(target module.Version, reqs Reqs, upgrade []module.Version) ([]module.Version, error) {
+	type node struct {
+		required []module.Version
+	}
+
+	// Explore the requirement graph, recording for each module path
+	// the maximum version that anything in the graph requires.
+	graph := map[module.Version]*node{target: nil}
+	min := map[string]string{target.Path: target.Version}
+	queue := []module.Version{target}
+	parent := map[module.Version]module.Version{}
+	for len(queue) > 0 {
+		m := queue[0]
+		queue = queue[1:]
+		var required []module.Version
+		if m.Version != "none" {
+			var err error
+			required, err = reqs.Required(m)
+			if err != nil {
+				stack := []module.Version{m}
+				for p, ok := parent[m]; ok; p, ok = parent[p] {
+					stack = append([]module.Version{p}, stack...)
+				}
+				return nil, &BuildListError{Err: err, Stack: stack}
+			}
+		}
+		if m == target {
+			required = append(required[:len(required):len(required)], upgrade...)
+		}
+		graph[m] = &node{required: required}
+		for _, r := range required {
+			if v, ok := min[r.Path]; !ok || reqs.Max(v, r.Version) != v {
+				min[r.Path] = r.Version
+			}
+			if _, ok := graph[r]; !ok {
+				graph[r] = nil
+				parent[r] = m
+				queue = append(queue, r)
+			}
+		}
+	}
+
+	// Construct the list by traversing the graph again,
+	// following only the selected version of each module.
+	list := []module.Version{target}
+	listed := map[string]bool{target.Path: true}
+	for i := 0; i < len(list); i++ {
+		for _, r := range graph[list[i]].required {
+			v := min[r.Path]
+			if r.Path != target.Path && reqs.Max(v, r.Version) != v {
+				panic(fmt.Sprintf("mistake: version %q does not satisfy requirement %+v", v, r)) // TODO: Don't panic.
+			}
+			if !listed[r.Path] && v != "none" {
+				list = append(list, module.Version{Path: r.Path, Version: v})
+				listed[r.Path] = true
+			}
+		}
+	}
+
+	tail := list[1:]
+	sort.Slice(tail, func(i, j int) bool {
+		return tail[i].Path < tail[j].Path
+	})
+	return list, nil
+}
+
+// Req returns the minimal requirement list for the target module
+// that results in the given build list, with the constraint that all
+// module paths listed in base must appear in the returned list.
+func Req(target module.Version, list []module.Version, base []string, reqs Reqs) ([]module.Version, error) {
+	// Note: Not running in parallel because we assume
+	// that list came from a previous operation that paged
+	// in all the requirements, so there's no I/O to overlap now.
+
+	// Compute postorder, cache requirements.
+	var postorder []module.Version
+	reqCache := map[module.Version][]module.Version{}
+	reqCache[target] = nil
+	var walk func(module.Version) error
+	walk = func(m module.Version) error {
+		if _, ok := reqCache[m]; ok {
+			return nil
+		}
+		var required []module.Version
+		if m.Version != "none" {
+			var err error
+			required, err = reqs.Required(m)
+			if err != nil {
+				return err
+			}
+		}
+		reqCache[m] = required
+		for _, m1 := range required {
+			if err := walk(m1); err != nil {
+				return err
+			}
+		}
+		postorder = append(postorder, m)
+		return nil
+	}
+	for _, m := range list {
+		if err := walk(m); err != nil {
+			return nil, err
+		}
+	}
+
+	// Walk modules in reverse post-order, only adding those not implied already.
+	have := map[string]string{}
+	walk = func(m module.Version) error {
+		if v, ok := have[m.Path]; ok && reqs.Max(m.Version, v) == v {
+			return nil
+		}
+		have[m.Path] = m.Version
+		for _, m1 := range reqCache[m] {
+			walk(m1)
+		}
+		return nil
+	}
+	max := map[string]string{}
+	for _, m := range list {
+		if v, ok := max[m.Path]; ok {
+			max[m.Path] = reqs.Max(m.Version, v)
+		} else {
+			max[m.Path] = m.Version
+		}
+	}
+	// First walk the base modules that must be listed.
+	var min []module.Version
+	for _, path := range base {
+		m := module.Version{Path: path, Version: max[path]}
+		min = append(min, m)
+		walk(m)
+	}
+	// Now the reverse postorder to bring in anything else.
+	for i := len(postorder) - 1; i >= 0; i-- {
+		m := postorder[i]
+		if max[m.Path] != m.Version {
+			// Older version.
+			continue
+		}
+		if _, ok := have[m.Path]; !ok {
+			min = append(min, m)
+			walk(m)
+		}
+	}
+	sort.Slice(min, func(i, j int) bool {
+		return min[i].Path < min[j].Path
+	})
+	return min, nil
+}
+
+// Upgrade returns a build list for the target module
+// in which the given additional modules are upgraded.
+func Upgrade(target module.Version, reqs Reqs, upgrade ...module.Version) ([]module.Version, error) {
+	return buildList(target, reqs, upgrade)
+}
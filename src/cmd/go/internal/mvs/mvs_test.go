@@ -0,0 +1,192 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mvs
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"cmd/go/internal/module"
+)
+
+// reqsMap implements Reqs for a graph described as text.
+// Each entry maps "path version" to the list of its requirements.
+// Versions are compared as strings.
+type reqsMap map[module.Version][]module.Version
+
+func (r reqsMap) Max(v1, v2 string) string {
+	if v1 == "none" || v2 == "" {
+		return v2
+	}
+	if v2 == "none" || v1 == "" {
+		return v1
+	}
+	if v1 < v2 {
+		return v2
+	}
+	return v1
+}
+
+func (r reqsMap) Required(m module.Version) ([]module.Version, error) {
+	rr, ok := r[m]
+	if !ok {
+		return nil, fmt.Errorf("missing module: %v", m)
+	}
+	return rr, nil
+}
+
+// parseGraph parses lines of the form "A1: B1 C2",
+// where each name is a single-letter path followed by a version.
+func parseGraph(t *testing.T, text string) reqsMap {
+	reqs := reqsMap{}
+	mv := func(s string) module.Version {
+		return module.Version{Path: s[:1], Version: s[1:]}
+	}
+	for _, line := range strings.Split(strings.TrimSpace(text), "\n") {
+		f := strings.Fields(line)
+		if len(f) == 0 || !strings.HasSuffix(f[0], ":") {
+			t.Fatalf("bad graph line %q", line)
+		}
+		m := mv(strings.TrimSuffix(f[0], ":"))
+		var list []module.Version
+		for _, s := range f[1:] {
+			list = append(list, mv(s))
+		}
+		reqs[m] = list
+	}
+	return reqs
+}
+
+func fmtList(list []module.Version) string {
+	var s []string
+	for _, m := range list {
+		s = append(s, m.Path+m.Version)
+	}
+	return strings.Join(s, " ")
+}
+
+var buildListTests = []struct {
+	name  string
+	graph string
+	list  string
+	req   string
+}{
+	{
+		name: "blog",
+		graph: `
+			A: B1 C2
+			B1: D3
+			C1: D2
+			C2: D4
+			C3: D5
+			C4: G1
+			D2: E1
+			D3: E2
+			D4: E2 F1
+			D5: E2
+			G1: C4
+			E1:
+			E2:
+			F1:
+		`,
+		list: "A B1 C2 D4 E2 F1",
+		req:  "B1 C2",
+	},
+	{
+		name: "trim",
+		graph: `
+			A: B1 C2
+			B1: D3
+			C2: B2
+			B2:
+			D3:
+		`,
+		list: "A B2 C2",
+		req:  "C2",
+	},
+	{
+		name: "cycle",
+		graph: `
+			A: B1
+			B1: C1
+			C1: B2
+			B2: C1
+		`,
+		list: "A B2 C1",
+		req:  "B2",
+	},
+	{
+		name: "none",
+		graph: `
+			A: Bnone C1
+			C1:
+		`,
+		list: "A C1",
+		req:  "C1",
+	},
+}
+
+func TestBuildList(t *testing.T) {
+	for _, tt := range buildListTests {
+		t.Run(tt.name, func(t *testing.T) {
+			reqs := parseGraph(t, tt.graph)
+			target := module.Version{Path: "A"}
+			list, err := BuildList(target, reqs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if have := fmtList(list); have != tt.list {
+				t.Errorf("BuildList = %s, want %s", have, tt.list)
+			}
+			req, err := Req(target, list, nil, reqs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if have := fmtList(req); have != tt.req {
+				t.Errorf("Req = %s, want %s", have, tt.req)
+			}
+		})
+	}
+}
+
+func TestUpgrade(t *testing.T) {
+	reqs := parseGraph(t, `
+		A: B1 C1
+		B1:
+		B2: D1
+		C1:
+		D1:
+	`)
+	target := module.Version{Path: "A"}
+	list, err := Upgrade(target, reqs, module.Version{Path: "B", Version: "2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have, want := fmtList(list), "A B2 C1 D1"; have != want {
+		t.Errorf("Upgrade = %s, want %s", have, want)
+	}
+}
+
+func TestBuildListError(t *testing.T) {
+	reqs := parseGraph(t, `
+		A: B1
+		B1: C1
+	`)
+	_, err := BuildList(module.Version{Path: "A"}, reqs)
+	var e *BuildListError
+	if err == nil || !errors.As(err, &e) {
+		t.Fatalf("BuildList = %v, want *BuildListError", err)
+	}
+	want := []module.Version{{Path: "A"}, {Path: "B", Version: "1"}, {Path: "C", Version: "1"}}
+	if !reflect.DeepEqual(e.Stack, want) {
+		t.Errorf("error stack = %v, want %v", e.Stack, want)
+	}
+	if have, want := err.Error(), "A ->\n\tB@1 ->\n\tC@1: missing module: C@1"; have != want {
+		t.Errorf("err.Error() = %q, want %q", have, want)
+	}
+}
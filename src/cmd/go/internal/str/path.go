@@ -9,6 +9,23 @@ import (
 	"strings"
 )
 
+// HasPathPrefix reports whether the slash-separated path s
+// begins with the elements in prefix.
+func HasPathPrefix(s, prefix string) bool {
+	if len(s) == len(prefix) {
+		return s == prefix
+	}
+	if prefix == "" {
+		return true
+	}
+	if len(s) > len(prefix) {
+		if prefix[len(prefix)-1] == '/' || s[len(prefix)] == '/' {
+			return s[:len(prefix)] == prefix
+		}
+	}
+	return false
+}
+
 // HasFilePathPrefix reports whether the filesystem path s begins with the
 // elements in prefix.
 func HasFilePathPrefix(s, prefix string) bool {
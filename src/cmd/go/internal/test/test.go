@@ -63,8 +63,8 @@ followed by detailed output for each failed package.
 
 'Go test' recompiles each package along with any files with names matching
 the file pattern "*_test.go".
-These additional files can contain test functions, benchmark functions, and
-example functions. See 'go help testfunc' for more.
+These additional files can contain test functions, benchmark functions, fuzz
+tests and example functions. See 'go help testfunc' for more.
 Each listed package causes the execution of a separate test binary.
 Files whose names begin with "_" (including "_test.go") or "." are ignored.
 
@@ -253,6 +253,36 @@ const testFlag2 = `
 	-failfast
 	    Do not start new tests after the first test failure.
 
+	-fuzz regexp
+	    Run the fuzz test matching the regular expression. When specified,
+	    the command line argument must match exactly one package, and
+	    regexp must match exactly one fuzz test within that package.
+	    Fuzzing will occur after tests, benchmarks, seed corpora of other
+	    fuzz tests, and examples have completed; benchmarks are not run.
+	    The package is built with coverage instrumentation, which guides
+	    the generation of new inputs. See the Fuzzing section of the
+	    testing package documentation for details.
+
+	    The instrumentation is the same source rewriting used by -cover,
+	    so it has the same limitations. Only the package under test, or
+	    the packages named by -coverpkg, are instrumented; code in other
+	    packages, including the standard library, does not guide fuzzing.
+	    Line numbers reported by panics and by the runtime in the
+	    instrumented packages may differ from those in the original
+	    source files.
+
+	-fuzzminimizetime t
+	    Spend at most t minimizing a failing input found while fuzzing,
+	    specified as a time.Duration (for example, -fuzzminimizetime 30s).
+	    The default is 60s. A value of 0 disables minimization.
+
+	-fuzztime t
+	    Run enough iterations of the fuzz target during fuzzing to take t,
+	    specified as a time.Duration (for example, -fuzztime 1h30s).
+	    The default is to run forever.
+	    The special syntax Nx means to run the fuzz target N times
+	    (for example, -fuzztime 1000x).
+
 	-list regexp
 	    List tests, benchmarks, or examples matching the regular expression.
 	    No tests, benchmarks or examples will be run. This will only
@@ -434,6 +464,10 @@ A benchmark function is one named BenchmarkXxx and should have the signature,
 
 	func BenchmarkXxx(b *testing.B) { ... }
 
+A fuzz test is one named FuzzXxx and should have the signature,
+
+	func FuzzXxx(f *testing.F) { ... }
+
 An example function is similar to a test function but, instead of using
 *testing.T to report success or failure, prints output to os.Stdout.
 If the last comment in the function starts with "Output:" then the output
@@ -495,6 +529,7 @@ var (
 	testTimeout      string          // -timeout flag
 	testArgs         []string
 	testBench        bool
+	testFuzz         string // -fuzz flag
 	testList         bool
 	testShowPass     bool   // show passing output
 	testVetList      string // -vet flag
@@ -519,19 +554,19 @@ var testVetFlags = []string{
 	// "-asmdecl",
 	// "-assign",
 	"-atomic",
-	"-bool",
-	"-buildtags",
+	"-bools",
+	"-buildtag",
 	// "-cgocall",
 	// "-composites",
 	// "-copylocks",
 	// "-httpresponse",
+	// "-loopclosure",
 	// "-lostcancel",
-	// "-methods",
 	"-nilfunc",
 	"-printf",
-	// "-rangeloops",
 	// "-shift",
-	// "-structtags",
+	// "-stdmethods",
+	// "-structtag",
 	// "-tests",
 	// "-unreachable",
 	// "-unsafeptr",
@@ -560,6 +595,9 @@ func runTest(cmd *base.Command, args []string) {
 	if testProfile != "" && len(pkgs) != 1 {
 		base.Fatalf("cannot use %s flag with multiple packages", testProfile)
 	}
+	if testFuzz != "" && len(pkgs) != 1 {
+		base.Fatalf("cannot use -fuzz flag with multiple packages")
+	}
 	initCoverProfile()
 	defer closeCoverProfile()
 
@@ -573,6 +611,10 @@ func runTest(cmd *base.Command, args []string) {
 		// An explicit zero disables the test timeout.
 		// Let it have one century (almost) before we kill it.
 		testKillTimeout = 100 * 365 * 24 * time.Hour
+	} else if testFuzz != "" && testTimeout == "" {
+		// Fuzzing runs until it finds a failure or -fuzztime expires,
+		// so without an explicit timeout there is no point killing it.
+		testKillTimeout = 100 * 365 * 24 * time.Hour
 	}
 
 	// show passing test output (after buffering) with -v flag.
@@ -707,7 +749,7 @@ func runTest(cmd *base.Command, args []string) {
 	// Prepare build + run + print actions for all packages being tested.
 	for _, p := range pkgs {
 		// sync/atomic import is inserted by the cover tool. See #18486
-		if testCover && testCoverMode == "atomic" {
+		if (testCover || testFuzz != "") && testCoverMode == "atomic" {
 			ensureImport(p, "sync/atomic")
 		}
 
@@ -796,7 +838,9 @@ func builderTest(b *work.Builder, p *load.Package) (buildAction, runAction, prin
 	//	pmain - pkg.test binary
 	var ptest, pxtest, pmain *load.Package
 
-	localCover := testCover && testCoverPaths == nil
+	// Fuzzing is guided by the coverage of the package under test,
+	// so -fuzz implies local coverage instrumentation.
+	localCover := (testCover || testFuzz != "") && testCoverPaths == nil
 
 	ptest, pxtest, err = load.TestPackagesFor(p, localCover || p.Name == "main")
 	if err != nil {
@@ -1165,10 +1209,10 @@ func (c *runCache) builderRunTest(b *work.Builder, a *work.Action) error {
 	}
 
 	var buf bytes.Buffer
-	if len(pkgArgs) == 0 || testBench {
+	if len(pkgArgs) == 0 || testBench || testFuzz != "" {
 		// Stream test output (no buffering) when no package has
 		// been given on the command line (implicit current directory)
-		// or when benchmarking.
+		// or when benchmarking or fuzzing.
 		// No change to stdout.
 	} else {
 		// If we're only running a single package under test or if parallelism is
@@ -1220,7 +1264,15 @@ func (c *runCache) builderRunTest(b *work.Builder, a *work.Action) error {
 	if !c.disableCache && len(execCmd) == 0 {
 		testlogArg = []string{"-test.testlogfile=" + a.Objdir + "testlog.txt"}
 	}
-	args := str.StringList(execCmd, a.Deps[0].BuiltTarget(), testlogArg, testArgs)
+	fuzzArg := []string{}
+	if testFuzz != "" {
+		// Keep the inputs found while fuzzing in the build cache,
+		// so that later runs can start from them.
+		if dir := cache.DefaultDir(); dir != "off" {
+			fuzzArg = []string{"-test.fuzzcachedir=" + filepath.Join(dir, "fuzz", a.Package.ImportPath)}
+		}
+	}
+	args := str.StringList(execCmd, a.Deps[0].BuiltTarget(), testlogArg, fuzzArg, testArgs)
 
 	if testCoverProfile != "" {
 		// Write coverage to temporary profile, for merging later.
@@ -1817,6 +1869,7 @@ func writeTestmain(out string, t *testFuncs) error {
 type testFuncs struct {
 	Tests       []testFunc
 	Benchmarks  []testFunc
+	FuzzTargets []testFunc
 	Examples    []testFunc
 	TestMain    *testFunc
 	Package     *load.Package
@@ -1835,6 +1888,16 @@ func (t *testFuncs) CoverEnabled() bool {
 	return testCover
 }
 
+// CoverInstrumented reports whether the tested packages are compiled
+// with coverage counters, either for -cover or to guide -fuzz.
+func (t *testFuncs) CoverInstrumented() bool {
+	return testCover || testFuzz != ""
+}
+
+func (t *testFuncs) FuzzEnabled() bool {
+	return testFuzz != ""
+}
+
 // ImportPath returns the import path of the package being tested, if it is within GOPATH.
 // This is printed by the testing package when running benchmarks.
 func (t *testFuncs) ImportPath() string {
@@ -1917,6 +1980,13 @@ func (t *testFuncs) load(filename, pkg string, doImport, seen *bool) error {
 			}
 			t.Benchmarks = append(t.Benchmarks, testFunc{pkg, name, "", false})
 			*doImport, *seen = true, true
+		case isTest(name, "Fuzz"):
+			err := checkTestFunc(n, "F")
+			if err != nil {
+				return err
+			}
+			t.FuzzTargets = append(t.FuzzTargets, testFunc{pkg, name, "", false})
+			*doImport, *seen = true, true
 		}
 	}
 	ex := doc.Examples(f)
@@ -1975,6 +2045,12 @@ var benchmarks = []testing.InternalBenchmark{
 {{end}}
 }
 
+var fuzzTargets = []testing.InternalFuzzTarget{
+{{range .FuzzTargets}}
+	{"{{.Name}}", {{.Package}}.{{.Name}}},
+{{end}}
+}
+
 var examples = []testing.InternalExample{
 {{range .Examples}}
 	{"{{.Name}}", {{.Package}}.{{.Name}}, {{.Output | printf "%q"}}, {{.Unordered}}},
@@ -1985,7 +2061,7 @@ func init() {
 	testdeps.ImportPath = {{.ImportPath | printf "%q"}}
 }
 
-{{if .CoverEnabled}}
+{{if .CoverInstrumented}}
 
 // Only updated by init functions, so no need for atomicity.
 var (
@@ -2033,7 +2109,10 @@ func main() {
 		CoveredPackages: {{printf "%q" .Covered}},
 	})
 {{end}}
-	m := testing.MainStart(testdeps.TestDeps{}, tests, benchmarks, examples)
+{{if .FuzzEnabled}}
+	testdeps.CoverCounters = coverCounters
+{{end}}
+	m := testing.MainStart(testdeps.TestDeps{}, tests, benchmarks, fuzzTargets, examples)
 {{with .TestMain}}
 	{{.Package}}.{{.Name}}(m)
 {{else}}
@@ -47,6 +47,9 @@ var testFlagDefn = []*cmdflag.Defn{
 	{Name: "cpu", PassToTest: true},
 	{Name: "cpuprofile", PassToTest: true},
 	{Name: "failfast", BoolVar: new(bool), PassToTest: true},
+	{Name: "fuzz", PassToTest: true},
+	{Name: "fuzzminimizetime", PassToTest: true},
+	{Name: "fuzztime", PassToTest: true},
 	{Name: "list", PassToTest: true},
 	{Name: "memprofile", PassToTest: true},
 	{Name: "memprofilerate", PassToTest: true},
@@ -151,6 +154,8 @@ func testFlags(args []string) (packageNames, passToTest []string) {
 			case "bench":
 				// record that we saw the flag; don't care about the value
 				testBench = true
+			case "fuzz":
+				testFuzz = value
 			case "list":
 				testList = true
 			case "timeout":
@@ -194,6 +199,11 @@ func testFlags(args []string) (packageNames, passToTest []string) {
 
 	if testCoverMode == "" {
 		testCoverMode = "set"
+		if testFuzz != "" {
+			// Fuzzing is guided by how often each block runs,
+			// not just whether it runs.
+			testCoverMode = "count"
+		}
 		if cfg.BuildRace {
 			// Default coverage mode is atomic when -race is set.
 			testCoverMode = "atomic"
@@ -30,6 +30,14 @@ The build flags supported by go vet are those that control package resolution
 and execution, such as -n, -x, -v, -tags, and -toolexec.
 For more about these flags, see 'go help build'.
 
+The -vettool=prog flag selects a different analysis tool with alternative
+or additional checks. The tool must implement the command-line protocol
+of package go/analysis/unitchecker, and go vet accepts any flags that the
+tool reports. For example, a tool named mytool built with unitchecker
+can be run using:
+
+	go vet -vettool=$(which mytool)
+
 See also: go fmt, go fix.
 	`,
 }
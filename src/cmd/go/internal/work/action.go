@@ -65,6 +65,7 @@ type Action struct {
 	IgnoreFail bool                          // whether to run f even if dependencies fail
 	TestOutput *bytes.Buffer                 // test output buffer
 	Args       []string                      // additional args for runProgram
+	VetxOnly   bool                          // Mode=="vet": only being called to supply info about dependencies
 
 	triggers []*Action // inverse of deps
 
@@ -132,6 +133,7 @@ type actionJSON struct {
 	Deps       []int    `json:",omitempty"`
 	IgnoreFail bool     `json:",omitempty"`
 	Args       []string `json:",omitempty"`
+	VetxOnly   bool     `json:",omitempty"`
 	Link       bool     `json:",omitempty"`
 	Objdir     string   `json:",omitempty"`
 	Target     string   `json:",omitempty"`
@@ -172,6 +174,7 @@ func actionGraphJSON(a *Action) string {
 			ID:         id,
 			IgnoreFail: a.IgnoreFail,
 			Args:       a.Args,
+			VetxOnly:   a.VetxOnly,
 			Objdir:     a.Objdir,
 			Target:     a.Target,
 			Failed:     a.Failed,
@@ -374,6 +377,17 @@ func (b *Builder) CompileAction(mode, depMode BuildMode, p *load.Package) *Actio
 // If the caller may be causing p to be installed, it is up to the caller
 // to make sure that the install depends on (runs after) vet.
 func (b *Builder) VetAction(mode, depMode BuildMode, p *load.Package) *Action {
+	a := b.vetAction(mode, depMode, p)
+	a.VetxOnly = false
+	return a
+}
+
+// vetAction returns the action for running go vet on package p,
+// depending on the vet actions for the packages p imports,
+// which supply the facts that vet of p needs.
+// Unless the caller resets VetxOnly, the action only computes those facts
+// and does not report the problems it finds.
+func (b *Builder) vetAction(mode, depMode BuildMode, p *load.Package) *Action {
 	// Construct vet action.
 	a := b.cacheAction("vet", p, func() *Action {
 		a1 := b.CompileAction(mode, depMode, p)
@@ -385,11 +399,18 @@ func (b *Builder) VetAction(mode, depMode BuildMode, p *load.Package) *Action {
 		stk.Pop()
 		aFmt := b.CompileAction(ModeBuild, depMode, p1)
 
+		deps := []*Action{a1, aFmt}
+		for _, p1 := range p.Internal.Imports {
+			deps = append(deps, b.vetAction(mode, depMode, p1))
+		}
+
 		a := &Action{
-			Mode:    "vet",
-			Package: p,
-			Deps:    []*Action{a1, aFmt},
-			Objdir:  a1.Objdir,
+			Mode:       "vet",
+			Package:    p,
+			Deps:       deps,
+			Objdir:     a1.Objdir,
+			VetxOnly:   true,
+			IgnoreFail: true, // it's OK if vet of dependencies "fails" (reports problems)
 		}
 		if a1.Func == nil {
 			// Built-in packages like unsafe.
@@ -281,6 +281,7 @@ func (b *Builder) buildActionID(a *Action) cache.ActionID {
 		p.SysoFiles,
 		p.SwigFiles,
 		p.SwigCXXFiles,
+		p.EmbedFiles,
 	)
 	for _, file := range inputFiles {
 		fmt.Fprintf(h, "file %s %s\n", file, b.fileHash(filepath.Join(p.Dir, file)))
@@ -505,10 +506,21 @@ func (b *Builder) build(a *Action) (err error) {
 		// so that vet's error messages will use absolute paths,
 		// so that we can reformat them relative to the directory
 		// in which the go command is invoked.
+		var nongofiles []string
+		nongofiles = append(nongofiles, a.Package.CFiles...)
+		nongofiles = append(nongofiles, a.Package.CXXFiles...)
+		nongofiles = append(nongofiles, a.Package.MFiles...)
+		nongofiles = append(nongofiles, a.Package.HFiles...)
+		nongofiles = append(nongofiles, a.Package.SFiles...)
+		nongofiles = append(nongofiles, a.Package.SysoFiles...)
+		nongofiles = append(nongofiles, a.Package.FFiles...)
+
 		vcfg = &vetConfig{
+			ID:          a.Package.ImportPath,
 			Compiler:    cfg.BuildToolchainName,
 			Dir:         a.Package.Dir,
 			GoFiles:     mkAbsFiles(a.Package.Dir, gofiles),
+			NonGoFiles:  mkAbsFiles(a.Package.Dir, nongofiles),
 			ImportPath:  a.Package.ImportPath,
 			ImportMap:   make(map[string]string),
 			PackageFile: make(map[string]string),
@@ -569,9 +581,29 @@ func (b *Builder) build(a *Action) (err error) {
 		return nil
 	}
 
+	// Prepare Go embed config if needed.
+	// Unlike the import config, it's okay for the embed config to change.
+	var embedcfg []byte
+	if len(p.Internal.Embed) > 0 {
+		var embed struct {
+			Patterns map[string][]string
+			Files    map[string]string
+		}
+		embed.Patterns = p.Internal.Embed
+		embed.Files = make(map[string]string)
+		for _, file := range p.EmbedFiles {
+			embed.Files[file] = filepath.Join(p.Dir, file)
+		}
+		js, err := json.MarshalIndent(&embed, "", "\t")
+		if err != nil {
+			return fmt.Errorf("marshal embedcfg: %v", err)
+		}
+		embedcfg = js
+	}
+
 	// Compile Go.
 	objpkg := objdir + "_pkg_.a"
-	ofile, out, err := BuildToolchain.gc(b, a, objpkg, icfg.Bytes(), len(sfiles) > 0, gofiles)
+	ofile, out, err := BuildToolchain.gc(b, a, objpkg, icfg.Bytes(), embedcfg, len(sfiles) > 0, gofiles)
 	if len(out) > 0 {
 		b.showOutput(a, a.Package.Dir, a.Package.ImportPath, b.processOutput(out))
 		if err != nil {
@@ -677,15 +709,22 @@ func (b *Builder) build(a *Action) (err error) {
 	return nil
 }
 
+// vetConfig is the configuration passed to vet describing a single package.
 type vetConfig struct {
-	Compiler    string
-	Dir         string
-	GoFiles     []string
-	ImportMap   map[string]string
-	PackageFile map[string]string
-	ImportPath  string
+	ID         string   // package ID (example: "fmt [fmt.test]")
+	Compiler   string   // compiler name (gc, gccgo)
+	Dir        string   // directory containing package
+	ImportPath string   // canonical import path ("package path")
+	GoFiles    []string // absolute paths to package source files
+	NonGoFiles []string // absolute paths to package non-Go files
+
+	ImportMap   map[string]string // map import path in source code to package path
+	PackageFile map[string]string // map package path to .a file with export data
+	PackageVetx map[string]string // map package path to vetx data from earlier vet run
+	VetxOnly    bool              // only compute vetx data; don't report detected problems
+	VetxOutput  string            // write vetx data to this output file
 
-	SucceedOnTypecheckFailure bool
+	SucceedOnTypecheckFailure bool // awful hack; see #18395 and below
 }
 
 // VetTool is the path to an alternate vet tool binary.
@@ -699,14 +738,46 @@ var VetFlags []string
 func (b *Builder) vet(a *Action) error {
 	// a.Deps[0] is the build of the package being vetted.
 	// a.Deps[1] is the build of the "fmt" package.
+	// a.Deps[2:] are the vet actions for the packages it imports.
+
+	a.Failed = false // vet of dependency may have failed but we can still succeed
+
+	if a.Deps[0].Failed {
+		// The build of the package has failed. Skip vet check.
+		return nil
+	}
 
 	vcfg := a.Deps[0].vetCfg
 	if vcfg == nil {
-		// Vet config should only be missing if the build failed.
-		if !a.Deps[0].Failed {
-			return fmt.Errorf("vet config not found")
+		return fmt.Errorf("vet config not found")
+	}
+
+	vcfg.VetxOnly = a.VetxOnly
+	vcfg.VetxOutput = a.Objdir + "vet.out"
+	vcfg.PackageVetx = make(map[string]string)
+
+	h := cache.NewHash("vet " + a.Package.ImportPath)
+	fmt.Fprintf(h, "vet %q\n", b.toolID("vet"))
+	fmt.Fprintf(h, "vetflags %q\n", VetFlags)
+	fmt.Fprintf(h, "pkg %q\n", a.Deps[0].actionID)
+	for _, a1 := range a.Deps {
+		if a1.Mode == "vet" && a1.built != "" {
+			fmt.Fprintf(h, "vetout %q %s\n", a1.Package.ImportPath, b.fileHash(a1.built))
+			vcfg.PackageVetx[a1.Package.ImportPath] = a1.built
+		}
+	}
+	key := cache.ActionID(h.Sum())
+
+	// The facts computed for a dependency depend only on its sources,
+	// the facts of its own dependencies, and the vet tool and flags,
+	// so they can be reused from the cache.
+	if vcfg.VetxOnly && !cfg.BuildA {
+		if c := cache.Default(); c != nil {
+			if file, _, err := c.GetFile(key); err == nil {
+				a.built = file
+				return nil
+			}
 		}
-		return nil
 	}
 
 	if vcfg.ImportMap["fmt"] == "" {
@@ -741,7 +812,19 @@ func (b *Builder) vet(a *Action) error {
 	if tool == "" {
 		tool = base.Tool("vet")
 	}
-	return b.run(a, p.Dir, p.ImportPath, env, cfg.BuildToolexec, tool, VetFlags, a.Objdir+"vet.cfg")
+	if err := b.run(a, p.Dir, p.ImportPath, env, cfg.BuildToolexec, tool, VetFlags, a.Objdir+"vet.cfg"); err != nil {
+		return err
+	}
+
+	// Vet succeeded; stash vet.out in cache for future use.
+	if f, err := os.Open(vcfg.VetxOutput); err == nil {
+		a.built = vcfg.VetxOutput
+		if c := cache.Default(); c != nil {
+			c.Put(key, f)
+		}
+		f.Close()
+	}
+	return nil
 }
 
 // linkActionID computes the action ID for a link action.
@@ -1608,7 +1691,7 @@ func mkAbs(dir, f string) string {
 type toolchain interface {
 	// gc runs the compiler in a specific directory on a set of files
 	// and returns the name of the generated output file.
-	gc(b *Builder, a *Action, archive string, importcfg []byte, asmhdr bool, gofiles []string) (ofile string, out []byte, err error)
+	gc(b *Builder, a *Action, archive string, importcfg, embedcfg []byte, asmhdr bool, gofiles []string) (ofile string, out []byte, err error)
 	// cc runs the toolchain's C compiler in a directory on a C file
 	// to produce an output file.
 	cc(b *Builder, a *Action, ofile, cfile string) error
@@ -1645,7 +1728,7 @@ func (noToolchain) linker() string {
 	return ""
 }
 
-func (noToolchain) gc(b *Builder, a *Action, archive string, importcfg []byte, asmhdr bool, gofiles []string) (ofile string, out []byte, err error) {
+func (noToolchain) gc(b *Builder, a *Action, archive string, importcfg, embedcfg []byte, asmhdr bool, gofiles []string) (ofile string, out []byte, err error) {
 	return "", nil, noCompiler()
 }
 
@@ -2301,7 +2384,7 @@ func (b *Builder) swigDoIntSize(objdir string) (intsize string, err error) {
 
 	p := load.GoFilesPackage(srcs)
 
-	if _, _, e := BuildToolchain.gc(b, &Action{Mode: "swigDoIntSize", Package: p, Objdir: objdir}, "", nil, false, srcs); e != nil {
+	if _, _, e := BuildToolchain.gc(b, &Action{Mode: "swigDoIntSize", Package: p, Objdir: objdir}, "", nil, nil, false, srcs); e != nil {
 		return "32", nil
 	}
 	return "64", nil
@@ -51,8 +51,11 @@ func checkGccgoBin() {
 	os.Exit(2)
 }
 
-func (tools gccgoToolchain) gc(b *Builder, a *Action, archive string, importcfg []byte, asmhdr bool, gofiles []string) (ofile string, output []byte, err error) {
+func (tools gccgoToolchain) gc(b *Builder, a *Action, archive string, importcfg, embedcfg []byte, asmhdr bool, gofiles []string) (ofile string, output []byte, err error) {
 	p := a.Package
+	if embedcfg != nil {
+		return "", nil, fmt.Errorf("%s: gccgo does not support //go:embed", p.ImportPath)
+	}
 	objdir := a.Objdir
 	out := "_go_.o"
 	ofile = objdir + out
@@ -0,0 +1,146 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main_test
+
+import (
+	"archive/zip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// A modProxyFile is one file in a module version served by a test proxy.
+type modProxyFile struct {
+	name, data string
+}
+
+// testModProxy describes the modules served by the test proxy,
+// keyed by "path@version".
+var testModProxy = map[string][]modProxyFile{
+	"example.com/dep@v1.0.0": {
+		{"go.mod", "module example.com/dep\n"},
+		{"dep.go", "package dep\n\nconst V = \"v1.0.0\"\n"},
+	},
+	"example.com/dep@v1.1.0": {
+		{"go.mod", "module example.com/dep\n\nrequire example.com/lib v1.0.0\n"},
+		{"dep.go", "package dep\n\nimport _ \"example.com/lib\"\n\nconst V = \"v1.1.0\"\n"},
+	},
+	"example.com/lib@v1.0.0": {
+		{"go.mod", "module example.com/lib\n"},
+		{"lib.go", "package lib\n"},
+	},
+}
+
+// writeModProxy writes the modules in testModProxy to a directory
+// in the GOPROXY file layout and returns that directory.
+func writeModProxy(tg *testgoData) string {
+	dir := tg.path("proxy")
+	lists := make(map[string][]string)
+	for pathVers, files := range testModProxy {
+		i := strings.Index(pathVers, "@")
+		path, vers := pathVers[:i], pathVers[i+1:]
+		vdir := filepath.Join(dir, path, "@v")
+		tg.must(os.MkdirAll(vdir, 0777))
+		lists[vdir] = append(lists[vdir], vers)
+		tg.must(ioutil.WriteFile(filepath.Join(vdir, vers+".info"), []byte(`{"Version":"`+vers+`","Time":"2018-02-14T00:00:00Z"}`), 0666))
+
+		f, err := os.Create(filepath.Join(vdir, vers+".zip"))
+		tg.must(err)
+		z := zip.NewWriter(f)
+		for _, file := range files {
+			if file.name == "go.mod" {
+				tg.must(ioutil.WriteFile(filepath.Join(vdir, vers+".mod"), []byte(file.data), 0666))
+			}
+			w, err := z.Create(pathVers + "/" + file.name)
+			tg.must(err)
+			_, err = w.Write([]byte(file.data))
+			tg.must(err)
+		}
+		tg.must(z.Close())
+		tg.must(f.Close())
+	}
+	for vdir, list := range lists {
+		tg.must(ioutil.WriteFile(filepath.Join(vdir, "list"), []byte(strings.Join(list, "\n")+"\n"), 0666))
+	}
+	return dir
+}
+
+func testModGo(t *testing.T) *testgoData {
+	tg := testgo(t)
+	tg.makeTempdir()
+	tg.setenv("GO111MODULE", "on")
+	tg.setenv("GOPATH", tg.path("gopath"))
+	tg.setenv("GOPROXY", writeModProxy(tg))
+	tg.tempDir("x")
+	tg.cd(tg.path("x"))
+	return tg
+}
+
+func TestModInitAndBuild(t *testing.T) {
+	tg := testModGo(t)
+	defer tg.cleanup()
+
+	tg.must(ioutil.WriteFile(tg.path("x/x.go"), []byte("package x\n\nimport \"example.com/dep\"\n\nconst V = dep.V\n"), 0666))
+	tg.run("mod", "init", "example.com/x")
+	tg.grepStderr("creating new go.mod: module example.com/x", "did not create go.mod")
+
+	tg.run("build")
+	tg.grepStderr(`go: adding example\.com/dep v1\.1\.0`, "did not add latest dep")
+	data, err := ioutil.ReadFile(tg.path("x/go.mod"))
+	tg.must(err)
+	if !strings.Contains(string(data), "require example.com/dep v1.1.0") {
+		t.Fatalf("go.mod does not require example.com/dep v1.1.0:\n%s", data)
+	}
+	tg.mustExist(tg.path("x/go.sum"))
+
+	tg.run("list", "-m", "all")
+	tg.grepStdout(`^example\.com/x$`, "did not list main module")
+	tg.grepStdout(`^example\.com/dep v1\.1\.0$`, "did not list dep")
+	tg.grepStdout(`^example\.com/lib v1\.0\.0$`, "did not list indirect lib")
+
+	tg.run("mod", "graph")
+	tg.grepStdout(`^example\.com/dep@v1\.1\.0 example\.com/lib@v1\.0\.0$`, "missing graph edge")
+
+	tg.run("mod", "verify")
+	tg.grepStdout("all modules verified", "did not verify modules")
+}
+
+func TestModGetDowngrade(t *testing.T) {
+	tg := testModGo(t)
+	defer tg.cleanup()
+
+	tg.must(ioutil.WriteFile(tg.path("x/go.mod"), []byte("module example.com/x\n\nrequire example.com/dep v1.1.0\n"), 0666))
+	tg.must(ioutil.WriteFile(tg.path("x/x.go"), []byte("package x\n\nimport \"example.com/dep\"\n\nconst V = dep.V\n"), 0666))
+
+	tg.run("get", "-d", "example.com/dep@v1.0.0")
+	tg.run("list", "-m", "all")
+	tg.grepStdout(`^example\.com/dep v1\.0\.0$`, "did not downgrade dep")
+	tg.grepStdoutNot(`example\.com/lib`, "lib still in build list after downgrade")
+
+	tg.runFail("get", "-d", "example.com/dep@v1.2.0")
+	tg.grepStderr(`example\.com/dep@v1\.2\.0`, "unknown version not reported")
+}
+
+func TestModSumMismatch(t *testing.T) {
+	tg := testModGo(t)
+	defer tg.cleanup()
+
+	tg.must(ioutil.WriteFile(tg.path("x/go.mod"), []byte("module example.com/x\n\nrequire example.com/dep v1.0.0\n"), 0666))
+	tg.must(ioutil.WriteFile(tg.path("x/go.sum"), []byte("example.com/dep v1.0.0/go.mod h1:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=\n"), 0666))
+	tg.must(ioutil.WriteFile(tg.path("x/x.go"), []byte("package x\n\nimport _ \"example.com/dep\"\n"), 0666))
+	tg.runFail("build")
+	tg.grepStderr("checksum mismatch", "did not detect go.sum mismatch")
+}
+
+func TestModOffInGOPATH(t *testing.T) {
+	tg := testModGo(t)
+	defer tg.cleanup()
+
+	tg.setenv("GO111MODULE", "off")
+	tg.runFail("mod", "graph")
+	tg.grepStderr("modules disabled", "go mod ran with modules off")
+}
@@ -23,6 +23,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync/atomic"
 )
@@ -199,13 +200,12 @@ func (p platform) vet() {
 	w := make(whitelist)
 	w.load(p.os, p.arch)
 
-	// 'go tool vet .' is considerably faster than 'go vet ./...'
-	// TODO: The unsafeptr checks are disabled for now,
+	// The unsafeptr checks are disabled for now,
 	// because there are so many false positives,
 	// and no clear way to improve vet to eliminate large chunks of them.
 	// And having them in the whitelists will just cause annoyance
 	// and churn when working on the runtime.
-	cmd := exec.Command(cmdGoPath, "tool", "vet", "-unsafeptr=false", "-source", ".")
+	cmd := exec.Command(cmdGoPath, "vet", "-unsafeptr=false", "std", "cmd")
 	cmd.Dir = filepath.Join(runtime.GOROOT(), "src")
 	cmd.Env = append(os.Environ(), "GOOS="+p.os, "GOARCH="+p.arch, "CGO_ENABLED=0")
 	stderr, err := cmd.StderrPipe()
@@ -222,6 +222,10 @@ func (p platform) vet() {
 NextLine:
 	for scan.Scan() {
 		line := scan.Text()
+		if strings.HasPrefix(line, "# ") {
+			// Package header printed by the go command.
+			continue
+		}
 		if strings.HasPrefix(line, "vet: ") {
 			// Typecheck failure: Malformed syntax or multiple packages or the like.
 			// This will yield nicer error messages elsewhere, so ignore them here.
@@ -245,6 +249,12 @@ NextLine:
 			file, msg = fields[0], fields[1]
 		case 3:
 			file, lineno, msg = fields[0], fields[1], fields[2]
+			// Drop the column number, if any.
+			if i := strings.Index(msg, ":"); i > 0 {
+				if _, err := strconv.Atoi(msg[:i]); err == nil {
+					msg = msg[i+1:]
+				}
+			}
 		default:
 			if !parseFailed {
 				parseFailed = true
@@ -253,6 +263,7 @@ NextLine:
 			fmt.Fprintln(os.Stderr, line)
 		}
 		msg = strings.TrimSpace(msg)
+		file = strings.TrimPrefix(file, "./")
 
 		for _, ignore := range ignorePathPrefixes {
 			if strings.HasPrefix(file, filepath.FromSlash(ignore)) {
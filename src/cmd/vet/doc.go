@@ -25,13 +25,15 @@ check every possible problem and depends on unreliable heuristics
 so it should be used as guidance only, not as a firm indicator of
 program correctness.
 
-By default the -all flag is set so all checks are performed.
+By default all checks are performed.
 If any flags are explicitly set to true, only those tests are run. Conversely, if
 any flag is explicitly set to false, only those tests are disabled.  Thus -printf=true
 runs the printf check, -printf=false runs all checks except the printf check.
 
-By default vet uses the object files generated by 'go install some/pkg' to typecheck the code.
-If the -source flag is provided, vet uses only source code.
+Each check is implemented as an analysis.Analyzer (see package go/analysis),
+and flags specific to a check are prefixed by its name, as in -printf.funcs.
+Flag names used by earlier versions of vet, such as -printfuncs, are still
+accepted.
 
 Available checks:
 
@@ -55,13 +57,13 @@ Common mistaken usages of the sync/atomic package.
 
 Boolean conditions
 
-Flag: -bool
+Flag: -bools
 
 Mistakes involving boolean operators.
 
 Build tags
 
-Flag: -buildtags
+Flag: -buildtag
 
 Badly formed or misplaced +build tags.
 
@@ -101,7 +103,7 @@ until its parent context is cancelled.
 
 Methods
 
-Flag: -methods
+Flag: -stdmethods
 
 Non-standard signatures for methods with familiar names, including:
 	Format GobEncode GobDecode MarshalJSON MarshalXML
@@ -128,7 +130,7 @@ with these names, disregarding case:
 	Fatal Fatalf
 	Log Logf
 	Panic Panicf Panicln
-The -printfuncs flag can be used to redefine this list.
+The -printf.funcs flag can be used to add to this list.
 If the function name ends with an 'f', the function is assumed to take
 a format descriptor string in the manner of fmt.Printf. If not, vet
 complains about arguments that look like format descriptor strings.
@@ -136,18 +138,17 @@ complains about arguments that look like format descriptor strings.
 It also checks for errors such as using a Writer as the first argument of
 Printf.
 
+Functions that forward their arguments to one of these functions, such as
+a function log(format string, args ...interface{}) that calls fmt.Printf,
+are detected and checked in the same way, even when they are declared in
+another package.
+
 Range loop variables
 
-Flag: -rangeloops
+Flag: -loopclosure
 
 Incorrect uses of range loop variables in closures.
 
-Shadowed variables
-
-Flag: -shadow=false (experimental; must be set explicitly)
-
-Variables that may have been unintentionally shadowed.
-
 Shifts
 
 Flag: -shift
@@ -156,7 +157,7 @@ Shifts equal to or longer than the variable's length.
 
 Struct tags
 
-Flag: -structtags
+Flag: -structtag
 
 Struct tags that do not follow the format understood by reflect.StructTag.Get.
 Well-known encoding struct tags (json, xml) used with unexported fields.
@@ -190,35 +191,37 @@ Flag: -unusedresult
 
 Calls to well-known functions and methods that return a value that is
 discarded.  By default, this includes functions like fmt.Errorf and
-fmt.Sprintf and methods like String and Error. The flags -unusedfuncs
-and -unusedstringmethods control the set.
+fmt.Sprintf and methods like String and Error. The flags -unusedresult.funcs
+and -unusedresult.stringmethods control the set.
 
 Other flags
 
 These flags configure the behavior of vet:
 
-	-all (default true)
-		Enable all non-experimental checks.
-	-v
-		Verbose mode
-	-printfuncs
+	-c=N
+		Display offending line plus N lines of context.
+	-json
+		Emit analysis diagnostics (and errors) in JSON format.
+	-flags
+		Print the analyzer flags in JSON, for use by the go command.
+	-printf.funcs
 		A comma-separated list of print-like function names
 		to supplement the standard list.
 		For more information, see the discussion of the -printf flag.
-	-shadowstrict
-		Whether to be strict about shadowing; can be noisy.
 
-Using vet directly
+Using other analysis tools
 
-For testing and debugging vet can be run directly by invoking
-"go tool vet" or just running the binary. Run this way, vet might not
-have up to date information for imported packages.
+Vet is built from the analyzers in go/analysis/passes using the
+go/analysis/unitchecker driver. The same driver can be used to build
+a vet-like tool containing a different set of analyzers, including
+ones outside the standard library. Such a tool is run by the go command
+in place of vet using the -vettool flag:
 
-	go tool vet source/directory/*.go
-vets the files named, all of which must be in the same package.
+	go vet -vettool=$(which mytool) package/path/name
 
-	go tool vet source/directory
-recursively descends the directory, vetting each package it finds.
+Vet is always invoked by the go command, which supplies the type
+information, and the facts computed for dependencies, that the analyzers
+need. Running "go tool vet" directly is not supported.
 
 */
 package main
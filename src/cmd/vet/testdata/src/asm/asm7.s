@@ -3,7 +3,6 @@
 // license that can be found in the LICENSE file.
 
 // +build ppc64 ppc64le
-// +build vet_test
 
 TEXT ·arg1(SB),0,$0-2
 	MOVB	x+0(FP), R3
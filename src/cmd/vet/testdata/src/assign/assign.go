@@ -4,7 +4,7 @@
 
 // This file contains tests for the useless-assignment checker.
 
-package testdata
+package assign
 
 import "math/rand"
 
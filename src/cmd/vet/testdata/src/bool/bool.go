@@ -4,7 +4,7 @@
 
 // This file contains tests for the bool checker.
 
-package testdata
+package bool
 
 import "io"
 
@@ -20,9 +20,13 @@ func RatherStupidConditions() {
 	}
 	_ = f == nil || f == nil // ERROR "redundant or: f == nil || f == nil"
 
-	_ = i == byte(1) || i == byte(1) // ERROR "redundant or: i == byte(1) || i == byte(1)"
-	_ = i == T(2) || i == T(2)       // ERROR "redundant or: i == T(2) || i == T(2)"
-	_ = FT(f) == nil || FT(f) == nil // ERROR "redundant or: FT(f) == nil || FT(f) == nil"
+	{
+		var i byte
+		_ = i == byte(1) || i == byte(1) // ERROR "redundant or: i == byte(1) || i == byte(1)"
+		var t T
+		_ = t == T(2) || t == T(2)       // ERROR "redundant or: t == T(2) || t == T(2)"
+		_ = FT(f) == nil || FT(f) == nil // ERROR "redundant or: FT(f) == nil || FT(f) == nil"
+	}
 
 	// TODO: distinguish from an actual func call
 	_ = (func() int)(f) == nil || (func() int)(f) == nil
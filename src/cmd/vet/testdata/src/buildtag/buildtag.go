@@ -7,7 +7,7 @@
 // +builder // ERROR "possible malformed \+build comment"
 // +build !ignore
 
-package testdata
+package buildtag
 
 // +build toolate // ERROR "build comment must appear before package clause and be followed by a blank line"
 
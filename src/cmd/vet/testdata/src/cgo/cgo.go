@@ -4,9 +4,9 @@
 
 // This file contains tests for the cgo checker.
 
-package testdata
+package cgo
 
-// void f(void *);
+// void f(void *ptr) {}
 import "C"
 
 import "unsafe"
@@ -5,11 +5,9 @@
 // Test the cgo checker on a file that doesn't use cgo, but has an
 // import named "C".
 
-package testdata
+package cgo
 
 import C "fmt"
 
-var _ = C.Println(*p(**p))
-
 // Passing a pointer (via a slice), but C is fmt, not cgo.
-var _ = C.Println([]int{3})
+var _, _ = C.Println([]int{3})
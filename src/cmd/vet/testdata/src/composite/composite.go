@@ -4,15 +4,14 @@
 
 // This file contains the test for untagged struct literals.
 
-package testdata
+package composite
 
 import (
 	"flag"
 	"go/scanner"
+	"go/token"
 	"image"
 	"unicode"
-
-	"path/to/unknownpkg"
 )
 
 var Okay1 = []string{
@@ -87,8 +86,8 @@ var goodNamedSliceLiteral = unicode.SpecialCase{
 	unicode.CaseRange{Lo: 1, Hi: 2},
 }
 var badNamedSliceLiteral = unicode.SpecialCase{
-	{1, 2},                  // ERROR "unkeyed fields"
-	unicode.CaseRange{1, 2}, // ERROR "unkeyed fields"
+	{1, 2, [unicode.MaxCase]rune{}},                  // ERROR "unkeyed fields"
+	unicode.CaseRange{1, 2, [unicode.MaxCase]rune{}}, // ERROR "unkeyed fields"
 }
 
 // ErrorList is a named slice, so no warnings should be emitted.
@@ -96,17 +95,13 @@ var goodScannerErrorList = scanner.ErrorList{
 	&scanner.Error{Msg: "foobar"},
 }
 var badScannerErrorList = scanner.ErrorList{
-	&scanner.Error{"foobar"}, // ERROR "unkeyed fields"
+	&scanner.Error{token.Position{}, "foobar"}, // ERROR "unkeyed fields"
 }
 
-// Check whitelisted structs: if vet is run with --compositewhitelist=false,
+// Check whitelisted structs: if vet is run with -composites.whitelist=false,
 // this line triggers an error.
 var whitelistedPoint = image.Point{1, 2}
 
-// Do not check type from unknown package.
-// See issue 15408.
-var unknownPkgVar = unknownpkg.Foobar{"foo", "bar"}
-
 // A named pointer slice of CaseRange to test issue 23539. In
 // particular, we're interested in how some slice elements omit their
 // type.
@@ -115,6 +110,6 @@ var goodNamedPointerSliceLiteral = []*unicode.CaseRange{
 	&unicode.CaseRange{Lo: 1, Hi: 2},
 }
 var badNamedPointerSliceLiteral = []*unicode.CaseRange{
-	{1, 2}, // ERROR "unkeyed fields"
-	&unicode.CaseRange{1, 2}, // ERROR "unkeyed fields"
+	{1, 2, [unicode.MaxCase]rune{}},                   // ERROR "unkeyed fields"
+	&unicode.CaseRange{1, 2, [unicode.MaxCase]rune{}}, // ERROR "unkeyed fields"
 }
@@ -1,4 +1,4 @@
-package testdata
+package copylock
 
 import (
 	"sync"
@@ -11,16 +11,20 @@ import (
 func OkFunc() {
 	var x *sync.Mutex
 	p := x
+	_ = &p
 	var y sync.Mutex
 	p = &y
 
 	var z = sync.Mutex{}
+	_ = &z
 	w := sync.Mutex{}
+	_ = &w
 
 	w = sync.Mutex{}
 	q := struct{ L sync.Mutex }{
 		L: sync.Mutex{},
 	}
+	_ = &q
 
 	yy := []Tlock{
 		Tlock{},
@@ -28,12 +32,16 @@ func OkFunc() {
 			once: sync.Once{},
 		},
 	}
+	_ = &yy
 
 	nl := new(sync.Mutex)
+	_ = &nl
 	mx := make([]sync.Mutex, 10)
+	_ = &mx
 	xx := struct{ L *sync.Mutex }{
 		L: new(sync.Mutex),
 	}
+	_ = &xx
 }
 
 type Tlock struct {
@@ -50,51 +58,60 @@ func BadFunc() {
 	var t Tlock
 	var tp *Tlock
 	tp = &t
-	*tp = t // ERROR "assignment copies lock value to \*tp: testdata.Tlock contains sync.Once contains sync.Mutex"
-	t = *tp // ERROR "assignment copies lock value to t: testdata.Tlock contains sync.Once contains sync.Mutex"
+	*tp = t // ERROR "assignment copies lock value to \*tp: copylock.Tlock contains sync.Once contains sync.Mutex"
+	t = *tp // ERROR "assignment copies lock value to t: copylock.Tlock contains sync.Once contains sync.Mutex"
 
-	y := *x   // ERROR "assignment copies lock value to y: sync.Mutex"
-	var z = t // ERROR "variable declaration copies lock value to z: testdata.Tlock contains sync.Once contains sync.Mutex"
+	y = *x    // ERROR "assignment copies lock value to y: sync.Mutex"
+	var z = t // ERROR "variable declaration copies lock value to z: copylock.Tlock contains sync.Once contains sync.Mutex"
+	_ = &z
 
 	w := struct{ L sync.Mutex }{
 		L: *x, // ERROR "literal copies lock value from \*x: sync.Mutex"
 	}
+	_ = &w
 	var q = map[int]Tlock{
-		1: t,   // ERROR "literal copies lock value from t: testdata.Tlock contains sync.Once contains sync.Mutex"
-		2: *tp, // ERROR "literal copies lock value from \*tp: testdata.Tlock contains sync.Once contains sync.Mutex"
+		1: t,   // ERROR "literal copies lock value from t: copylock.Tlock contains sync.Once contains sync.Mutex"
+		2: *tp, // ERROR "literal copies lock value from \*tp: copylock.Tlock contains sync.Once contains sync.Mutex"
 	}
+	_ = &q
 	yy := []Tlock{
-		t,   // ERROR "literal copies lock value from t: testdata.Tlock contains sync.Once contains sync.Mutex"
-		*tp, // ERROR "literal copies lock value from \*tp: testdata.Tlock contains sync.Once contains sync.Mutex"
+		t,   // ERROR "literal copies lock value from t: copylock.Tlock contains sync.Once contains sync.Mutex"
+		*tp, // ERROR "literal copies lock value from \*tp: copylock.Tlock contains sync.Once contains sync.Mutex"
 	}
+	_ = &yy
 
 	// override 'new' keyword
 	new := func(interface{}) {}
-	new(t) // ERROR "call of new copies lock value: testdata.Tlock contains sync.Once contains sync.Mutex"
+	new(t) // ERROR "call of new copies lock value: copylock.Tlock contains sync.Once contains sync.Mutex"
 
 	// copy of array of locks
 	var muA [5]sync.Mutex
 	muB := muA        // ERROR "assignment copies lock value to muB: sync.Mutex"
 	muA = muB         // ERROR "assignment copies lock value to muA: sync.Mutex"
 	muSlice := muA[:] // OK
+	_ = &muSlice
 
 	// multidimensional array
 	var mmuA [5][5]sync.Mutex
 	mmuB := mmuA        // ERROR "assignment copies lock value to mmuB: sync.Mutex"
 	mmuA = mmuB         // ERROR "assignment copies lock value to mmuA: sync.Mutex"
 	mmuSlice := mmuA[:] // OK
+	_ = &mmuSlice
 
 	// slice copy is ok
 	var fmuA [5][][5]sync.Mutex
 	fmuB := fmuA        // OK
 	fmuA = fmuB         // OK
 	fmuSlice := fmuA[:] // OK
+	_ = &fmuSlice
 }
 
 func LenAndCapOnLockArrays() {
 	var a [5]sync.Mutex
 	aLen := len(a) // OK
+	_ = &aLen
 	aCap := cap(a) // OK
+	_ = &aCap
 
 	// override 'len' and 'cap' keywords
 
@@ -107,9 +124,9 @@ func LenAndCapOnLockArrays() {
 
 func SizeofMutex() {
 	var mu sync.Mutex
-	unsafe.Sizeof(mu)  // OK
-	unsafe1.Sizeof(mu) // OK
-	Sizeof(mu)         // OK
+	_ = unsafe.Sizeof(mu)  // OK
+	_ = unsafe1.Sizeof(mu) // OK
+	_ = Sizeof(mu)         // OK
 	unsafe := struct{ Sizeof func(interface{}) }{}
 	unsafe.Sizeof(mu) // ERROR "call of unsafe.Sizeof copies lock value: sync.Mutex"
 	Sizeof := func(interface{}) {}
@@ -121,55 +138,85 @@ func SyncTypesCheck() {
 	// sync.RWMutex copying
 	var rwmuX sync.RWMutex
 	var rwmuXX = sync.RWMutex{}
+	_ = &rwmuXX
 	rwmuX1 := new(sync.RWMutex)
-	rwmuY := rwmuX     // ERROR "assignment copies lock value to rwmuY: sync.RWMutex"
+	_ = &rwmuX1
+	rwmuY := rwmuX // ERROR "assignment copies lock value to rwmuY: sync.RWMutex"
+	_ = &rwmuY
 	rwmuY = rwmuX      // ERROR "assignment copies lock value to rwmuY: sync.RWMutex"
 	var rwmuYY = rwmuX // ERROR "variable declaration copies lock value to rwmuYY: sync.RWMutex"
+	_ = &rwmuYY
 	rwmuP := &rwmuX
+	_ = &rwmuP
 	rwmuZ := &sync.RWMutex{}
+	_ = &rwmuZ
 
 	// sync.Cond copying
 	var condX sync.Cond
 	var condXX = sync.Cond{}
+	_ = &condXX
 	condX1 := new(sync.Cond)
-	condY := condX     // ERROR "assignment copies lock value to condY: sync.Cond contains sync.noCopy"
+	_ = &condX1
+	condY := condX // ERROR "assignment copies lock value to condY: sync.Cond contains sync.noCopy"
+	_ = &condY
 	condY = condX      // ERROR "assignment copies lock value to condY: sync.Cond contains sync.noCopy"
 	var condYY = condX // ERROR "variable declaration copies lock value to condYY: sync.Cond contains sync.noCopy"
+	_ = &condYY
 	condP := &condX
+	_ = &condP
 	condZ := &sync.Cond{
 		L: &sync.Mutex{},
 	}
+	_ = &condZ
 	condZ = sync.NewCond(&sync.Mutex{})
 
 	// sync.WaitGroup copying
 	var wgX sync.WaitGroup
 	var wgXX = sync.WaitGroup{}
+	_ = &wgXX
 	wgX1 := new(sync.WaitGroup)
-	wgY := wgX     // ERROR "assignment copies lock value to wgY: sync.WaitGroup contains sync.noCopy"
+	_ = &wgX1
+	wgY := wgX // ERROR "assignment copies lock value to wgY: sync.WaitGroup contains sync.noCopy"
+	_ = &wgY
 	wgY = wgX      // ERROR "assignment copies lock value to wgY: sync.WaitGroup contains sync.noCopy"
 	var wgYY = wgX // ERROR "variable declaration copies lock value to wgYY: sync.WaitGroup contains sync.noCopy"
+	_ = &wgYY
 	wgP := &wgX
+	_ = &wgP
 	wgZ := &sync.WaitGroup{}
+	_ = &wgZ
 
 	// sync.Pool copying
 	var poolX sync.Pool
 	var poolXX = sync.Pool{}
+	_ = &poolXX
 	poolX1 := new(sync.Pool)
-	poolY := poolX     // ERROR "assignment copies lock value to poolY: sync.Pool contains sync.noCopy"
+	_ = &poolX1
+	poolY := poolX // ERROR "assignment copies lock value to poolY: sync.Pool contains sync.noCopy"
+	_ = &poolY
 	poolY = poolX      // ERROR "assignment copies lock value to poolY: sync.Pool contains sync.noCopy"
 	var poolYY = poolX // ERROR "variable declaration copies lock value to poolYY: sync.Pool contains sync.noCopy"
+	_ = &poolYY
 	poolP := &poolX
+	_ = &poolP
 	poolZ := &sync.Pool{}
+	_ = &poolZ
 
 	// sync.Once copying
 	var onceX sync.Once
 	var onceXX = sync.Once{}
+	_ = &onceXX
 	onceX1 := new(sync.Once)
-	onceY := onceX     // ERROR "assignment copies lock value to onceY: sync.Once contains sync.Mutex"
+	_ = &onceX1
+	onceY := onceX // ERROR "assignment copies lock value to onceY: sync.Once contains sync.Mutex"
+	_ = &onceY
 	onceY = onceX      // ERROR "assignment copies lock value to onceY: sync.Once contains sync.Mutex"
 	var onceYY = onceX // ERROR "variable declaration copies lock value to onceYY: sync.Once contains sync.Mutex"
+	_ = &onceYY
 	onceP := &onceX
+	_ = &onceP
 	onceZ := &sync.Once{}
+	_ = &onceZ
 }
 
 // AtomicTypesCheck checks copying of sync/atomic types
@@ -177,12 +224,18 @@ func AtomicTypesCheck() {
 	// atomic.Value copying
 	var vX atomic.Value
 	var vXX = atomic.Value{}
+	_ = &vXX
 	vX1 := new(atomic.Value)
+	_ = &vX1
 	// These are OK because the value has not been used yet.
 	// (And vet can't tell whether it has been used, so they're always OK.)
 	vY := vX
+	_ = &vY
 	vY = vX
 	var vYY = vX
+	_ = &vYY
 	vP := &vX
+	_ = &vP
 	vZ := &atomic.Value{}
+	_ = &vZ
 }
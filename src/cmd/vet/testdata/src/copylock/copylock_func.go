@@ -5,15 +5,15 @@
 // This file contains tests for the copylock checker's
 // function declaration analysis.
 
-package testdata
+package copylock
 
 import "sync"
 
-func OkFunc(*sync.Mutex) {}
-func BadFunc(sync.Mutex) {} // ERROR "BadFunc passes lock by value: sync.Mutex"
-func BadFunc2(sync.Map)  {} // ERROR "BadFunc2 passes lock by value: sync.Map contains sync.Mutex"
-func OkRet() *sync.Mutex {}
-func BadRet() sync.Mutex {} // Don't warn about results
+func OkFunc1(*sync.Mutex)     {}
+func BadFunc1(sync.Mutex)     {} // ERROR "BadFunc1 passes lock by value: sync.Mutex"
+func BadFunc2(sync.Map)       {} // ERROR "BadFunc2 passes lock by value: sync.Map contains sync.Mutex"
+func OkRet1() *sync.Mutex     { return nil }
+func BadRet1() (m sync.Mutex) { return } // Don't warn about results
 
 var (
 	OkClosure   = func(*sync.Mutex) {}
@@ -25,21 +25,21 @@ type EmbeddedRWMutex struct {
 	sync.RWMutex
 }
 
-func (*EmbeddedRWMutex) OkMeth() {}
-func (EmbeddedRWMutex) BadMeth() {} // ERROR "BadMeth passes lock by value: testdata.EmbeddedRWMutex"
-func OkFunc(e *EmbeddedRWMutex)  {}
-func BadFunc(EmbeddedRWMutex)    {} // ERROR "BadFunc passes lock by value: testdata.EmbeddedRWMutex"
-func OkRet() *EmbeddedRWMutex    {}
-func BadRet() EmbeddedRWMutex    {} // Don't warn about results
+func (*EmbeddedRWMutex) OkMeth()   {}
+func (EmbeddedRWMutex) BadMeth()   {} // ERROR "BadMeth passes lock by value: copylock.EmbeddedRWMutex"
+func OkFunc3(e *EmbeddedRWMutex)   {}
+func BadFunc3(EmbeddedRWMutex)     {} // ERROR "BadFunc3 passes lock by value: copylock.EmbeddedRWMutex"
+func OkRet3() *EmbeddedRWMutex     { return nil }
+func BadRet3() (m EmbeddedRWMutex) { return } // Don't warn about results
 
 type FieldMutex struct {
 	s sync.Mutex
 }
 
-func (*FieldMutex) OkMeth()   {}
-func (FieldMutex) BadMeth()   {} // ERROR "BadMeth passes lock by value: testdata.FieldMutex contains sync.Mutex"
-func OkFunc(*FieldMutex)      {}
-func BadFunc(FieldMutex, int) {} // ERROR "BadFunc passes lock by value: testdata.FieldMutex contains sync.Mutex"
+func (*FieldMutex) OkMeth()    {}
+func (FieldMutex) BadMeth()    {} // ERROR "BadMeth passes lock by value: copylock.FieldMutex contains sync.Mutex"
+func OkFunc4(*FieldMutex)      {}
+func BadFunc4(FieldMutex, int) {} // ERROR "BadFunc4 passes lock by value: copylock.FieldMutex contains sync.Mutex"
 
 type L0 struct {
 	L1
@@ -54,16 +54,16 @@ type L2 struct {
 }
 
 func (*L0) Ok() {}
-func (L0) Bad() {} // ERROR "Bad passes lock by value: testdata.L0 contains testdata.L1 contains testdata.L2"
+func (L0) Bad() {} // ERROR "Bad passes lock by value: copylock.L0 contains copylock.L1 contains copylock.L2"
 
 type EmbeddedMutexPointer struct {
 	s *sync.Mutex // safe to copy this pointer
 }
 
-func (*EmbeddedMutexPointer) Ok()      {}
-func (EmbeddedMutexPointer) AlsoOk()   {}
-func StillOk(EmbeddedMutexPointer)     {}
-func LookinGood() EmbeddedMutexPointer {}
+func (*EmbeddedMutexPointer) Ok()          {}
+func (EmbeddedMutexPointer) AlsoOk()       {}
+func StillOk(EmbeddedMutexPointer)         {}
+func LookinGood() (p EmbeddedMutexPointer) { return }
 
 type EmbeddedLocker struct {
 	sync.Locker // safe to copy interface values
@@ -78,7 +78,7 @@ func (*CustomLock) Lock()   {}
 func (*CustomLock) Unlock() {}
 
 func Ok(*CustomLock) {}
-func Bad(CustomLock) {} // ERROR "Bad passes lock by value: testdata.CustomLock"
+func Bad(CustomLock) {} // ERROR "Bad passes lock by value: copylock.CustomLock"
 
 // Passing lock values into interface function arguments
 func FuncCallInterfaceArg(f func(a int, b interface{})) {
@@ -90,7 +90,7 @@ func FuncCallInterfaceArg(f func(a int, b interface{})) {
 	f(3, &sync.Mutex{})
 	f(4, m) // ERROR "call of f copies lock value: sync.Mutex"
 	f(5, t) // ERROR "call of f copies lock value: struct.lock sync.Mutex. contains sync.Mutex"
-	var fntab []func(t)
+	var fntab []func(interface{})
 	fntab[0](t) // ERROR "call of fntab.0. copies lock value: struct.lock sync.Mutex. contains sync.Mutex"
 }
 
@@ -114,9 +114,10 @@ func ReturnViaInterface(x int) (int, interface{}) {
 // Some cases that we don't warn about.
 
 func AcceptedCases() {
-	x := EmbeddedRwMutex{} // composite literal on RHS is OK (#16227)
-	x = BadRet()           // function call on RHS is OK (#16227)
-	x = *OKRet()           // indirection of function call on RHS is OK (#16227)
+	x := EmbeddedRWMutex{} // composite literal on RHS is OK (#16227)
+	x = BadRet3()          // function call on RHS is OK (#16227)
+	x = *OkRet3()          // indirection of function call on RHS is OK (#16227)
+	_ = &x
 }
 
 // TODO: Unfortunate cases
@@ -126,7 +127,7 @@ func AcceptedCases() {
 // sync.Mutex gets called out, but without any reference to the sync.Once.
 type LocalOnce sync.Once
 
-func (LocalOnce) Bad() {} // ERROR "Bad passes lock by value: testdata.LocalOnce contains sync.Mutex"
+func (LocalOnce) Bad() {} // ERROR "Bad passes lock by value: copylock.LocalOnce contains sync.Mutex"
 
 // False negative:
 // LocalMutex doesn't have a Lock method.
@@ -5,13 +5,15 @@
 // This file contains tests for the copylock checker's
 // range statement analysis.
 
-package testdata
+package copylock
 
 import "sync"
 
 func rangeMutex() {
 	var mu sync.Mutex
+	_ = &mu
 	var i int
+	_ = &i
 
 	var s []sync.Mutex
 	for range s {
@@ -19,34 +21,42 @@ func rangeMutex() {
 	for i = range s {
 	}
 	for i := range s {
+		_ = &i
 	}
 	for i, _ = range s {
 	}
 	for i, _ := range s {
+		_ = &i
 	}
 	for _, mu = range s { // ERROR "range var mu copies lock: sync.Mutex"
 	}
 	for _, m := range s { // ERROR "range var m copies lock: sync.Mutex"
+		_ = &m
 	}
 	for i, mu = range s { // ERROR "range var mu copies lock: sync.Mutex"
 	}
 	for i, m := range s { // ERROR "range var m copies lock: sync.Mutex"
+		_, _ = &i, &m
 	}
 
 	var a [3]sync.Mutex
 	for _, m := range a { // ERROR "range var m copies lock: sync.Mutex"
+		_ = &m
 	}
 
 	var m map[sync.Mutex]sync.Mutex
 	for k := range m { // ERROR "range var k copies lock: sync.Mutex"
+		_ = &k
 	}
 	for mu, _ = range m { // ERROR "range var mu copies lock: sync.Mutex"
 	}
 	for k, _ := range m { // ERROR "range var k copies lock: sync.Mutex"
+		_ = &k
 	}
 	for _, mu = range m { // ERROR "range var mu copies lock: sync.Mutex"
 	}
 	for _, v := range m { // ERROR "range var v copies lock: sync.Mutex"
+		_ = &v
 	}
 
 	var c chan sync.Mutex
@@ -55,6 +65,7 @@ func rangeMutex() {
 	for mu = range c { // ERROR "range var mu copies lock: sync.Mutex"
 	}
 	for v := range c { // ERROR "range var v copies lock: sync.Mutex"
+		_ = &v
 	}
 
 	// Test non-idents in range variables
@@ -2,71 +2,67 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-// +build ignore
-
 // This file contains tests for the dead code checker.
 
-package testdata
+package deadcode
 
 type T int
 
 var x interface{}
 var c chan int
 
-func external() int // ok
-
-func _() int {
+func _() {
 }
 
-func _() int {
+func _() {
 	print(1)
 }
 
-func _() int {
+func _() {
 	print(1)
-	return 2
+	return
 	println() // ERROR "unreachable code"
 }
 
-func _() int {
+func _() {
 L:
 	print(1)
 	goto L
 	println() // ERROR "unreachable code"
 }
 
-func _() int {
+func _() {
 	print(1)
 	panic(2)
 	println() // ERROR "unreachable code"
 }
 
 // but only builtin panic
-func _() int {
+func _() {
 	var panic = func(int) {}
 	print(1)
 	panic(2)
 	println() // ok
 }
 
-func _() int {
+func _() {
 	{
 		print(1)
-		return 2
+		return
 		println() // ERROR "unreachable code"
 	}
 	println() // ok
 }
 
-func _() int {
+func _() {
 	{
 		print(1)
-		return 2
+		return
 	}
 	println() // ERROR "unreachable code"
 }
 
-func _() int {
+func _() {
 L:
 	{
 		print(1)
@@ -76,7 +72,7 @@ L:
 	println() // ok
 }
 
-func _() int {
+func _() {
 L:
 	{
 		print(1)
@@ -85,14 +81,14 @@ L:
 	println() // ERROR "unreachable code"
 }
 
-func _() int {
+func _() {
 	print(1)
 	{
 		panic(2)
 	}
 }
 
-func _() int {
+func _() {
 	print(1)
 	{
 		panic(2)
@@ -100,7 +96,7 @@ func _() int {
 	}
 }
 
-func _() int {
+func _() {
 	print(1)
 	{
 		panic(2)
@@ -108,14 +104,14 @@ func _() int {
 	println() // ERROR "unreachable code"
 }
 
-func _() int {
+func _() {
 	print(1)
-	return 2
+	return
 	{ // ERROR "unreachable code"
 	}
 }
 
-func _() int {
+func _() {
 L:
 	print(1)
 	goto L
@@ -123,23 +119,23 @@ L:
 	}
 }
 
-func _() int {
+func _() {
 	print(1)
 	panic(2)
 	{ // ERROR "unreachable code"
 	}
 }
 
-func _() int {
+func _() {
 	{
 		print(1)
-		return 2
+		return
 		{ // ERROR "unreachable code"
 		}
 	}
 }
 
-func _() int {
+func _() {
 L:
 	{
 		print(1)
@@ -149,7 +145,7 @@ L:
 	}
 }
 
-func _() int {
+func _() {
 	print(1)
 	{
 		panic(2)
@@ -158,16 +154,16 @@ func _() int {
 	}
 }
 
-func _() int {
+func _() {
 	{
 		print(1)
-		return 2
+		return
 	}
 	{ // ERROR "unreachable code"
 	}
 }
 
-func _() int {
+func _() {
 L:
 	{
 		print(1)
@@ -177,7 +173,7 @@ L:
 	}
 }
 
-func _() int {
+func _() {
 	print(1)
 	{
 		panic(2)
@@ -186,7 +182,7 @@ func _() int {
 	}
 }
 
-func _() int {
+func _() {
 	print(1)
 	if x == nil {
 		panic(2)
@@ -196,7 +192,7 @@ func _() int {
 	println() // ERROR "unreachable code"
 }
 
-func _() int {
+func _() {
 L:
 	print(1)
 	if x == nil {
@@ -207,13 +203,13 @@ L:
 	println() // ERROR "unreachable code"
 }
 
-func _() int {
+func _() {
 L:
 	print(1)
 	if x == nil {
 		panic(2)
 	} else if x == 1 {
-		return 0
+		return
 	} else if x != 2 {
 		panic(3)
 	} else {
@@ -225,7 +221,7 @@ L:
 // if-else chain missing final else is not okay, even if the
 // conditions cover every possible case.
 
-func _() int {
+func _() {
 	print(1)
 	if x == nil {
 		panic(2)
@@ -235,7 +231,7 @@ func _() int {
 	println() // ok
 }
 
-func _() int {
+func _() {
 	print(1)
 	if x == nil {
 		panic(2)
@@ -243,27 +239,26 @@ func _() int {
 	println() // ok
 }
 
-func _() int {
-L:
+func _() {
 	print(1)
 	if x == nil {
 		panic(2)
 	} else if x == 1 {
-		return 0
+		return
 	} else if x != 1 {
 		panic(3)
 	}
 	println() // ok
 }
 
-func _() int {
+func _() {
 	print(1)
 	for {
 	}
 	println() // ERROR "unreachable code"
 }
 
-func _() int {
+func _() {
 	for {
 		for {
 			break
@@ -272,7 +267,7 @@ func _() int {
 	println() // ERROR "unreachable code"
 }
 
-func _() int {
+func _() {
 	for {
 		for {
 			break
@@ -281,7 +276,7 @@ func _() int {
 	}
 }
 
-func _() int {
+func _() {
 	for {
 		for {
 			continue
@@ -290,7 +285,7 @@ func _() int {
 	}
 }
 
-func _() int {
+func _() {
 	for {
 	L:
 		for {
@@ -300,7 +295,7 @@ func _() int {
 	println() // ERROR "unreachable code"
 }
 
-func _() int {
+func _() {
 	print(1)
 	for {
 		break
@@ -308,7 +303,7 @@ func _() int {
 	println() // ok
 }
 
-func _() int {
+func _() {
 	for {
 		for {
 		}
@@ -317,7 +312,7 @@ func _() int {
 	println() // ok
 }
 
-func _() int {
+func _() {
 L:
 	for {
 		for {
@@ -327,14 +322,14 @@ L:
 	println() // ok
 }
 
-func _() int {
+func _() {
 	print(1)
 	for x == nil {
 	}
 	println() // ok
 }
 
-func _() int {
+func _() {
 	for x == nil {
 		for {
 			break
@@ -343,7 +338,7 @@ func _() int {
 	println() // ok
 }
 
-func _() int {
+func _() {
 	for x == nil {
 	L:
 		for {
@@ -353,14 +348,14 @@ func _() int {
 	println() // ok
 }
 
-func _() int {
+func _() {
 	print(1)
 	for true {
 	}
 	println() // ok
 }
 
-func _() int {
+func _() {
 	for true {
 		for {
 			break
@@ -369,7 +364,7 @@ func _() int {
 	println() // ok
 }
 
-func _() int {
+func _() {
 	for true {
 	L:
 		for {
@@ -379,13 +374,13 @@ func _() int {
 	println() // ok
 }
 
-func _() int {
+func _() {
 	print(1)
 	select {}
 	println() // ERROR "unreachable code"
 }
 
-func _() int {
+func _() {
 	print(1)
 	select {
 	case <-c:
@@ -395,7 +390,7 @@ func _() int {
 	}
 }
 
-func _() int {
+func _() {
 	print(1)
 	select {
 	case <-c:
@@ -405,7 +400,7 @@ func _() int {
 	println() // ERROR "unreachable code"
 }
 
-func _() int {
+func _() {
 	print(1)
 	select {
 	case <-c:
@@ -416,7 +411,7 @@ func _() int {
 	}
 }
 
-func _() int {
+func _() {
 	print(1)
 	select {
 	case <-c:
@@ -427,7 +422,7 @@ func _() int {
 	println() // ERROR "unreachable code"
 }
 
-func _() int {
+func _() {
 L:
 	print(1)
 	select {
@@ -442,7 +437,7 @@ L:
 	}
 }
 
-func _() int {
+func _() {
 L:
 	print(1)
 	select {
@@ -456,7 +451,7 @@ L:
 	println() // ERROR "unreachable code"
 }
 
-func _() int {
+func _() {
 	print(1)
 	select {
 	case <-c:
@@ -469,7 +464,7 @@ func _() int {
 	}
 }
 
-func _() int {
+func _() {
 	print(1)
 	select {
 	case <-c:
@@ -481,7 +476,7 @@ func _() int {
 	println() // ERROR "unreachable code"
 }
 
-func _() int {
+func _() {
 	print(1)
 	select {
 	case <-c:
@@ -490,7 +485,7 @@ func _() int {
 	println() // ok
 }
 
-func _() int {
+func _() {
 L:
 	print(1)
 	select {
@@ -504,7 +499,7 @@ L:
 	println() // ok
 }
 
-func _() int {
+func _() {
 	print(1)
 	select {
 	case <-c:
@@ -516,7 +511,7 @@ func _() int {
 	println() // ok
 }
 
-func _() int {
+func _() {
 	print(1)
 	select {
 	default:
@@ -525,7 +520,7 @@ func _() int {
 	println() // ok
 }
 
-func _() int {
+func _() {
 	print(1)
 	select {
 	case <-c:
@@ -536,7 +531,7 @@ func _() int {
 	println() // ok
 }
 
-func _() int {
+func _() {
 	print(1)
 L:
 	select {
@@ -549,7 +544,7 @@ L:
 	println() // ok
 }
 
-func _() int {
+func _() {
 	print(1)
 L:
 	select {
@@ -563,7 +558,7 @@ L:
 	println() // ok
 }
 
-func _() int {
+func _() {
 	print(1)
 	select {
 	case <-c:
@@ -576,7 +571,7 @@ func _() int {
 	println() // ok
 }
 
-func _() int {
+func _() {
 	print(1)
 	switch x {
 	case 1:
@@ -584,28 +579,28 @@ func _() int {
 		panic(3)
 		println() // ERROR "unreachable code"
 	default:
-		return 4
+		return
 		println() // ERROR "unreachable code"
 	}
 }
 
-func _() int {
+func _() {
 	print(1)
 	switch x {
 	case 1:
 		print(2)
 		panic(3)
 	default:
-		return 4
+		return
 	}
 	println() // ERROR "unreachable code"
 }
 
-func _() int {
+func _() {
 	print(1)
 	switch x {
 	default:
-		return 4
+		return
 		println() // ERROR "unreachable code"
 	case 1:
 		print(2)
@@ -614,11 +609,11 @@ func _() int {
 	}
 }
 
-func _() int {
+func _() {
 	print(1)
 	switch x {
 	default:
-		return 4
+		return
 	case 1:
 		print(2)
 		panic(3)
@@ -626,54 +621,54 @@ func _() int {
 	println() // ERROR "unreachable code"
 }
 
-func _() int {
+func _() {
 	print(1)
 	switch x {
 	case 1:
 		print(2)
 		fallthrough
 	default:
-		return 4
+		return
 		println() // ERROR "unreachable code"
 	}
 }
 
-func _() int {
+func _() {
 	print(1)
 	switch x {
 	case 1:
 		print(2)
 		fallthrough
 	default:
-		return 4
+		return
 	}
 	println() // ERROR "unreachable code"
 }
 
-func _() int {
+func _() {
 	print(1)
 	switch {
 	}
 	println() // ok
 }
 
-func _() int {
+func _() {
 	print(1)
 	switch x {
 	case 1:
 		print(2)
 		panic(3)
 	case 2:
-		return 4
+		return
 	}
 	println() // ok
 }
 
-func _() int {
+func _() {
 	print(1)
 	switch x {
 	case 2:
-		return 4
+		return
 	case 1:
 		print(2)
 		panic(3)
@@ -681,19 +676,19 @@ func _() int {
 	println() // ok
 }
 
-func _() int {
+func _() {
 	print(1)
 	switch x {
 	case 1:
 		print(2)
 		fallthrough
 	case 2:
-		return 4
+		return
 	}
 	println() // ok
 }
 
-func _() int {
+func _() {
 	print(1)
 	switch x {
 	case 1:
@@ -703,7 +698,7 @@ func _() int {
 	println() // ok
 }
 
-func _() int {
+func _() {
 	print(1)
 L:
 	switch x {
@@ -712,16 +707,16 @@ L:
 		panic(3)
 		break L // ERROR "unreachable code"
 	default:
-		return 4
+		return
 	}
 	println() // ok
 }
 
-func _() int {
+func _() {
 	print(1)
 	switch x {
 	default:
-		return 4
+		return
 		break // ERROR "unreachable code"
 	case 1:
 		print(2)
@@ -730,7 +725,7 @@ func _() int {
 	println() // ok
 }
 
-func _() int {
+func _() {
 	print(1)
 L:
 	switch x {
@@ -740,12 +735,12 @@ L:
 			break L
 		}
 	default:
-		return 4
+		return
 	}
 	println() // ok
 }
 
-func _() int {
+func _() {
 	print(1)
 	switch x.(type) {
 	case int:
@@ -753,28 +748,28 @@ func _() int {
 		panic(3)
 		println() // ERROR "unreachable code"
 	default:
-		return 4
+		return
 		println() // ERROR "unreachable code"
 	}
 }
 
-func _() int {
+func _() {
 	print(1)
 	switch x.(type) {
 	case int:
 		print(2)
 		panic(3)
 	default:
-		return 4
+		return
 	}
 	println() // ERROR "unreachable code"
 }
 
-func _() int {
+func _() {
 	print(1)
 	switch x.(type) {
 	default:
-		return 4
+		return
 		println() // ERROR "unreachable code"
 	case int:
 		print(2)
@@ -783,11 +778,11 @@ func _() int {
 	}
 }
 
-func _() int {
+func _() {
 	print(1)
 	switch x.(type) {
 	default:
-		return 4
+		return
 	case int:
 		print(2)
 		panic(3)
@@ -795,54 +790,30 @@ func _() int {
 	println() // ERROR "unreachable code"
 }
 
-func _() int {
-	print(1)
-	switch x.(type) {
-	case int:
-		print(2)
-		fallthrough
-	default:
-		return 4
-		println() // ERROR "unreachable code"
-	}
-}
-
-func _() int {
-	print(1)
-	switch x.(type) {
-	case int:
-		print(2)
-		fallthrough
-	default:
-		return 4
-	}
-	println() // ERROR "unreachable code"
-}
-
-func _() int {
+func _() {
 	print(1)
 	switch {
 	}
 	println() // ok
 }
 
-func _() int {
+func _() {
 	print(1)
 	switch x.(type) {
 	case int:
 		print(2)
 		panic(3)
 	case float64:
-		return 4
+		return
 	}
 	println() // ok
 }
 
-func _() int {
+func _() {
 	print(1)
 	switch x.(type) {
 	case float64:
-		return 4
+		return
 	case int:
 		print(2)
 		panic(3)
@@ -850,19 +821,7 @@ func _() int {
 	println() // ok
 }
 
-func _() int {
-	print(1)
-	switch x.(type) {
-	case int:
-		print(2)
-		fallthrough
-	case float64:
-		return 4
-	}
-	println() // ok
-}
-
-func _() int {
+func _() {
 	print(1)
 	switch x.(type) {
 	case int:
@@ -872,7 +831,7 @@ func _() int {
 	println() // ok
 }
 
-func _() int {
+func _() {
 	print(1)
 L:
 	switch x.(type) {
@@ -881,16 +840,16 @@ L:
 		panic(3)
 		break L // ERROR "unreachable code"
 	default:
-		return 4
+		return
 	}
 	println() // ok
 }
 
-func _() int {
+func _() {
 	print(1)
 	switch x.(type) {
 	default:
-		return 4
+		return
 		break // ERROR "unreachable code"
 	case int:
 		print(2)
@@ -899,7 +858,7 @@ func _() int {
 	println() // ok
 }
 
-func _() int {
+func _() {
 	print(1)
 L:
 	switch x.(type) {
@@ -909,7 +868,7 @@ L:
 			break L
 		}
 	default:
-		return 4
+		return
 	}
 	println() // ok
 }
@@ -917,48 +876,48 @@ L:
 // again, but without the leading print(1).
 // testing that everything works when the terminating statement is first.
 
-func _() int {
+func _() {
 	println() // ok
 }
 
-func _() int {
-	return 2
+func _() {
+	return
 	println() // ERROR "unreachable code"
 }
 
-func _() int {
+func _() {
 L:
 	goto L
 	println() // ERROR "unreachable code"
 }
 
-func _() int {
+func _() {
 	panic(2)
 	println() // ERROR "unreachable code"
 }
 
 // but only builtin panic
-func _() int {
+func _() {
 	var panic = func(int) {}
 	panic(2)
 	println() // ok
 }
 
-func _() int {
+func _() {
 	{
-		return 2
+		return
 		println() // ERROR "unreachable code"
 	}
 }
 
-func _() int {
+func _() {
 	{
-		return 2
+		return
 	}
 	println() // ERROR "unreachable code"
 }
 
-func _() int {
+func _() {
 L:
 	{
 		goto L
@@ -966,7 +925,7 @@ L:
 	}
 }
 
-func _() int {
+func _() {
 L:
 	{
 		goto L
@@ -974,28 +933,28 @@ L:
 	println() // ERROR "unreachable code"
 }
 
-func _() int {
+func _() {
 	{
 		panic(2)
 		println() // ERROR "unreachable code"
 	}
 }
 
-func _() int {
+func _() {
 	{
 		panic(2)
 	}
 	println() // ERROR "unreachable code"
 }
 
-func _() int {
-	return 2
+func _() {
+	return
 	{ // ERROR "unreachable code"
 	}
 	println() // ok
 }
 
-func _() int {
+func _() {
 L:
 	goto L
 	{ // ERROR "unreachable code"
@@ -1003,23 +962,23 @@ L:
 	println() // ok
 }
 
-func _() int {
+func _() {
 	panic(2)
 	{ // ERROR "unreachable code"
 	}
 	println() // ok
 }
 
-func _() int {
+func _() {
 	{
-		return 2
+		return
 		{ // ERROR "unreachable code"
 		}
 	}
 	println() // ok
 }
 
-func _() int {
+func _() {
 L:
 	{
 		goto L
@@ -1029,7 +988,7 @@ L:
 	println() // ok
 }
 
-func _() int {
+func _() {
 	{
 		panic(2)
 		{ // ERROR "unreachable code"
@@ -1038,16 +997,16 @@ func _() int {
 	println() // ok
 }
 
-func _() int {
+func _() {
 	{
-		return 2
+		return
 	}
 	{ // ERROR "unreachable code"
 	}
 	println() // ok
 }
 
-func _() int {
+func _() {
 L:
 	{
 		goto L
@@ -1057,7 +1016,7 @@ L:
 	println() // ok
 }
 
-func _() int {
+func _() {
 	{
 		panic(2)
 	}
@@ -1068,58 +1027,58 @@ func _() int {
 
 // again, with func literals
 
-var _ = func() int {
+var _ = func() {
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
-	return 2
+	return
 	println() // ERROR "unreachable code"
 }
 
-var _ = func() int {
+var _ = func() {
 L:
 	print(1)
 	goto L
 	println() // ERROR "unreachable code"
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	panic(2)
 	println() // ERROR "unreachable code"
 }
 
 // but only builtin panic
-var _ = func() int {
+var _ = func() {
 	var panic = func(int) {}
 	print(1)
 	panic(2)
 	println() // ok
 }
 
-var _ = func() int {
+var _ = func() {
 	{
 		print(1)
-		return 2
+		return
 		println() // ERROR "unreachable code"
 	}
 	println() // ok
 }
 
-var _ = func() int {
+var _ = func() {
 	{
 		print(1)
-		return 2
+		return
 	}
 	println() // ERROR "unreachable code"
 }
 
-var _ = func() int {
+var _ = func() {
 L:
 	{
 		print(1)
@@ -1129,7 +1088,7 @@ L:
 	println() // ok
 }
 
-var _ = func() int {
+var _ = func() {
 L:
 	{
 		print(1)
@@ -1138,14 +1097,14 @@ L:
 	println() // ERROR "unreachable code"
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	{
 		panic(2)
 	}
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	{
 		panic(2)
@@ -1153,7 +1112,7 @@ var _ = func() int {
 	}
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	{
 		panic(2)
@@ -1161,14 +1120,14 @@ var _ = func() int {
 	println() // ERROR "unreachable code"
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
-	return 2
+	return
 	{ // ERROR "unreachable code"
 	}
 }
 
-var _ = func() int {
+var _ = func() {
 L:
 	print(1)
 	goto L
@@ -1176,23 +1135,23 @@ L:
 	}
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	panic(2)
 	{ // ERROR "unreachable code"
 	}
 }
 
-var _ = func() int {
+var _ = func() {
 	{
 		print(1)
-		return 2
+		return
 		{ // ERROR "unreachable code"
 		}
 	}
 }
 
-var _ = func() int {
+var _ = func() {
 L:
 	{
 		print(1)
@@ -1202,7 +1161,7 @@ L:
 	}
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	{
 		panic(2)
@@ -1211,16 +1170,16 @@ var _ = func() int {
 	}
 }
 
-var _ = func() int {
+var _ = func() {
 	{
 		print(1)
-		return 2
+		return
 	}
 	{ // ERROR "unreachable code"
 	}
 }
 
-var _ = func() int {
+var _ = func() {
 L:
 	{
 		print(1)
@@ -1230,7 +1189,7 @@ L:
 	}
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	{
 		panic(2)
@@ -1239,7 +1198,7 @@ var _ = func() int {
 	}
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	if x == nil {
 		panic(2)
@@ -1249,7 +1208,7 @@ var _ = func() int {
 	println() // ERROR "unreachable code"
 }
 
-var _ = func() int {
+var _ = func() {
 L:
 	print(1)
 	if x == nil {
@@ -1260,13 +1219,13 @@ L:
 	println() // ERROR "unreachable code"
 }
 
-var _ = func() int {
+var _ = func() {
 L:
 	print(1)
 	if x == nil {
 		panic(2)
 	} else if x == 1 {
-		return 0
+		return
 	} else if x != 2 {
 		panic(3)
 	} else {
@@ -1278,7 +1237,7 @@ L:
 // if-else chain missing final else is not okay, even if the
 // conditions cover every possible case.
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	if x == nil {
 		panic(2)
@@ -1288,7 +1247,7 @@ var _ = func() int {
 	println() // ok
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	if x == nil {
 		panic(2)
@@ -1296,27 +1255,26 @@ var _ = func() int {
 	println() // ok
 }
 
-var _ = func() int {
-L:
+var _ = func() {
 	print(1)
 	if x == nil {
 		panic(2)
 	} else if x == 1 {
-		return 0
+		return
 	} else if x != 1 {
 		panic(3)
 	}
 	println() // ok
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	for {
 	}
 	println() // ERROR "unreachable code"
 }
 
-var _ = func() int {
+var _ = func() {
 	for {
 		for {
 			break
@@ -1325,7 +1283,7 @@ var _ = func() int {
 	println() // ERROR "unreachable code"
 }
 
-var _ = func() int {
+var _ = func() {
 	for {
 		for {
 			break
@@ -1334,7 +1292,7 @@ var _ = func() int {
 	}
 }
 
-var _ = func() int {
+var _ = func() {
 	for {
 		for {
 			continue
@@ -1343,7 +1301,7 @@ var _ = func() int {
 	}
 }
 
-var _ = func() int {
+var _ = func() {
 	for {
 	L:
 		for {
@@ -1353,7 +1311,7 @@ var _ = func() int {
 	println() // ERROR "unreachable code"
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	for {
 		break
@@ -1361,7 +1319,7 @@ var _ = func() int {
 	println() // ok
 }
 
-var _ = func() int {
+var _ = func() {
 	for {
 		for {
 		}
@@ -1370,7 +1328,7 @@ var _ = func() int {
 	println() // ok
 }
 
-var _ = func() int {
+var _ = func() {
 L:
 	for {
 		for {
@@ -1380,14 +1338,14 @@ L:
 	println() // ok
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	for x == nil {
 	}
 	println() // ok
 }
 
-var _ = func() int {
+var _ = func() {
 	for x == nil {
 		for {
 			break
@@ -1396,7 +1354,7 @@ var _ = func() int {
 	println() // ok
 }
 
-var _ = func() int {
+var _ = func() {
 	for x == nil {
 	L:
 		for {
@@ -1406,14 +1364,14 @@ var _ = func() int {
 	println() // ok
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	for true {
 	}
 	println() // ok
 }
 
-var _ = func() int {
+var _ = func() {
 	for true {
 		for {
 			break
@@ -1422,7 +1380,7 @@ var _ = func() int {
 	println() // ok
 }
 
-var _ = func() int {
+var _ = func() {
 	for true {
 	L:
 		for {
@@ -1432,13 +1390,13 @@ var _ = func() int {
 	println() // ok
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	select {}
 	println() // ERROR "unreachable code"
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	select {
 	case <-c:
@@ -1448,7 +1406,7 @@ var _ = func() int {
 	}
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	select {
 	case <-c:
@@ -1458,7 +1416,7 @@ var _ = func() int {
 	println() // ERROR "unreachable code"
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	select {
 	case <-c:
@@ -1469,7 +1427,7 @@ var _ = func() int {
 	}
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	select {
 	case <-c:
@@ -1480,7 +1438,7 @@ var _ = func() int {
 	println() // ERROR "unreachable code"
 }
 
-var _ = func() int {
+var _ = func() {
 L:
 	print(1)
 	select {
@@ -1495,7 +1453,7 @@ L:
 	}
 }
 
-var _ = func() int {
+var _ = func() {
 L:
 	print(1)
 	select {
@@ -1509,7 +1467,7 @@ L:
 	println() // ERROR "unreachable code"
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	select {
 	case <-c:
@@ -1522,7 +1480,7 @@ var _ = func() int {
 	}
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	select {
 	case <-c:
@@ -1534,7 +1492,7 @@ var _ = func() int {
 	println() // ERROR "unreachable code"
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	select {
 	case <-c:
@@ -1543,7 +1501,7 @@ var _ = func() int {
 	println() // ok
 }
 
-var _ = func() int {
+var _ = func() {
 L:
 	print(1)
 	select {
@@ -1557,7 +1515,7 @@ L:
 	println() // ok
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	select {
 	case <-c:
@@ -1569,7 +1527,7 @@ var _ = func() int {
 	println() // ok
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	select {
 	default:
@@ -1578,7 +1536,7 @@ var _ = func() int {
 	println() // ok
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	select {
 	case <-c:
@@ -1589,7 +1547,7 @@ var _ = func() int {
 	println() // ok
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 L:
 	select {
@@ -1602,7 +1560,7 @@ L:
 	println() // ok
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 L:
 	select {
@@ -1616,7 +1574,7 @@ L:
 	println() // ok
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	select {
 	case <-c:
@@ -1629,7 +1587,7 @@ var _ = func() int {
 	println() // ok
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	switch x {
 	case 1:
@@ -1637,28 +1595,28 @@ var _ = func() int {
 		panic(3)
 		println() // ERROR "unreachable code"
 	default:
-		return 4
+		return
 		println() // ERROR "unreachable code"
 	}
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	switch x {
 	case 1:
 		print(2)
 		panic(3)
 	default:
-		return 4
+		return
 	}
 	println() // ERROR "unreachable code"
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	switch x {
 	default:
-		return 4
+		return
 		println() // ERROR "unreachable code"
 	case 1:
 		print(2)
@@ -1667,11 +1625,11 @@ var _ = func() int {
 	}
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	switch x {
 	default:
-		return 4
+		return
 	case 1:
 		print(2)
 		panic(3)
@@ -1679,54 +1637,54 @@ var _ = func() int {
 	println() // ERROR "unreachable code"
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	switch x {
 	case 1:
 		print(2)
 		fallthrough
 	default:
-		return 4
+		return
 		println() // ERROR "unreachable code"
 	}
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	switch x {
 	case 1:
 		print(2)
 		fallthrough
 	default:
-		return 4
+		return
 	}
 	println() // ERROR "unreachable code"
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	switch {
 	}
 	println() // ok
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	switch x {
 	case 1:
 		print(2)
 		panic(3)
 	case 2:
-		return 4
+		return
 	}
 	println() // ok
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	switch x {
 	case 2:
-		return 4
+		return
 	case 1:
 		print(2)
 		panic(3)
@@ -1734,19 +1692,19 @@ var _ = func() int {
 	println() // ok
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	switch x {
 	case 1:
 		print(2)
 		fallthrough
 	case 2:
-		return 4
+		return
 	}
 	println() // ok
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	switch x {
 	case 1:
@@ -1756,7 +1714,7 @@ var _ = func() int {
 	println() // ok
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 L:
 	switch x {
@@ -1765,16 +1723,16 @@ L:
 		panic(3)
 		break L // ERROR "unreachable code"
 	default:
-		return 4
+		return
 	}
 	println() // ok
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	switch x {
 	default:
-		return 4
+		return
 		break // ERROR "unreachable code"
 	case 1:
 		print(2)
@@ -1783,7 +1741,7 @@ var _ = func() int {
 	println() // ok
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 L:
 	switch x {
@@ -1793,12 +1751,12 @@ L:
 			break L
 		}
 	default:
-		return 4
+		return
 	}
 	println() // ok
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	switch x.(type) {
 	case int:
@@ -1806,28 +1764,28 @@ var _ = func() int {
 		panic(3)
 		println() // ERROR "unreachable code"
 	default:
-		return 4
+		return
 		println() // ERROR "unreachable code"
 	}
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	switch x.(type) {
 	case int:
 		print(2)
 		panic(3)
 	default:
-		return 4
+		return
 	}
 	println() // ERROR "unreachable code"
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	switch x.(type) {
 	default:
-		return 4
+		return
 		println() // ERROR "unreachable code"
 	case int:
 		print(2)
@@ -1836,11 +1794,11 @@ var _ = func() int {
 	}
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	switch x.(type) {
 	default:
-		return 4
+		return
 	case int:
 		print(2)
 		panic(3)
@@ -1848,54 +1806,30 @@ var _ = func() int {
 	println() // ERROR "unreachable code"
 }
 
-var _ = func() int {
-	print(1)
-	switch x.(type) {
-	case int:
-		print(2)
-		fallthrough
-	default:
-		return 4
-		println() // ERROR "unreachable code"
-	}
-}
-
-var _ = func() int {
-	print(1)
-	switch x.(type) {
-	case int:
-		print(2)
-		fallthrough
-	default:
-		return 4
-	}
-	println() // ERROR "unreachable code"
-}
-
-var _ = func() int {
+var _ = func() {
 	print(1)
 	switch {
 	}
 	println() // ok
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	switch x.(type) {
 	case int:
 		print(2)
 		panic(3)
 	case float64:
-		return 4
+		return
 	}
 	println() // ok
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	switch x.(type) {
 	case float64:
-		return 4
+		return
 	case int:
 		print(2)
 		panic(3)
@@ -1903,19 +1837,7 @@ var _ = func() int {
 	println() // ok
 }
 
-var _ = func() int {
-	print(1)
-	switch x.(type) {
-	case int:
-		print(2)
-		fallthrough
-	case float64:
-		return 4
-	}
-	println() // ok
-}
-
-var _ = func() int {
+var _ = func() {
 	print(1)
 	switch x.(type) {
 	case int:
@@ -1925,7 +1847,7 @@ var _ = func() int {
 	println() // ok
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 L:
 	switch x.(type) {
@@ -1934,16 +1856,16 @@ L:
 		panic(3)
 		break L // ERROR "unreachable code"
 	default:
-		return 4
+		return
 	}
 	println() // ok
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 	switch x.(type) {
 	default:
-		return 4
+		return
 		break // ERROR "unreachable code"
 	case int:
 		print(2)
@@ -1952,7 +1874,7 @@ var _ = func() int {
 	println() // ok
 }
 
-var _ = func() int {
+var _ = func() {
 	print(1)
 L:
 	switch x.(type) {
@@ -1962,7 +1884,7 @@ L:
 			break L
 		}
 	default:
-		return 4
+		return
 	}
 	println() // ok
 }
@@ -1970,48 +1892,48 @@ L:
 // again, but without the leading print(1).
 // testing that everything works when the terminating statement is first.
 
-var _ = func() int {
+var _ = func() {
 	println() // ok
 }
 
-var _ = func() int {
-	return 2
+var _ = func() {
+	return
 	println() // ERROR "unreachable code"
 }
 
-var _ = func() int {
+var _ = func() {
 L:
 	goto L
 	println() // ERROR "unreachable code"
 }
 
-var _ = func() int {
+var _ = func() {
 	panic(2)
 	println() // ERROR "unreachable code"
 }
 
 // but only builtin panic
-var _ = func() int {
+var _ = func() {
 	var panic = func(int) {}
 	panic(2)
 	println() // ok
 }
 
-var _ = func() int {
+var _ = func() {
 	{
-		return 2
+		return
 		println() // ERROR "unreachable code"
 	}
 }
 
-var _ = func() int {
+var _ = func() {
 	{
-		return 2
+		return
 	}
 	println() // ERROR "unreachable code"
 }
 
-var _ = func() int {
+var _ = func() {
 L:
 	{
 		goto L
@@ -2019,7 +1941,7 @@ L:
 	}
 }
 
-var _ = func() int {
+var _ = func() {
 L:
 	{
 		goto L
@@ -2027,28 +1949,28 @@ L:
 	println() // ERROR "unreachable code"
 }
 
-var _ = func() int {
+var _ = func() {
 	{
 		panic(2)
 		println() // ERROR "unreachable code"
 	}
 }
 
-var _ = func() int {
+var _ = func() {
 	{
 		panic(2)
 	}
 	println() // ERROR "unreachable code"
 }
 
-var _ = func() int {
-	return 2
+var _ = func() {
+	return
 	{ // ERROR "unreachable code"
 	}
 	println() // ok
 }
 
-var _ = func() int {
+var _ = func() {
 L:
 	goto L
 	{ // ERROR "unreachable code"
@@ -2056,23 +1978,23 @@ L:
 	println() // ok
 }
 
-var _ = func() int {
+var _ = func() {
 	panic(2)
 	{ // ERROR "unreachable code"
 	}
 	println() // ok
 }
 
-var _ = func() int {
+var _ = func() {
 	{
-		return 2
+		return
 		{ // ERROR "unreachable code"
 		}
 	}
 	println() // ok
 }
 
-var _ = func() int {
+var _ = func() {
 L:
 	{
 		goto L
@@ -2082,7 +2004,7 @@ L:
 	println() // ok
 }
 
-var _ = func() int {
+var _ = func() {
 	{
 		panic(2)
 		{ // ERROR "unreachable code"
@@ -2091,16 +2013,16 @@ var _ = func() int {
 	println() // ok
 }
 
-var _ = func() int {
+var _ = func() {
 	{
-		return 2
+		return
 	}
 	{ // ERROR "unreachable code"
 	}
 	println() // ok
 }
 
-var _ = func() int {
+var _ = func() {
 L:
 	{
 		goto L
@@ -2110,7 +2032,7 @@ L:
 	println() // ok
 }
 
-var _ = func() int {
+var _ = func() {
 	{
 		panic(2)
 	}
@@ -2118,8 +2040,3 @@ var _ = func() int {
 	}
 	println() // ok
 }
-
-var _ = func() {
-	// goto without label used to panic
-	goto
-}
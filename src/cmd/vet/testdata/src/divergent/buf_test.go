@@ -2,6 +2,10 @@
 
 package buf_test
 
+import "divergent" // package buf
+
+var _ buf.Buf
+
 func Example() {} // OK because is package-level.
 
 func Example_suffix() {} // OK because refers to suffix annotation.
@@ -0,0 +1,35 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package externalprintf declares printf wrappers used by the print test.
+// Vet recognizes them as wrappers from their bodies and records that
+// fact for use when checking calls from other packages.
+package externalprintf
+
+import "fmt"
+
+func Printf(format string, args ...interface{}) {
+	fmt.Printf(format, args...)
+}
+
+func Println(args ...interface{}) {
+	fmt.Println(args...)
+}
+
+func Sprintf(format string, args ...interface{}) string {
+	return fmt.Sprintf(format, args...)
+}
+
+func Logf(level int, format string, args ...interface{}) {
+	Printf(format, args...)
+}
+
+func Errorf(level, code int, format string, args ...interface{}) error {
+	return fmt.Errorf(format, args...)
+}
+
+// NotAWrapper does not forward its arguments to a print function.
+func NotAWrapper(format string, args ...interface{}) {
+	fmt.Print(format)
+}
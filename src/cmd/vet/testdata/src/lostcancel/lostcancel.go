@@ -2,44 +2,58 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-package testdata
+package lostcancel
 
 import (
 	"context"
 	"log"
 	"os"
 	"testing"
+	"time"
 )
 
+var bg = context.Background()
+
 // Check the three functions and assignment forms (var, :=, =) we look for.
 // (Do these early: line numbers are fragile.)
 func _() {
-	var ctx, cancel = context.WithCancel() // ERROR "the cancel function is not used on all paths \(possible context leak\)"
-} // ERROR "this return statement may be reached without using the cancel var defined on line 17"
+	var _, cancel = context.WithCancel(bg) // ERROR "the cancel function is not used on all paths \(possible context leak\)"
+	if false {
+		_ = cancel
+	}
+} // ERROR "this return statement may be reached without using the cancel var defined on line 20"
 
 func _() {
-	ctx, cancel2 := context.WithDeadline() // ERROR "the cancel2 function is not used..."
-} // ERROR "may be reached without using the cancel2 var defined on line 21"
+	_, cancel2 := context.WithDeadline(bg, time.Time{}) // ERROR "the cancel2 function is not used..."
+	if false {
+		_ = cancel2
+	}
+} // ERROR "may be reached without using the cancel2 var defined on line 27"
 
 func _() {
 	var ctx context.Context
 	var cancel3 func()
-	ctx, cancel3 = context.WithTimeout() // ERROR "function is not used..."
-} // ERROR "this return statement may be reached without using the cancel3 var defined on line 27"
+	ctx, cancel3 = context.WithTimeout(bg, 0) // ERROR "function is not used..."
+	if false {
+		_ = cancel3
+	}
+	_ = ctx
+} // ERROR "this return statement may be reached without using the cancel3 var defined on line 36"
 
 func _() {
-	ctx, _ := context.WithCancel()  // ERROR "the cancel function returned by context.WithCancel should be called, not discarded, to avoid a context leak"
-	ctx, _ = context.WithTimeout()  // ERROR "the cancel function returned by context.WithTimeout should be called, not discarded, to avoid a context leak"
-	ctx, _ = context.WithDeadline() // ERROR "the cancel function returned by context.WithDeadline should be called, not discarded, to avoid a context leak"
+	ctx, _ := context.WithCancel(bg)               // ERROR "the cancel function returned by context.WithCancel should be called, not discarded, to avoid a context leak"
+	ctx, _ = context.WithTimeout(bg, 0)            // ERROR "the cancel function returned by context.WithTimeout should be called, not discarded, to avoid a context leak"
+	ctx, _ = context.WithDeadline(bg, time.Time{}) // ERROR "the cancel function returned by context.WithDeadline should be called, not discarded, to avoid a context leak"
+	_ = ctx
 }
 
 func _() {
-	ctx, cancel := context.WithCancel()
+	_, cancel := context.WithCancel(bg)
 	defer cancel() // ok
 }
 
 func _() {
-	ctx, cancel := context.WithCancel() // ERROR "not used on all paths"
+	_, cancel := context.WithCancel(bg) // ERROR "not used on all paths"
 	if condition {
 		cancel()
 	}
@@ -47,7 +61,7 @@ func _() {
 }
 
 func _() {
-	ctx, cancel := context.WithCancel()
+	_, cancel := context.WithCancel(bg)
 	if condition {
 		cancel()
 	} else {
@@ -59,7 +73,7 @@ func _() {
 }
 
 func _() {
-	ctx, cancel := context.WithCancel() // ERROR "not used on all paths"
+	_, cancel := context.WithCancel(bg) // ERROR "not used on all paths"
 	if condition {
 		cancel()
 	} else {
@@ -70,7 +84,7 @@ func _() {
 } // ERROR "this return statement may be reached without using the cancel var"
 
 func _() {
-	ctx, cancel := context.WithCancel()
+	_, cancel := context.WithCancel(bg)
 	// ok: used on all paths
 	switch someInt {
 	case 0:
@@ -88,7 +102,7 @@ func _() {
 }
 
 func _() {
-	ctx, cancel := context.WithCancel() // ERROR "not used on all paths"
+	_, cancel := context.WithCancel(bg) // ERROR "not used on all paths"
 	switch someInt {
 	case 0:
 		new(testing.T).FailNow()
@@ -103,12 +117,13 @@ func _() {
 	}
 } // ERROR "this return statement may be reached without using the cancel var"
 
-func _(ch chan int) int {
-	ctx, cancel := context.WithCancel() // ERROR "not used on all paths"
+func _(ch chan int) {
+	_, cancel := context.WithCancel(bg) // ERROR "not used on all paths"
 	select {
 	case <-ch:
 		new(testing.T).FailNow()
-	case y <- ch:
+	case y := <-ch:
+		_ = y
 		print("hi") // falls through to implicit return
 	case ch <- 1:
 		cancel()
@@ -118,17 +133,23 @@ func _(ch chan int) int {
 } // ERROR "this return statement may be reached without using the cancel var"
 
 func _(ch chan int) int {
-	ctx, cancel := context.WithCancel()
+	_, cancel := context.WithCancel(bg)
+	if false {
+		_ = cancel
+	}
 	// A blocking select must execute one of its cases.
 	select {
 	case <-ch:
-		panic()
+		panic(0)
 	}
 }
 
 func _() {
 	go func() {
-		ctx, cancel := context.WithCancel() // ERROR "not used on all paths"
+		ctx, cancel := context.WithCancel(bg) // ERROR "not used on all paths"
+		if false {
+			_ = cancel
+		}
 		print(ctx)
 	}() // ERROR "may be reached without using the cancel var"
 }
@@ -144,12 +165,12 @@ func _() {
 
 // Regression test for Go issue 16230.
 func _() (ctx context.Context, cancel func()) {
-	ctx, cancel = context.WithCancel()
+	ctx, cancel = context.WithCancel(bg)
 	return // a naked return counts as a load of the named result values
 }
 
 // Same as above, but for literal function.
 var _ = func() (ctx context.Context, cancel func()) {
-	ctx, cancel = context.WithCancel()
+	ctx, cancel = context.WithCancel(bg)
 	return
 }
@@ -6,7 +6,7 @@
 
 // This file contains the code to check canonical methods.
 
-package testdata
+package method
 
 import (
 	"fmt"
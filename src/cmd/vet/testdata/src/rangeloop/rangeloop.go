@@ -4,7 +4,7 @@
 
 // This file contains tests for the rangeloop checker.
 
-package testdata
+package rangeloop
 
 func RangeLoopTests() {
 	var s []int
@@ -62,7 +62,7 @@ func RangeLoopTests() {
 	for _, v := range s {
 		go func() {
 			_ = T{v: 1}
-			_ = []int{v: 1} // ERROR "loop variable v captured by func literal"
+			_ = map[int]int{v: 1} // ERROR "loop variable v captured by func literal"
 		}()
 	}
 
@@ -82,7 +82,7 @@ func RangeLoopTests() {
 		cdr *cons
 	}
 	var head *cons
-	for p := head; p != nil; p = p.next {
+	for p := head; p != nil; p = p.cdr {
 		go func() {
 			print(p.car) // ERROR "loop variable p captured by func literal"
 		}()
@@ -4,7 +4,7 @@
 
 // This file contains tests for the suspicious shift checker.
 
-package testdata
+package shift
 
 import (
 	"fmt"
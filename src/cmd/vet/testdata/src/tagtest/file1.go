@@ -6,5 +6,8 @@
 
 package main
 
+import "fmt"
+
 func main() {
+	fmt.Printf("%s", 0)
 }
@@ -6,5 +6,8 @@
 
 package main
 
+import "fmt"
+
 func ignore() {
+	fmt.Printf("%s", 0)
 }
@@ -1,7 +1,8 @@
-package testdata
+package testingpkg
 
 import (
 	"testing"
+	. "testing"
 )
 
 // Buf is a ...
@@ -19,11 +20,11 @@ var DefaultBuf Buf
 
 func Example() {} // OK because is package-level.
 
-func Example_goodSuffix() // OK because refers to suffix annotation.
+func Example_goodSuffix() {} // OK because refers to suffix annotation.
 
-func Example_BadSuffix() // ERROR "Example_BadSuffix has malformed example suffix: BadSuffix"
+func Example_BadSuffix() {} // ERROR "Example_BadSuffix has malformed example suffix: BadSuffix"
 
-func ExampleBuf() // OK because refers to known top-level type.
+func ExampleBuf() {} // OK because refers to known top-level type.
 
 func ExampleBuf_Append() {} // OK because refers to known method.
 
@@ -43,11 +44,11 @@ func ExampleBuf_Len(i int) {} // ERROR "ExampleBuf_Len should be niladic"
 
 // "Puffer" is German for "Buffer".
 
-func ExamplePuffer() // ERROR "ExamplePuffer refers to unknown identifier: Puffer"
+func ExamplePuffer() {} // ERROR "ExamplePuffer refers to unknown identifier: Puffer"
 
-func ExamplePuffer_Append() // ERROR "ExamplePuffer_Append refers to unknown identifier: Puffer"
+func ExamplePuffer_Append() {} // ERROR "ExamplePuffer_Append refers to unknown identifier: Puffer"
 
-func ExamplePuffer_suffix() // ERROR "ExamplePuffer_suffix refers to unknown identifier: Puffer"
+func ExamplePuffer_suffix() {} // ERROR "ExamplePuffer_suffix refers to unknown identifier: Puffer"
 
 func nonTest() {} // OK because it doesn't start with "Test".
 
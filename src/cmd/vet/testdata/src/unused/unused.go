@@ -4,7 +4,7 @@
 
 // This file contains tests for the unusedresult checker.
 
-package testdata
+package unused
 
 import (
 	"bytes"
@@ -6,39 +6,42 @@ package main_test
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"go/build"
 	"internal/testenv"
+	"io/ioutil"
+	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"testing"
 )
 
-const (
-	dataDir = "testdata"
-	binary  = "testvet.exe"
-)
+const dataDir = "testdata"
+
+var binary string
 
 // We implement TestMain so remove the test binary when all is done.
 func TestMain(m *testing.M) {
+	dir, err := ioutil.TempDir("", "vet_test")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	binary = filepath.Join(dir, "testvet.exe")
+
 	result := m.Run()
-	os.Remove(binary)
+	os.RemoveAll(dir)
 	os.Exit(result)
 }
 
-func MustHavePerl(t *testing.T) {
-	switch runtime.GOOS {
-	case "plan9", "windows":
-		t.Skipf("skipping test: perl not available on %s", runtime.GOOS)
-	}
-	if _, err := exec.LookPath("perl"); err != nil {
-		t.Skipf("skipping test: perl not found in path")
-	}
-}
-
 var (
 	buildMu sync.Mutex // guards following
 	built   = false    // We have built the binary.
@@ -55,7 +58,6 @@ func Build(t *testing.T) {
 		t.Skip("cannot run on this environment")
 	}
 	testenv.MustHaveGoBuild(t)
-	MustHavePerl(t)
 	cmd := exec.Command(testenv.GoToolPath(t), "build", "-o", binary)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
@@ -66,178 +68,396 @@ func Build(t *testing.T) {
 	built = true
 }
 
-func Vet(t *testing.T, files []string) {
-	errchk := filepath.Join(runtime.GOROOT(), "test", "errchk")
-	flags := []string{
-		"./" + binary,
-		"-printfuncs=Warn:1,Warnf:1",
-		"-all",
-		"-shadow",
-	}
-	cmd := exec.Command(errchk, append(flags, files...)...)
-	if !run(cmd, t) {
-		t.Fatal("vet command failed")
+// vetCmd returns a command that runs go vet, using the test binary
+// as the analysis tool, on pkgs in the GOPATH rooted at testdata.
+func vetCmd(t *testing.T, args ...string) *exec.Cmd {
+	cmd := exec.Command(testenv.GoToolPath(t), "vet", "-vettool="+binary)
+	cmd.Args = append(cmd.Args, args...)
+	testdata, err := filepath.Abs(dataDir)
+	if err != nil {
+		t.Fatal(err)
 	}
+	cmd.Env = append(os.Environ(), "GOPATH="+testdata, "GO111MODULE=off")
+	return cmd
 }
 
-// Run this shell script, but do it in Go so it can be run by "go test".
-// 	go build -o testvet
-// 	$(GOROOT)/test/errchk ./testvet -shadow -printfuncs='Warn:1,Warnf:1' testdata/*.go testdata/*.s
-// 	rm testvet
-//
-
-// TestVet tests self-contained files in testdata/*.go.
-//
-// If a file contains assembly or has inter-dependencies, it should be
-// in its own test, like TestVetAsm, TestDivergentPackagesExamples,
-// etc below.
+// TestVet checks each package in testdata/src against the
+// // ERROR comments in its source files.
 func TestVet(t *testing.T) {
-	Build(t)
 	t.Parallel()
-
-	// errchk ./testvet
-	gos, err := filepath.Glob(filepath.Join(dataDir, "*.go"))
-	if err != nil {
-		t.Fatal(err)
-	}
-	wide := runtime.GOMAXPROCS(0)
-	if wide > len(gos) {
-		wide = len(gos)
-	}
-	batch := make([][]string, wide)
-	for i, file := range gos {
-		// TODO: Remove print.go exception once we require type checking for everything,
-		// and then delete TestVetPrint.
-		if strings.HasSuffix(file, "print.go") {
-			continue
-		}
-		batch[i%wide] = append(batch[i%wide], file)
-	}
-	for i, files := range batch {
-		if len(files) == 0 {
-			continue
-		}
-		files := files
-		t.Run(fmt.Sprint(i), func(t *testing.T) {
+	Build(t)
+	for _, pkg := range []string{
+		"assign",
+		"atomic",
+		"bool",
+		"buildtag",
+		"cgo",
+		"composite",
+		"copylock",
+		"deadcode",
+		"divergent",
+		"httpresponse",
+		"lostcancel",
+		"method",
+		"nilfunc",
+		"print",
+		"rangeloop",
+		"shift",
+		"structtag",
+		"testingpkg",
+		"unsafeptr",
+		"unused",
+	} {
+		pkg := pkg
+		t.Run(pkg, func(t *testing.T) {
 			t.Parallel()
-			t.Logf("files: %q", files)
-			Vet(t, files)
-		})
-	}
-}
 
-func TestVetPrint(t *testing.T) {
-	Build(t)
-	errchk := filepath.Join(runtime.GOROOT(), "test", "errchk")
-	cmd := exec.Command(
-		errchk,
-		"go", "vet", "-vettool=./"+binary,
-		"-printf",
-		"-printfuncs=Warn:1,Warnf:1",
-		"testdata/print.go",
-	)
-	if !run(cmd, t) {
-		t.Fatal("vet command failed")
+			// Skip cgo test on platforms without cgo.
+			if pkg == "cgo" && !cgoEnabled(t) {
+				return
+			}
+
+			cmd := vetCmd(t, "-printfuncs=Warn,Warnf", pkg)
+			errchk(cmd, pkgFiles(t, pkg, runtime.GOOS, runtime.GOARCH), t)
+		})
 	}
 }
 
-func TestVetAsm(t *testing.T) {
-	Build(t)
+// pkgFiles returns the Go and assembly files of the testdata package pkg
+// that are selected by build constraints for goos/goarch.
+func pkgFiles(t *testing.T, pkg, goos, goarch string) []string {
+	ctxt := build.Default
+	ctxt.GOOS = goos
+	ctxt.GOARCH = goarch
+	ctxt.CgoEnabled = true
 
-	asmDir := filepath.Join(dataDir, "asm")
-	gos, err := filepath.Glob(filepath.Join(asmDir, "*.go"))
+	dir := filepath.Join(dataDir, "src", pkg)
+	names, err := filepath.Glob(filepath.Join(dir, "*.[gs]*"))
 	if err != nil {
 		t.Fatal(err)
 	}
-	asms, err := filepath.Glob(filepath.Join(asmDir, "*.s"))
-	if err != nil {
-		t.Fatal(err)
+	var files []string
+	for _, name := range names {
+		if ok, err := ctxt.MatchFile(dir, filepath.Base(name)); err != nil {
+			t.Fatal(err)
+		} else if ok {
+			files = append(files, name)
+		}
 	}
-
-	t.Parallel()
-	// errchk ./testvet
-	Vet(t, append(gos, asms...))
+	return files
 }
 
-func TestVetDirs(t *testing.T) {
+// asmArchs lists the architectures for which testdata/src/asm
+// contains assembly files.
+var asmArchs = []string{"386", "amd64", "arm", "mips64", "mipsle", "ppc64", "s390x"}
+
+// TestVetAsm checks the assembly files in testdata/src/asm.
+// Many of them do not assemble, so rather than use go vet,
+// which builds the package first, it runs the vet tool directly
+// on a configuration file for each architecture.
+func TestVetAsm(t *testing.T) {
 	t.Parallel()
 	Build(t)
-	for _, dir := range []string{
-		"testingpkg",
-		"divergent",
-		"buildtag",
-		"incomplete", // incomplete examples
-		"cgo",
-	} {
-		dir := dir
-		t.Run(dir, func(t *testing.T) {
-			t.Parallel()
-			gos, err := filepath.Glob(filepath.Join("testdata", dir, "*.go"))
+	dir, err := ioutil.TempDir("", "vet_asm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, arch := range asmArchs {
+		arch := arch
+		t.Run(arch, func(t *testing.T) {
+			var gofiles, sfiles []string
+			for _, file := range pkgFiles(t, "asm", "linux", arch) {
+				abs, err := filepath.Abs(file)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if strings.HasSuffix(abs, ".go") {
+					gofiles = append(gofiles, abs)
+				} else {
+					sfiles = append(sfiles, abs)
+				}
+			}
+			cfg, err := json.Marshal(map[string]interface{}{
+				"ID":         "asm",
+				"Compiler":   "gc",
+				"ImportPath": "asm",
+				"GoFiles":    gofiles,
+				"NonGoFiles": sfiles,
+				"VetxOutput": filepath.Join(dir, arch+".vetx"),
+			})
 			if err != nil {
 				t.Fatal(err)
 			}
-			Vet(t, gos)
+			cfgFile := filepath.Join(dir, arch+".cfg")
+			if err := ioutil.WriteFile(cfgFile, cfg, 0666); err != nil {
+				t.Fatal(err)
+			}
+			cmd := exec.Command(binary, "-asmdecl", cfgFile)
+			cmd.Env = append(os.Environ(), "GOOS=linux", "GOARCH="+arch)
+			errchk(cmd, append(gofiles, sfiles...), t)
 		})
 	}
 }
 
-func run(c *exec.Cmd, t *testing.T) bool {
+func cgoEnabled(t *testing.T) bool {
+	// Don't trust build.Default.CgoEnabled as it is false for
+	// cross-builds unless CGO_ENABLED is explicitly specified.
+	// That's fine for the builders, but causes commands like
+	// 'GOARCH=386 go test .' to fail.
+	// Instead, we ask the go command.
+	cmd := exec.Command(testenv.GoToolPath(t), "list", "-f", "{{context.CgoEnabled}}")
+	out, _ := cmd.CombinedOutput()
+	return string(out) == "true\n"
+}
+
+func errchk(c *exec.Cmd, files []string, t *testing.T) {
 	output, err := c.CombinedOutput()
-	if err != nil {
+	if _, ok := err.(*exec.ExitError); !ok {
 		t.Logf("vet output:\n%s", output)
 		t.Fatal(err)
 	}
-	// Errchk delights by not returning non-zero status if it finds errors, so we look at the output.
-	// It prints "BUG" if there is a failure.
-	if !c.ProcessState.Success() {
-		t.Logf("vet output:\n%s", output)
-		return false
+	fullshort := make([]string, 0, len(files)*2)
+	for _, f := range files {
+		fullshort = append(fullshort, f, filepath.Base(f))
 	}
-	ok := !bytes.Contains(output, []byte("BUG"))
-	if !ok {
-		t.Logf("vet output:\n%s", output)
+	err = errorCheck(string(output), false, fullshort...)
+	if err != nil {
+		t.Errorf("error check failed: %s", err)
 	}
-	return ok
 }
 
 // TestTags verifies that the -tags argument controls which files to check.
 func TestTags(t *testing.T) {
 	t.Parallel()
 	Build(t)
-	for _, tag := range []string{"testtag", "x testtag y", "x,testtag,y"} {
+	for _, tag := range []string{"testtag", "x testtag y"} {
 		tag := tag
 		t.Run(tag, func(t *testing.T) {
 			t.Parallel()
 			t.Logf("-tags=%s", tag)
-			args := []string{
-				"-tags=" + tag,
-				"-v", // We're going to look at the files it examines.
-				"testdata/tagtest",
-			}
-			cmd := exec.Command("./"+binary, args...)
+			cmd := vetCmd(t, "-tags="+tag, "tagtest")
 			output, err := cmd.CombinedOutput()
-			if err != nil {
-				t.Fatal(err)
+			if err == nil {
+				t.Fatalf("vet succeeded, want diagnostics:\n%s", output)
 			}
 			// file1 has testtag and file2 has !testtag.
-			if !bytes.Contains(output, []byte(filepath.Join("tagtest", "file1.go"))) {
-				t.Error("file1 was excluded, should be included")
+			if !bytes.Contains(output, []byte("file1.go")) {
+				t.Errorf("file1 was excluded, should be included:\n%s", output)
 			}
-			if bytes.Contains(output, []byte(filepath.Join("tagtest", "file2.go"))) {
-				t.Error("file2 was included, should be excluded")
+			if bytes.Contains(output, []byte("file2.go")) {
+				t.Errorf("file2 was included, should be excluded:\n%s", output)
 			}
 		})
 	}
 }
 
-// Issue #21188.
-func TestVetVerbose(t *testing.T) {
-	t.Parallel()
-	Build(t)
-	cmd := exec.Command("./"+binary, "-v", "-all", "testdata/cgo/cgo3.go")
-	out, err := cmd.CombinedOutput()
+// All declarations below were adapted from test/run.go.
+
+// errorCheck matches errors in outStr against comments in source files.
+// For each line of the source files which should generate an error,
+// there should be a comment of the form // ERROR "regexp".
+// If outStr has an error for a line which has no such comment,
+// this function will report an error.
+// Likewise if outStr does not have an error for a line which has a comment,
+// or if the error message does not match the <regexp>.
+// The <regexp> syntax is Perl but it's best to stick to egrep.
+//
+// Sources files are supplied as fullshort slice.
+// It consists of pairs: full path to source file and its base name.
+func errorCheck(outStr string, wantAuto bool, fullshort ...string) (err error) {
+	var errs []error
+	out := splitOutput(outStr, wantAuto)
+	// Cut directory name.
+	for i := range out {
+		for j := 0; j < len(fullshort); j += 2 {
+			full, short := fullshort[j], fullshort[j+1]
+			out[i] = strings.Replace(out[i], full, short, -1)
+		}
+	}
+
+	var want []wantedError
+	for j := 0; j < len(fullshort); j += 2 {
+		full, short := fullshort[j], fullshort[j+1]
+		want = append(want, wantedErrors(full, short)...)
+	}
+	for _, we := range want {
+		var errmsgs []string
+		if we.auto {
+			errmsgs, out = partitionStrings("<autogenerated>", out)
+		} else {
+			errmsgs, out = partitionStrings(we.prefix, out)
+		}
+		if len(errmsgs) == 0 {
+			errs = append(errs, fmt.Errorf("%s:%d: missing error %q", we.file, we.lineNum, we.reStr))
+			continue
+		}
+		matched := false
+		n := len(out)
+		for _, errmsg := range errmsgs {
+			// Assume errmsg says "file:line: foo".
+			// Cut leading "file:line: " to avoid accidental matching of file name instead of message.
+			text := errmsg
+			if i := strings.Index(text, " "); i >= 0 {
+				text = text[i+1:]
+			}
+			if we.re.MatchString(text) {
+				matched = true
+			} else {
+				out = append(out, errmsg)
+			}
+		}
+		if !matched {
+			errs = append(errs, fmt.Errorf("%s:%d: no match for %#q in:\n\t%s", we.file, we.lineNum, we.reStr, strings.Join(out[n:], "\n\t")))
+			continue
+		}
+	}
+
+	if len(out) > 0 {
+		errs = append(errs, fmt.Errorf("Unmatched Errors:"))
+		for _, errLine := range out {
+			errs = append(errs, fmt.Errorf("%s", errLine))
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "\n")
+	for _, err := range errs {
+		fmt.Fprintf(&buf, "%s\n", err.Error())
+	}
+	return errors.New(buf.String())
+}
+
+func splitOutput(out string, wantAuto bool) []string {
+	// gc error messages continue onto additional lines with leading tabs.
+	// Split the output at the beginning of each line that doesn't begin with a tab.
+	// <autogenerated> lines are impossible to match so those are filtered out.
+	var res []string
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSuffix(line, "\r") // normalize Windows output
+		if strings.HasPrefix(line, "\t") {
+			res[len(res)-1] += "\n" + line
+		} else if strings.HasPrefix(line, "go tool") || strings.HasPrefix(line, "#") || !wantAuto && strings.HasPrefix(line, "<autogenerated>") {
+			continue
+		} else if strings.TrimSpace(line) != "" {
+			res = append(res, line)
+		}
+	}
+	return res
+}
+
+// matchPrefix reports whether s starts with file name prefix followed by a :,
+// and possibly preceded by a directory name.
+func matchPrefix(s, prefix string) bool {
+	i := strings.Index(s, ":")
+	if i < 0 {
+		return false
+	}
+	j := strings.LastIndex(s[:i], "/")
+	s = s[j+1:]
+	if len(s) <= len(prefix) || s[:len(prefix)] != prefix {
+		return false
+	}
+	if s[len(prefix)] == ':' {
+		return true
+	}
+	return false
+}
+
+func partitionStrings(prefix string, strs []string) (matched, unmatched []string) {
+	for _, s := range strs {
+		if matchPrefix(s, prefix) {
+			matched = append(matched, s)
+		} else {
+			unmatched = append(unmatched, s)
+		}
+	}
+	return
+}
+
+type wantedError struct {
+	reStr   string
+	re      *regexp.Regexp
+	lineNum int
+	auto    bool // match <autogenerated> line
+	file    string
+	prefix  string
+}
+
+var (
+	errRx       = regexp.MustCompile(`// (?:GC_)?ERROR (.*)`)
+	errAutoRx   = regexp.MustCompile(`// (?:GC_)?ERRORAUTO (.*)`)
+	errQuotesRx = regexp.MustCompile(`"([^"]*)"`)
+	lineRx      = regexp.MustCompile(`LINE(([+-])([0-9]+))?`)
+)
+
+// wantedErrors parses expected errors from comments in a file.
+func wantedErrors(file, short string) (errs []wantedError) {
+	cache := make(map[string]*regexp.Regexp)
+
+	src, err := ioutil.ReadFile(file)
 	if err != nil {
-		t.Logf("%s", out)
-		t.Error(err)
+		log.Fatal(err)
+	}
+	for i, line := range strings.Split(string(src), "\n") {
+		lineNum := i + 1
+		if strings.Contains(line, "////") {
+			// double comment disables ERROR
+			continue
+		}
+		var auto bool
+		m := errAutoRx.FindStringSubmatch(line)
+		if m != nil {
+			auto = true
+		} else {
+			m = errRx.FindStringSubmatch(line)
+		}
+		if m == nil {
+			continue
+		}
+		all := m[1]
+		mm := errQuotesRx.FindAllStringSubmatch(all, -1)
+		if mm == nil {
+			log.Fatalf("%s:%d: invalid errchk line: %s", file, lineNum, line)
+		}
+		for _, m := range mm {
+			rx := lineRx.ReplaceAllStringFunc(m[1], func(m string) string {
+				n := lineNum
+				if strings.HasPrefix(m, "LINE+") {
+					delta, _ := strconv.Atoi(m[5:])
+					n += delta
+				} else if strings.HasPrefix(m, "LINE-") {
+					delta, _ := strconv.Atoi(m[5:])
+					n -= delta
+				}
+				return fmt.Sprintf("%s:%d", short, n)
+			})
+			re := cache[rx]
+			if re == nil {
+				var err error
+				re, err = regexp.Compile(rx)
+				if err != nil {
+					log.Fatalf("%s:%d: invalid regexp \"%#q\" in ERROR line: %v", file, lineNum, rx, err)
+				}
+				cache[rx] = re
+			}
+			prefix := fmt.Sprintf("%s:%d", short, lineNum)
+			errs = append(errs, wantedError{
+				reStr:   rx,
+				re:      re,
+				prefix:  prefix,
+				auto:    auto,
+				lineNum: lineNum,
+				file:    short,
+			})
+		}
 	}
+
+	return
 }
@@ -0,0 +1,203 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package edwards25519 implements operations in GF(2**255-19) and on the
+// twisted Edwards curve -x^2 + y^2 = 1 + d x^2 y^2 that underlies Ed25519.
+package edwards25519
+
+var (
+	// d is the curve constant -121665/121666.
+	d fieldElement
+	// d2 is 2*d.
+	d2 fieldElement
+	// sqrtM1 is a square root of -1.
+	sqrtM1 fieldElement
+	// basePoint is the generator B of the prime order subgroup, the point
+	// with y = 4/5 and positive x. See RFC 8032, Section 5.1.
+	basePoint ExtendedGroupElement
+)
+
+func init() {
+	var num, den fieldElement
+
+	num[0] = 121665
+	den[0] = 121666
+	feInvert(&den, &den)
+	feMul(&d, &num, &den)
+	feNeg(&d, &d)
+	feAdd(&d2, &d, &d)
+
+	// 2 is not a square modulo p, so 2^((p-1)/4) = 2^(2^253-5) is a square
+	// root of -1.
+	var two, t fieldElement
+	two[0] = 2
+	fePow22523(&t, &two)
+	feSquare(&t, &t)
+	feMul(&sqrtM1, &t, &two)
+
+	feZero(&num)
+	feZero(&den)
+	num[0] = 4
+	den[0] = 5
+	feInvert(&den, &den)
+	feMul(&t, &num, &den)
+	var s [32]byte
+	feToBytes(&s, &t)
+	if !basePoint.FromBytes(&s) {
+		panic("edwards25519: failed to decode the base point")
+	}
+}
+
+// ExtendedGroupElement is a point on the curve in extended coordinates
+// (X:Y:Z:T), where x = X/Z, y = Y/Z and x*y = T/Z. See "Twisted Edwards
+// Curves Revisited" by Hisil, Wong, Carter and Dawson.
+type ExtendedGroupElement struct {
+	X, Y, Z, T fieldElement
+}
+
+// Zero sets p to the identity element.
+func (p *ExtendedGroupElement) Zero() {
+	feZero(&p.X)
+	feOne(&p.Y)
+	feOne(&p.Z)
+	feZero(&p.T)
+}
+
+// ToBytes encodes p according to RFC 8032, Section 5.1.2.
+func (p *ExtendedGroupElement) ToBytes(s *[32]byte) {
+	var recip, x, y fieldElement
+
+	feInvert(&recip, &p.Z)
+	feMul(&x, &p.X, &recip)
+	feMul(&y, &p.Y, &recip)
+	feToBytes(s, &y)
+	s[31] ^= feIsNegative(&x) << 7
+}
+
+// FromBytes decodes s into p according to RFC 8032, Section 5.1.3. It
+// returns false if s is not a valid point encoding. It does not run in
+// constant time.
+func (p *ExtendedGroupElement) FromBytes(s *[32]byte) bool {
+	var u, v, v3, vxx, check fieldElement
+
+	// The most significant bit of s is the sign of x.
+	ys := *s
+	ys[31] &= 127
+	feFromBytes(&p.Y, &ys)
+	feOne(&p.Z)
+	feSquare(&u, &p.Y)
+	feMul(&v, &u, &d)
+	feSub(&u, &u, &p.Z) // u = y^2-1
+	feAdd(&v, &v, &p.Z) // v = dy^2+1
+
+	// Compute the candidate root x = u*v^3 * (u*v^7)^((p-5)/8).
+	feSquare(&v3, &v)
+	feMul(&v3, &v3, &v) // v3 = v^3
+	feSquare(&p.X, &v3)
+	feMul(&p.X, &p.X, &v)
+	feMul(&p.X, &p.X, &u) // x = uv^7
+
+	fePow22523(&p.X, &p.X) // x = (uv^7)^((q-5)/8)
+	feMul(&p.X, &p.X, &v3)
+	feMul(&p.X, &p.X, &u) // x = uv^3(uv^7)^((q-5)/8)
+
+	feSquare(&vxx, &p.X)
+	feMul(&vxx, &vxx, &v)
+	feSub(&check, &vxx, &u) // vx^2-u
+	if feIsNonZero(&check) == 1 {
+		feAdd(&check, &vxx, &u) // vx^2+u
+		if feIsNonZero(&check) == 1 {
+			return false
+		}
+		feMul(&p.X, &p.X, &sqrtM1)
+	}
+
+	if feIsNegative(&p.X) != s[31]>>7 {
+		if feIsNonZero(&p.X) == 0 {
+			// There is no negative zero. See RFC 8032, Section 5.1.3.
+			return false
+		}
+		feNeg(&p.X, &p.X)
+	}
+
+	feMul(&p.T, &p.X, &p.Y)
+	return true
+}
+
+// geAdd sets r = p + q. The formulas are complete for the Ed25519 curve, so
+// they can also be used for doubling, and don't depend on the inputs.
+func geAdd(r, p, q *ExtendedGroupElement) {
+	var a, b, c, dd, e, f, g, h, t fieldElement
+
+	feSub(&a, &p.Y, &p.X)
+	feSub(&t, &q.Y, &q.X)
+	feMul(&a, &a, &t) // A = (Y1-X1)*(Y2-X2)
+	feAdd(&b, &p.Y, &p.X)
+	feAdd(&t, &q.Y, &q.X)
+	feMul(&b, &b, &t) // B = (Y1+X1)*(Y2+X2)
+	feMul(&c, &p.T, &q.T)
+	feMul(&c, &c, &d2) // C = T1*2d*T2
+	feMul(&dd, &p.Z, &q.Z)
+	feAdd(&dd, &dd, &dd) // D = Z1*2*Z2
+	feSub(&e, &b, &a)
+	feSub(&f, &dd, &c)
+	feAdd(&g, &dd, &c)
+	feAdd(&h, &b, &a)
+
+	feMul(&r.X, &e, &f)
+	feMul(&r.Y, &g, &h)
+	feMul(&r.T, &e, &h)
+	feMul(&r.Z, &f, &g)
+}
+
+// geNeg sets r = -p.
+func geNeg(r, p *ExtendedGroupElement) {
+	feNeg(&r.X, &p.X)
+	feCopy(&r.Y, &p.Y)
+	feCopy(&r.Z, &p.Z)
+	feNeg(&r.T, &p.T)
+}
+
+// geCMove replaces p with q if b == 1, and leaves it unchanged if b == 0.
+func geCMove(p, q *ExtendedGroupElement, b int32) {
+	feCMove(&p.X, &q.X, b)
+	feCMove(&p.Y, &q.Y, b)
+	feCMove(&p.Z, &q.Z, b)
+	feCMove(&p.T, &q.T, b)
+}
+
+// GeScalarMult sets h = a*A, where a = a[0]+256*a[1]+...+256^31 a[31]. It
+// runs in time independent of a.
+func GeScalarMult(h *ExtendedGroupElement, a *[32]byte, A *ExtendedGroupElement) {
+	var sum ExtendedGroupElement
+
+	h.Zero()
+	for i := 255; i >= 0; i-- {
+		geAdd(h, h, h)
+		geAdd(&sum, h, A)
+		geCMove(h, &sum, int32(a[i/8]>>uint(i%8))&1)
+	}
+}
+
+// GeScalarMultBase sets h = a*B, where B is the base point. It runs in time
+// independent of a.
+func GeScalarMultBase(h *ExtendedGroupElement, a *[32]byte) {
+	GeScalarMult(h, a, &basePoint)
+}
+
+// GeDoubleScalarMultVartime sets r = a*A + b*B, where B is the base point.
+// It does not run in constant time, and must only be used with public
+// inputs.
+func GeDoubleScalarMultVartime(r *ExtendedGroupElement, a *[32]byte, A *ExtendedGroupElement, b *[32]byte) {
+	var aA, bB ExtendedGroupElement
+
+	GeScalarMult(&aA, a, A)
+	GeScalarMult(&bB, b, &basePoint)
+	geAdd(r, &aA, &bB)
+}
+
+// Negate sets p = -p.
+func (p *ExtendedGroupElement) Negate() {
+	geNeg(p, p)
+}
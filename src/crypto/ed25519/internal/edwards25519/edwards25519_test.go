@@ -0,0 +1,55 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package edwards25519
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestBasePointEncoding(t *testing.T) {
+	// The base point has y = 4/5 and a positive x coordinate.
+	want, _ := hex.DecodeString("5866666666666666666666666666666666666666666666666666666666666666")
+	var s [32]byte
+	basePoint.ToBytes(&s)
+	if !bytes.Equal(s[:], want) {
+		t.Errorf("base point encodes to %x, want %x", s, want)
+	}
+}
+
+func TestScalarMultConsistency(t *testing.T) {
+	// 2*B computed by scalar multiplication must match B+B, and decoding
+	// and re-encoding a point must be the identity.
+	var two [32]byte
+	two[0] = 2
+	var p, q ExtendedGroupElement
+	GeScalarMultBase(&p, &two)
+	geAdd(&q, &basePoint, &basePoint)
+
+	var ps, qs [32]byte
+	p.ToBytes(&ps)
+	q.ToBytes(&qs)
+	if ps != qs {
+		t.Fatalf("2*B = %x, B+B = %x", ps, qs)
+	}
+
+	var r ExtendedGroupElement
+	if !r.FromBytes(&ps) {
+		t.Fatalf("failed to decode %x", ps)
+	}
+	var rs [32]byte
+	r.ToBytes(&rs)
+	if rs != ps {
+		t.Errorf("round trip of %x gave %x", ps, rs)
+	}
+
+	// y = 2 is not on the curve.
+	var bad [32]byte
+	bad[0] = 2
+	if r.FromBytes(&bad) {
+		t.Errorf("decoded invalid point %x", bad)
+	}
+}
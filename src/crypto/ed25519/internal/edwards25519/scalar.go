@@ -0,0 +1,158 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package edwards25519
+
+// Scalars are integers modulo the group order
+//
+//	l = 2^252 + 27742317777372353535851937790883648493
+//
+// and are represented in little-endian byte order. Intermediate results are
+// held in signed 21-bit limbs, as in the ref10 implementation.
+
+// order is l in little-endian byte order.
+var order = [32]byte{
+	0xed, 0xd3, 0xf5, 0x5c, 0x1a, 0x63, 0x12, 0x58,
+	0xd6, 0x9c, 0xf7, 0xa2, 0xde, 0xf9, 0xde, 0x14,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x10,
+}
+
+const limbMask = 1<<21 - 1
+
+// loadLimb returns the 21 bits of s starting at bit 21*i.
+func loadLimb(s []byte, i int) int64 {
+	return (load4(s[21*i/8:]) >> uint(21*i%8)) & limbMask
+}
+
+// ScReduce sets out = s mod l, where s is a 512-bit little-endian integer.
+func ScReduce(out *[32]byte, s *[64]byte) {
+	var limbs [24]int64
+	for i := 0; i < 23; i++ {
+		limbs[i] = loadLimb(s[:], i)
+	}
+	limbs[23] = load4(s[60:]) >> 3
+	reduce(out, &limbs)
+}
+
+// ScMulAdd sets s = (a*b + c) mod l.
+func ScMulAdd(s, a, b, c *[32]byte) {
+	var al, bl, cl [12]int64
+	for i := 0; i < 11; i++ {
+		al[i] = loadLimb(a[:], i)
+		bl[i] = loadLimb(b[:], i)
+		cl[i] = loadLimb(c[:], i)
+	}
+	al[11] = load4(a[28:]) >> 7
+	bl[11] = load4(b[28:]) >> 7
+	cl[11] = load4(c[28:]) >> 7
+
+	var limbs [24]int64
+	copy(limbs[:], cl[:])
+	for i := 0; i < 12; i++ {
+		for j := 0; j < 12; j++ {
+			limbs[i+j] += al[i] * bl[j]
+		}
+	}
+
+	for i := 0; i < 23; i += 2 {
+		carryRound(&limbs, i)
+	}
+	for i := 1; i < 22; i += 2 {
+		carryRound(&limbs, i)
+	}
+	reduce(s, &limbs)
+}
+
+// ScMinimal reports whether s is strictly less than l, as required of the S
+// half of a signature by RFC 8032, Section 5.1.7.
+func ScMinimal(s *[32]byte) bool {
+	for i := 31; i >= 0; i-- {
+		if s[i] < order[i] {
+			return true
+		}
+		if s[i] > order[i] {
+			return false
+		}
+	}
+	return false
+}
+
+// carryRound moves the rounded-off high bits of limb i into limb i+1, leaving
+// limb i in [-2^20, 2^20).
+func carryRound(s *[24]int64, i int) {
+	carry := (s[i] + 1<<20) >> 21
+	s[i+1] += carry
+	s[i] -= carry << 21
+}
+
+// carryFloor moves the high bits of limb i into limb i+1, leaving limb i in
+// [0, 2^21).
+func carryFloor(s *[24]int64, i int) {
+	carry := s[i] >> 21
+	s[i+1] += carry
+	s[i] -= carry << 21
+}
+
+// fold eliminates limb k by adding its value times 2^(21*k) mod l into the
+// limbs below it, using 2^252 = -27742317777372353535851937790883648493
+// (mod l).
+func fold(s *[24]int64, k int) {
+	s[k-12] += s[k] * 666643
+	s[k-11] += s[k] * 470296
+	s[k-10] += s[k] * 654183
+	s[k-9] -= s[k] * 997805
+	s[k-8] += s[k] * 136657
+	s[k-7] -= s[k] * 683901
+	s[k] = 0
+}
+
+// reduce sets out to the value of s mod l, where s holds 24 limbs, each at
+// most a little over 21 bits wide.
+func reduce(out *[32]byte, s *[24]int64) {
+	for k := 23; k >= 18; k-- {
+		fold(s, k)
+	}
+	for i := 6; i <= 16; i += 2 {
+		carryRound(s, i)
+	}
+	for i := 7; i <= 15; i += 2 {
+		carryRound(s, i)
+	}
+
+	for k := 17; k >= 12; k-- {
+		fold(s, k)
+	}
+	for i := 0; i <= 10; i += 2 {
+		carryRound(s, i)
+	}
+	for i := 1; i <= 11; i += 2 {
+		carryRound(s, i)
+	}
+
+	fold(s, 12)
+	for i := 0; i <= 11; i++ {
+		carryFloor(s, i)
+	}
+
+	fold(s, 12)
+	for i := 0; i <= 10; i++ {
+		carryFloor(s, i)
+	}
+
+	var acc uint64
+	var bits uint
+	n := 0
+	for i := 0; i < 12; i++ {
+		acc |= uint64(s[i]) << bits
+		bits += 21
+		for bits >= 8 {
+			out[n] = byte(acc)
+			acc >>= 8
+			bits -= 8
+			n++
+		}
+	}
+	out[n] = byte(acc)
+}
@@ -0,0 +1,73 @@
+// Copyright 2016 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package edwards25519
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+// leToBig interprets b as a little-endian integer.
+func leToBig(b []byte) *big.Int {
+	be := make([]byte, len(b))
+	for i := range b {
+		be[len(b)-1-i] = b[i]
+	}
+	return new(big.Int).SetBytes(be)
+}
+
+var bigOrder = leToBig(order[:])
+
+func TestScReduce(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	var in [64]byte
+	var out [32]byte
+	for i := 0; i < 1000; i++ {
+		r.Read(in[:])
+		if i == 0 {
+			for j := range in {
+				in[j] = 0xff
+			}
+		}
+		ScReduce(&out, &in)
+		want := new(big.Int).Mod(leToBig(in[:]), bigOrder)
+		if got := leToBig(out[:]); got.Cmp(want) != 0 {
+			t.Fatalf("ScReduce(%x) = %v, want %v", in, got, want)
+		}
+	}
+}
+
+func TestScMulAdd(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	var a, b, c, out [32]byte
+	for i := 0; i < 1000; i++ {
+		r.Read(a[:])
+		r.Read(b[:])
+		r.Read(c[:])
+		// Inputs are reduced scalars, except for the clamped secret key.
+		a[31] &= 15
+		b[31] &= 63
+		c[31] &= 15
+		ScMulAdd(&out, &a, &b, &c)
+		want := new(big.Int).Mul(leToBig(a[:]), leToBig(b[:]))
+		want.Add(want, leToBig(c[:]))
+		want.Mod(want, bigOrder)
+		if got := leToBig(out[:]); got.Cmp(want) != 0 {
+			t.Fatalf("ScMulAdd(%x, %x, %x) = %v, want %v", a, b, c, got, want)
+		}
+	}
+}
+
+func TestScMinimal(t *testing.T) {
+	if ScMinimal(&order) {
+		t.Errorf("ScMinimal(l) = true")
+	}
+	s := order
+	s[0]--
+	if !ScMinimal(&s) {
+		t.Errorf("ScMinimal(l-1) = false")
+	}
+}
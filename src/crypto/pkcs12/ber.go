@@ -0,0 +1,158 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkcs12
+
+import (
+	"encoding/asn1"
+	"errors"
+)
+
+// Some encoders, notably on Windows, produce PKCS #12 files in BER
+// rather than DER. encoding/asn1 only accepts DER, so such input is
+// first converted: indefinite lengths are replaced by definite ones,
+// and constructed OCTET STRINGs are replaced by primitive ones holding
+// the concatenation of their segments.
+
+var errBER = errors.New("pkcs12: invalid BER encoding")
+
+// unmarshal parses the BER or DER encoded in into out and rejects
+// trailing data.
+func unmarshal(in []byte, out interface{}) error {
+	der, rest, err := berToDER(in)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 0 {
+		return errors.New("pkcs12: trailing data found")
+	}
+	if rest, err := asn1.Unmarshal(der, out); err != nil {
+		return err
+	} else if len(rest) != 0 {
+		return errors.New("pkcs12: trailing data found")
+	}
+	return nil
+}
+
+// berToDER converts the first element of ber to DER and returns it
+// along with the remaining input.
+func berToDER(ber []byte) (der, rest []byte, err error) {
+	// Identifier octets.
+	if len(ber) < 2 {
+		return nil, nil, errBER
+	}
+	tagLen := 1
+	if ber[0]&0x1f == 0x1f {
+		for {
+			if tagLen >= len(ber) {
+				return nil, nil, errBER
+			}
+			tagLen++
+			if ber[tagLen-1]&0x80 == 0 {
+				break
+			}
+		}
+	}
+	tag := ber[:tagLen]
+	constructed := ber[0]&0x20 != 0
+	ber = ber[tagLen:]
+
+	// Length octets.
+	if len(ber) == 0 {
+		return nil, nil, errBER
+	}
+	indefinite := ber[0] == 0x80
+	length := 0
+	switch n := int(ber[0] & 0x7f); {
+	case indefinite:
+		if !constructed {
+			return nil, nil, errBER
+		}
+		ber = ber[1:]
+	case ber[0]&0x80 == 0:
+		length = n
+		ber = ber[1:]
+	default:
+		if n > 4 || len(ber) < 1+n {
+			return nil, nil, errBER
+		}
+		for _, b := range ber[1 : 1+n] {
+			length = length<<8 | int(b)
+		}
+		if length < 0 {
+			return nil, nil, errBER
+		}
+		ber = ber[1+n:]
+	}
+	if !indefinite && len(ber) < length {
+		return nil, nil, errBER
+	}
+
+	if !constructed {
+		return appendElement(nil, tag, ber[:length]), ber[length:], nil
+	}
+
+	// Convert each child element.
+	var contents []byte
+	children := ber
+	if !indefinite {
+		children = ber[:length]
+	}
+	for {
+		if indefinite {
+			if len(children) < 2 {
+				return nil, nil, errBER
+			}
+			if children[0] == 0 && children[1] == 0 {
+				rest = children[2:]
+				break
+			}
+		} else if len(children) == 0 {
+			rest = ber[length:]
+			break
+		}
+		var child []byte
+		child, children, err = berToDER(children)
+		if err != nil {
+			return nil, nil, err
+		}
+		contents = append(contents, child...)
+	}
+
+	if len(tag) == 1 && tag[0] == 0x20|asn1.TagOctetString {
+		// Concatenate the segments of a constructed OCTET STRING.
+		var octets []byte
+		for len(contents) > 0 {
+			var segment asn1.RawValue
+			if contents, err = asn1.Unmarshal(contents, &segment); err != nil {
+				return nil, nil, err
+			}
+			if segment.Class != asn1.ClassUniversal || segment.Tag != asn1.TagOctetString {
+				return nil, nil, errBER
+			}
+			octets = append(octets, segment.Bytes...)
+		}
+		return appendElement(nil, []byte{asn1.TagOctetString}, octets), rest, nil
+	}
+	return appendElement(nil, tag, contents), rest, nil
+}
+
+// appendElement appends the DER encoding of an element with the given
+// identifier octets and contents to b.
+func appendElement(b, tag, contents []byte) []byte {
+	b = append(b, tag...)
+	n := len(contents)
+	switch {
+	case n < 0x80:
+		b = append(b, byte(n))
+	default:
+		var l []byte
+		for ; n > 0; n >>= 8 {
+			l = append([]byte{byte(n)}, l...)
+		}
+		b = append(b, 0x80|byte(len(l)))
+		b = append(b, l...)
+	}
+	return append(b, contents...)
+}
@@ -0,0 +1,278 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkcs12
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"hash"
+	"io"
+)
+
+var (
+	oidPBEWithSHAAnd3KeyTripleDESCBC = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 1, 3}
+	oidPBEWithSHAAnd40BitRC2CBC      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 1, 6}
+	oidPBES2                         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2                        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+
+	oidHMACWithSHA1   = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 7}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidHMACWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 10}
+	oidHMACWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 11}
+
+	oidDESEDE3CBC = asn1.ObjectIdentifier{1, 2, 840, 113549, 3, 7}
+	oidAES128CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC  = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+
+	oidSHA1   = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidSHA256 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSHA384 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	oidSHA512 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+)
+
+// maxIterations bounds the iteration counts accepted when decoding, so
+// that a crafted file cannot make key derivation run for a very long time.
+// It is well above the counts used by common implementations.
+const maxIterations = 1 << 20
+
+// RFC 7292, appendix C
+type pbeParams struct {
+	Salt       []byte
+	Iterations int
+}
+
+// RFC 8018, appendix A.4
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+// RFC 8018, appendix A.2
+type pbkdf2Params struct {
+	Salt       []byte
+	Iterations int
+	KeyLength  int                      `asn1:"optional"`
+	PRF        pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+// hashFromOID returns the hash function identified by oid as a message
+// digest, or nil if it isn't supported.
+func hashFromOID(oid asn1.ObjectIdentifier) func() hash.Hash {
+	switch {
+	case oid.Equal(oidSHA1):
+		return sha1.New
+	case oid.Equal(oidSHA256):
+		return sha256.New
+	case oid.Equal(oidSHA384):
+		return sha512.New384
+	case oid.Equal(oidSHA512):
+		return sha512.New
+	}
+	return nil
+}
+
+// prfFromOID returns the hash function underlying the PBKDF2
+// pseudorandom function identified by oid, or nil if it isn't
+// supported.
+func prfFromOID(oid asn1.ObjectIdentifier) func() hash.Hash {
+	switch {
+	case len(oid) == 0, oid.Equal(oidHMACWithSHA1):
+		// hmacWithSHA1 is the default.
+		return sha1.New
+	case oid.Equal(oidHMACWithSHA256):
+		return sha256.New
+	case oid.Equal(oidHMACWithSHA384):
+		return sha512.New384
+	case oid.Equal(oidHMACWithSHA512):
+		return sha512.New
+	}
+	return nil
+}
+
+// newCBC returns the block cipher identified by oid and its key size,
+// or nil if it isn't supported.
+func newCBC(oid asn1.ObjectIdentifier) (func(key []byte) (cipher.Block, error), int) {
+	switch {
+	case oid.Equal(oidDESEDE3CBC):
+		return des.NewTripleDESCipher, 24
+	case oid.Equal(oidAES128CBC):
+		return aes.NewCipher, 16
+	case oid.Equal(oidAES192CBC):
+		return aes.NewCipher, 24
+	case oid.Equal(oidAES256CBC):
+		return aes.NewCipher, 32
+	}
+	return nil, 0
+}
+
+// pbeCipher returns the block cipher and IV for decrypting data
+// protected with algorithm under password.
+func pbeCipher(algorithm pkix.AlgorithmIdentifier, password string) (cipher.Block, []byte, error) {
+	switch {
+	case algorithm.Algorithm.Equal(oidPBEWithSHAAnd3KeyTripleDESCBC):
+		var params pbeParams
+		if err := unmarshal(algorithm.Parameters.FullBytes, &params); err != nil {
+			return nil, nil, err
+		}
+		if params.Iterations < 1 || params.Iterations > maxIterations {
+			return nil, nil, errors.New("pkcs12: invalid iteration count")
+		}
+		p, err := bmpString(password)
+		if err != nil {
+			return nil, nil, err
+		}
+		key := pkcs12KDF(sha1.New, kdfKey, p, params.Salt, params.Iterations, 24)
+		iv := pkcs12KDF(sha1.New, kdfIV, p, params.Salt, params.Iterations, des.BlockSize)
+		block, err := des.NewTripleDESCipher(key)
+		return block, iv, err
+
+	case algorithm.Algorithm.Equal(oidPBES2):
+		var params pbes2Params
+		if err := unmarshal(algorithm.Parameters.FullBytes, &params); err != nil {
+			return nil, nil, err
+		}
+		if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) {
+			return nil, nil, NotImplementedError("PBES2 key derivation function " + params.KeyDerivationFunc.Algorithm.String())
+		}
+		var kdfParams pbkdf2Params
+		if err := unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdfParams); err != nil {
+			return nil, nil, err
+		}
+		if kdfParams.Iterations < 1 || kdfParams.Iterations > maxIterations {
+			return nil, nil, errors.New("pkcs12: invalid iteration count")
+		}
+		prf := prfFromOID(kdfParams.PRF.Algorithm)
+		if prf == nil {
+			return nil, nil, NotImplementedError("PBKDF2 pseudorandom function " + kdfParams.PRF.Algorithm.String())
+		}
+		newCipher, keyLen := newCBC(params.EncryptionScheme.Algorithm)
+		if newCipher == nil {
+			return nil, nil, NotImplementedError("PBES2 encryption scheme " + params.EncryptionScheme.Algorithm.String())
+		}
+		if kdfParams.KeyLength != 0 && kdfParams.KeyLength != keyLen {
+			return nil, nil, errors.New("pkcs12: invalid PBKDF2 key length")
+		}
+		var iv []byte
+		if err := unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+			return nil, nil, err
+		}
+		// The password is used as is, rather than as a BMPString.
+		key := pbkdf2(prf, []byte(password), kdfParams.Salt, kdfParams.Iterations, keyLen)
+		block, err := newCipher(key)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(iv) != block.BlockSize() {
+			return nil, nil, errors.New("pkcs12: invalid PBES2 IV length")
+		}
+		return block, iv, nil
+
+	case algorithm.Algorithm.Equal(oidPBEWithSHAAnd40BitRC2CBC):
+		return nil, nil, NotImplementedError("pbeWithSHAAnd40BitRC2-CBC encryption")
+	}
+	return nil, nil, NotImplementedError("encryption algorithm " + algorithm.Algorithm.String())
+}
+
+// pbDecrypt decrypts ciphertext, which was encrypted with algorithm
+// under password.
+func pbDecrypt(algorithm pkix.AlgorithmIdentifier, ciphertext []byte, password string) ([]byte, error) {
+	block, iv, err := pbeCipher(algorithm, password)
+	if err != nil {
+		return nil, err
+	}
+	bs := block.BlockSize()
+	if len(ciphertext) == 0 || len(ciphertext)%bs != 0 {
+		return nil, errors.New("pkcs12: invalid ciphertext length")
+	}
+	plaintext := make([]byte, len(ciphertext))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, ciphertext)
+
+	// Remove the PKCS #7 padding. A wrong password almost always
+	// results in invalid padding.
+	n := int(plaintext[len(plaintext)-1])
+	if n == 0 || n > bs || n > len(plaintext) {
+		return nil, ErrIncorrectPassword
+	}
+	if !bytes.Equal(plaintext[len(plaintext)-n:], bytes.Repeat([]byte{byte(n)}, n)) {
+		return nil, ErrIncorrectPassword
+	}
+	return plaintext[:len(plaintext)-n], nil
+}
+
+// pbEncrypt encrypts plaintext under password, with the algorithms of
+// the given encoding, and returns the algorithm identifier and the
+// ciphertext.
+func pbEncrypt(rand io.Reader, enc encoding, plaintext []byte, password string) (pkix.AlgorithmIdentifier, []byte, error) {
+	var algorithm pkix.AlgorithmIdentifier
+	salt := make([]byte, enc.saltLen)
+	if _, err := io.ReadFull(rand, salt); err != nil {
+		return algorithm, nil, err
+	}
+
+	if enc.legacy {
+		b, err := asn1.Marshal(pbeParams{Salt: salt, Iterations: enc.iterations})
+		if err != nil {
+			return algorithm, nil, err
+		}
+		algorithm.Algorithm = oidPBEWithSHAAnd3KeyTripleDESCBC
+		algorithm.Parameters.FullBytes = b
+	} else {
+		iv := make([]byte, aes.BlockSize)
+		if _, err := io.ReadFull(rand, iv); err != nil {
+			return algorithm, nil, err
+		}
+		kdfParams, err := asn1.Marshal(pbkdf2Params{
+			Salt:       salt,
+			Iterations: enc.iterations,
+			PRF: pkix.AlgorithmIdentifier{
+				Algorithm:  oidHMACWithSHA256,
+				Parameters: asn1.NullRawValue,
+			},
+		})
+		if err != nil {
+			return algorithm, nil, err
+		}
+		ivBytes, err := asn1.Marshal(iv)
+		if err != nil {
+			return algorithm, nil, err
+		}
+		b, err := asn1.Marshal(pbes2Params{
+			KeyDerivationFunc: pkix.AlgorithmIdentifier{
+				Algorithm:  oidPBKDF2,
+				Parameters: asn1.RawValue{FullBytes: kdfParams},
+			},
+			EncryptionScheme: pkix.AlgorithmIdentifier{
+				Algorithm:  oidAES256CBC,
+				Parameters: asn1.RawValue{FullBytes: ivBytes},
+			},
+		})
+		if err != nil {
+			return algorithm, nil, err
+		}
+		algorithm.Algorithm = oidPBES2
+		algorithm.Parameters.FullBytes = b
+	}
+
+	block, iv, err := pbeCipher(algorithm, password)
+	if err != nil {
+		return algorithm, nil, err
+	}
+	bs := block.BlockSize()
+	n := bs - len(plaintext)%bs
+	ciphertext := make([]byte, len(plaintext)+n)
+	copy(ciphertext, plaintext)
+	copy(ciphertext[len(plaintext):], bytes.Repeat([]byte{byte(n)}, n))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, ciphertext)
+	return algorithm, ciphertext, nil
+}
@@ -0,0 +1,31 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkcs12_test
+
+import (
+	"crypto/pkcs12"
+	"crypto/tls"
+	"io/ioutil"
+	"log"
+)
+
+func ExampleDecode() {
+	pfxData, err := ioutil.ReadFile("client.p12")
+	if err != nil {
+		log.Fatal(err)
+	}
+	key, certs, err := pkcs12.Decode(pfxData, "password")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Use the key and its chain as a TLS client certificate.
+	cert := tls.Certificate{PrivateKey: key, Leaf: certs[0]}
+	for _, c := range certs {
+		cert.Certificate = append(cert.Certificate, c.Raw)
+	}
+	config := &tls.Config{Certificates: []tls.Certificate{cert}}
+	_ = config
+}
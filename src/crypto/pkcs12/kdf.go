@@ -0,0 +1,120 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package pkcs12
+
+import (
+	"crypto/hmac"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"unicode/utf16"
+)
+
+// bmpString returns s encoded as a null-terminated BMPString, the form
+// in which passwords are fed to the key derivation function of RFC
+// 7292, appendix B.1.
+func bmpString(s string) ([]byte, error) {
+	ret := make([]byte, 0, 2*len(s)+2)
+	for _, r := range s {
+		if t, _ := utf16.EncodeRune(r); t != 0xfffd {
+			return nil, errors.New("pkcs12: password contains characters outside the Basic Multilingual Plane")
+		}
+		ret = append(ret, byte(r>>8), byte(r))
+	}
+	return append(ret, 0, 0), nil
+}
+
+// Diversifiers of the key derivation function, RFC 7292, appendix B.3.
+const (
+	kdfKey = 1
+	kdfIV  = 2
+	kdfMAC = 3
+)
+
+// pkcs12KDF derives size bytes from password and salt using the
+// key derivation function of RFC 7292, appendix B.2, with hash h.
+// The password must already be encoded as by bmpString.
+func pkcs12KDF(h func() hash.Hash, id byte, password, salt []byte, iterations, size int) []byte {
+	hh := h()
+	u := hh.Size()
+	v := hh.BlockSize()
+
+	// fill returns b repeated to a multiple of v bytes.
+	fill := func(b []byte) []byte {
+		if len(b) == 0 {
+			return nil
+		}
+		ret := make([]byte, v*((len(b)+v-1)/v))
+		for i := range ret {
+			ret[i] = b[i%len(b)]
+		}
+		return ret
+	}
+
+	D := make([]byte, v)
+	for i := range D {
+		D[i] = id
+	}
+	I := append(fill(salt), fill(password)...)
+	B := make([]byte, v)
+
+	var ret []byte
+	for {
+		hh.Reset()
+		hh.Write(D)
+		hh.Write(I)
+		A := hh.Sum(nil)
+		for i := 1; i < iterations; i++ {
+			hh.Reset()
+			hh.Write(A)
+			A = hh.Sum(A[:0])
+		}
+		ret = append(ret, A...)
+		if len(ret) >= size {
+			return ret[:size]
+		}
+
+		// Set each v-byte block of I to (I_j + B + 1) mod 2^(8v),
+		// where B is A repeated to v bytes.
+		for i := range B {
+			B[i] = A[i%u]
+		}
+		for j := 0; j < len(I); j += v {
+			carry := uint(1)
+			for k := v - 1; k >= 0; k-- {
+				x := uint(I[j+k]) + uint(B[k]) + carry
+				I[j+k] = byte(x)
+				carry = x >> 8
+			}
+		}
+	}
+}
+
+// pbkdf2 derives a key of keyLen bytes from password and salt using
+// PBKDF2 of RFC 8018, section 5.2, with HMAC based on h as the
+// pseudorandom function.
+func pbkdf2(h func() hash.Hash, password, salt []byte, iterations, keyLen int) []byte {
+	prf := hmac.New(h, password)
+	var ret, U []byte
+	var block [4]byte
+	for i := uint32(1); len(ret) < keyLen; i++ {
+		binary.BigEndian.PutUint32(block[:], i)
+		prf.Reset()
+		prf.Write(salt)
+		prf.Write(block[:])
+		U = prf.Sum(U[:0])
+		T := append([]byte(nil), U...)
+		for n := 1; n < iterations; n++ {
+			prf.Reset()
+			prf.Write(U)
+			U = prf.Sum(U[:0])
+			for k := range T {
+				T[k] ^= U[k]
+			}
+		}
+		ret = append(ret, T...)
+	}
+	return ret[:keyLen]
+}
@@ -0,0 +1,467 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package pkcs12 implements reading and writing of PKCS #12 files, as
+// defined in RFC 7292, which bundle a private key with its certificate
+// chain and are also known as .p12 or .pfx files.
+//
+// Decode supports password-based encryption with
+// pbeWithSHAAnd3-KeyTripleDES-CBC and with PBES2, using PBKDF2 and AES
+// or Triple DES in CBC mode, and MACs based on SHA-1 and SHA-2.
+// Files protected with RC2, common among those made by older software,
+// are not supported.
+package pkcs12
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"io"
+	"strconv"
+)
+
+var (
+	oidDataContentType          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidEncryptedDataContentType = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 6}
+
+	oidKeyBag              = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 1}
+	oidPKCS8ShroudedKeyBag = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 2}
+	oidCertBag             = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 3}
+
+	oidCertTypeX509Certificate = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 22, 1}
+	oidLocalKeyID              = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 21}
+)
+
+// ErrIncorrectPassword is returned when an incorrect password is
+// detected, usually by a failed MAC check.
+var ErrIncorrectPassword = errors.New("pkcs12: decryption password incorrect")
+
+// NotImplementedError indicates that the input uses a feature of PKCS
+// #12 that this package does not support.
+type NotImplementedError string
+
+func (e NotImplementedError) Error() string {
+	return "pkcs12: " + string(e) + " is not supported"
+}
+
+// These are the ASN.1 structures of RFC 7292 and, for ContentInfo and
+// EncryptedData, RFC 2315.
+
+type pfxPdu struct {
+	Version  int
+	AuthSafe contentInfo
+	MacData  macData `asn1:"optional"`
+}
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"tag:0,explicit,optional"`
+}
+
+type encryptedData struct {
+	Version              int
+	EncryptedContentInfo encryptedContentInfo
+}
+
+type encryptedContentInfo struct {
+	ContentType                asn1.ObjectIdentifier
+	ContentEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedContent           asn1.RawValue `asn1:"tag:0,optional"`
+}
+
+type macData struct {
+	Mac        digestInfo
+	MacSalt    []byte
+	Iterations int `asn1:"optional,default:1"`
+}
+
+type digestInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	Digest    []byte
+}
+
+type safeBag struct {
+	Id         asn1.ObjectIdentifier
+	Value      asn1.RawValue     `asn1:"tag:0,explicit"`
+	Attributes []pkcs12Attribute `asn1:"set,optional"`
+}
+
+type pkcs12Attribute struct {
+	Id    asn1.ObjectIdentifier
+	Value asn1.RawValue `asn1:"set"`
+}
+
+type certBag struct {
+	Id   asn1.ObjectIdentifier
+	Data []byte `asn1:"tag:0,explicit"`
+}
+
+type encryptedPrivateKeyInfo struct {
+	AlgorithmIdentifier pkix.AlgorithmIdentifier
+	EncryptedData       []byte
+}
+
+// Decode extracts a private key and certificates from pfxData, which
+// must be a PKCS #12 file protected with password. If the file contains
+// a private key, the certificate for it comes first in the returned
+// slice, followed by any others in the order they appear in the file.
+//
+// If the file has a MAC, which is usual, it is checked before anything
+// is decrypted and ErrIncorrectPassword is returned if it doesn't match.
+func Decode(pfxData []byte, password string) (key crypto.PrivateKey, certs []*x509.Certificate, err error) {
+	var pfx pfxPdu
+	if err := unmarshal(pfxData, &pfx); err != nil {
+		return nil, nil, err
+	}
+	if pfx.Version != 3 {
+		return nil, nil, NotImplementedError("PFX version " + strconv.Itoa(pfx.Version))
+	}
+	if !pfx.AuthSafe.ContentType.Equal(oidDataContentType) {
+		return nil, nil, NotImplementedError("public-key integrity mode")
+	}
+	var authenticatedSafe []byte
+	if err := unmarshal(pfx.AuthSafe.Content.Bytes, &authenticatedSafe); err != nil {
+		return nil, nil, err
+	}
+
+	if len(pfx.MacData.Mac.Algorithm.Algorithm) != 0 {
+		if err := verifyMAC(&pfx.MacData, authenticatedSafe, password); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var contents []contentInfo
+	if err := unmarshal(authenticatedSafe, &contents); err != nil {
+		return nil, nil, err
+	}
+	var bags []safeBag
+	for _, ci := range contents {
+		var data []byte
+		switch {
+		case ci.ContentType.Equal(oidDataContentType):
+			if err := unmarshal(ci.Content.Bytes, &data); err != nil {
+				return nil, nil, err
+			}
+		case ci.ContentType.Equal(oidEncryptedDataContentType):
+			var ed encryptedData
+			if err := unmarshal(ci.Content.Bytes, &ed); err != nil {
+				return nil, nil, err
+			}
+			if ed.Version != 0 {
+				return nil, nil, NotImplementedError("encrypted data version " + strconv.Itoa(ed.Version))
+			}
+			ciphertext, err := encryptedContent(ed.EncryptedContentInfo.EncryptedContent)
+			if err != nil {
+				return nil, nil, err
+			}
+			if data, err = pbDecrypt(ed.EncryptedContentInfo.ContentEncryptionAlgorithm, ciphertext, password); err != nil {
+				return nil, nil, err
+			}
+		default:
+			return nil, nil, NotImplementedError("content type " + ci.ContentType.String())
+		}
+		var safeContents []safeBag
+		if err := unmarshal(data, &safeContents); err != nil {
+			return nil, nil, err
+		}
+		bags = append(bags, safeContents...)
+	}
+
+	for _, bag := range bags {
+		switch {
+		case bag.Id.Equal(oidCertBag):
+			var cb certBag
+			if err := unmarshal(bag.Value.Bytes, &cb); err != nil {
+				return nil, nil, err
+			}
+			if !cb.Id.Equal(oidCertTypeX509Certificate) {
+				// Ignore SDSI certificates.
+				continue
+			}
+			cert, err := x509.ParseCertificate(cb.Data)
+			if err != nil {
+				return nil, nil, err
+			}
+			certs = append(certs, cert)
+
+		case bag.Id.Equal(oidKeyBag), bag.Id.Equal(oidPKCS8ShroudedKeyBag):
+			if key != nil {
+				return nil, nil, errors.New("pkcs12: expected exactly one private key, found more")
+			}
+			der := bag.Value.Bytes
+			if bag.Id.Equal(oidPKCS8ShroudedKeyBag) {
+				var info encryptedPrivateKeyInfo
+				if err := unmarshal(der, &info); err != nil {
+					return nil, nil, err
+				}
+				if der, err = pbDecrypt(info.AlgorithmIdentifier, info.EncryptedData, password); err != nil {
+					return nil, nil, err
+				}
+			}
+			if key, err = x509.ParsePKCS8PrivateKey(der); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	if key != nil {
+		// Move the certificate for the key to the front.
+		for i, cert := range certs {
+			if matchesKey(cert, key) {
+				copy(certs[1:i+1], certs[:i])
+				certs[0] = cert
+				break
+			}
+		}
+	}
+	return key, certs, nil
+}
+
+// encryptedContent returns the contents of an encryptedContent field,
+// which some encoders split into segments.
+func encryptedContent(v asn1.RawValue) ([]byte, error) {
+	if !v.IsCompound {
+		return v.Bytes, nil
+	}
+	var ret []byte
+	for rest := v.Bytes; len(rest) > 0; {
+		var segment []byte
+		var err error
+		if rest, err = asn1.Unmarshal(rest, &segment); err != nil {
+			return nil, err
+		}
+		ret = append(ret, segment...)
+	}
+	return ret, nil
+}
+
+// matchesKey reports whether cert is for the public part of key.
+func matchesKey(cert *x509.Certificate, key crypto.PrivateKey) bool {
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return false
+	}
+	pub, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(pub, cert.RawSubjectPublicKeyInfo)
+}
+
+// verifyMAC checks the MAC of the authenticated safe contents.
+func verifyMAC(md *macData, message []byte, password string) error {
+	h := hashFromOID(md.Mac.Algorithm.Algorithm)
+	if h == nil {
+		return NotImplementedError("MAC digest algorithm " + md.Mac.Algorithm.Algorithm.String())
+	}
+	if md.Iterations < 1 || md.Iterations > maxIterations {
+		return errors.New("pkcs12: invalid MAC iteration count")
+	}
+	p, err := bmpString(password)
+	if err != nil {
+		return err
+	}
+	key := pkcs12KDF(h, kdfMAC, p, md.MacSalt, md.Iterations, h().Size())
+	mac := hmac.New(h, key)
+	mac.Write(message)
+	if !hmac.Equal(mac.Sum(nil), md.Mac.Digest) {
+		return ErrIncorrectPassword
+	}
+	return nil
+}
+
+// An encoding is the set of algorithms used to protect an encoded file.
+type encoding struct {
+	legacy     bool // use pbeWithSHAAnd3-KeyTripleDES-CBC and a SHA-1 MAC rather than PBES2 and SHA-256
+	iterations int
+	saltLen    int
+}
+
+var (
+	modernEncoding = encoding{iterations: 2048, saltLen: 16}
+	legacyEncoding = encoding{legacy: true, iterations: 2048, saltLen: 8}
+)
+
+// Encode returns a PKCS #12 file containing key and certs, protected
+// with password. If key is not nil, certs[0] must be its certificate;
+// the remaining certificates are typically its chain.
+//
+// The key and certificates are encrypted with PBES2, using
+// PBKDF2-HMAC-SHA-256 and AES-256-CBC, and the file is authenticated
+// with an HMAC-SHA-256 MAC, as OpenSSL 3 does by default. Some older
+// software can only read files made by EncodeLegacy.
+func Encode(rand io.Reader, key crypto.PrivateKey, certs []*x509.Certificate, password string) ([]byte, error) {
+	return encode(rand, modernEncoding, key, certs, password)
+}
+
+// EncodeLegacy is like Encode, but encrypts with
+// pbeWithSHAAnd3-KeyTripleDES-CBC and uses an HMAC-SHA-1 MAC, for
+// compatibility with older software.
+func EncodeLegacy(rand io.Reader, key crypto.PrivateKey, certs []*x509.Certificate, password string) ([]byte, error) {
+	return encode(rand, legacyEncoding, key, certs, password)
+}
+
+func encode(rand io.Reader, enc encoding, key crypto.PrivateKey, certs []*x509.Certificate, password string) ([]byte, error) {
+	// Check the password early for a clearer error.
+	if _, err := bmpString(password); err != nil {
+		return nil, err
+	}
+
+	// The key and its certificate are linked by a localKeyId attribute
+	// holding the certificate's SHA-1 fingerprint, as other
+	// implementations do.
+	var keyAttributes []pkcs12Attribute
+	if key != nil {
+		if len(certs) == 0 || !matchesKey(certs[0], key) {
+			return nil, errors.New("pkcs12: the first certificate must be for the private key")
+		}
+		fingerprint := sha1.Sum(certs[0].Raw)
+		attr, err := newAttribute(oidLocalKeyID, fingerprint[:])
+		if err != nil {
+			return nil, err
+		}
+		keyAttributes = []pkcs12Attribute{attr}
+	}
+
+	var certBags []safeBag
+	for i, cert := range certs {
+		b, err := asn1.Marshal(certBag{Id: oidCertTypeX509Certificate, Data: cert.Raw})
+		if err != nil {
+			return nil, err
+		}
+		bag := safeBag{Id: oidCertBag, Value: explicitValue(b)}
+		if i == 0 {
+			bag.Attributes = keyAttributes
+		}
+		certBags = append(certBags, bag)
+	}
+
+	var contents []contentInfo
+	if len(certBags) > 0 {
+		safeContents, err := asn1.Marshal(certBags)
+		if err != nil {
+			return nil, err
+		}
+		algorithm, ciphertext, err := pbEncrypt(rand, enc, safeContents, password)
+		if err != nil {
+			return nil, err
+		}
+		b, err := asn1.Marshal(encryptedData{
+			EncryptedContentInfo: encryptedContentInfo{
+				ContentType:                oidDataContentType,
+				ContentEncryptionAlgorithm: algorithm,
+				EncryptedContent:           asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, Bytes: ciphertext},
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		contents = append(contents, contentInfo{
+			ContentType: oidEncryptedDataContentType,
+			Content:     explicitValue(b),
+		})
+	}
+
+	if key != nil {
+		pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		algorithm, ciphertext, err := pbEncrypt(rand, enc, pkcs8, password)
+		if err != nil {
+			return nil, err
+		}
+		b, err := asn1.Marshal(encryptedPrivateKeyInfo{AlgorithmIdentifier: algorithm, EncryptedData: ciphertext})
+		if err != nil {
+			return nil, err
+		}
+		safeContents, err := asn1.Marshal([]safeBag{{
+			Id:         oidPKCS8ShroudedKeyBag,
+			Value:      explicitValue(b),
+			Attributes: keyAttributes,
+		}})
+		if err != nil {
+			return nil, err
+		}
+		ci, err := newDataContentInfo(safeContents)
+		if err != nil {
+			return nil, err
+		}
+		contents = append(contents, ci)
+	}
+
+	authenticatedSafe, err := asn1.Marshal(contents)
+	if err != nil {
+		return nil, err
+	}
+	pfx := pfxPdu{Version: 3}
+	if pfx.AuthSafe, err = newDataContentInfo(authenticatedSafe); err != nil {
+		return nil, err
+	}
+	if pfx.MacData, err = newMAC(rand, enc, authenticatedSafe, password); err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(pfx)
+}
+
+// newAttribute returns an attribute with a single OCTET STRING value.
+func newAttribute(id asn1.ObjectIdentifier, value []byte) (pkcs12Attribute, error) {
+	b, err := asn1.Marshal(value)
+	if err != nil {
+		return pkcs12Attribute{}, err
+	}
+	return pkcs12Attribute{
+		Id:    id,
+		Value: asn1.RawValue{Tag: asn1.TagSet, IsCompound: true, Bytes: b},
+	}, nil
+}
+
+// newDataContentInfo returns a ContentInfo of type data holding b.
+func newDataContentInfo(b []byte) (contentInfo, error) {
+	octets, err := asn1.Marshal(b)
+	if err != nil {
+		return contentInfo{}, err
+	}
+	return contentInfo{
+		ContentType: oidDataContentType,
+		Content:     explicitValue(octets),
+	}, nil
+}
+
+// explicitValue returns the value of a field tagged [0] EXPLICIT
+// holding the encoded element b. encoding/asn1 doesn't add or remove
+// explicit tags around RawValues.
+func explicitValue(b []byte) asn1.RawValue {
+	return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: b}
+}
+
+// newMAC returns the MacData authenticating message.
+func newMAC(rand io.Reader, enc encoding, message []byte, password string) (macData, error) {
+	md := macData{
+		MacSalt:    make([]byte, 8),
+		Iterations: enc.iterations,
+	}
+	if _, err := io.ReadFull(rand, md.MacSalt); err != nil {
+		return md, err
+	}
+	md.Mac.Algorithm = pkix.AlgorithmIdentifier{Algorithm: oidSHA256, Parameters: asn1.NullRawValue}
+	if enc.legacy {
+		md.Mac.Algorithm.Algorithm = oidSHA1
+	}
+	h := hashFromOID(md.Mac.Algorithm.Algorithm)
+	p, err := bmpString(password)
+	if err != nil {
+		return md, err
+	}
+	key := pkcs12KDF(h, kdfMAC, p, md.MacSalt, md.Iterations, h().Size())
+	mac := hmac.New(h, key)
+	mac.Write(message)
+	md.Mac.Digest = mac.Sum(nil)
+	return md, nil
+}
@@ -38,6 +38,12 @@ const (
 	alertInappropriateFallback  alert = 86
 	alertUserCanceled           alert = 90
 	alertNoRenegotiation        alert = 100
+	alertMissingExtension       alert = 109
+	alertUnsupportedExtension   alert = 110
+	alertUnrecognizedName       alert = 112
+	alertBadCertStatusResponse  alert = 113
+	alertUnknownPSKIdentity     alert = 115
+	alertCertificateRequired    alert = 116
 	alertNoApplicationProtocol  alert = 120
 )
 
@@ -65,6 +71,12 @@ var alertText = map[alert]string{
 	alertInappropriateFallback:  "inappropriate fallback",
 	alertUserCanceled:           "user canceled",
 	alertNoRenegotiation:        "no renegotiation",
+	alertMissingExtension:       "missing extension",
+	alertUnsupportedExtension:   "unsupported extension",
+	alertUnrecognizedName:       "unrecognized name",
+	alertBadCertStatusResponse:  "bad certificate status response",
+	alertUnknownPSKIdentity:     "unknown PSK identity",
+	alertCertificateRequired:    "certificate required",
 	alertNoApplicationProtocol:  "no application protocol",
 }
 
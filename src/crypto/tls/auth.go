@@ -0,0 +1,186 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tls
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+)
+
+// This file implements the CertificateVerify signatures of TLS 1.3. See
+// RFC 8446, Section 4.4.3. It also holds the helpers for the RSA-PSS
+// signature schemes, which TLS 1.2 shares with TLS 1.3.
+
+const (
+	serverSignatureContext = "TLS 1.3, server CertificateVerify\x00"
+	clientSignatureContext = "TLS 1.3, client CertificateVerify\x00"
+)
+
+var signaturePadding = bytes.Repeat([]byte{0x20}, 64)
+
+// signedMessage returns the content covered by a TLS 1.3 CertificateVerify
+// signature, hashed with sigHash. If sigHash is zero, as for Ed25519, the
+// content is returned as is.
+func signedMessage(sigHash crypto.Hash, context string, transcript hash.Hash) []byte {
+	if sigHash == crypto.Hash(0) {
+		b := make([]byte, 0, len(signaturePadding)+len(context)+transcript.Size())
+		b = append(b, signaturePadding...)
+		b = append(b, context...)
+		return transcript.Sum(b)
+	}
+	h := sigHash.New()
+	h.Write(signaturePadding)
+	h.Write([]byte(context))
+	h.Write(transcript.Sum(nil))
+	return h.Sum(nil)
+}
+
+// signatureSchemesForPublicKeyTLS13 returns the signature schemes that can
+// be used with pub in TLS 1.3, in preference order.
+func signatureSchemesForPublicKeyTLS13(pub crypto.PublicKey) []SignatureScheme {
+	switch pub := pub.(type) {
+	case *ecdsa.PublicKey:
+		// In TLS 1.3 the curve is bound to the signature scheme.
+		switch pub.Curve {
+		case elliptic.P256():
+			return []SignatureScheme{ECDSAWithP256AndSHA256}
+		case elliptic.P384():
+			return []SignatureScheme{ECDSAWithP384AndSHA384}
+		case elliptic.P521():
+			return []SignatureScheme{ECDSAWithP521AndSHA512}
+		default:
+			return nil
+		}
+	case *rsa.PublicKey:
+		return []SignatureScheme{PSSWithSHA256, PSSWithSHA384, PSSWithSHA512}
+	case ed25519.PublicKey:
+		return []SignatureScheme{Ed25519}
+	default:
+		return nil
+	}
+}
+
+// selectSignatureSchemeTLS13 picks a signature scheme for signing with priv
+// that the peer advertised in peerAlgs.
+func selectSignatureSchemeTLS13(priv crypto.Signer, peerAlgs []SignatureScheme) (SignatureScheme, error) {
+	for _, candidate := range signatureSchemesForPublicKeyTLS13(priv.Public()) {
+		if isSupportedSignatureAlgorithm(candidate, peerAlgs) {
+			return candidate, nil
+		}
+	}
+	return 0, errors.New("tls: peer doesn't support any of the certificate's signature algorithms")
+}
+
+// hashForSignatureSchemeTLS13 returns the hash used by a TLS 1.3 signature
+// scheme. It is zero for Ed25519, which signs the message directly.
+func hashForSignatureSchemeTLS13(sigAlg SignatureScheme) (crypto.Hash, error) {
+	switch sigAlg {
+	case PSSWithSHA256, ECDSAWithP256AndSHA256:
+		return crypto.SHA256, nil
+	case PSSWithSHA384, ECDSAWithP384AndSHA384:
+		return crypto.SHA384, nil
+	case PSSWithSHA512, ECDSAWithP521AndSHA512:
+		return crypto.SHA512, nil
+	case Ed25519:
+		return crypto.Hash(0), nil
+	default:
+		return 0, fmt.Errorf("tls: unsupported signature algorithm %#04x", uint16(sigAlg))
+	}
+}
+
+// signHandshakeTLS13 signs the TLS 1.3 CertificateVerify content for the
+// given context and transcript.
+func signHandshakeTLS13(rand io.Reader, priv crypto.Signer, sigAlg SignatureScheme, context string, transcript hash.Hash) ([]byte, error) {
+	sigHash, err := hashForSignatureSchemeTLS13(sigAlg)
+	if err != nil {
+		return nil, err
+	}
+	digest := signedMessage(sigHash, context, transcript)
+
+	return priv.Sign(rand, digest, signerOptsForSignatureScheme(sigAlg, sigHash))
+}
+
+// signerOptsForSignatureScheme returns the options to pass to
+// crypto.Signer.Sign to produce a signature of scheme sigAlg over a digest
+// computed with hashFunc. If sigAlg is zero, as before TLS 1.2, hashFunc
+// alone is returned.
+func signerOptsForSignatureScheme(sigAlg SignatureScheme, hashFunc crypto.Hash) crypto.SignerOpts {
+	if signatureFromSignatureScheme(sigAlg) == signatureRSAPSS {
+		return &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: hashFunc}
+	}
+	return hashFunc
+}
+
+// isTLS12SignatureSchemeForKey reports whether sigAlg can be used in TLS 1.2
+// to sign with pub, a key of signature type sigType. RSA keys can produce
+// both PKCS #1 v1.5 and RSA-PSS signatures, as long as the key is large
+// enough for a PSS salt as long as the hash output.
+func isTLS12SignatureSchemeForKey(sigAlg SignatureScheme, sigType uint8, pub crypto.PublicKey) bool {
+	switch signatureFromSignatureScheme(sigAlg) {
+	case sigType:
+		return true
+	case signatureRSAPSS:
+		rsaPub, ok := pub.(*rsa.PublicKey)
+		if !ok || sigType != signatureRSA {
+			return false
+		}
+		hashFunc, err := lookupTLSHash(sigAlg)
+		if err != nil {
+			return false
+		}
+		emLen := (rsaPub.N.BitLen() + 6) / 8
+		return emLen >= 2*hashFunc.Size()+2
+	default:
+		return false
+	}
+}
+
+// verifyHandshakeSignatureTLS13 verifies a TLS 1.3 CertificateVerify
+// signature made by pub.
+func verifyHandshakeSignatureTLS13(pub crypto.PublicKey, sigAlg SignatureScheme, context string, transcript hash.Hash, sig []byte) error {
+	if !isSupportedSignatureAlgorithm(sigAlg, signatureSchemesForPublicKeyTLS13(pub)) {
+		return errors.New("tls: peer used an invalid signature algorithm for its certificate")
+	}
+	sigHash, err := hashForSignatureSchemeTLS13(sigAlg)
+	if err != nil {
+		return err
+	}
+	digest := signedMessage(sigHash, context, transcript)
+
+	switch pub := pub.(type) {
+	case *ecdsa.PublicKey:
+		ecdsaSig := new(ecdsaSignature)
+		if _, err := asn1.Unmarshal(sig, ecdsaSig); err != nil {
+			return err
+		}
+		if ecdsaSig.R.Sign() <= 0 || ecdsaSig.S.Sign() <= 0 {
+			return errors.New("tls: ECDSA signature contained zero or negative values")
+		}
+		if !ecdsa.Verify(pub, digest, ecdsaSig.R, ecdsaSig.S) {
+			return errors.New("tls: ECDSA verification failure")
+		}
+	case *rsa.PublicKey:
+		opts := &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}
+		if err := rsa.VerifyPSS(pub, sigHash, digest, sig, opts); err != nil {
+			return err
+		}
+	case ed25519.PublicKey:
+		if !ed25519.Verify(pub, digest, sig) {
+			return errors.New("tls: Ed25519 verification failure")
+		}
+	default:
+		return errors.New("tls: unsupported certificate key type")
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tls
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPickTLS12HashForSignature(t *testing.T) {
+	rsaPub := testRSAPrivateKey.Public()
+	ecdsaPub := testECDSAPrivateKey.Public()
+	pssOnly := &Config{SignatureSchemes: []SignatureScheme{PSSWithSHA384, PSSWithSHA256}}
+
+	tests := []struct {
+		config     *Config
+		sigType    uint8
+		pub        interface{}
+		clientList []SignatureScheme
+		want       SignatureScheme // zero if an error is expected
+	}{
+		{nil, signatureRSA, rsaPub, []SignatureScheme{PSSWithSHA256, PKCS1WithSHA256}, PSSWithSHA256},
+		{nil, signatureRSA, rsaPub, []SignatureScheme{PKCS1WithSHA256, PSSWithSHA256}, PKCS1WithSHA256},
+		// The test key is too small for RSA-PSS with SHA-512.
+		{nil, signatureRSA, rsaPub, []SignatureScheme{PSSWithSHA512, PKCS1WithSHA384}, PKCS1WithSHA384},
+		{nil, signatureECDSA, ecdsaPub, []SignatureScheme{PSSWithSHA256, ECDSAWithP256AndSHA256}, ECDSAWithP256AndSHA256},
+		{nil, signatureRSA, rsaPub, nil, PKCS1WithSHA1},
+		{pssOnly, signatureRSA, rsaPub, []SignatureScheme{PKCS1WithSHA256, PSSWithSHA256}, PSSWithSHA256},
+		{pssOnly, signatureRSA, rsaPub, []SignatureScheme{PKCS1WithSHA256}, 0},
+		{pssOnly, signatureRSA, rsaPub, nil, 0},
+		{pssOnly, signatureEd25519, nil, []SignatureScheme{Ed25519}, 0},
+	}
+	for i, test := range tests {
+		got, err := pickTLS12HashForSignature(test.config, test.sigType, test.pub, test.clientList)
+		if test.want == 0 {
+			if err == nil {
+				t.Errorf("#%d: got %#04x, expected an error", i, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: unexpected error: %s", i, err)
+		} else if got != test.want {
+			t.Errorf("#%d: got %#04x, expected %#04x", i, got, test.want)
+		}
+	}
+}
+
+func TestConfigSignatureSchemes(t *testing.T) {
+	if got := (*Config)(nil).signatureSchemes(supportedSignatureAlgorithms); !reflect.DeepEqual(got, supportedSignatureAlgorithms) {
+		t.Errorf("nil Config: got %v, expected the default schemes", got)
+	}
+	config := &Config{SignatureSchemes: []SignatureScheme{Ed25519, PKCS1WithSHA256, PSSWithSHA256}}
+	if got, want := config.signatureSchemes(supportedSignatureAlgorithms), []SignatureScheme{PKCS1WithSHA256, PSSWithSHA256}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, expected %v", got, want)
+	}
+}
+
+// rsaPSSChain returns a server certificate for example.golang, signed with
+// RSA-PSS by a root that is itself signed with RSA-PSS and a non-default
+// salt length, along with a pool containing the root.
+func rsaPSSChain(t *testing.T) (Certificate, *x509.CertPool) {
+	now := time.Now()
+	rootTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "RSA-PSS root"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:               true,
+		SignatureAlgorithm: x509.SHA256WithRSAPSS,
+		PSSSaltLength:      20,
+	}
+	rootDER, err := x509.CreateCertificate(rand.Reader, rootTemplate, rootTemplate, testRSAPrivateKey.Public(), testRSAPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := x509.ParseCertificate(rootDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafTemplate := &x509.Certificate{
+		SerialNumber:       big.NewInt(2),
+		Subject:            pkix.Name{CommonName: "example.golang"},
+		NotBefore:          now.Add(-time.Hour),
+		NotAfter:           now.Add(time.Hour),
+		KeyUsage:           x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:        []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:           []string{"example.golang"},
+		SignatureAlgorithm: x509.SHA384WithRSAPSS,
+	}
+	leafDER, err := x509.CreateCertificate(rand.Reader, leafTemplate, root, testRSAPrivateKey.Public(), testRSAPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(root)
+	return Certificate{Certificate: [][]byte{leafDER}, PrivateKey: testRSAPrivateKey}, pool
+}
+
+func TestRSAPSSTLS12(t *testing.T) {
+	cert, pool := rsaPSSChain(t)
+	pssOnly := []SignatureScheme{PSSWithSHA256, PSSWithSHA384, PSSWithSHA512}
+
+	clientConfig := testConfig.Clone()
+	clientConfig.Time = nil
+	clientConfig.InsecureSkipVerify = false
+	clientConfig.RootCAs = pool
+	clientConfig.ServerName = "example.golang"
+	clientConfig.Certificates = []Certificate{cert}
+	clientConfig.CipherSuites = []uint16{TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}
+	clientConfig.SignatureSchemes = pssOnly
+	serverConfig := testConfig.Clone()
+	serverConfig.Time = nil
+	serverConfig.Certificates = []Certificate{cert}
+	serverConfig.ClientAuth = RequireAndVerifyClientCert
+	serverConfig.ClientCAs = pool
+	serverConfig.SignatureSchemes = pssOnly
+
+	serverState, clientState, err := testHandshakeTLS13(t, clientConfig, serverConfig)
+	if err != nil {
+		t.Fatalf("handshake failed: %s", err)
+	}
+	if clientState.Version != VersionTLS12 {
+		t.Errorf("got version %#04x, expected %#04x", clientState.Version, VersionTLS12)
+	}
+	if len(clientState.VerifiedChains) != 1 || len(serverState.VerifiedChains) != 1 {
+		t.Errorf("got %d and %d verified chains, expected one each", len(clientState.VerifiedChains), len(serverState.VerifiedChains))
+	}
+}
+
+func TestSignatureSchemesRestriction(t *testing.T) {
+	for _, vers := range []uint16{VersionTLS12, VersionTLS13} {
+		clientConfig := testConfigTLS13()
+		clientConfig.MaxVersion = vers
+		clientConfig.SignatureSchemes = []SignatureScheme{PKCS1WithSHA256}
+		serverConfig := testConfigTLS13()
+		serverConfig.SignatureSchemes = []SignatureScheme{PSSWithSHA256}
+		if _, _, err := testHandshakeTLS13(t, clientConfig, serverConfig); err == nil {
+			t.Errorf("version %#04x: handshake succeeded without a common signature scheme", vers)
+		}
+
+		serverConfig.SignatureSchemes = []SignatureScheme{PKCS1WithSHA256, PSSWithSHA256}
+		_, clientState, err := testHandshakeTLS13(t, clientConfig, serverConfig)
+		switch vers {
+		case VersionTLS12:
+			if err != nil {
+				t.Errorf("TLS 1.2 handshake with PKCS #1 v1.5 failed: %s", err)
+			}
+		case VersionTLS13:
+			// RSA keys must use RSA-PSS in TLS 1.3.
+			if err == nil {
+				t.Errorf("TLS 1.3 handshake succeeded with PKCS #1 v1.5, version %#04x", clientState.Version)
+			}
+		}
+	}
+}
+
+func TestSignatureSchemesClientCertificate(t *testing.T) {
+	// The server only accepts ECDSA, so the client can't sign with its
+	// RSA key.
+	clientConfig := testConfig.Clone()
+	clientConfig.Certificates = []Certificate{{
+		Certificate: [][]byte{testRSACertificate},
+		PrivateKey:  testRSAPrivateKey,
+	}}
+	serverConfig := testConfig.Clone()
+	serverConfig.ClientAuth = RequireAnyClientCert
+	serverConfig.SignatureSchemes = []SignatureScheme{ECDSAWithP256AndSHA256}
+	serverConfig.CipherSuites = []uint16{TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256}
+	serverConfig.Certificates = []Certificate{{
+		Certificate: [][]byte{testECDSACertificate},
+		PrivateKey:  testECDSAPrivateKey,
+	}}
+	if _, _, err := testHandshakeTLS13(t, clientConfig, serverConfig); err == nil {
+		t.Errorf("handshake succeeded with a client signature scheme the server doesn't accept")
+	}
+
+	clientConfig.Certificates[0] = serverConfig.Certificates[0]
+	if _, _, err := testHandshakeTLS13(t, clientConfig, serverConfig); err != nil {
+		t.Errorf("handshake with an ECDSA client certificate failed: %s", err)
+	}
+}
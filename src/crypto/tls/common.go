@@ -26,6 +26,7 @@ const (
 	VersionTLS10 = 0x0301
 	VersionTLS11 = 0x0302
 	VersionTLS12 = 0x0303
+	VersionTLS13 = 0x0304
 )
 
 const (
@@ -37,6 +38,10 @@ const (
 
 	minVersion = VersionTLS10
 	maxVersion = VersionTLS12
+
+	// maxSupportedVersion is the highest version implemented by this
+	// package. It is only used when explicitly enabled by Config.MaxVersion.
+	maxSupportedVersion = VersionTLS13
 )
 
 // TLS record types.
@@ -51,19 +56,23 @@ const (
 
 // TLS handshake message types.
 const (
-	typeHelloRequest       uint8 = 0
-	typeClientHello        uint8 = 1
-	typeServerHello        uint8 = 2
-	typeNewSessionTicket   uint8 = 4
-	typeCertificate        uint8 = 11
-	typeServerKeyExchange  uint8 = 12
-	typeCertificateRequest uint8 = 13
-	typeServerHelloDone    uint8 = 14
-	typeCertificateVerify  uint8 = 15
-	typeClientKeyExchange  uint8 = 16
-	typeFinished           uint8 = 20
-	typeCertificateStatus  uint8 = 22
-	typeNextProtocol       uint8 = 67 // Not IANA assigned
+	typeHelloRequest        uint8 = 0
+	typeClientHello         uint8 = 1
+	typeServerHello         uint8 = 2
+	typeNewSessionTicket    uint8 = 4
+	typeEndOfEarlyData      uint8 = 5
+	typeEncryptedExtensions uint8 = 8
+	typeCertificate         uint8 = 11
+	typeServerKeyExchange   uint8 = 12
+	typeCertificateRequest  uint8 = 13
+	typeServerHelloDone     uint8 = 14
+	typeCertificateVerify   uint8 = 15
+	typeClientKeyExchange   uint8 = 16
+	typeFinished            uint8 = 20
+	typeCertificateStatus   uint8 = 22
+	typeKeyUpdate           uint8 = 24
+	typeNextProtocol        uint8 = 67  // Not IANA assigned
+	typeMessageHash         uint8 = 254 // synthetic message
 )
 
 // TLS compression types.
@@ -73,16 +82,24 @@ const (
 
 // TLS extension numbers
 const (
-	extensionServerName          uint16 = 0
-	extensionStatusRequest       uint16 = 5
-	extensionSupportedCurves     uint16 = 10
-	extensionSupportedPoints     uint16 = 11
-	extensionSignatureAlgorithms uint16 = 13
-	extensionALPN                uint16 = 16
-	extensionSCT                 uint16 = 18 // https://tools.ietf.org/html/rfc6962#section-6
-	extensionSessionTicket       uint16 = 35
-	extensionNextProtoNeg        uint16 = 13172 // not IANA assigned
-	extensionRenegotiationInfo   uint16 = 0xff01
+	extensionServerName              uint16 = 0
+	extensionStatusRequest           uint16 = 5
+	extensionSupportedCurves         uint16 = 10
+	extensionSupportedPoints         uint16 = 11
+	extensionSignatureAlgorithms     uint16 = 13
+	extensionALPN                    uint16 = 16
+	extensionSCT                     uint16 = 18 // https://tools.ietf.org/html/rfc6962#section-6
+	extensionSessionTicket           uint16 = 35
+	extensionPreSharedKey            uint16 = 41
+	extensionEarlyData               uint16 = 42
+	extensionSupportedVersions       uint16 = 43
+	extensionCookie                  uint16 = 44
+	extensionPSKModes                uint16 = 45
+	extensionCertificateAuthorities  uint16 = 47
+	extensionSignatureAlgorithmsCert uint16 = 50
+	extensionKeyShare                uint16 = 51
+	extensionNextProtoNeg            uint16 = 13172 // not IANA assigned
+	extensionRenegotiationInfo       uint16 = 0xff01
 )
 
 // TLS signaling cipher suite values
@@ -101,6 +118,25 @@ const (
 	X25519    CurveID = 29
 )
 
+// TLS 1.3 Key Share. See RFC 8446, Section 4.2.8.
+type keyShare struct {
+	group CurveID
+	data  []byte
+}
+
+// TLS 1.3 PSK Key Exchange Modes. See RFC 8446, Section 4.2.9.
+const (
+	pskModePlain uint8 = 0
+	pskModeDHE   uint8 = 1
+)
+
+// TLS 1.3 PSK Identity. Can be a Session Ticket, or a reference to a saved
+// session. See RFC 8446, Section 4.2.11.
+type pskIdentity struct {
+	label               []byte
+	obfuscatedTicketAge uint32
+}
+
 // TLS Elliptic Curve Point Formats
 // https://www.iana.org/assignments/tls-parameters/tls-parameters.xml#tls-parameters-9
 const (
@@ -131,23 +167,78 @@ const (
 const (
 	signatureRSA   uint8 = 1
 	signatureECDSA uint8 = 3
+
+	// signatureEd25519 is the ed25519 SignatureAlgorithm value of RFC 8422,
+	// Section 5.1.3.
+	signatureEd25519 uint8 = 7
+
+	// signatureRSAPSS is not a TLS 1.2 SignatureAlgorithm value. It is used
+	// internally to identify the RSASSA-PSS signature schemes, which only
+	// exist as TLS 1.3 style SignatureScheme values.
+	signatureRSAPSS uint8 = 8
 )
 
 // supportedSignatureAlgorithms contains the signature and hash algorithms that
 // the code advertises as supported in a TLS 1.2 ClientHello and in a TLS 1.2
 // CertificateRequest. The two fields are merged to match with TLS 1.3.
 // Note that in TLS 1.2, the ECDSA algorithms are not constrained to P-256, etc.
+// The rsa_pss_rsae schemes can be used with RSA keys in TLS 1.2 as well, see
+// RFC 8446, Section 4.2.3.
 var supportedSignatureAlgorithms = []SignatureScheme{
-	PKCS1WithSHA256,
+	PSSWithSHA256,
 	ECDSAWithP256AndSHA256,
-	PKCS1WithSHA384,
+	PSSWithSHA384,
 	ECDSAWithP384AndSHA384,
+	PSSWithSHA512,
+	ECDSAWithP521AndSHA512,
+	PKCS1WithSHA256,
+	PKCS1WithSHA384,
 	PKCS1WithSHA512,
+	PKCS1WithSHA1,
+	ECDSAWithSHA1,
+}
+
+// supportedSignatureAlgorithmsTLS13 contains the signature algorithms that
+// the code advertises as supported when TLS 1.3 is enabled. RSA-PSS is
+// mandatory for RSA keys in TLS 1.3, and SHA-1 is not allowed in handshake
+// signatures (RFC 8446, Section 4.2.3).
+var supportedSignatureAlgorithmsTLS13 = []SignatureScheme{
+	PSSWithSHA256,
+	ECDSAWithP256AndSHA256,
+	Ed25519,
+	PSSWithSHA384,
+	ECDSAWithP384AndSHA384,
+	PSSWithSHA512,
 	ECDSAWithP521AndSHA512,
+	PKCS1WithSHA256,
+	PKCS1WithSHA384,
+	PKCS1WithSHA512,
 	PKCS1WithSHA1,
 	ECDSAWithSHA1,
 }
 
+// helloRetryRequestRandom is set as the Random value of a ServerHello
+// to signal that the message is actually a HelloRetryRequest.
+var helloRetryRequestRandom = []byte{ // See RFC 8446, Section 4.1.3.
+	0xCF, 0x21, 0xAD, 0x74, 0xE5, 0x9A, 0x61, 0x11,
+	0xBE, 0x1D, 0x8C, 0x02, 0x1E, 0x65, 0xB8, 0x91,
+	0xC2, 0xA2, 0x11, 0x16, 0x7A, 0xBB, 0x8C, 0x5E,
+	0x07, 0x9E, 0x09, 0xE2, 0xC8, 0xA8, 0x33, 0x9C,
+}
+
+const (
+	// downgradeCanaryTLS12 or downgradeCanaryTLS11 is embedded in the server
+	// random as a downgrade protection if the server would be capable of
+	// negotiating a higher version. See RFC 8446, Section 4.1.3.
+	downgradeCanaryTLS12 = "DOWNGRD\x01"
+	downgradeCanaryTLS11 = "DOWNGRD\x00"
+)
+
+// maxSessionTicketLifetime is the maximum allowed lifetime of a TLS 1.3
+// session ticket, and the lifetime we set for all tickets we send.
+// See RFC 8446, Section 4.6.1.
+const maxSessionTicketLifetime = 7 * 24 * time.Hour
+
 // ConnectionState records basic TLS details about the connection.
 type ConnectionState struct {
 	Version                     uint16                // TLS version used by the connection (e.g. VersionTLS12)
@@ -192,6 +283,13 @@ type ClientSessionState struct {
 	masterSecret       []byte                // MasterSecret generated by client on a full handshake
 	serverCertificates []*x509.Certificate   // Certificate chain presented by the server
 	verifiedChains     [][]*x509.Certificate // Certificate chains we built for verification
+	ocspResponse       []byte                // Stapled OCSP response from the server, if any
+
+	// TLS 1.3 fields. For TLS 1.3 sessions masterSecret holds the
+	// resumption master secret.
+	receivedAt time.Time // When the session ticket was received from the server
+	useBy      time.Time // Expiration of the ticket lifetime as set by the server
+	ageAdd     uint32    // Random obfuscation factor for sending the ticket age
 }
 
 // ClientSessionCache is a cache of ClientSessionState objects that can be used
@@ -204,7 +302,9 @@ type ClientSessionCache interface {
 	// On return, ok is true if one was found.
 	Get(sessionKey string) (session *ClientSessionState, ok bool)
 
-	// Put adds the ClientSessionState to the cache with the given key.
+	// Put adds the ClientSessionState to the cache with the given key. It
+	// might get called multiple times in a connection if a TLS 1.3 server
+	// provides more than one session ticket.
 	Put(sessionKey string, cs *ClientSessionState)
 }
 
@@ -226,6 +326,9 @@ const (
 	ECDSAWithP384AndSHA384 SignatureScheme = 0x0503
 	ECDSAWithP521AndSHA512 SignatureScheme = 0x0603
 
+	// EdDSA algorithms.
+	Ed25519 SignatureScheme = 0x0807
+
 	// Legacy signature and hash algorithms for TLS 1.2.
 	ECDSAWithSHA1 SignatureScheme = 0x0203
 )
@@ -425,6 +528,22 @@ type Config struct {
 	// an IP address.
 	ServerName string
 
+	// RequireOCSPStaple causes a client to reject servers that don't
+	// staple a valid OCSP response reporting that their certificate
+	// is good. The response must be signed by, or on behalf of, the
+	// issuer of the server's certificate in a verified chain, and be
+	// current according to Time. It is ignored if InsecureSkipVerify
+	// is set.
+	//
+	// The response is saved with resumable sessions. A session is
+	// only resumed if its saved response is still current; otherwise
+	// a full handshake is performed to obtain a new one.
+	//
+	// Independently of this setting, a client that verifies the
+	// server's certificate rejects it if a valid stapled response
+	// reports that it has been revoked.
+	RequireOCSPStaple bool
+
 	// ClientAuth determines the server's policy for
 	// TLS Client Authentication. The default is NoClientCert.
 	ClientAuth ClientAuthType
@@ -475,8 +594,8 @@ type Config struct {
 	MinVersion uint16
 
 	// MaxVersion contains the maximum SSL/TLS version that is acceptable.
-	// If zero, then the maximum version supported by this package is used,
-	// which is currently TLS 1.2.
+	// If zero, then TLS 1.2 is taken as the maximum. TLS 1.3 is only
+	// negotiated when MaxVersion is set to VersionTLS13.
 	MaxVersion uint16
 
 	// CurvePreferences contains the elliptic curves that will be used in
@@ -484,6 +603,13 @@ type Config struct {
 	// be used.
 	CurvePreferences []CurveID
 
+	// SignatureSchemes, if not empty, restricts the signature schemes
+	// that will be advertised, used and accepted in handshake signatures
+	// to the ones it contains, in preference order. If empty, all
+	// supported schemes are allowed. It does not affect the verification
+	// of certificate signatures.
+	SignatureSchemes []SignatureScheme
+
 	// DynamicRecordSizingDisabled disables adaptive sizing of TLS records.
 	// When true, the largest possible TLS record size is always used. When
 	// false, the size of TLS records may be adjusted in an attempt to
@@ -561,6 +687,7 @@ func (c *Config) Clone() *Config {
 		RootCAs:                     c.RootCAs,
 		NextProtos:                  c.NextProtos,
 		ServerName:                  c.ServerName,
+		RequireOCSPStaple:           c.RequireOCSPStaple,
 		ClientAuth:                  c.ClientAuth,
 		ClientCAs:                   c.ClientCAs,
 		InsecureSkipVerify:          c.InsecureSkipVerify,
@@ -572,6 +699,7 @@ func (c *Config) Clone() *Config {
 		MinVersion:                  c.MinVersion,
 		MaxVersion:                  c.MaxVersion,
 		CurvePreferences:            c.CurvePreferences,
+		SignatureSchemes:            c.SignatureSchemes,
 		DynamicRecordSizingDisabled: c.DynamicRecordSizingDisabled,
 		Renegotiation:               c.Renegotiation,
 		KeyLogWriter:                c.KeyLogWriter,
@@ -677,9 +805,23 @@ func (c *Config) maxVersion() uint16 {
 	if c == nil || c.MaxVersion == 0 {
 		return maxVersion
 	}
+	if c.MaxVersion > maxSupportedVersion {
+		return maxSupportedVersion
+	}
 	return c.MaxVersion
 }
 
+// supportedVersions returns the list of protocol versions that are
+// enabled by c, in preference order, for use in the supported_versions
+// extension.
+func (c *Config) supportedVersions() []uint16 {
+	var versions []uint16
+	for v := c.maxVersion(); v >= c.minVersion() && v >= VersionSSL30; v-- {
+		versions = append(versions, v)
+	}
+	return versions
+}
+
 var defaultCurvePreferences = []CurveID{X25519, CurveP256, CurveP384, CurveP521}
 
 func (c *Config) curvePreferences() []CurveID {
@@ -689,11 +831,30 @@ func (c *Config) curvePreferences() []CurveID {
 	return c.CurvePreferences
 }
 
+// signatureSchemes returns the elements of schemes allowed by
+// c.SignatureSchemes, in the preference order of c.SignatureSchemes.
+func (c *Config) signatureSchemes(schemes []SignatureScheme) []SignatureScheme {
+	if c == nil || len(c.SignatureSchemes) == 0 {
+		return schemes
+	}
+	var allowed []SignatureScheme
+	for _, s := range c.SignatureSchemes {
+		if isSupportedSignatureAlgorithm(s, schemes) {
+			allowed = append(allowed, s)
+		}
+	}
+	return allowed
+}
+
 // mutualVersion returns the protocol version to use given the advertised
-// version of the peer.
+// version of the peer. It never negotiates TLS 1.3, which can only be
+// selected through the supported_versions extension.
 func (c *Config) mutualVersion(vers uint16) (uint16, bool) {
 	minVersion := c.minVersion()
 	maxVersion := c.maxVersion()
+	if maxVersion > VersionTLS12 {
+		maxVersion = VersionTLS12
+	}
 
 	if vers < minVersion {
 		return 0, false
@@ -704,6 +865,20 @@ func (c *Config) mutualVersion(vers uint16) (uint16, bool) {
 	return vers, true
 }
 
+// mutualVersionFromList returns the highest version in c's supported range
+// that also appears in peerVersions, as advertised in a supported_versions
+// extension.
+func (c *Config) mutualVersionFromList(peerVersions []uint16) (uint16, bool) {
+	for _, v := range c.supportedVersions() {
+		for _, pv := range peerVersions {
+			if v == pv {
+				return v, true
+			}
+		}
+	}
+	return 0, false
+}
+
 // getCertificate returns the best certificate for the given ClientHelloInfo,
 // defaulting to the first element of c.Certificates.
 func (c *Config) getCertificate(clientHello *ClientHelloInfo) (*Certificate, error) {
@@ -771,11 +946,25 @@ func (c *Config) BuildNameToCertificate() {
 // writeKeyLog logs client random and master secret if logging was enabled by
 // setting c.KeyLogWriter.
 func (c *Config) writeKeyLog(clientRandom, masterSecret []byte) error {
+	return c.writeKeyLogLabel("CLIENT_RANDOM", clientRandom, masterSecret)
+}
+
+// Key log labels for the TLS 1.3 traffic secrets.
+const (
+	keyLogLabelClientHandshake = "CLIENT_HANDSHAKE_TRAFFIC_SECRET"
+	keyLogLabelServerHandshake = "SERVER_HANDSHAKE_TRAFFIC_SECRET"
+	keyLogLabelClientTraffic   = "CLIENT_TRAFFIC_SECRET_0"
+	keyLogLabelServerTraffic   = "SERVER_TRAFFIC_SECRET_0"
+)
+
+// writeKeyLogLabel logs a secret under the given NSS key log label if logging
+// was enabled by setting c.KeyLogWriter.
+func (c *Config) writeKeyLogLabel(label string, clientRandom, secret []byte) error {
 	if c.KeyLogWriter == nil {
 		return nil
 	}
 
-	logLine := []byte(fmt.Sprintf("CLIENT_RANDOM %x %x\n", clientRandom, masterSecret))
+	logLine := []byte(fmt.Sprintf("%s %x %x\n", label, clientRandom, secret))
 
 	writerMutex.Lock()
 	_, err := c.KeyLogWriter.Write(logLine)
@@ -793,9 +982,9 @@ type Certificate struct {
 	Certificate [][]byte
 	// PrivateKey contains the private key corresponding to the public key
 	// in Leaf. For a server, this must implement crypto.Signer and/or
-	// crypto.Decrypter, with an RSA or ECDSA PublicKey. For a client
-	// (performing client authentication), this must be a crypto.Signer
-	// with an RSA or ECDSA PublicKey.
+	// crypto.Decrypter, with an RSA, ECDSA or Ed25519 PublicKey. For a
+	// client (performing client authentication), this must be a
+	// crypto.Signer with an RSA, ECDSA or Ed25519 PublicKey.
 	PrivateKey crypto.PrivateKey
 	// OCSPStaple contains an optional OCSP response which will be served
 	// to clients that request it.
@@ -900,8 +1089,9 @@ func defaultConfig() *Config {
 }
 
 var (
-	once                   sync.Once
-	varDefaultCipherSuites []uint16
+	once                        sync.Once
+	varDefaultCipherSuites      []uint16
+	varDefaultCipherSuitesTLS13 []uint16
 )
 
 func defaultCipherSuites() []uint16 {
@@ -909,6 +1099,13 @@ func defaultCipherSuites() []uint16 {
 	return varDefaultCipherSuites
 }
 
+// defaultCipherSuitesTLS13 returns the TLS 1.3 cipher suites in preference
+// order. They are not configurable.
+func defaultCipherSuitesTLS13() []uint16 {
+	once.Do(initDefaultCipherSuites)
+	return varDefaultCipherSuitesTLS13
+}
+
 func initDefaultCipherSuites() {
 	var topCipherSuites []uint16
 	if cipherhw.AESGCMSupport() {
@@ -922,6 +1119,11 @@ func initDefaultCipherSuites() {
 			TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
 			TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,
 		}
+		varDefaultCipherSuitesTLS13 = []uint16{
+			TLS_AES_128_GCM_SHA256,
+			TLS_CHACHA20_POLY1305_SHA256,
+			TLS_AES_256_GCM_SHA384,
+		}
 	} else {
 		// Without AES-GCM hardware, we put the ChaCha20-Poly1305
 		// cipher suites first.
@@ -933,6 +1135,11 @@ func initDefaultCipherSuites() {
 			TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
 			TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
 		}
+		varDefaultCipherSuitesTLS13 = []uint16{
+			TLS_CHACHA20_POLY1305_SHA256,
+			TLS_AES_128_GCM_SHA256,
+			TLS_AES_256_GCM_SHA384,
+		}
 	}
 
 	varDefaultCipherSuites = make([]uint16, 0, len(cipherSuites))
@@ -973,6 +1180,10 @@ func signatureFromSignatureScheme(signatureAlgorithm SignatureScheme) uint8 {
 		return signatureRSA
 	case ECDSAWithSHA1, ECDSAWithP256AndSHA256, ECDSAWithP384AndSHA384, ECDSAWithP521AndSHA512:
 		return signatureECDSA
+	case PSSWithSHA256, PSSWithSHA384, PSSWithSHA512:
+		return signatureRSAPSS
+	case Ed25519:
+		return signatureEd25519
 	default:
 		return 0
 	}
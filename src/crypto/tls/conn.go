@@ -81,6 +81,11 @@ type Conn struct {
 	clientProtocol         string
 	clientProtocolFallback bool
 
+	// resumptionSecret is the TLS 1.3 resumption master secret of the
+	// most recent handshake, used to derive the PSKs of new session
+	// tickets.
+	resumptionSecret []byte
+
 	// input/output
 	in, out   halfConn     // in.Mutex < out.Mutex
 	rawInput  *block       // raw input, right off the wire
@@ -158,6 +163,8 @@ type halfConn struct {
 
 	// used to save allocating a new buffer for each MAC.
 	inDigestBuf, outDigestBuf []byte
+
+	trafficSecret []byte // current TLS 1.3 traffic secret
 }
 
 func (hc *halfConn) setErrorLocked(err error) error {
@@ -189,6 +196,21 @@ func (hc *halfConn) changeCipherSpec() error {
 	return nil
 }
 
+// setTrafficSecret installs the TLS 1.3 keys derived from secret. Unlike
+// changeCipherSpec, the new keys take effect immediately.
+func (hc *halfConn) setTrafficSecret(suite *cipherSuiteTLS13, secret []byte) {
+	hc.version = VersionTLS13
+	hc.trafficSecret = secret
+	key, iv := suite.trafficKey(secret)
+	hc.cipher = suite.aead(key, iv)
+	hc.mac = nil
+	hc.nextCipher = nil
+	hc.nextMac = nil
+	for i := range hc.seq {
+		hc.seq[i] = 0
+	}
+}
+
 // incSeq increments the sequence number.
 func (hc *halfConn) incSeq() {
 	for i := 7; i >= 0; i-- {
@@ -302,17 +324,40 @@ func (hc *halfConn) decrypt(b *block) (ok bool, prefixLen int, alertValue alert)
 				nonce = hc.seq[:]
 			}
 
-			copy(hc.additionalData[:], hc.seq[:])
-			copy(hc.additionalData[8:], b.data[:3])
-			n := len(payload) - c.Overhead()
-			hc.additionalData[11] = byte(n >> 8)
-			hc.additionalData[12] = byte(n)
+			var additionalData []byte
+			if hc.version == VersionTLS13 {
+				// In TLS 1.3 the additional data is the record
+				// header itself. See RFC 8446, Section 5.2.
+				additionalData = b.data[:recordHeaderLen]
+			} else {
+				copy(hc.additionalData[:], hc.seq[:])
+				copy(hc.additionalData[8:], b.data[:3])
+				n := len(payload) - c.Overhead()
+				hc.additionalData[11] = byte(n >> 8)
+				hc.additionalData[12] = byte(n)
+				additionalData = hc.additionalData[:]
+			}
 			var err error
-			payload, err = c.Open(payload[:0], nonce, payload, hc.additionalData[:])
+			payload, err = c.Open(payload[:0], nonce, payload, additionalData)
 			if err != nil {
 				return false, 0, alertBadRecordMAC
 			}
 			b.resize(recordHeaderLen + explicitIVLen + len(payload))
+
+			if hc.version == VersionTLS13 {
+				// Strip the padding and recover the real content
+				// type, which is the last non-zero byte of the
+				// plaintext.
+				i := len(b.data) - 1
+				for i >= recordHeaderLen && b.data[i] == 0 {
+					i--
+				}
+				if i < recordHeaderLen {
+					return false, 0, alertUnexpectedMessage
+				}
+				b.data[0] = b.data[i]
+				b.resize(i)
+			}
 		case cbcMode:
 			blockSize := c.BlockSize()
 			if hc.version >= VersionTLS11 {
@@ -417,12 +462,21 @@ func (hc *halfConn) encrypt(b *block, explicitIVLen int) (bool, alert) {
 			payload := b.data[recordHeaderLen+explicitIVLen:]
 			payload = payload[:payloadLen]
 
-			copy(hc.additionalData[:], hc.seq[:])
-			copy(hc.additionalData[8:], b.data[:3])
-			hc.additionalData[11] = byte(payloadLen >> 8)
-			hc.additionalData[12] = byte(payloadLen)
+			var additionalData []byte
+			if hc.version == VersionTLS13 {
+				n := payloadLen + c.Overhead()
+				b.data[3] = byte(n >> 8)
+				b.data[4] = byte(n)
+				additionalData = b.data[:recordHeaderLen]
+			} else {
+				copy(hc.additionalData[:], hc.seq[:])
+				copy(hc.additionalData[8:], b.data[:3])
+				hc.additionalData[11] = byte(payloadLen >> 8)
+				hc.additionalData[12] = byte(payloadLen)
+				additionalData = hc.additionalData[:]
+			}
 
-			c.Seal(payload[:0], nonce, payload, hc.additionalData[:])
+			c.Seal(payload[:0], nonce, payload, additionalData)
 		case cbcMode:
 			blockSize := c.BlockSize()
 			if explicitIVLen > 0 {
@@ -574,7 +628,9 @@ func (c *Conn) readRecord(want recordType) error {
 		c.sendAlert(alertInternalError)
 		return c.in.setErrorLocked(errors.New("tls: unknown record type requested"))
 	case recordTypeHandshake, recordTypeChangeCipherSpec:
-		if c.handshakeComplete {
+		// TLS 1.3 carries NewSessionTicket and KeyUpdate messages
+		// after the handshake.
+		if c.handshakeComplete && !(want == recordTypeHandshake && c.vers == VersionTLS13) {
 			c.sendAlert(alertInternalError)
 			return c.in.setErrorLocked(errors.New("tls: handshake or ChangeCipherSpec requested while not in handshake"))
 		}
@@ -617,7 +673,9 @@ Again:
 
 	vers := uint16(b.data[1])<<8 | uint16(b.data[2])
 	n := int(b.data[3])<<8 | int(b.data[4])
-	if c.haveVers && vers != c.vers {
+	// The record version is frozen at TLS 1.2 in TLS 1.3 and must be
+	// ignored. See RFC 8446, Section 5.1.
+	if c.haveVers && c.vers != VersionTLS13 && vers != c.vers {
 		c.sendAlert(alertProtocolVersion)
 		msg := fmt.Sprintf("received record with version %x when expecting version %x", vers, c.vers)
 		return c.in.setErrorLocked(c.newRecordHeaderError(msg))
@@ -649,11 +707,36 @@ Again:
 
 	// Process message.
 	b, c.rawInput = c.in.splitBlock(b, recordHeaderLen+n)
+
+	if c.vers == VersionTLS13 {
+		// In TLS 1.3, unencrypted change_cipher_spec records may be
+		// sent for middlebox compatibility and are dropped without
+		// being decrypted. See RFC 8446, Section 5.
+		if typ == recordTypeChangeCipherSpec {
+			data := b.data[recordHeaderLen:]
+			if c.handshakeComplete || len(data) != 1 || data[0] != 1 || c.hand.Len() > 0 {
+				c.in.freeBlock(b)
+				return c.in.setErrorLocked(c.sendAlert(alertUnexpectedMessage))
+			}
+			c.in.freeBlock(b)
+			goto Again
+		}
+		// Once keys are in use, every record must be protected.
+		if c.in.cipher != nil && typ != recordTypeApplicationData {
+			c.in.freeBlock(b)
+			return c.in.setErrorLocked(c.sendAlert(alertUnexpectedMessage))
+		}
+	}
+
 	ok, off, alertValue := c.in.decrypt(b)
 	if !ok {
 		c.in.freeBlock(b)
 		return c.in.setErrorLocked(c.sendAlert(alertValue))
 	}
+	if c.in.version == VersionTLS13 && c.in.cipher != nil {
+		// The real content type is recovered by decrypt.
+		typ = recordType(b.data[0])
+	}
 	b.off = off
 	data := b.data[b.off:]
 	if len(data) > maxPlaintext {
@@ -723,7 +806,7 @@ Again:
 
 	case recordTypeHandshake:
 		// TODO(rsc): Should at least pick off connection close.
-		if typ != want && !(c.isClient && c.config.Renegotiation != RenegotiateNever) {
+		if typ != want && c.vers != VersionTLS13 && !(c.isClient && c.config.Renegotiation != RenegotiateNever) {
 			return c.in.setErrorLocked(c.sendAlert(alertNoRenegotiation))
 		}
 		c.hand.Write(data)
@@ -811,6 +894,9 @@ func (c *Conn) maxPayloadSizeForWrite(typ recordType, explicitIVLen int) int {
 	}
 
 	payloadBytes := tcpMSSEstimate - recordHeaderLen - explicitIVLen
+	if c.out.version == VersionTLS13 {
+		payloadBytes-- // encrypted ContentType
+	}
 	if c.out.cipher != nil {
 		switch ciph := c.out.cipher.(type) {
 		case cipher.Stream:
@@ -911,6 +997,10 @@ func (c *Conn) writeRecordLocked(typ recordType, data []byte) (int, error) {
 			// Some TLS servers fail if the record version is
 			// greater than TLS 1.0 for the initial ClientHello.
 			vers = VersionTLS10
+		} else if vers == VersionTLS13 {
+			// TLS 1.3 froze the record layer version at 1.2.
+			// See RFC 8446, Section 5.1.
+			vers = VersionTLS12
 		}
 		b.data[1] = byte(vers >> 8)
 		b.data[2] = byte(vers)
@@ -927,6 +1017,13 @@ func (c *Conn) writeRecordLocked(typ recordType, data []byte) (int, error) {
 			}
 		}
 		copy(b.data[recordHeaderLen+explicitIVLen:], data)
+		if c.out.version == VersionTLS13 && c.out.cipher != nil {
+			// Protected TLS 1.3 records carry their real type
+			// inside the encrypted payload. See RFC 8446, Section 5.2.
+			b.resize(len(b.data) + 1)
+			b.data[len(b.data)-1] = byte(typ)
+			b.data[0] = byte(recordTypeApplicationData)
+		}
 		c.out.encrypt(b, explicitIVLen)
 		if _, err := c.write(b.data); err != nil {
 			return n, err
@@ -983,6 +1080,33 @@ func (c *Conn) readHandshake() (interface{}, error) {
 	}
 	data = c.hand.Next(4 + n)
 	var m handshakeMessage
+	if c.vers == VersionTLS13 {
+		switch data[0] {
+		case typeClientHello:
+			m = new(clientHelloMsg)
+		case typeServerHello:
+			m = new(serverHelloMsg)
+		case typeEncryptedExtensions:
+			m = new(encryptedExtensionsMsg)
+		case typeCertificate:
+			m = new(certificateMsgTLS13)
+		case typeCertificateRequest:
+			m = new(certificateRequestMsgTLS13)
+		case typeCertificateVerify:
+			m = &certificateVerifyMsg{
+				hasSignatureAndHash: true,
+			}
+		case typeFinished:
+			m = new(finishedMsg)
+		case typeNewSessionTicket:
+			m = new(newSessionTicketMsgTLS13)
+		case typeKeyUpdate:
+			m = new(keyUpdateMsg)
+		default:
+			return nil, c.in.setErrorLocked(c.sendAlert(alertUnexpectedMessage))
+		}
+		return c.unmarshalHandshake(m, data)
+	}
 	switch data[0] {
 	case typeHelloRequest:
 		m = new(helloRequestMsg)
@@ -1017,7 +1141,12 @@ func (c *Conn) readHandshake() (interface{}, error) {
 	default:
 		return nil, c.in.setErrorLocked(c.sendAlert(alertUnexpectedMessage))
 	}
+	return c.unmarshalHandshake(m, data)
+}
 
+// unmarshalHandshake parses data, which holds a complete handshake message,
+// into m.
+func (c *Conn) unmarshalHandshake(m handshakeMessage, data []byte) (interface{}, error) {
 	// The handshake message unmarshalers
 	// expect to be able to keep references to data,
 	// so pass in a fresh copy that won't be overwritten.
@@ -1091,6 +1220,85 @@ func (c *Conn) Write(b []byte) (int, error) {
 	return n + m, c.out.setErrorLocked(err)
 }
 
+// handlePostHandshakeMessage processes a handshake message arrived after the
+// handshake is complete. Up to TLS 1.2, it indicates the start of a
+// renegotiation.
+// c.in.Mutex <= L
+func (c *Conn) handlePostHandshakeMessage() error {
+	if c.vers != VersionTLS13 {
+		return c.handleRenegotiation()
+	}
+
+	msg, err := c.readHandshake()
+	if err != nil {
+		return err
+	}
+
+	switch msg := msg.(type) {
+	case *newSessionTicketMsgTLS13:
+		return c.handleNewSessionTicket(msg)
+	case *keyUpdateMsg:
+		return c.handleKeyUpdate(msg)
+	default:
+		c.sendAlert(alertUnexpectedMessage)
+		return fmt.Errorf("tls: received unexpected handshake message of type %T", msg)
+	}
+}
+
+// handleKeyUpdate processes a TLS 1.3 KeyUpdate message from the peer.
+// c.in.Mutex <= L
+func (c *Conn) handleKeyUpdate(keyUpdate *keyUpdateMsg) error {
+	cipherSuite := cipherSuiteTLS13ByID(c.cipherSuite)
+	if cipherSuite == nil {
+		return c.in.setErrorLocked(c.sendAlert(alertInternalError))
+	}
+
+	// A KeyUpdate changes the keys, so it must be the last message in
+	// its record. See RFC 8446, Section 5.1.
+	if c.hand.Len() > 0 {
+		return c.in.setErrorLocked(c.sendAlert(alertUnexpectedMessage))
+	}
+
+	newSecret := cipherSuite.nextTrafficSecret(c.in.trafficSecret)
+	c.in.setTrafficSecret(cipherSuite, newSecret)
+
+	if keyUpdate.updateRequested {
+		return c.sendKeyUpdate(false)
+	}
+	return nil
+}
+
+// sendKeyUpdate sends a TLS 1.3 KeyUpdate message and switches to the next
+// sending keys. If requestUpdate is true the peer is asked to update its
+// sending keys as well.
+// L < c.out.Mutex.
+func (c *Conn) sendKeyUpdate(requestUpdate bool) error {
+	cipherSuite := cipherSuiteTLS13ByID(c.cipherSuite)
+	if cipherSuite == nil {
+		return errors.New("tls: key update is only supported in TLS 1.3")
+	}
+
+	c.out.Lock()
+	defer c.out.Unlock()
+
+	if err := c.out.err; err != nil {
+		return err
+	}
+
+	msg := &keyUpdateMsg{
+		updateRequested: requestUpdate,
+	}
+	if _, err := c.writeRecordLocked(recordTypeHandshake, msg.marshal()); err != nil {
+		// Surface the error at the next write.
+		c.out.setErrorLocked(err)
+		return err
+	}
+
+	newSecret := cipherSuite.nextTrafficSecret(c.out.trafficSecret)
+	c.out.setTrafficSecret(cipherSuite, newSecret)
+	return nil
+}
+
 // handleRenegotiation processes a HelloRequest handshake message.
 // c.in.Mutex <= L
 func (c *Conn) handleRenegotiation() error {
@@ -1157,10 +1365,11 @@ func (c *Conn) Read(b []byte) (n int, err error) {
 				// Soft error, like EAGAIN
 				return 0, err
 			}
-			if c.hand.Len() > 0 {
-				// We received handshake bytes, indicating the
-				// start of a renegotiation.
-				if err := c.handleRenegotiation(); err != nil {
+			for c.hand.Len() > 0 {
+				// We received handshake bytes, indicating either
+				// the start of a renegotiation or, in TLS 1.3, a
+				// post-handshake message.
+				if err := c.handlePostHandshakeMessage(); err != nil {
 					return 0, err
 				}
 			}
@@ -1369,7 +1578,7 @@ func (c *Conn) ConnectionState() ConnectionState {
 		state.VerifiedChains = c.verifiedChains
 		state.SignedCertificateTimestamps = c.scts
 		state.OCSPResponse = c.ocspResponse
-		if !c.didResume {
+		if !c.didResume && c.vers != VersionTLS13 {
 			if c.clientFinishedIsFirst {
 				state.TLSUnique = c.clientFinished[:]
 			} else {
@@ -8,6 +8,7 @@ import (
 	"bytes"
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rsa"
 	"crypto/subtle"
 	"crypto/x509"
@@ -17,6 +18,7 @@ import (
 	"net"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type clientHandshakeState struct {
@@ -29,26 +31,47 @@ type clientHandshakeState struct {
 	session      *ClientSessionState
 }
 
-func makeClientHello(config *Config) (*clientHelloMsg, error) {
+func (c *Conn) makeClientHello() (*clientHelloMsg, ecdheParameters, error) {
+	config := c.config
 	if len(config.ServerName) == 0 && !config.InsecureSkipVerify {
-		return nil, errors.New("tls: either ServerName or InsecureSkipVerify must be specified in the tls.Config")
+		return nil, nil, errors.New("tls: either ServerName or InsecureSkipVerify must be specified in the tls.Config")
 	}
 
 	nextProtosLength := 0
 	for _, proto := range config.NextProtos {
 		if l := len(proto); l == 0 || l > 255 {
-			return nil, errors.New("tls: invalid NextProtos value")
+			return nil, nil, errors.New("tls: invalid NextProtos value")
 		} else {
 			nextProtosLength += 1 + l
 		}
 	}
 
 	if nextProtosLength > 0xffff {
-		return nil, errors.New("tls: NextProtos values too large")
+		return nil, nil, errors.New("tls: NextProtos values too large")
+	}
+
+	supportedVersions := config.supportedVersions()
+	if c.handshakes > 0 {
+		// TLS 1.3 must not be offered in a renegotiation.
+		// See RFC 8446, Section 4.1.2.
+		for len(supportedVersions) > 0 && supportedVersions[0] > VersionTLS12 {
+			supportedVersions = supportedVersions[1:]
+		}
+	}
+	if len(supportedVersions) == 0 {
+		return nil, nil, errors.New("tls: no supported versions satisfy MinVersion and MaxVersion")
+	}
+
+	clientHelloVersion := supportedVersions[0]
+	// The version at the beginning of the ClientHello was capped at TLS 1.2
+	// for compatibility reasons. The supported_versions extension is used
+	// to negotiate versions now. See RFC 8446, Section 4.2.1.
+	if clientHelloVersion > VersionTLS12 {
+		clientHelloVersion = VersionTLS12
 	}
 
 	hello := &clientHelloMsg{
-		vers:                         config.maxVersion(),
+		vers:                         clientHelloVersion,
 		compressionMethods:           []uint8{compressionNone},
 		random:                       make([]byte, 32),
 		ocspStapling:                 true,
@@ -81,14 +104,32 @@ NextCipherSuite:
 
 	_, err := io.ReadFull(config.rand(), hello.random)
 	if err != nil {
-		return nil, errors.New("tls: short read from Rand: " + err.Error())
+		return nil, nil, errors.New("tls: short read from Rand: " + err.Error())
 	}
 
 	if hello.vers >= VersionTLS12 {
-		hello.supportedSignatureAlgorithms = supportedSignatureAlgorithms
+		hello.supportedSignatureAlgorithms = config.signatureSchemes(supportedSignatureAlgorithms)
+	}
+
+	var params ecdheParameters
+	if supportedVersions[0] == VersionTLS13 {
+		hello.supportedVersions = supportedVersions
+		hello.cipherSuites = append(hello.cipherSuites, defaultCipherSuitesTLS13()...)
+		hello.supportedSignatureAlgorithms = config.signatureSchemes(supportedSignatureAlgorithmsTLS13)
+
+		curveID := config.curvePreferences()[0]
+		if _, ok := curveForCurveID(curveID); curveID != X25519 && !ok {
+			return nil, nil, errors.New("tls: CurvePreferences includes unsupported curve")
+		}
+		params, err = generateECDHEParameters(config.rand(), curveID)
+		if err != nil {
+			return nil, nil, err
+		}
+		hello.keyShares = []keyShare{{group: curveID, data: params.PublicKey()}}
+		hello.pskModes = []uint8{pskModeDHE}
 	}
 
-	return hello, nil
+	return hello, params, nil
 }
 
 // c.out.Mutex <= L; c.handshakeMutex <= L.
@@ -101,7 +142,7 @@ func (c *Conn) clientHandshake() error {
 	// need to be reset.
 	c.didResume = false
 
-	hello, err := makeClientHello(c.config)
+	hello, ecdheParams, err := c.makeClientHello()
 	if err != nil {
 		return err
 	}
@@ -129,7 +170,7 @@ func (c *Conn) clientHandshake() error {
 		// available.
 		cacheKey = clientSessionCacheKey(c.conn.RemoteAddr(), c.config)
 		candidateSession, ok := sessionCache.Get(cacheKey)
-		if ok {
+		if ok && candidateSession != nil {
 			// Check that the ciphersuite/version used for the
 			// previous session are still valid.
 			cipherSuiteOk := false
@@ -148,7 +189,35 @@ func (c *Conn) clientHandshake() error {
 		}
 	}
 
-	if session != nil {
+	var earlySecret, binderKey []byte
+	if session != nil && session.vers == VersionTLS13 && c.config.time().After(session.useBy) {
+		// Don't offer an expired TLS 1.3 session ticket.
+		session = nil
+	}
+	if session != nil && c.config.RequireOCSPStaple && !c.config.InsecureSkipVerify &&
+		c.checkOCSPStaple(session.ocspResponse, session.serverCertificates, session.verifiedChains) != nil {
+		// Resuming would skip the certificate status check, so
+		// only resume if the session's saved response still holds.
+		session = nil
+	}
+	if session != nil && session.vers == VersionTLS13 {
+		// In TLS 1.3 the session is resumed by offering its PSK, and
+		// the binder commits to the rest of the ClientHello.
+		// See RFC 8446, Section 4.2.11.
+		suite := cipherSuiteTLS13ByID(session.cipherSuite)
+		ticketAge := uint32(c.config.time().Sub(session.receivedAt) / time.Millisecond)
+		hello.pskIdentities = []pskIdentity{{
+			label:               session.sessionTicket,
+			obfuscatedTicketAge: ticketAge + session.ageAdd,
+		}}
+		hello.pskBinders = [][]byte{make([]byte, suite.hash.Size())}
+
+		earlySecret = suite.extract(session.masterSecret, nil)
+		binderKey = suite.deriveSecret(earlySecret, resumptionBinderLabel, nil)
+		transcript := suite.hash.New()
+		transcript.Write(hello.marshalWithoutBinders())
+		hello.updateBinders([][]byte{suite.finishedHash(binderKey, transcript)})
+	} else if session != nil {
 		hello.sessionTicket = session.sessionTicket
 		// A random session ID is used to detect when the
 		// server accepted the ticket and is resuming a session
@@ -159,10 +228,46 @@ func (c *Conn) clientHandshake() error {
 		}
 	}
 
+	// send ClientHello
+	if _, err := c.writeRecord(recordTypeHandshake, hello.marshal()); err != nil {
+		return err
+	}
+
+	msg, err := c.readHandshake()
+	if err != nil {
+		return err
+	}
+
+	serverHello, ok := msg.(*serverHelloMsg)
+	if !ok {
+		c.sendAlert(alertUnexpectedMessage)
+		return unexpectedMessageError(serverHello, msg)
+	}
+
+	if err := c.pickTLSVersion(hello, serverHello); err != nil {
+		return err
+	}
+
+	if c.vers == VersionTLS13 {
+		hs := &clientHandshakeStateTLS13{
+			c:           c,
+			serverHello: serverHello,
+			hello:       hello,
+			ecdheParams: ecdheParams,
+			session:     session,
+			earlySecret: earlySecret,
+			binderKey:   binderKey,
+		}
+
+		// In TLS 1.3, session tickets are delivered after the handshake.
+		return hs.handshake()
+	}
+
 	hs := &clientHandshakeState{
-		c:       c,
-		hello:   hello,
-		session: session,
+		c:           c,
+		serverHello: serverHello,
+		hello:       hello,
+		session:     session,
 	}
 
 	if err = hs.handshake(); err != nil {
@@ -178,32 +283,56 @@ func (c *Conn) clientHandshake() error {
 	return nil
 }
 
-// Does the handshake, either a full one or resumes old session.
-// Requires hs.c, hs.hello, and, optionally, hs.session to be set.
-func (hs *clientHandshakeState) handshake() error {
-	c := hs.c
-
-	// send ClientHello
-	if _, err := c.writeRecord(recordTypeHandshake, hs.hello.marshal()); err != nil {
-		return err
+// pickTLSVersion sets c.vers according to the version selected by the
+// server, and checks the server random for downgrade protection signals.
+func (c *Conn) pickTLSVersion(hello *clientHelloMsg, serverHello *serverHelloMsg) error {
+	peerVersion := serverHello.vers
+	if serverHello.supportedVersion != 0 {
+		peerVersion = serverHello.supportedVersion
+	}
+
+	vers, ok := c.config.mutualVersion(peerVersion)
+	if serverHello.supportedVersion != 0 {
+		// A server may only select TLS 1.3 or higher with the
+		// supported_versions extension, and only if we offered it.
+		// See RFC 8446, Section 4.2.1.
+		vers, ok = peerVersion, false
+		for _, v := range hello.supportedVersions {
+			if v == peerVersion && v >= VersionTLS13 {
+				ok = true
+				break
+			}
+		}
 	}
-
-	msg, err := c.readHandshake()
-	if err != nil {
-		return err
+	if !ok || vers < VersionTLS10 {
+		// TLS 1.0 is the minimum version supported as a client.
+		c.sendAlert(alertProtocolVersion)
+		return fmt.Errorf("tls: server selected unsupported protocol version %x", peerVersion)
 	}
 
-	var ok bool
-	if hs.serverHello, ok = msg.(*serverHelloMsg); !ok {
-		c.sendAlert(alertUnexpectedMessage)
-		return unexpectedMessageError(hs.serverHello, msg)
+	// If we offered TLS 1.3, a server that negotiated a lower version
+	// signals a downgrade attack in its random. See RFC 8446, Section 4.1.3.
+	if len(hello.supportedVersions) > 0 && hello.supportedVersions[0] == VersionTLS13 && vers <= VersionTLS12 {
+		canary := string(serverHello.random[24:])
+		if canary == downgradeCanaryTLS12 || canary == downgradeCanaryTLS11 {
+			c.sendAlert(alertIllegalParameter)
+			return errors.New("tls: downgrade attempt detected, possibly due to a MitM attack or a broken middlebox")
+		}
 	}
 
-	if err = hs.pickTLSVersion(); err != nil {
-		return err
-	}
+	c.vers = vers
+	c.haveVers = true
 
-	if err = hs.pickCipherSuite(); err != nil {
+	return nil
+}
+
+// Does the handshake, either a full one or resumes old session.
+// Requires hs.c, hs.hello, hs.serverHello, and, optionally, hs.session to
+// be set.
+func (hs *clientHandshakeState) handshake() error {
+	c := hs.c
+
+	if err := hs.pickCipherSuite(); err != nil {
 		return err
 	}
 
@@ -271,20 +400,6 @@ func (hs *clientHandshakeState) handshake() error {
 	return nil
 }
 
-func (hs *clientHandshakeState) pickTLSVersion() error {
-	vers, ok := hs.c.config.mutualVersion(hs.serverHello.vers)
-	if !ok || vers < VersionTLS10 {
-		// TLS 1.0 is the minimum version supported as a client.
-		hs.c.sendAlert(alertProtocolVersion)
-		return fmt.Errorf("tls: server selected unsupported protocol version %x", hs.serverHello.vers)
-	}
-
-	hs.c.vers = vers
-	hs.c.haveVers = true
-
-	return nil
-}
-
 func (hs *clientHandshakeState) pickCipherSuite() error {
 	if hs.suite = mutualCipherSuite(hs.hello.cipherSuites, hs.serverHello.cipherSuite); hs.suite == nil {
 		hs.c.sendAlert(alertHandshakeFailure)
@@ -309,57 +424,7 @@ func (hs *clientHandshakeState) doFullHandshake() error {
 	}
 	hs.finishedHash.Write(certMsg.marshal())
 
-	if c.handshakes == 0 {
-		// If this is the first handshake on a connection, process and
-		// (optionally) verify the server's certificates.
-		certs := make([]*x509.Certificate, len(certMsg.certificates))
-		for i, asn1Data := range certMsg.certificates {
-			cert, err := x509.ParseCertificate(asn1Data)
-			if err != nil {
-				c.sendAlert(alertBadCertificate)
-				return errors.New("tls: failed to parse certificate from server: " + err.Error())
-			}
-			certs[i] = cert
-		}
-
-		if !c.config.InsecureSkipVerify {
-			opts := x509.VerifyOptions{
-				Roots:         c.config.RootCAs,
-				CurrentTime:   c.config.time(),
-				DNSName:       c.config.ServerName,
-				Intermediates: x509.NewCertPool(),
-			}
-
-			for i, cert := range certs {
-				if i == 0 {
-					continue
-				}
-				opts.Intermediates.AddCert(cert)
-			}
-			c.verifiedChains, err = certs[0].Verify(opts)
-			if err != nil {
-				c.sendAlert(alertBadCertificate)
-				return err
-			}
-		}
-
-		if c.config.VerifyPeerCertificate != nil {
-			if err := c.config.VerifyPeerCertificate(certMsg.certificates, c.verifiedChains); err != nil {
-				c.sendAlert(alertBadCertificate)
-				return err
-			}
-		}
-
-		switch certs[0].PublicKey.(type) {
-		case *rsa.PublicKey, *ecdsa.PublicKey:
-			break
-		default:
-			c.sendAlert(alertUnsupportedCertificate)
-			return fmt.Errorf("tls: server's certificate contains an unsupported type of public key: %T", certs[0].PublicKey)
-		}
-
-		c.peerCertificates = certs
-	} else {
+	if c.handshakes != 0 {
 		// This is a renegotiation handshake. We require that the
 		// server's identity (i.e. leaf certificate) is unchanged and
 		// thus any previous trust decision is still valid.
@@ -402,6 +467,15 @@ func (hs *clientHandshakeState) doFullHandshake() error {
 		}
 	}
 
+	if c.handshakes == 0 {
+		// If this is the first handshake on a connection, process and
+		// (optionally) verify the server's certificates, along with
+		// any stapled OCSP response.
+		if err := c.verifyServerCertificate(certMsg.certificates); err != nil {
+			return err
+		}
+	}
+
 	keyAgreement := hs.suite.ka(c.vers)
 
 	skx, ok := msg.(*serverKeyExchangeMsg)
@@ -426,7 +500,7 @@ func (hs *clientHandshakeState) doFullHandshake() error {
 		certRequested = true
 		hs.finishedHash.Write(certReq.marshal())
 
-		if chainToSend, err = hs.getCertificate(certReq); err != nil {
+		if chainToSend, err = c.getClientCertificate(certReq); err != nil {
 			c.sendAlert(alertInternalError)
 			return err
 		}
@@ -492,7 +566,7 @@ func (hs *clientHandshakeState) doFullHandshake() error {
 
 		// SignatureAndHashAlgorithm was introduced in TLS 1.2.
 		if certVerify.hasSignatureAndHash {
-			certVerify.signatureAlgorithm, err = hs.finishedHash.selectClientCertSignatureAlgorithm(certReq.supportedSignatureAlgorithms, signatureType)
+			certVerify.signatureAlgorithm, err = hs.finishedHash.selectClientCertSignatureAlgorithm(c.config, certReq.supportedSignatureAlgorithms, signatureType, key.Public())
 			if err != nil {
 				c.sendAlert(alertInternalError)
 				return err
@@ -503,7 +577,7 @@ func (hs *clientHandshakeState) doFullHandshake() error {
 			c.sendAlert(alertInternalError)
 			return err
 		}
-		certVerify.signature, err = key.Sign(c.config.rand(), digest, hashFunc)
+		certVerify.signature, err = key.Sign(c.config.rand(), digest, signerOptsForSignatureScheme(certVerify.signatureAlgorithm, hashFunc))
 		if err != nil {
 			c.sendAlert(alertInternalError)
 			return err
@@ -526,6 +600,104 @@ func (hs *clientHandshakeState) doFullHandshake() error {
 	return nil
 }
 
+// verifyServerCertificate parses and verifies the provided chain, setting
+// c.verifiedChains and c.peerCertificates or sending the appropriate alert.
+func (c *Conn) verifyServerCertificate(certificates [][]byte) error {
+	certs := make([]*x509.Certificate, len(certificates))
+	for i, asn1Data := range certificates {
+		cert, err := x509.ParseCertificate(asn1Data)
+		if err != nil {
+			c.sendAlert(alertBadCertificate)
+			return errors.New("tls: failed to parse certificate from server: " + err.Error())
+		}
+		certs[i] = cert
+	}
+
+	if !c.config.InsecureSkipVerify {
+		opts := x509.VerifyOptions{
+			Roots:         c.config.RootCAs,
+			CurrentTime:   c.config.time(),
+			DNSName:       c.config.ServerName,
+			Intermediates: x509.NewCertPool(),
+		}
+
+		for i, cert := range certs {
+			if i == 0 {
+				continue
+			}
+			opts.Intermediates.AddCert(cert)
+		}
+		if len(c.ocspResponse) > 0 {
+			// Only the leaf's status is stapled, so this is used
+			// only to reject a revoked certificate.
+			opts.OCSPResponses = [][]byte{c.ocspResponse}
+		}
+		var err error
+		c.verifiedChains, err = certs[0].Verify(opts)
+		if err != nil {
+			if cie, ok := err.(x509.CertificateInvalidError); ok && cie.Reason == x509.Revoked {
+				c.sendAlert(alertCertificateRevoked)
+			} else {
+				c.sendAlert(alertBadCertificate)
+			}
+			return err
+		}
+		if c.config.RequireOCSPStaple {
+			if err := c.checkOCSPStaple(c.ocspResponse, certs, c.verifiedChains); err != nil {
+				c.sendAlert(alertBadCertStatusResponse)
+				return err
+			}
+		}
+	}
+
+	if c.config.VerifyPeerCertificate != nil {
+		if err := c.config.VerifyPeerCertificate(certificates, c.verifiedChains); err != nil {
+			c.sendAlert(alertBadCertificate)
+			return err
+		}
+	}
+
+	switch certs[0].PublicKey.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+		break
+	default:
+		c.sendAlert(alertUnsupportedCertificate)
+		return fmt.Errorf("tls: server's certificate contains an unsupported type of public key: %T", certs[0].PublicKey)
+	}
+
+	c.peerCertificates = certs
+
+	return nil
+}
+
+// checkOCSPStaple returns an error unless staple is a valid, current
+// OCSP response reporting that the server's certificate, certs[0], is
+// good, signed for its issuer in one of verifiedChains.
+func (c *Conn) checkOCSPStaple(staple []byte, certs []*x509.Certificate, verifiedChains [][]*x509.Certificate) error {
+	if len(staple) == 0 {
+		return errors.New("tls: server did not staple an OCSP response")
+	}
+	now := c.config.time()
+	err := errors.New("tls: no verified chain to check the stapled OCSP response against")
+	for _, chain := range verifiedChains {
+		if len(chain) < 2 || len(certs) == 0 {
+			continue
+		}
+		resp, perr := x509.ParseOCSPResponseForCert(staple, certs[0], chain[1])
+		switch {
+		case perr != nil:
+			err = errors.New("tls: invalid stapled OCSP response: " + perr.Error())
+		case now.Before(resp.ThisUpdate) || !resp.NextUpdate.IsZero() && now.After(resp.NextUpdate):
+			err = errors.New("tls: stapled OCSP response is not current")
+		case resp.Status != x509.OCSPGood:
+			err = errors.New("tls: stapled OCSP response has status " + resp.Status.String())
+		default:
+			return nil
+		}
+	}
+	return err
+}
+
 func (hs *clientHandshakeState) establishKeys() error {
 	c := hs.c
 
@@ -625,6 +797,7 @@ func (hs *clientHandshakeState) processServerHello() (bool, error) {
 	hs.masterSecret = hs.session.masterSecret
 	c.peerCertificates = hs.session.serverCertificates
 	c.verifiedChains = hs.session.verifiedChains
+	c.ocspResponse = hs.session.ocspResponse
 	return true, nil
 }
 
@@ -681,6 +854,7 @@ func (hs *clientHandshakeState) readSessionTicket() error {
 		masterSecret:       hs.masterSecret,
 		serverCertificates: c.peerCertificates,
 		verifiedChains:     c.verifiedChains,
+		ocspResponse:       c.ocspResponse,
 	}
 
 	return nil
@@ -728,9 +902,9 @@ const (
 	tls11SignatureSchemesNumRSA = 4
 )
 
-func (hs *clientHandshakeState) getCertificate(certReq *certificateRequestMsg) (*Certificate, error) {
-	c := hs.c
-
+// getClientCertificate selects a client certificate matching certReq, either
+// from c.config.Certificates or by calling c.config.GetClientCertificate.
+func (c *Conn) getClientCertificate(certReq *certificateRequestMsg) (*Certificate, error) {
 	var rsaAvail, ecdsaAvail bool
 	for _, certType := range certReq.certificateTypes {
 		switch certType {
@@ -801,6 +975,9 @@ findCert:
 			switch {
 			case rsaAvail && x509Cert.PublicKeyAlgorithm == x509.RSA:
 			case ecdsaAvail && x509Cert.PublicKeyAlgorithm == x509.ECDSA:
+			case ecdsaAvail && x509Cert.PublicKeyAlgorithm == x509.Ed25519 && c.vers >= VersionTLS13:
+				// The ecdsa_sign type also covers Ed25519 (RFC 8422,
+				// Section 5.5), but we can only sign with it in TLS 1.3.
 			default:
 				continue findCert
 			}
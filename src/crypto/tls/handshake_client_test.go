@@ -7,8 +7,11 @@ package tls
 import (
 	"bytes"
 	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/base64"
 	"encoding/binary"
 	"encoding/pem"
@@ -464,9 +467,20 @@ func runClientTestTLS12(t *testing.T, template *clientTest) {
 }
 
 func TestHandshakeClientRSARC4(t *testing.T) {
+	// These flows were recorded before RSA-PSS was offered in TLS 1.2,
+	// and OpenSSL no longer builds RC4 by default, so the client keeps
+	// offering the signature schemes they were recorded with.
+	config := testConfig.Clone()
+	config.SignatureSchemes = []SignatureScheme{
+		PKCS1WithSHA256, ECDSAWithP256AndSHA256,
+		PKCS1WithSHA384, ECDSAWithP384AndSHA384,
+		PKCS1WithSHA512, ECDSAWithP521AndSHA512,
+		PKCS1WithSHA1, ECDSAWithSHA1,
+	}
 	test := &clientTest{
 		name:    "RSA-RC4",
 		command: []string{"openssl", "s_server", "-cipher", "RC4-SHA"},
+		config:  config,
 	}
 	runClientTestTLS10(t, test)
 	runClientTestTLS11(t, test)
@@ -1578,3 +1592,160 @@ func TestGetClientCertificate(t *testing.T) {
 		}
 	}
 }
+
+// clientHandshakeErr runs a handshake between a client and a server
+// with the given configs and returns the client's error.
+func clientHandshakeErr(t *testing.T, clientConfig, serverConfig *Config) error {
+	c, s := localPipe(t)
+	go func() {
+		Server(s, serverConfig).Handshake()
+		s.Close()
+	}()
+	err := Client(c, clientConfig).Handshake()
+	c.Close()
+	return err
+}
+
+func TestRequireOCSPStaple(t *testing.T) {
+	now := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	issue := func(template, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parent == nil {
+			parent, parentKey = template, key
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cert, key
+	}
+	ca, caKey := issue(&x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "OCSP Test CA"},
+		NotBefore:             now.AddDate(-1, 0, 0),
+		NotAfter:              now.AddDate(1, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA: true,
+	}, nil, nil)
+	leaf, leafKey := issue(&x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "example.golang"},
+		DNSNames:     []string{"example.golang"},
+		NotBefore:    now.AddDate(-1, 0, 0),
+		NotAfter:     now.AddDate(1, 0, 0),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca, caKey)
+	staple := func(status x509.OCSPStatus, thisUpdate time.Time) []byte {
+		der, err := x509.CreateOCSPResponse(rand.Reader, ca, nil, &x509.OCSPResponse{
+			Status:       status,
+			SerialNumber: leaf.SerialNumber,
+			ThisUpdate:   thisUpdate,
+			NextUpdate:   thisUpdate.Add(24 * time.Hour),
+			RevokedAt:    thisUpdate,
+		}, caKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return der
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	tests := []struct {
+		name    string
+		staple  []byte
+		require bool
+		wantErr string
+	}{
+		{"good", staple(x509.OCSPGood, now.Add(-time.Hour)), true, ""},
+		{"none", nil, true, "did not staple"},
+		{"none, not required", nil, false, ""},
+		{"revoked", staple(x509.OCSPRevoked, now.Add(-time.Hour)), true, "revoked"},
+		{"revoked, not required", staple(x509.OCSPRevoked, now.Add(-time.Hour)), false, "revoked"},
+		{"unknown", staple(x509.OCSPUnknown, now.Add(-time.Hour)), true, "status unknown"},
+		{"expired", staple(x509.OCSPGood, now.Add(-48*time.Hour)), true, "not current"},
+		{"garbage", []byte("not an OCSP response"), true, "invalid stapled OCSP response"},
+	}
+	for _, vers := range []uint16{VersionTLS12, VersionTLS13} {
+		for _, tt := range tests {
+			serverConfig := &Config{
+				Certificates: []Certificate{{
+					Certificate: [][]byte{leaf.Raw},
+					PrivateKey:  leafKey,
+					OCSPStaple:  tt.staple,
+				}},
+				MaxVersion: vers,
+				Time:       func() time.Time { return now },
+			}
+			clientConfig := &Config{
+				RootCAs:           roots,
+				ServerName:        "example.golang",
+				RequireOCSPStaple: tt.require,
+				MaxVersion:        vers,
+				Time:              func() time.Time { return now },
+			}
+			err := clientHandshakeErr(t, clientConfig, serverConfig)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("%x %s: unexpected error: %v", vers, tt.name, err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("%x %s: error = %v; want one containing %q", vers, tt.name, err, tt.wantErr)
+			}
+		}
+	}
+
+	// A session is resumed with its saved staple while that is
+	// current, and not resumed once it has expired.
+	for _, vers := range []uint16{VersionTLS12, VersionTLS13} {
+		good := staple(x509.OCSPGood, now.Add(-time.Hour))
+		serverConfig := &Config{
+			Certificates: []Certificate{{
+				Certificate: [][]byte{leaf.Raw},
+				PrivateKey:  leafKey,
+				OCSPStaple:  good,
+			}},
+			MaxVersion: vers,
+			Time:       func() time.Time { return now },
+		}
+		clientNow := now
+		clientConfig := &Config{
+			RootCAs:            roots,
+			ServerName:         "example.golang",
+			RequireOCSPStaple:  true,
+			MaxVersion:         vers,
+			Time:               func() time.Time { return clientNow },
+			ClientSessionCache: NewLRUClientSessionCache(1),
+		}
+		if _, _, err := testHandshakeTLS13(t, clientConfig, serverConfig); err != nil {
+			t.Fatalf("%x: initial handshake: %v", vers, err)
+		}
+		_, cs, err := testHandshakeTLS13(t, clientConfig, serverConfig)
+		if err != nil {
+			t.Fatalf("%x: resumed handshake: %v", vers, err)
+		}
+		if !cs.DidResume {
+			t.Errorf("%x: session with a current staple was not resumed", vers)
+		}
+		if !bytes.Equal(cs.OCSPResponse, good) {
+			t.Errorf("%x: resumed connection has OCSPResponse %x; want the saved staple", vers, cs.OCSPResponse)
+		}
+
+		clientNow = now.Add(24 * time.Hour)
+		serverConfig.Certificates[0].OCSPStaple = staple(x509.OCSPGood, clientNow.Add(-time.Hour))
+		_, cs, err = testHandshakeTLS13(t, clientConfig, serverConfig)
+		if err != nil {
+			t.Fatalf("%x: handshake after the saved staple expired: %v", vers, err)
+		}
+		if cs.DidResume {
+			t.Errorf("%x: session with an expired staple was resumed", vers)
+		}
+	}
+}
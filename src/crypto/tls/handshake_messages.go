@@ -28,6 +28,13 @@ type clientHelloMsg struct {
 	secureRenegotiation          []byte
 	secureRenegotiationSupported bool
 	alpnProtocols                []string
+	supportedVersions            []uint16
+	cookie                       []byte
+	keyShares                    []keyShare
+	earlyData                    bool
+	pskModes                     []uint8
+	pskIdentities                []pskIdentity
+	pskBinders                   [][]byte
 }
 
 func (m *clientHelloMsg) equal(i interface{}) bool {
@@ -53,7 +60,14 @@ func (m *clientHelloMsg) equal(i interface{}) bool {
 		eqSignatureAlgorithms(m.supportedSignatureAlgorithms, m1.supportedSignatureAlgorithms) &&
 		m.secureRenegotiationSupported == m1.secureRenegotiationSupported &&
 		bytes.Equal(m.secureRenegotiation, m1.secureRenegotiation) &&
-		eqStrings(m.alpnProtocols, m1.alpnProtocols)
+		eqStrings(m.alpnProtocols, m1.alpnProtocols) &&
+		eqUint16s(m.supportedVersions, m1.supportedVersions) &&
+		bytes.Equal(m.cookie, m1.cookie) &&
+		eqKeyShares(m.keyShares, m1.keyShares) &&
+		m.earlyData == m1.earlyData &&
+		bytes.Equal(m.pskModes, m1.pskModes) &&
+		eqPSKIdentities(m.pskIdentities, m1.pskIdentities) &&
+		eqByteSlices(m.pskBinders, m1.pskBinders)
 }
 
 func (m *clientHelloMsg) marshal() []byte {
@@ -109,6 +123,35 @@ func (m *clientHelloMsg) marshal() []byte {
 	if m.scts {
 		numExtensions++
 	}
+	if len(m.supportedVersions) > 0 {
+		extensionsLength += 1 + 2*len(m.supportedVersions)
+		numExtensions++
+	}
+	if len(m.cookie) > 0 {
+		extensionsLength += 2 + len(m.cookie)
+		numExtensions++
+	}
+	if len(m.keyShares) > 0 {
+		extensionsLength += 2
+		for _, ks := range m.keyShares {
+			extensionsLength += 4 + len(ks.data)
+		}
+		numExtensions++
+	}
+	if m.earlyData {
+		numExtensions++
+	}
+	if len(m.pskModes) > 0 {
+		extensionsLength += 1 + len(m.pskModes)
+		numExtensions++
+	}
+	if len(m.pskIdentities) > 0 {
+		extensionsLength += 2 + m.pskBindersLen()
+		for _, psk := range m.pskIdentities {
+			extensionsLength += 2 + len(psk.label) + 4
+		}
+		numExtensions++
+	}
 	if numExtensions > 0 {
 		extensionsLength += 4 * numExtensions
 		length += 2 + extensionsLength
@@ -291,12 +334,159 @@ func (m *clientHelloMsg) marshal() []byte {
 		// zero uint16 for the zero-length extension_data
 		z = z[4:]
 	}
+	if len(m.supportedVersions) > 0 {
+		// RFC 8446, Section 4.2.1
+		z[0] = byte(extensionSupportedVersions >> 8)
+		z[1] = byte(extensionSupportedVersions)
+		l := 1 + 2*len(m.supportedVersions)
+		z[2] = byte(l >> 8)
+		z[3] = byte(l)
+		z[4] = byte(l - 1)
+		z = z[5:]
+		for _, vers := range m.supportedVersions {
+			z[0] = byte(vers >> 8)
+			z[1] = byte(vers)
+			z = z[2:]
+		}
+	}
+	if len(m.cookie) > 0 {
+		// RFC 8446, Section 4.2.2
+		z[0] = byte(extensionCookie >> 8)
+		z[1] = byte(extensionCookie)
+		l := 2 + len(m.cookie)
+		z[2] = byte(l >> 8)
+		z[3] = byte(l)
+		z[4] = byte(len(m.cookie) >> 8)
+		z[5] = byte(len(m.cookie))
+		copy(z[6:], m.cookie)
+		z = z[6+len(m.cookie):]
+	}
+	if len(m.keyShares) > 0 {
+		// RFC 8446, Section 4.2.8
+		z[0] = byte(extensionKeyShare >> 8)
+		z[1] = byte(extensionKeyShare)
+		lengths := z[2:]
+		z = z[6:]
+
+		sharesLength := 0
+		for _, ks := range m.keyShares {
+			z[0] = byte(ks.group >> 8)
+			z[1] = byte(ks.group)
+			z[2] = byte(len(ks.data) >> 8)
+			z[3] = byte(len(ks.data))
+			copy(z[4:], ks.data)
+			z = z[4+len(ks.data):]
+			sharesLength += 4 + len(ks.data)
+		}
+
+		lengths[2] = byte(sharesLength >> 8)
+		lengths[3] = byte(sharesLength)
+		sharesLength += 2
+		lengths[0] = byte(sharesLength >> 8)
+		lengths[1] = byte(sharesLength)
+	}
+	if m.earlyData {
+		// RFC 8446, Section 4.2.10
+		z[0] = byte(extensionEarlyData >> 8)
+		z[1] = byte(extensionEarlyData)
+		z = z[4:]
+	}
+	if len(m.pskModes) > 0 {
+		// RFC 8446, Section 4.2.9
+		z[0] = byte(extensionPSKModes >> 8)
+		z[1] = byte(extensionPSKModes)
+		l := 1 + len(m.pskModes)
+		z[2] = byte(l >> 8)
+		z[3] = byte(l)
+		z[4] = byte(len(m.pskModes))
+		copy(z[5:], m.pskModes)
+		z = z[5+len(m.pskModes):]
+	}
+	if len(m.pskIdentities) > 0 {
+		// RFC 8446, Section 4.2.11. This extension must be the last
+		// one in the ClientHello.
+		z[0] = byte(extensionPreSharedKey >> 8)
+		z[1] = byte(extensionPreSharedKey)
+		lengths := z[2:]
+		z = z[6:]
+
+		identitiesLength := 0
+		for _, psk := range m.pskIdentities {
+			z[0] = byte(len(psk.label) >> 8)
+			z[1] = byte(len(psk.label))
+			copy(z[2:], psk.label)
+			z = z[2+len(psk.label):]
+			z[0] = byte(psk.obfuscatedTicketAge >> 24)
+			z[1] = byte(psk.obfuscatedTicketAge >> 16)
+			z[2] = byte(psk.obfuscatedTicketAge >> 8)
+			z[3] = byte(psk.obfuscatedTicketAge)
+			z = z[4:]
+			identitiesLength += 2 + len(psk.label) + 4
+		}
+		lengths[2] = byte(identitiesLength >> 8)
+		lengths[3] = byte(identitiesLength)
+		l := 2 + identitiesLength + m.pskBindersLen()
+		lengths[0] = byte(l >> 8)
+		lengths[1] = byte(l)
+
+		m.writeBinders(z)
+	}
 
 	m.raw = x
 
 	return x
 }
 
+// pskBindersLen returns the length of the encoded PskBinderEntry list,
+// including its length prefix.
+func (m *clientHelloMsg) pskBindersLen() int {
+	l := 2
+	for _, binder := range m.pskBinders {
+		l += 1 + len(binder)
+	}
+	return l
+}
+
+// writeBinders encodes m.pskBinders into z, which must be exactly
+// m.pskBindersLen() bytes long.
+func (m *clientHelloMsg) writeBinders(z []byte) {
+	l := m.pskBindersLen() - 2
+	z[0] = byte(l >> 8)
+	z[1] = byte(l)
+	z = z[2:]
+	for _, binder := range m.pskBinders {
+		z[0] = byte(len(binder))
+		copy(z[1:], binder)
+		z = z[1+len(binder):]
+	}
+}
+
+// marshalWithoutBinders returns the ClientHello through the
+// PreSharedKeyExtension.identities field, according to RFC 8446, Section
+// 4.2.11.2. Note that m.pskBinders must be set to slices of the correct length.
+func (m *clientHelloMsg) marshalWithoutBinders() []byte {
+	full := m.marshal()
+	return full[:len(full)-m.pskBindersLen()]
+}
+
+// updateBinders updates the m.pskBinders field, if necessary updating the
+// cached marshaled representation. The supplied binders must have the same
+// length as the current m.pskBinders.
+func (m *clientHelloMsg) updateBinders(pskBinders [][]byte) {
+	if len(pskBinders) != len(m.pskBinders) {
+		panic("tls: internal error: pskBinders length mismatch")
+	}
+	for i := range m.pskBinders {
+		if len(pskBinders[i]) != len(m.pskBinders[i]) {
+			panic("tls: internal error: pskBinders length mismatch")
+		}
+	}
+	m.pskBinders = pskBinders
+	if m.raw != nil {
+		m.writeBinders(m.raw[len(m.raw)-m.pskBindersLen():])
+	}
+}
+
 func (m *clientHelloMsg) unmarshal(data []byte) bool {
 	if len(data) < 42 {
 		return false
@@ -347,6 +537,13 @@ func (m *clientHelloMsg) unmarshal(data []byte) bool {
 	m.supportedSignatureAlgorithms = nil
 	m.alpnProtocols = nil
 	m.scts = false
+	m.supportedVersions = nil
+	m.cookie = nil
+	m.keyShares = nil
+	m.earlyData = false
+	m.pskModes = nil
+	m.pskIdentities = nil
+	m.pskBinders = nil
 
 	if len(data) == 0 {
 		// ClientHello is optionally followed by extension data
@@ -496,6 +693,118 @@ func (m *clientHelloMsg) unmarshal(data []byte) bool {
 			if length != 0 {
 				return false
 			}
+		case extensionSupportedVersions:
+			// RFC 8446, Section 4.2.1
+			if length < 1 {
+				return false
+			}
+			l := int(data[0])
+			if l%2 == 1 || l == 0 || length != l+1 {
+				return false
+			}
+			d := data[1:length]
+			for len(d) > 0 {
+				m.supportedVersions = append(m.supportedVersions, uint16(d[0])<<8|uint16(d[1]))
+				d = d[2:]
+			}
+		case extensionCookie:
+			// RFC 8446, Section 4.2.2
+			if length < 2 {
+				return false
+			}
+			l := int(data[0])<<8 | int(data[1])
+			if l == 0 || length != l+2 {
+				return false
+			}
+			m.cookie = data[2:length]
+		case extensionKeyShare:
+			// RFC 8446, Section 4.2.8
+			if length < 2 {
+				return false
+			}
+			l := int(data[0])<<8 | int(data[1])
+			if length != l+2 {
+				return false
+			}
+			d := data[2:length]
+			for len(d) > 0 {
+				if len(d) < 4 {
+					return false
+				}
+				group := CurveID(d[0])<<8 | CurveID(d[1])
+				dataLen := int(d[2])<<8 | int(d[3])
+				d = d[4:]
+				if dataLen == 0 || len(d) < dataLen {
+					return false
+				}
+				m.keyShares = append(m.keyShares, keyShare{group: group, data: d[:dataLen]})
+				d = d[dataLen:]
+			}
+		case extensionEarlyData:
+			// RFC 8446, Section 4.2.10
+			if length != 0 {
+				return false
+			}
+			m.earlyData = true
+		case extensionPSKModes:
+			// RFC 8446, Section 4.2.9
+			if length < 1 {
+				return false
+			}
+			l := int(data[0])
+			if l == 0 || length != l+1 {
+				return false
+			}
+			m.pskModes = data[1:length]
+		case extensionPreSharedKey:
+			// RFC 8446, Section 4.2.11
+			if len(data) != length {
+				return false // pre_shared_key must be the last extension
+			}
+			if length < 2 {
+				return false
+			}
+			l := int(data[0])<<8 | int(data[1])
+			d := data[2:length]
+			if l == 0 || len(d) < l {
+				return false
+			}
+			identities := d[:l]
+			d = d[l:]
+			for len(identities) > 0 {
+				if len(identities) < 2 {
+					return false
+				}
+				labelLen := int(identities[0])<<8 | int(identities[1])
+				identities = identities[2:]
+				if labelLen == 0 || len(identities) < labelLen+4 {
+					return false
+				}
+				var psk pskIdentity
+				psk.label = identities[:labelLen]
+				identities = identities[labelLen:]
+				psk.obfuscatedTicketAge = uint32(identities[0])<<24 | uint32(identities[1])<<16 |
+					uint32(identities[2])<<8 | uint32(identities[3])
+				identities = identities[4:]
+				m.pskIdentities = append(m.pskIdentities, psk)
+			}
+			if len(d) < 2 {
+				return false
+			}
+			l = int(d[0])<<8 | int(d[1])
+			d = d[2:]
+			if l == 0 || len(d) != l {
+				return false
+			}
+			for len(d) > 0 {
+				binderLen := int(d[0])
+				d = d[1:]
+				if binderLen == 0 || len(d) < binderLen {
+					return false
+				}
+				m.pskBinders = append(m.pskBinders, d[:binderLen])
+				d = d[binderLen:]
+			}
 		}
 		data = data[length:]
 	}
@@ -518,6 +827,16 @@ type serverHelloMsg struct {
 	secureRenegotiation          []byte
 	secureRenegotiationSupported bool
 	alpnProtocol                 string
+
+	// TLS 1.3 extensions.
+	supportedVersion        uint16
+	serverShare             keyShare
+	selectedIdentityPresent bool
+	selectedIdentity        uint16
+
+	// HelloRetryRequest extensions.
+	cookie        []byte
+	selectedGroup CurveID
 }
 
 func (m *serverHelloMsg) equal(i interface{}) bool {
@@ -547,7 +866,14 @@ func (m *serverHelloMsg) equal(i interface{}) bool {
 		m.ticketSupported == m1.ticketSupported &&
 		m.secureRenegotiationSupported == m1.secureRenegotiationSupported &&
 		bytes.Equal(m.secureRenegotiation, m1.secureRenegotiation) &&
-		m.alpnProtocol == m1.alpnProtocol
+		m.alpnProtocol == m1.alpnProtocol &&
+		m.supportedVersion == m1.supportedVersion &&
+		m.serverShare.group == m1.serverShare.group &&
+		bytes.Equal(m.serverShare.data, m1.serverShare.data) &&
+		m.selectedIdentityPresent == m1.selectedIdentityPresent &&
+		m.selectedIdentity == m1.selectedIdentity &&
+		bytes.Equal(m.cookie, m1.cookie) &&
+		m.selectedGroup == m1.selectedGroup
 }
 
 func (m *serverHelloMsg) marshal() []byte {
@@ -593,6 +919,25 @@ func (m *serverHelloMsg) marshal() []byte {
 		extensionsLength += 2 + sctLen
 		numExtensions++
 	}
+	if m.supportedVersion != 0 {
+		extensionsLength += 2
+		numExtensions++
+	}
+	if m.serverShare.group != 0 {
+		extensionsLength += 4 + len(m.serverShare.data)
+		numExtensions++
+	} else if m.selectedGroup != 0 {
+		extensionsLength += 2
+		numExtensions++
+	}
+	if m.selectedIdentityPresent {
+		extensionsLength += 2
+		numExtensions++
+	}
+	if len(m.cookie) > 0 {
+		extensionsLength += 2 + len(m.cookie)
+		numExtensions++
+	}
 
 	if numExtensions > 0 {
 		extensionsLength += 4 * numExtensions
@@ -688,6 +1033,53 @@ func (m *serverHelloMsg) marshal() []byte {
 			z = z[len(sct)+2:]
 		}
 	}
+	if m.supportedVersion != 0 {
+		z[0] = byte(extensionSupportedVersions >> 8)
+		z[1] = byte(extensionSupportedVersions)
+		z[3] = 2
+		z[4] = byte(m.supportedVersion >> 8)
+		z[5] = byte(m.supportedVersion)
+		z = z[6:]
+	}
+	if m.serverShare.group != 0 {
+		z[0] = byte(extensionKeyShare >> 8)
+		z[1] = byte(extensionKeyShare)
+		l := 4 + len(m.serverShare.data)
+		z[2] = byte(l >> 8)
+		z[3] = byte(l)
+		z[4] = byte(m.serverShare.group >> 8)
+		z[5] = byte(m.serverShare.group)
+		z[6] = byte(len(m.serverShare.data) >> 8)
+		z[7] = byte(len(m.serverShare.data))
+		copy(z[8:], m.serverShare.data)
+		z = z[8+len(m.serverShare.data):]
+	} else if m.selectedGroup != 0 {
+		z[0] = byte(extensionKeyShare >> 8)
+		z[1] = byte(extensionKeyShare)
+		z[3] = 2
+		z[4] = byte(m.selectedGroup >> 8)
+		z[5] = byte(m.selectedGroup)
+		z = z[6:]
+	}
+	if m.selectedIdentityPresent {
+		z[0] = byte(extensionPreSharedKey >> 8)
+		z[1] = byte(extensionPreSharedKey)
+		z[3] = 2
+		z[4] = byte(m.selectedIdentity >> 8)
+		z[5] = byte(m.selectedIdentity)
+		z = z[6:]
+	}
+	if len(m.cookie) > 0 {
+		z[0] = byte(extensionCookie >> 8)
+		z[1] = byte(extensionCookie)
+		l := 2 + len(m.cookie)
+		z[2] = byte(l >> 8)
+		z[3] = byte(l)
+		z[4] = byte(len(m.cookie) >> 8)
+		z[5] = byte(len(m.cookie))
+		copy(z[6:], m.cookie)
+		z = z[6+len(m.cookie):]
+	}
 
 	m.raw = x
 
@@ -720,6 +1112,12 @@ func (m *serverHelloMsg) unmarshal(data []byte) bool {
 	m.scts = nil
 	m.ticketSupported = false
 	m.alpnProtocol = ""
+	m.supportedVersion = 0
+	m.serverShare = keyShare{}
+	m.selectedIdentityPresent = false
+	m.selectedIdentity = 0
+	m.cookie = nil
+	m.selectedGroup = 0
 
 	if len(data) == 0 {
 		// ServerHello is optionally followed by extension data
@@ -827,6 +1225,42 @@ func (m *serverHelloMsg) unmarshal(data []byte) bool {
 				m.scts = append(m.scts, d[:sctLen])
 				d = d[sctLen:]
 			}
+		case extensionSupportedVersions:
+			if length != 2 {
+				return false
+			}
+			m.supportedVersion = uint16(data[0])<<8 | uint16(data[1])
+		case extensionKeyShare:
+			// HelloRetryRequest messages only carry the selected
+			// group. See RFC 8446, Section 4.2.8.
+			if length == 2 {
+				m.selectedGroup = CurveID(data[0])<<8 | CurveID(data[1])
+				break
+			}
+			if length < 4 {
+				return false
+			}
+			m.serverShare.group = CurveID(data[0])<<8 | CurveID(data[1])
+			l := int(data[2])<<8 | int(data[3])
+			if l == 0 || length != l+4 {
+				return false
+			}
+			m.serverShare.data = data[4:length]
+		case extensionPreSharedKey:
+			if length != 2 {
+				return false
+			}
+			m.selectedIdentityPresent = true
+			m.selectedIdentity = uint16(data[0])<<8 | uint16(data[1])
+		case extensionCookie:
+			if length < 2 {
+				return false
+			}
+			l := int(data[0])<<8 | int(data[1])
+			if l == 0 || length != l+2 {
+				return false
+			}
+			m.cookie = data[2:length]
 		}
 		data = data[length:]
 	}
@@ -1491,59 +1925,732 @@ func (m *newSessionTicketMsg) unmarshal(data []byte) bool {
 	return true
 }
 
-type helloRequestMsg struct {
+type newSessionTicketMsgTLS13 struct {
+	raw          []byte
+	lifetime     uint32
+	ageAdd       uint32
+	nonce        []byte
+	label        []byte
+	maxEarlyData uint32
 }
 
-func (*helloRequestMsg) marshal() []byte {
-	return []byte{typeHelloRequest, 0, 0, 0}
+func (m *newSessionTicketMsgTLS13) equal(i interface{}) bool {
+	m1, ok := i.(*newSessionTicketMsgTLS13)
+	if !ok {
+		return false
+	}
+
+	return bytes.Equal(m.raw, m1.raw) &&
+		m.lifetime == m1.lifetime &&
+		m.ageAdd == m1.ageAdd &&
+		bytes.Equal(m.nonce, m1.nonce) &&
+		bytes.Equal(m.label, m1.label) &&
+		m.maxEarlyData == m1.maxEarlyData
 }
 
-func (*helloRequestMsg) unmarshal(data []byte) bool {
-	return len(data) == 4
+func (m *newSessionTicketMsgTLS13) marshal() (x []byte) {
+	if m.raw != nil {
+		return m.raw
+	}
+
+	// See RFC 8446, Section 4.6.1.
+	extensionsLength := 0
+	if m.maxEarlyData > 0 {
+		extensionsLength = 4 + 4
+	}
+	length := 4 + 4 + 1 + len(m.nonce) + 2 + len(m.label) + 2 + extensionsLength
+	x = make([]byte, 4+length)
+	x[0] = typeNewSessionTicket
+	x[1] = uint8(length >> 16)
+	x[2] = uint8(length >> 8)
+	x[3] = uint8(length)
+	y := x[4:]
+	y[0] = uint8(m.lifetime >> 24)
+	y[1] = uint8(m.lifetime >> 16)
+	y[2] = uint8(m.lifetime >> 8)
+	y[3] = uint8(m.lifetime)
+	y[4] = uint8(m.ageAdd >> 24)
+	y[5] = uint8(m.ageAdd >> 16)
+	y[6] = uint8(m.ageAdd >> 8)
+	y[7] = uint8(m.ageAdd)
+	y[8] = uint8(len(m.nonce))
+	copy(y[9:], m.nonce)
+	y = y[9+len(m.nonce):]
+	y[0] = uint8(len(m.label) >> 8)
+	y[1] = uint8(len(m.label))
+	copy(y[2:], m.label)
+	y = y[2+len(m.label):]
+	y[0] = uint8(extensionsLength >> 8)
+	y[1] = uint8(extensionsLength)
+	if m.maxEarlyData > 0 {
+		y[2] = uint8(extensionEarlyData >> 8)
+		y[3] = uint8(extensionEarlyData)
+		y[5] = 4
+		y[6] = uint8(m.maxEarlyData >> 24)
+		y[7] = uint8(m.maxEarlyData >> 16)
+		y[8] = uint8(m.maxEarlyData >> 8)
+		y[9] = uint8(m.maxEarlyData)
+	}
+
+	m.raw = x
+
+	return
 }
 
-func eqUint16s(x, y []uint16) bool {
-	if len(x) != len(y) {
+func (m *newSessionTicketMsgTLS13) unmarshal(data []byte) bool {
+	*m = newSessionTicketMsgTLS13{raw: data}
+
+	if len(data) < 4+4+4+1 {
 		return false
 	}
-	for i, v := range x {
-		if y[i] != v {
-			return false
-		}
+	length := uint32(data[1])<<16 | uint32(data[2])<<8 | uint32(data[3])
+	if uint32(len(data))-4 != length {
+		return false
 	}
-	return true
-}
 
-func eqCurveIDs(x, y []CurveID) bool {
-	if len(x) != len(y) {
+	data = data[4:]
+	m.lifetime = uint32(data[0])<<24 | uint32(data[1])<<16 | uint32(data[2])<<8 | uint32(data[3])
+	m.ageAdd = uint32(data[4])<<24 | uint32(data[5])<<16 | uint32(data[6])<<8 | uint32(data[7])
+	nonceLen := int(data[8])
+	data = data[9:]
+	if len(data) < nonceLen+2 {
 		return false
 	}
-	for i, v := range x {
-		if y[i] != v {
-			return false
-		}
+	m.nonce = data[:nonceLen]
+	data = data[nonceLen:]
+
+	labelLen := int(data[0])<<8 | int(data[1])
+	data = data[2:]
+	if labelLen == 0 || len(data) < labelLen+2 {
+		return false
 	}
-	return true
-}
+	m.label = data[:labelLen]
+	data = data[labelLen:]
 
-func eqStrings(x, y []string) bool {
-	if len(x) != len(y) {
+	extensionsLength := int(data[0])<<8 | int(data[1])
+	data = data[2:]
+	if len(data) != extensionsLength {
 		return false
 	}
-	for i, v := range x {
-		if y[i] != v {
+	for len(data) != 0 {
+		if len(data) < 4 {
+			return false
+		}
+		extension := uint16(data[0])<<8 | uint16(data[1])
+		length := int(data[2])<<8 | int(data[3])
+		data = data[4:]
+		if len(data) < length {
 			return false
 		}
+
+		switch extension {
+		case extensionEarlyData:
+			if length != 4 {
+				return false
+			}
+			m.maxEarlyData = uint32(data[0])<<24 | uint32(data[1])<<16 | uint32(data[2])<<8 | uint32(data[3])
+		}
+		data = data[length:]
 	}
+
 	return true
 }
 
-func eqByteSlices(x, y [][]byte) bool {
-	if len(x) != len(y) {
+type encryptedExtensionsMsg struct {
+	raw          []byte
+	alpnProtocol string
+}
+
+func (m *encryptedExtensionsMsg) equal(i interface{}) bool {
+	m1, ok := i.(*encryptedExtensionsMsg)
+	if !ok {
 		return false
 	}
-	for i, v := range x {
-		if !bytes.Equal(v, y[i]) {
+
+	return bytes.Equal(m.raw, m1.raw) &&
+		m.alpnProtocol == m1.alpnProtocol
+}
+
+func (m *encryptedExtensionsMsg) marshal() []byte {
+	if m.raw != nil {
+		return m.raw
+	}
+
+	// See RFC 8446, Section 4.3.1.
+	extensionsLength := 0
+	alpnLen := len(m.alpnProtocol)
+	if alpnLen > 0 {
+		if alpnLen >= 256 {
+			panic("invalid ALPN protocol")
+		}
+		extensionsLength += 4 + 2 + 1 + alpnLen
+	}
+
+	length := 2 + extensionsLength
+	x := make([]byte, 4+length)
+	x[0] = typeEncryptedExtensions
+	x[1] = uint8(length >> 16)
+	x[2] = uint8(length >> 8)
+	x[3] = uint8(length)
+	x[4] = uint8(extensionsLength >> 8)
+	x[5] = uint8(extensionsLength)
+	z := x[6:]
+	if alpnLen > 0 {
+		z[0] = byte(extensionALPN >> 8)
+		z[1] = byte(extensionALPN)
+		l := 2 + 1 + alpnLen
+		z[2] = byte(l >> 8)
+		z[3] = byte(l)
+		l -= 2
+		z[4] = byte(l >> 8)
+		z[5] = byte(l)
+		z[6] = byte(alpnLen)
+		copy(z[7:], m.alpnProtocol)
+	}
+
+	m.raw = x
+	return x
+}
+
+func (m *encryptedExtensionsMsg) unmarshal(data []byte) bool {
+	*m = encryptedExtensionsMsg{raw: data}
+
+	if len(data) < 6 {
+		return false
+	}
+	length := int(data[1])<<16 | int(data[2])<<8 | int(data[3])
+	if len(data)-4 != length {
+		return false
+	}
+	extensionsLength := int(data[4])<<8 | int(data[5])
+	data = data[6:]
+	if len(data) != extensionsLength {
+		return false
+	}
+
+	for len(data) != 0 {
+		if len(data) < 4 {
+			return false
+		}
+		extension := uint16(data[0])<<8 | uint16(data[1])
+		length := int(data[2])<<8 | int(data[3])
+		data = data[4:]
+		if len(data) < length {
+			return false
+		}
+
+		switch extension {
+		case extensionALPN:
+			d := data[:length]
+			if len(d) < 3 {
+				return false
+			}
+			l := int(d[0])<<8 | int(d[1])
+			if l != len(d)-2 {
+				return false
+			}
+			d = d[2:]
+			l = int(d[0])
+			if l != len(d)-1 {
+				return false
+			}
+			d = d[1:]
+			if len(d) == 0 {
+				// ALPN protocols must not be empty.
+				return false
+			}
+			m.alpnProtocol = string(d)
+		}
+		data = data[length:]
+	}
+
+	return true
+}
+
+type certificateMsgTLS13 struct {
+	raw          []byte
+	certificates [][]byte
+	ocspStaple   []byte
+	scts         [][]byte
+}
+
+func (m *certificateMsgTLS13) equal(i interface{}) bool {
+	m1, ok := i.(*certificateMsgTLS13)
+	if !ok {
+		return false
+	}
+
+	return bytes.Equal(m.raw, m1.raw) &&
+		eqByteSlices(m.certificates, m1.certificates) &&
+		bytes.Equal(m.ocspStaple, m1.ocspStaple) &&
+		eqByteSlices(m.scts, m1.scts)
+}
+
+func (m *certificateMsgTLS13) marshal() (x []byte) {
+	if m.raw != nil {
+		return m.raw
+	}
+
+	// See RFC 8446, Section 4.4.2. The OCSP staple and the SCTs are
+	// carried in the extensions of the leaf certificate entry.
+	leafExtensionsLength := 0
+	if len(m.ocspStaple) > 0 {
+		leafExtensionsLength += 4 + 1 + 3 + len(m.ocspStaple)
+	}
+	sctLen := 0
+	if len(m.scts) > 0 {
+		for _, sct := range m.scts {
+			sctLen += 2 + len(sct)
+		}
+		leafExtensionsLength += 4 + 2 + sctLen
+	}
+
+	certificatesLength := 0
+	for i, cert := range m.certificates {
+		certificatesLength += 3 + len(cert) + 2
+		if i == 0 {
+			certificatesLength += leafExtensionsLength
+		}
+	}
+
+	length := 1 + 3 + certificatesLength
+	x = make([]byte, 4+length)
+	x[0] = typeCertificate
+	x[1] = uint8(length >> 16)
+	x[2] = uint8(length >> 8)
+	x[3] = uint8(length)
+	// x[4] is the empty certificate_request_context.
+	x[5] = uint8(certificatesLength >> 16)
+	x[6] = uint8(certificatesLength >> 8)
+	x[7] = uint8(certificatesLength)
+
+	y := x[8:]
+	for i, cert := range m.certificates {
+		y[0] = uint8(len(cert) >> 16)
+		y[1] = uint8(len(cert) >> 8)
+		y[2] = uint8(len(cert))
+		copy(y[3:], cert)
+		y = y[3+len(cert):]
+		if i != 0 {
+			// No extensions.
+			y = y[2:]
+			continue
+		}
+
+		y[0] = uint8(leafExtensionsLength >> 8)
+		y[1] = uint8(leafExtensionsLength)
+		y = y[2:]
+		if len(m.ocspStaple) > 0 {
+			y[0] = uint8(extensionStatusRequest >> 8)
+			y[1] = uint8(extensionStatusRequest)
+			l := 1 + 3 + len(m.ocspStaple)
+			y[2] = uint8(l >> 8)
+			y[3] = uint8(l)
+			y[4] = statusTypeOCSP
+			y[5] = uint8(len(m.ocspStaple) >> 16)
+			y[6] = uint8(len(m.ocspStaple) >> 8)
+			y[7] = uint8(len(m.ocspStaple))
+			copy(y[8:], m.ocspStaple)
+			y = y[8+len(m.ocspStaple):]
+		}
+		if len(m.scts) > 0 {
+			y[0] = uint8(extensionSCT >> 8)
+			y[1] = uint8(extensionSCT)
+			l := 2 + sctLen
+			y[2] = uint8(l >> 8)
+			y[3] = uint8(l)
+			y[4] = uint8(sctLen >> 8)
+			y[5] = uint8(sctLen)
+			y = y[6:]
+			for _, sct := range m.scts {
+				y[0] = uint8(len(sct) >> 8)
+				y[1] = uint8(len(sct))
+				copy(y[2:], sct)
+				y = y[2+len(sct):]
+			}
+		}
+	}
+
+	m.raw = x
+	return
+}
+
+func (m *certificateMsgTLS13) unmarshal(data []byte) bool {
+	*m = certificateMsgTLS13{raw: data}
+
+	if len(data) < 8 {
+		return false
+	}
+	length := int(data[1])<<16 | int(data[2])<<8 | int(data[3])
+	if len(data)-4 != length {
+		return false
+	}
+	contextLen := int(data[4])
+	data = data[5:]
+	if len(data) < contextLen+3 {
+		return false
+	}
+	data = data[contextLen:]
+	certificatesLength := int(data[0])<<16 | int(data[1])<<8 | int(data[2])
+	data = data[3:]
+	if len(data) != certificatesLength {
+		return false
+	}
+
+	for len(data) > 0 {
+		if len(data) < 3 {
+			return false
+		}
+		certLen := int(data[0])<<16 | int(data[1])<<8 | int(data[2])
+		data = data[3:]
+		if certLen == 0 || len(data) < certLen+2 {
+			return false
+		}
+		m.certificates = append(m.certificates, data[:certLen])
+		data = data[certLen:]
+
+		extensionsLength := int(data[0])<<8 | int(data[1])
+		data = data[2:]
+		if len(data) < extensionsLength {
+			return false
+		}
+		extensions := data[:extensionsLength]
+		data = data[extensionsLength:]
+		for len(extensions) > 0 {
+			if len(extensions) < 4 {
+				return false
+			}
+			extension := uint16(extensions[0])<<8 | uint16(extensions[1])
+			l := int(extensions[2])<<8 | int(extensions[3])
+			extensions = extensions[4:]
+			if len(extensions) < l {
+				return false
+			}
+			d := extensions[:l]
+			extensions = extensions[l:]
+
+			// Extensions are only meaningful for the leaf.
+			if len(m.certificates) != 1 {
+				continue
+			}
+			switch extension {
+			case extensionStatusRequest:
+				if len(d) < 4 || d[0] != statusTypeOCSP {
+					return false
+				}
+				respLen := int(d[1])<<16 | int(d[2])<<8 | int(d[3])
+				if respLen == 0 || len(d) != 4+respLen {
+					return false
+				}
+				m.ocspStaple = d[4:]
+			case extensionSCT:
+				if len(d) < 2 {
+					return false
+				}
+				sctsLen := int(d[0])<<8 | int(d[1])
+				d = d[2:]
+				if sctsLen == 0 || len(d) != sctsLen {
+					return false
+				}
+				for len(d) > 0 {
+					if len(d) < 2 {
+						return false
+					}
+					sctLen := int(d[0])<<8 | int(d[1])
+					d = d[2:]
+					if sctLen == 0 || len(d) < sctLen {
+						return false
+					}
+					m.scts = append(m.scts, d[:sctLen])
+					d = d[sctLen:]
+				}
+			}
+		}
+	}
+
+	return true
+}
+
+type certificateRequestMsgTLS13 struct {
+	raw                          []byte
+	supportedSignatureAlgorithms []SignatureScheme
+	certificateAuthorities       [][]byte
+}
+
+func (m *certificateRequestMsgTLS13) equal(i interface{}) bool {
+	m1, ok := i.(*certificateRequestMsgTLS13)
+	if !ok {
+		return false
+	}
+
+	return bytes.Equal(m.raw, m1.raw) &&
+		eqSignatureAlgorithms(m.supportedSignatureAlgorithms, m1.supportedSignatureAlgorithms) &&
+		eqByteSlices(m.certificateAuthorities, m1.certificateAuthorities)
+}
+
+func (m *certificateRequestMsgTLS13) marshal() (x []byte) {
+	if m.raw != nil {
+		return m.raw
+	}
+
+	// See RFC 8446, Section 4.3.2. The signature_algorithms extension
+	// is mandatory.
+	extensionsLength := 4 + 2 + 2*len(m.supportedSignatureAlgorithms)
+	casLength := 0
+	if len(m.certificateAuthorities) > 0 {
+		for _, ca := range m.certificateAuthorities {
+			casLength += 2 + len(ca)
+		}
+		extensionsLength += 4 + 2 + casLength
+	}
+
+	length := 1 + 2 + extensionsLength
+	x = make([]byte, 4+length)
+	x[0] = typeCertificateRequest
+	x[1] = uint8(length >> 16)
+	x[2] = uint8(length >> 8)
+	x[3] = uint8(length)
+	// x[4] is the empty certificate_request_context.
+	x[5] = uint8(extensionsLength >> 8)
+	x[6] = uint8(extensionsLength)
+
+	y := x[7:]
+	y[0] = uint8(extensionSignatureAlgorithms >> 8)
+	y[1] = uint8(extensionSignatureAlgorithms)
+	l := 2 + 2*len(m.supportedSignatureAlgorithms)
+	y[2] = uint8(l >> 8)
+	y[3] = uint8(l)
+	l -= 2
+	y[4] = uint8(l >> 8)
+	y[5] = uint8(l)
+	y = y[6:]
+	for _, sigAlgo := range m.supportedSignatureAlgorithms {
+		y[0] = uint8(sigAlgo >> 8)
+		y[1] = uint8(sigAlgo)
+		y = y[2:]
+	}
+
+	if len(m.certificateAuthorities) > 0 {
+		y[0] = uint8(extensionCertificateAuthorities >> 8)
+		y[1] = uint8(extensionCertificateAuthorities)
+		l := 2 + casLength
+		y[2] = uint8(l >> 8)
+		y[3] = uint8(l)
+		y[4] = uint8(casLength >> 8)
+		y[5] = uint8(casLength)
+		y = y[6:]
+		for _, ca := range m.certificateAuthorities {
+			y[0] = uint8(len(ca) >> 8)
+			y[1] = uint8(len(ca))
+			copy(y[2:], ca)
+			y = y[2+len(ca):]
+		}
+	}
+
+	m.raw = x
+	return
+}
+
+func (m *certificateRequestMsgTLS13) unmarshal(data []byte) bool {
+	*m = certificateRequestMsgTLS13{raw: data}
+
+	if len(data) < 7 {
+		return false
+	}
+	length := int(data[1])<<16 | int(data[2])<<8 | int(data[3])
+	if len(data)-4 != length {
+		return false
+	}
+	contextLen := int(data[4])
+	data = data[5:]
+	if len(data) < contextLen+2 {
+		return false
+	}
+	data = data[contextLen:]
+	extensionsLength := int(data[0])<<8 | int(data[1])
+	data = data[2:]
+	if len(data) != extensionsLength {
+		return false
+	}
+
+	for len(data) != 0 {
+		if len(data) < 4 {
+			return false
+		}
+		extension := uint16(data[0])<<8 | uint16(data[1])
+		length := int(data[2])<<8 | int(data[3])
+		data = data[4:]
+		if len(data) < length {
+			return false
+		}
+		d := data[:length]
+		data = data[length:]
+
+		switch extension {
+		case extensionSignatureAlgorithms:
+			if len(d) < 2 {
+				return false
+			}
+			l := int(d[0])<<8 | int(d[1])
+			d = d[2:]
+			if l == 0 || l%2 == 1 || len(d) != l {
+				return false
+			}
+			for len(d) > 0 {
+				m.supportedSignatureAlgorithms = append(m.supportedSignatureAlgorithms, SignatureScheme(d[0])<<8|SignatureScheme(d[1]))
+				d = d[2:]
+			}
+		case extensionCertificateAuthorities:
+			if len(d) < 2 {
+				return false
+			}
+			l := int(d[0])<<8 | int(d[1])
+			d = d[2:]
+			if l == 0 || len(d) != l {
+				return false
+			}
+			for len(d) > 0 {
+				if len(d) < 2 {
+					return false
+				}
+				caLen := int(d[0])<<8 | int(d[1])
+				d = d[2:]
+				if caLen == 0 || len(d) < caLen {
+					return false
+				}
+				m.certificateAuthorities = append(m.certificateAuthorities, d[:caLen])
+				d = d[caLen:]
+			}
+		}
+	}
+
+	return true
+}
+
+type keyUpdateMsg struct {
+	raw             []byte
+	updateRequested bool
+}
+
+func (m *keyUpdateMsg) equal(i interface{}) bool {
+	m1, ok := i.(*keyUpdateMsg)
+	if !ok {
+		return false
+	}
+
+	return bytes.Equal(m.raw, m1.raw) &&
+		m.updateRequested == m1.updateRequested
+}
+
+func (m *keyUpdateMsg) marshal() []byte {
+	if m.raw != nil {
+		return m.raw
+	}
+
+	// See RFC 8446, Section 4.6.3.
+	x := []byte{typeKeyUpdate, 0, 0, 1, 0}
+	if m.updateRequested {
+		x[4] = 1
+	}
+
+	m.raw = x
+	return x
+}
+
+func (m *keyUpdateMsg) unmarshal(data []byte) bool {
+	m.raw = data
+
+	if len(data) != 5 {
+		return false
+	}
+	switch data[4] {
+	case 0:
+		m.updateRequested = false
+	case 1:
+		m.updateRequested = true
+	default:
+		return false
+	}
+	return true
+}
+
+type helloRequestMsg struct {
+}
+
+func (*helloRequestMsg) marshal() []byte {
+	return []byte{typeHelloRequest, 0, 0, 0}
+}
+
+func (*helloRequestMsg) unmarshal(data []byte) bool {
+	return len(data) == 4
+}
+
+func eqUint16s(x, y []uint16) bool {
+	if len(x) != len(y) {
+		return false
+	}
+	for i, v := range x {
+		if y[i] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func eqCurveIDs(x, y []CurveID) bool {
+	if len(x) != len(y) {
+		return false
+	}
+	for i, v := range x {
+		if y[i] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func eqStrings(x, y []string) bool {
+	if len(x) != len(y) {
+		return false
+	}
+	for i, v := range x {
+		if y[i] != v {
+			return false
+		}
+	}
+	return true
+}
+
+func eqByteSlices(x, y [][]byte) bool {
+	if len(x) != len(y) {
+		return false
+	}
+	for i, v := range x {
+		if !bytes.Equal(v, y[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func eqKeyShares(x, y []keyShare) bool {
+	if len(x) != len(y) {
+		return false
+	}
+	for i := range x {
+		if x[i].group != y[i].group || !bytes.Equal(x[i].data, y[i].data) {
+			return false
+		}
+	}
+	return true
+}
+
+func eqPSKIdentities(x, y []pskIdentity) bool {
+	if len(x) != len(y) {
+		return false
+	}
+	for i := range x {
+		if !bytes.Equal(x[i].label, y[i].label) || x[i].obfuscatedTicketAge != y[i].obfuscatedTicketAge {
 			return false
 		}
 	}
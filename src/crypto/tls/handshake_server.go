@@ -7,6 +7,7 @@ package tls
 import (
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rsa"
 	"crypto/subtle"
 	"crypto/x509"
@@ -50,6 +51,15 @@ func (c *Conn) serverHandshake() error {
 		return err
 	}
 
+	if c.vers == VersionTLS13 {
+		hs := serverHandshakeStateTLS13{
+			c:               c,
+			clientHello:     hs.clientHello,
+			clientHelloInfo: hs.clientHelloInfo(),
+		}
+		return hs.handshake()
+	}
+
 	// For an overview of TLS handshaking, see https://tools.ietf.org/html/rfc5246#section-7.3
 	c.buffering = true
 	if isResume {
@@ -109,7 +119,9 @@ func (c *Conn) serverHandshake() error {
 }
 
 // readClientHello reads a ClientHello message from the client and decides
-// whether we will perform session resumption.
+// whether we will perform session resumption. If TLS 1.3 is negotiated, it
+// returns as soon as c.vers is set, and the rest of the ClientHello is
+// processed by serverHandshakeStateTLS13.
 func (hs *serverHandshakeState) readClientHello() (isResume bool, err error) {
 	c := hs.c
 
@@ -134,13 +146,25 @@ func (hs *serverHandshakeState) readClientHello() (isResume bool, err error) {
 		}
 	}
 
-	c.vers, ok = c.config.mutualVersion(hs.clientHello.vers)
-	if !ok {
-		c.sendAlert(alertProtocolVersion)
-		return false, fmt.Errorf("tls: client offered an unsupported, maximum protocol version of %x", hs.clientHello.vers)
+	if len(hs.clientHello.supportedVersions) > 0 {
+		c.vers, ok = c.config.mutualVersionFromList(hs.clientHello.supportedVersions)
+		if !ok {
+			c.sendAlert(alertProtocolVersion)
+			return false, fmt.Errorf("tls: client offered only unsupported versions: %x", hs.clientHello.supportedVersions)
+		}
+	} else {
+		c.vers, ok = c.config.mutualVersion(hs.clientHello.vers)
+		if !ok {
+			c.sendAlert(alertProtocolVersion)
+			return false, fmt.Errorf("tls: client offered an unsupported, maximum protocol version of %x", hs.clientHello.vers)
+		}
 	}
 	c.haveVers = true
 
+	if c.vers == VersionTLS13 {
+		return false, nil
+	}
+
 	hs.hello = new(serverHelloMsg)
 
 	supportedCurve := false
@@ -186,6 +210,16 @@ Curves:
 		return false, err
 	}
 
+	// If we could have negotiated TLS 1.3, signal the downgrade in the
+	// last eight bytes of the random. See RFC 8446, Section 4.1.3.
+	if c.config.maxVersion() >= VersionTLS13 {
+		if c.vers == VersionTLS12 {
+			copy(hs.hello.random[24:], downgradeCanaryTLS12)
+		} else {
+			copy(hs.hello.random[24:], downgradeCanaryTLS11)
+		}
+	}
+
 	if len(hs.clientHello.secureRenegotiation) != 0 {
 		c.sendAlert(alertHandshakeFailure)
 		return false, errors.New("tls: initial handshake had non-empty renegotiation extension")
@@ -226,6 +260,11 @@ Curves:
 		switch priv.Public().(type) {
 		case *ecdsa.PublicKey:
 			hs.ecdsaOk = true
+		case ed25519.PublicKey:
+			// Ed25519 keys can sign for the ECDHE_ECDSA cipher suites in
+			// TLS 1.2, if the client supports them. See RFC 8422.
+			hs.ecdsaOk = c.vers >= VersionTLS12 &&
+				isSupportedSignatureAlgorithm(Ed25519, c.config.signatureSchemes(hs.clientHello.supportedSignatureAlgorithms))
 		case *rsa.PublicKey:
 			hs.rsaSignOk = true
 		default:
@@ -290,9 +329,12 @@ func (hs *serverHandshakeState) checkForResumption() bool {
 		return false
 	}
 
-	var ok bool
-	var sessionTicket = append([]uint8{}, hs.clientHello.sessionTicket...)
-	if hs.sessionState, ok = c.decryptTicket(sessionTicket); !ok {
+	plaintext, usedOldKey := c.decryptTicket(hs.clientHello.sessionTicket)
+	if plaintext == nil {
+		return false
+	}
+	hs.sessionState = &sessionState{usedOldKey: usedOldKey}
+	if ok := hs.sessionState.unmarshal(plaintext); !ok {
 		return false
 	}
 
@@ -347,7 +389,8 @@ func (hs *serverHandshakeState) doResumeHandshake() error {
 	}
 
 	if len(hs.sessionState.certificates) > 0 {
-		if _, err := hs.processCertsFromClient(hs.sessionState.certificates); err != nil {
+		hs.certsFromClient = hs.sessionState.certificates
+		if _, err := c.processCertsFromClient(hs.sessionState.certificates); err != nil {
 			return err
 		}
 	}
@@ -418,7 +461,7 @@ func (hs *serverHandshakeState) doFullHandshake() error {
 		}
 		if c.vers >= VersionTLS12 {
 			certReq.hasSignatureAndHash = true
-			certReq.supportedSignatureAlgorithms = supportedSignatureAlgorithms
+			certReq.supportedSignatureAlgorithms = c.config.signatureSchemes(supportedSignatureAlgorithms)
 		}
 
 		// An empty list of certificateAuthorities signals to
@@ -471,7 +514,8 @@ func (hs *serverHandshakeState) doFullHandshake() error {
 			}
 		}
 
-		pub, err = hs.processCertsFromClient(certMsg.certificates)
+		hs.certsFromClient = certMsg.certificates
+		pub, err = c.processCertsFromClient(certMsg.certificates)
 		if err != nil {
 			return err
 		}
@@ -523,7 +567,7 @@ func (hs *serverHandshakeState) doFullHandshake() error {
 		var sigType uint8
 		if certVerify.hasSignatureAndHash {
 			signatureAlgorithm = certVerify.signatureAlgorithm
-			if !isSupportedSignatureAlgorithm(signatureAlgorithm, supportedSignatureAlgorithms) {
+			if !isSupportedSignatureAlgorithm(signatureAlgorithm, c.config.signatureSchemes(supportedSignatureAlgorithms)) {
 				return errors.New("tls: unsupported hash function for client certificate")
 			}
 			sigType = signatureFromSignatureScheme(signatureAlgorithm)
@@ -562,7 +606,7 @@ func (hs *serverHandshakeState) doFullHandshake() error {
 				err = errors.New("tls: ECDSA verification failure")
 			}
 		case *rsa.PublicKey:
-			if sigType != signatureRSA {
+			if sigType != signatureRSA && sigType != signatureRSAPSS {
 				err = errors.New("tls: bad signature type for client's RSA certificate")
 				break
 			}
@@ -571,7 +615,15 @@ func (hs *serverHandshakeState) doFullHandshake() error {
 			if digest, hashFunc, err = hs.finishedHash.hashForClientCertificate(sigType, signatureAlgorithm, hs.masterSecret); err != nil {
 				break
 			}
-			err = rsa.VerifyPKCS1v15(key, hashFunc, digest, certVerify.signature)
+			if sigType == signatureRSAPSS {
+				opts := &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}
+				err = rsa.VerifyPSS(key, hashFunc, digest, certVerify.signature, opts)
+			} else {
+				err = rsa.VerifyPKCS1v15(key, hashFunc, digest, certVerify.signature)
+			}
+		default:
+			// Ed25519 client certificates are only supported in TLS 1.3.
+			err = fmt.Errorf("tls: unsupported client certificate key type %T", key)
 		}
 		if err != nil {
 			c.sendAlert(alertBadCertificate)
@@ -670,7 +722,7 @@ func (hs *serverHandshakeState) sendSessionTicket() error {
 		masterSecret: hs.masterSecret,
 		certificates: hs.certsFromClient,
 	}
-	m.ticket, err = c.encryptTicket(&state)
+	m.ticket, err = c.encryptTicket(state.marshal())
 	if err != nil {
 		return err
 	}
@@ -706,10 +758,7 @@ func (hs *serverHandshakeState) sendFinished(out []byte) error {
 // processCertsFromClient takes a chain of client certificates either from a
 // Certificates message or from a sessionState and verifies them. It returns
 // the public key of the leaf certificate.
-func (hs *serverHandshakeState) processCertsFromClient(certificates [][]byte) (crypto.PublicKey, error) {
-	c := hs.c
-
-	hs.certsFromClient = certificates
+func (c *Conn) processCertsFromClient(certificates [][]byte) (crypto.PublicKey, error) {
 	certs := make([]*x509.Certificate, len(certificates))
 	var err error
 	for i, asn1Data := range certificates {
@@ -753,7 +802,7 @@ func (hs *serverHandshakeState) processCertsFromClient(certificates [][]byte) (c
 
 	var pub crypto.PublicKey
 	switch key := certs[0].PublicKey.(type) {
-	case *ecdsa.PublicKey, *rsa.PublicKey:
+	case *ecdsa.PublicKey, *rsa.PublicKey, ed25519.PublicKey:
 		pub = key
 	default:
 		c.sendAlert(alertUnsupportedCertificate)
@@ -815,7 +864,9 @@ func (hs *serverHandshakeState) clientHelloInfo() *ClientHelloInfo {
 	}
 
 	var supportedVersions []uint16
-	if hs.clientHello.vers > VersionTLS12 {
+	if len(hs.clientHello.supportedVersions) > 0 {
+		supportedVersions = hs.clientHello.supportedVersions
+	} else if hs.clientHello.vers > VersionTLS12 {
 		supportedVersions = suppVersArray[:]
 	} else if hs.clientHello.vers >= VersionSSL30 {
 		supportedVersions = suppVersArray[VersionTLS12-hs.clientHello.vers:]
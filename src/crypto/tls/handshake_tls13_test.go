@@ -0,0 +1,458 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tls
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// localPipe returns a pair of connected TCP connections over the loopback
+// interface. Unlike net.Pipe, writes are buffered, which the TLS 1.3
+// handshake relies on as the server sends session tickets in its first
+// flight.
+func localPipe(t *testing.T) (net.Conn, net.Conn) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	type result struct {
+		c   net.Conn
+		err error
+	}
+	accepted := make(chan result, 1)
+	go func() {
+		c, err := ln.Accept()
+		accepted <- result{c, err}
+	}()
+
+	c1, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := <-accepted
+	if r.err != nil {
+		c1.Close()
+		t.Fatal(r.err)
+	}
+	return c1, r.c
+}
+
+// testHandshakeTLS13 runs a handshake between a client and a server over a
+// localPipe. The server writes a short message after the handshake, which
+// the client reads, processing any session tickets sent before it.
+func testHandshakeTLS13(t *testing.T, clientConfig, serverConfig *Config) (serverState, clientState ConnectionState, err error) {
+	c, s := localPipe(t)
+	done := make(chan error, 1)
+	go func() {
+		cli := Client(c, clientConfig)
+		defer cli.Close()
+		if err := cli.Handshake(); err != nil {
+			done <- err
+			return
+		}
+		if _, err := ioutil.ReadAll(cli); err != nil {
+			done <- err
+			return
+		}
+		clientState = cli.ConnectionState()
+		done <- nil
+	}()
+	server := Server(s, serverConfig)
+	err = server.Handshake()
+	if err == nil {
+		serverState = server.ConnectionState()
+		_, err = server.Write([]byte("hello"))
+	}
+	server.Close()
+	if cliErr := <-done; err == nil && cliErr != nil {
+		err = errors.New("client: " + cliErr.Error())
+	}
+	return
+}
+
+func testConfigTLS13() *Config {
+	config := testConfig.Clone()
+	config.MaxVersion = VersionTLS13
+	config.CipherSuites = nil
+	return config
+}
+
+func TestHandshakeTLS13(t *testing.T) {
+	for _, preferServer := range []bool{false, true} {
+		clientConfig := testConfigTLS13()
+		serverConfig := testConfigTLS13()
+		serverConfig.PreferServerCipherSuites = preferServer
+
+		serverState, clientState, err := testHandshakeTLS13(t, clientConfig, serverConfig)
+		if err != nil {
+			t.Fatalf("handshake failed: %s", err)
+		}
+		for _, state := range []ConnectionState{serverState, clientState} {
+			if state.Version != VersionTLS13 {
+				t.Errorf("got version %#04x, expected %#04x", state.Version, VersionTLS13)
+			}
+			if cipherSuiteTLS13ByID(state.CipherSuite) == nil {
+				t.Errorf("got cipher suite %#04x, expected a TLS 1.3 one", state.CipherSuite)
+			}
+			if state.DidResume {
+				t.Errorf("unexpected resumption")
+			}
+		}
+		if serverState.CipherSuite != clientState.CipherSuite {
+			t.Errorf("server and client disagree on the cipher suite: %#04x and %#04x", serverState.CipherSuite, clientState.CipherSuite)
+		}
+		if len(clientState.PeerCertificates) == 0 {
+			t.Errorf("client did not receive the server certificate")
+		}
+	}
+}
+
+func TestClientHelloTLS13(t *testing.T) {
+	c := Client(nil, testConfigTLS13())
+	hello, params, err := c.makeClientHello()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The legacy version is frozen at TLS 1.2. See RFC 8446, Section 4.1.2.
+	if hello.vers != VersionTLS12 {
+		t.Errorf("got legacy version %#04x, expected %#04x", hello.vers, VersionTLS12)
+	}
+	if len(hello.supportedVersions) == 0 || hello.supportedVersions[0] != VersionTLS13 {
+		t.Errorf("got supported versions %x, expected TLS 1.3 first", hello.supportedVersions)
+	}
+	if params == nil || len(hello.keyShares) != 1 || hello.keyShares[0].group != params.CurveID() {
+		t.Errorf("ClientHello does not contain a key share")
+	}
+
+	c = Client(nil, testConfig)
+	hello, params, err = c.makeClientHello()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hello.supportedVersions) != 0 || len(hello.keyShares) != 0 || params != nil {
+		t.Errorf("TLS 1.2 ClientHello contains TLS 1.3 extensions")
+	}
+}
+
+func TestHandshakeTLS13Fallback(t *testing.T) {
+	// A TLS 1.3 client against a TLS 1.2 server negotiates TLS 1.2.
+	clientConfig := testConfigTLS13()
+	serverConfig := testConfig.Clone()
+	serverState, _, err := testHandshake(clientConfig, serverConfig)
+	if err != nil {
+		t.Fatalf("handshake failed: %s", err)
+	}
+	if serverState.Version != VersionTLS12 {
+		t.Errorf("got version %#04x, expected %#04x", serverState.Version, VersionTLS12)
+	}
+
+	// A TLS 1.2 client against a TLS 1.3 server negotiates TLS 1.2.
+	clientConfig = testConfig.Clone()
+	serverConfig = testConfigTLS13()
+	serverState, _, err = testHandshake(clientConfig, serverConfig)
+	if err != nil {
+		t.Fatalf("handshake failed: %s", err)
+	}
+	if serverState.Version != VersionTLS12 {
+		t.Errorf("got version %#04x, expected %#04x", serverState.Version, VersionTLS12)
+	}
+}
+
+func TestDowngradeCanary(t *testing.T) {
+	// A TLS 1.3 server marks a TLS 1.2 ServerHello, which a TLS 1.3 client
+	// that was forced down to TLS 1.2 must reject.
+	clientConfig := testConfigTLS13()
+	serverConfig := testConfigTLS13()
+
+	c, s := net.Pipe()
+	go func() {
+		cli := Client(c, clientConfig)
+		hello, _, err := cli.makeClientHello()
+		if err != nil {
+			c.Close()
+			return
+		}
+		// Strip the supported_versions extension, as a MitM would.
+		hello.supportedVersions = nil
+		cli.vers = VersionTLS12
+		cli.writeRecord(recordTypeHandshake, hello.marshal())
+		msg, err := cli.readHandshake()
+		if err != nil {
+			c.Close()
+			return
+		}
+		serverHello, ok := msg.(*serverHelloMsg)
+		if !ok {
+			c.Close()
+			return
+		}
+		if string(serverHello.random[24:]) != downgradeCanaryTLS12 {
+			t.Errorf("server random does not contain the TLS 1.2 downgrade canary")
+		}
+		hello.supportedVersions = []uint16{VersionTLS13, VersionTLS12}
+		if err := cli.pickTLSVersion(hello, serverHello); err == nil || !strings.Contains(err.Error(), "downgrade") {
+			t.Errorf("got error %v, expected a downgrade error", err)
+		}
+		c.Close()
+	}()
+	Server(s, serverConfig).Handshake()
+	s.Close()
+}
+
+func TestHelloRetryRequest(t *testing.T) {
+	clientConfig := testConfigTLS13()
+	clientConfig.CurvePreferences = []CurveID{X25519, CurveP256}
+	serverConfig := testConfigTLS13()
+	serverConfig.CurvePreferences = []CurveID{CurveP256}
+
+	serverState, clientState, err := testHandshakeTLS13(t, clientConfig, serverConfig)
+	if err != nil {
+		t.Fatalf("handshake failed: %s", err)
+	}
+	if serverState.Version != VersionTLS13 || clientState.Version != VersionTLS13 {
+		t.Errorf("got versions %#04x and %#04x, expected TLS 1.3", serverState.Version, clientState.Version)
+	}
+}
+
+func TestResumptionTLS13(t *testing.T) {
+	clientConfig := testConfigTLS13()
+	clientConfig.ClientSessionCache = NewLRUClientSessionCache(32)
+	// Without a ServerName the cache is keyed by the remote address, which
+	// changes across localPipe calls.
+	clientConfig.ServerName = "example.golang"
+	serverConfig := testConfigTLS13()
+
+	testResumeState := func(test string, didResume bool) {
+		serverState, clientState, err := testHandshakeTLS13(t, clientConfig, serverConfig)
+		if err != nil {
+			t.Fatalf("%s: handshake failed: %s", test, err)
+		}
+		if serverState.DidResume != didResume || clientState.DidResume != didResume {
+			t.Fatalf("%s: resumed %v (server) and %v (client), expected %v", test, serverState.DidResume, clientState.DidResume, didResume)
+		}
+		if clientState.Version != VersionTLS13 {
+			t.Fatalf("%s: got version %#04x, expected %#04x", test, clientState.Version, VersionTLS13)
+		}
+		if didResume && len(clientState.PeerCertificates) == 0 {
+			t.Fatalf("%s: expected the server certificates to be carried over", test)
+		}
+	}
+
+	testResumeState("Handshake", false)
+	testResumeState("Resume", true)
+
+	serverConfig.SetSessionTicketKeys([][32]byte{{1}})
+	testResumeState("InvalidSessionTicketKey", false)
+	testResumeState("ResumeAfterInvalidSessionTicketKey", true)
+
+	// A HelloRetryRequest recomputes the PSK binders.
+	clientConfig.CurvePreferences = []CurveID{X25519, CurveP256}
+	serverConfig.CurvePreferences = []CurveID{CurveP256}
+	testResumeState("HelloRetryRequest", true)
+
+	serverConfig.SessionTicketsDisabled = true
+	testResumeState("SessionTicketsDisabled", false)
+}
+
+func TestClientAuthTLS13(t *testing.T) {
+	clientConfig := testConfigTLS13()
+	clientConfig.Certificates = []Certificate{{
+		Certificate: [][]byte{testECDSACertificate},
+		PrivateKey:  testECDSAPrivateKey,
+	}}
+	serverConfig := testConfigTLS13()
+	serverConfig.ClientAuth = RequireAnyClientCert
+
+	serverState, _, err := testHandshakeTLS13(t, clientConfig, serverConfig)
+	if err != nil {
+		t.Fatalf("handshake failed: %s", err)
+	}
+	if len(serverState.PeerCertificates) != 1 || !bytes.Equal(serverState.PeerCertificates[0].Raw, testECDSACertificate) {
+		t.Errorf("server did not receive the client certificate")
+	}
+
+	clientConfig.Certificates = nil
+	if _, _, err := testHandshakeTLS13(t, clientConfig, serverConfig); err == nil {
+		t.Errorf("handshake without a client certificate succeeded")
+	}
+
+	serverConfig.ClientAuth = RequestClientCert
+	serverState, _, err = testHandshakeTLS13(t, clientConfig, serverConfig)
+	if err != nil {
+		t.Fatalf("handshake failed: %s", err)
+	}
+	if len(serverState.PeerCertificates) != 0 {
+		t.Errorf("server received unexpected client certificates")
+	}
+}
+
+// ed25519Certificate returns a freshly generated, self-signed Ed25519
+// certificate.
+func ed25519Certificate(t *testing.T) Certificate {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "Ed25519 test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, pub, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Certificate{Certificate: [][]byte{der}, PrivateKey: priv}
+}
+
+func TestEd25519TLS13(t *testing.T) {
+	cert := ed25519Certificate(t)
+
+	clientConfig := testConfigTLS13()
+	clientConfig.Certificates = []Certificate{cert}
+	serverConfig := testConfigTLS13()
+	serverConfig.Certificates = []Certificate{cert}
+	serverConfig.ClientAuth = RequireAnyClientCert
+
+	serverState, clientState, err := testHandshakeTLS13(t, clientConfig, serverConfig)
+	if err != nil {
+		t.Fatalf("handshake failed: %s", err)
+	}
+	if clientState.Version != VersionTLS13 {
+		t.Errorf("got version %#04x, expected %#04x", clientState.Version, VersionTLS13)
+	}
+	if len(clientState.PeerCertificates) != 1 || !bytes.Equal(clientState.PeerCertificates[0].Raw, cert.Certificate[0]) {
+		t.Errorf("client did not receive the Ed25519 server certificate")
+	}
+	if len(serverState.PeerCertificates) != 1 || !bytes.Equal(serverState.PeerCertificates[0].Raw, cert.Certificate[0]) {
+		t.Errorf("server did not receive the Ed25519 client certificate")
+	}
+}
+
+func TestEd25519TLS12(t *testing.T) {
+	cert := ed25519Certificate(t)
+
+	// A client that enables TLS 1.3 advertises Ed25519, which a TLS 1.2
+	// server can then use with an ECDHE_ECDSA cipher suite.
+	clientConfig := testConfigTLS13()
+	serverConfig := testConfigTLS13()
+	serverConfig.MaxVersion = VersionTLS12
+	serverConfig.Certificates = []Certificate{cert}
+
+	_, clientState, err := testHandshakeTLS13(t, clientConfig, serverConfig)
+	if err != nil {
+		t.Fatalf("handshake failed: %s", err)
+	}
+	if clientState.Version != VersionTLS12 {
+		t.Errorf("got version %#04x, expected %#04x", clientState.Version, VersionTLS12)
+	}
+	if suite := mutualCipherSuite([]uint16{clientState.CipherSuite}, clientState.CipherSuite); suite == nil || suite.flags&suiteECDSA == 0 {
+		t.Errorf("got cipher suite %#04x, expected an ECDHE_ECDSA one", clientState.CipherSuite)
+	}
+
+	// A TLS 1.2 only client doesn't advertise Ed25519.
+	clientConfig.MaxVersion = VersionTLS12
+	if _, _, err := testHandshakeTLS13(t, clientConfig, serverConfig); err == nil {
+		t.Errorf("handshake with an Ed25519 certificate succeeded without client support")
+	}
+}
+
+func TestVerifyPeerCertificateTLS13(t *testing.T) {
+	clientConfig := testConfigTLS13()
+	called := false
+	clientConfig.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		called = true
+		if len(rawCerts) == 0 || !bytes.Equal(rawCerts[0], testRSACertificate) {
+			return errors.New("unexpected server certificate")
+		}
+		return nil
+	}
+	if _, _, err := testHandshakeTLS13(t, clientConfig, testConfigTLS13()); err != nil {
+		t.Fatalf("handshake failed: %s", err)
+	}
+	if !called {
+		t.Errorf("VerifyPeerCertificate was not called")
+	}
+}
+
+func TestALPNTLS13(t *testing.T) {
+	clientConfig := testConfigTLS13()
+	clientConfig.NextProtos = []string{"proto2", "proto1"}
+	serverConfig := testConfigTLS13()
+	serverConfig.NextProtos = []string{"proto1", "proto2"}
+
+	serverState, clientState, err := testHandshakeTLS13(t, clientConfig, serverConfig)
+	if err != nil {
+		t.Fatalf("handshake failed: %s", err)
+	}
+	if serverState.NegotiatedProtocol != "proto1" || clientState.NegotiatedProtocol != "proto1" {
+		t.Errorf("got protocols %q and %q, expected proto1", serverState.NegotiatedProtocol, clientState.NegotiatedProtocol)
+	}
+	if !clientState.NegotiatedProtocolIsMutual {
+		t.Errorf("NegotiatedProtocolIsMutual is false")
+	}
+}
+
+func TestKeyUpdate(t *testing.T) {
+	c, s := localPipe(t)
+	done := make(chan error, 1)
+	go func() {
+		server := Server(s, testConfigTLS13())
+		defer server.Close()
+		buf := make([]byte, 5)
+		for i := 0; i < 3; i++ {
+			if _, err := io.ReadFull(server, buf); err != nil {
+				done <- err
+				return
+			}
+			if _, err := server.Write(buf); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+
+	client := Client(c, testConfigTLS13())
+	defer client.Close()
+	buf := make([]byte, 5)
+	for i := 0; i < 3; i++ {
+		if i > 0 {
+			// Update our keys, and ask the server to update its own.
+			if err := client.sendKeyUpdate(i == 2); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := client.Write([]byte("hello")); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.ReadFull(client, buf); err != nil {
+			t.Fatal(err)
+		}
+		if string(buf) != "hello" {
+			t.Fatalf("got %q, expected %q", buf, "hello")
+		}
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
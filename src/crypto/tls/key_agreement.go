@@ -7,6 +7,7 @@ package tls
 import (
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/md5"
 	"crypto/rsa"
@@ -75,7 +76,11 @@ func (ka rsaKeyAgreement) generateClientKeyExchange(config *Config, clientHello
 		return nil, nil, err
 	}
 
-	encrypted, err := rsa.EncryptPKCS1v15(config.rand(), cert.PublicKey.(*rsa.PublicKey), preMasterSecret)
+	rsaKey, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, nil, errors.New("tls: server certificate contains incorrect key type for selected ciphersuite")
+	}
+	encrypted, err := rsa.EncryptPKCS1v15(config.rand(), rsaKey, preMasterSecret)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -112,11 +117,17 @@ func md5SHA1Hash(slices [][]byte) []byte {
 // hashForServerKeyExchange hashes the given slices and returns their digest
 // and the identifier of the hash function used. The signatureAlgorithm argument
 // is only used for >= TLS 1.2 and identifies the hash function to use.
+// Ed25519 signatures are not pre-hashed, so for them the concatenation of the
+// slices is returned along with a zero hash.
 func hashForServerKeyExchange(sigType uint8, signatureAlgorithm SignatureScheme, version uint16, slices ...[]byte) ([]byte, crypto.Hash, error) {
-	if version >= VersionTLS12 {
-		if !isSupportedSignatureAlgorithm(signatureAlgorithm, supportedSignatureAlgorithms) {
-			return nil, crypto.Hash(0), errors.New("tls: unsupported hash function used by peer")
+	if sigType == signatureEd25519 {
+		var signed []byte
+		for _, slice := range slices {
+			signed = append(signed, slice...)
 		}
+		return signed, crypto.Hash(0), nil
+	}
+	if version >= VersionTLS12 {
 		hashFunc, err := lookupTLSHash(signatureAlgorithm)
 		if err != nil {
 			return nil, crypto.Hash(0), err
@@ -135,28 +146,45 @@ func hashForServerKeyExchange(sigType uint8, signatureAlgorithm SignatureScheme,
 }
 
 // pickTLS12HashForSignature returns a TLS 1.2 hash identifier for signing a
-// ServerKeyExchange given the signature type being used and the client's
-// advertised list of supported signature and hash combinations.
-func pickTLS12HashForSignature(sigType uint8, clientList []SignatureScheme) (SignatureScheme, error) {
+// ServerKeyExchange given the signature type being used, the server's public
+// key and the client's advertised list of supported signature and hash
+// combinations. Only the signature schemes allowed by config are considered.
+func pickTLS12HashForSignature(config *Config, sigType uint8, pub crypto.PublicKey, clientList []SignatureScheme) (SignatureScheme, error) {
+	if sigType == signatureEd25519 {
+		// Ed25519 has no hash to negotiate, and must be explicitly
+		// advertised. See RFC 8422, Section 5.1.1.
+		if !isSupportedSignatureAlgorithm(Ed25519, config.signatureSchemes(clientList)) {
+			return 0, errors.New("tls: client doesn't support Ed25519 signatures")
+		}
+		return Ed25519, nil
+	}
+
+	serverList := config.signatureSchemes(supportedSignatureAlgorithms)
+
 	if len(clientList) == 0 {
 		// If the client didn't specify any signature_algorithms
 		// extension then we can assume that it supports SHA1. See
 		// http://tools.ietf.org/html/rfc5246#section-7.4.1.4.1
+		var sigAlg SignatureScheme
 		switch sigType {
 		case signatureRSA:
-			return PKCS1WithSHA1, nil
+			sigAlg = PKCS1WithSHA1
 		case signatureECDSA:
-			return ECDSAWithSHA1, nil
+			sigAlg = ECDSAWithSHA1
 		default:
 			return 0, errors.New("tls: unknown signature algorithm")
 		}
+		if !isSupportedSignatureAlgorithm(sigAlg, serverList) {
+			return 0, errors.New("tls: client doesn't support any common hash functions")
+		}
+		return sigAlg, nil
 	}
 
 	for _, sigAlg := range clientList {
-		if signatureFromSignatureScheme(sigAlg) != sigType {
+		if !isTLS12SignatureSchemeForKey(sigAlg, sigType, pub) {
 			continue
 		}
-		if isSupportedSignatureAlgorithm(sigAlg, supportedSignatureAlgorithms) {
+		if isSupportedSignatureAlgorithm(sigAlg, serverList) {
 			return sigAlg, nil
 		}
 	}
@@ -247,33 +275,47 @@ NextCandidate:
 	serverECDHParams[3] = byte(len(ecdhePublic))
 	copy(serverECDHParams[4:], ecdhePublic)
 
+	priv, ok := cert.PrivateKey.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("tls: certificate private key does not implement crypto.Signer")
+	}
+
+	// The ECDHE_ECDSA cipher suites are also used with Ed25519 certificates,
+	// which are only defined for TLS 1.2. See RFC 8422, Section 2.
+	sigType := ka.sigType
+	if _, ok := priv.Public().(ed25519.PublicKey); ok && sigType == signatureECDSA {
+		if ka.version < VersionTLS12 {
+			return nil, errors.New("tls: Ed25519 server keys require TLS 1.2")
+		}
+		sigType = signatureEd25519
+	}
+
 	var signatureAlgorithm SignatureScheme
 
 	if ka.version >= VersionTLS12 {
 		var err error
-		signatureAlgorithm, err = pickTLS12HashForSignature(ka.sigType, clientHello.supportedSignatureAlgorithms)
+		signatureAlgorithm, err = pickTLS12HashForSignature(config, sigType, priv.Public(), clientHello.supportedSignatureAlgorithms)
 		if err != nil {
 			return nil, err
 		}
+		sigType = signatureFromSignatureScheme(signatureAlgorithm)
 	}
 
-	digest, hashFunc, err := hashForServerKeyExchange(ka.sigType, signatureAlgorithm, ka.version, clientHello.random, hello.random, serverECDHParams)
+	digest, hashFunc, err := hashForServerKeyExchange(sigType, signatureAlgorithm, ka.version, clientHello.random, hello.random, serverECDHParams)
 	if err != nil {
 		return nil, err
 	}
 
-	priv, ok := cert.PrivateKey.(crypto.Signer)
-	if !ok {
-		return nil, errors.New("tls: certificate private key does not implement crypto.Signer")
-	}
 	var sig []byte
-	switch ka.sigType {
+	switch sigType {
 	case signatureECDSA:
 		_, ok := priv.Public().(*ecdsa.PublicKey)
 		if !ok {
 			return nil, errors.New("tls: ECDHE ECDSA requires an ECDSA server key")
 		}
-	case signatureRSA:
+	case signatureEd25519:
+		// The key type was checked above.
+	case signatureRSA, signatureRSAPSS:
 		_, ok := priv.Public().(*rsa.PublicKey)
 		if !ok {
 			return nil, errors.New("tls: ECDHE RSA requires a RSA server key")
@@ -281,7 +323,7 @@ NextCandidate:
 	default:
 		return nil, errors.New("tls: unknown ECDHE signature algorithm")
 	}
-	sig, err = priv.Sign(config.rand(), digest, hashFunc)
+	sig, err = priv.Sign(config.rand(), digest, signerOptsForSignatureScheme(signatureAlgorithm, hashFunc))
 	if err != nil {
 		return nil, errors.New("tls: failed to sign ECDHE parameters: " + err.Error())
 	}
@@ -377,10 +419,22 @@ func (ka *ecdheKeyAgreement) processServerKeyExchange(config *Config, clientHell
 	}
 
 	var signatureAlgorithm SignatureScheme
+	sigType := ka.sigType
 	if ka.version >= VersionTLS12 {
 		// handle SignatureAndHashAlgorithm
 		signatureAlgorithm = SignatureScheme(sig[0])<<8 | SignatureScheme(sig[1])
-		if signatureFromSignatureScheme(signatureAlgorithm) != ka.sigType {
+		if !isSupportedSignatureAlgorithm(signatureAlgorithm, clientHello.supportedSignatureAlgorithms) {
+			return errors.New("tls: unsupported hash function used by peer")
+		}
+		sigType = signatureFromSignatureScheme(signatureAlgorithm)
+		switch {
+		case sigType == signatureEd25519 && ka.sigType == signatureECDSA:
+			// Ed25519 is used with the ECDHE_ECDSA cipher suites, if
+			// we offered it. See RFC 8422, Section 5.4.
+		case sigType == signatureRSAPSS && ka.sigType == signatureRSA:
+			// RSA-PSS is used with the ECDHE_RSA cipher suites, if we
+			// offered it. See RFC 8446, Section 4.2.3.
+		case sigType != ka.sigType:
 			return errServerKeyExchange
 		}
 		sig = sig[2:]
@@ -394,11 +448,11 @@ func (ka *ecdheKeyAgreement) processServerKeyExchange(config *Config, clientHell
 	}
 	sig = sig[2:]
 
-	digest, hashFunc, err := hashForServerKeyExchange(ka.sigType, signatureAlgorithm, ka.version, clientHello.random, serverHello.random, serverECDHParams)
+	digest, hashFunc, err := hashForServerKeyExchange(sigType, signatureAlgorithm, ka.version, clientHello.random, serverHello.random, serverECDHParams)
 	if err != nil {
 		return err
 	}
-	switch ka.sigType {
+	switch sigType {
 	case signatureECDSA:
 		pubKey, ok := cert.PublicKey.(*ecdsa.PublicKey)
 		if !ok {
@@ -414,6 +468,14 @@ func (ka *ecdheKeyAgreement) processServerKeyExchange(config *Config, clientHell
 		if !ecdsa.Verify(pubKey, digest, ecdsaSig.R, ecdsaSig.S) {
 			return errors.New("tls: ECDSA verification failure")
 		}
+	case signatureEd25519:
+		pubKey, ok := cert.PublicKey.(ed25519.PublicKey)
+		if !ok {
+			return errors.New("tls: Ed25519 signature requires an Ed25519 server public key")
+		}
+		if !ed25519.Verify(pubKey, digest, sig) {
+			return errors.New("tls: Ed25519 verification failure")
+		}
 	case signatureRSA:
 		pubKey, ok := cert.PublicKey.(*rsa.PublicKey)
 		if !ok {
@@ -422,6 +484,15 @@ func (ka *ecdheKeyAgreement) processServerKeyExchange(config *Config, clientHell
 		if err := rsa.VerifyPKCS1v15(pubKey, hashFunc, digest, sig); err != nil {
 			return err
 		}
+	case signatureRSAPSS:
+		pubKey, ok := cert.PublicKey.(*rsa.PublicKey)
+		if !ok {
+			return errors.New("tls: ECDHE RSA requires a RSA server public key")
+		}
+		opts := &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}
+		if err := rsa.VerifyPSS(pubKey, hashFunc, digest, sig, opts); err != nil {
+			return err
+		}
 	default:
 		return errors.New("tls: unknown ECDHE signature algorithm")
 	}
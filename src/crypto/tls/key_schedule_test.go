@@ -0,0 +1,46 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tls
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func TestHKDF(t *testing.T) {
+	// Test case 1 from RFC 5869, Appendix A.
+	ikm := bytes.Repeat([]byte{0x0b}, 22)
+	salt, _ := hex.DecodeString("000102030405060708090a0b0c")
+	info, _ := hex.DecodeString("f0f1f2f3f4f5f6f7f8f9")
+	wantPRK := "077709362c2e32df0ddc3f0dc47bba6390b6c73bb50f9c3122ec844ad7c2b3e5"
+	wantOKM := "3cb25f25faacd57a90434f64d0362f2a2d2d0a90cf1a5a4c5db02d56ecc4c5bf34007208d5b887185865"
+
+	prk := hkdfExtract(sha256.New, ikm, salt)
+	if got := hex.EncodeToString(prk); got != wantPRK {
+		t.Errorf("hkdfExtract = %s, want %s", got, wantPRK)
+	}
+	okm := hkdfExpand(sha256.New, prk, info, 42)
+	if got := hex.EncodeToString(okm); got != wantOKM {
+		t.Errorf("hkdfExpand = %s, want %s", got, wantOKM)
+	}
+}
+
+func TestTrafficKey(t *testing.T) {
+	for _, suite := range cipherSuitesTLS13 {
+		secret := make([]byte, suite.hash.Size())
+		key, iv := suite.trafficKey(secret)
+		if len(key) != suite.keyLen {
+			t.Errorf("%#04x: got key length %d, expected %d", suite.id, len(key), suite.keyLen)
+		}
+		if len(iv) != aeadNonceLength {
+			t.Errorf("%#04x: got IV length %d, expected %d", suite.id, len(iv), aeadNonceLength)
+		}
+		if next := suite.nextTrafficSecret(secret); bytes.Equal(next, secret) || len(next) != len(secret) {
+			t.Errorf("%#04x: nextTrafficSecret did not produce a new secret", suite.id)
+		}
+	}
+}
@@ -310,10 +310,13 @@ func (h finishedHash) serverSum(masterSecret []byte) []byte {
 }
 
 // selectClientCertSignatureAlgorithm returns a SignatureScheme to sign a
-// client's CertificateVerify with, or an error if none can be found.
-func (h finishedHash) selectClientCertSignatureAlgorithm(serverList []SignatureScheme, sigType uint8) (SignatureScheme, error) {
+// client's CertificateVerify with pub, a key of signature type sigType, or
+// an error if none can be found. Only the signature schemes allowed by
+// config are considered.
+func (h finishedHash) selectClientCertSignatureAlgorithm(config *Config, serverList []SignatureScheme, sigType uint8, pub crypto.PublicKey) (SignatureScheme, error) {
+	clientList := config.signatureSchemes(supportedSignatureAlgorithms)
 	for _, v := range serverList {
-		if signatureFromSignatureScheme(v) == sigType && isSupportedSignatureAlgorithm(v, supportedSignatureAlgorithms) {
+		if isTLS12SignatureSchemeForKey(v, sigType, pub) && isSupportedSignatureAlgorithm(v, clientList) {
 			return v, nil
 		}
 	}
@@ -0,0 +1,445 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package x509
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"io"
+	"math/big"
+	"time"
+)
+
+// This file implements the X.509 v2 certificate revocation lists of
+// RFC 5280, section 5.
+
+var (
+	oidExtensionCRLNumber                = asn1.ObjectIdentifier{2, 5, 29, 20}
+	oidExtensionReasonCode               = asn1.ObjectIdentifier{2, 5, 29, 21}
+	oidExtensionDeltaCRLIndicator        = asn1.ObjectIdentifier{2, 5, 29, 27}
+	oidExtensionIssuingDistributionPoint = asn1.ObjectIdentifier{2, 5, 29, 28}
+	oidExtensionFreshestCRL              = asn1.ObjectIdentifier{2, 5, 29, 46}
+)
+
+// These are the ASN.1 structures of RFC 5280, section 5.1. They differ
+// from those of crypto/x509/pkix in keeping the issuer in its encoded
+// form.
+type certificateList struct {
+	TBSCertList        tbsCertificateList
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	SignatureValue     asn1.BitString
+}
+
+type tbsCertificateList struct {
+	Raw                 asn1.RawContent
+	Version             int `asn1:"optional,default:0"`
+	Signature           pkix.AlgorithmIdentifier
+	Issuer              asn1.RawValue
+	ThisUpdate          time.Time
+	NextUpdate          time.Time                 `asn1:"optional"`
+	RevokedCertificates []pkix.RevokedCertificate `asn1:"optional"`
+	Extensions          []pkix.Extension          `asn1:"tag:0,optional,explicit"`
+}
+
+// RFC 5280, 5.2.5
+type issuingDistributionPoint struct {
+	DistributionPoint          distributionPointName `asn1:"optional,tag:0"`
+	OnlyContainsUserCerts      bool                  `asn1:"optional,tag:1"`
+	OnlyContainsCACerts        bool                  `asn1:"optional,tag:2"`
+	OnlySomeReasons            asn1.BitString        `asn1:"optional,tag:3"`
+	IndirectCRL                bool                  `asn1:"optional,tag:4"`
+	OnlyContainsAttributeCerts bool                  `asn1:"optional,tag:5"`
+}
+
+// RevocationListEntry is a certificate listed in a RevocationList.
+type RevocationListEntry struct {
+	SerialNumber   *big.Int
+	RevocationTime time.Time
+
+	// ReasonCode is the CRLReason code of RFC 5280, section 5.3.1.
+	// The default, zero, means unspecified and is not encoded.
+	ReasonCode int
+
+	// Extensions contains the raw entry extensions. When parsing,
+	// this includes the reason code extension. It is ignored when
+	// creating a list; see ExtraExtensions.
+	Extensions []pkix.Extension
+
+	// ExtraExtensions contains extensions to be copied, raw, into
+	// the entry when creating a list. Values override any extensions
+	// that would otherwise be produced based on the other fields.
+	ExtraExtensions []pkix.Extension
+}
+
+// RevocationList is an X.509 v2 certificate revocation list.
+type RevocationList struct {
+	Raw                  []byte // Complete ASN.1 DER content (CRL, signature algorithm and signature).
+	RawTBSRevocationList []byte // TBSCertList part of raw ASN.1 DER content.
+	RawIssuer            []byte // DER encoded Issuer.
+
+	Issuer         pkix.Name
+	AuthorityKeyId []byte
+
+	Signature          []byte
+	SignatureAlgorithm SignatureAlgorithm
+
+	// PSSSaltLength is the salt length, in bytes, of an RSA-PSS
+	// signature, as for Certificate.
+	PSSSaltLength int
+
+	RevokedCertificates []RevocationListEntry
+
+	// Number is the monotonically increasing sequence number of the
+	// list. It is required when creating a list.
+	Number     *big.Int
+	ThisUpdate time.Time
+	NextUpdate time.Time
+
+	// BaseCRLNumber, if not nil, marks the list as a delta CRL that
+	// updates the complete CRL with that number, as described in RFC
+	// 5280, 5.2.4.
+	BaseCRLNumber *big.Int
+
+	// IssuingDistributionPoint holds the URIs of the issuing
+	// distribution point extension of RFC 5280, 5.2.5, which
+	// identifies where the list is published. OnlyContainsUserCerts
+	// and OnlyContainsCACerts limit the scope of the list and are
+	// encoded in the same extension.
+	IssuingDistributionPoint []string
+	OnlyContainsUserCerts    bool
+	OnlyContainsCACerts      bool
+
+	// FreshestCRL holds URIs from which delta CRLs for this list may
+	// be fetched, as described in RFC 5280, 5.2.6.
+	FreshestCRL []string
+
+	// Extensions contains raw X.509 extensions. When parsing a list,
+	// this can be used to extract extensions that are not parsed by
+	// this package. It is ignored when creating a list; see
+	// ExtraExtensions.
+	Extensions []pkix.Extension
+
+	// ExtraExtensions contains extensions to be copied, raw, into any
+	// created list. Values override any extensions that would
+	// otherwise be produced based on the other fields.
+	ExtraExtensions []pkix.Extension
+}
+
+// CheckSignatureFrom verifies that the signature on rl is a valid
+// signature from issuer.
+func (rl *RevocationList) CheckSignatureFrom(issuer *Certificate) error {
+	if issuer.KeyUsage != 0 && issuer.KeyUsage&KeyUsageCRLSign == 0 {
+		return ConstraintViolationError{}
+	}
+	if issuer.PublicKeyAlgorithm == UnknownPublicKeyAlgorithm {
+		return ErrUnsupportedAlgorithm
+	}
+	return checkSignatureWithPSSSaltLength(rl.SignatureAlgorithm, rl.RawTBSRevocationList, rl.Signature, issuer.PublicKey, rl.PSSSaltLength)
+}
+
+// marshalURIs returns the DistributionPointName listing uris.
+func marshalURIs(uris []string) (distributionPointName, error) {
+	var name distributionPointName
+	for _, uri := range uris {
+		if err := isIA5String(uri); err != nil {
+			return name, err
+		}
+		name.FullName = append(name.FullName, asn1.RawValue{Tag: nameTypeURI, Class: asn1.ClassContextSpecific, Bytes: []byte(uri)})
+	}
+	return name, nil
+}
+
+// parseURIs returns the URIs listed in name.
+func parseURIs(name distributionPointName) []string {
+	var uris []string
+	for _, n := range name.FullName {
+		if n.Class == asn1.ClassContextSpecific && n.Tag == nameTypeURI {
+			uris = append(uris, string(n.Bytes))
+		}
+	}
+	return uris
+}
+
+// buildCRLExtensions returns the extensions of the list described by
+// template.
+func buildCRLExtensions(template *RevocationList, authorityKeyId []byte) ([]pkix.Extension, error) {
+	var ret []pkix.Extension
+	add := func(id asn1.ObjectIdentifier, critical bool, val interface{}) error {
+		if oidInExtensions(id, template.ExtraExtensions) {
+			return nil
+		}
+		b, err := asn1.Marshal(val)
+		if err != nil {
+			return err
+		}
+		ret = append(ret, pkix.Extension{Id: id, Critical: critical, Value: b})
+		return nil
+	}
+
+	if len(authorityKeyId) > 0 {
+		if err := add(oidExtensionAuthorityKeyId, false, authKeyId{Id: authorityKeyId}); err != nil {
+			return nil, err
+		}
+	}
+	if err := add(oidExtensionCRLNumber, false, template.Number); err != nil {
+		return nil, err
+	}
+	if template.BaseCRLNumber != nil {
+		// RFC 5280, 5.2.4: "This extension MUST be marked critical."
+		if err := add(oidExtensionDeltaCRLIndicator, true, template.BaseCRLNumber); err != nil {
+			return nil, err
+		}
+	}
+	if len(template.IssuingDistributionPoint) > 0 || template.OnlyContainsUserCerts || template.OnlyContainsCACerts {
+		if template.OnlyContainsUserCerts && template.OnlyContainsCACerts {
+			return nil, errors.New("x509: a CRL cannot contain only user and only CA certificates")
+		}
+		name, err := marshalURIs(template.IssuingDistributionPoint)
+		if err != nil {
+			return nil, err
+		}
+		idp := issuingDistributionPoint{
+			DistributionPoint:     name,
+			OnlyContainsUserCerts: template.OnlyContainsUserCerts,
+			OnlyContainsCACerts:   template.OnlyContainsCACerts,
+		}
+		// RFC 5280, 5.2.5: "this extension is a critical CRL extension".
+		if err := add(oidExtensionIssuingDistributionPoint, true, idp); err != nil {
+			return nil, err
+		}
+	}
+	if len(template.FreshestCRL) > 0 {
+		name, err := marshalURIs(template.FreshestCRL)
+		if err != nil {
+			return nil, err
+		}
+		if err := add(oidExtensionFreshestCRL, false, []distributionPoint{{DistributionPoint: name}}); err != nil {
+			return nil, err
+		}
+	}
+
+	return append(ret, template.ExtraExtensions...), nil
+}
+
+// CreateRevocationList creates a new X.509 v2 certificate revocation
+// list based on a template. The following members of template are
+// used: BaseCRLNumber, ExtraExtensions, FreshestCRL,
+// IssuingDistributionPoint, NextUpdate, Number, OnlyContainsCACerts,
+// OnlyContainsUserCerts, PSSSaltLength, RevokedCertificates,
+// SignatureAlgorithm and ThisUpdate. Of each entry in
+// RevokedCertificates, ExtraExtensions, ReasonCode, RevocationTime and
+// SerialNumber are used.
+//
+// The list is issued by issuer and signed with priv, which must be the
+// private key of issuer. The authority key identifier is taken from
+// the SubjectKeyId of issuer, if any.
+//
+// The returned slice is the list in DER encoding.
+func CreateRevocationList(rand io.Reader, template *RevocationList, issuer *Certificate, priv crypto.Signer) ([]byte, error) {
+	if template == nil {
+		return nil, errors.New("x509: template can not be nil")
+	}
+	if issuer == nil {
+		return nil, errors.New("x509: issuer can not be nil")
+	}
+	if issuer.KeyUsage != 0 && issuer.KeyUsage&KeyUsageCRLSign == 0 {
+		return nil, errors.New("x509: issuer must have the crlSign key usage bit set")
+	}
+	if template.Number == nil {
+		return nil, errors.New("x509: template contains nil Number field")
+	}
+	if template.NextUpdate.Before(template.ThisUpdate) {
+		return nil, errors.New("x509: template.ThisUpdate is after template.NextUpdate")
+	}
+
+	hashFunc, signatureAlgorithm, err := signingParamsForPublicKey(priv.Public(), template.SignatureAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+	saltLength, err := pssSaltLength(template.SignatureAlgorithm, template.PSSSaltLength, hashFunc, &signatureAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	asn1Issuer, err := subjectBytes(issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	// Force revocation times to UTC per RFC 5280.
+	revoked := make([]pkix.RevokedCertificate, len(template.RevokedCertificates))
+	for i, rc := range template.RevokedCertificates {
+		if rc.SerialNumber == nil {
+			return nil, errors.New("x509: revoked certificate contains nil SerialNumber field")
+		}
+		revoked[i] = pkix.RevokedCertificate{
+			SerialNumber:   rc.SerialNumber,
+			RevocationTime: rc.RevocationTime.UTC(),
+		}
+		if rc.ReasonCode != 0 && !oidInExtensions(oidExtensionReasonCode, rc.ExtraExtensions) {
+			b, err := asn1.Marshal(asn1.Enumerated(rc.ReasonCode))
+			if err != nil {
+				return nil, err
+			}
+			revoked[i].Extensions = append(revoked[i].Extensions, pkix.Extension{Id: oidExtensionReasonCode, Value: b})
+		}
+		revoked[i].Extensions = append(revoked[i].Extensions, rc.ExtraExtensions...)
+	}
+
+	extensions, err := buildCRLExtensions(template, issuer.SubjectKeyId)
+	if err != nil {
+		return nil, err
+	}
+
+	tbs := tbsCertificateList{
+		Version:             1,
+		Signature:           signatureAlgorithm,
+		Issuer:              asn1.RawValue{FullBytes: asn1Issuer},
+		ThisUpdate:          template.ThisUpdate.UTC(),
+		NextUpdate:          template.NextUpdate.UTC(),
+		RevokedCertificates: revoked,
+		Extensions:          extensions,
+	}
+	tbsContents, err := asn1.Marshal(tbs)
+	if err != nil {
+		return nil, err
+	}
+	tbs.Raw = tbsContents
+
+	signed := tbsContents
+	if hashFunc != 0 {
+		h := hashFunc.New()
+		h.Write(signed)
+		signed = h.Sum(nil)
+	}
+	var signerOpts crypto.SignerOpts = hashFunc
+	if template.SignatureAlgorithm != 0 && template.SignatureAlgorithm.isRSAPSS() {
+		signerOpts = &rsa.PSSOptions{
+			SaltLength: saltLength,
+			Hash:       hashFunc,
+		}
+	}
+	signature, err := priv.Sign(rand, signed, signerOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(certificateList{
+		TBSCertList:        tbs,
+		SignatureAlgorithm: signatureAlgorithm,
+		SignatureValue:     asn1.BitString{Bytes: signature, BitLength: len(signature) * 8},
+	})
+}
+
+// ParseRevocationList parses a DER encoded X.509 v2 certificate
+// revocation list. The signature is not checked; see
+// RevocationList.CheckSignatureFrom.
+func ParseRevocationList(der []byte) (*RevocationList, error) {
+	var crl certificateList
+	if rest, err := asn1.Unmarshal(der, &crl); err != nil {
+		return nil, err
+	} else if len(rest) != 0 {
+		return nil, errors.New("x509: trailing data after CRL")
+	}
+	tbs := &crl.TBSCertList
+	if tbs.Version > 1 {
+		return nil, errors.New("x509: unsupported CRL version")
+	}
+
+	rl := &RevocationList{
+		Raw:                  der,
+		RawTBSRevocationList: tbs.Raw,
+		RawIssuer:            tbs.Issuer.FullBytes,
+		Signature:            crl.SignatureValue.RightAlign(),
+		ThisUpdate:           tbs.ThisUpdate,
+		NextUpdate:           tbs.NextUpdate,
+		Extensions:           tbs.Extensions,
+	}
+
+	rl.SignatureAlgorithm, rl.PSSSaltLength = getSignatureAlgorithmAndSaltFromAI(crl.SignatureAlgorithm)
+
+	var issuer pkix.RDNSequence
+	if rest, err := asn1.Unmarshal(tbs.Issuer.FullBytes, &issuer); err != nil {
+		return nil, err
+	} else if len(rest) != 0 {
+		return nil, errors.New("x509: trailing data after X.509 CRL issuer")
+	}
+	rl.Issuer.FillFromRDNSequence(&issuer)
+
+	for _, rc := range tbs.RevokedCertificates {
+		entry := RevocationListEntry{
+			SerialNumber:   rc.SerialNumber,
+			RevocationTime: rc.RevocationTime,
+			Extensions:     rc.Extensions,
+		}
+		for _, e := range rc.Extensions {
+			if !e.Id.Equal(oidExtensionReasonCode) {
+				continue
+			}
+			var reason asn1.Enumerated
+			if rest, err := asn1.Unmarshal(e.Value, &reason); err != nil {
+				return nil, err
+			} else if len(rest) != 0 {
+				return nil, errors.New("x509: trailing data after X.509 CRL reason code")
+			}
+			entry.ReasonCode = int(reason)
+		}
+		rl.RevokedCertificates = append(rl.RevokedCertificates, entry)
+	}
+
+	for _, e := range tbs.Extensions {
+		switch {
+		case e.Id.Equal(oidExtensionAuthorityKeyId):
+			var a authKeyId
+			if rest, err := asn1.Unmarshal(e.Value, &a); err != nil {
+				return nil, err
+			} else if len(rest) != 0 {
+				return nil, errors.New("x509: trailing data after X.509 authority key-id")
+			}
+			rl.AuthorityKeyId = a.Id
+
+		case e.Id.Equal(oidExtensionCRLNumber):
+			if rest, err := asn1.Unmarshal(e.Value, &rl.Number); err != nil {
+				return nil, err
+			} else if len(rest) != 0 {
+				return nil, errors.New("x509: trailing data after X.509 CRL number")
+			}
+
+		case e.Id.Equal(oidExtensionDeltaCRLIndicator):
+			if rest, err := asn1.Unmarshal(e.Value, &rl.BaseCRLNumber); err != nil {
+				return nil, err
+			} else if len(rest) != 0 {
+				return nil, errors.New("x509: trailing data after X.509 delta CRL indicator")
+			}
+
+		case e.Id.Equal(oidExtensionIssuingDistributionPoint):
+			var idp issuingDistributionPoint
+			if rest, err := asn1.Unmarshal(e.Value, &idp); err != nil {
+				return nil, err
+			} else if len(rest) != 0 {
+				return nil, errors.New("x509: trailing data after X.509 issuing distribution point")
+			}
+			rl.IssuingDistributionPoint = parseURIs(idp.DistributionPoint)
+			rl.OnlyContainsUserCerts = idp.OnlyContainsUserCerts
+			rl.OnlyContainsCACerts = idp.OnlyContainsCACerts
+
+		case e.Id.Equal(oidExtensionFreshestCRL):
+			var dps []distributionPoint
+			if rest, err := asn1.Unmarshal(e.Value, &dps); err != nil {
+				return nil, err
+			} else if len(rest) != 0 {
+				return nil, errors.New("x509: trailing data after X.509 freshest CRL")
+			}
+			for _, dp := range dps {
+				rl.FreshestCRL = append(rl.FreshestCRL, parseURIs(dp.DistributionPoint)...)
+			}
+		}
+	}
+
+	return rl, nil
+}
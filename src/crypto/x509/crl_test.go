@@ -0,0 +1,195 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package x509
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCreateRevocationList(t *testing.T) {
+	p := newRevocationTestPKI(t)
+	now := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	extra := pkix.Extension{Id: asn1.ObjectIdentifier{1, 2, 3, 4}, Value: []byte{5, 0}}
+	template := &RevocationList{
+		Number:     big.NewInt(7),
+		ThisUpdate: now,
+		NextUpdate: now.Add(24 * time.Hour),
+		RevokedCertificates: []RevocationListEntry{
+			{SerialNumber: p.leaf.SerialNumber, RevocationTime: now.Add(-time.Hour), ReasonCode: 1},
+			{SerialNumber: big.NewInt(99), RevocationTime: now.Add(-2 * time.Hour), ExtraExtensions: []pkix.Extension{extra}},
+		},
+		BaseCRLNumber:            big.NewInt(5),
+		IssuingDistributionPoint: []string{"http://example.com/delta.crl"},
+		OnlyContainsUserCerts:    true,
+		FreshestCRL:              []string{"http://example.com/freshest.crl"},
+		ExtraExtensions:          []pkix.Extension{extra},
+	}
+	der, err := CreateRevocationList(rand.Reader, template, p.inter, p.interKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rl, err := ParseRevocationList(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rl.CheckSignatureFrom(p.inter); err != nil {
+		t.Errorf("CheckSignatureFrom(issuer): %v", err)
+	}
+	if err := rl.CheckSignatureFrom(p.root); err == nil {
+		t.Error("CheckSignatureFrom(root) succeeded, want error")
+	}
+
+	if rl.Issuer.CommonName != "Intermediate" || string(rl.RawIssuer) != string(p.inter.RawSubject) {
+		t.Errorf("Issuer = %v, want Intermediate", rl.Issuer)
+	}
+	if string(rl.AuthorityKeyId) != string(p.inter.SubjectKeyId) {
+		t.Errorf("AuthorityKeyId = %x, want %x", rl.AuthorityKeyId, p.inter.SubjectKeyId)
+	}
+	if rl.Number.Cmp(template.Number) != 0 || rl.BaseCRLNumber.Cmp(template.BaseCRLNumber) != 0 {
+		t.Errorf("Number, BaseCRLNumber = %v, %v, want 7, 5", rl.Number, rl.BaseCRLNumber)
+	}
+	if !rl.ThisUpdate.Equal(template.ThisUpdate) || !rl.NextUpdate.Equal(template.NextUpdate) {
+		t.Errorf("ThisUpdate, NextUpdate = %v, %v", rl.ThisUpdate, rl.NextUpdate)
+	}
+	if !reflect.DeepEqual(rl.IssuingDistributionPoint, template.IssuingDistributionPoint) ||
+		!rl.OnlyContainsUserCerts || rl.OnlyContainsCACerts {
+		t.Errorf("issuing distribution point = %v, %v, %v", rl.IssuingDistributionPoint, rl.OnlyContainsUserCerts, rl.OnlyContainsCACerts)
+	}
+	if !reflect.DeepEqual(rl.FreshestCRL, template.FreshestCRL) {
+		t.Errorf("FreshestCRL = %v, want %v", rl.FreshestCRL, template.FreshestCRL)
+	}
+
+	critical := make(map[string]bool)
+	for _, e := range rl.Extensions {
+		critical[e.Id.String()] = e.Critical
+	}
+	for id, want := range map[string]bool{
+		"2.5.29.20": false, "2.5.29.27": true, "2.5.29.28": true,
+		"2.5.29.46": false, "1.2.3.4": false,
+	} {
+		if got, ok := critical[id]; !ok || got != want {
+			t.Errorf("extension %s: present %v, critical %v; want critical %v", id, ok, got, want)
+		}
+	}
+
+	if len(rl.RevokedCertificates) != 2 {
+		t.Fatalf("got %d revoked certificates, want 2", len(rl.RevokedCertificates))
+	}
+	for i, rc := range rl.RevokedCertificates {
+		want := template.RevokedCertificates[i]
+		if rc.SerialNumber.Cmp(want.SerialNumber) != 0 || !rc.RevocationTime.Equal(want.RevocationTime) || rc.ReasonCode != want.ReasonCode {
+			t.Errorf("entry %d = %v, %v, %d; want %v, %v, %d", i, rc.SerialNumber, rc.RevocationTime, rc.ReasonCode,
+				want.SerialNumber, want.RevocationTime, want.ReasonCode)
+		}
+	}
+	if exts := rl.RevokedCertificates[0].Extensions; len(exts) != 1 || !exts[0].Id.Equal(oidExtensionReasonCode) {
+		t.Errorf("entry 0 extensions = %v, want reason code", exts)
+	}
+	if exts := rl.RevokedCertificates[1].Extensions; len(exts) != 1 || !exts[0].Id.Equal(extra.Id) {
+		t.Errorf("entry 1 extensions = %v, want extra extension only", exts)
+	}
+}
+
+func TestCreateRevocationListVerify(t *testing.T) {
+	// A complete list without critical extensions can be used to
+	// check revocation during verification.
+	p := newRevocationTestPKI(t)
+	now := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	der, err := CreateRevocationList(rand.Reader, &RevocationList{
+		Number:     big.NewInt(1),
+		ThisUpdate: now.Add(-time.Hour),
+		NextUpdate: now.Add(time.Hour),
+		RevokedCertificates: []RevocationListEntry{
+			{SerialNumber: p.leaf.SerialNumber, RevocationTime: now.Add(-time.Hour), ReasonCode: 1},
+		},
+	}, p.inter, p.interKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	crl, err := ParseDERCRL(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := p.opts(now)
+	opts.CRLs = []*pkix.CertificateList{crl}
+	_, err = p.leaf.Verify(opts)
+	if e, ok := err.(CertificateInvalidError); !ok || e.Reason != Revoked {
+		t.Errorf("Verify error = %v, want Revoked", err)
+	}
+}
+
+func TestCreateRevocationListRSAPSS(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:         true,
+		SubjectKeyId: []byte{1, 2, 3},
+	}
+	certDER, err := CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer, err := ParseCertificate(certDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := CreateRevocationList(rand.Reader, &RevocationList{
+		Number:             big.NewInt(1),
+		ThisUpdate:         time.Now(),
+		NextUpdate:         time.Now().Add(time.Hour),
+		SignatureAlgorithm: SHA256WithRSAPSS,
+	}, issuer, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rl, err := ParseRevocationList(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rl.SignatureAlgorithm != SHA256WithRSAPSS {
+		t.Errorf("SignatureAlgorithm = %v, want SHA256WithRSAPSS", rl.SignatureAlgorithm)
+	}
+	if string(rl.AuthorityKeyId) != string(template.SubjectKeyId) {
+		t.Errorf("AuthorityKeyId = %x, want %x", rl.AuthorityKeyId, template.SubjectKeyId)
+	}
+	if err := rl.CheckSignatureFrom(issuer); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCreateRevocationListErrors(t *testing.T) {
+	p := newRevocationTestPKI(t)
+	now := time.Now()
+	tests := []struct {
+		name     string
+		template RevocationList
+		issuer   *Certificate
+	}{
+		{"no number", RevocationList{ThisUpdate: now, NextUpdate: now.Add(time.Hour)}, p.inter},
+		{"next update before this update", RevocationList{Number: big.NewInt(1), ThisUpdate: now, NextUpdate: now.Add(-time.Hour)}, p.inter},
+		{"issuer without crlSign", RevocationList{Number: big.NewInt(1), ThisUpdate: now, NextUpdate: now.Add(time.Hour)}, &Certificate{KeyUsage: KeyUsageDigitalSignature}},
+		{"conflicting scope", RevocationList{Number: big.NewInt(1), ThisUpdate: now, NextUpdate: now.Add(time.Hour), OnlyContainsUserCerts: true, OnlyContainsCACerts: true}, p.inter},
+		{"nil serial", RevocationList{Number: big.NewInt(1), ThisUpdate: now, NextUpdate: now.Add(time.Hour), RevokedCertificates: []RevocationListEntry{{RevocationTime: now}}}, p.inter},
+	}
+	for _, test := range tests {
+		if _, err := CreateRevocationList(rand.Reader, &test.template, test.issuer, p.interKey); err == nil {
+			t.Errorf("%s: CreateRevocationList succeeded, want error", test.name)
+		}
+	}
+}
@@ -0,0 +1,577 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package x509
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"io"
+	"math/big"
+	"strconv"
+	"time"
+)
+
+// This file implements the Online Certificate Status Protocol of RFC 6960.
+
+var (
+	oidOCSPBasicResponse = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 1}
+	oidSHA1              = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+)
+
+// ocspHashOIDs maps the hash functions that may identify a certificate
+// in an OCSP request or response to their OIDs.
+var ocspHashOIDs = []struct {
+	hash crypto.Hash
+	oid  asn1.ObjectIdentifier
+}{
+	{crypto.SHA1, oidSHA1},
+	{crypto.SHA256, oidSHA256},
+	{crypto.SHA384, oidSHA384},
+	{crypto.SHA512, oidSHA512},
+}
+
+func ocspHashFromOID(oid asn1.ObjectIdentifier) crypto.Hash {
+	for _, h := range ocspHashOIDs {
+		if h.oid.Equal(oid) {
+			return h.hash
+		}
+	}
+	return 0
+}
+
+func ocspOIDFromHash(hash crypto.Hash) (asn1.ObjectIdentifier, bool) {
+	for _, h := range ocspHashOIDs {
+		if h.hash == hash {
+			return h.oid, true
+		}
+	}
+	return nil, false
+}
+
+// These are the ASN.1 structures of RFC 6960, section 4.
+
+type ocspCertID struct {
+	HashAlgorithm  pkix.AlgorithmIdentifier
+	IssuerNameHash []byte
+	IssuerKeyHash  []byte
+	SerialNumber   *big.Int
+}
+
+type ocspRequest struct {
+	TBSRequest ocspTBSRequest
+}
+
+type ocspTBSRequest struct {
+	Version       int           `asn1:"explicit,tag:0,default:0,optional"`
+	RequestorName asn1.RawValue `asn1:"explicit,tag:1,optional"`
+	RequestList   []ocspSingleRequest
+}
+
+type ocspSingleRequest struct {
+	Cert ocspCertID
+}
+
+type ocspResponseASN1 struct {
+	Status   asn1.Enumerated
+	Response ocspResponseBytes `asn1:"explicit,tag:0,optional"`
+}
+
+type ocspResponseBytes struct {
+	ResponseType asn1.ObjectIdentifier
+	Response     []byte
+}
+
+type ocspBasicResponse struct {
+	TBSResponseData    ocspResponseData
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          asn1.BitString
+	Certificates       []asn1.RawValue `asn1:"explicit,tag:0,optional"`
+}
+
+type ocspResponseData struct {
+	Raw            asn1.RawContent
+	Version        int `asn1:"explicit,tag:0,default:0,optional"`
+	RawResponderID asn1.RawValue
+	ProducedAt     time.Time `asn1:"generalized"`
+	Responses      []ocspSingleResponse
+	Extensions     []pkix.Extension `asn1:"explicit,tag:1,optional"`
+}
+
+type ocspSingleResponse struct {
+	CertID           ocspCertID
+	Good             asn1.Flag        `asn1:"tag:0,optional"`
+	Revoked          ocspRevokedInfo  `asn1:"tag:1,optional"`
+	Unknown          asn1.Flag        `asn1:"tag:2,optional"`
+	ThisUpdate       time.Time        `asn1:"generalized"`
+	NextUpdate       time.Time        `asn1:"generalized,explicit,tag:0,optional"`
+	SingleExtensions []pkix.Extension `asn1:"explicit,tag:1,optional"`
+}
+
+type ocspRevokedInfo struct {
+	RevocationTime time.Time       `asn1:"generalized"`
+	Reason         asn1.Enumerated `asn1:"explicit,tag:0,optional"`
+}
+
+// OCSPStatus is the status of a certificate in an OCSP response.
+type OCSPStatus int
+
+const (
+	OCSPGood OCSPStatus = iota
+	OCSPRevoked
+	OCSPUnknown
+)
+
+func (s OCSPStatus) String() string {
+	switch s {
+	case OCSPGood:
+		return "good"
+	case OCSPRevoked:
+		return "revoked"
+	case OCSPUnknown:
+		return "unknown"
+	}
+	return "OCSPStatus(" + strconv.Itoa(int(s)) + ")"
+}
+
+// OCSPResponseStatus is the status of an OCSP response as a whole,
+// indicating whether the responder processed the request.
+type OCSPResponseStatus int
+
+const (
+	OCSPSuccess           OCSPResponseStatus = 0
+	OCSPMalformed         OCSPResponseStatus = 1
+	OCSPInternalError     OCSPResponseStatus = 2
+	OCSPTryLater          OCSPResponseStatus = 3
+	OCSPSignatureRequired OCSPResponseStatus = 5
+	OCSPUnauthorized      OCSPResponseStatus = 6
+)
+
+func (s OCSPResponseStatus) String() string {
+	switch s {
+	case OCSPSuccess:
+		return "success"
+	case OCSPMalformed:
+		return "malformed request"
+	case OCSPInternalError:
+		return "internal error"
+	case OCSPTryLater:
+		return "try later"
+	case OCSPSignatureRequired:
+		return "signature required"
+	case OCSPUnauthorized:
+		return "unauthorized"
+	}
+	return "OCSPResponseStatus(" + strconv.Itoa(int(s)) + ")"
+}
+
+// OCSPResponseError is returned when parsing an OCSP response whose
+// status is not OCSPSuccess. Such responses carry no certificate status.
+type OCSPResponseError struct {
+	Status OCSPResponseStatus
+}
+
+func (e OCSPResponseError) Error() string {
+	return "x509: OCSP response has status " + e.Status.String()
+}
+
+// OCSPRequest is a request for the status of a single certificate.
+type OCSPRequest struct {
+	HashAlgorithm  crypto.Hash // of IssuerNameHash and IssuerKeyHash
+	IssuerNameHash []byte
+	IssuerKeyHash  []byte
+	SerialNumber   *big.Int
+}
+
+// issuerKeyBytes returns the bits of issuer's public key, which
+// identify it in OCSP messages.
+func issuerKeyBytes(issuer *Certificate) ([]byte, error) {
+	var pki publicKeyInfo
+	if rest, err := asn1.Unmarshal(issuer.RawSubjectPublicKeyInfo, &pki); err != nil {
+		return nil, err
+	} else if len(rest) != 0 {
+		return nil, errors.New("x509: trailing data after public key")
+	}
+	return pki.PublicKey.RightAlign(), nil
+}
+
+// newOCSPCertID returns the identifier of the certificate with the
+// given serial number issued by issuer.
+func newOCSPCertID(serial *big.Int, issuer *Certificate, hash crypto.Hash) (ocspCertID, error) {
+	if hash == 0 {
+		hash = crypto.SHA1
+	}
+	oid, ok := ocspOIDFromHash(hash)
+	if !ok || !hash.Available() {
+		return ocspCertID{}, errors.New("x509: unsupported OCSP hash function")
+	}
+	key, err := issuerKeyBytes(issuer)
+	if err != nil {
+		return ocspCertID{}, err
+	}
+	h := hash.New()
+	h.Write(issuer.RawSubject)
+	nameHash := h.Sum(nil)
+	h.Reset()
+	h.Write(key)
+	return ocspCertID{
+		HashAlgorithm:  pkix.AlgorithmIdentifier{Algorithm: oid, Parameters: asn1.NullRawValue},
+		IssuerNameHash: nameHash,
+		IssuerKeyHash:  h.Sum(nil),
+		SerialNumber:   serial,
+	}, nil
+}
+
+// matches reports whether id identifies a certificate with the given
+// serial number issued by issuer. A nil issuer matches any issuer.
+func (id *ocspCertID) matches(serial *big.Int, issuer *Certificate) bool {
+	if serial != nil && id.SerialNumber.Cmp(serial) != 0 {
+		return false
+	}
+	if issuer == nil {
+		return true
+	}
+	hash := ocspHashFromOID(id.HashAlgorithm.Algorithm)
+	if hash == 0 || !hash.Available() {
+		return false
+	}
+	want, err := newOCSPCertID(serial, issuer, hash)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(id.IssuerNameHash, want.IssuerNameHash) &&
+		bytes.Equal(id.IssuerKeyHash, want.IssuerKeyHash)
+}
+
+// CreateOCSPRequest returns a DER-encoded OCSP request for the status
+// of cert, which was issued by issuer. The certificate is identified
+// using hash, or SHA-1 if hash is zero, as most responders expect.
+func CreateOCSPRequest(cert, issuer *Certificate, hash crypto.Hash) ([]byte, error) {
+	id, err := newOCSPCertID(cert.SerialNumber, issuer, hash)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(ocspRequest{
+		TBSRequest: ocspTBSRequest{
+			RequestList: []ocspSingleRequest{{Cert: id}},
+		},
+	})
+}
+
+// ParseOCSPRequest parses a DER-encoded OCSP request. Only requests
+// for a single certificate are supported.
+func ParseOCSPRequest(der []byte) (*OCSPRequest, error) {
+	var req ocspRequest
+	if rest, err := asn1.Unmarshal(der, &req); err != nil {
+		return nil, err
+	} else if len(rest) != 0 {
+		return nil, errors.New("x509: trailing data after OCSP request")
+	}
+	if len(req.TBSRequest.RequestList) != 1 {
+		return nil, errors.New("x509: OCSP request must contain exactly one certificate")
+	}
+	id := req.TBSRequest.RequestList[0].Cert
+	hash := ocspHashFromOID(id.HashAlgorithm.Algorithm)
+	if hash == 0 {
+		return nil, errors.New("x509: unknown OCSP hash algorithm")
+	}
+	return &OCSPRequest{
+		HashAlgorithm:  hash,
+		IssuerNameHash: id.IssuerNameHash,
+		IssuerKeyHash:  id.IssuerKeyHash,
+		SerialNumber:   id.SerialNumber,
+	}, nil
+}
+
+// OCSPResponse is the status of a single certificate, as reported by
+// an OCSP responder.
+type OCSPResponse struct {
+	Raw          []byte // Complete ASN.1 DER content.
+	Status       OCSPStatus
+	SerialNumber *big.Int
+
+	ProducedAt time.Time
+	ThisUpdate time.Time
+	NextUpdate time.Time // zero if the responder did not set it
+
+	// RevokedAt and RevocationReason are set if Status is
+	// OCSPRevoked. RevocationReason is a CRLReason code of RFC
+	// 5280, section 5.3.1.
+	RevokedAt        time.Time
+	RevocationReason int
+
+	// Certificate is the responder's certificate, if the response
+	// was signed by a responder delegated by the issuer rather than
+	// by the issuer itself.
+	Certificate *Certificate
+
+	TBSResponseData    []byte
+	Signature          []byte
+	SignatureAlgorithm SignatureAlgorithm
+
+	// IssuerHash is the hash function identifying the certificate
+	// and its issuer. When creating a response, zero means SHA-1.
+	IssuerHash crypto.Hash
+
+	// Exactly one of RawResponderName and ResponderKeyHash is set.
+	RawResponderName []byte
+	ResponderKeyHash []byte
+
+	// Extensions are the response's singleExtensions.
+	Extensions []pkix.Extension
+}
+
+// CheckSignatureFrom checks that the signature on r is valid and was
+// made by issuer, or by a responder certificate included in r that
+// issuer authorized to sign OCSP responses.
+func (r *OCSPResponse) CheckSignatureFrom(issuer *Certificate) error {
+	signer := issuer
+	if r.Certificate != nil && !r.Certificate.Equal(issuer) {
+		if err := r.Certificate.CheckSignatureFrom(issuer); err != nil {
+			return errors.New("x509: OCSP responder certificate not issued by issuer: " + err.Error())
+		}
+		authorized := false
+		for _, eku := range r.Certificate.ExtKeyUsage {
+			if eku == ExtKeyUsageOCSPSigning {
+				authorized = true
+				break
+			}
+		}
+		if !authorized {
+			return errors.New("x509: OCSP responder certificate is not authorized to sign OCSP responses")
+		}
+		signer = r.Certificate
+	}
+	return signer.CheckSignature(r.SignatureAlgorithm, r.TBSResponseData, r.Signature)
+}
+
+// ParseOCSPResponse parses a DER-encoded OCSP response, which must
+// contain the status of exactly one certificate. If issuer is not nil,
+// the response's signature is checked as by CheckSignatureFrom and the
+// certificate must have been issued by issuer.
+//
+// Responses whose status is not OCSPSuccess result in an
+// OCSPResponseError.
+func ParseOCSPResponse(der []byte, issuer *Certificate) (*OCSPResponse, error) {
+	return ParseOCSPResponseForCert(der, nil, issuer)
+}
+
+// ParseOCSPResponseForCert is like ParseOCSPResponse, but if cert is
+// not nil it selects the status of cert from a response that may
+// contain several.
+func ParseOCSPResponseForCert(der []byte, cert, issuer *Certificate) (*OCSPResponse, error) {
+	var resp ocspResponseASN1
+	if rest, err := asn1.Unmarshal(der, &resp); err != nil {
+		return nil, err
+	} else if len(rest) != 0 {
+		return nil, errors.New("x509: trailing data after OCSP response")
+	}
+	if status := OCSPResponseStatus(resp.Status); status != OCSPSuccess {
+		return nil, OCSPResponseError{status}
+	}
+	if !resp.Response.ResponseType.Equal(oidOCSPBasicResponse) {
+		return nil, errors.New("x509: unsupported OCSP response type")
+	}
+	var basic ocspBasicResponse
+	if rest, err := asn1.Unmarshal(resp.Response.Response, &basic); err != nil {
+		return nil, err
+	} else if len(rest) != 0 {
+		return nil, errors.New("x509: trailing data after OCSP basic response")
+	}
+	if len(basic.Certificates) > 1 {
+		return nil, errors.New("x509: OCSP response contains more than one certificate")
+	}
+
+	var single *ocspSingleResponse
+	var serial *big.Int
+	if cert != nil {
+		serial = cert.SerialNumber
+	}
+	responses := basic.TBSResponseData.Responses
+	switch {
+	case cert == nil && len(responses) != 1:
+		return nil, errors.New("x509: OCSP response must contain exactly one certificate status")
+	case cert == nil:
+		single = &responses[0]
+		if issuer != nil && !single.CertID.matches(nil, issuer) {
+			return nil, errors.New("x509: OCSP response is for a certificate of a different issuer")
+		}
+	default:
+		for i := range responses {
+			if responses[i].CertID.matches(serial, issuer) {
+				single = &responses[i]
+				break
+			}
+		}
+		if single == nil {
+			return nil, errors.New("x509: OCSP response does not contain the status of the certificate")
+		}
+	}
+
+	r := &OCSPResponse{
+		Raw:                der,
+		SerialNumber:       single.CertID.SerialNumber,
+		ProducedAt:         basic.TBSResponseData.ProducedAt,
+		ThisUpdate:         single.ThisUpdate,
+		NextUpdate:         single.NextUpdate,
+		TBSResponseData:    basic.TBSResponseData.Raw,
+		Signature:          basic.Signature.RightAlign(),
+		SignatureAlgorithm: getSignatureAlgorithmFromAI(basic.SignatureAlgorithm),
+		IssuerHash:         ocspHashFromOID(single.CertID.HashAlgorithm.Algorithm),
+		Extensions:         single.SingleExtensions,
+	}
+	switch {
+	case bool(single.Good):
+		r.Status = OCSPGood
+	case bool(single.Unknown):
+		r.Status = OCSPUnknown
+	default:
+		r.Status = OCSPRevoked
+		r.RevokedAt = single.Revoked.RevocationTime
+		r.RevocationReason = int(single.Revoked.Reason)
+	}
+
+	rawID := basic.TBSResponseData.RawResponderID
+	switch {
+	case rawID.Class == asn1.ClassContextSpecific && rawID.Tag == 1:
+		r.RawResponderName = rawID.Bytes
+	case rawID.Class == asn1.ClassContextSpecific && rawID.Tag == 2:
+		if _, err := asn1.Unmarshal(rawID.Bytes, &r.ResponderKeyHash); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("x509: invalid OCSP responder ID")
+	}
+
+	if len(basic.Certificates) > 0 {
+		c, err := ParseCertificate(basic.Certificates[0].FullBytes)
+		if err != nil {
+			return nil, err
+		}
+		r.Certificate = c
+	}
+
+	if issuer != nil {
+		if err := r.CheckSignatureFrom(issuer); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// CreateOCSPResponse returns a DER-encoded OCSP response reporting the
+// status of the certificate with template.SerialNumber, issued by
+// issuer. The following members of template are used: Status,
+// SerialNumber, ThisUpdate, NextUpdate, RevokedAt, RevocationReason,
+// IssuerHash, Extensions, SignatureAlgorithm and ProducedAt, which
+// defaults to the current time.
+//
+// The response is signed with priv, which must be the private key of
+// responder, a certificate issued by issuer for signing OCSP responses
+// that is included in the response. If responder is nil, priv must be
+// the private key of issuer.
+func CreateOCSPResponse(rand io.Reader, issuer, responder *Certificate, template *OCSPResponse, priv interface{}) ([]byte, error) {
+	key, ok := priv.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("x509: certificate private key does not implement crypto.Signer")
+	}
+	if responder == nil {
+		responder = issuer
+	}
+	hashFunc, signatureAlgorithm, err := signingParamsForPublicKey(key.Public(), template.SignatureAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := newOCSPCertID(template.SerialNumber, issuer, template.IssuerHash)
+	if err != nil {
+		return nil, err
+	}
+	single := ocspSingleResponse{
+		CertID:           id,
+		ThisUpdate:       template.ThisUpdate.UTC(),
+		NextUpdate:       template.NextUpdate.UTC(),
+		SingleExtensions: template.Extensions,
+	}
+	switch template.Status {
+	case OCSPGood:
+		single.Good = true
+	case OCSPUnknown:
+		single.Unknown = true
+	case OCSPRevoked:
+		single.Revoked = ocspRevokedInfo{
+			RevocationTime: template.RevokedAt.UTC(),
+			Reason:         asn1.Enumerated(template.RevocationReason),
+		}
+	default:
+		return nil, errors.New("x509: invalid OCSP status")
+	}
+
+	// Responders are identified by the SHA-1 hash of their key.
+	responderKey, err := issuerKeyBytes(responder)
+	if err != nil {
+		return nil, err
+	}
+	h := crypto.SHA1.New()
+	h.Write(responderKey)
+	keyHash, err := asn1.Marshal(h.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	producedAt := template.ProducedAt
+	if producedAt.IsZero() {
+		producedAt = time.Now()
+	}
+	tbs := ocspResponseData{
+		RawResponderID: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 2, IsCompound: true, Bytes: keyHash},
+		ProducedAt:     producedAt.UTC(),
+		Responses:      []ocspSingleResponse{single},
+	}
+	tbsContents, err := asn1.Marshal(tbs)
+	if err != nil {
+		return nil, err
+	}
+
+	signed := tbsContents
+	if hashFunc != 0 {
+		h := hashFunc.New()
+		h.Write(signed)
+		signed = h.Sum(nil)
+	}
+	var signerOpts crypto.SignerOpts = hashFunc
+	if template.SignatureAlgorithm != 0 && template.SignatureAlgorithm.isRSAPSS() {
+		signerOpts = &rsa.PSSOptions{
+			SaltLength: rsa.PSSSaltLengthEqualsHash,
+			Hash:       hashFunc,
+		}
+	}
+	signature, err := key.Sign(rand, signed, signerOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	basic := ocspBasicResponse{
+		TBSResponseData:    ocspResponseData{Raw: tbsContents},
+		SignatureAlgorithm: signatureAlgorithm,
+		Signature:          asn1.BitString{Bytes: signature, BitLength: len(signature) * 8},
+	}
+	if !responder.Equal(issuer) {
+		basic.Certificates = []asn1.RawValue{{FullBytes: responder.Raw}}
+	}
+	basicBytes, err := asn1.Marshal(basic)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(ocspResponseASN1{
+		Status: asn1.Enumerated(OCSPSuccess),
+		Response: ocspResponseBytes{
+			ResponseType: oidOCSPBasicResponse,
+			Response:     basicBytes,
+		},
+	})
+}
@@ -0,0 +1,145 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package x509
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestOCSPRequestRoundTrip(t *testing.T) {
+	p := newRevocationTestPKI(t)
+	for _, hash := range []crypto.Hash{0, crypto.SHA256} {
+		der, err := CreateOCSPRequest(p.leaf, p.inter, hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, err := ParseOCSPRequest(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := hash
+		if want == 0 {
+			want = crypto.SHA1
+		}
+		if req.HashAlgorithm != want {
+			t.Errorf("HashAlgorithm = %v; want %v", req.HashAlgorithm, want)
+		}
+		if req.SerialNumber.Cmp(p.leaf.SerialNumber) != 0 {
+			t.Errorf("SerialNumber = %v; want %v", req.SerialNumber, p.leaf.SerialNumber)
+		}
+		id, _ := newOCSPCertID(p.leaf.SerialNumber, p.inter, want)
+		if !bytes.Equal(req.IssuerNameHash, id.IssuerNameHash) || !bytes.Equal(req.IssuerKeyHash, id.IssuerKeyHash) {
+			t.Errorf("issuer hashes don't match the issuer")
+		}
+	}
+}
+
+func TestOCSPResponseRoundTrip(t *testing.T) {
+	p := newRevocationTestPKI(t)
+	now := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	ext := pkix.Extension{Id: asn1.ObjectIdentifier{1, 2, 3, 4}, Value: []byte{5, 0}}
+	template := &OCSPResponse{
+		Status:           OCSPRevoked,
+		SerialNumber:     p.leaf.SerialNumber,
+		ProducedAt:       now,
+		ThisUpdate:       now.Add(-time.Hour),
+		NextUpdate:       now.Add(time.Hour),
+		RevokedAt:        now.Add(-24 * time.Hour),
+		RevocationReason: 1, // keyCompromise
+		IssuerHash:       crypto.SHA256,
+		Extensions:       []pkix.Extension{ext},
+	}
+
+	tests := []struct {
+		name      string
+		responder *Certificate
+		key       interface{}
+	}{
+		{"issuer", nil, p.interKey},
+		{"delegated", p.responder, p.responderKey},
+	}
+	for _, tt := range tests {
+		der, err := CreateOCSPResponse(rand.Reader, p.inter, tt.responder, template, tt.key)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		resp, err := ParseOCSPResponse(der, p.inter)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if resp.Status != OCSPRevoked || resp.SerialNumber.Cmp(p.leaf.SerialNumber) != 0 ||
+			!resp.ProducedAt.Equal(now) || !resp.ThisUpdate.Equal(template.ThisUpdate) ||
+			!resp.NextUpdate.Equal(template.NextUpdate) || !resp.RevokedAt.Equal(template.RevokedAt) ||
+			resp.RevocationReason != 1 || resp.IssuerHash != crypto.SHA256 ||
+			len(resp.Extensions) != 1 || !resp.Extensions[0].Id.Equal(ext.Id) {
+			t.Errorf("%s: parsed response %+v doesn't match template", tt.name, resp)
+		}
+		if (resp.Certificate != nil) != (tt.responder != nil) {
+			t.Errorf("%s: Certificate = %v", tt.name, resp.Certificate)
+		}
+		if len(resp.ResponderKeyHash) != 20 {
+			t.Errorf("%s: ResponderKeyHash = %x", tt.name, resp.ResponderKeyHash)
+		}
+
+		if _, err := ParseOCSPResponseForCert(der, p.leaf, p.inter); err != nil {
+			t.Errorf("%s: ParseOCSPResponseForCert: %v", tt.name, err)
+		}
+		if _, err := ParseOCSPResponseForCert(der, p.inter, p.inter); err == nil {
+			t.Errorf("%s: ParseOCSPResponseForCert succeeded for another certificate", tt.name)
+		}
+		if _, err := ParseOCSPResponse(der, p.root); err == nil {
+			t.Errorf("%s: ParseOCSPResponse succeeded with the wrong issuer", tt.name)
+		}
+	}
+}
+
+func TestOCSPResponseBadSigner(t *testing.T) {
+	p := newRevocationTestPKI(t)
+	template := &OCSPResponse{Status: OCSPGood, SerialNumber: p.leaf.SerialNumber, ThisUpdate: time.Now()}
+
+	// The leaf is issued by the intermediate, but not authorized to
+	// sign OCSP responses.
+	der, err := CreateOCSPResponse(rand.Reader, p.inter, p.leaf, template, p.leafKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseOCSPResponse(der, p.inter); err == nil {
+		t.Error("response from unauthorized responder accepted")
+	}
+	// Without an issuer, the signature isn't checked.
+	if _, err := ParseOCSPResponse(der, nil); err != nil {
+		t.Errorf("ParseOCSPResponse without issuer: %v", err)
+	}
+
+	// Signed by the wrong key.
+	der, err = CreateOCSPResponse(rand.Reader, p.inter, nil, template, p.rootKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseOCSPResponse(der, p.inter); err == nil {
+		t.Error("response with bad signature accepted")
+	}
+}
+
+func TestOCSPResponseError(t *testing.T) {
+	der, _ := asn1.Marshal(ocspResponseASN1{Status: asn1.Enumerated(OCSPTryLater)})
+	_, err := ParseOCSPResponse(der, nil)
+	if err != (OCSPResponseError{OCSPTryLater}) {
+		t.Errorf("error = %v; want OCSPResponseError{OCSPTryLater}", err)
+	}
+	if _, err := ParseOCSPResponse([]byte("garbage"), nil); err == nil {
+		t.Error("garbage parsed successfully")
+	}
+	if _, err := CreateOCSPResponse(rand.Reader, nil, nil, &OCSPResponse{Status: 7, SerialNumber: big.NewInt(1)}, nil); err == nil {
+		t.Error("CreateOCSPResponse without a key succeeded")
+	}
+}
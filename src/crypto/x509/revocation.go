@@ -0,0 +1,232 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package x509
+
+import (
+	"bytes"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"strings"
+	"time"
+)
+
+// RevocationStatus is the revocation status of a certificate.
+type RevocationStatus int
+
+const (
+	// RevocationStatusUnknown means that a source has no
+	// information about a certificate.
+	RevocationStatusUnknown RevocationStatus = iota
+	// RevocationStatusGood means that a certificate was not revoked
+	// at the time of the check.
+	RevocationStatusGood
+	// RevocationStatusRevoked means that a certificate was revoked
+	// at or before the time of the check.
+	RevocationStatusRevoked
+)
+
+// A RevocationChecker is a source of revocation information, such as
+// an OCSP client or a cache of CRLs, that can be consulted by
+// Certificate.Verify through VerifyOptions.RevocationCheckers.
+type RevocationChecker interface {
+	// CheckRevocation returns the status of cert, which was issued
+	// by issuer, at the given time. If the checker has no
+	// information about cert, it returns RevocationStatusUnknown,
+	// optionally with an error explaining why.
+	CheckRevocation(cert, issuer *Certificate, now time.Time) (RevocationStatus, error)
+}
+
+// A crlChecker checks certificates against a set of CRLs.
+//
+// Only complete CRLs issued directly by a certificate's issuer are
+// used. CRLs that have expired, are not yet valid, have a bad
+// signature or contain critical extensions, such as those limiting
+// their scope, are ignored.
+type crlChecker []*pkix.CertificateList
+
+func (crls crlChecker) CheckRevocation(cert, issuer *Certificate, now time.Time) (RevocationStatus, error) {
+	// Every usable CRL is consulted, since the issuer may have
+	// published several and only some of them may list cert.
+	status := RevocationStatusUnknown
+	var err error
+	for _, crl := range crls {
+		if !crlIssuedBy(crl, issuer) {
+			continue
+		}
+		if e := checkCRL(crl, issuer, now); e != nil {
+			err = e
+			continue
+		}
+		for _, rc := range crl.TBSCertList.RevokedCertificates {
+			if rc.SerialNumber != nil && rc.SerialNumber.Cmp(cert.SerialNumber) == 0 && !rc.RevocationTime.After(now) {
+				return RevocationStatusRevoked, nil
+			}
+		}
+		status = RevocationStatusGood
+	}
+	if status == RevocationStatusGood {
+		return status, nil
+	}
+	return status, err
+}
+
+// crlIssuedBy reports whether crl names issuer as its issuer.
+func crlIssuedBy(crl *pkix.CertificateList, issuer *Certificate) bool {
+	// Compare the encoded names, as for certificates.
+	var tbs struct {
+		Version   int `asn1:"optional,default:0"`
+		Signature pkix.AlgorithmIdentifier
+		Issuer    asn1.RawValue
+	}
+	if _, err := asn1.Unmarshal(crl.TBSCertList.Raw, &tbs); err != nil {
+		return false
+	}
+	return bytes.Equal(tbs.Issuer.FullBytes, issuer.RawSubject)
+}
+
+// checkCRL returns an error if crl can't be used to check the status of
+// certificates issued by issuer at the given time.
+func checkCRL(crl *pkix.CertificateList, issuer *Certificate, now time.Time) error {
+	if now.Before(crl.TBSCertList.ThisUpdate) {
+		return errors.New("x509: CRL is not yet valid")
+	}
+	if crl.HasExpired(now) {
+		return errors.New("x509: CRL has expired")
+	}
+	for _, ext := range crl.TBSCertList.Extensions {
+		if ext.Critical {
+			return errors.New("x509: CRL has an unhandled critical extension")
+		}
+	}
+	if err := issuer.CheckCRLSignature(crl); err != nil {
+		return errors.New("x509: invalid CRL signature: " + err.Error())
+	}
+	return nil
+}
+
+// An ocspChecker checks certificates against a set of DER-encoded OCSP
+// responses. Responses that are malformed, for other certificates,
+// improperly signed or outside their validity period are ignored.
+type ocspChecker [][]byte
+
+func (responses ocspChecker) CheckRevocation(cert, issuer *Certificate, now time.Time) (RevocationStatus, error) {
+	var err error
+	for _, der := range responses {
+		resp, e := ParseOCSPResponseForCert(der, cert, issuer)
+		if e != nil {
+			err = e
+			continue
+		}
+		if e := checkOCSPResponseTime(resp, now); e != nil {
+			err = e
+			continue
+		}
+		switch resp.Status {
+		case OCSPGood:
+			return RevocationStatusGood, nil
+		case OCSPRevoked:
+			if resp.RevokedAt.After(now) {
+				return RevocationStatusGood, nil
+			}
+			return RevocationStatusRevoked, nil
+		}
+	}
+	return RevocationStatusUnknown, err
+}
+
+// checkOCSPResponseTime returns an error if resp is not valid at the
+// given time.
+func checkOCSPResponseTime(resp *OCSPResponse, now time.Time) error {
+	if now.Before(resp.ThisUpdate) {
+		return errors.New("x509: OCSP response is not yet valid")
+	}
+	if !resp.NextUpdate.IsZero() && now.After(resp.NextUpdate) {
+		return errors.New("x509: OCSP response has expired")
+	}
+	return nil
+}
+
+// checksRevocation reports whether Verify should check the revocation
+// status of chains.
+func (opts *VerifyOptions) checksRevocation() bool {
+	return len(opts.CRLs) > 0 || len(opts.OCSPResponses) > 0 ||
+		len(opts.RevocationCheckers) > 0 || opts.RequireRevocationStatus
+}
+
+// checkRevocation returns the chains in which no certificate has been
+// revoked, or an error if there are none.
+func (opts *VerifyOptions) checkRevocation(chains [][]*Certificate) ([][]*Certificate, error) {
+	now := opts.CurrentTime
+	if now.IsZero() {
+		now = time.Now()
+	}
+	var checkers []RevocationChecker
+	if len(opts.OCSPResponses) > 0 {
+		checkers = append(checkers, ocspChecker(opts.OCSPResponses))
+	}
+	if len(opts.CRLs) > 0 {
+		checkers = append(checkers, crlChecker(opts.CRLs))
+	}
+	checkers = append(checkers, opts.RevocationCheckers...)
+
+	// Chains often share certificates, so remember the outcome of
+	// checking each (certificate, issuer) pair.
+	type pair struct{ cert, issuer *Certificate }
+	checked := make(map[pair]error)
+	check := func(cert, issuer *Certificate) error {
+		p := pair{cert, issuer}
+		if err, ok := checked[p]; ok {
+			return err
+		}
+		err := checkCertRevocation(cert, issuer, now, checkers, opts.RequireRevocationStatus)
+		checked[p] = err
+		return err
+	}
+
+	var (
+		valid    [][]*Certificate
+		firstErr error
+	)
+NextChain:
+	for _, chain := range chains {
+		// The root is trusted directly and has no issuer to ask.
+		for i := 0; i < len(chain)-1; i++ {
+			if err := check(chain[i], chain[i+1]); err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue NextChain
+			}
+		}
+		valid = append(valid, chain)
+	}
+	if len(valid) == 0 {
+		return nil, firstErr
+	}
+	return valid, nil
+}
+
+// checkCertRevocation consults checkers in order until one knows the
+// status of cert.
+func checkCertRevocation(cert, issuer *Certificate, now time.Time, checkers []RevocationChecker, require bool) error {
+	var details []string
+	for _, c := range checkers {
+		status, err := c.CheckRevocation(cert, issuer, now)
+		switch status {
+		case RevocationStatusGood:
+			return nil
+		case RevocationStatusRevoked:
+			return CertificateInvalidError{cert, Revoked, ""}
+		}
+		if err != nil {
+			details = append(details, err.Error())
+		}
+	}
+	if require {
+		return CertificateInvalidError{cert, RevocationUnchecked, strings.Join(details, "; ")}
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package x509
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// revocationTestPKI is a root, an intermediate issued by the root, a
+// leaf issued by the intermediate and an OCSP responder delegated by
+// the intermediate.
+type revocationTestPKI struct {
+	root, inter, leaf, responder             *Certificate
+	rootKey, interKey, leafKey, responderKey *ecdsa.PrivateKey
+}
+
+func newRevocationTestPKI(t *testing.T) *revocationTestPKI {
+	p := new(revocationTestPKI)
+	notBefore := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	notAfter := notBefore.AddDate(10, 0, 0)
+	serial := int64(0)
+	issue := func(name string, isCA bool, ekus []ExtKeyUsage, parent *Certificate, parentKey *ecdsa.PrivateKey) (*Certificate, *ecdsa.PrivateKey) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		serial++
+		template := &Certificate{
+			SerialNumber:          big.NewInt(serial),
+			Subject:               pkix.Name{CommonName: name},
+			NotBefore:             notBefore,
+			NotAfter:              notAfter,
+			BasicConstraintsValid: true,
+			IsCA:        isCA,
+			ExtKeyUsage: ekus,
+			DNSNames:    []string{"example.com"},
+		}
+		if isCA {
+			template.DNSNames = nil
+			template.KeyUsage = KeyUsageCertSign | KeyUsageCRLSign
+		}
+		if parent == nil {
+			parent, parentKey = template, key
+		}
+		der, err := CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cert, err := ParseCertificate(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cert, key
+	}
+	p.root, p.rootKey = issue("Root", true, nil, nil, nil)
+	p.inter, p.interKey = issue("Intermediate", true, nil, p.root, p.rootKey)
+	p.leaf, p.leafKey = issue("Leaf", false, []ExtKeyUsage{ExtKeyUsageServerAuth}, p.inter, p.interKey)
+	p.responder, p.responderKey = issue("Responder", false, []ExtKeyUsage{ExtKeyUsageOCSPSigning}, p.inter, p.interKey)
+	return p
+}
+
+func (p *revocationTestPKI) opts(now time.Time) VerifyOptions {
+	roots, inters := NewCertPool(), NewCertPool()
+	roots.AddCert(p.root)
+	inters.AddCert(p.inter)
+	return VerifyOptions{Roots: roots, Intermediates: inters, CurrentTime: now}
+}
+
+func (p *revocationTestPKI) crl(t *testing.T, issuer *Certificate, key *ecdsa.PrivateKey, now time.Time, revoked ...*Certificate) *pkix.CertificateList {
+	var rcs []pkix.RevokedCertificate
+	for _, c := range revoked {
+		rcs = append(rcs, pkix.RevokedCertificate{SerialNumber: c.SerialNumber, RevocationTime: now.Add(-time.Hour)})
+	}
+	der, err := issuer.CreateCRL(rand.Reader, key, rcs, now.Add(-time.Hour), now.Add(24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	crl, err := ParseDERCRL(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return crl
+}
+
+func (p *revocationTestPKI) ocsp(t *testing.T, cert *Certificate, status OCSPStatus, now time.Time) []byte {
+	der, err := CreateOCSPResponse(rand.Reader, p.inter, p.responder, &OCSPResponse{
+		Status:       status,
+		SerialNumber: cert.SerialNumber,
+		ThisUpdate:   now.Add(-time.Hour),
+		NextUpdate:   now.Add(time.Hour),
+		RevokedAt:    now.Add(-2 * time.Hour),
+	}, p.responderKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+type staticChecker struct {
+	status RevocationStatus
+	err    error
+	calls  int
+}
+
+func (c *staticChecker) CheckRevocation(cert, issuer *Certificate, now time.Time) (RevocationStatus, error) {
+	c.calls++
+	return c.status, c.err
+}
+
+func checkVerifyReason(t *testing.T, name string, err error, reason InvalidReason, cert *Certificate) {
+	t.Helper()
+	cie, ok := err.(CertificateInvalidError)
+	if !ok {
+		t.Errorf("%s: Verify error = %v; want CertificateInvalidError", name, err)
+		return
+	}
+	if cie.Reason != reason {
+		t.Errorf("%s: Verify error reason = %d (%v); want %d", name, cie.Reason, err, reason)
+	}
+	if cie.Cert != cert {
+		t.Errorf("%s: Verify error is for %q; want %q", name, cie.Cert.Subject.CommonName, cert.Subject.CommonName)
+	}
+}
+
+func TestVerifyCRL(t *testing.T) {
+	p := newRevocationTestPKI(t)
+	now := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	opts := p.opts(now)
+	opts.CRLs = []*pkix.CertificateList{
+		p.crl(t, p.root, p.rootKey, now),
+		p.crl(t, p.inter, p.interKey, now),
+	}
+	if _, err := p.leaf.Verify(opts); err != nil {
+		t.Errorf("unrevoked leaf: %v", err)
+	}
+
+	opts.CRLs[1] = p.crl(t, p.inter, p.interKey, now, p.leaf)
+	_, err := p.leaf.Verify(opts)
+	checkVerifyReason(t, "revoked leaf", err, Revoked, p.leaf)
+
+	opts.CRLs = []*pkix.CertificateList{
+		p.crl(t, p.root, p.rootKey, now, p.inter),
+		p.crl(t, p.inter, p.interKey, now),
+	}
+	_, err = p.leaf.Verify(opts)
+	checkVerifyReason(t, "revoked intermediate", err, Revoked, p.inter)
+
+	// A newer CRL revoking the leaf is found after an older one that
+	// doesn't list it.
+	opts.CRLs = []*pkix.CertificateList{
+		p.crl(t, p.inter, p.interKey, now.Add(-30*time.Minute)),
+		p.crl(t, p.inter, p.interKey, now, p.leaf),
+	}
+	_, err = p.leaf.Verify(opts)
+	checkVerifyReason(t, "old CRL then revoking CRL", err, Revoked, p.leaf)
+
+	// Before the revocation, the certificate was good.
+	opts.CurrentTime = now.Add(-90 * time.Minute)
+	opts.CRLs = []*pkix.CertificateList{p.crl(t, p.inter, p.interKey, now.Add(-90*time.Minute), p.leaf)}
+	opts.CRLs[0].TBSCertList.RevokedCertificates[0].RevocationTime = now
+	if _, err := p.leaf.Verify(opts); err != nil {
+		t.Errorf("leaf before revocation time: %v", err)
+	}
+
+	// A CRL signed by the wrong key, or expired, is ignored.
+	opts.CurrentTime = now
+	opts.CRLs = []*pkix.CertificateList{p.crl(t, p.inter, p.leafKey, now, p.leaf)}
+	if _, err := p.leaf.Verify(opts); err != nil {
+		t.Errorf("CRL with bad signature: %v", err)
+	}
+	opts.CRLs = []*pkix.CertificateList{p.crl(t, p.inter, p.interKey, now, p.leaf)}
+	opts.CurrentTime = now.Add(48 * time.Hour)
+	if _, err := p.leaf.Verify(opts); err != nil {
+		t.Errorf("expired CRL: %v", err)
+	}
+	opts.RequireRevocationStatus = true
+	_, err = p.leaf.Verify(opts)
+	checkVerifyReason(t, "expired CRL, status required", err, RevocationUnchecked, p.leaf)
+}
+
+func TestVerifyOCSP(t *testing.T) {
+	p := newRevocationTestPKI(t)
+	now := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	opts := p.opts(now)
+	opts.OCSPResponses = [][]byte{p.ocsp(t, p.leaf, OCSPGood, now)}
+	if _, err := p.leaf.Verify(opts); err != nil {
+		t.Errorf("good leaf: %v", err)
+	}
+
+	opts.OCSPResponses = [][]byte{p.ocsp(t, p.leaf, OCSPRevoked, now)}
+	_, err := p.leaf.Verify(opts)
+	checkVerifyReason(t, "revoked leaf", err, Revoked, p.leaf)
+
+	// An OCSP response takes precedence over a CRL.
+	opts.OCSPResponses = [][]byte{p.ocsp(t, p.leaf, OCSPGood, now)}
+	opts.CRLs = []*pkix.CertificateList{p.crl(t, p.inter, p.interKey, now, p.leaf)}
+	if _, err := p.leaf.Verify(opts); err != nil {
+		t.Errorf("good OCSP response and revoking CRL: %v", err)
+	}
+	opts.CRLs = nil
+
+	// An OCSP unknown status, or an expired response, leaves the
+	// status unknown, which is fatal only if required.
+	for _, der := range [][]byte{
+		p.ocsp(t, p.leaf, OCSPUnknown, now),
+		p.ocsp(t, p.leaf, OCSPRevoked, now.Add(-3*time.Hour)),
+		p.ocsp(t, p.inter, OCSPRevoked, now), // wrong certificate
+	} {
+		opts.OCSPResponses = [][]byte{der}
+		opts.RequireRevocationStatus = false
+		if _, err := p.leaf.Verify(opts); err != nil {
+			t.Errorf("unknown status: %v", err)
+		}
+		opts.RequireRevocationStatus = true
+		_, err = p.leaf.Verify(opts)
+		checkVerifyReason(t, "unknown status, required", err, RevocationUnchecked, p.leaf)
+	}
+}
+
+func TestVerifyRevocationCheckers(t *testing.T) {
+	p := newRevocationTestPKI(t)
+	now := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	unknown := &staticChecker{status: RevocationStatusUnknown, err: errors.New("responder unreachable")}
+	good := &staticChecker{status: RevocationStatusGood}
+	revoked := &staticChecker{status: RevocationStatusRevoked}
+
+	opts := p.opts(now)
+	opts.RevocationCheckers = []RevocationChecker{unknown, good, revoked}
+	if _, err := p.leaf.Verify(opts); err != nil {
+		t.Errorf("Verify: %v", err)
+	}
+	// The leaf and intermediate are checked; the root is not.
+	if unknown.calls != 2 || good.calls != 2 || revoked.calls != 0 {
+		t.Errorf("checker calls = %d, %d, %d; want 2, 2, 0", unknown.calls, good.calls, revoked.calls)
+	}
+
+	opts.RevocationCheckers = []RevocationChecker{unknown, revoked, good}
+	_, err := p.leaf.Verify(opts)
+	checkVerifyReason(t, "revoked", err, Revoked, p.leaf)
+
+	opts.RevocationCheckers = []RevocationChecker{unknown}
+	opts.RequireRevocationStatus = true
+	_, err = p.leaf.Verify(opts)
+	checkVerifyReason(t, "unknown", err, RevocationUnchecked, p.leaf)
+	if got, want := err.Error(), "x509: certificate revocation status unknown: responder unreachable"; got != want {
+		t.Errorf("error = %q; want %q", got, want)
+	}
+}
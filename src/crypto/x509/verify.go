@@ -6,6 +6,7 @@ package x509
 
 import (
 	"bytes"
+	"crypto/x509/pkix"
 	"errors"
 	"fmt"
 	"net"
@@ -57,6 +58,12 @@ const (
 	// certificate does not permit an extended key usage that is claimed by
 	// the leaf certificate.
 	CANotAuthorizedForExtKeyUsage
+	// Revoked results when a certificate has been revoked according
+	// to the revocation information given in the VerifyOptions.
+	Revoked
+	// RevocationUnchecked results when VerifyOptions.RequireRevocationStatus
+	// is set and the revocation status of a certificate is unknown.
+	RevocationUnchecked
 )
 
 // CertificateInvalidError results when an odd error occurs. Users of this
@@ -87,6 +94,13 @@ func (e CertificateInvalidError) Error() string {
 		return "x509: issuer has name constraints but leaf doesn't have a SAN extension"
 	case UnconstrainedName:
 		return "x509: issuer has name constraints but leaf contains unknown or unconstrained name: " + e.Detail
+	case Revoked:
+		return "x509: certificate has been revoked"
+	case RevocationUnchecked:
+		if e.Detail == "" {
+			return "x509: certificate revocation status unknown"
+		}
+		return "x509: certificate revocation status unknown: " + e.Detail
 	}
 	return "x509: unknown error"
 }
@@ -189,6 +203,22 @@ type VerifyOptions struct {
 	// certificates from consuming excessive amounts of CPU time when
 	// validating.
 	MaxConstraintComparisions int
+
+	// CRLs, OCSPResponses and RevocationCheckers are sources of
+	// revocation information. If any are set, the revocation status
+	// of every certificate in a chain other than the root is looked
+	// up in OCSPResponses, then CRLs, then each RevocationChecker, in
+	// order, until one of them knows it. Chains containing a revoked
+	// certificate are rejected.
+	CRLs []*pkix.CertificateList
+	// OCSPResponses are DER-encoded OCSP responses, such as one
+	// stapled to a TLS handshake.
+	OCSPResponses      [][]byte
+	RevocationCheckers []RevocationChecker
+	// RequireRevocationStatus causes chains to be rejected if the
+	// revocation status of any certificate other than the root is
+	// unknown.
+	RequireRevocationStatus bool
 }
 
 const (
@@ -804,7 +834,8 @@ func (c *Certificate) isValid(certType int, currentChain []*Certificate, opts *V
 // root that enumerates EKUs prevents a leaf from asserting an EKU not in that
 // list.
 //
-// WARNING: this function doesn't do any revocation checking.
+// Revocation is checked only against the sources given in opts. No
+// network requests are made to fetch CRLs or OCSP responses.
 func (c *Certificate) Verify(opts VerifyOptions) (chains [][]*Certificate, err error) {
 	// Platform-specific verification needs the ASN.1 contents so
 	// this makes the behavior consistent across platforms.
@@ -821,7 +852,11 @@ func (c *Certificate) Verify(opts VerifyOptions) (chains [][]*Certificate, err e
 
 	// Use Windows's own verification and chain building.
 	if opts.Roots == nil && runtime.GOOS == "windows" {
-		return c.systemVerify(&opts)
+		chains, err = c.systemVerify(&opts)
+		if err != nil || !opts.checksRevocation() {
+			return chains, err
+		}
+		return opts.checkRevocation(chains)
 	}
 
 	if opts.Roots == nil {
@@ -882,6 +917,9 @@ func (c *Certificate) Verify(opts VerifyOptions) (chains [][]*Certificate, err e
 		}
 	}
 
+	if opts.checksRevocation() {
+		return opts.checkRevocation(candidateChains)
+	}
 	return candidateChains, nil
 }
 
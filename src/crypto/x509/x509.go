@@ -14,6 +14,7 @@ import (
 	"crypto"
 	"crypto/dsa"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rsa"
 	_ "crypto/sha1"
@@ -31,6 +32,7 @@ import (
 	"strconv"
 	"strings"
 	"time"
+	"unicode/utf16"
 	"unicode/utf8"
 
 	"golang_org/x/crypto/cryptobyte"
@@ -47,11 +49,11 @@ type pkixPublicKey struct {
 // ParsePKIXPublicKey parses a DER encoded public key. These values are
 // typically found in PEM blocks with "BEGIN PUBLIC KEY".
 //
-// Supported key types include RSA, DSA, and ECDSA. Unknown key
+// Supported key types include RSA, DSA, ECDSA, and Ed25519. Unknown key
 // types result in an error.
 //
 // On success, pub will be of type *rsa.PublicKey, *dsa.PublicKey,
-// or *ecdsa.PublicKey.
+// *ecdsa.PublicKey, or ed25519.PublicKey.
 func ParsePKIXPublicKey(derBytes []byte) (pub interface{}, err error) {
 	var pki publicKeyInfo
 	if rest, err := asn1.Unmarshal(derBytes, &pki); err != nil {
@@ -93,8 +95,11 @@ func marshalPublicKey(pub interface{}) (publicKeyBytes []byte, publicKeyAlgorith
 			return
 		}
 		publicKeyAlgorithm.Parameters.FullBytes = paramBytes
+	case ed25519.PublicKey:
+		publicKeyBytes = pub
+		publicKeyAlgorithm.Algorithm = oidPublicKeyEd25519
 	default:
-		return nil, pkix.AlgorithmIdentifier{}, errors.New("x509: only RSA and ECDSA public keys supported")
+		return nil, pkix.AlgorithmIdentifier{}, errors.New("x509: only RSA, ECDSA and Ed25519 public keys supported")
 	}
 
 	return publicKeyBytes, publicKeyAlgorithm, nil
@@ -189,6 +194,7 @@ const (
 	SHA256WithRSAPSS
 	SHA384WithRSAPSS
 	SHA512WithRSAPSS
+	PureEd25519
 )
 
 func (algo SignatureAlgorithm) isRSAPSS() bool {
@@ -216,12 +222,14 @@ const (
 	RSA
 	DSA
 	ECDSA
+	Ed25519
 )
 
 var publicKeyAlgoName = [...]string{
-	RSA:   "RSA",
-	DSA:   "DSA",
-	ECDSA: "ECDSA",
+	RSA:     "RSA",
+	DSA:     "DSA",
+	ECDSA:   "ECDSA",
+	Ed25519: "Ed25519",
 }
 
 func (algo PublicKeyAlgorithm) String() string {
@@ -280,6 +288,11 @@ func (algo PublicKeyAlgorithm) String() string {
 //
 // ecdsa-with-SHA512 OBJECT IDENTIFIER ::= { iso(1) member-body(2)
 //    us(840) ansi-X9-62(10045) signatures(4) ecdsa-with-SHA2(3) 4 }
+//
+//
+// RFC 8410 3 Curve25519 and Curve448 Algorithm Identifiers
+//
+// id-Ed25519   OBJECT IDENTIFIER ::= { 1 3 101 112 }
 
 var (
 	oidSignatureMD2WithRSA      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 2}
@@ -295,6 +308,7 @@ var (
 	oidSignatureECDSAWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
 	oidSignatureECDSAWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 3}
 	oidSignatureECDSAWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 4}
+	oidSignatureEd25519         = asn1.ObjectIdentifier{1, 3, 101, 112}
 
 	oidSHA256 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
 	oidSHA384 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
@@ -331,6 +345,7 @@ var signatureAlgorithmDetails = []struct {
 	{ECDSAWithSHA256, "ECDSA-SHA256", oidSignatureECDSAWithSHA256, ECDSA, crypto.SHA256},
 	{ECDSAWithSHA384, "ECDSA-SHA384", oidSignatureECDSAWithSHA384, ECDSA, crypto.SHA384},
 	{ECDSAWithSHA512, "ECDSA-SHA512", oidSignatureECDSAWithSHA512, ECDSA, crypto.SHA512},
+	{PureEd25519, "Ed25519", oidSignatureEd25519, Ed25519, crypto.Hash(0) /* no pre-hashing */},
 }
 
 // pssParameters reflects the parameters in an AlgorithmIdentifier that
@@ -346,8 +361,8 @@ type pssParameters struct {
 }
 
 // rsaPSSParameters returns an asn1.RawValue suitable for use as the Parameters
-// in an AlgorithmIdentifier that specifies RSA PSS.
-func rsaPSSParameters(hashFunc crypto.Hash) asn1.RawValue {
+// in an AlgorithmIdentifier that specifies RSA PSS with the given salt length.
+func rsaPSSParameters(hashFunc crypto.Hash, saltLength int) asn1.RawValue {
 	var hashOID asn1.ObjectIdentifier
 
 	switch hashFunc {
@@ -367,7 +382,7 @@ func rsaPSSParameters(hashFunc crypto.Hash) asn1.RawValue {
 		MGF: pkix.AlgorithmIdentifier{
 			Algorithm: oidMGF1,
 		},
-		SaltLength:   hashFunc.Size(),
+		SaltLength:   saltLength,
 		TrailerField: 1,
 	}
 
@@ -390,14 +405,35 @@ func rsaPSSParameters(hashFunc crypto.Hash) asn1.RawValue {
 	return asn1.RawValue{FullBytes: serialized}
 }
 
+// pssSaltLength returns the salt length with which to sign using algo,
+// given the PSSSaltLength of a template, and sets the parameters of ai
+// to match.
+func pssSaltLength(algo SignatureAlgorithm, templateSaltLength int, hashFunc crypto.Hash, ai *pkix.AlgorithmIdentifier) (int, error) {
+	if !algo.isRSAPSS() || templateSaltLength == 0 {
+		return rsa.PSSSaltLengthEqualsHash, nil
+	}
+	if templateSaltLength < 0 {
+		return 0, errors.New("x509: negative PSSSaltLength")
+	}
+	ai.Parameters = rsaPSSParameters(hashFunc, templateSaltLength)
+	return templateSaltLength, nil
+}
+
 func getSignatureAlgorithmFromAI(ai pkix.AlgorithmIdentifier) SignatureAlgorithm {
+	algo, _ := getSignatureAlgorithmAndSaltFromAI(ai)
+	return algo
+}
+
+// getSignatureAlgorithmAndSaltFromAI is like getSignatureAlgorithmFromAI,
+// but also returns the salt length of an RSA PSS algorithm.
+func getSignatureAlgorithmAndSaltFromAI(ai pkix.AlgorithmIdentifier) (SignatureAlgorithm, int) {
 	if !ai.Algorithm.Equal(oidSignatureRSAPSS) {
 		for _, details := range signatureAlgorithmDetails {
 			if ai.Algorithm.Equal(details.oid) {
-				return details.algo
+				return details.algo, 0
 			}
 		}
-		return UnknownSignatureAlgorithm
+		return UnknownSignatureAlgorithm, 0
 	}
 
 	// RSA PSS is special because it encodes important parameters
@@ -405,39 +441,40 @@ func getSignatureAlgorithmFromAI(ai pkix.AlgorithmIdentifier) SignatureAlgorithm
 
 	var params pssParameters
 	if _, err := asn1.Unmarshal(ai.Parameters.FullBytes, &params); err != nil {
-		return UnknownSignatureAlgorithm
+		return UnknownSignatureAlgorithm, 0
 	}
 
 	var mgf1HashFunc pkix.AlgorithmIdentifier
 	if _, err := asn1.Unmarshal(params.MGF.Parameters.FullBytes, &mgf1HashFunc); err != nil {
-		return UnknownSignatureAlgorithm
+		return UnknownSignatureAlgorithm, 0
 	}
 
 	// PSS is greatly overburdened with options. This code forces
 	// them into three buckets by requiring that the MGF1 hash
 	// function always match the message hash function (as
 	// recommended in
-	// https://tools.ietf.org/html/rfc3447#section-8.1), that the
-	// salt length matches the hash length, and that the trailer
-	// field has the default value.
+	// https://tools.ietf.org/html/rfc3447#section-8.1) and that the
+	// trailer field has the default value. The salt length is
+	// returned separately, and is checked when verifying. An empty
+	// salt is not supported, since rsa.VerifyPSS can't require one.
 	if !bytes.Equal(params.Hash.Parameters.FullBytes, asn1.NullBytes) ||
 		!params.MGF.Algorithm.Equal(oidMGF1) ||
 		!mgf1HashFunc.Algorithm.Equal(params.Hash.Algorithm) ||
 		!bytes.Equal(mgf1HashFunc.Parameters.FullBytes, asn1.NullBytes) ||
-		params.TrailerField != 1 {
-		return UnknownSignatureAlgorithm
+		params.TrailerField != 1 || params.SaltLength <= 0 {
+		return UnknownSignatureAlgorithm, 0
 	}
 
 	switch {
-	case params.Hash.Algorithm.Equal(oidSHA256) && params.SaltLength == 32:
-		return SHA256WithRSAPSS
-	case params.Hash.Algorithm.Equal(oidSHA384) && params.SaltLength == 48:
-		return SHA384WithRSAPSS
-	case params.Hash.Algorithm.Equal(oidSHA512) && params.SaltLength == 64:
-		return SHA512WithRSAPSS
+	case params.Hash.Algorithm.Equal(oidSHA256):
+		return SHA256WithRSAPSS, params.SaltLength
+	case params.Hash.Algorithm.Equal(oidSHA384):
+		return SHA384WithRSAPSS, params.SaltLength
+	case params.Hash.Algorithm.Equal(oidSHA512):
+		return SHA512WithRSAPSS, params.SaltLength
 	}
 
-	return UnknownSignatureAlgorithm
+	return UnknownSignatureAlgorithm, 0
 }
 
 // RFC 3279, 2.3 Public Key Algorithms
@@ -454,10 +491,15 @@ func getSignatureAlgorithmFromAI(ai pkix.AlgorithmIdentifier) SignatureAlgorithm
 //
 // id-ecPublicKey OBJECT IDENTIFIER ::= {
 //       iso(1) member-body(2) us(840) ansi-X9-62(10045) keyType(2) 1 }
+//
+// RFC 8410, 3 Curve25519 and Curve448 Algorithm Identifiers
+//
+// id-Ed25519   OBJECT IDENTIFIER ::= { 1 3 101 112 }
 var (
-	oidPublicKeyRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
-	oidPublicKeyDSA   = asn1.ObjectIdentifier{1, 2, 840, 10040, 4, 1}
-	oidPublicKeyECDSA = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	oidPublicKeyRSA     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidPublicKeyDSA     = asn1.ObjectIdentifier{1, 2, 840, 10040, 4, 1}
+	oidPublicKeyECDSA   = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+	oidPublicKeyEd25519 = oidSignatureEd25519
 )
 
 func getPublicKeyAlgorithmFromOID(oid asn1.ObjectIdentifier) PublicKeyAlgorithm {
@@ -468,6 +510,8 @@ func getPublicKeyAlgorithmFromOID(oid asn1.ObjectIdentifier) PublicKeyAlgorithm
 		return DSA
 	case oid.Equal(oidPublicKeyECDSA):
 		return ECDSA
+	case oid.Equal(oidPublicKeyEd25519):
+		return Ed25519
 	}
 	return UnknownPublicKeyAlgorithm
 }
@@ -640,6 +684,15 @@ type Certificate struct {
 	Signature          []byte
 	SignatureAlgorithm SignatureAlgorithm
 
+	// PSSSaltLength is the salt length, in bytes, of an RSA-PSS
+	// signature. When parsing, it is populated from the signature
+	// parameters if SignatureAlgorithm is an RSA-PSS algorithm;
+	// signatures declaring an empty salt are not supported and parse
+	// as UnknownSignatureAlgorithm. When creating a certificate with
+	// an RSA-PSS SignatureAlgorithm, zero means a salt as long as the
+	// hash output.
+	PSSSaltLength int
+
 	PublicKeyAlgorithm PublicKeyAlgorithm
 	PublicKey          interface{}
 
@@ -729,6 +782,35 @@ type Certificate struct {
 	CRLDistributionPoints []string
 
 	PolicyIdentifiers []asn1.ObjectIdentifier
+
+	// Policies are the certificate policies together with their
+	// qualifiers. When parsing, it contains an element for each of
+	// PolicyIdentifiers. When creating a certificate, any of
+	// PolicyIdentifiers that do not appear in Policies are also
+	// included, without qualifiers.
+	Policies []PolicyInformation
+}
+
+// PolicyInformation is a certificate policy and its qualifiers, as
+// described in RFC 5280, 4.2.1.4.
+type PolicyInformation struct {
+	Policy asn1.ObjectIdentifier
+
+	// CPSURIs point to certification practice statements published
+	// by the issuer.
+	CPSURIs []string
+
+	// UserNotices are intended to be displayed to relying parties.
+	UserNotices []UserNotice
+}
+
+// UserNotice is a user notice policy qualifier. Either or both of a
+// notice reference, made up of Organization and NoticeNumbers, and an
+// ExplicitText may be present.
+type UserNotice struct {
+	Organization  string
+	NoticeNumbers []int
+	ExplicitText  string
 }
 
 // ErrUnsupportedAlgorithm results from attempting to perform an operation that
@@ -833,11 +915,15 @@ func (c *Certificate) CheckSignatureFrom(parent *Certificate) error {
 
 	// TODO(agl): don't ignore the path length constraint.
 
+	if c.SignatureAlgorithm.isRSAPSS() {
+		return checkSignatureWithPSSSaltLength(c.SignatureAlgorithm, c.RawTBSCertificate, c.Signature, parent.PublicKey, c.PSSSaltLength)
+	}
 	return parent.CheckSignature(c.SignatureAlgorithm, c.RawTBSCertificate, c.Signature)
 }
 
 // CheckSignature verifies that signature is a valid signature over signed from
-// c's public key.
+// c's public key. RSA-PSS signatures must use a salt as long as the hash
+// output.
 func (c *Certificate) CheckSignature(algo SignatureAlgorithm, signed, signature []byte) error {
 	return checkSignature(algo, signed, signature, c.PublicKey)
 }
@@ -869,6 +955,17 @@ func signaturePublicKeyAlgoMismatchError(expectedPubKeyAlgo PublicKeyAlgorithm,
 // CheckSignature verifies that signature is a valid signature over signed from
 // a crypto.PublicKey.
 func checkSignature(algo SignatureAlgorithm, signed, signature []byte, publicKey crypto.PublicKey) (err error) {
+	return checkSignatureWithPSSSaltLength(algo, signed, signature, publicKey, rsa.PSSSaltLengthEqualsHash)
+}
+
+// checkSignatureWithPSSSaltLength is like checkSignature, but checks the salt
+// of RSA-PSS signatures against saltLength. As in the PSSSaltLength fields,
+// zero means a salt as long as the hash output.
+func checkSignatureWithPSSSaltLength(algo SignatureAlgorithm, signed, signature []byte, publicKey crypto.PublicKey, saltLength int) (err error) {
+	if saltLength == 0 {
+		// Never pass rsa.PSSSaltLengthAuto on, which accepts any salt.
+		saltLength = rsa.PSSSaltLengthEqualsHash
+	}
 	var hashType crypto.Hash
 	var pubKeyAlgo PublicKeyAlgorithm
 
@@ -881,28 +978,30 @@ func checkSignature(algo SignatureAlgorithm, signed, signature []byte, publicKey
 
 	switch hashType {
 	case crypto.Hash(0):
-		return ErrUnsupportedAlgorithm
+		// Ed25519 signs the message itself rather than a digest.
+		if pubKeyAlgo != Ed25519 {
+			return ErrUnsupportedAlgorithm
+		}
 	case crypto.MD5:
 		return InsecureAlgorithmError(algo)
+	default:
+		if !hashType.Available() {
+			return ErrUnsupportedAlgorithm
+		}
+		h := hashType.New()
+		h.Write(signed)
+		signed = h.Sum(nil)
 	}
 
-	if !hashType.Available() {
-		return ErrUnsupportedAlgorithm
-	}
-	h := hashType.New()
-
-	h.Write(signed)
-	digest := h.Sum(nil)
-
 	switch pub := publicKey.(type) {
 	case *rsa.PublicKey:
 		if pubKeyAlgo != RSA {
 			return signaturePublicKeyAlgoMismatchError(pubKeyAlgo, pub)
 		}
 		if algo.isRSAPSS() {
-			return rsa.VerifyPSS(pub, hashType, digest, signature, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+			return rsa.VerifyPSS(pub, hashType, signed, signature, &rsa.PSSOptions{SaltLength: saltLength})
 		} else {
-			return rsa.VerifyPKCS1v15(pub, hashType, digest, signature)
+			return rsa.VerifyPKCS1v15(pub, hashType, signed, signature)
 		}
 	case *dsa.PublicKey:
 		if pubKeyAlgo != DSA {
@@ -917,7 +1016,7 @@ func checkSignature(algo SignatureAlgorithm, signed, signature []byte, publicKey
 		if dsaSig.R.Sign() <= 0 || dsaSig.S.Sign() <= 0 {
 			return errors.New("x509: DSA signature contained zero or negative values")
 		}
-		if !dsa.Verify(pub, digest, dsaSig.R, dsaSig.S) {
+		if !dsa.Verify(pub, signed, dsaSig.R, dsaSig.S) {
 			return errors.New("x509: DSA verification failure")
 		}
 		return
@@ -934,18 +1033,26 @@ func checkSignature(algo SignatureAlgorithm, signed, signature []byte, publicKey
 		if ecdsaSig.R.Sign() <= 0 || ecdsaSig.S.Sign() <= 0 {
 			return errors.New("x509: ECDSA signature contained zero or negative values")
 		}
-		if !ecdsa.Verify(pub, digest, ecdsaSig.R, ecdsaSig.S) {
+		if !ecdsa.Verify(pub, signed, ecdsaSig.R, ecdsaSig.S) {
 			return errors.New("x509: ECDSA verification failure")
 		}
 		return
+	case ed25519.PublicKey:
+		if pubKeyAlgo != Ed25519 {
+			return signaturePublicKeyAlgoMismatchError(pubKeyAlgo, pub)
+		}
+		if !ed25519.Verify(pub, signed, signature) {
+			return errors.New("x509: Ed25519 verification failure")
+		}
+		return
 	}
 	return ErrUnsupportedAlgorithm
 }
 
 // CheckCRLSignature checks that the signature in crl is from c.
 func (c *Certificate) CheckCRLSignature(crl *pkix.CertificateList) error {
-	algo := getSignatureAlgorithmFromAI(crl.SignatureAlgorithm)
-	return c.CheckSignature(algo, crl.TBSCertList.Raw, crl.SignatureValue.RightAlign())
+	algo, saltLength := getSignatureAlgorithmAndSaltFromAI(crl.SignatureAlgorithm)
+	return checkSignatureWithPSSSaltLength(algo, crl.TBSCertList.Raw, crl.SignatureValue.RightAlign(), c.PublicKey, saltLength)
 }
 
 type UnhandledCriticalExtension struct{}
@@ -961,8 +1068,130 @@ type basicConstraints struct {
 
 // RFC 5280 4.2.1.4
 type policyInformation struct {
-	Policy asn1.ObjectIdentifier
-	// policyQualifiers omitted
+	Policy     asn1.ObjectIdentifier
+	Qualifiers []policyQualifierInfo `asn1:"optional"`
+}
+
+type policyQualifierInfo struct {
+	PolicyQualifierId asn1.ObjectIdentifier
+	Qualifier         asn1.RawValue
+}
+
+type userNotice struct {
+	NoticeRef    noticeReference `asn1:"optional"`
+	ExplicitText asn1.RawValue   `asn1:"optional"`
+}
+
+type noticeReference struct {
+	Organization  asn1.RawValue
+	NoticeNumbers []int
+}
+
+// marshalPolicies returns the policyInformation sequence for the given
+// policies.
+func marshalPolicies(policies []PolicyInformation, identifiers []asn1.ObjectIdentifier) ([]policyInformation, error) {
+	var ret []policyInformation
+	for _, p := range policies {
+		info := policyInformation{Policy: p.Policy}
+		for _, uri := range p.CPSURIs {
+			if err := isIA5String(uri); err != nil {
+				return nil, err
+			}
+			info.Qualifiers = append(info.Qualifiers, policyQualifierInfo{
+				PolicyQualifierId: oidPolicyQualifierCPS,
+				Qualifier:         asn1.RawValue{Tag: asn1.TagIA5String, Bytes: []byte(uri)},
+			})
+		}
+		for _, n := range p.UserNotices {
+			var un userNotice
+			if len(n.Organization) > 0 || len(n.NoticeNumbers) > 0 {
+				un.NoticeRef = noticeReference{
+					Organization:  asn1.RawValue{Tag: asn1.TagUTF8String, Bytes: []byte(n.Organization)},
+					NoticeNumbers: n.NoticeNumbers,
+				}
+			}
+			if len(n.ExplicitText) > 0 {
+				un.ExplicitText = asn1.RawValue{Tag: asn1.TagUTF8String, Bytes: []byte(n.ExplicitText)}
+			}
+			b, err := asn1.Marshal(un)
+			if err != nil {
+				return nil, err
+			}
+			info.Qualifiers = append(info.Qualifiers, policyQualifierInfo{
+				PolicyQualifierId: oidPolicyQualifierUserNotice,
+				Qualifier:         asn1.RawValue{FullBytes: b},
+			})
+		}
+		ret = append(ret, info)
+	}
+
+NextIdentifier:
+	for _, id := range identifiers {
+		for _, p := range policies {
+			if p.Policy.Equal(id) {
+				continue NextIdentifier
+			}
+		}
+		ret = append(ret, policyInformation{Policy: id})
+	}
+	return ret, nil
+}
+
+// parsePolicy returns the PolicyInformation for info. Qualifiers of
+// unknown types are ignored.
+func parsePolicy(info policyInformation) (PolicyInformation, error) {
+	ret := PolicyInformation{Policy: info.Policy}
+	for _, q := range info.Qualifiers {
+		switch {
+		case q.PolicyQualifierId.Equal(oidPolicyQualifierCPS):
+			if q.Qualifier.Tag != asn1.TagIA5String {
+				return ret, errors.New("x509: invalid CPS URI policy qualifier")
+			}
+			ret.CPSURIs = append(ret.CPSURIs, string(q.Qualifier.Bytes))
+		case q.PolicyQualifierId.Equal(oidPolicyQualifierUserNotice):
+			var un userNotice
+			if rest, err := asn1.Unmarshal(q.Qualifier.FullBytes, &un); err != nil {
+				return ret, err
+			} else if len(rest) != 0 {
+				return ret, errors.New("x509: trailing data after user notice policy qualifier")
+			}
+			var n UserNotice
+			var err error
+			if len(un.NoticeRef.Organization.FullBytes) > 0 {
+				if n.Organization, err = parseDisplayText(un.NoticeRef.Organization); err != nil {
+					return ret, err
+				}
+				n.NoticeNumbers = un.NoticeRef.NoticeNumbers
+			}
+			if len(un.ExplicitText.FullBytes) > 0 {
+				if n.ExplicitText, err = parseDisplayText(un.ExplicitText); err != nil {
+					return ret, err
+				}
+			}
+			ret.UserNotices = append(ret.UserNotices, n)
+		}
+	}
+	return ret, nil
+}
+
+// parseDisplayText decodes a DisplayText string of RFC 5280, 4.2.1.4.
+func parseDisplayText(v asn1.RawValue) (string, error) {
+	if v.Class == asn1.ClassUniversal {
+		switch v.Tag {
+		case asn1.TagIA5String, asn1.TagUTF8String, 26 /* VisibleString */ :
+			return string(v.Bytes), nil
+		case 30: // BMPString
+			if len(v.Bytes)%2 != 0 {
+				return "", errors.New("x509: invalid BMPString in policy qualifier")
+			}
+			s := make([]uint16, len(v.Bytes)/2)
+			for i := range s {
+				s[i] = uint16(v.Bytes[2*i])<<8 | uint16(v.Bytes[2*i+1])
+			}
+			return string(utf16.Decode(s)), nil
+		}
+	}
+	return "", errors.New("x509: invalid DisplayText in policy qualifier")
 }
 
 const (
@@ -1075,6 +1304,18 @@ func parsePublicKey(algo PublicKeyAlgorithm, keyData *publicKeyInfo) (interface{
 			Y:     y,
 		}
 		return pub, nil
+	case Ed25519:
+		// RFC 8410, Section 3
+		// > For all of the OIDs, the parameters MUST be absent.
+		if len(keyData.Algorithm.Parameters.FullBytes) != 0 {
+			return nil, errors.New("x509: Ed25519 key encoded with illegal parameters")
+		}
+		if len(asn1Data) != ed25519.PublicKeySize {
+			return nil, errors.New("x509: wrong Ed25519 public key size")
+		}
+		pub := make([]byte, ed25519.PublicKeySize)
+		copy(pub, asn1Data)
+		return ed25519.PublicKey(pub), nil
 	default:
 		return nil, nil
 	}
@@ -1351,8 +1592,8 @@ func parseCertificate(in *certificate) (*Certificate, error) {
 	out.RawIssuer = in.TBSCertificate.Issuer.FullBytes
 
 	out.Signature = in.SignatureValue.RightAlign()
-	out.SignatureAlgorithm =
-		getSignatureAlgorithmFromAI(in.TBSCertificate.SignatureAlgorithm)
+	out.SignatureAlgorithm, out.PSSSaltLength =
+		getSignatureAlgorithmAndSaltFromAI(in.TBSCertificate.SignatureAlgorithm)
 
 	out.PublicKeyAlgorithm =
 		getPublicKeyAlgorithmFromOID(in.TBSCertificate.PublicKey.Algorithm.Algorithm)
@@ -1524,8 +1765,12 @@ func parseCertificate(in *certificate) (*Certificate, error) {
 					return nil, errors.New("x509: trailing data after X.509 certificate policies")
 				}
 				out.PolicyIdentifiers = make([]asn1.ObjectIdentifier, len(policies))
+				out.Policies = make([]PolicyInformation, len(policies))
 				for i, policy := range policies {
 					out.PolicyIdentifiers[i] = policy.Policy
+					if out.Policies[i], err = parsePolicy(policy); err != nil {
+						return nil, err
+					}
 				}
 
 			default:
@@ -1651,6 +1896,11 @@ var (
 	oidAuthorityInfoAccessIssuers = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 2}
 )
 
+var (
+	oidPolicyQualifierCPS        = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 2, 1}
+	oidPolicyQualifierUserNotice = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 2, 2}
+)
+
 // oidNotInExtensions returns whether an extension with the given oid exists in
 // extensions.
 func oidInExtensions(oid asn1.ObjectIdentifier, extensions []pkix.Extension) bool {
@@ -1816,12 +2066,13 @@ func buildExtensions(template *Certificate, subjectIsEmpty bool, authorityKeyId
 		n++
 	}
 
-	if len(template.PolicyIdentifiers) > 0 &&
+	if (len(template.PolicyIdentifiers) > 0 || len(template.Policies) > 0) &&
 		!oidInExtensions(oidExtensionCertificatePolicies, template.ExtraExtensions) {
 		ret[n].Id = oidExtensionCertificatePolicies
-		policies := make([]policyInformation, len(template.PolicyIdentifiers))
-		for i, policy := range template.PolicyIdentifiers {
-			policies[i].Policy = policy
+		var policies []policyInformation
+		policies, err = marshalPolicies(template.Policies, template.PolicyIdentifiers)
+		if err != nil {
+			return
 		}
 		ret[n].Value, err = asn1.Marshal(policies)
 		if err != nil {
@@ -1995,8 +2246,12 @@ func signingParamsForPublicKey(pub interface{}, requestedSigAlgo SignatureAlgori
 			err = errors.New("x509: unknown elliptic curve")
 		}
 
+	case ed25519.PublicKey:
+		pubType = Ed25519
+		sigAlgo.Algorithm = oidSignatureEd25519
+
 	default:
-		err = errors.New("x509: only RSA and ECDSA keys supported")
+		err = errors.New("x509: only RSA, ECDSA and Ed25519 keys supported")
 	}
 
 	if err != nil {
@@ -2015,12 +2270,12 @@ func signingParamsForPublicKey(pub interface{}, requestedSigAlgo SignatureAlgori
 				return
 			}
 			sigAlgo.Algorithm, hashFunc = details.oid, details.hash
-			if hashFunc == 0 {
+			if hashFunc == 0 && pubType != Ed25519 {
 				err = errors.New("x509: cannot sign with hash function requested")
 				return
 			}
 			if requestedSigAlgo.isRSAPSS() {
-				sigAlgo.Parameters = rsaPSSParameters(hashFunc)
+				sigAlgo.Parameters = rsaPSSParameters(hashFunc, hashFunc.Size())
 			}
 			found = true
 			break
@@ -2040,9 +2295,11 @@ var emptyASN1Subject = []byte{0x30, 0}
 
 // CreateCertificate creates a new X.509v3 certificate based on a template.
 // The following members of template are used: AuthorityKeyId,
-// BasicConstraintsValid, DNSNames, ExcludedDNSDomains, ExtKeyUsage,
-// IsCA, KeyUsage, MaxPathLen, MaxPathLenZero, NotAfter, NotBefore,
-// PermittedDNSDomains, PermittedDNSDomainsCritical, SerialNumber,
+// BasicConstraintsValid, CRLDistributionPoints, DNSNames,
+// ExcludedDNSDomains, ExtKeyUsage, IsCA, IssuingCertificateURL,
+// KeyUsage, MaxPathLen, MaxPathLenZero, NotAfter, NotBefore,
+// OCSPServer, PermittedDNSDomains, PermittedDNSDomainsCritical,
+// Policies, PolicyIdentifiers, PSSSaltLength, SerialNumber,
 // SignatureAlgorithm, Subject, SubjectKeyId, and UnknownExtKeyUsage.
 //
 // The certificate is signed by parent. If parent is equal to template then the
@@ -2052,7 +2309,7 @@ var emptyASN1Subject = []byte{0x30, 0}
 // The returned slice is the certificate in DER encoding.
 //
 // All keys types that are implemented via crypto.Signer are supported (This
-// includes *rsa.PublicKey and *ecdsa.PublicKey.)
+// includes *rsa.PublicKey, *ecdsa.PublicKey and ed25519.PublicKey.)
 //
 // The AuthorityKeyId will be taken from the SubjectKeyId of parent, if any,
 // unless the resulting certificate is self-signed. Otherwise the value from
@@ -2072,6 +2329,11 @@ func CreateCertificate(rand io.Reader, template, parent *Certificate, pub, priv
 		return nil, err
 	}
 
+	saltLength, err := pssSaltLength(template.SignatureAlgorithm, template.PSSSaltLength, hashFunc, &signatureAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+
 	publicKeyBytes, publicKeyAlgorithm, err := marshalPublicKey(pub)
 	if err != nil {
 		return nil, err
@@ -2116,21 +2378,24 @@ func CreateCertificate(rand io.Reader, template, parent *Certificate, pub, priv
 
 	c.Raw = tbsCertContents
 
-	h := hashFunc.New()
-	h.Write(tbsCertContents)
-	digest := h.Sum(nil)
+	signed := tbsCertContents
+	if hashFunc != 0 {
+		h := hashFunc.New()
+		h.Write(signed)
+		signed = h.Sum(nil)
+	}
 
 	var signerOpts crypto.SignerOpts
 	signerOpts = hashFunc
 	if template.SignatureAlgorithm != 0 && template.SignatureAlgorithm.isRSAPSS() {
 		signerOpts = &rsa.PSSOptions{
-			SaltLength: rsa.PSSSaltLengthEqualsHash,
+			SaltLength: saltLength,
 			Hash:       hashFunc,
 		}
 	}
 
 	var signature []byte
-	signature, err = key.Sign(rand, digest, signerOpts)
+	signature, err = key.Sign(rand, signed, signerOpts)
 	if err != nil {
 		return
 	}
@@ -2177,6 +2442,10 @@ func ParseDERCRL(derBytes []byte) (*pkix.CertificateList, error) {
 
 // CreateCRL returns a DER encoded CRL, signed by this Certificate, that
 // contains the given list of revoked certificates.
+//
+// CreateCRL produces only the legacy fields of pkix.TBSCertificateList.
+// To create a list with a CRL number, reason codes or other extensions,
+// use CreateRevocationList.
 func (c *Certificate) CreateCRL(rand io.Reader, priv interface{}, revokedCerts []pkix.RevokedCertificate, now, expiry time.Time) (crlBytes []byte, err error) {
 	key, ok := priv.(crypto.Signer)
 	if !ok {
@@ -2220,12 +2489,15 @@ func (c *Certificate) CreateCRL(rand io.Reader, priv interface{}, revokedCerts [
 		return
 	}
 
-	h := hashFunc.New()
-	h.Write(tbsCertListContents)
-	digest := h.Sum(nil)
+	signed := tbsCertListContents
+	if hashFunc != 0 {
+		h := hashFunc.New()
+		h.Write(signed)
+		signed = h.Sum(nil)
+	}
 
 	var signature []byte
-	signature, err = key.Sign(rand, digest, hashFunc)
+	signature, err = key.Sign(rand, signed, hashFunc)
 	if err != nil {
 		return
 	}
@@ -2248,12 +2520,17 @@ type CertificateRequest struct {
 	Signature          []byte
 	SignatureAlgorithm SignatureAlgorithm
 
+	// PSSSaltLength is the salt length, in bytes, of an RSA-PSS
+	// signature, as for Certificate.
+	PSSSaltLength int
+
 	PublicKeyAlgorithm PublicKeyAlgorithm
 	PublicKey          interface{}
 
 	Subject pkix.Name
 
-	// Attributes is the dried husk of a bug and shouldn't be used.
+	// Attributes is the dried husk of a bug and shouldn't be used. See
+	// ChallengePassword, UnstructuredName and OtherAttributes instead.
 	Attributes []pkix.AttributeTypeAndValueSET
 
 	// Extensions contains raw X.509 extensions. When parsing CSRs, this
@@ -2275,6 +2552,27 @@ type CertificateRequest struct {
 	EmailAddresses []string
 	IPAddresses    []net.IP
 	URIs           []*url.URL
+
+	// ChallengePassword and UnstructuredName are the PKCS #9 attributes
+	// of RFC 2985, 5.4.1 and 5.4.2. Enrollment protocols such as SCEP
+	// use the challenge password to authenticate requests.
+	ChallengePassword string
+	UnstructuredName  string
+
+	// OtherAttributes contains the attributes of a parsed CSR other
+	// than the extension request, challenge password and unstructured
+	// name. A challenge password or unstructured name whose value
+	// can't be decoded as a string, such as a BMPString, is also kept
+	// here. When creating a CSR, they are copied, raw, into it and
+	// override ChallengePassword and UnstructuredName.
+	OtherAttributes []CSRAttribute
+}
+
+// CSRAttribute is an attribute of a certificate request, as described in
+// RFC 2986, section 4.1.
+type CSRAttribute struct {
+	Type   asn1.ObjectIdentifier
+	Values []asn1.RawValue `asn1:"set"`
 }
 
 // These structures reflect the ASN.1 structure of X.509 certificate
@@ -2299,6 +2597,11 @@ type certificateRequest struct {
 // extensions in a CSR.
 var oidExtensionRequest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 14}
 
+var (
+	oidChallengePassword = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 7}
+	oidUnstructuredName  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 2}
+)
+
 // newRawAttributes converts AttributeTypeAndValueSETs from a template
 // CertificateRequest's Attributes into tbsCertificateRequest RawAttributes.
 func newRawAttributes(attributes []pkix.AttributeTypeAndValueSET) ([]asn1.RawValue, error) {
@@ -2335,22 +2638,15 @@ func parseRawAttributes(rawAttributes []asn1.RawValue) []pkix.AttributeTypeAndVa
 // parseCSRExtensions parses the attributes from a CSR and extracts any
 // requested extensions.
 func parseCSRExtensions(rawAttributes []asn1.RawValue) ([]pkix.Extension, error) {
-	// pkcs10Attribute reflects the Attribute structure from section 4.1 of
-	// https://tools.ietf.org/html/rfc2986.
-	type pkcs10Attribute struct {
-		Id     asn1.ObjectIdentifier
-		Values []asn1.RawValue `asn1:"set"`
-	}
-
 	var ret []pkix.Extension
 	for _, rawAttr := range rawAttributes {
-		var attr pkcs10Attribute
+		var attr CSRAttribute
 		if rest, err := asn1.Unmarshal(rawAttr.FullBytes, &attr); err != nil || len(rest) != 0 || len(attr.Values) == 0 {
 			// Ignore attributes that don't parse.
 			continue
 		}
 
-		if !attr.Id.Equal(oidExtensionRequest) {
+		if !attr.Type.Equal(oidExtensionRequest) {
 			continue
 		}
 
@@ -2364,15 +2660,74 @@ func parseCSRExtensions(rawAttributes []asn1.RawValue) ([]pkix.Extension, error)
 	return ret, nil
 }
 
+// parseCSRAttributes parses the attributes from a CSR other than the
+// extension request into out.
+func parseCSRAttributes(out *CertificateRequest, rawAttributes []asn1.RawValue) {
+	for _, rawAttr := range rawAttributes {
+		var attr CSRAttribute
+		if rest, err := asn1.Unmarshal(rawAttr.FullBytes, &attr); err != nil || len(rest) != 0 || len(attr.Values) == 0 {
+			// Ignore attributes that don't parse.
+			continue
+		}
+
+		var value *string
+		switch {
+		case attr.Type.Equal(oidExtensionRequest):
+			continue
+		case attr.Type.Equal(oidChallengePassword):
+			value = &out.ChallengePassword
+		case attr.Type.Equal(oidUnstructuredName):
+			value = &out.UnstructuredName
+		}
+		if value != nil {
+			var s string
+			if rest, err := asn1.Unmarshal(attr.Values[0].FullBytes, &s); err == nil && len(rest) == 0 {
+				*value = s
+				continue
+			}
+			// Values of other string types, such as BMPString, are
+			// kept raw.
+		}
+		out.OtherAttributes = append(out.OtherAttributes, attr)
+	}
+}
+
+// newCSRStringAttribute returns the encoding of an attribute with a
+// single string value.
+func newCSRStringAttribute(oid asn1.ObjectIdentifier, value string) (asn1.RawValue, error) {
+	b, err := asn1.Marshal(value)
+	if err != nil {
+		return asn1.RawValue{}, err
+	}
+	b, err = asn1.Marshal(CSRAttribute{Type: oid, Values: []asn1.RawValue{{FullBytes: b}}})
+	if err != nil {
+		return asn1.RawValue{}, err
+	}
+	return asn1.RawValue{FullBytes: b}, nil
+}
+
+// csrAttributeInList reports whether an attribute of the given type is in
+// attributes.
+func csrAttributeInList(oid asn1.ObjectIdentifier, attributes []CSRAttribute) bool {
+	for _, attr := range attributes {
+		if attr.Type.Equal(oid) {
+			return true
+		}
+	}
+	return false
+}
+
 // CreateCertificateRequest creates a new certificate request based on a
-// template. The following members of template are used: Attributes, DNSNames,
-// EmailAddresses, ExtraExtensions, IPAddresses, URIs, SignatureAlgorithm, and
-// Subject. The private key is the private key of the signer.
+// template. The following members of template are used: Attributes,
+// ChallengePassword, DNSNames, EmailAddresses, ExtraExtensions,
+// IPAddresses, OtherAttributes, PSSSaltLength, URIs, SignatureAlgorithm,
+// Subject, and UnstructuredName. The private key is the private key of
+// the signer.
 //
 // The returned slice is the certificate request in DER encoding.
 //
 // All keys types that are implemented via crypto.Signer are supported (This
-// includes *rsa.PublicKey and *ecdsa.PublicKey.)
+// includes *rsa.PublicKey, *ecdsa.PublicKey and ed25519.PublicKey.)
 func CreateCertificateRequest(rand io.Reader, template *CertificateRequest, priv interface{}) (csr []byte, err error) {
 	key, ok := priv.(crypto.Signer)
 	if !ok {
@@ -2385,6 +2740,10 @@ func CreateCertificateRequest(rand io.Reader, template *CertificateRequest, priv
 	if err != nil {
 		return nil, err
 	}
+	saltLength, err := pssSaltLength(template.SignatureAlgorithm, template.PSSSaltLength, hashFunc, &sigAlgo)
+	if err != nil {
+		return nil, err
+	}
 
 	var publicKeyBytes []byte
 	var publicKeyAlgorithm pkix.AlgorithmIdentifier
@@ -2481,6 +2840,32 @@ func CreateCertificateRequest(rand io.Reader, template *CertificateRequest, priv
 		return
 	}
 
+	for _, attr := range []struct {
+		oid   asn1.ObjectIdentifier
+		value string
+	}{
+		{oidChallengePassword, template.ChallengePassword},
+		{oidUnstructuredName, template.UnstructuredName},
+	} {
+		if len(attr.value) == 0 || csrAttributeInList(attr.oid, template.OtherAttributes) {
+			continue
+		}
+		var raw asn1.RawValue
+		raw, err = newCSRStringAttribute(attr.oid, attr.value)
+		if err != nil {
+			return
+		}
+		rawAttributes = append(rawAttributes, raw)
+	}
+	for _, attr := range template.OtherAttributes {
+		var b []byte
+		b, err = asn1.Marshal(attr)
+		if err != nil {
+			return
+		}
+		rawAttributes = append(rawAttributes, asn1.RawValue{FullBytes: b})
+	}
+
 	tbsCSR := tbsCertificateRequest{
 		Version: 0, // PKCS #10, RFC 2986
 		Subject: asn1.RawValue{FullBytes: asn1Subject},
@@ -2500,12 +2885,24 @@ func CreateCertificateRequest(rand io.Reader, template *CertificateRequest, priv
 	}
 	tbsCSR.Raw = tbsCSRContents
 
-	h := hashFunc.New()
-	h.Write(tbsCSRContents)
-	digest := h.Sum(nil)
+	signed := tbsCSRContents
+	if hashFunc != 0 {
+		h := hashFunc.New()
+		h.Write(signed)
+		signed = h.Sum(nil)
+	}
+
+	var signerOpts crypto.SignerOpts
+	signerOpts = hashFunc
+	if template.SignatureAlgorithm != 0 && template.SignatureAlgorithm.isRSAPSS() {
+		signerOpts = &rsa.PSSOptions{
+			SaltLength: saltLength,
+			Hash:       hashFunc,
+		}
+	}
 
 	var signature []byte
-	signature, err = key.Sign(rand, digest, hashFunc)
+	signature, err = key.Sign(rand, signed, signerOpts)
 	if err != nil {
 		return
 	}
@@ -2542,8 +2939,7 @@ func parseCertificateRequest(in *certificateRequest) (*CertificateRequest, error
 		RawSubjectPublicKeyInfo:  in.TBSCSR.PublicKey.Raw,
 		RawSubject:               in.TBSCSR.Subject.FullBytes,
 
-		Signature:          in.SignatureValue.RightAlign(),
-		SignatureAlgorithm: getSignatureAlgorithmFromAI(in.SignatureAlgorithm),
+		Signature: in.SignatureValue.RightAlign(),
 
 		PublicKeyAlgorithm: getPublicKeyAlgorithmFromOID(in.TBSCSR.PublicKey.Algorithm.Algorithm),
 
@@ -2551,6 +2947,8 @@ func parseCertificateRequest(in *certificateRequest) (*CertificateRequest, error
 		Attributes: parseRawAttributes(in.TBSCSR.RawAttributes),
 	}
 
+	out.SignatureAlgorithm, out.PSSSaltLength = getSignatureAlgorithmAndSaltFromAI(in.SignatureAlgorithm)
+
 	var err error
 	out.PublicKey, err = parsePublicKey(out.PublicKeyAlgorithm, &in.TBSCSR.PublicKey)
 	if err != nil {
@@ -2570,6 +2968,8 @@ func parseCertificateRequest(in *certificateRequest) (*CertificateRequest, error
 		return nil, err
 	}
 
+	parseCSRAttributes(out, in.TBSCSR.RawAttributes)
+
 	for _, extension := range out.Extensions {
 		if extension.Id.Equal(oidExtensionSubjectAltName) {
 			out.DNSNames, out.EmailAddresses, out.IPAddresses, out.URIs, err = parseSANExtension(extension.Value)
@@ -2584,5 +2984,60 @@ func parseCertificateRequest(in *certificateRequest) (*CertificateRequest, error
 
 // CheckSignature reports whether the signature on c is valid.
 func (c *CertificateRequest) CheckSignature() error {
-	return checkSignature(c.SignatureAlgorithm, c.RawTBSCertificateRequest, c.Signature, c.PublicKey)
+	return checkSignatureWithPSSSaltLength(c.SignatureAlgorithm, c.RawTBSCertificateRequest, c.Signature, c.PublicKey, c.PSSSaltLength)
+}
+
+// CreateCertificateFromRequest creates a new certificate, signed by
+// parent, for the public key of csr, whose signature must be valid. The
+// certificate is based on template, as for CreateCertificate, except
+// that the subject is taken from csr if template has none.
+//
+// Extensions requested in csr are only copied into the certificate if
+// their type is in allowedExtensions and template does not produce an
+// extension of the same type. A requested subject alternative name is
+// copied only if template has no subject alternative names.
+func CreateCertificateFromRequest(rand io.Reader, template, parent *Certificate, csr *CertificateRequest, allowedExtensions []asn1.ObjectIdentifier, priv interface{}) ([]byte, error) {
+	if err := csr.CheckSignature(); err != nil {
+		return nil, err
+	}
+
+	t := *template
+	if len(t.RawSubject) == 0 && len(t.Subject.ToRDNSequence()) == 0 {
+		t.RawSubject = csr.RawSubject
+	}
+
+	// Find the extensions that template produces by itself.
+	produced, err := buildExtensions(template, false, parent.SubjectKeyId)
+	if err != nil {
+		return nil, err
+	}
+
+	t.ExtraExtensions = append([]pkix.Extension(nil), template.ExtraExtensions...)
+	for _, e := range csr.Extensions {
+		if !oidInList(e.Id, allowedExtensions) || oidInExtensions(e.Id, produced) {
+			continue
+		}
+		if e.Id.Equal(oidExtensionSubjectAltName) {
+			// Copy the names rather than the raw extension, so that
+			// it's marked critical if the subject is empty.
+			t.DNSNames = csr.DNSNames
+			t.EmailAddresses = csr.EmailAddresses
+			t.IPAddresses = csr.IPAddresses
+			t.URIs = csr.URIs
+			continue
+		}
+		t.ExtraExtensions = append(t.ExtraExtensions, e)
+	}
+
+	return CreateCertificate(rand, &t, parent, csr.PublicKey, priv)
+}
+
+// oidInList reports whether oid is in list.
+func oidInList(oid asn1.ObjectIdentifier, list []asn1.ObjectIdentifier) bool {
+	for _, o := range list {
+		if o.Equal(oid) {
+			return true
+		}
+	}
+	return false
 }
@@ -6,8 +6,10 @@ package x509
 
 import (
 	"bytes"
+	"crypto"
 	"crypto/dsa"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
@@ -77,6 +79,27 @@ func TestParsePKIXPublicKey(t *testing.T) {
 	}
 }
 
+func TestParsePKIXPublicKeyEd25519(t *testing.T) {
+	// From RFC 8410, Section 10.1.
+	der, _ := hex.DecodeString("302a300506032b657003210019bf44096984cdfe8541bac167dc3b96c85086aa30b6b6cb0c5c38ad703166e1")
+	pub, err := ParsePKIXPublicKey(der)
+	if err != nil {
+		t.Fatalf("Failed to parse Ed25519 public key: %s", err)
+	}
+	edPub, ok := pub.(ed25519.PublicKey)
+	if !ok {
+		t.Fatalf("Value returned from ParsePKIXPublicKey was not an Ed25519 public key")
+	}
+
+	der2, err := MarshalPKIXPublicKey(edPub)
+	if err != nil {
+		t.Fatalf("Failed to marshal Ed25519 public key: %s", err)
+	}
+	if !bytes.Equal(der2, der) {
+		t.Errorf("Reserialization of public key didn't match. got %x, want %x", der2, der)
+	}
+}
+
 var pemPublicKey = `-----BEGIN PUBLIC KEY-----
 MIIBIjANBgkqhkiG9w0BAQEFAAOCAQ8AMIIBCgKCAQEA3VoPN9PKUjKFLMwOge6+
 wnDi8sbETGIx2FKXGgqtAKpzmem53kRGEQg8WeqRmp12wgp74TGpkEXsGae7RS1k
@@ -496,6 +519,11 @@ func TestCreateSelfSignedCertificate(t *testing.T) {
 		t.Fatalf("Failed to generate ECDSA key: %s", err)
 	}
 
+	ed25519Pub, ed25519Priv, err := ed25519.GenerateKey(random)
+	if err != nil {
+		t.Fatalf("Failed to generate Ed25519 key: %s", err)
+	}
+
 	tests := []struct {
 		name      string
 		pub, priv interface{}
@@ -509,6 +537,7 @@ func TestCreateSelfSignedCertificate(t *testing.T) {
 		{"RSAPSS/RSAPSS", &testPrivateKey.PublicKey, testPrivateKey, true, SHA256WithRSAPSS},
 		{"ECDSA/RSAPSS", &ecdsaPriv.PublicKey, testPrivateKey, false, SHA256WithRSAPSS},
 		{"RSAPSS/ECDSA", &testPrivateKey.PublicKey, ecdsaPriv, false, ECDSAWithSHA384},
+		{"Ed25519", ed25519Pub, ed25519Priv, true, PureEd25519},
 	}
 
 	testExtKeyUsage := []ExtKeyUsage{ExtKeyUsageClientAuth, ExtKeyUsageServerAuth}
@@ -962,6 +991,174 @@ func TestRSAPSSSelfSigned(t *testing.T) {
 	}
 }
 
+func TestRSAPSSSaltLength(t *testing.T) {
+	now := time.Now()
+	rootTemplate := &Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "PSS Root"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(time.Hour),
+		KeyUsage:              KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:               true,
+		SignatureAlgorithm: SHA256WithRSAPSS,
+		PSSSaltLength:      20,
+	}
+	rootDER, err := CreateCertificate(rand.Reader, rootTemplate, rootTemplate, &testPrivateKey.PublicKey, testPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, err := ParseCertificate(rootDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root.SignatureAlgorithm != SHA256WithRSAPSS || root.PSSSaltLength != 20 {
+		t.Errorf("root signature = %v with salt length %d, want SHA256WithRSAPSS with 20", root.SignatureAlgorithm, root.PSSSaltLength)
+	}
+	if err := root.CheckSignatureFrom(root); err != nil {
+		t.Errorf("CheckSignatureFrom: %v", err)
+	}
+	// CheckSignature only accepts salts as long as the hash output.
+	if err := root.CheckSignature(root.SignatureAlgorithm, root.RawTBSCertificate, root.Signature); err == nil {
+		t.Error("CheckSignature accepted a 20 byte salt")
+	}
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafTemplate := &Certificate{
+		SerialNumber:       big.NewInt(2),
+		Subject:            pkix.Name{CommonName: "leaf"},
+		NotBefore:          now.Add(-time.Hour),
+		NotAfter:           now.Add(time.Hour),
+		DNSNames:           []string{"example.com"},
+		SignatureAlgorithm: SHA384WithRSAPSS,
+	}
+	leafDER, err := CreateCertificate(rand.Reader, leafTemplate, root, &leafKey.PublicKey, testPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := ParseCertificate(leafDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leaf.SignatureAlgorithm != SHA384WithRSAPSS || leaf.PSSSaltLength != 48 {
+		t.Errorf("leaf signature = %v with salt length %d, want SHA384WithRSAPSS with 48", leaf.SignatureAlgorithm, leaf.PSSSaltLength)
+	}
+
+	roots := NewCertPool()
+	roots.AddCert(root)
+	if _, err := leaf.Verify(VerifyOptions{Roots: roots, DNSName: "example.com"}); err != nil {
+		t.Errorf("Verify: %v", err)
+	}
+
+	leaf.PSSSaltLength = 32
+	if err := leaf.CheckSignatureFrom(root); err == nil {
+		t.Error("CheckSignatureFrom accepted a signature with the wrong salt length")
+	}
+
+	rootTemplate.PSSSaltLength = -1
+	if _, err := CreateCertificate(rand.Reader, rootTemplate, rootTemplate, &testPrivateKey.PublicKey, testPrivateKey); err == nil {
+		t.Error("CreateCertificate accepted a negative PSSSaltLength")
+	}
+
+	// A zero PSSSaltLength means a salt as long as the hash output,
+	// not any salt.
+	root.PSSSaltLength = 0
+	if err := root.CheckSignatureFrom(root); err == nil {
+		t.Error("CheckSignatureFrom accepted a 20 byte salt with a zero PSSSaltLength")
+	}
+}
+
+func TestRSAPSSSaltLengthCSRAndCRL(t *testing.T) {
+	csrDER, err := CreateCertificateRequest(rand.Reader, &CertificateRequest{
+		Subject:            pkix.Name{CommonName: "PSS CSR"},
+		SignatureAlgorithm: SHA256WithRSAPSS,
+		PSSSaltLength:      20,
+	}, testPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	csr, err := ParseCertificateRequest(csrDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if csr.SignatureAlgorithm != SHA256WithRSAPSS || csr.PSSSaltLength != 20 {
+		t.Errorf("CSR signature = %v with salt length %d, want SHA256WithRSAPSS with 20", csr.SignatureAlgorithm, csr.PSSSaltLength)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		t.Errorf("CertificateRequest.CheckSignature: %v", err)
+	}
+	csr.PSSSaltLength = 32
+	if err := csr.CheckSignature(); err == nil {
+		t.Error("CertificateRequest.CheckSignature accepted a signature with the wrong salt length")
+	}
+
+	now := time.Now()
+	issuerTemplate := &Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "PSS CRL Issuer"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(time.Hour),
+		KeyUsage:              KeyUsageCertSign | KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA: true,
+	}
+	issuerDER, err := CreateCertificate(rand.Reader, issuerTemplate, issuerTemplate, &testPrivateKey.PublicKey, testPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer, err := ParseCertificate(issuerDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	crlDER, err := CreateRevocationList(rand.Reader, &RevocationList{
+		Number:             big.NewInt(1),
+		ThisUpdate:         now,
+		NextUpdate:         now.Add(time.Hour),
+		SignatureAlgorithm: SHA256WithRSAPSS,
+		PSSSaltLength:      20,
+	}, issuer, testPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rl, err := ParseRevocationList(crlDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rl.SignatureAlgorithm != SHA256WithRSAPSS || rl.PSSSaltLength != 20 {
+		t.Errorf("CRL signature = %v with salt length %d, want SHA256WithRSAPSS with 20", rl.SignatureAlgorithm, rl.PSSSaltLength)
+	}
+	if err := rl.CheckSignatureFrom(issuer); err != nil {
+		t.Errorf("RevocationList.CheckSignatureFrom: %v", err)
+	}
+	rl.PSSSaltLength = 32
+	if err := rl.CheckSignatureFrom(issuer); err == nil {
+		t.Error("RevocationList.CheckSignatureFrom accepted a signature with the wrong salt length")
+	}
+
+	crl, err := ParseDERCRL(crlDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := issuer.CheckCRLSignature(crl); err != nil {
+		t.Errorf("CheckCRLSignature: %v", err)
+	}
+	crl.SignatureAlgorithm.Parameters = rsaPSSParameters(crypto.SHA256, 32)
+	if err := issuer.CheckCRLSignature(crl); err == nil {
+		t.Error("CheckCRLSignature accepted a signature with the wrong salt length")
+	}
+	// An explicit empty salt is rejected rather than accepting any salt.
+	crl.SignatureAlgorithm.Parameters = rsaPSSParameters(crypto.SHA256, 0)
+	if algo, _ := getSignatureAlgorithmAndSaltFromAI(crl.SignatureAlgorithm); algo != UnknownSignatureAlgorithm {
+		t.Errorf("signature algorithm with an empty salt parsed as %v", algo)
+	}
+	if err := issuer.CheckCRLSignature(crl); err == nil {
+		t.Error("CheckCRLSignature accepted a signature declaring an empty salt")
+	}
+}
+
 const pemCertificate = `-----BEGIN CERTIFICATE-----
 MIIDATCCAemgAwIBAgIRAKQkkrFx1T/dgB/Go/xBM5swDQYJKoZIhvcNAQELBQAw
 EjEQMA4GA1UEChMHQWNtZSBDbzAeFw0xNjA4MTcyMDM2MDdaFw0xNzA4MTcyMDM2
@@ -1130,6 +1327,7 @@ func TestCreateCertificateRequest(t *testing.T) {
 		{"ECDSA-256", ecdsa256Priv, ECDSAWithSHA1},
 		{"ECDSA-384", ecdsa384Priv, ECDSAWithSHA1},
 		{"ECDSA-521", ecdsa521Priv, ECDSAWithSHA1},
+		{"RSA-PSS", testPrivateKey, SHA256WithRSAPSS},
 	}
 
 	for _, test := range tests {
@@ -1333,6 +1531,164 @@ func TestCriticalFlagInCSRRequestedExtensions(t *testing.T) {
 	}
 }
 
+func TestCertificateRequestAttributes(t *testing.T) {
+	other := CSRAttribute{
+		Type:   asn1.ObjectIdentifier{1, 2, 3, 4},
+		Values: []asn1.RawValue{{FullBytes: []byte{0x02, 0x01, 0x05}}},
+	}
+	template := CertificateRequest{
+		Subject:           pkix.Name{CommonName: "scep client"},
+		DNSNames:          []string{"client.example.com"},
+		ChallengePassword: "s3cret!",
+		UnstructuredName:  "unstructured ü",
+		OtherAttributes:   []CSRAttribute{other},
+	}
+	csr := marshalAndParseCSR(t, &template)
+	if csr.ChallengePassword != template.ChallengePassword {
+		t.Errorf("ChallengePassword = %q, want %q", csr.ChallengePassword, template.ChallengePassword)
+	}
+	if csr.UnstructuredName != template.UnstructuredName {
+		t.Errorf("UnstructuredName = %q, want %q", csr.UnstructuredName, template.UnstructuredName)
+	}
+	if len(csr.OtherAttributes) != 1 || !csr.OtherAttributes[0].Type.Equal(other.Type) ||
+		!bytes.Equal(csr.OtherAttributes[0].Values[0].FullBytes, other.Values[0].FullBytes) {
+		t.Errorf("OtherAttributes = %v, want %v", csr.OtherAttributes, []CSRAttribute{other})
+	}
+	if !reflect.DeepEqual(csr.DNSNames, template.DNSNames) {
+		t.Errorf("DNSNames = %v, want %v", csr.DNSNames, template.DNSNames)
+	}
+
+	// An attribute in OtherAttributes overrides the typed field.
+	b, err := asn1.Marshal("override")
+	if err != nil {
+		t.Fatal(err)
+	}
+	template.OtherAttributes = []CSRAttribute{{Type: oidChallengePassword, Values: []asn1.RawValue{{FullBytes: b}}}}
+	csr = marshalAndParseCSR(t, &template)
+	if csr.ChallengePassword != "override" {
+		t.Errorf("ChallengePassword = %q, want %q", csr.ChallengePassword, "override")
+	}
+	if len(csr.OtherAttributes) != 0 {
+		t.Errorf("OtherAttributes = %v, want none", csr.OtherAttributes)
+	}
+
+	// A challenge password that isn't a Go string type, here the
+	// BMPString "pw", is kept raw rather than failing the parse.
+	bmp := []byte{30, 4, 0, 'p', 0, 'w'}
+	template.OtherAttributes = []CSRAttribute{{Type: oidChallengePassword, Values: []asn1.RawValue{{FullBytes: bmp}}}}
+	csr = marshalAndParseCSR(t, &template)
+	if csr.ChallengePassword != "" {
+		t.Errorf("ChallengePassword = %q, want none", csr.ChallengePassword)
+	}
+	if len(csr.OtherAttributes) != 1 || !csr.OtherAttributes[0].Type.Equal(oidChallengePassword) ||
+		!bytes.Equal(csr.OtherAttributes[0].Values[0].FullBytes, bmp) {
+		t.Errorf("OtherAttributes = %v, want the BMPString challenge password", csr.OtherAttributes)
+	}
+}
+
+func TestCreateCertificateFromRequest(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sanContents, err := marshalSANs([]string{"requested.example.com"}, nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	basicConstraints, err := asn1.Marshal(basicConstraints{IsCA: true, MaxPathLen: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyUsage := pkix.Extension{Id: oidExtensionKeyUsage, Critical: true, Value: []byte{0x03, 0x02, 0x07, 0x80}}
+	csrDER, err := CreateCertificateRequest(rand.Reader, &CertificateRequest{
+		Subject: pkix.Name{CommonName: "requested"},
+		ExtraExtensions: []pkix.Extension{
+			{Id: oidExtensionSubjectAltName, Value: sanContents},
+			{Id: oidExtensionBasicConstraints, Value: basicConstraints},
+			keyUsage,
+		},
+	}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	csr, err := ParseCertificateRequest(csrDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	caTemplate := &Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "CA"},
+		NotBefore:             time.Unix(1000, 0),
+		NotAfter:              time.Unix(100000, 0),
+		BasicConstraintsValid: true,
+		IsCA:     true,
+		KeyUsage: KeyUsageCertSign,
+	}
+	caDER, err := CreateCertificate(rand.Reader, caTemplate, caTemplate, &testPrivateKey.PublicKey, testPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	allowed := []asn1.ObjectIdentifier{oidExtensionSubjectAltName, oidExtensionKeyUsage}
+	template := &Certificate{
+		SerialNumber: big.NewInt(2),
+		NotBefore:    time.Unix(1000, 0),
+		NotAfter:     time.Unix(100000, 0),
+	}
+	der, err := CreateCertificateFromRequest(rand.Reader, template, ca, csr, allowed, testPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cert.CheckSignatureFrom(ca); err != nil {
+		t.Errorf("CheckSignatureFrom: %v", err)
+	}
+	if !reflect.DeepEqual(cert.PublicKey, &key.PublicKey) {
+		t.Errorf("PublicKey = %v, want the requested key", cert.PublicKey)
+	}
+	if cert.Subject.CommonName != "requested" {
+		t.Errorf("Subject = %v, want the requested subject", cert.Subject)
+	}
+	if !reflect.DeepEqual(cert.DNSNames, []string{"requested.example.com"}) {
+		t.Errorf("DNSNames = %v, want the requested names", cert.DNSNames)
+	}
+	if cert.KeyUsage != KeyUsageDigitalSignature {
+		t.Errorf("KeyUsage = %v, want the requested usage", cert.KeyUsage)
+	}
+	if cert.BasicConstraintsValid || cert.IsCA {
+		t.Error("basic constraints were copied from the request but not allowed")
+	}
+
+	// Fields of the template take precedence over requested extensions.
+	template.Subject = pkix.Name{CommonName: "issued"}
+	template.DNSNames = []string{"issued.example.com"}
+	template.KeyUsage = KeyUsageKeyEncipherment
+	der, err = CreateCertificateFromRequest(rand.Reader, template, ca, csr, allowed, testPrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert, err = ParseCertificate(der); err != nil {
+		t.Fatal(err)
+	}
+	if cert.Subject.CommonName != "issued" || !reflect.DeepEqual(cert.DNSNames, template.DNSNames) || cert.KeyUsage != KeyUsageKeyEncipherment {
+		t.Errorf("got subject %v, names %v, key usage %v; want the template's", cert.Subject, cert.DNSNames, cert.KeyUsage)
+	}
+
+	// Requests with a bad signature are rejected.
+	csr.Signature[len(csr.Signature)-1] ^= 1
+	if _, err := CreateCertificateFromRequest(rand.Reader, template, ca, csr, allowed, testPrivateKey); err == nil {
+		t.Error("CreateCertificateFromRequest with a bad CSR signature succeeded, want error")
+	}
+}
+
 // serialiseAndParse generates a self-signed certificate from template and
 // returns a parsed version of it.
 func serialiseAndParse(t *testing.T, template *Certificate) *Certificate {
@@ -1946,3 +2302,81 @@ func TestMultipleURLsInCRLDP(t *testing.T) {
 		t.Errorf("CRL distribution points = %#v, want #%v", got, want)
 	}
 }
+
+func TestCertificatePolicies(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	policies := []PolicyInformation{
+		{
+			Policy:  asn1.ObjectIdentifier{2, 23, 140, 1, 2, 1},
+			CPSURIs: []string{"https://example.com/cps"},
+			UserNotices: []UserNotice{
+				{ExplicitText: "Relying parties must read the CPS"},
+				{Organization: "Example Org", NoticeNumbers: []int{1, 2}},
+			},
+		},
+		{Policy: asn1.ObjectIdentifier{1, 2, 3}},
+	}
+	template := &Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "policies"},
+		NotBefore:             time.Unix(1000, 0),
+		NotAfter:              time.Unix(100000, 0),
+		OCSPServer:            []string{"http://ocsp.example.com"},
+		IssuingCertificateURL: []string{"http://example.com/ca.crt"},
+		PolicyIdentifiers:     []asn1.ObjectIdentifier{{1, 2, 3}, {1, 2, 4}},
+		Policies:              policies,
+	}
+	der, err := CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := append(policies, PolicyInformation{Policy: asn1.ObjectIdentifier{1, 2, 4}})
+	if !reflect.DeepEqual(cert.Policies, want) {
+		t.Errorf("Policies = %#v, want %#v", cert.Policies, want)
+	}
+	wantIDs := []asn1.ObjectIdentifier{{2, 23, 140, 1, 2, 1}, {1, 2, 3}, {1, 2, 4}}
+	if !reflect.DeepEqual(cert.PolicyIdentifiers, wantIDs) {
+		t.Errorf("PolicyIdentifiers = %v, want %v", cert.PolicyIdentifiers, wantIDs)
+	}
+	if !reflect.DeepEqual(cert.OCSPServer, template.OCSPServer) {
+		t.Errorf("OCSPServer = %v, want %v", cert.OCSPServer, template.OCSPServer)
+	}
+	if !reflect.DeepEqual(cert.IssuingCertificateURL, template.IssuingCertificateURL) {
+		t.Errorf("IssuingCertificateURL = %v, want %v", cert.IssuingCertificateURL, template.IssuingCertificateURL)
+	}
+
+	template.Policies = []PolicyInformation{{Policy: asn1.ObjectIdentifier{1, 2, 3}, CPSURIs: []string{"https://example.com/ü"}}}
+	if _, err := CreateCertificate(rand.Reader, template, template, &key.PublicKey, key); err == nil {
+		t.Error("CreateCertificate with a non-IA5String CPS URI succeeded, want error")
+	}
+}
+
+func TestParseDisplayText(t *testing.T) {
+	tests := []struct {
+		v    asn1.RawValue
+		want string
+		ok   bool
+	}{
+		{asn1.RawValue{Tag: asn1.TagIA5String, Bytes: []byte("ia5")}, "ia5", true},
+		{asn1.RawValue{Tag: 26, Bytes: []byte("visible")}, "visible", true},
+		{asn1.RawValue{Tag: asn1.TagUTF8String, Bytes: []byte("utf8 ü")}, "utf8 ü", true},
+		{asn1.RawValue{Tag: 30, Bytes: []byte{0, 'b', 0, 'm', 0, 'p', 0, 0xfc}}, "bmpü", true},
+		{asn1.RawValue{Tag: 30, Bytes: []byte{0, 'b', 0}}, "", false},
+		{asn1.RawValue{Tag: asn1.TagPrintableString, Bytes: []byte("printable")}, "", false},
+		{asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: asn1.TagUTF8String, Bytes: []byte("tagged")}, "", false},
+	}
+	for _, test := range tests {
+		got, err := parseDisplayText(test.v)
+		if (err == nil) != test.ok || got != test.want {
+			t.Errorf("parseDisplayText(%v) = %q, %v; want %q, ok %v", test.v, got, err, test.want, test.ok)
+		}
+	}
+}
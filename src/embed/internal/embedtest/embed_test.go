@@ -0,0 +1,184 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package embedtest
+
+import (
+	"embed"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+//go:embed testdata/h*.txt
+//go:embed c*.txt testdata/g*.txt
+var global embed.FS
+
+//go:embed c*txt
+var concurrency string
+
+//go:embed testdata/g*.txt
+var glass []byte
+
+//go:embed testdata
+var testDirAll embed.FS
+
+//go:embed testdata/.hidden testdata/ascii.txt
+var explicit embed.FS
+
+func testFiles(t *testing.T, f embed.FS, name, data string) {
+	t.Helper()
+	d, err := f.ReadFile(name)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if string(d) != data {
+		t.Errorf("read %v = %q, want %q", name, d, data)
+	}
+}
+
+func testString(t *testing.T, s, name, data string) {
+	t.Helper()
+	if s != data {
+		t.Errorf("%v = %q, want %q", name, s, data)
+	}
+}
+
+func testDir(t *testing.T, f embed.FS, name string, expect ...string) {
+	t.Helper()
+	dirs, err := f.ReadDir(name)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	var names []string
+	for _, d := range dirs {
+		name := d.Name()
+		if d.IsDir() {
+			name += "/"
+		}
+		names = append(names, name)
+	}
+	if !reflect.DeepEqual(names, expect) {
+		t.Errorf("readdir %v = %v, want %v", name, names, expect)
+	}
+}
+
+func TestGlobal(t *testing.T) {
+	testFiles(t, global, "concurrency.txt", "Concurrency is not parallelism.\n")
+	testFiles(t, global, "testdata/hello.txt", "hello, world\n")
+	testFiles(t, global, "testdata/glass.txt", "I can eat glass and it doesn't hurt me.\n")
+
+	testString(t, concurrency, "concurrency", "Concurrency is not parallelism.\n")
+	testString(t, string(glass), "glass", "I can eat glass and it doesn't hurt me.\n")
+
+	testDir(t, global, ".", "concurrency.txt", "testdata/")
+	testDir(t, global, "testdata", "glass.txt", "hello.txt")
+
+	// The []byte is a private copy; changing it must not affect the FS.
+	glass[0] = 'J'
+	testFiles(t, global, "testdata/glass.txt", "I can eat glass and it doesn't hurt me.\n")
+	glass[0] = 'I'
+}
+
+func TestHidden(t *testing.T) {
+	testDir(t, testDirAll, ".", "testdata/")
+	testDir(t, testDirAll, "testdata", "-not-hidden/", "ascii.txt", "glass.txt", "hello.txt", "sub/")
+	testDir(t, testDirAll, "testdata/sub", "sub.txt")
+
+	testDir(t, explicit, "testdata", ".hidden", "ascii.txt")
+	testFiles(t, explicit, "testdata/.hidden", "hidden\n")
+	if _, err := testDirAll.Open("testdata/.hidden"); err == nil {
+		t.Errorf("Open(testdata/.hidden) succeeded in directory embedding, want error")
+	}
+}
+
+func TestOpen(t *testing.T) {
+	for _, name := range []string{"", "/testdata", "testdata/", "./testdata", "testdata/../testdata", "nonexist"} {
+		if _, err := global.Open(name); err == nil {
+			t.Errorf("Open(%q) succeeded, want error", name)
+		}
+	}
+
+	f, err := global.Open("testdata/hello.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Name() != "hello.txt" || info.Size() != int64(len("hello, world\n")) || info.IsDir() || !info.Mode().IsRegular() {
+		t.Errorf("Stat = %v %v %v %v", info.Name(), info.Size(), info.IsDir(), info.Mode())
+	}
+	s, ok := f.(io.Seeker)
+	if !ok {
+		t.Fatal("embedded file does not implement io.Seeker")
+	}
+	if _, err := s.Seek(7, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "world\n" {
+		t.Errorf("read after Seek = %q, want %q", data, "world\n")
+	}
+}
+
+func TestReadDirPaging(t *testing.T) {
+	f, err := testDirAll.Open("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	d, ok := f.(fs.ReadDirFile)
+	if !ok {
+		t.Fatal("embedded directory does not implement fs.ReadDirFile")
+	}
+	var names []string
+	for {
+		list, err := d.ReadDir(2)
+		for _, e := range list {
+			names = append(names, e.Name())
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list) == 0 || len(list) > 2 {
+			t.Fatalf("ReadDir(2) returned %d entries", len(list))
+		}
+	}
+	want := []string{"-not-hidden", "ascii.txt", "glass.txt", "hello.txt", "sub"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("ReadDir(2) loop = %v, want %v", names, want)
+	}
+}
+
+func TestFSFuncs(t *testing.T) {
+	sub, err := fs.Sub(testDirAll, "testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := fs.ReadFile(sub, "sub/sub.txt")
+	if err != nil || string(data) != "sub\n" {
+		t.Errorf("ReadFile(sub, sub/sub.txt) = %q, %v", data, err)
+	}
+	matches, err := fs.Glob(sub, "*s*.txt")
+	if want := []string{"ascii.txt", "glass.txt"}; err != nil || !reflect.DeepEqual(matches, want) {
+		t.Errorf("Glob(sub, *s*.txt) = %v, %v, want %v", matches, err, want)
+	}
+	info, err := fs.Stat(sub, "sub")
+	if err != nil || !info.IsDir() || info.Name() != "sub" {
+		t.Errorf("Stat(sub, sub) = %v, %v", info, err)
+	}
+}
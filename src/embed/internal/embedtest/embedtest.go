@@ -0,0 +1,7 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package embedtest holds the tests for package embed, which embed
+// files from this directory with //go:embed directives.
+package embedtest
@@ -0,0 +1,34 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package embedtest_test
+
+import (
+	"embed"
+	"testing"
+)
+
+var (
+	//go:embed testdata/*.txt
+	allTxt embed.FS
+
+	//go:embed testdata/hello.txt
+	helloString string
+
+	//go:embed testdata/hello.txt
+	helloBytes []byte
+)
+
+func TestXGlobal(t *testing.T) {
+	data, err := allTxt.ReadFile("testdata/ascii.txt")
+	if err != nil || string(data) != "ascii\n" {
+		t.Errorf("ReadFile(testdata/ascii.txt) = %q, %v", data, err)
+	}
+	if helloString != "hello, world\n" {
+		t.Errorf("helloString = %q", helloString)
+	}
+	if string(helloBytes) != "hello, world\n" {
+		t.Errorf("helloBytes = %q", helloBytes)
+	}
+}
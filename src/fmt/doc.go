@@ -49,6 +49,9 @@
 		%p	base 16 notation, with leading 0x
 		The %b, %d, %o, %x and %X verbs also work with pointers,
 		formatting the value exactly as if it were an integer.
+	Error (Errorf only):
+		%w	the error's message, as with %v; the returned error wraps
+			the operand so that errors.Unwrap returns it
 
 	The default format for %v is:
 		bool:                    %t
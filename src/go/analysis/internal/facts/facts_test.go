@@ -0,0 +1,107 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package facts_test
+
+import (
+	"encoding/gob"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+
+	"go/analysis/internal/facts"
+)
+
+type myFact struct {
+	S string
+}
+
+func (f *myFact) String() string { return fmt.Sprintf("myFact(%s)", f.S) }
+func (f *myFact) AFact()         {}
+
+func init() {
+	gob.Register(new(myFact))
+}
+
+// importer maps import paths to previously type-checked packages.
+type importer map[string]*types.Package
+
+func (imp importer) Import(path string) (*types.Package, error) {
+	if pkg, ok := imp[path]; ok {
+		return pkg, nil
+	}
+	return nil, fmt.Errorf("can't import %q", path)
+}
+
+func TestEncodeDecode(t *testing.T) {
+	// Each package exports a fact about itself and about its
+	// functions; c sees the facts of a through b, even though
+	// it does not import a directly.
+	pkgs := []struct {
+		path, src string
+	}{
+		{"a", `package a; type T int; func F() {}; func unexp() {}`},
+		{"b", `package b; import "a"; func G() a.T { a.F(); return 0 }`},
+		{"c", `package c; import "b"; var _ = b.G()`},
+	}
+
+	imp := make(importer)
+	encoded := make(map[string][]byte)
+	fset := token.NewFileSet()
+	for _, p := range pkgs {
+		f, err := parser.ParseFile(fset, p.path+".go", p.src, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		conf := types.Config{Importer: imp}
+		pkg, err := conf.Check(p.path, fset, []*ast.File{f}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		imp[p.path] = pkg
+
+		set, err := facts.Decode(pkg, func(path string) ([]byte, error) {
+			return encoded[path], nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Check the facts of the dependencies.
+		var got []string
+		for _, dep := range []string{"a", "b"} {
+			depPkg := imp[dep]
+			if depPkg == nil || depPkg == pkg {
+				continue
+			}
+			var fact myFact
+			if set.ImportPackageFact(depPkg, &fact) {
+				got = append(got, fact.S)
+			}
+		}
+		if p.path == "c" {
+			if want := "[a b]"; fmt.Sprint(got) != want {
+				t.Errorf("package facts visible from c: got %v, want %s", got, want)
+			}
+			var fact myFact
+			if !set.ImportObjectFact(imp["a"].Scope().Lookup("T"), &fact) || fact.S != "a.T" {
+				t.Errorf("object fact for a.T not visible from c: got %v", fact)
+			}
+			if set.ImportObjectFact(imp["a"].Scope().Lookup("unexp"), &fact) {
+				t.Errorf("unexpected fact for unexported a.unexp")
+			}
+		}
+
+		// Export facts for this package and its objects.
+		set.ExportPackageFact(&myFact{p.path})
+		for _, name := range pkg.Scope().Names() {
+			obj := pkg.Scope().Lookup(name)
+			set.ExportObjectFact(obj, &myFact{p.path + "." + name})
+		}
+		encoded[p.path] = set.Encode()
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package objectpath_test
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"strings"
+	"testing"
+
+	"go/analysis/internal/objectpath"
+)
+
+const src = `package b
+
+import "fmt"
+
+type A struct{ x int }
+
+func (A) Method(x string) bool { return false }
+
+type B A
+
+type T interface {
+	F() func() *struct{ X int }
+}
+
+type U interface {
+	fmt.Stringer
+	T
+}
+
+type M map[struct{ x int }]struct{ y int }
+
+var V func(Param int) (Result string)
+
+const C = 1
+
+func Func() {}
+
+type Alias = struct{ F int }
+
+var unexp int
+`
+
+func TestPaths(t *testing.T) {
+	pkg := typecheck(t, src)
+	for _, test := range []struct {
+		path    objectpath.Path
+		wantobj string
+	}{
+		{"A", "type b.A struct{x int}"},
+		{"A.UF0", "field x int"},
+		{"A.M0", "func (b.A).Method(x string) bool"},
+		{"A.M0.PA0", "var x string"},
+		{"A.M0.RA0", "var  bool"},
+		{"B.UF0", "field x int"},
+		{"T.UM0.RA0.RA0.EF0", "field X int"},
+		{"M.UKF0", "field x int"},
+		{"M.UEF0", "field y int"},
+		{"V", "var b.V func(Param int) (Result string)"},
+		{"V.PA0", "var Param int"},
+		{"V.RA0", "var Result string"},
+		{"C", "const b.C untyped int"},
+		{"Func", "func b.Func()"},
+		{"Alias.F0", "field F int"},
+		{"T.UM0", "func (b.T).F() func() *struct{X int}"},
+	} {
+		obj, err := objectpath.Object(pkg, test.path)
+		if err != nil {
+			t.Errorf("Object(%q) failed: %v", test.path, err)
+			continue
+		}
+		if got := obj.String(); got != test.wantobj {
+			t.Errorf("Object(%q) = %s, want %s", test.path, got, test.wantobj)
+		}
+
+		// Check that For returns a path that denotes the same object.
+		path, err := objectpath.For(obj)
+		if err != nil {
+			t.Errorf("For(%v) failed: %v", obj, err)
+			continue
+		}
+		obj2, err := objectpath.Object(pkg, path)
+		if err != nil {
+			t.Errorf("Object(For(%v)) = Object(%q) failed: %v", obj, path, err)
+			continue
+		}
+		if obj2 != obj {
+			t.Errorf("Object(For(%v)) = Object(%q) = %v, want same object", obj, path, obj2)
+		}
+	}
+}
+
+// TestSourceAndExportData checks that a method has the same path
+// whether its package was type-checked from source or loaded from
+// export data, even though the two list methods in different orders.
+func TestSourceAndExportData(t *testing.T) {
+	src, err := importer.For("source", nil).Import("testing")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gc, err := importer.For("gc", nil).Import("testing")
+	if err != nil {
+		t.Skip(err)
+	}
+	named := src.Scope().Lookup("common").Type().(*types.Named)
+	for i := 0; i < named.NumMethods(); i++ {
+		m := named.Method(i)
+		path, err := objectpath.For(m)
+		if err != nil {
+			t.Errorf("For(%v) failed: %v", m, err)
+			continue
+		}
+		obj, err := objectpath.Object(gc, path)
+		if err != nil {
+			t.Errorf("Object(%q) failed: %v", path, err)
+			continue
+		}
+		if obj.Name() != m.Name() {
+			t.Errorf("Object(%q) = %v, want method %s", path, obj, m.Name())
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	pkg := typecheck(t, src)
+	for _, test := range []struct {
+		path    objectpath.Path
+		wantErr string
+	}{
+		{"", "empty path"},
+		{"Z", `package b does not contain "Z"`},
+		{"A.", "invalid path: ends with '.', want [AFMO]"},
+		{"A.M9", "method index 9 out of range [0-1)"},
+		{"A.UF1", "field index 1 out of range [0-1)"},
+		{"V.K", "invalid path: ends with 'K', want [AFMO]"},
+		{"V.KA0", "cannot apply 'K' to func(Param int) (Result string) (got *types.Signature, want map)"},
+		{"A.F0", "cannot apply 'F' to b.A (got *types.Named, want struct)"},
+		{"U.UM1", "path denotes func (fmt.Stringer).String() string, which belongs to a different package"},
+	} {
+		obj, err := objectpath.Object(pkg, test.path)
+		if err == nil {
+			t.Errorf("Object(%q) = %s, want error", test.path, obj)
+			continue
+		}
+		if !strings.Contains(err.Error(), test.wantErr) {
+			t.Errorf("Object(%q) error was %q, want %q", test.path, err, test.wantErr)
+		}
+	}
+
+	// Objects that have no path.
+	for _, name := range []string{"unexp"} {
+		if path, err := objectpath.For(pkg.Scope().Lookup(name)); err == nil {
+			t.Errorf("For(%s) = %q, want error", name, path)
+		}
+	}
+	if path, err := objectpath.For(types.Universe.Lookup("int")); err == nil {
+		t.Errorf("For(int) = %q, want error", path)
+	}
+}
+
+func typecheck(t *testing.T, src string) *types.Package {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "b.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := types.Config{Importer: importer.Default()}
+	pkg, err := conf.Check("b", fset, []*ast.File{f}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pkg
+}
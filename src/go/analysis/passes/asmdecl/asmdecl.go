@@ -2,9 +2,9 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-// Identify mismatches between assembly files and Go func declarations.
-
-package main
+// Package asmdecl defines an Analyzer that reports mismatches between
+// assembly files and Go declarations.
+package asmdecl
 
 import (
 	"bytes"
@@ -13,11 +13,21 @@ import (
 	"go/build"
 	"go/token"
 	"go/types"
+	"log"
 	"regexp"
 	"strconv"
 	"strings"
+
+	"go/analysis"
+	"go/analysis/passes/internal/analysisutil"
 )
 
+var Analyzer = &analysis.Analyzer{
+	Name: "asmdecl",
+	Doc:  "report mismatches between assembly files and Go declarations",
+	Run:  run,
+}
+
 // 'kind' is a kind of assembly variable.
 // The kinds 1, 2, 4, 8 stand for values of that size.
 type asmKind int
@@ -118,48 +128,48 @@ var (
 	ppc64Suff    = re(`([BHWD])(ZU|Z|U|BR)?$`)
 )
 
-func asmCheck(pkg *Package) {
-	if !vet("asmdecl") {
-		return
-	}
-
+func run(pass *analysis.Pass) (interface{}, error) {
 	// No work if no assembly files.
-	if !pkg.hasFileWithSuffix(".s") {
-		return
+	var sfiles []string
+	for _, fname := range pass.OtherFiles {
+		if strings.HasSuffix(fname, ".s") {
+			sfiles = append(sfiles, fname)
+		}
+	}
+	if sfiles == nil {
+		return nil, nil
 	}
 
 	// Gather declarations. knownFunc[name][arch] is func description.
 	knownFunc := make(map[string]map[string]*asmFunc)
 
-	for _, f := range pkg.files {
-		if f.file != nil {
-			for _, decl := range f.file.Decls {
-				if decl, ok := decl.(*ast.FuncDecl); ok && decl.Body == nil {
-					knownFunc[decl.Name.Name] = f.asmParseDecl(decl)
-				}
+	for _, f := range pass.Files {
+		for _, decl := range f.Decls {
+			if decl, ok := decl.(*ast.FuncDecl); ok && decl.Body == nil {
+				knownFunc[decl.Name.Name] = asmParseDecl(pass, decl)
 			}
 		}
 	}
 
 Files:
-	for _, f := range pkg.files {
-		if !strings.HasSuffix(f.name, ".s") {
-			continue
+	for _, fname := range sfiles {
+		content, tf, err := analysisutil.ReadFile(pass.Fset, fname)
+		if err != nil {
+			return nil, err
 		}
-		Println("Checking file", f.name)
 
 		// Determine architecture from file name if possible.
 		var arch string
 		var archDef *asmArch
 		for _, a := range arches {
-			if strings.HasSuffix(f.name, "_"+a.name+".s") {
+			if strings.HasSuffix(fname, "_"+a.name+".s") {
 				arch = a.name
 				archDef = a
 				break
 			}
 		}
 
-		lines := strings.SplitAfter(string(f.content), "\n")
+		lines := strings.SplitAfter(string(content), "\n")
 		var (
 			fn                 *asmFunc
 			fnName             string
@@ -173,7 +183,7 @@ Files:
 			if fn != nil && fn.vars["ret"] != nil && !haveRetArg && len(retLine) > 0 {
 				v := fn.vars["ret"]
 				for _, line := range retLine {
-					f.Badf(token.NoPos, "%s:%d: [%s] %s: RET without writing to %d-byte ret+%d(FP)", f.name, line, arch, fnName, v.size, v.off)
+					pass.Reportf(analysisutil.LineStart(tf, line), "[%s] %s: RET without writing to %d-byte ret+%d(FP)", arch, fnName, v.size, v.off)
 				}
 			}
 			retLine = nil
@@ -182,7 +192,7 @@ Files:
 			lineno++
 
 			badf := func(format string, args ...interface{}) {
-				f.Badf(token.NoPos, "%s:%d: [%s] %s: %s", f.name, lineno, arch, fnName, fmt.Sprintf(format, args...))
+				pass.Reportf(analysisutil.LineStart(tf, lineno), "[%s] %s: %s", arch, fnName, fmt.Sprintf(format, args...))
 			}
 
 			if arch == "" {
@@ -225,16 +235,17 @@ Files:
 						}
 					}
 					if arch == "" {
-						f.Warnf(token.NoPos, "%s: cannot determine architecture for assembly file", f.name)
+						log.Printf("%s: cannot determine architecture for assembly file", fname)
 						continue Files
 					}
 				}
 				fnName = m[2]
-				if pkgName := strings.TrimSpace(m[1]); pkgName != "" {
-					pathParts := strings.Split(pkgName, "∕")
-					pkgName = pathParts[len(pathParts)-1]
-					if pkgName != f.pkg.path {
-						f.Warnf(token.NoPos, "%s:%d: [%s] cannot check cross-package assembly function: %s is in package %s", f.name, lineno, arch, fnName, pkgName)
+				if pkgPath := strings.TrimSpace(m[1]); pkgPath != "" {
+					// The assembler uses Unicode division slash within
+					// identifiers to represent the directory separator.
+					pkgPath = strings.Replace(pkgPath, "∕", "/", -1)
+					if pkgPath != pass.Pkg.Path() {
+						// log.Printf("%s:%d: [%s] cannot check cross-package assembly function: %s is in package %s", fname, lineno, arch, fnName, pkgPath)
 						fn = nil
 						fnName = ""
 						continue
@@ -356,6 +367,7 @@ Files:
 		}
 		flushRet()
 	}
+	return nil, nil
 }
 
 func asmKindForType(t types.Type, size int) asmKind {
@@ -478,7 +490,7 @@ func appendComponentsRecursive(arch *asmArch, t types.Type, cc []component, suff
 }
 
 // asmParseDecl parses a function decl for expected assembly variables.
-func (f *File) asmParseDecl(decl *ast.FuncDecl) map[string]*asmFunc {
+func asmParseDecl(pass *analysis.Pass, decl *ast.FuncDecl) map[string]*asmFunc {
 	var (
 		arch   *asmArch
 		fn     *asmFunc
@@ -490,7 +502,16 @@ func (f *File) asmParseDecl(decl *ast.FuncDecl) map[string]*asmFunc {
 	addParams := func(list []*ast.Field, isret bool) {
 		argnum := 0
 		for _, fld := range list {
-			t := f.pkg.types[fld.Type].Type
+			t := pass.TypesInfo.Types[fld.Type].Type
+
+			// The type checker does not record a type for
+			// the *ast.Ellipsis of a variadic parameter.
+			if t == nil {
+				if ell, ok := fld.Type.(*ast.Ellipsis); ok {
+					t = types.NewSlice(pass.TypesInfo.Types[ell.Elt].Type)
+				}
+			}
+
 			align := int(arch.sizes.Alignof(t))
 			size := int(arch.sizes.Sizeof(t))
 			offset += -offset & (align - 1)
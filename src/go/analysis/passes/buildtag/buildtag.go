@@ -0,0 +1,139 @@
+// Copyright 2013 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package buildtag defines an Analyzer that checks build tags.
+package buildtag
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"io/ioutil"
+	"strings"
+	"unicode"
+
+	"go/analysis"
+	"go/analysis/passes/internal/analysisutil"
+)
+
+var Analyzer = &analysis.Analyzer{
+	Name: "buildtag",
+	Doc:  "check that +build tags are well-formed and correctly located",
+	Run:  runBuildTag,
+}
+
+func runBuildTag(pass *analysis.Pass) (interface{}, error) {
+	for _, f := range pass.Files {
+		if err := checkGoFile(pass, f); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range pass.OtherFiles {
+		if err := checkOtherFile(pass, name); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+func checkGoFile(pass *analysis.Pass, f *ast.File) error {
+	tf := pass.Fset.File(f.Pos())
+	content, err := ioutil.ReadFile(tf.Name())
+	if err != nil {
+		return err
+	}
+	checkContent(pass, tf, content)
+	return nil
+}
+
+func checkOtherFile(pass *analysis.Pass, filename string) error {
+	content, tf, err := analysisutil.ReadFile(pass.Fset, filename)
+	if err != nil {
+		return err
+	}
+	checkContent(pass, tf, content)
+	return nil
+}
+
+var (
+	nl         = []byte("\n")
+	slashSlash = []byte("//")
+	plusBuild  = []byte("+build")
+)
+
+// checkContent checks that the build tags in the content of file tf
+// are in the correct location and well-formed.
+func checkContent(pass *analysis.Pass, tf *token.File, content []byte) {
+	report := func(line int, format string, args ...interface{}) {
+		pass.Reportf(analysisutil.LineStart(tf, line), format, args...)
+	}
+
+	lines := bytes.SplitAfter(content, nl)
+
+	// Determine cutpoint where +build comments are no longer valid.
+	// They are valid in leading // comments in the file followed by
+	// a blank line.
+	var cutoff int
+	for i, line := range lines {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			cutoff = i
+			continue
+		}
+		if bytes.HasPrefix(line, slashSlash) {
+			continue
+		}
+		break
+	}
+
+	for i, line := range lines {
+		line = bytes.TrimSpace(line)
+		if !bytes.HasPrefix(line, slashSlash) {
+			continue
+		}
+		text := bytes.TrimSpace(line[2:])
+		if bytes.HasPrefix(text, plusBuild) {
+			fields := bytes.Fields(text)
+			if !bytes.Equal(fields[0], plusBuild) {
+				// Comment is something like +buildasdf not +build.
+				report(i+1, "possible malformed +build comment")
+				continue
+			}
+			if i >= cutoff {
+				report(i+1, "+build comment must appear before package clause and be followed by a blank line")
+				continue
+			}
+			// Check arguments.
+			if msg := checkArguments(fields); msg != "" {
+				report(i+1, "%s", msg)
+			}
+			continue
+		}
+		// Comment with +build but not at beginning.
+		if bytes.Contains(line, plusBuild) && i < cutoff {
+			report(i+1, "possible malformed +build comment")
+			continue
+		}
+	}
+}
+
+// checkArguments returns a description of the first problem
+// with the arguments of a +build line, or "" if there is none.
+func checkArguments(fields [][]byte) string {
+	for _, arg := range fields[1:] {
+		for _, elem := range strings.Split(string(arg), ",") {
+			if strings.HasPrefix(elem, "!!") {
+				return fmt.Sprintf("invalid double negative in build constraint: %s", arg)
+			}
+			elem = strings.TrimPrefix(elem, "!")
+			for _, c := range elem {
+				if !unicode.IsLetter(c) && !unicode.IsDigit(c) && c != '_' && c != '.' {
+					return fmt.Sprintf("invalid non-alphanumeric build constraint: %s", arg)
+				}
+			}
+		}
+	}
+	return ""
+}
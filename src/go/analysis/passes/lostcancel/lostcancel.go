@@ -2,41 +2,70 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-package main
+// Package lostcancel defines an Analyzer that checks for failure to
+// call a context cancelation function.
+package lostcancel
 
 import (
-	"cmd/vet/internal/cfg"
 	"fmt"
 	"go/ast"
 	"go/types"
-	"strconv"
+
+	"go/analysis"
+	"go/analysis/passes/ctrlflow"
+	"go/analysis/passes/inspect"
+	"go/analysis/passes/internal/analysisutil"
+	"go/ast/inspector"
+	"go/cfg"
 )
 
-func init() {
-	register("lostcancel",
-		"check for failure to call cancelation function returned by context.WithCancel",
-		checkLostCancel,
-		funcDecl, funcLit)
+const Doc = `check cancel func returned by context.WithCancel is called
+
+The cancelation function returned by context.WithCancel, WithTimeout,
+and WithDeadline must be called or the new context will remain live
+until its parent context is cancelled.
+(The background context is never cancelled.)`
+
+var Analyzer = &analysis.Analyzer{
+	Name: "lostcancel",
+	Doc:  Doc,
+	Run:  run,
+	Requires: []*analysis.Analyzer{
+		inspect.Analyzer,
+		ctrlflow.Analyzer,
+	},
 }
 
-const debugLostCancel = false
+const debug = false
 
 var contextPackage = "context"
 
-// checkLostCancel reports a failure to the call the cancel function
+func run(pass *analysis.Pass) (interface{}, error) {
+	// Fast path: bypass check if package doesn't import context.
+	if !analysisutil.Imports(pass.Pkg, contextPackage) {
+		return nil, nil
+	}
+
+	// Call runFunc for each Func{Decl,Lit}.
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	nodeTypes := []ast.Node{
+		(*ast.FuncLit)(nil),
+		(*ast.FuncDecl)(nil),
+	}
+	inspect.Preorder(nodeTypes, func(n ast.Node) {
+		runFunc(pass, n)
+	})
+	return nil, nil
+}
+
+// runFunc reports a failure to call the cancel function
 // returned by context.WithCancel, either because the variable was
 // assigned to the blank identifier, or because there exists a
 // control-flow path from the call to a return statement and that path
 // does not "use" the cancel function.  Any reference to the variable
 // counts as a use, even within a nested function literal.
-//
-// checkLostCancel analyzes a single named or literal function.
-func checkLostCancel(f *File, node ast.Node) {
-	// Fast path: bypass check if file doesn't use context.WithCancel.
-	if !hasImport(f.file, contextPackage) {
-		return
-	}
-
+// It analyzes a single named or literal function.
+func runFunc(pass *analysis.Pass, node ast.Node) {
 	// Maps each cancel variable to its defining ValueSpec/AssignStmt.
 	cancelvars := make(map[*types.Var]ast.Node)
 
@@ -60,7 +89,7 @@ func checkLostCancel(f *File, node ast.Node) {
 		//   ctx, cancel     = context.WithCancel(...)
 		//   var ctx, cancel = context.WithCancel(...)
 		//
-		if isContextWithCancel(f, n) && isCall(stack[len(stack)-2]) {
+		if isContextWithCancel(pass, n) && isCall(stack[len(stack)-2]) {
 			var id *ast.Ident // id of cancel var
 			stmt := stack[len(stack)-3]
 			switch stmt := stmt.(type) {
@@ -75,11 +104,11 @@ func checkLostCancel(f *File, node ast.Node) {
 			}
 			if id != nil {
 				if id.Name == "_" {
-					f.Badf(id.Pos(), "the cancel function returned by context.%s should be called, not discarded, to avoid a context leak",
+					pass.Reportf(id.Pos(), "the cancel function returned by context.%s should be called, not discarded, to avoid a context leak",
 						n.(*ast.SelectorExpr).Sel.Name)
-				} else if v, ok := f.pkg.uses[id].(*types.Var); ok {
+				} else if v, ok := pass.TypesInfo.Uses[id].(*types.Var); ok {
 					cancelvars[v] = stmt
-				} else if v, ok := f.pkg.defs[id].(*types.Var); ok {
+				} else if v, ok := pass.TypesInfo.Defs[id].(*types.Var); ok {
 					cancelvars[v] = stmt
 				}
 			}
@@ -92,66 +121,53 @@ func checkLostCancel(f *File, node ast.Node) {
 		return // no need to build CFG
 	}
 
-	// Tell the CFG builder which functions never return.
-	info := &types.Info{Uses: f.pkg.uses, Selections: f.pkg.selectors}
-	mayReturn := func(call *ast.CallExpr) bool {
-		name := callName(info, call)
-		return !noReturnFuncs[name]
-	}
-
-	// Build the CFG.
+	// Obtain the CFG.
+	cfgs := pass.ResultOf[ctrlflow.Analyzer].(*ctrlflow.CFGs)
 	var g *cfg.CFG
 	var sig *types.Signature
 	switch node := node.(type) {
 	case *ast.FuncDecl:
-		obj := f.pkg.defs[node.Name]
+		obj := pass.TypesInfo.Defs[node.Name]
 		if obj == nil {
 			return // type error (e.g. duplicate function declaration)
 		}
 		sig, _ = obj.Type().(*types.Signature)
-		g = cfg.New(node.Body, mayReturn)
+		g = cfgs.FuncDecl(node)
 	case *ast.FuncLit:
-		sig, _ = f.pkg.types[node.Type].Type.(*types.Signature)
-		g = cfg.New(node.Body, mayReturn)
+		sig, _ = pass.TypesInfo.Types[node.Type].Type.(*types.Signature)
+		g = cfgs.FuncLit(node)
+	}
+	if g == nil {
+		return // no function body
 	}
 
 	// Print CFG.
-	if debugLostCancel {
-		fmt.Println(g.Format(f.fset))
+	if debug {
+		fmt.Println(g.Format(pass.Fset))
 	}
 
 	// Examine the CFG for each variable in turn.
 	// (It would be more efficient to analyze all cancelvars in a
 	// single pass over the AST, but seldom is there more than one.)
 	for v, stmt := range cancelvars {
-		if ret := lostCancelPath(f, g, v, stmt, sig); ret != nil {
-			lineno := f.fset.Position(stmt.Pos()).Line
-			f.Badf(stmt.Pos(), "the %s function is not used on all paths (possible context leak)", v.Name())
-			f.Badf(ret.Pos(), "this return statement may be reached without using the %s var defined on line %d", v.Name(), lineno)
+		if ret := lostCancelPath(pass, g, v, stmt, sig); ret != nil {
+			lineno := pass.Fset.Position(stmt.Pos()).Line
+			pass.Reportf(stmt.Pos(), "the %s function is not used on all paths (possible context leak)", v.Name())
+			pass.Reportf(ret.Pos(), "this return statement may be reached without using the %s var defined on line %d", v.Name(), lineno)
 		}
 	}
 }
 
 func isCall(n ast.Node) bool { _, ok := n.(*ast.CallExpr); return ok }
 
-func hasImport(f *ast.File, path string) bool {
-	for _, imp := range f.Imports {
-		v, _ := strconv.Unquote(imp.Path.Value)
-		if v == path {
-			return true
-		}
-	}
-	return false
-}
-
 // isContextWithCancel reports whether n is one of the qualified identifiers
 // context.With{Cancel,Timeout,Deadline}.
-func isContextWithCancel(f *File, n ast.Node) bool {
+func isContextWithCancel(pass *analysis.Pass, n ast.Node) bool {
 	if sel, ok := n.(*ast.SelectorExpr); ok {
 		switch sel.Sel.Name {
 		case "WithCancel", "WithTimeout", "WithDeadline":
 			if x, ok := sel.X.(*ast.Ident); ok {
-				if pkgname, ok := f.pkg.uses[x].(*types.PkgName); ok {
+				if pkgname, ok := pass.TypesInfo.Uses[x].(*types.PkgName); ok {
 					return pkgname.Imported().Path() == contextPackage
 				}
 				// Import failed, so we can't check package path.
@@ -167,17 +183,17 @@ func isContextWithCancel(f *File, n ast.Node) bool {
 // the 'cancel' variable v) to a return statement, that doesn't "use" v.
 // If it finds one, it returns the return statement (which may be synthetic).
 // sig is the function's type, if known.
-func lostCancelPath(f *File, g *cfg.CFG, v *types.Var, stmt ast.Node, sig *types.Signature) *ast.ReturnStmt {
+func lostCancelPath(pass *analysis.Pass, g *cfg.CFG, v *types.Var, stmt ast.Node, sig *types.Signature) *ast.ReturnStmt {
 	vIsNamedResult := sig != nil && tupleContains(sig.Results(), v)
 
 	// uses reports whether stmts contain a "use" of variable v.
-	uses := func(f *File, v *types.Var, stmts []ast.Node) bool {
+	uses := func(pass *analysis.Pass, v *types.Var, stmts []ast.Node) bool {
 		found := false
 		for _, stmt := range stmts {
 			ast.Inspect(stmt, func(n ast.Node) bool {
 				switch n := n.(type) {
 				case *ast.Ident:
-					if f.pkg.uses[n] == v {
+					if pass.TypesInfo.Uses[n] == v {
 						found = true
 					}
 				case *ast.ReturnStmt:
@@ -195,10 +211,10 @@ func lostCancelPath(f *File, g *cfg.CFG, v *types.Var, stmt ast.Node, sig *types
 
 	// blockUses computes "uses" for each block, caching the result.
 	memo := make(map[*cfg.Block]bool)
-	blockUses := func(f *File, v *types.Var, b *cfg.Block) bool {
+	blockUses := func(pass *analysis.Pass, v *types.Var, b *cfg.Block) bool {
 		res, ok := memo[b]
 		if !ok {
-			res = uses(f, v, b.Nodes)
+			res = uses(pass, v, b.Nodes)
 			memo[b] = res
 		}
 		return res
@@ -223,7 +239,7 @@ outer:
 	}
 
 	// Is v "used" in the remainder of its defining block?
-	if uses(f, v, rest) {
+	if uses(pass, v, rest) {
 		return nil
 	}
 
@@ -242,13 +258,13 @@ outer:
 				seen[b] = true
 
 				// Prune the search if the block uses v.
-				if blockUses(f, v, b) {
+				if blockUses(pass, v, b) {
 					continue
 				}
 
 				// Found path to return statement?
 				if ret := b.Return(); ret != nil {
-					if debugLostCancel {
+					if debug {
 						fmt.Printf("found path to return in block %s\n", b)
 					}
 					return ret // found
@@ -256,7 +272,7 @@ outer:
 
 				// Recur
 				if ret := search(b.Succs); ret != nil {
-					if debugLostCancel {
+					if debug {
 						fmt.Printf(" from block %s\n", b)
 					}
 					return ret
@@ -276,47 +292,3 @@ func tupleContains(tuple *types.Tuple, v *types.Var) bool {
 	}
 	return false
 }
-
-var noReturnFuncs = map[string]bool{
-	"(*testing.common).FailNow": true,
-	"(*testing.common).Fatal":   true,
-	"(*testing.common).Fatalf":  true,
-	"(*testing.common).Skip":    true,
-	"(*testing.common).SkipNow": true,
-	"(*testing.common).Skipf":   true,
-	"log.Fatal":                 true,
-	"log.Fatalf":                true,
-	"log.Fatalln":               true,
-	"os.Exit":                   true,
-	"panic":                     true,
-	"runtime.Goexit":            true,
-}
-
-// callName returns the canonical name of the builtin, method, or
-// function called by call, if known.
-func callName(info *types.Info, call *ast.CallExpr) string {
-	switch fun := call.Fun.(type) {
-	case *ast.Ident:
-		// builtin, e.g. "panic"
-		if obj, ok := info.Uses[fun].(*types.Builtin); ok {
-			return obj.Name()
-		}
-	case *ast.SelectorExpr:
-		if sel, ok := info.Selections[fun]; ok && sel.Kind() == types.MethodVal {
-			// method call, e.g. "(*testing.common).Fatal"
-			meth := sel.Obj()
-			return fmt.Sprintf("(%s).%s",
-				meth.Type().(*types.Signature).Recv().Type(),
-				meth.Name())
-		}
-		if obj, ok := info.Uses[fun.Sel]; ok {
-			// qualified identifier, e.g. "os.Exit"
-			return fmt.Sprintf("%s.%s",
-				obj.Pkg().Path(),
-				obj.Name())
-		}
-	}
-
-	// function with no name, or defined in missing imported package
-	return ""
-}
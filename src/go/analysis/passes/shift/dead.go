@@ -1,20 +1,21 @@
 // Copyright 2017 The Go Authors. All rights reserved.
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
-//
-// Simplified dead code detector. Used for skipping certain checks
-// on unreachable code (for instance, shift checks on arch-specific code).
 
-package main
+package shift
+
+// Simplified dead code detector.
+// Used for skipping shift checks on unreachable arch-specific code.
 
 import (
 	"go/ast"
 	"go/constant"
+	"go/types"
 )
 
-// updateDead puts unreachable "if" and "case" nodes into f.dead.
-func (f *File) updateDead(node ast.Node) {
-	if f.dead[node] {
+// updateDead puts unreachable "if" and "case" nodes into dead.
+func updateDead(info *types.Info, dead map[ast.Node]bool, node ast.Node) {
+	if dead[node] {
 		// The node is already marked as dead.
 		return
 	}
@@ -23,15 +24,17 @@ func (f *File) updateDead(node ast.Node) {
 	case *ast.IfStmt:
 		// "if" branch is dead if its condition evaluates
 		// to constant false.
-		v := f.pkg.types[stmt.Cond].Value
+		v := info.Types[stmt.Cond].Value
 		if v == nil {
 			return
 		}
 		if !constant.BoolVal(v) {
-			f.setDead(stmt.Body)
+			setDead(dead, stmt.Body)
 			return
 		}
-		f.setDead(stmt.Else)
+		if stmt.Else != nil {
+			setDead(dead, stmt.Else)
+		}
 	case *ast.SwitchStmt:
 		// Case clause with empty switch tag is dead if it evaluates
 		// to constant false.
@@ -44,12 +47,12 @@ func (f *File) updateDead(node ast.Node) {
 					continue
 				}
 				for _, expr := range cc.List {
-					v := f.pkg.types[expr].Value
+					v := info.Types[expr].Value
 					if v == nil || constant.BoolVal(v) {
 						continue BodyLoopBool
 					}
 				}
-				f.setDead(cc)
+				setDead(dead, cc)
 			}
 			return
 		}
@@ -57,7 +60,7 @@ func (f *File) updateDead(node ast.Node) {
 		// Case clause is dead if its constant value doesn't match
 		// the constant value from the switch tag.
 		// TODO: This handles integer comparisons only.
-		v := f.pkg.types[stmt.Tag].Value
+		v := info.Types[stmt.Tag].Value
 		if v == nil || v.Kind() != constant.Int {
 			return
 		}
@@ -73,7 +76,7 @@ func (f *File) updateDead(node ast.Node) {
 				continue
 			}
 			for _, expr := range cc.List {
-				v := f.pkg.types[expr].Value
+				v := info.Types[expr].Value
 				if v == nil {
 					continue BodyLoopInt
 				}
@@ -82,27 +85,17 @@ func (f *File) updateDead(node ast.Node) {
 					continue BodyLoopInt
 				}
 			}
-			f.setDead(cc)
+			setDead(dead, cc)
 		}
 	}
 }
 
 // setDead marks the node and all the children as dead.
-func (f *File) setDead(node ast.Node) {
-	dv := deadVisitor{
-		f: f,
-	}
-	ast.Walk(dv, node)
-}
-
-type deadVisitor struct {
-	f *File
-}
-
-func (dv deadVisitor) Visit(node ast.Node) ast.Visitor {
-	if node == nil {
-		return nil
-	}
-	dv.f.dead[node] = true
-	return dv
+func setDead(dead map[ast.Node]bool, node ast.Node) {
+	ast.Inspect(node, func(node ast.Node) bool {
+		if node != nil {
+			dead[node] = true
+		}
+		return true
+	})
 }
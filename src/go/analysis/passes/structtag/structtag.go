@@ -2,52 +2,70 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-// This file contains the test for canonical struct tags.
-
-package main
+// Package structtag defines an Analyzer that checks struct field tags
+// are well formed.
+package structtag
 
 import (
 	"errors"
+	"fmt"
 	"go/ast"
 	"go/token"
+	"path/filepath"
 	"reflect"
 	"strconv"
 	"strings"
+
+	"go/analysis"
+	"go/analysis/passes/inspect"
+	"go/ast/inspector"
 )
 
-func init() {
-	register("structtags",
-		"check that struct field tags have canonical format and apply to exported fields as needed",
-		checkStructFieldTags,
-		structType)
+const Doc = `check that struct field tags conform to reflect.StructTag.Get
+
+Also report certain struct tags (json, xml) used with unexported fields.`
+
+var Analyzer = &analysis.Analyzer{
+	Name:             "structtag",
+	Doc:              Doc,
+	Requires:         []*analysis.Analyzer{inspect.Analyzer},
+	RunDespiteErrors: true,
+	Run:              run,
 }
 
-// checkStructFieldTags checks all the field tags of a struct, including checking for duplicates.
-func checkStructFieldTags(f *File, node ast.Node) {
-	var seen map[[2]string]token.Pos
-	for _, field := range node.(*ast.StructType).Fields.List {
-		checkCanonicalFieldTag(f, field, &seen)
+func run(pass *analysis.Pass) (interface{}, error) {
+	inspect := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+
+	nodeFilter := []ast.Node{
+		(*ast.StructType)(nil),
 	}
+	inspect.Preorder(nodeFilter, func(n ast.Node) {
+		var seen map[[2]string]token.Pos
+		for _, field := range n.(*ast.StructType).Fields.List {
+			checkCanonicalFieldTag(pass, field, &seen)
+		}
+	})
+	return nil, nil
 }
 
 var checkTagDups = []string{"json", "xml"}
 var checkTagSpaces = map[string]bool{"json": true, "xml": true, "asn1": true}
 
 // checkCanonicalFieldTag checks a single struct field tag.
-func checkCanonicalFieldTag(f *File, field *ast.Field, seen *map[[2]string]token.Pos) {
+func checkCanonicalFieldTag(pass *analysis.Pass, field *ast.Field, seen *map[[2]string]token.Pos) {
 	if field.Tag == nil {
 		return
 	}
 
 	tag, err := strconv.Unquote(field.Tag.Value)
 	if err != nil {
-		f.Badf(field.Pos(), "unable to read struct tag %s", field.Tag.Value)
+		pass.Reportf(field.Pos(), "unable to read struct tag %s", field.Tag.Value)
 		return
 	}
 
 	if err := validateStructTag(tag); err != nil {
 		raw, _ := strconv.Unquote(field.Tag.Value) // field.Tag.Value is known to be a quoted string
-		f.Badf(field.Pos(), "struct field tag %#q not compatible with reflect.StructTag.Get: %s", raw, err)
+		pass.Reportf(field.Pos(), "struct field tag %#q not compatible with reflect.StructTag.Get: %s", raw, err)
 	}
 
 	for _, key := range checkTagDups {
@@ -85,7 +103,7 @@ func checkCanonicalFieldTag(f *File, field *ast.Field, seen *map[[2]string]token
 			} else {
 				name = field.Type.(*ast.Ident).Name
 			}
-			f.Badf(field.Pos(), "struct field %s repeats %s tag %q also at %s", name, key, val, f.loc(pos))
+			pass.Reportf(field.Pos(), "struct field %s repeats %s tag %q also at %s", name, key, val, loc(pass.Fset, field.Pos(), pos))
 		} else {
 			(*seen)[[2]string{key, val}] = field.Pos()
 		}
@@ -105,12 +123,25 @@ func checkCanonicalFieldTag(f *File, field *ast.Field, seen *map[[2]string]token
 
 	for _, enc := range [...]string{"json", "xml"} {
 		if reflect.StructTag(tag).Get(enc) != "" {
-			f.Badf(field.Pos(), "struct field %s has %s tag but is not exported", field.Names[0].Name, enc)
+			pass.Reportf(field.Pos(), "struct field %s has %s tag but is not exported", field.Names[0].Name, enc)
 			return
 		}
 	}
 }
 
+// loc returns the file and line of pos. Columns are omitted because
+// pos is the start of the field, not the part of the tag at fault.
+// The file name is made relative to the directory of the file
+// containing from, so the message does not depend on how the
+// package was loaded.
+func loc(fset *token.FileSet, from, pos token.Pos) string {
+	posn := fset.Position(pos)
+	if rel, err := filepath.Rel(filepath.Dir(fset.Position(from).Filename), posn.Filename); err == nil {
+		posn.Filename = rel
+	}
+	return fmt.Sprintf("%s:%d", posn.Filename, posn.Line)
+}
+
 var (
 	errTagSyntax      = errors.New("bad syntax for struct tag pair")
 	errTagKeySyntax   = errors.New("bad syntax for struct tag key")
@@ -2,7 +2,9 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-package main
+// Package tests defines an Analyzer that checks for common mistaken
+// usages of tests and examples.
+package tests
 
 import (
 	"go/ast"
@@ -10,13 +12,47 @@ import (
 	"strings"
 	"unicode"
 	"unicode/utf8"
+
+	"go/analysis"
 )
 
-func init() {
-	register("tests",
-		"check for common mistaken usages of tests/documentation examples",
-		checkTestFunctions,
-		funcDecl)
+const Doc = `check for common mistaken usages of tests and examples
+
+The tests checker walks Test, Benchmark and Example functions checking
+malformed names, wrong signatures and examples documenting non-existent
+identifiers.`
+
+var Analyzer = &analysis.Analyzer{
+	Name: "tests",
+	Doc:  Doc,
+	Run:  run,
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	for _, f := range pass.Files {
+		if !strings.HasSuffix(pass.Fset.File(f.Pos()).Name(), "_test.go") {
+			continue
+		}
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv != nil {
+				// Ignore non-functions or functions with receivers.
+				continue
+			}
+
+			report := func(format string, args ...interface{}) { pass.Reportf(fn.Pos(), format, args...) }
+
+			switch {
+			case strings.HasPrefix(fn.Name.Name, "Example"):
+				checkExample(fn, pass, report)
+			case strings.HasPrefix(fn.Name.Name, "Test"):
+				checkTest(fn, "Test", report)
+			case strings.HasPrefix(fn.Name.Name, "Benchmark"):
+				checkTest(fn, "Benchmark", report)
+			}
+		}
+	}
+	return nil, nil
 }
 
 func isExampleSuffix(s string) bool {
@@ -59,28 +95,25 @@ func lookup(name string, scopes []*types.Scope) types.Object {
 	return nil
 }
 
-func extendedScope(f *File) []*types.Scope {
-	scopes := []*types.Scope{f.pkg.typesPkg.Scope()}
-	if f.basePkg != nil {
-		scopes = append(scopes, f.basePkg.typesPkg.Scope())
-	} else {
-		// If basePkg is not specified (e.g. when checking a single file) try to
-		// find it among imports.
-		pkgName := f.pkg.typesPkg.Name()
-		if strings.HasSuffix(pkgName, "_test") {
-			basePkgName := strings.TrimSuffix(pkgName, "_test")
-			for _, p := range f.pkg.typesPkg.Imports() {
-				if p.Name() == basePkgName {
-					scopes = append(scopes, p.Scope())
-					break
-				}
+func extendedScope(pass *analysis.Pass) []*types.Scope {
+	scopes := []*types.Scope{pass.Pkg.Scope()}
+
+	// An external test package (x_test) may refer to identifiers
+	// of the package under test.
+	pkgName := pass.Pkg.Name()
+	if strings.HasSuffix(pkgName, "_test") {
+		basePkgName := strings.TrimSuffix(pkgName, "_test")
+		for _, p := range pass.Pkg.Imports() {
+			if p.Name() == basePkgName {
+				scopes = append(scopes, p.Scope())
+				break
 			}
 		}
 	}
 	return scopes
 }
 
-func checkExample(fn *ast.FuncDecl, f *File, report reporter) {
+func checkExample(fn *ast.FuncDecl, pass *analysis.Pass, report reporter) {
 	fnName := fn.Name.Name
 	if params := fn.Type.Params; len(params.List) != 0 {
 		report("%s should be niladic", fnName)
@@ -89,13 +122,6 @@ func checkExample(fn *ast.FuncDecl, f *File, report reporter) {
 		report("%s should return nothing", fnName)
 	}
 
-	if filesRun && !includesNonTest {
-		// The coherence checks between a test and the package it tests
-		// will report false positives if no non-test files have
-		// been provided.
-		return
-	}
-
 	if fnName == "Example" {
 		// Nothing more to do.
 		return
@@ -105,7 +131,7 @@ func checkExample(fn *ast.FuncDecl, f *File, report reporter) {
 		exName = strings.TrimPrefix(fnName, "Example")
 		elems  = strings.SplitN(exName, "_", 3)
 		ident  = elems[0]
-		obj    = lookup(ident, extendedScope(f))
+		obj    = lookup(ident, extendedScope(pass))
 	)
 	if ident != "" && obj == nil {
 		// Check ExampleFoo and ExampleBadFoo.
@@ -159,29 +185,3 @@ func checkTest(fn *ast.FuncDecl, prefix string, report reporter) {
 }
 
 type reporter func(format string, args ...interface{})
-
-// checkTestFunctions walks Test, Benchmark and Example functions checking
-// malformed names, wrong signatures and examples documenting nonexistent
-// identifiers.
-func checkTestFunctions(f *File, node ast.Node) {
-	if !strings.HasSuffix(f.name, "_test.go") {
-		return
-	}
-
-	fn, ok := node.(*ast.FuncDecl)
-	if !ok || fn.Recv != nil {
-		// Ignore non-functions or functions with receivers.
-		return
-	}
-
-	report := func(format string, args ...interface{}) { f.Badf(node.Pos(), format, args...) }
-
-	switch {
-	case strings.HasPrefix(fn.Name.Name, "Example"):
-		checkExample(fn, f, report)
-	case strings.HasPrefix(fn.Name.Name, "Test"):
-		checkTest(fn, "Test", report)
-	case strings.HasPrefix(fn.Name.Name, "Benchmark"):
-		checkTest(fn, "Benchmark", report)
-	}
-}
@@ -0,0 +1,90 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"strings"
+	"testing"
+)
+
+type testFact struct{}
+
+func (*testFact) AFact() {}
+
+type badFact struct{}
+
+func (badFact) AFact() {}
+
+func TestValidate(t *testing.T) {
+	var (
+		dependsOnSelf = &Analyzer{
+			Name: "dependsOnSelf",
+			Doc:  "this analyzer depends on itself",
+		}
+		inCycleA = &Analyzer{
+			Name: "inCycleA",
+			Doc:  "this analyzer depends on inCycleB",
+		}
+		inCycleB = &Analyzer{
+			Name: "inCycleB",
+			Doc:  "this analyzer depends on inCycleA and notInCycleA",
+		}
+		pointsToCycle = &Analyzer{
+			Name: "pointsToCycle",
+			Doc:  "this analyzer depends on inCycleA",
+		}
+		notInCycleA = &Analyzer{
+			Name: "notInCycleA",
+			Doc:  "this analyzer depends on notInCycleB and notInCycleC",
+		}
+		notInCycleB = &Analyzer{
+			Name: "notInCycleB",
+			Doc:  "this analyzer depends on notInCycleC",
+		}
+		notInCycleC = &Analyzer{
+			Name: "notInCycleC",
+			Doc:  "this analyzer has no dependencies",
+		}
+	)
+
+	dependsOnSelf.Requires = append(dependsOnSelf.Requires, dependsOnSelf)
+	inCycleA.Requires = append(inCycleA.Requires, inCycleB)
+	inCycleB.Requires = append(inCycleB.Requires, inCycleA, notInCycleA)
+	pointsToCycle.Requires = append(pointsToCycle.Requires, inCycleA)
+	notInCycleA.Requires = append(notInCycleA.Requires, notInCycleB, notInCycleC)
+	notInCycleB.Requires = append(notInCycleB.Requires, notInCycleC)
+	notInCycleC.Requires = []*Analyzer{}
+
+	cases := []struct {
+		analyzers []*Analyzer
+		err       string // substring of the expected error, or "" for success
+	}{
+		{[]*Analyzer{dependsOnSelf}, "cycle detected involving dependsOnSelf"},
+		{[]*Analyzer{inCycleA, inCycleB}, "cycle detected involving inCycleA"},
+		{[]*Analyzer{pointsToCycle}, "cycle detected involving inCycleA"},
+		{[]*Analyzer{notInCycleA}, ""},
+		{[]*Analyzer{notInCycleA, notInCycleA}, "duplicate analyzer: notInCycleA"},
+		{[]*Analyzer{{Name: "bad-name", Doc: "doc"}}, `invalid analyzer name "bad-name"`},
+		{[]*Analyzer{{Name: "nodoc"}}, `analyzer "nodoc" is undocumented`},
+		{[]*Analyzer{{Name: "badfact", Doc: "doc", FactTypes: []Fact{badFact{}}}}, "is not a pointer"},
+		{[]*Analyzer{
+			{Name: "fact1", Doc: "doc", FactTypes: []Fact{new(testFact)}},
+			{Name: "fact2", Doc: "doc", FactTypes: []Fact{new(testFact)}},
+		}, "registered by two analyzers"},
+	}
+
+	for _, c := range cases {
+		err := Validate(c.analyzers)
+		if c.err == "" {
+			if err != nil {
+				t.Errorf("Validate(%v) = %v, want nil", c.analyzers, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), c.err) {
+			t.Errorf("Validate(%v) = %v, want error containing %q", c.analyzers, err, c.err)
+		}
+	}
+}
@@ -425,6 +425,18 @@ type Package struct {
 	XTestGoFiles   []string                    // _test.go files outside package
 	XTestImports   []string                    // import paths from XTestGoFiles
 	XTestImportPos map[string][]token.Position // line information for XTestImports
+
+	// //go:embed patterns found in Go source files
+	// For example, if a source file says
+	//	//go:embed a* b.c
+	// then the list will contain those two strings as separate entries.
+	// (See package embed for more details about //go:embed.)
+	EmbedPatterns        []string                    // patterns from GoFiles, CgoFiles
+	EmbedPatternPos      map[string][]token.Position // line information for EmbedPatterns
+	TestEmbedPatterns    []string                    // patterns from TestGoFiles
+	TestEmbedPatternPos  map[string][]token.Position // line information for TestEmbedPatterns
+	XTestEmbedPatterns   []string                    // patterns from XTestGoFiles
+	XTestEmbedPatternPos map[string][]token.Position // line information for XTestEmbedPatterns
 }
 
 // IsCommand reports whether the package is considered a
@@ -717,6 +729,9 @@ Found:
 	imported := make(map[string][]token.Position)
 	testImported := make(map[string][]token.Position)
 	xTestImported := make(map[string][]token.Position)
+	embedPos := make(map[string][]token.Position)
+	testEmbedPos := make(map[string][]token.Position)
+	xTestEmbedPos := make(map[string][]token.Position)
 	allTags := make(map[string]bool)
 	fset := token.NewFileSet()
 	for _, d := range dirs {
@@ -835,6 +850,7 @@ Found:
 
 		// Record imports and information about cgo.
 		isCgo := false
+		isEmbed := false
 		for _, decl := range pf.Decls {
 			d, ok := decl.(*ast.GenDecl)
 			if !ok {
@@ -857,6 +873,9 @@ Found:
 				} else {
 					imported[path] = append(imported[path], fset.Position(spec.Pos()))
 				}
+				if path == "embed" {
+					isEmbed = true
+				}
 				if path == "C" {
 					if isTest {
 						badFile(fmt.Errorf("use of cgo in test %s not supported", filename))
@@ -875,6 +894,24 @@ Found:
 				}
 			}
 		}
+		if isEmbed {
+			// The //go:embed directives can appear anywhere in the file,
+			// not just in the header returned by matchFile.
+			embeds, err := ctxt.readEmbeds(filename)
+			if err != nil {
+				badFile(err)
+				continue
+			}
+			for _, emb := range embeds {
+				if isXTest {
+					xTestEmbedPos[emb.pattern] = append(xTestEmbedPos[emb.pattern], emb.pos)
+				} else if isTest {
+					testEmbedPos[emb.pattern] = append(testEmbedPos[emb.pattern], emb.pos)
+				} else {
+					embedPos[emb.pattern] = append(embedPos[emb.pattern], emb.pos)
+				}
+			}
+		}
 		if isCgo {
 			allTags["cgo"] = true
 			if ctxt.CgoEnabled {
@@ -902,9 +939,12 @@ Found:
 	}
 	sort.Strings(p.AllTags)
 
-	p.Imports, p.ImportPos = cleanImports(imported)
-	p.TestImports, p.TestImportPos = cleanImports(testImported)
-	p.XTestImports, p.XTestImportPos = cleanImports(xTestImported)
+	p.Imports, p.ImportPos = cleanDecls(imported)
+	p.TestImports, p.TestImportPos = cleanDecls(testImported)
+	p.XTestImports, p.XTestImportPos = cleanDecls(xTestImported)
+	p.EmbedPatterns, p.EmbedPatternPos = cleanDecls(embedPos)
+	p.TestEmbedPatterns, p.TestEmbedPatternPos = cleanDecls(testEmbedPos)
+	p.XTestEmbedPatterns, p.XTestEmbedPatternPos = cleanDecls(xTestEmbedPos)
 
 	// add the .S files only if we are using cgo
 	// (which means gcc will compile them).
@@ -1123,7 +1163,23 @@ func (ctxt *Context) matchFile(dir, name string, allTags map[string]bool, binary
 	return
 }
 
-func cleanImports(m map[string][]token.Position) ([]string, map[string][]token.Position) {
+// readEmbeds returns the //go:embed patterns in the named Go source file.
+func (ctxt *Context) readEmbeds(filename string) ([]fileEmbed, error) {
+	f, err := ctxt.openFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadAll(f)
+	f.Close()
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %v", filename, err)
+	}
+	return readGoEmbed(data, filename)
+}
+
+// cleanDecls returns the sorted keys of m, which maps import paths
+// or embed patterns to the positions where they appear, and m itself.
+func cleanDecls(m map[string][]token.Position) ([]string, map[string][]token.Position) {
 	all := make([]string, 0, len(m))
 	for path := range m {
 		all = append(all, path)
@@ -109,6 +109,26 @@ func TestMultiplePackageImport(t *testing.T) {
 	}
 }
 
+func TestImportEmbedPatterns(t *testing.T) {
+	p, err := ImportDir("testdata/embed", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"dir/*", "x.txt", "y y.txt"}; !reflect.DeepEqual(p.EmbedPatterns, want) {
+		t.Errorf("EmbedPatterns = %q, want %q", p.EmbedPatterns, want)
+	}
+	if want := []string{"t.txt"}; !reflect.DeepEqual(p.TestEmbedPatterns, want) {
+		t.Errorf("TestEmbedPatterns = %q, want %q", p.TestEmbedPatterns, want)
+	}
+	if want := []string{"xt.txt"}; !reflect.DeepEqual(p.XTestEmbedPatterns, want) {
+		t.Errorf("XTestEmbedPatterns = %q, want %q", p.XTestEmbedPatterns, want)
+	}
+	pos := p.EmbedPatternPos["y y.txt"]
+	if len(pos) != 1 || pos[0].Line != 5 || pos[0].Column != 18 {
+		t.Errorf("EmbedPatternPos[\"y y.txt\"] = %v, want line 5 column 18", pos)
+	}
+}
+
 func TestLocalDirectory(t *testing.T) {
 	if runtime.GOOS == "darwin" {
 		switch runtime.GOARCH {
@@ -34,7 +34,7 @@ import (
 //
 var pkgDeps = map[string][]string{
 	// L0 is the lowest level, core, nearly unavoidable packages.
-	"errors":                  {},
+	"errors":                  {"runtime", "internal/reflectlite"},
 	"io":                      {"errors", "sync", "sync/atomic"},
 	"runtime":                 {"unsafe", "runtime/internal/atomic", "runtime/internal/sys", "internal/cpu", "internal/bytealg"},
 	"runtime/internal/sys":    {},
@@ -45,6 +45,8 @@ var pkgDeps = map[string][]string{
 	"unsafe":                  {},
 	"internal/cpu":            {},
 	"internal/bytealg":        {"unsafe", "internal/cpu"},
+	"internal/reflectlite":    {"runtime", "unsafe"},
+	"internal/oserror":        {"errors"},
 
 	"L0": {
 		"errors",
@@ -56,6 +58,8 @@ var pkgDeps = map[string][]string{
 		"unsafe",
 		"internal/cpu",
 		"internal/bytealg",
+		"internal/reflectlite",
+		"internal/oserror",
 	},
 
 	// L1 adds simple functions and strings processing,
@@ -158,15 +162,19 @@ var pkgDeps = map[string][]string{
 
 	"internal/poll":    {"L0", "internal/race", "syscall", "time", "unicode/utf16", "unicode/utf8", "internal/syscall/windows"},
 	"internal/testlog": {"L0"},
+	"log/internal":     {"L0"},
 	"os":               {"L1", "os", "syscall", "time", "internal/poll", "internal/syscall/windows", "internal/testlog"},
 	"path/filepath":    {"L2", "os", "syscall", "internal/syscall/windows"},
 	"io/ioutil":        {"L2", "os", "path/filepath", "time"},
+	"io/fs":            {"L2", "os"},
+	"embed":            {"L2", "io/fs", "time"},
 	"os/exec":          {"L2", "os", "context", "path/filepath", "syscall"},
 	"os/signal":        {"L2", "os", "syscall"},
 
 	// OS enables basic operating system functionality,
 	// but not direct use of package syscall, nor os/signal.
 	"OS": {
+		"io/fs",
 		"io/ioutil",
 		"os",
 		"os/exec",
@@ -176,7 +184,7 @@ var pkgDeps = map[string][]string{
 
 	// Formatted I/O: few dependencies (L1) but we must add reflect.
 	"fmt": {"L1", "os", "reflect"},
-	"log": {"L1", "os", "fmt", "time"},
+	"log": {"L1", "os", "fmt", "log/internal", "time"},
 
 	// Packages used by testing must be low-level (L2+fmt).
 	"regexp":         {"L2", "regexp/syntax"},
@@ -186,7 +194,7 @@ var pkgDeps = map[string][]string{
 	"runtime/trace":  {"L0", "context", "fmt"},
 	"text/tabwriter": {"L2"},
 
-	"testing":          {"L2", "flag", "fmt", "internal/race", "os", "runtime/debug", "runtime/pprof", "runtime/trace", "time"},
+	"testing":          {"L2", "flag", "fmt", "internal/race", "io/ioutil", "os", "reflect", "runtime/debug", "runtime/pprof", "runtime/trace", "time"},
 	"testing/iotest":   {"L2", "log"},
 	"testing/quick":    {"L2", "flag", "fmt", "reflect", "time"},
 	"internal/testenv": {"L2", "OS", "flag", "testing", "syscall"},
@@ -228,6 +236,49 @@ var pkgDeps = map[string][]string{
 	"go/internal/srcimporter":   {"L4", "OS", "fmt", "go/ast", "go/build", "go/parser", "go/token", "go/types", "path/filepath"},
 	"go/types":                  {"L4", "GOPARSER", "container/heap", "go/constant"},
 
+	// Go static analysis.
+	"go/ast/inspector": {"L4", "go/ast"},
+	"go/cfg":           {"L4", "GOPARSER", "go/format"},
+
+	"go/analysis":                              {"L4", "GOPARSER", "flag", "go/types"},
+	"go/analysis/internal/analysisflags":       {"L4", "OS", "crypto/sha256", "encoding/json", "flag", "go/analysis", "go/token"},
+	"go/analysis/internal/facts":               {"L4", "OS", "encoding/gob", "go/analysis", "go/analysis/internal/objectpath", "go/types"},
+	"go/analysis/internal/objectpath":          {"L4", "go/types"},
+	"go/analysis/passes/internal/analysisutil": {"L4", "OS", "GOPARSER", "go/types"},
+	"go/analysis/unitchecker": {
+		"L4", "OS", "GOPARSER", "encoding/gob", "encoding/json", "flag",
+		"go/analysis", "go/analysis/internal/analysisflags", "go/analysis/internal/facts",
+		"go/build", "go/importer", "go/types",
+	},
+
+	"GOANALYSIS": {
+		"L4", "OS", "GOPARSER", "go/constant", "go/types", "regexp",
+		"go/analysis", "go/analysis/passes/internal/analysisutil", "go/ast/inspector",
+	},
+
+	"go/analysis/passes/asmdecl":      {"GOANALYSIS", "go/build"},
+	"go/analysis/passes/assign":       {"GOANALYSIS", "go/analysis/passes/inspect"},
+	"go/analysis/passes/atomic":       {"GOANALYSIS", "go/analysis/passes/inspect"},
+	"go/analysis/passes/bools":        {"GOANALYSIS", "go/analysis/passes/inspect"},
+	"go/analysis/passes/buildtag":     {"GOANALYSIS"},
+	"go/analysis/passes/cgocall":      {"GOANALYSIS", "go/analysis/passes/inspect"},
+	"go/analysis/passes/composite":    {"GOANALYSIS", "go/analysis/passes/inspect"},
+	"go/analysis/passes/copylock":     {"GOANALYSIS", "go/analysis/passes/inspect"},
+	"go/analysis/passes/ctrlflow":     {"GOANALYSIS", "go/analysis/passes/inspect", "go/cfg"},
+	"go/analysis/passes/httpresponse": {"GOANALYSIS", "go/analysis/passes/inspect"},
+	"go/analysis/passes/inspect":      {"GOANALYSIS"},
+	"go/analysis/passes/loopclosure":  {"GOANALYSIS", "go/analysis/passes/inspect"},
+	"go/analysis/passes/lostcancel":   {"GOANALYSIS", "go/analysis/passes/ctrlflow", "go/analysis/passes/inspect", "go/cfg"},
+	"go/analysis/passes/nilfunc":      {"GOANALYSIS", "go/analysis/passes/inspect"},
+	"go/analysis/passes/printf":       {"GOANALYSIS", "go/analysis/passes/inspect"},
+	"go/analysis/passes/shift":        {"GOANALYSIS", "go/analysis/passes/inspect"},
+	"go/analysis/passes/stdmethods":   {"GOANALYSIS", "go/analysis/passes/inspect"},
+	"go/analysis/passes/structtag":    {"GOANALYSIS", "go/analysis/passes/inspect"},
+	"go/analysis/passes/tests":        {"GOANALYSIS"},
+	"go/analysis/passes/unreachable":  {"GOANALYSIS", "go/analysis/passes/inspect"},
+	"go/analysis/passes/unsafeptr":    {"GOANALYSIS", "go/analysis/passes/inspect"},
+	"go/analysis/passes/unusedresult": {"GOANALYSIS", "go/analysis/passes/inspect"},
+
 	// One of a kind.
 	"archive/tar":              {"L4", "OS", "syscall", "os/user"},
 	"archive/zip":              {"L4", "OS", "compress/flate"},
@@ -264,8 +315,10 @@ var pkgDeps = map[string][]string{
 	"image/jpeg":               {"L4", "image/internal/imageutil"},
 	"image/png":                {"L4", "compress/zlib"},
 	"index/suffixarray":        {"L4", "regexp"},
+	"internal/fuzz":            {"L4", "OS", "context", "crypto/sha256", "go/ast", "go/parser", "go/token"},
 	"internal/singleflight":    {"sync"},
 	"internal/trace":           {"L4", "OS"},
+	"log/slog":                 {"L4", "context", "encoding", "encoding/json", "log/internal"},
 	"math/big":                 {"L4"},
 	"mime":                     {"L4", "OS", "syscall", "internal/syscall/windows/registry"},
 	"mime/quotedprintable":     {"L4"},
@@ -273,7 +326,7 @@ var pkgDeps = map[string][]string{
 	"net/url":                  {"L4"},
 	"plugin":                   {"L0", "OS", "CGO"},
 	"runtime/pprof/internal/profile": {"L4", "OS", "compress/gzip", "regexp"},
-	"testing/internal/testdeps":      {"L4", "internal/testlog", "runtime/pprof", "regexp"},
+	"testing/internal/testdeps":      {"L4", "OS", "context", "internal/fuzz", "internal/testlog", "os/signal", "runtime/pprof", "regexp"},
 	"text/scanner":                   {"L4", "OS"},
 	"text/template/parse":            {"L4"},
 
@@ -339,9 +392,12 @@ var pkgDeps = map[string][]string{
 	"crypto/sha256": {"L3"},
 	"crypto/sha512": {"L3"},
 
+	"crypto/ed25519/internal/edwards25519": {"L3"},
+
 	"CRYPTO": {
 		"crypto/aes",
 		"crypto/des",
+		"crypto/ed25519/internal/edwards25519",
 		"crypto/hmac",
 		"crypto/md5",
 		"crypto/rc4",
@@ -361,6 +417,7 @@ var pkgDeps = map[string][]string{
 	// Mathematical crypto: dependencies on fmt (L4) and math/big.
 	// We could avoid some of the fmt, but math/big imports fmt anyway.
 	"crypto/dsa":      {"L4", "CRYPTO", "math/big"},
+	"crypto/ed25519":  {"L3", "CRYPTO", "crypto/rand"},
 	"crypto/ecdsa":    {"L4", "CRYPTO", "crypto/elliptic", "math/big", "encoding/asn1"},
 	"crypto/elliptic": {"L4", "CRYPTO", "math/big"},
 	"crypto/rsa":      {"L4", "CRYPTO", "crypto/rand", "math/big"},
@@ -369,6 +426,7 @@ var pkgDeps = map[string][]string{
 		"CRYPTO",
 		"crypto/dsa",
 		"crypto/ecdsa",
+		"crypto/ed25519",
 		"crypto/elliptic",
 		"crypto/rand",
 		"crypto/rsa",
@@ -387,6 +445,7 @@ var pkgDeps = map[string][]string{
 		"golang_org/x/crypto/cryptobyte", "golang_org/x/crypto/cryptobyte/asn1",
 	},
 	"crypto/x509/pkix": {"L4", "CRYPTO-MATH", "encoding/hex"},
+	"crypto/pkcs12":    {"L4", "CRYPTO-MATH", "crypto/x509", "crypto/x509/pkix"},
 
 	// Simple net+crypto-aware packages.
 	"mime/multipart": {"L4", "OS", "mime", "crypto/rand", "net/textproto", "mime/quotedprintable"},
@@ -420,9 +479,11 @@ var pkgDeps = map[string][]string{
 	"net/http/cgi":       {"L4", "NET", "OS", "crypto/tls", "net/http", "regexp"},
 	"net/http/cookiejar": {"L4", "NET", "net/http"},
 	"net/http/fcgi":      {"L4", "NET", "OS", "context", "net/http", "net/http/cgi"},
-	"net/http/httptest":  {"L4", "NET", "OS", "crypto/tls", "flag", "net/http", "net/http/internal", "crypto/x509"},
-	"net/http/httputil":  {"L4", "NET", "OS", "context", "net/http", "net/http/internal"},
+	"net/http/httptest":  {"L4", "NET", "OS", "context", "crypto/tls", "flag", "net/http", "net/http/internal", "crypto/x509"},
+	"net/http/httputil":  {"L4", "NET", "OS", "context", "net/http", "net/http/httptrace", "net/http/internal"},
 	"net/http/pprof":     {"L4", "OS", "html/template", "net/http", "runtime/pprof", "runtime/trace"},
+	"net/http/sse":       {"L4", "NET", "context", "net/http"},
+	"net/http/websocket": {"L4", "NET", "compress/flate", "context", "crypto/rand", "crypto/sha1", "crypto/tls", "encoding/base64", "io/ioutil", "net/http", "net/url"},
 	"net/rpc":            {"L4", "NET", "encoding/gob", "html/template", "net/http"},
 	"net/rpc/jsonrpc":    {"L4", "NET", "encoding/json", "net/rpc"},
 }
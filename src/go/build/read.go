@@ -6,8 +6,14 @@ package build
 
 import (
 	"bufio"
+	"bytes"
 	"errors"
+	"fmt"
+	"go/token"
 	"io"
+	"strconv"
+	"strings"
+	"unicode"
 	"unicode/utf8"
 )
 
@@ -245,3 +251,166 @@ func readImports(f io.Reader, reportSyntaxError bool, imports *[]string) ([]byte
 
 	return r.buf, r.err
 }
+
+// A fileEmbed is a single //go:embed pattern and its position.
+type fileEmbed struct {
+	pattern string
+	pos     token.Position
+}
+
+var goEmbed = []byte("//go:embed")
+
+// readGoEmbed returns the patterns of the //go:embed directives
+// in the Go source data, which is the complete content of filename.
+// A directive is recognized only in a // comment that begins a line;
+// comments inside string literals and /* */ comments are skipped.
+func readGoEmbed(data []byte, filename string) ([]fileEmbed, error) {
+	var embeds []fileEmbed
+	line, lineStart := 1, 0
+	startLine := true // only spaces seen since the start of the line
+	for i := 0; i < len(data); {
+		switch c := data[i]; {
+		case c == '\n':
+			i++
+			line, lineStart = line+1, i
+			startLine = true
+
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+
+		case c == '"' || c == '\'':
+			// Interpreted string or rune literal; cannot span lines.
+			startLine = false
+			for i++; i < len(data) && data[i] != c && data[i] != '\n'; i++ {
+				if data[i] == '\\' {
+					i++
+				}
+			}
+			i++
+
+		case c == '`':
+			// Raw string literal; may span lines.
+			startLine = false
+			for i++; i < len(data) && data[i] != '`'; i++ {
+				if data[i] == '\n' {
+					line, lineStart = line+1, i+1
+				}
+			}
+			i++
+
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			startLine = false
+			end := bytes.Index(data[i+2:], []byte("*/"))
+			if end < 0 {
+				end = len(data)
+			} else {
+				end += i + 2 + len("*/")
+			}
+			for ; i < end; i++ {
+				if data[i] == '\n' {
+					line, lineStart = line+1, i+1
+				}
+			}
+
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			end := bytes.IndexByte(data[i:], '\n')
+			if end < 0 {
+				end = len(data)
+			} else {
+				end += i
+			}
+			text := data[i:end]
+			if startLine && bytes.HasPrefix(text, goEmbed) && len(text) > len(goEmbed) && (text[len(goEmbed)] == ' ' || text[len(goEmbed)] == '\t') {
+				pos := token.Position{
+					Filename: filename,
+					Offset:   i + len(goEmbed),
+					Line:     line,
+					Column:   i + len(goEmbed) - lineStart + 1,
+				}
+				list, err := parseGoEmbed(string(text[len(goEmbed):]), pos)
+				if err != nil {
+					return nil, err
+				}
+				embeds = append(embeds, list...)
+			}
+			i = end
+
+		default:
+			startLine = false
+			i++
+		}
+	}
+	return embeds, nil
+}
+
+// parseGoEmbed parses the text following "//go:embed" to extract the glob patterns.
+// It accepts unquoted space-separated patterns as well as double-quoted and back-quoted Go strings.
+// There is a copy of this code in cmd/compile/internal/gc/embed.go.
+func parseGoEmbed(args string, pos token.Position) ([]fileEmbed, error) {
+	orig := args
+	// posOf returns the position of the start of rest, a suffix of orig.
+	posOf := func(rest string) token.Position {
+		n := len(orig) - len(rest)
+		p := pos
+		p.Offset += n
+		p.Column += n
+		return p
+	}
+
+	var list []fileEmbed
+	for args = strings.TrimLeftFunc(args, unicode.IsSpace); args != ""; args = strings.TrimLeftFunc(args, unicode.IsSpace) {
+		var path string
+		pathPos := posOf(args)
+	Switch:
+		switch args[0] {
+		default:
+			i := len(args)
+			for j, c := range args {
+				if unicode.IsSpace(c) {
+					i = j
+					break
+				}
+			}
+			path = args[:i]
+			args = args[i:]
+
+		case '`':
+			i := strings.Index(args[1:], "`")
+			if i < 0 {
+				return nil, fmt.Errorf("%s: invalid quoted string in //go:embed: %s", pathPos, args)
+			}
+			path = args[1 : 1+i]
+			args = args[1+i+1:]
+
+		case '"':
+			i := 1
+			for ; i < len(args); i++ {
+				if args[i] == '\\' {
+					i++
+					continue
+				}
+				if args[i] == '"' {
+					q, err := strconv.Unquote(args[:i+1])
+					if err != nil {
+						return nil, fmt.Errorf("%s: invalid quoted string in //go:embed: %s", pathPos, args[:i+1])
+					}
+					path = q
+					args = args[i+1:]
+					break Switch
+				}
+			}
+			if i >= len(args) {
+				return nil, fmt.Errorf("%s: invalid quoted string in //go:embed: %s", pathPos, args)
+			}
+		}
+
+		if args != "" {
+			r, _ := utf8.DecodeRuneInString(args)
+			if !unicode.IsSpace(r) {
+				return nil, fmt.Errorf("%s: invalid quoted string in //go:embed: %s", pathPos, args)
+			}
+		}
+		list = append(list, fileEmbed{path, pathPos})
+	}
+	return list, nil
+}
@@ -5,7 +5,9 @@
 package build
 
 import (
+	"fmt"
 	"io"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -224,3 +226,90 @@ func TestReadFailuresIgnored(t *testing.T) {
 	}
 	testRead(t, tests, func(r io.Reader) ([]byte, error) { return readImports(r, false, nil) })
 }
+
+var readEmbedTests = []struct {
+	in  string
+	out []string
+}{
+	{
+		"package p\n",
+		nil,
+	},
+	{
+		"package p\nimport \"embed\"\nvar i int\n//go:embed x y z\nvar files embed.FS",
+		[]string{
+			`test:4:12:x`,
+			`test:4:14:y`,
+			`test:4:16:z`,
+		},
+	},
+	{
+		"package p\nimport \"embed\"\nvar i int\n//go:embed x \"\\x79\" `z`\nvar files embed.FS",
+		[]string{
+			`test:4:12:x`,
+			`test:4:14:y`,
+			`test:4:21:z`,
+		},
+	},
+	{
+		"package p\nimport \"embed\"\nvar i int\n//go:embed x y\n//go:embed z\nvar files embed.FS",
+		[]string{
+			`test:4:12:x`,
+			`test:4:14:y`,
+			`test:5:12:z`,
+		},
+	},
+	{
+		"package p\nimport \"embed\"\nvar i int\n\t //go:embed x y\n\t //go:embed z\n\t var files embed.FS",
+		[]string{
+			`test:4:14:x`,
+			`test:4:16:y`,
+			`test:5:14:z`,
+		},
+	},
+	{
+		"package p\nimport \"embed\"\n//go:embed x y z\nvar files embed.FS",
+		[]string{
+			`test:3:12:x`,
+			`test:3:14:y`,
+			`test:3:16:z`,
+		},
+	},
+	{
+		"package p\nimport \"embed\"\nvar s = \"\\n//go:embed x\"\nvar r = `\n//go:embed y\n`\n/*\n//go:embed z\n*/\nvar files embed.FS",
+		nil,
+	},
+	{
+		"package p\nimport \"embed\"\nvar i int // //go:embed x\n//go:embedded y\nvar files embed.FS",
+		nil,
+	},
+}
+
+func TestReadEmbed(t *testing.T) {
+	for i, tt := range readEmbedTests {
+		embeds, err := readGoEmbed([]byte(tt.in), "test")
+		if err != nil {
+			t.Errorf("#%d: %v", i, err)
+			continue
+		}
+		var got []string
+		for _, e := range embeds {
+			got = append(got, fmt.Sprintf("%s:%d:%d:%s", e.pos.Filename, e.pos.Line, e.pos.Column, e.pattern))
+		}
+		if !reflect.DeepEqual(got, tt.out) {
+			t.Errorf("#%d: embeds:\nhave %q\nwant %q", i, got, tt.out)
+		}
+	}
+}
+
+func TestReadEmbedErrors(t *testing.T) {
+	for _, in := range []string{
+		"package p\n//go:embed \"x\n",
+		"package p\n//go:embed `x\n",
+		"package p\n//go:embed \"x\"y\n",
+	} {
+		if _, err := readGoEmbed([]byte(in), "test"); err == nil {
+			t.Errorf("readGoEmbed(%q) succeeded, want error", in)
+		}
+	}
+}
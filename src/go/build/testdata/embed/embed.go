@@ -0,0 +1,9 @@
+package embed
+
+import _ "embed"
+
+//go:embed x.txt "y y.txt"
+var s string
+
+//go:embed dir/*
+var d string
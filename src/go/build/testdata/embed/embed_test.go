@@ -0,0 +1,6 @@
+package embed
+
+import _ "embed"
+
+//go:embed t.txt
+var t string
@@ -0,0 +1,6 @@
+package embed_test
+
+import _ "embed"
+
+//go:embed xt.txt
+var xt string
@@ -2,7 +2,7 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-// This package constructs a simple control-flow graph (CFG) of the
+// Package cfg constructs a simple control-flow graph (CFG) of the
 // statements and expressions within a single function.
 //
 // Use cfg.New to construct the CFG for a function body.
@@ -34,14 +34,13 @@
 //
 // The CFG does not record conditions associated with conditional branch
 // edges, nor the short-circuit semantics of the && and || operators,
-// nor abnormal control flow caused by panic.  If you need this
-// information, use golang.org/x/tools/go/ssa instead.
+// nor abnormal control flow caused by panic.
 //
 package cfg
 
-// Although the vet tool has type information, it is often extremely
-// fragmentary, so for simplicity this package does not depend on
-// go/types.  Consequently control-flow conditions are ignored even
+// Although the analysis passes that use this package have type
+// information, it is often extremely fragmentary, so for simplicity
+// this package does not depend on go/types.  Consequently control-flow conditions are ignored even
 // when constant, and "mayReturn" information must be provided by the
 // client.
 import (
@@ -68,6 +67,7 @@ type CFG struct {
 type Block struct {
 	Nodes []ast.Node // statements, expressions, and ValueSpecs
 	Succs []*Block   // successor nodes in the graph
+	Live  bool       // block is reachable from entry
 
 	comment     string    // for debugging
 	index       int32     // index within CFG.Blocks
@@ -99,6 +99,18 @@ func New(body *ast.BlockStmt, mayReturn func(*ast.CallExpr) bool) *CFG {
 		})
 	}
 
+	// Compute liveness (reachability from entry point).
+	q := make([]*Block, 0, len(b.cfg.Blocks))
+	q = append(q, b.cfg.Blocks[0]) // entry point
+	for len(q) > 0 {
+		b := q[len(q)-1]
+		q = q[:len(q)-1]
+		if !b.Live {
+			b.Live = true
+			q = append(q, b.Succs...)
+		}
+	}
+
 	return b.cfg
 }
 
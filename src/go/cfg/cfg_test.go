@@ -142,24 +142,11 @@ func TestDeadCode(t *testing.T) {
 		if decl, ok := decl.(*ast.FuncDecl); ok {
 			g := New(decl.Body, mayReturn)
 
-			// Mark blocks reachable from entry.
-			live := make(map[*Block]bool)
-			var visit func(*Block)
-			visit = func(b *Block) {
-				if !live[b] {
-					live[b] = true
-					for _, succ := range b.Succs {
-						visit(succ)
-					}
-				}
-			}
-			visit(g.Blocks[0])
-
 			// Print statements in unreachable blocks
 			// (in order determined by builder).
 			var buf bytes.Buffer
 			for _, b := range g.Blocks {
-				if !live[b] {
+				if !b.Live {
 					for _, n := range b.Nodes {
 						fmt.Fprintf(&buf, "\t%s\n", formatNode(fset, n))
 					}
@@ -6,6 +6,7 @@ package template_test
 
 import (
 	"bytes"
+	"embed"
 	. "html/template"
 	"strings"
 	"testing"
@@ -155,3 +156,29 @@ func (c *testCase) mustExecute(t *Template, val interface{}, want string) {
 		c.t.Fatalf("template output:\n%s\nwant:\n%s", buf.String(), want)
 	}
 }
+
+//go:embed testdata
+var testdataFS embed.FS
+
+func TestParseFS(t *testing.T) {
+	if _, err := ParseFS(testdataFS, "testdata/nonexistent*.tmpl"); err == nil {
+		t.Error("expected error for pattern matching no files; got none")
+	}
+
+	tmpl, err := ParseFS(testdataFS, "testdata/*.tmpl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, "T1", "<x>"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "T1 invokes T2: (<b>&lt;x&gt;</b>)"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+
+	// ParseFS must fail once the template has been executed.
+	if _, err := tmpl.ParseFS(testdataFS, "testdata/t2.tmpl"); err == nil {
+		t.Error("ParseFS after Execute succeeded, want error")
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fuzz
+
+import "sort"
+
+// coverage tracks the coverage counters inserted into the code under test
+// by the go command's coverage instrumentation. Before each input is run,
+// the counters are cleared; afterward, their values are reduced to a set
+// of hit-count buckets, and an input is interesting if it reaches a
+// bucket for some counter that no earlier input reached.
+type coverage struct {
+	counters [][]uint32
+
+	// seen holds, for each counter, a bit mask of the
+	// buckets that have been observed so far.
+	seen [][]byte
+}
+
+// newCoverage returns a coverage tracker for counters, keyed by file name.
+func newCoverage(counters map[string][]uint32) *coverage {
+	var files []string
+	for file := range counters {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+	c := new(coverage)
+	for _, file := range files {
+		c.counters = append(c.counters, counters[file])
+		c.seen = append(c.seen, make([]byte, len(counters[file])))
+	}
+	return c
+}
+
+// enabled reports whether there are any counters to guide fuzzing.
+func (c *coverage) enabled() bool {
+	return len(c.counters) > 0
+}
+
+// reset clears all counters.
+func (c *coverage) reset() {
+	for _, counters := range c.counters {
+		for i := range counters {
+			counters[i] = 0
+		}
+	}
+}
+
+// update records the buckets reached by the counters since the
+// last reset and reports whether any of them had not been seen before.
+func (c *coverage) update() bool {
+	found := false
+	for i, counters := range c.counters {
+		seen := c.seen[i]
+		for j, n := range counters {
+			if b := bucket(n); b&^seen[j] != 0 {
+				seen[j] |= b
+				found = true
+			}
+		}
+	}
+	return found
+}
+
+// count returns the number of counters that have been reached so far.
+func (c *coverage) count() int {
+	n := 0
+	for _, seen := range c.seen {
+		for _, b := range seen {
+			if b != 0 {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// bucket maps a hit count to a bit identifying its order of magnitude,
+// so that an input which runs a loop a few more times than a previous
+// one does not count as new coverage.
+func bucket(n uint32) byte {
+	switch {
+	case n == 0:
+		return 0
+	case n == 1:
+		return 1 << 0
+	case n == 2:
+		return 1 << 1
+	case n == 3:
+		return 1 << 2
+	case n < 8:
+		return 1 << 3
+	case n < 16:
+		return 1 << 4
+	case n < 32:
+		return 1 << 5
+	case n < 128:
+		return 1 << 6
+	default:
+		return 1 << 7
+	}
+}
@@ -0,0 +1,284 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fuzz
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"math"
+	"strconv"
+	"unicode/utf8"
+)
+
+// encVersion1 will be the first line of a file with version 1 encoding.
+var encVersion1 = "go test fuzz v1"
+
+// marshalCorpusFile encodes an arbitrary number of arguments into the file
+// format for the corpus.
+func marshalCorpusFile(vals ...interface{}) []byte {
+	if len(vals) == 0 {
+		panic("must have at least one value to marshal")
+	}
+	b := bytes.NewBuffer([]byte(encVersion1 + "\n"))
+	for _, val := range vals {
+		switch t := val.(type) {
+		case int, int8, int16, int64, uint, uint16, uint32, uint64, bool:
+			fmt.Fprintf(b, "%T(%v)\n", t, t)
+		case float32:
+			if math.IsNaN(float64(t)) || math.IsInf(float64(t), 0) {
+				// Non-finite values have no literal form, so encode their bits.
+				fmt.Fprintf(b, "math.Float32frombits(0x%x)\n", math.Float32bits(t))
+			} else {
+				fmt.Fprintf(b, "%T(%v)\n", t, t)
+			}
+		case float64:
+			if math.IsNaN(t) || math.IsInf(t, 0) {
+				fmt.Fprintf(b, "math.Float64frombits(0x%x)\n", math.Float64bits(t))
+			} else {
+				fmt.Fprintf(b, "%T(%v)\n", t, t)
+			}
+		case string:
+			fmt.Fprintf(b, "string(%q)\n", t)
+		case rune: // int32
+			// Only encode as a rune literal if the value is a valid,
+			// printable rune; otherwise the quoted form would not
+			// round-trip.
+			if utf8.ValidRune(t) && strconv.IsPrint(t) {
+				fmt.Fprintf(b, "rune(%q)\n", t)
+			} else {
+				fmt.Fprintf(b, "int32(%v)\n", t)
+			}
+		case byte: // uint8
+			fmt.Fprintf(b, "byte(%q)\n", t)
+		case []byte: // []uint8
+			fmt.Fprintf(b, "[]byte(%q)\n", t)
+		default:
+			panic(fmt.Sprintf("unsupported type: %T", t))
+		}
+	}
+	return b.Bytes()
+}
+
+// unmarshalCorpusFile decodes corpus bytes into their respective values.
+func unmarshalCorpusFile(b []byte) ([]interface{}, error) {
+	if len(b) == 0 {
+		return nil, fmt.Errorf("cannot unmarshal empty string")
+	}
+	lines := bytes.Split(b, []byte("\n"))
+	if len(lines) < 2 {
+		return nil, fmt.Errorf("must include version and at least one value")
+	}
+	if string(lines[0]) != encVersion1 {
+		return nil, fmt.Errorf("unknown encoding version: %s", lines[0])
+	}
+	var vals []interface{}
+	for _, line := range lines[1:] {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		v, err := parseCorpusValue(line)
+		if err != nil {
+			return nil, fmt.Errorf("malformed line %q: %v", line, err)
+		}
+		vals = append(vals, v)
+	}
+	return vals, nil
+}
+
+// parseCorpusValue parses a single line of a corpus file, which has the
+// form of a Go conversion expression such as int(5) or []byte("abc").
+func parseCorpusValue(line []byte) (interface{}, error) {
+	fs := token.NewFileSet()
+	expr, err := parser.ParseExprFrom(fs, "(test)", line, 0)
+	if err != nil {
+		return nil, err
+	}
+	call, ok := expr.(*ast.CallExpr)
+	if !ok {
+		return nil, fmt.Errorf("expected call expression")
+	}
+	if len(call.Args) != 1 {
+		return nil, fmt.Errorf("expected call expression with 1 argument; got %d", len(call.Args))
+	}
+	arg := call.Args[0]
+
+	if arrayType, ok := call.Fun.(*ast.ArrayType); ok {
+		if arrayType.Len != nil {
+			return nil, fmt.Errorf("expected []byte or primitive type")
+		}
+		elt, ok := arrayType.Elt.(*ast.Ident)
+		if !ok || elt.Name != "byte" {
+			return nil, fmt.Errorf("expected []byte")
+		}
+		lit, ok := arg.(*ast.BasicLit)
+		if !ok || lit.Kind != token.STRING {
+			return nil, fmt.Errorf("string literal required for type []byte")
+		}
+		s, err := strconv.Unquote(lit.Value)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(s), nil
+	}
+
+	if sel, ok := call.Fun.(*ast.SelectorExpr); ok {
+		pkg, ok := sel.X.(*ast.Ident)
+		if !ok || pkg.Name != "math" {
+			return nil, fmt.Errorf("invalid selector type")
+		}
+		lit, ok := arg.(*ast.BasicLit)
+		if !ok || lit.Kind != token.INT {
+			return nil, fmt.Errorf("integer literal required for %s.%s", pkg.Name, sel.Sel.Name)
+		}
+		switch sel.Sel.Name {
+		case "Float64frombits":
+			u, err := strconv.ParseUint(lit.Value, 0, 64)
+			if err != nil {
+				return nil, err
+			}
+			return math.Float64frombits(u), nil
+		case "Float32frombits":
+			u, err := strconv.ParseUint(lit.Value, 0, 32)
+			if err != nil {
+				return nil, err
+			}
+			return math.Float32frombits(uint32(u)), nil
+		}
+		return nil, fmt.Errorf("expected math.Float64frombits or math.Float32frombits")
+	}
+
+	idType, ok := call.Fun.(*ast.Ident)
+	if !ok {
+		return nil, fmt.Errorf("expected []byte or primitive type")
+	}
+	if idType.Name == "bool" {
+		id, ok := arg.(*ast.Ident)
+		if !ok {
+			return nil, fmt.Errorf("malformed bool")
+		}
+		switch id.Name {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+		return nil, fmt.Errorf("true or false required for type bool")
+	}
+
+	var (
+		val  string
+		kind token.Token
+	)
+	if op, ok := arg.(*ast.UnaryExpr); ok && op.Op == token.SUB {
+		// Negative numbers are parsed as a unary minus applied to a literal.
+		lit, ok := op.X.(*ast.BasicLit)
+		if !ok || (lit.Kind != token.INT && lit.Kind != token.FLOAT) {
+			return nil, fmt.Errorf("numeric literal required after '-'")
+		}
+		val, kind = "-"+lit.Value, lit.Kind
+	} else {
+		lit, ok := arg.(*ast.BasicLit)
+		if !ok {
+			return nil, fmt.Errorf("literal value required for primitive type")
+		}
+		val, kind = lit.Value, lit.Kind
+	}
+	return parsePrimitive(idType.Name, val, kind)
+}
+
+// parsePrimitive parses val, a literal of the given kind, as a value of the
+// named primitive type.
+func parsePrimitive(typ, val string, kind token.Token) (interface{}, error) {
+	switch typ {
+	case "string":
+		if kind != token.STRING {
+			return nil, fmt.Errorf("string literal value required for type string")
+		}
+		return strconv.Unquote(val)
+	case "byte", "rune":
+		if kind == token.INT {
+			if typ == "byte" {
+				return parsePrimitive("uint8", val, kind)
+			}
+			return parsePrimitive("int32", val, kind)
+		}
+		if kind != token.CHAR {
+			return nil, fmt.Errorf("character literal required for type %s", typ)
+		}
+		if len(val) < 2 {
+			return nil, fmt.Errorf("malformed character literal %s", val)
+		}
+		r, _, tail, err := strconv.UnquoteChar(val[1:len(val)-1], '\'')
+		if err != nil {
+			return nil, err
+		}
+		if tail != "" {
+			return nil, fmt.Errorf("character literal %s has more than one character", val)
+		}
+		if typ == "byte" {
+			if r > math.MaxUint8 {
+				return nil, fmt.Errorf("character literal %s out of range for type byte", val)
+			}
+			return byte(r), nil
+		}
+		return r, nil
+	case "int", "int8", "int16", "int32", "int64":
+		if kind != token.INT {
+			return nil, fmt.Errorf("integer literal required for type %s", typ)
+		}
+		switch typ {
+		case "int":
+			n, err := strconv.ParseInt(val, 0, strconv.IntSize)
+			return int(n), err
+		case "int8":
+			n, err := strconv.ParseInt(val, 0, 8)
+			return int8(n), err
+		case "int16":
+			n, err := strconv.ParseInt(val, 0, 16)
+			return int16(n), err
+		case "int32":
+			n, err := strconv.ParseInt(val, 0, 32)
+			return int32(n), err
+		default:
+			return strconv.ParseInt(val, 0, 64)
+		}
+	case "uint", "uint8", "uint16", "uint32", "uint64":
+		if kind != token.INT {
+			return nil, fmt.Errorf("integer literal required for type %s", typ)
+		}
+		switch typ {
+		case "uint":
+			n, err := strconv.ParseUint(val, 0, strconv.IntSize)
+			return uint(n), err
+		case "uint8":
+			n, err := strconv.ParseUint(val, 0, 8)
+			return uint8(n), err
+		case "uint16":
+			n, err := strconv.ParseUint(val, 0, 16)
+			return uint16(n), err
+		case "uint32":
+			n, err := strconv.ParseUint(val, 0, 32)
+			return uint32(n), err
+		default:
+			return strconv.ParseUint(val, 0, 64)
+		}
+	case "float32":
+		if kind != token.FLOAT && kind != token.INT {
+			return nil, fmt.Errorf("float or integer literal required for type float32")
+		}
+		f, err := strconv.ParseFloat(val, 32)
+		return float32(f), err
+	case "float64":
+		if kind != token.FLOAT && kind != token.INT {
+			return nil, fmt.Errorf("float or integer literal required for type float64")
+		}
+		return strconv.ParseFloat(val, 64)
+	}
+	return nil, fmt.Errorf("expected []byte or primitive type")
+}
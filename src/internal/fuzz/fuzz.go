@@ -0,0 +1,444 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package fuzz provides common fuzzing functionality for tests built with
+// "go test" and for programs that use fuzzing functionality in the testing
+// package.
+package fuzz
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// CoordinateFuzzingOpts is a set of arguments for CoordinateFuzzing.
+// The zero value is valid for each field unless specified otherwise.
+type CoordinateFuzzingOpts struct {
+	// Log is a writer for logging progress messages and warnings.
+	// If nil, ioutil.Discard will be used instead.
+	Log io.Writer
+
+	// Timeout is the amount of wall clock time to spend fuzzing after the
+	// corpus has loaded. If zero, there will be no time limit.
+	Timeout time.Duration
+
+	// Limit is the number of random values to generate and test. If zero,
+	// there will be no limit on the number of generated values.
+	Limit int64
+
+	// MinimizeTimeout is the amount of wall clock time to spend minimizing
+	// after discovering a crasher. If zero, there will be no time limit.
+	// If negative, crashers are not minimized.
+	MinimizeTimeout time.Duration
+
+	// Seed is a list of seed values added by the fuzz target with
+	// testing.F.Add and in testdata.
+	Seed []CorpusEntry
+
+	// Types is the list of types which make up a corpus entry.
+	// Types must be set and must match values in Seed.
+	Types []reflect.Type
+
+	// CorpusDir is a directory where files containing values that crash the
+	// code being tested may be written. CorpusDir must be set.
+	CorpusDir string
+
+	// CacheDir is a directory containing additional "interesting" values.
+	// The fuzzer may derive new values from these, and may write new values
+	// here. If empty, interesting values are kept in memory only.
+	CacheDir string
+
+	// Counters holds the coverage counters of the instrumented code,
+	// keyed by file name. If empty, fuzzing is not coverage-guided.
+	Counters map[string][]uint32
+
+	// Fn runs the fuzz function on the given entry and returns a non-nil
+	// error describing the failure if the function fails. Fn must be set.
+	Fn func(CorpusEntry) error
+}
+
+// CorpusEntry represents an individual input for fuzzing.
+//
+// We must use an equivalent type in the testing and testing/internal/testdeps
+// packages, but testing can't import this package directly, and we don't want
+// to export this type from testing. Instead, we use the same struct type and
+// use a type alias (not a defined type) for convenience.
+type CorpusEntry = struct {
+	// Path is the path of the corpus file, if the entry was loaded from disk.
+	// For other entries, including seed values provided by f.Add, Path is
+	// the name of the test, e.g. seed#0.
+	Path string
+
+	// Data is the raw input data, in the corpus file format.
+	Data []byte
+
+	// Values is the unmarshaled values from a corpus file.
+	Values []interface{}
+
+	// IsSeed reports whether the entry is part of the seed corpus
+	// (added with f.Add or read from testdata), as opposed to
+	// one discovered by the fuzzer.
+	IsSeed bool
+}
+
+// crashError wraps a failure found while fuzzing with the location
+// of the file recording the failing input.
+type crashError struct {
+	path string
+	err  error
+}
+
+func (e *crashError) Error() string {
+	return e.err.Error()
+}
+
+func (e *crashError) Unwrap() error {
+	return e.err
+}
+
+// CrashPath returns the path of the file recording the failing input.
+func (e *crashError) CrashPath() string {
+	return e.path
+}
+
+// CoordinateFuzzing runs the fuzz function opts.Fn on randomly mutated
+// inputs until it fails, ctx is canceled, or the time or count limits in
+// opts are reached.
+//
+// Inputs are derived from the seed corpus and from interesting values
+// in opts.CacheDir. An input is interesting if it reaches new coverage
+// counters in the instrumented code; interesting inputs are added to the
+// corpus for further mutation and written to opts.CacheDir.
+//
+// If an input fails, CoordinateFuzzing minimizes it, writes it to
+// opts.CorpusDir so that it is run as part of the seed corpus from then on,
+// and returns an error describing the failure. The error has a CrashPath
+// method returning the name of the file that was written.
+//
+// If ctx is canceled, CoordinateFuzzing returns ctx.Err().
+func CoordinateFuzzing(ctx context.Context, opts CoordinateFuzzingOpts) error {
+	if opts.Log == nil {
+		opts.Log = ioutil.Discard
+	}
+	c := &coordinator{
+		opts:     opts,
+		cov:      newCoverage(opts.Counters),
+		mutator:  newMutator(),
+		start:    time.Now(),
+		lastTick: time.Now(),
+	}
+	if !c.cov.enabled() {
+		fmt.Fprintf(opts.Log, "warning: the test binary was not built with coverage instrumentation, so fuzzing will run without coverage guidance and may be inefficient\n")
+	}
+
+	// Gather baseline coverage from the seed corpus and the cache.
+	corpus := append([]CorpusEntry(nil), opts.Seed...)
+	if opts.CacheDir != "" {
+		cached, err := ReadCorpus(opts.CacheDir, opts.Types)
+		if err != nil {
+			// Malformed cache entries are not fatal: they may have
+			// been written by a different version of the fuzz target.
+			fmt.Fprintf(opts.Log, "warning: %v\n", err)
+		}
+		corpus = append(corpus, cached...)
+	}
+	if len(corpus) == 0 {
+		corpus = append(corpus, CorpusEntry{Path: "zero", Values: zeroValues(opts.Types)})
+	}
+	for i, e := range corpus {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := c.run(e.Values); err != nil {
+			if e.IsSeed {
+				// The seed corpus is already on disk or in the test source;
+				// there is nothing new to record.
+				return fmt.Errorf("%v\nfuzz: seed input %s failed", err, e.Path)
+			}
+			return c.crash(e.Values, err)
+		}
+		c.logTick(fmt.Sprintf("gathering baseline coverage: %d/%d completed", i+1, len(corpus)))
+	}
+	c.corpus = corpus
+	fmt.Fprintf(opts.Log, "fuzz: elapsed: %s, gathering baseline coverage: %d/%d completed, now fuzzing\n", c.elapsed(), len(corpus), len(corpus))
+
+	var deadline <-chan time.Time
+	if opts.Timeout > 0 {
+		t := time.NewTimer(opts.Timeout)
+		defer t.Stop()
+		deadline = t.C
+	}
+	for opts.Limit == 0 || c.execs < opts.Limit {
+		select {
+		case <-ctx.Done():
+			c.logStats()
+			return ctx.Err()
+		case <-deadline:
+			c.logStats()
+			return nil
+		default:
+		}
+
+		vals := copyValues(c.corpus[c.mutator.rand(len(c.corpus))].Values)
+		c.mutator.mutate(vals)
+		c.execs++
+		if err := c.run(vals); err != nil {
+			c.logStats()
+			return c.crash(vals, err)
+		}
+		if c.newCoverage {
+			c.addInteresting(vals)
+		}
+		c.logTick("")
+	}
+	c.logStats()
+	return nil
+}
+
+// A coordinator holds the state of a fuzzing run.
+type coordinator struct {
+	opts    CoordinateFuzzingOpts
+	cov     *coverage
+	mutator *mutator
+	corpus  []CorpusEntry
+
+	// newCoverage reports whether the last call to run
+	// reached new coverage.
+	newCoverage bool
+
+	start       time.Time
+	lastTick    time.Time
+	execs       int64
+	interesting int
+}
+
+// run calls the fuzz function on vals and records the coverage it reaches.
+// The fuzz function gets a copy of vals, so that it cannot modify
+// values retained in the corpus.
+func (c *coordinator) run(vals []interface{}) error {
+	c.cov.reset()
+	err := c.opts.Fn(CorpusEntry{Values: copyValues(vals)})
+	c.newCoverage = c.cov.update()
+	return err
+}
+
+// addInteresting adds vals to the corpus and the cache.
+func (c *coordinator) addInteresting(vals []interface{}) {
+	data := marshalCorpusFile(vals...)
+	e := CorpusEntry{Data: data, Values: vals}
+	if c.opts.CacheDir != "" {
+		path, err := writeToCorpus(data, c.opts.CacheDir)
+		if err != nil {
+			fmt.Fprintf(c.opts.Log, "warning: %v\n", err)
+		}
+		e.Path = path
+	}
+	c.corpus = append(c.corpus, e)
+	c.interesting++
+}
+
+// crash minimizes the failing input vals, writes it to the corpus
+// directory, and returns the error to report.
+func (c *coordinator) crash(vals []interface{}, err error) error {
+	if c.opts.MinimizeTimeout >= 0 && hasMinimizableValue(vals) {
+		fmt.Fprintf(c.opts.Log, "fuzz: elapsed: %s, minimizing\n", c.elapsed())
+		var deadline time.Time
+		if c.opts.MinimizeTimeout > 0 {
+			deadline = time.Now().Add(c.opts.MinimizeTimeout)
+		}
+		stop := func() bool {
+			return !deadline.IsZero() && time.Now().After(deadline)
+		}
+		minVals := copyValues(vals)
+		minimizeInput(minVals, func(candidate []interface{}) bool {
+			if e := c.run(candidate); e != nil {
+				err = e
+				return true
+			}
+			return false
+		}, stop)
+		vals = minVals
+	}
+	path, werr := writeToCorpus(marshalCorpusFile(vals...), c.opts.CorpusDir)
+	if werr != nil {
+		return fmt.Errorf("%v\nfuzz: could not write failing input: %v", err, werr)
+	}
+	return &crashError{path: path, err: err}
+}
+
+func (c *coordinator) elapsed() time.Duration {
+	return time.Since(c.start).Round(time.Second)
+}
+
+// logTick logs progress if enough time has passed since the last log.
+func (c *coordinator) logTick(phase string) {
+	if time.Since(c.lastTick) < 3*time.Second {
+		return
+	}
+	if phase != "" {
+		fmt.Fprintf(c.opts.Log, "fuzz: elapsed: %s, %s\n", c.elapsed(), phase)
+		c.lastTick = time.Now()
+		return
+	}
+	c.logStats()
+}
+
+func (c *coordinator) logStats() {
+	c.lastTick = time.Now()
+	rate := float64(c.execs) / time.Since(c.start).Seconds()
+	if c.cov.enabled() {
+		fmt.Fprintf(c.opts.Log, "fuzz: elapsed: %s, execs: %d (%.0f/sec), new interesting: %d (total: %d)\n", c.elapsed(), c.execs, rate, c.interesting, len(c.corpus))
+	} else {
+		fmt.Fprintf(c.opts.Log, "fuzz: elapsed: %s, execs: %d (%.0f/sec)\n", c.elapsed(), c.execs, rate)
+	}
+}
+
+// ReadCorpus reads the corpus from the provided dir. The returned corpus
+// entries are guaranteed to match the given types. Any malformed files will
+// be saved in a MalformedCorpusError and returned, along with the most recent
+// error. A missing directory is treated as an empty corpus.
+func ReadCorpus(dir string, types []reflect.Type) ([]CorpusEntry, error) {
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil // No corpus to read
+	} else if err != nil {
+		return nil, fmt.Errorf("reading seed corpus from testdata: %v", err)
+	}
+	var corpus []CorpusEntry
+	var errs []error
+	for _, file := range files {
+		// Skip directories and the temporary files of writeToCorpus.
+		if file.IsDir() || strings.HasPrefix(file.Name(), ".") {
+			continue
+		}
+		filename := filepath.Join(dir, file.Name())
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read corpus file: %v", err)
+		}
+		vals, err := unmarshalCorpusFile(data)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%q: %v", filename, err))
+			continue
+		}
+		if err := CheckCorpus(vals, types); err != nil {
+			errs = append(errs, fmt.Errorf("%q: %v", filename, err))
+			continue
+		}
+		corpus = append(corpus, CorpusEntry{Path: filename, Data: data, Values: vals, IsSeed: true})
+	}
+	if len(errs) > 0 {
+		return corpus, &MalformedCorpusError{errs: errs}
+	}
+	return corpus, nil
+}
+
+// CheckCorpus verifies that the types in vals match the expected types
+// provided.
+func CheckCorpus(vals []interface{}, types []reflect.Type) error {
+	if len(vals) != len(types) {
+		return fmt.Errorf("wrong number of values in corpus entry: %d, want %d", len(vals), len(types))
+	}
+	valsT := make([]reflect.Type, len(vals))
+	for i, v := range vals {
+		valsT[i] = reflect.TypeOf(v)
+	}
+	for i := range types {
+		if valsT[i] != types[i] {
+			return fmt.Errorf("mismatched types in corpus entry: %v, want %v", valsT, types)
+		}
+	}
+	return nil
+}
+
+// MalformedCorpusError is an error found while reading the corpus from the
+// filesystem. All of the errors are stored in the errs list. The testing
+// framework uses this to report malformed files in testdata.
+type MalformedCorpusError struct {
+	errs []error
+}
+
+func (e *MalformedCorpusError) Error() string {
+	var msgs []string
+	for _, s := range e.errs {
+		msgs = append(msgs, s.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// writeToCorpus atomically writes the given bytes to a new file in testdata.
+// If the directory does not exist, it will create one. If the file already
+// exists, writeToCorpus will not rewrite it. writeToCorpus returns the
+// file's name, or an error if it failed.
+func writeToCorpus(b []byte, dir string) (name string, err error) {
+	sum := fmt.Sprintf("%x", sha256.Sum256(b))
+	name = filepath.Join(dir, sum)
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(name); err == nil {
+		return name, nil
+	}
+	tmp, err := ioutil.TempFile(dir, "."+sum+"-")
+	if err != nil {
+		return "", err
+	}
+	_, err = tmp.Write(b)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), name)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return name, nil
+}
+
+// zeroValues returns the zero value of each of types.
+func zeroValues(types []reflect.Type) []interface{} {
+	vals := make([]interface{}, len(types))
+	for i, t := range types {
+		if t.Kind() == reflect.Slice {
+			vals[i] = reflect.MakeSlice(t, 0, 0).Interface()
+		} else {
+			vals[i] = reflect.Zero(t).Interface()
+		}
+	}
+	return vals
+}
+
+// copyValues returns a copy of vals that shares no memory with it.
+func copyValues(vals []interface{}) []interface{} {
+	c := make([]interface{}, len(vals))
+	for i, v := range vals {
+		if b, ok := v.([]byte); ok {
+			v = append([]byte(nil), b...)
+		}
+		c[i] = v
+	}
+	return c
+}
+
+// hasMinimizableValue reports whether vals contains a value that
+// minimizeInput knows how to shrink.
+func hasMinimizableValue(vals []interface{}) bool {
+	for _, v := range vals {
+		switch v.(type) {
+		case []byte, string:
+			return true
+		}
+	}
+	return false
+}
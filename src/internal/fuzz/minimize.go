@@ -0,0 +1,101 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fuzz
+
+// minimizeInput tries to reduce the size of the []byte and string values
+// in vals while keeping them interesting, as reported by try.
+// It gives up early if stop returns true. vals is modified in place.
+func minimizeInput(vals []interface{}, try func([]interface{}) bool, stop func() bool) {
+	for i, v := range vals {
+		switch v := v.(type) {
+		case []byte:
+			vals[i] = minimizeBytes(v, func(b []byte) bool {
+				vals[i] = append([]byte(nil), b...)
+				return try(vals)
+			}, stop)
+		case string:
+			vals[i] = string(minimizeBytes([]byte(v), func(b []byte) bool {
+				vals[i] = string(b)
+				return try(vals)
+			}, stop))
+		}
+	}
+}
+
+// minimizeBytes returns the smallest version of v it can find for which
+// try reports true. try must not retain its argument.
+func minimizeBytes(v []byte, try func([]byte) bool, stop func() bool) []byte {
+	// First, try to cut the tail.
+	for n := 1024; n != 0; n /= 2 {
+		for len(v) > n {
+			if stop() {
+				return v
+			}
+			candidate := v[:len(v)-n]
+			if !try(candidate) {
+				break
+			}
+			v = candidate
+		}
+	}
+
+	// Then, try to remove each individual byte.
+	tmp := make([]byte, len(v))
+	for i := 0; i < len(v)-1; i++ {
+		if stop() {
+			return v
+		}
+		candidate := tmp[:len(v)-1]
+		copy(candidate[:i], v[:i])
+		copy(candidate[i:], v[i+1:])
+		if !try(candidate) {
+			continue
+		}
+		// Update v to delete the value at index i.
+		copy(v[i:], v[i+1:])
+		v = v[:len(candidate)]
+		// v[i] is now different, so redo it.
+		i--
+	}
+
+	// Then, try to remove each possible range of bytes.
+	for i := 0; i < len(v)-1; i++ {
+		copy(tmp, v[:i])
+		for j := len(v); j > i+1; j-- {
+			if stop() {
+				return v
+			}
+			candidate := tmp[:len(v)-j+i]
+			copy(candidate[i:], v[j:])
+			if !try(candidate) {
+				continue
+			}
+			// Update v and reset the loop with the new length.
+			copy(v[i:], v[j:])
+			v = v[:len(candidate)]
+			j = len(v)
+		}
+	}
+
+	// Finally, try to make the input more readable by replacing
+	// each byte with a printable character.
+	printable := []byte("012789ABCXYZabcxyz !\"#$%&'()*+,.")
+	for i, b := range v {
+		if stop() {
+			return v
+		}
+		for _, c := range printable {
+			if b == c {
+				break
+			}
+			v[i] = c
+			if try(v) {
+				break
+			}
+			v[i] = b
+		}
+	}
+	return v
+}
@@ -0,0 +1,86 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fuzz
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMinimizeInput(t *testing.T) {
+	never := func() bool { return false }
+	tests := []struct {
+		name     string
+		input    []interface{}
+		try      func([]interface{}) bool
+		expected []interface{}
+	}{
+		{
+			name:  "ones_byte",
+			input: []interface{}{[]byte{0, 1, 1, 1, 1, 0, 0, 1, 1, 0}},
+			try: func(vals []interface{}) bool {
+				// Interesting while there are at least three ones.
+				return bytes.Count(vals[0].([]byte), []byte{1}) >= 3
+			},
+			expected: []interface{}{[]byte{1, 1, 1}},
+		},
+		{
+			name:  "ones_string",
+			input: []interface{}{"001010001000000000000000000"},
+			try: func(vals []interface{}) bool {
+				return strings.Count(vals[0].(string), "1") >= 3
+			},
+			expected: []interface{}{"111"},
+		},
+		{
+			name:  "prefix",
+			input: []interface{}{[]byte("some very long input that starts with a key")},
+			try: func(vals []interface{}) bool {
+				return bytes.HasPrefix(vals[0].([]byte), []byte("some"))
+			},
+			expected: []interface{}{[]byte("some")},
+		},
+		{
+			name:  "unprintable",
+			input: []interface{}{[]byte{0xff, 0xfe, 0xfd, 0xfc}},
+			try: func(vals []interface{}) bool {
+				return len(vals[0].([]byte)) >= 2
+			},
+			expected: []interface{}{[]byte("00")},
+		},
+		{
+			name:  "multiple",
+			input: []interface{}{[]byte("aaaaa"), 42, "bbbbb"},
+			try: func(vals []interface{}) bool {
+				return len(vals[0].([]byte)) > 0 && vals[1].(int) == 42 && strings.Contains(vals[2].(string), "b")
+			},
+			expected: []interface{}{[]byte("0"), 42, "b"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			vals := copyValues(tc.input)
+			minimizeInput(vals, tc.try, never)
+			if !valuesEqual(vals, tc.expected) {
+				t.Errorf("unexpected result: %#v, want %#v", vals, tc.expected)
+			}
+		})
+	}
+}
+
+func TestMinimizeInputStop(t *testing.T) {
+	calls := 0
+	input := []byte("0123456789")
+	vals := []interface{}{input}
+	minimizeInput(vals, func([]interface{}) bool {
+		calls++
+		return true
+	}, func() bool { return calls > 0 })
+	if calls != 1 {
+		t.Errorf("minimizeInput called try %d times after stop; want 1", calls)
+	}
+}
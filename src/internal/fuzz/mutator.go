@@ -0,0 +1,418 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fuzz
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// A mutator makes random changes to the values of a corpus entry.
+type mutator struct {
+	r *rand.Rand
+
+	// maxBytes limits the length of mutated []byte and string values.
+	maxBytes int
+}
+
+func newMutator() *mutator {
+	return &mutator{
+		r:        rand.New(rand.NewSource(time.Now().UnixNano())),
+		maxBytes: 1 << 20,
+	}
+}
+
+// rand returns a random integer in [0, n).
+func (m *mutator) rand(n int) int {
+	return m.r.Intn(n)
+}
+
+// chooseLen chooses the length of a range mutation in [1, n].
+// It favors short ranges over long ones.
+func (m *mutator) chooseLen(n int) int {
+	switch x := m.rand(100); {
+	case x < 90:
+		return m.rand(min(8, n)) + 1
+	case x < 99:
+		return m.rand(min(32, n)) + 1
+	default:
+		return m.rand(n) + 1
+	}
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// mutate performs a random mutation of one of the values in vals,
+// which are modified in place. Values of type []byte must not be
+// shared with other corpus entries.
+func (m *mutator) mutate(vals []interface{}) {
+	i := m.rand(len(vals))
+	switch v := vals[i].(type) {
+	case int:
+		vals[i] = int(m.mutateInt(int64(v)))
+	case int8:
+		vals[i] = int8(m.mutateInt(int64(v)))
+	case int16:
+		vals[i] = int16(m.mutateInt(int64(v)))
+	case int32:
+		vals[i] = int32(m.mutateInt(int64(v)))
+	case int64:
+		vals[i] = m.mutateInt(v)
+	case uint:
+		vals[i] = uint(m.mutateUInt(uint64(v)))
+	case uint8:
+		vals[i] = uint8(m.mutateUInt(uint64(v)))
+	case uint16:
+		vals[i] = uint16(m.mutateUInt(uint64(v)))
+	case uint32:
+		vals[i] = uint32(m.mutateUInt(uint64(v)))
+	case uint64:
+		vals[i] = m.mutateUInt(v)
+	case float32:
+		vals[i] = float32(m.mutateFloat(float64(v)))
+	case float64:
+		vals[i] = m.mutateFloat(v)
+	case bool:
+		vals[i] = !v
+	case string:
+		vals[i] = string(m.mutateBytes([]byte(v)))
+	case []byte:
+		vals[i] = m.mutateBytes(v)
+	default:
+		panic(fmt.Sprintf("type not supported for mutating: %T", vals[i]))
+	}
+}
+
+// interesting8, interesting16 and interesting32 are values that
+// often trigger edge cases in code.
+var (
+	interesting8  = []int8{-128, -1, 0, 1, 16, 32, 64, 100, 127}
+	interesting16 = []int16{-32768, -129, 128, 255, 256, 512, 1000, 1024, 4096, 32767}
+	interesting32 = []int32{-2147483648, -100663046, -32769, 32768, 65535, 65536, 100663045, 2147483647}
+)
+
+func (m *mutator) interesting() int64 {
+	switch m.rand(3) {
+	case 0:
+		return int64(interesting8[m.rand(len(interesting8))])
+	case 1:
+		return int64(interesting16[m.rand(len(interesting16))])
+	default:
+		return int64(interesting32[m.rand(len(interesting32))])
+	}
+}
+
+func (m *mutator) mutateInt(v int64) int64 {
+	switch m.rand(4) {
+	case 0:
+		return v + int64(m.rand(16)+1)
+	case 1:
+		return v - int64(m.rand(16)+1)
+	case 2:
+		return v ^ 1<<uint(m.rand(64))
+	default:
+		return m.interesting()
+	}
+}
+
+func (m *mutator) mutateUInt(v uint64) uint64 {
+	switch m.rand(4) {
+	case 0:
+		return v + uint64(m.rand(16)+1)
+	case 1:
+		return v - uint64(m.rand(16)+1)
+	case 2:
+		return v ^ 1<<uint(m.rand(64))
+	default:
+		return uint64(m.interesting())
+	}
+}
+
+func (m *mutator) mutateFloat(v float64) float64 {
+	switch m.rand(5) {
+	case 0:
+		return v + float64(m.rand(16)+1)
+	case 1:
+		return v - float64(m.rand(16)+1)
+	case 2:
+		return v * float64(m.rand(16)+1)
+	case 3:
+		return v / float64(m.rand(16)+1)
+	default:
+		switch m.rand(6) {
+		case 0:
+			return 0
+		case 1:
+			return math.Copysign(0, -1)
+		case 2:
+			return math.Inf(1)
+		case 3:
+			return math.Inf(-1)
+		case 4:
+			return math.NaN()
+		default:
+			return float64(m.interesting())
+		}
+	}
+}
+
+// byteSliceMutators are the mutations that may be applied
+// to []byte and string values. Each returns the mutated slice,
+// or nil if the mutation does not apply to b.
+var byteSliceMutators = []func(m *mutator, b []byte) []byte{
+	byteSliceRemoveBytes,
+	byteSliceInsertRandomBytes,
+	byteSliceDuplicateBytes,
+	byteSliceOverwriteBytes,
+	byteSliceBitFlip,
+	byteSliceXORByte,
+	byteSliceSwapByte,
+	byteSliceArithmeticUint8,
+	byteSliceArithmeticUint16,
+	byteSliceArithmeticUint32,
+	byteSliceOverwriteInterestingUint8,
+	byteSliceOverwriteInterestingUint16,
+	byteSliceOverwriteInterestingUint32,
+	byteSliceInsertConstantBytes,
+	byteSliceShuffleBytes,
+}
+
+// mutateBytes applies a few random mutations to b, which is modified
+// in place, and returns the result.
+func (m *mutator) mutateBytes(b []byte) []byte {
+	// Not every mutation applies to every slice, so give up
+	// after a bounded number of attempts.
+	n := 1 + m.rand(4)
+	for tries := 0; n > 0 && tries < 100; tries++ {
+		mut := byteSliceMutators[m.rand(len(byteSliceMutators))]
+		if r := mut(m, b); r != nil {
+			b = r
+			n--
+		}
+	}
+	return b
+}
+
+// growLen chooses the number of bytes to add to b,
+// or returns 0 if b cannot grow.
+func (m *mutator) growLen(b []byte) int {
+	room := m.maxBytes - len(b)
+	if room <= 0 {
+		return 0
+	}
+	return m.chooseLen(min(room, 1024))
+}
+
+func byteSliceRemoveBytes(m *mutator, b []byte) []byte {
+	if len(b) <= 1 {
+		return nil
+	}
+	pos0 := m.rand(len(b))
+	pos1 := pos0 + m.chooseLen(len(b)-pos0)
+	copy(b[pos0:], b[pos1:])
+	return b[:len(b)-(pos1-pos0)]
+}
+
+func byteSliceInsertRandomBytes(m *mutator, b []byte) []byte {
+	n := m.growLen(b)
+	if n == 0 {
+		return nil
+	}
+	pos := m.rand(len(b) + 1)
+	b = append(b, make([]byte, n)...)
+	copy(b[pos+n:], b[pos:])
+	for i := 0; i < n; i++ {
+		b[pos+i] = byte(m.rand(256))
+	}
+	return b
+}
+
+func byteSliceDuplicateBytes(m *mutator, b []byte) []byte {
+	if len(b) <= 1 {
+		return nil
+	}
+	src := m.rand(len(b))
+	dst := m.rand(len(b))
+	for dst == src {
+		dst = m.rand(len(b))
+	}
+	n := m.chooseLen(len(b) - src)
+	if len(b)+n > m.maxBytes {
+		return nil
+	}
+	dup := append([]byte(nil), b[src:src+n]...)
+	b = append(b, dup...)
+	copy(b[dst+n:], b[dst:len(b)-n])
+	copy(b[dst:], dup)
+	return b
+}
+
+func byteSliceOverwriteBytes(m *mutator, b []byte) []byte {
+	if len(b) <= 1 {
+		return nil
+	}
+	src := m.rand(len(b))
+	dst := m.rand(len(b))
+	for dst == src {
+		dst = m.rand(len(b))
+	}
+	n := m.chooseLen(len(b) - src)
+	if dst+n > len(b) {
+		n = len(b) - dst
+	}
+	copy(b[dst:], b[src:src+n])
+	return b
+}
+
+func byteSliceBitFlip(m *mutator, b []byte) []byte {
+	if len(b) == 0 {
+		return nil
+	}
+	pos := m.rand(len(b))
+	b[pos] ^= 1 << uint(m.rand(8))
+	return b
+}
+
+func byteSliceXORByte(m *mutator, b []byte) []byte {
+	if len(b) == 0 {
+		return nil
+	}
+	pos := m.rand(len(b))
+	// In order to avoid a no-op (where the random value matches
+	// the existing value), use XOR instead of just setting to
+	// the random value.
+	b[pos] ^= byte(1 + m.rand(255))
+	return b
+}
+
+func byteSliceSwapByte(m *mutator, b []byte) []byte {
+	if len(b) <= 1 {
+		return nil
+	}
+	src := m.rand(len(b))
+	dst := m.rand(len(b))
+	for dst == src {
+		dst = m.rand(len(b))
+	}
+	b[src], b[dst] = b[dst], b[src]
+	return b
+}
+
+// randDelta returns a non-zero value in [-35, 35].
+func (m *mutator) randDelta() int {
+	v := 1 + m.rand(35)
+	if m.rand(2) == 0 {
+		v = -v
+	}
+	return v
+}
+
+// randByteOrder returns a random byte order.
+func (m *mutator) randByteOrder() binary.ByteOrder {
+	if m.rand(2) == 0 {
+		return binary.LittleEndian
+	}
+	return binary.BigEndian
+}
+
+func byteSliceArithmeticUint8(m *mutator, b []byte) []byte {
+	if len(b) == 0 {
+		return nil
+	}
+	pos := m.rand(len(b))
+	b[pos] += byte(m.randDelta())
+	return b
+}
+
+func byteSliceArithmeticUint16(m *mutator, b []byte) []byte {
+	if len(b) < 2 {
+		return nil
+	}
+	pos := m.rand(len(b) - 1)
+	enc := m.randByteOrder()
+	enc.PutUint16(b[pos:], enc.Uint16(b[pos:])+uint16(m.randDelta()))
+	return b
+}
+
+func byteSliceArithmeticUint32(m *mutator, b []byte) []byte {
+	if len(b) < 4 {
+		return nil
+	}
+	pos := m.rand(len(b) - 3)
+	enc := m.randByteOrder()
+	enc.PutUint32(b[pos:], enc.Uint32(b[pos:])+uint32(m.randDelta()))
+	return b
+}
+
+func byteSliceOverwriteInterestingUint8(m *mutator, b []byte) []byte {
+	if len(b) == 0 {
+		return nil
+	}
+	pos := m.rand(len(b))
+	b[pos] = byte(interesting8[m.rand(len(interesting8))])
+	return b
+}
+
+func byteSliceOverwriteInterestingUint16(m *mutator, b []byte) []byte {
+	if len(b) < 2 {
+		return nil
+	}
+	pos := m.rand(len(b) - 1)
+	v := uint16(interesting16[m.rand(len(interesting16))])
+	m.randByteOrder().PutUint16(b[pos:], v)
+	return b
+}
+
+func byteSliceOverwriteInterestingUint32(m *mutator, b []byte) []byte {
+	if len(b) < 4 {
+		return nil
+	}
+	pos := m.rand(len(b) - 3)
+	v := uint32(interesting32[m.rand(len(interesting32))])
+	m.randByteOrder().PutUint32(b[pos:], v)
+	return b
+}
+
+func byteSliceInsertConstantBytes(m *mutator, b []byte) []byte {
+	n := m.growLen(b)
+	if n == 0 {
+		return nil
+	}
+	pos := m.rand(len(b) + 1)
+	b = append(b, make([]byte, n)...)
+	copy(b[pos+n:], b[pos:])
+	rb := byte(m.rand(256))
+	for i := pos; i < pos+n; i++ {
+		b[i] = rb
+	}
+	return b
+}
+
+func byteSliceShuffleBytes(m *mutator, b []byte) []byte {
+	if len(b) <= 1 {
+		return nil
+	}
+	dst := m.rand(len(b))
+	n := m.chooseLen(len(b) - dst)
+	if n <= 2 {
+		return nil
+	}
+	// Start at the end of the range, and iterate backwards
+	// to dst, swapping each element with another element in
+	// dst:dst+n (Fisher-Yates shuffle).
+	for i := n - 1; i > 0; i-- {
+		j := m.rand(i + 1)
+		b[dst+i], b[dst+j] = b[dst+j], b[dst+i]
+	}
+	return b
+}
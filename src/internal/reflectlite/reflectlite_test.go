@@ -0,0 +1,71 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package reflectlite_test
+
+import (
+	. "internal/reflectlite"
+	"io"
+	"os"
+	"testing"
+)
+
+type myError struct{}
+
+func (*myError) Error() string { return "my error" }
+
+func TestImplements(t *testing.T) {
+	errorType := TypeOf((*error)(nil)).Elem()
+	readerType := TypeOf((*io.Reader)(nil)).Elem()
+	tests := []struct {
+		v    interface{}
+		typ  Type
+		want bool
+	}{
+		{new(myError), errorType, true},
+		{myError{}, errorType, false},
+		{&os.PathError{}, errorType, true},
+		{os.Stdin, readerType, true},
+		{new(myError), readerType, false},
+	}
+	for _, tt := range tests {
+		if got := TypeOf(tt.v).Implements(tt.typ); got != tt.want {
+			t.Errorf("%v.Implements(%v) = %v, want %v", TypeOf(tt.v), tt.typ, got, tt.want)
+		}
+	}
+}
+
+func TestSet(t *testing.T) {
+	var err error
+	v := ValueOf(&err).Elem()
+	if !v.CanSet() {
+		t.Fatal("CanSet() = false for addressable interface value")
+	}
+	e := new(myError)
+	v.Set(ValueOf(e))
+	if err != e {
+		t.Errorf("after Set, err = %v, want %v", err, e)
+	}
+
+	var p *os.PathError
+	pv := ValueOf(&p).Elem()
+	if pe := (&os.PathError{}); TypeOf(pe).AssignableTo(pv.Type()) {
+		pv.Set(ValueOf(pe))
+		if p != pe {
+			t.Errorf("after Set, p = %p, want %p", p, pe)
+		}
+	} else {
+		t.Errorf("%v not assignable to %v", TypeOf(pe), pv.Type())
+	}
+}
+
+func TestTypeNames(t *testing.T) {
+	typ := TypeOf(&os.PathError{}).Elem()
+	if typ.Name() != "PathError" || typ.PkgPath() != "os" || typ.Kind() != Struct {
+		t.Errorf("got Name %q, PkgPath %q, Kind %v; want PathError, os, struct", typ.Name(), typ.PkgPath(), typ.Kind())
+	}
+	if s := TypeOf(&os.PathError{}).String(); s != "*os.PathError" {
+		t.Errorf("String() = %q, want *os.PathError", s)
+	}
+}
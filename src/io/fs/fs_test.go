@@ -0,0 +1,93 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package fs_test
+
+import (
+	. "io/fs"
+	"testing"
+)
+
+var isValidPathTests = []struct {
+	name string
+	ok   bool
+}{
+	{".", true},
+	{"x", true},
+	{"x/y", true},
+
+	{"", false},
+	{"..", false},
+	{"/", false},
+	{"x/", false},
+	{"/x", false},
+	{"x/y/", false},
+	{"/x/y", false},
+	{"./", false},
+	{"./x", false},
+	{"x/.", false},
+	{"x/./y", false},
+	{"../", false},
+	{"../x", false},
+	{"x/..", false},
+	{"x/../y", false},
+	{"x//y", false},
+	{`x\`, true},
+	{`x\y`, true},
+	{`x:y`, true},
+	{`\x`, true},
+	{"x\xff", false},
+}
+
+func TestValidPath(t *testing.T) {
+	for _, tt := range isValidPathTests {
+		ok := ValidPath(tt.name)
+		if ok != tt.ok {
+			t.Errorf("ValidPath(%q) = %v, want %v", tt.name, ok, tt.ok)
+		}
+	}
+}
+
+var globTests = []struct {
+	pattern string
+	bad     bool
+}{
+	{"[]", true},
+	{"nonexist/[]", true},
+	{"[x", true},
+	{"a\\", true},
+	{"[a-]", true},
+	{"*", false},
+	{"a/b/c", false},
+	{"[a-c]/*", false},
+	{"[^\\]]", false},
+	{"\\*", false},
+}
+
+func TestGlobError(t *testing.T) {
+	for _, tt := range globTests {
+		_, err := Glob(emptyFS{}, tt.pattern)
+		if (err != nil) != tt.bad {
+			t.Errorf("Glob(%q) error = %v, want error %v", tt.pattern, err, tt.bad)
+		}
+	}
+}
+
+func TestSubInvalid(t *testing.T) {
+	for _, dir := range []string{"", "/x", "x/", "../x"} {
+		if _, err := Sub(emptyFS{}, dir); err == nil {
+			t.Errorf("Sub(%q) succeeded, want error", dir)
+		}
+	}
+	if fsys, err := Sub(emptyFS{}, "."); err != nil || fsys != (emptyFS{}) {
+		t.Errorf("Sub(.) = %v, %v, want original file system", fsys, err)
+	}
+}
+
+// emptyFS is a file system with no files.
+type emptyFS struct{}
+
+func (emptyFS) Open(name string) (File, error) {
+	return nil, &PathError{Op: "open", Path: name, Err: ErrNotExist}
+}
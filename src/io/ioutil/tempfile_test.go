@@ -2,9 +2,10 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-package ioutil
+package ioutil_test
 
 import (
+	. "io/ioutil"
 	"os"
 	"path/filepath"
 	"regexp"
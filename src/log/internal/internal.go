@@ -0,0 +1,13 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package internal contains definitions used by both log and log/slog.
+package internal
+
+// DefaultOutput holds a function which calls the default log.Logger's
+// output function.
+// It allows slog's default handler to write through the standard
+// logger, so that it honors the log package's flags, prefix and
+// output, without the log package exporting that function.
+var DefaultOutput func(pc uintptr, data []byte) error
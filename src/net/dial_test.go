@@ -7,12 +7,14 @@ package net
 import (
 	"bufio"
 	"context"
+	"errors"
 	"internal/poll"
 	"internal/testenv"
 	"io"
 	"os"
 	"runtime"
 	"sync"
+	"syscall"
 	"testing"
 	"time"
 )
@@ -143,7 +145,8 @@ const (
 // more quickly than expected. This test hook prevents dialTCP from returning
 // before the deadline.
 func slowDialTCP(ctx context.Context, net string, laddr, raddr *TCPAddr) (*TCPConn, error) {
-	c, err := doDialTCP(ctx, net, laddr, raddr)
+	sd := &sysDialer{network: net, address: raddr.String()}
+	c, err := sd.doDialTCP(ctx, laddr, raddr)
 	if ParseIP(slowDst4).Equal(raddr.IP) || ParseIP(slowDst6).Equal(raddr.IP) {
 		// Wait for the deadline, or indefinitely if none exists.
 		<-ctx.Done()
@@ -295,12 +298,12 @@ func TestDialParallel(t *testing.T) {
 			FallbackDelay: fallbackDelay,
 		}
 		startTime := time.Now()
-		dp := &dialParam{
+		sd := &sysDialer{
 			Dialer:  d,
 			network: "tcp",
 			address: "?",
 		}
-		c, err := dialParallel(context.Background(), dp, primaries, fallbacks)
+		c, err := sd.dialParallel(context.Background(), primaries, fallbacks)
 		elapsed := time.Since(startTime)
 
 		if c != nil {
@@ -331,7 +334,7 @@ func TestDialParallel(t *testing.T) {
 			wg.Done()
 		}()
 		startTime = time.Now()
-		c, err = dialParallel(ctx, dp, primaries, fallbacks)
+		c, err = sd.dialParallel(ctx, primaries, fallbacks)
 		if c != nil {
 			c.Close()
 		}
@@ -467,13 +470,14 @@ func TestDialParallelSpuriousConnection(t *testing.T) {
 		// Now ignore the provided context (which will be canceled) and use a
 		// different one to make sure this completes with a valid connection,
 		// which we hope to be closed below:
-		return doDialTCP(context.Background(), net, laddr, raddr)
+		sd := &sysDialer{network: net, address: raddr.String()}
+		return sd.doDialTCP(context.Background(), laddr, raddr)
 	}
 
 	d := Dialer{
 		FallbackDelay: fallbackDelay,
 	}
-	dp := &dialParam{
+	sd := &sysDialer{
 		Dialer:  d,
 		network: "tcp",
 		address: "?",
@@ -488,7 +492,7 @@ func TestDialParallelSpuriousConnection(t *testing.T) {
 	}
 
 	// dialParallel returns one connection (and closes the other.)
-	c, err := dialParallel(context.Background(), dp, makeAddr("127.0.0.1"), makeAddr("::1"))
+	c, err := sd.dialParallel(context.Background(), makeAddr("127.0.0.1"), makeAddr("::1"))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -745,6 +749,105 @@ func TestDialerKeepAlive(t *testing.T) {
 	}
 }
 
+func TestDialerControl(t *testing.T) {
+	switch runtime.GOOS {
+	case "nacl", "plan9":
+		t.Skipf("not supported on %s", runtime.GOOS)
+	}
+
+	t.Run("StreamDial", func(t *testing.T) {
+		for _, network := range []string{"tcp", "tcp4", "tcp6", "unix", "unixpacket"} {
+			if !testableNetwork(network) {
+				continue
+			}
+			ln, err := newLocalListener(network)
+			if err != nil {
+				t.Error(err)
+				continue
+			}
+			var gotNetwork, gotAddress string
+			d := Dialer{Control: func(network, address string, c syscall.RawConn) error {
+				gotNetwork, gotAddress = network, address
+				return c.Control(func(uintptr) {})
+			}}
+			c, err := d.Dial(network, ln.Addr().String())
+			if err != nil {
+				t.Error(err)
+				ln.Close()
+				continue
+			}
+			c.Close()
+			ln.Close()
+			if !validControlNetwork(network, gotNetwork) {
+				t.Errorf("%s: got network %q", network, gotNetwork)
+			}
+			if gotAddress != ln.Addr().String() {
+				t.Errorf("%s: got address %q; want %q", network, gotAddress, ln.Addr().String())
+			}
+		}
+	})
+	t.Run("PacketDial", func(t *testing.T) {
+		for _, network := range []string{"udp", "udp4", "udp6", "unixgram"} {
+			if !testableNetwork(network) {
+				continue
+			}
+			c1, err := newLocalPacketListener(network)
+			if err != nil {
+				t.Error(err)
+				continue
+			}
+			if network == "unixgram" {
+				defer os.Remove(c1.LocalAddr().String())
+			}
+			defer c1.Close()
+			var called bool
+			d := Dialer{Control: func(network, address string, c syscall.RawConn) error {
+				called = true
+				if !validControlNetwork(c1.LocalAddr().Network(), network) {
+					t.Errorf("got network %q", network)
+				}
+				return nil
+			}}
+			c2, err := d.Dial(network, c1.LocalAddr().String())
+			if err != nil {
+				t.Error(err)
+				continue
+			}
+			c2.Close()
+			if !called {
+				t.Errorf("%s: Control not called", network)
+			}
+		}
+	})
+	t.Run("Error", func(t *testing.T) {
+		ln, err := newLocalListener("tcp")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer ln.Close()
+		errControl := errors.New("control failed")
+		d := Dialer{Control: func(string, string, syscall.RawConn) error { return errControl }}
+		c, err := d.Dial("tcp", ln.Addr().String())
+		if err == nil {
+			c.Close()
+			t.Fatal("Dial succeeded; want error")
+		}
+		if perr, ok := err.(*OpError); !ok || perr.Err != errControl {
+			t.Errorf("got %v; want OpError wrapping %v", err, errControl)
+		}
+	})
+}
+
+// validControlNetwork reports whether got is a network name that a
+// control function may see for a socket opened on network.
+func validControlNetwork(network, got string) bool {
+	switch network {
+	case "tcp", "udp":
+		return got == network+"4" || got == network+"6"
+	}
+	return got == network
+}
+
 func TestDialCancel(t *testing.T) {
 	switch testenv.Builder() {
 	case "linux-arm64-buildlet":
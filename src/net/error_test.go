@@ -6,6 +6,7 @@ package net
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"internal/poll"
 	"io"
@@ -797,3 +798,20 @@ func parseLookupPortError(nestedErr error) error {
 	}
 	return fmt.Errorf("unexpected type on 1st nested level: %T", nestedErr)
 }
+
+func TestOpErrorUnwrap(t *testing.T) {
+	errTest := errors.New("test error")
+	for _, err := range []error{
+		&OpError{Op: "dial", Net: "tcp", Err: errTest},
+		&OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", errTest)},
+		fmt.Errorf("wrapped: %w", &OpError{Op: "write", Net: "udp", Err: errTest}),
+	} {
+		if !errors.Is(err, errTest) {
+			t.Errorf("errors.Is(%v, errTest) = false, want true", err)
+		}
+		var oe *OpError
+		if !errors.As(err, &oe) {
+			t.Errorf("errors.As(%v, *OpError) = false, want true", err)
+		}
+	}
+}
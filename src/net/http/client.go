@@ -104,6 +104,15 @@ type Client struct {
 	// RoundTripper implementations should use Request.Cancel
 	// instead of implementing CancelRequest.
 	Timeout time.Duration
+
+	// Retry specifies the policy for retrying failed requests.
+	// If nil, each request (and each redirect) is attempted
+	// exactly once.
+	//
+	// Retries happen within a single call to Do and count
+	// against Timeout. A ClientTrace Retry hook in the request's
+	// context is called before every retry.
+	Retry *RetryPolicy
 }
 
 // DefaultClient is the default Client and is used by Get, Head, and Post.
@@ -612,7 +621,7 @@ func (c *Client) Do(req *Request) (*Response, error) {
 		reqs = append(reqs, req)
 		var err error
 		var didTimeout func() bool
-		if resp, didTimeout, err = c.send(req, deadline); err != nil {
+		if resp, didTimeout, err = c.sendWithRetry(req, deadline); err != nil {
 			// c.send() always closes req.Body
 			reqBodyClosed = true
 			if !deadline.IsZero() && didTimeout() {
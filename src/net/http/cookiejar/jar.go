@@ -95,6 +95,7 @@ type entry struct {
 	Path       string
 	Secure     bool
 	HttpOnly   bool
+	SameSite   http.SameSite
 	Persistent bool
 	HostOnly   bool
 	Expires    time.Time
@@ -119,6 +120,23 @@ func (e *entry) shouldSend(https bool, host, path string) bool {
 	return e.domainMatch(host) && e.pathMatch(path) && (https || !e.Secure)
 }
 
+// sameSiteMatch determines whether e's SameSite attribute permits
+// including it in a request. sameSite reports whether the request is
+// same-site and lax whether it is same-site or a cross-site top-level
+// navigation using a safe method.
+//
+// As specified in draft-ietf-httpbis-cookie-same-site-00, a SameSite
+// attribute without a recognized value enforces the strict mode.
+func (e *entry) sameSiteMatch(sameSite, lax bool) bool {
+	switch e.SameSite {
+	case http.SameSiteStrictMode, http.SameSiteDefaultMode:
+		return sameSite
+	case http.SameSiteLaxMode:
+		return lax
+	}
+	return true
+}
+
 // domainMatch implements "domain-match" of RFC 6265 section 5.1.3.
 func (e *entry) domainMatch(host string) bool {
 	if e.Domain == host {
@@ -150,12 +168,37 @@ func hasDotSuffix(s, suffix string) bool {
 // Cookies implements the Cookies method of the http.CookieJar interface.
 //
 // It returns an empty slice if the URL's scheme is not HTTP or HTTPS.
+//
+// The jar cannot know which site initiated the request, so Cookies
+// treats every request as same-site and ignores the cookies' SameSite
+// attributes. Use SiteCookies to apply them.
 func (j *Jar) Cookies(u *url.URL) (cookies []*http.Cookie) {
 	return j.cookies(u, time.Now())
 }
 
+// SiteCookies is like Cookies but applies the SameSite attributes of the
+// cookies to a request for u with the given method that was initiated by
+// a document loaded from site. The request is same-site if u and site
+// have the same registrable domain, as determined by the jar's
+// PublicSuffixList. A nil site denotes a request that no document
+// initiated, such as one for a URL entered by a user, and is same-site.
+//
+// Cookies with SameSite=Strict, or with a SameSite attribute without a
+// recognized value, are returned only for same-site requests. Cookies
+// with SameSite=Lax are also returned for cross-site requests if topLevel
+// is set, meaning the request is a top-level navigation, and method is
+// safe (GET, HEAD, OPTIONS or TRACE). Other cookies are not restricted.
+func (j *Jar) SiteCookies(u, site *url.URL, method string, topLevel bool) (cookies []*http.Cookie) {
+	return j.siteCookies(u, site, method, topLevel, time.Now())
+}
+
 // cookies is like Cookies but takes the current time as a parameter.
 func (j *Jar) cookies(u *url.URL, now time.Time) (cookies []*http.Cookie) {
+	return j.siteCookies(u, nil, "", false, now)
+}
+
+// siteCookies is like SiteCookies but takes the current time as a parameter.
+func (j *Jar) siteCookies(u, site *url.URL, method string, topLevel bool, now time.Time) (cookies []*http.Cookie) {
 	if u.Scheme != "http" && u.Scheme != "https" {
 		return cookies
 	}
@@ -165,6 +208,14 @@ func (j *Jar) cookies(u *url.URL, now time.Time) (cookies []*http.Cookie) {
 	}
 	key := jarKey(host, j.psList)
 
+	sameSite := site == nil
+	if !sameSite {
+		if siteHost, err := canonicalHost(site.Host); err == nil {
+			sameSite = jarKey(siteHost, j.psList) == key
+		}
+	}
+	lax := sameSite || topLevel && isSafeMethod(method)
+
 	j.mu.Lock()
 	defer j.mu.Unlock()
 
@@ -187,7 +238,7 @@ func (j *Jar) cookies(u *url.URL, now time.Time) (cookies []*http.Cookie) {
 			modified = true
 			continue
 		}
-		if !e.shouldSend(https, host, path) {
+		if !e.shouldSend(https, host, path) || !e.sameSiteMatch(sameSite, lax) {
 			continue
 		}
 		e.LastAccess = now
@@ -222,6 +273,16 @@ func (j *Jar) cookies(u *url.URL, now time.Time) (cookies []*http.Cookie) {
 	return cookies
 }
 
+// isSafeMethod reports whether method is one of the "safe" HTTP methods
+// of RFC 7231 section 4.2.1. The empty string means GET.
+func isSafeMethod(method string) bool {
+	switch method {
+	case "", "GET", "HEAD", "OPTIONS", "TRACE":
+		return true
+	}
+	return false
+}
+
 // SetCookies implements the SetCookies method of the http.CookieJar interface.
 //
 // It does nothing if the URL's scheme is not HTTP or HTTPS.
@@ -417,6 +478,7 @@ func (j *Jar) newEntry(c *http.Cookie, now time.Time, defPath, host string) (e e
 	e.Value = c.Value
 	e.Secure = c.Secure
 	e.HttpOnly = c.HttpOnly
+	e.SameSite = c.SameSite
 
 	return e, false, nil
 }
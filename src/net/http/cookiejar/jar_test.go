@@ -1320,3 +1320,57 @@ func TestIssue19384(t *testing.T) {
 		}
 	}
 }
+
+func TestSameSite(t *testing.T) {
+	jar := newTestJar()
+	from := mustParseURL("https://www.host.test/")
+	var cookies []*http.Cookie
+	for _, line := range []string{
+		"A=a",
+		"B=b; SameSite",
+		"C=c; SameSite=Lax",
+		"D=d; SameSite=Strict",
+		"E=e; SameSite=None",
+	} {
+		cookies = append(cookies, (&http.Response{Header: http.Header{"Set-Cookie": {line}}}).Cookies()...)
+	}
+	jar.setCookies(from, cookies, tNow)
+
+	to := mustParseURL("https://www.host.test/path")
+	for _, test := range []struct {
+		site     string
+		method   string
+		topLevel bool
+		want     string
+	}{
+		{"", "GET", false, "A=a B=b C=c D=d E=e"},
+		{"https://www.host.test/", "POST", false, "A=a B=b C=c D=d E=e"},
+		{"http://sub.host.test/", "POST", false, "A=a B=b C=c D=d E=e"},
+		{"https://www.other.test/", "GET", false, "A=a E=e"},
+		{"https://www.other.test/", "GET", true, "A=a C=c E=e"},
+		{"https://www.other.test/", "HEAD", true, "A=a C=c E=e"},
+		{"https://www.other.test/", "POST", true, "A=a E=e"},
+		{"https://host.co.uk/", "GET", false, "A=a E=e"},
+	} {
+		var site *url.URL
+		if test.site != "" {
+			site = mustParseURL(test.site)
+		}
+		var s []string
+		for _, c := range jar.siteCookies(to, site, test.method, test.topLevel, tNow.Add(time.Second)) {
+			s = append(s, c.Name+"="+c.Value)
+		}
+		if got := strings.Join(s, " "); got != test.want {
+			t.Errorf("site=%q method=%s topLevel=%t:\ngot  %q\nwant %q", test.site, test.method, test.topLevel, got, test.want)
+		}
+	}
+
+	// Cookies ignores SameSite.
+	var s []string
+	for _, c := range jar.cookies(to, tNow.Add(time.Second)) {
+		s = append(s, c.Name+"="+c.Value)
+	}
+	if got, want := strings.Join(s, " "), "A=a B=b C=c D=d E=e"; got != want {
+		t.Errorf("Cookies:\ngot  %q\nwant %q", got, want)
+	}
+}
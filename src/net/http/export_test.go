@@ -31,6 +31,7 @@ var (
 	ExportHttp2ConfigureServer        = http2ConfigureServer
 	Export_shouldCopyHeaderOnRedirect = shouldCopyHeaderOnRedirect
 	Export_writeStatusLine            = writeStatusLine
+	ExportParseRetryAfter             = parseRetryAfter
 )
 
 func init() {
@@ -7,6 +7,7 @@ package http_test
 import (
 	"bufio"
 	"bytes"
+	"embed"
 	"errors"
 	"fmt"
 	"io"
@@ -396,6 +397,56 @@ func TestFileServerImplicitLeadingSlash(t *testing.T) {
 	}
 }
 
+//go:embed testdata
+var testdataFS embed.FS
+
+func TestFileServerFS(t *testing.T) {
+	defer afterTest(t)
+	ts := httptest.NewServer(FileServer(FS(testdataFS)))
+	defer ts.Close()
+	get := func(suffix string) (int, string) {
+		res, err := Get(ts.URL + suffix)
+		if err != nil {
+			t.Fatalf("Get %s: %v", suffix, err)
+		}
+		b, err := ioutil.ReadAll(res.Body)
+		if err != nil {
+			t.Fatalf("ReadAll %s: %v", suffix, err)
+		}
+		res.Body.Close()
+		return res.StatusCode, string(b)
+	}
+
+	file, err := ioutil.ReadFile(testFile)
+	if err != nil {
+		t.Fatal("reading file:", err)
+	}
+	if code, s := get("/testdata/file"); code != StatusOK || s != string(file) {
+		t.Errorf("GET /testdata/file = %d %q, want %d %q", code, s, StatusOK, file)
+	}
+	if code, s := get("/testdata/"); code != StatusOK || s != "index.html says hello\n" {
+		t.Errorf("GET /testdata/ = %d %q, want index.html contents", code, s)
+	}
+	if code, s := get("/"); code != StatusOK || !strings.Contains(s, ">testdata/<") {
+		t.Errorf("GET / = %d %q, want directory listing with testdata/", code, s)
+	}
+	if code, _ := get("/testdata/missing"); code != StatusNotFound {
+		t.Errorf("GET /testdata/missing = %d, want %d", code, StatusNotFound)
+	}
+
+	req, _ := NewRequest("GET", ts.URL+"/testdata/file", nil)
+	req.Header.Set("Range", "bytes=0-4")
+	res, err := DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if res.StatusCode != StatusPartialContent || string(b) != string(file[:5]) {
+		t.Errorf("GET range = %d %q, want %d %q", res.StatusCode, b, StatusPartialContent, file[:5])
+	}
+}
+
 func TestDirJoin(t *testing.T) {
 	if runtime.GOOS == "windows" {
 		t.Skip("skipping test on windows")
@@ -8,8 +8,10 @@ package httptest
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
@@ -17,16 +19,27 @@ import (
 	"net/http"
 	"net/http/internal"
 	"os"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // A Server is an HTTP server listening on a system-chosen port on the
 // local loopback interface, for use in end-to-end HTTP tests.
+// A Server created by NewMemoryServer or NewUnstartedMemoryServer
+// instead serves connections over in-memory pipes; only the client
+// returned by its Client method can reach it.
 type Server struct {
 	URL      string // base URL of form http://ipaddr:port with no trailing slash
 	Listener net.Listener
 
+	// EnableHTTP2 controls whether HTTP/2 is enabled
+	// on the server. It must be set between calling
+	// NewUnstartedServer or NewUnstartedMemoryServer
+	// and calling StartTLS.
+	EnableHTTP2 bool
+
 	// TLS is the optional TLS configuration, populated with a new config
 	// after TLS is started. If set on an unstarted server before StartTLS
 	// is called, existing fields are copied into the new config.
@@ -50,6 +63,10 @@ type Server struct {
 	// client is configured for use with the server.
 	// Its transport is automatically closed when Close is called.
 	client *http.Client
+
+	// dial, if non-nil, is the DialContext func used by client's
+	// transport to connect to an in-memory Listener.
+	dial func(ctx context.Context, network, addr string) (net.Conn, error)
 }
 
 func newLocalListener() net.Listener {
@@ -69,6 +86,70 @@ func newLocalListener() net.Listener {
 	return l
 }
 
+// memListenerID numbers in-memory listeners so that each in-memory
+// Server has a distinct URL.
+var memListenerID int64
+
+// memListener is a net.Listener whose connections are in-memory
+// net.Pipe connections created by its dial method.
+type memListener struct {
+	addr   memAddr
+	conns  chan net.Conn
+	closed chan struct{}
+	once   sync.Once
+}
+
+func newMemListener() *memListener {
+	id := atomic.AddInt64(&memListenerID, 1)
+	return &memListener{
+		addr:   memAddr("memory:" + strconv.FormatInt(id, 10)),
+		conns:  make(chan net.Conn),
+		closed: make(chan struct{}),
+	}
+}
+
+var errMemListenerClosed = errors.New("httptest: in-memory listener closed")
+
+func (l *memListener) Accept() (net.Conn, error) {
+	select {
+	case c := <-l.conns:
+		return c, nil
+	case <-l.closed:
+		return nil, errMemListenerClosed
+	}
+}
+
+func (l *memListener) Close() error {
+	l.once.Do(func() { close(l.closed) })
+	return nil
+}
+
+func (l *memListener) Addr() net.Addr { return l.addr }
+
+// dial returns the client end of a new in-memory connection whose
+// server end is returned by Accept. The address is ignored.
+func (l *memListener) dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	c, s := net.Pipe()
+	select {
+	case l.conns <- s:
+		return c, nil
+	case <-l.closed:
+		c.Close()
+		s.Close()
+		return nil, errMemListenerClosed
+	case <-ctx.Done():
+		c.Close()
+		s.Close()
+		return nil, ctx.Err()
+	}
+}
+
+// memAddr is the address of a memListener.
+type memAddr string
+
+func (memAddr) Network() string  { return "memory" }
+func (a memAddr) String() string { return string(a) }
+
 // When debugging a particular http server-based test,
 // this flag lets you run
 //	go test -run=BrokenTest -httptest.serve=127.0.0.1:8000
@@ -96,13 +177,40 @@ func NewUnstartedServer(handler http.Handler) *Server {
 	}
 }
 
+// NewMemoryServer starts and returns a new Server that serves
+// connections over in-memory pipes instead of a network listener.
+// Its URL cannot be reached with other clients; requests must be
+// made with the client returned by Client.
+// The caller should call Close when finished, to shut it down.
+func NewMemoryServer(handler http.Handler) *Server {
+	ts := NewUnstartedMemoryServer(handler)
+	ts.Start()
+	return ts
+}
+
+// NewUnstartedMemoryServer returns a new in-memory Server, as created
+// by NewMemoryServer, but doesn't start it.
+//
+// After changing its configuration, the caller should call Start or
+// StartTLS.
+//
+// The caller should call Close when finished, to shut it down.
+func NewUnstartedMemoryServer(handler http.Handler) *Server {
+	ln := newMemListener()
+	return &Server{
+		Listener: ln,
+		Config:   &http.Server{Handler: handler},
+		dial:     ln.dial,
+	}
+}
+
 // Start starts a server from NewUnstartedServer.
 func (s *Server) Start() {
 	if s.URL != "" {
 		panic("Server already started")
 	}
 	if s.client == nil {
-		s.client = &http.Client{Transport: &http.Transport{}}
+		s.client = &http.Client{Transport: &http.Transport{DialContext: s.dial}}
 	}
 	s.URL = "http://" + s.Listener.Addr().String()
 	s.wrap()
@@ -119,7 +227,7 @@ func (s *Server) StartTLS() {
 		panic("Server already started")
 	}
 	if s.client == nil {
-		s.client = &http.Client{Transport: &http.Transport{}}
+		s.client = &http.Client{}
 	}
 	cert, err := tls.X509KeyPair(internal.LocalhostCert, internal.LocalhostKey)
 	if err != nil {
@@ -133,7 +241,11 @@ func (s *Server) StartTLS() {
 		s.TLS = new(tls.Config)
 	}
 	if s.TLS.NextProtos == nil {
-		s.TLS.NextProtos = []string{"http/1.1"}
+		nextProtos := []string{"http/1.1"}
+		if s.EnableHTTP2 {
+			nextProtos = []string{"h2", "http/1.1"}
+		}
+		s.TLS.NextProtos = nextProtos
 	}
 	if len(s.TLS.Certificates) == 0 {
 		s.TLS.Certificates = []tls.Certificate{cert}
@@ -144,10 +256,18 @@ func (s *Server) StartTLS() {
 	}
 	certpool := x509.NewCertPool()
 	certpool.AddCert(s.certificate)
+	clientConfig := &tls.Config{
+		RootCAs: certpool,
+	}
+	if s.dial != nil {
+		// The in-memory listener's address is not a name in
+		// the certificate.
+		clientConfig.ServerName = "example.com"
+	}
 	s.client.Transport = &http.Transport{
-		TLSClientConfig: &tls.Config{
-			RootCAs: certpool,
-		},
+		TLSClientConfig:   clientConfig,
+		DialContext:       s.dial,
+		ForceAttemptHTTP2: s.EnableHTTP2,
 	}
 	s.Listener = tls.NewListener(s.Listener, s.TLS)
 	s.URL = "https://" + s.Listener.Addr().String()
@@ -6,9 +6,11 @@ package httptest
 
 import (
 	"bufio"
+	"io"
 	"io/ioutil"
 	"net"
 	"net/http"
+	"strings"
 	"testing"
 )
 
@@ -202,3 +204,132 @@ func TestServerZeroValueClose(t *testing.T) {
 
 	ts.Close() // tests that it doesn't panic
 }
+
+func TestMemoryServer(t *testing.T) {
+	ts := NewMemoryServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Proto)
+	}))
+	defer ts.Close()
+	if !strings.HasPrefix(ts.URL, "http://memory:") {
+		t.Errorf("URL = %q; want http://memory:N", ts.URL)
+	}
+	for i := 0; i < 2; i++ {
+		res, err := ts.Client().Get(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != "HTTP/1.1" {
+			t.Errorf("got %q, want HTTP/1.1", got)
+		}
+	}
+
+	ts.Close()
+	if res, err := ts.Client().Get(ts.URL); err == nil {
+		res.Body.Close()
+		t.Fatalf("Get after Close succeeded: %v", res.Status)
+	}
+}
+
+func TestMemoryServerUnreachable(t *testing.T) {
+	ts := NewMemoryServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	other := NewMemoryServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer other.Close()
+	if ts.URL == other.URL {
+		t.Errorf("two in-memory servers share URL %q", ts.URL)
+	}
+	if res, err := http.Get(ts.URL); err == nil {
+		res.Body.Close()
+		t.Fatal("in-memory server reachable by http.Get")
+	}
+}
+
+func TestMemoryServerHijack(t *testing.T) {
+	ts := NewMemoryServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, brw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer c.Close()
+		brw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: echo\r\n\r\n")
+		brw.Flush()
+		line, err := brw.ReadString('\n')
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		io.WriteString(c, strings.ToUpper(line))
+	}))
+	defer ts.Close()
+
+	req, _ := http.NewRequest("GET", ts.URL, nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "echo")
+	res, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("status = %v; want 101", res.Status)
+	}
+	rwc := res.Body.(io.ReadWriteCloser)
+	defer rwc.Close()
+	io.WriteString(rwc, "hello\n")
+	line, err := bufio.NewReader(rwc).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line != "HELLO\n" {
+		t.Errorf("got %q, want %q", line, "HELLO\n")
+	}
+}
+
+func TestMemoryServerCloseClientConnections(t *testing.T) {
+	var s *Server
+	s = NewMemoryServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.CloseClientConnections()
+	}))
+	defer s.Close()
+	res, err := s.Client().Get(s.URL)
+	if err == nil {
+		res.Body.Close()
+		t.Fatalf("Unexpected response: %#v", res)
+	}
+}
+
+func TestMemoryServerTLS(t *testing.T) {
+	for _, h2 := range []bool{false, true} {
+		ts := NewUnstartedMemoryServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, r.Proto)
+		}))
+		ts.EnableHTTP2 = h2
+		ts.StartTLS()
+		defer ts.Close()
+
+		want := "HTTP/1.1"
+		if h2 {
+			want = "HTTP/2.0"
+		}
+		res, err := ts.Client().Get(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want || res.Proto != want {
+			t.Errorf("EnableHTTP2 = %v: server saw %q, client saw %q; want %q", h2, got, res.Proto, want)
+		}
+		if res.TLS == nil {
+			t.Errorf("EnableHTTP2 = %v: response not over TLS", h2)
+		}
+	}
+}
@@ -149,6 +149,17 @@ type ClientTrace struct {
 	// request and any body. It may be called multiple times
 	// in the case of retried requests.
 	WroteRequest func(WroteRequestInfo)
+
+	// Retry is called when an http.Client with a retry policy
+	// has decided to retry a request, before it waits for the
+	// backoff delay to elapse.
+	Retry func(RetryInfo)
+
+	// CacheHit is called when a caching RoundTripper, such as
+	// httputil.CachingTransport, answers a request with a stored
+	// response, including one it has just revalidated with the
+	// server.
+	CacheHit func(CacheHitInfo)
 }
 
 // WroteRequestInfo contains information provided to the WroteRequest
@@ -158,6 +169,39 @@ type WroteRequestInfo struct {
 	Err error
 }
 
+// RetryInfo contains information provided to the Retry hook.
+type RetryInfo struct {
+	// Attempt is the number of the attempt that just completed,
+	// starting at 1.
+	Attempt int
+
+	// Delay is how long the client will wait before making
+	// the next attempt.
+	Delay time.Duration
+
+	// StatusCode is the HTTP status code of the response that
+	// triggered the retry, or 0 if the attempt failed with an error.
+	StatusCode int
+
+	// Err is the error that triggered the retry, if any.
+	Err error
+}
+
+// CacheHitInfo contains information provided to the CacheHit hook.
+type CacheHitInfo struct {
+	// Age is the age of the stored response.
+	Age time.Duration
+
+	// Revalidated is whether the server was asked if the
+	// stored response could still be used.
+	Revalidated bool
+
+	// Stale is whether the stored response was served after
+	// its freshness lifetime, as allowed by a max-stale request
+	// directive.
+	Stale bool
+}
+
 // compose modifies t such that it respects the previously-registered hooks in old,
 // subject to the composition policy requested in t.Compose.
 func (t *ClientTrace) compose(old *ClientTrace) {
@@ -0,0 +1,642 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// HTTP client response caching, as specified by RFC 7234.
+
+package httputil
+
+import (
+	"bufio"
+	"bytes"
+	"container/list"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptrace"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A Cache stores the encoded responses kept by a CachingTransport.
+// Implementations must be safe for concurrent use by multiple
+// goroutines.
+type Cache interface {
+	// Get returns the value stored for key, if any.
+	Get(key string) (value []byte, ok bool)
+
+	// Set stores value for key, replacing any previous value.
+	Set(key string, value []byte)
+
+	// Delete removes any value stored for key.
+	Delete(key string)
+}
+
+// defaultMemoryCacheBytes is the size limit of a MemoryCache whose
+// MaxBytes is zero.
+const defaultMemoryCacheBytes = 64 << 20
+
+// MemoryCache is a Cache that keeps values in memory, evicting the
+// least recently used values once their total size exceeds MaxBytes.
+// The zero value is an empty cache ready to use.
+type MemoryCache struct {
+	// MaxBytes limits the total size of the stored values. Values
+	// larger than the limit are not stored. If zero, a default limit
+	// of 64 MiB is used.
+	MaxBytes int64
+
+	mu    sync.Mutex
+	m     map[string]*list.Element // of *memoryCacheEntry
+	lru   list.List                // most recently used at front
+	bytes int64
+}
+
+type memoryCacheEntry struct {
+	key   string
+	value []byte
+}
+
+func (c *MemoryCache) maxBytes() int64 {
+	if c.MaxBytes > 0 {
+		return c.MaxBytes
+	}
+	return defaultMemoryCacheBytes
+}
+
+// Get returns the value stored for key, if any.
+func (c *MemoryCache) Get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.m[key]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(el)
+	return el.Value.(*memoryCacheEntry).value, true
+}
+
+// Set stores value for key, evicting the least recently used values
+// if needed to stay within MaxBytes.
+func (c *MemoryCache) Set(key string, value []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.deleteLocked(key)
+	max := c.maxBytes()
+	if int64(len(value)) > max {
+		return
+	}
+	if c.m == nil {
+		c.m = make(map[string]*list.Element)
+	}
+	c.m[key] = c.lru.PushFront(&memoryCacheEntry{key, value})
+	c.bytes += int64(len(value))
+	for c.bytes > max {
+		c.deleteLocked(c.lru.Back().Value.(*memoryCacheEntry).key)
+	}
+}
+
+// Delete removes key from the cache.
+func (c *MemoryCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.deleteLocked(key)
+}
+
+func (c *MemoryCache) deleteLocked(key string) {
+	el, ok := c.m[key]
+	if !ok {
+		return
+	}
+	c.lru.Remove(el)
+	delete(c.m, key)
+	c.bytes -= int64(len(el.Value.(*memoryCacheEntry).value))
+}
+
+// CachingTransport is an http.RoundTripper that acts as a private
+// HTTP cache, as described in RFC 7234.
+//
+// Responses to GET requests are stored according to their
+// Cache-Control and Expires headers, and reused while fresh.
+// Stale responses with an ETag or Last-Modified validator are
+// revalidated with a conditional request; a 304 Not Modified reply
+// refreshes the stored response, which is then returned with status
+// and body intact. Request Cache-Control directives (no-cache,
+// no-store, max-age, max-stale, min-fresh and only-if-cached) are
+// honored. Successful requests with unsafe methods, such as POST or
+// DELETE, invalidate the stored response for their URL.
+//
+// One response is kept per URL. If it has a Vary header, it is only
+// used for requests whose varying header fields have the same values
+// as the request that produced it.
+//
+// A response is stored once its body has been read to the end, unless
+// the body is larger than MaxEntryBytes.
+// Responses served from the cache carry an Age header. A ClientTrace
+// CacheHit hook in the request's context is called for every
+// response served from the cache.
+//
+// Requests with a Range header, or with conditional headers set by the
+// caller, bypass the cache.
+type CachingTransport struct {
+	// Transport is used to make requests that can't be served
+	// from the cache. If nil, http.DefaultTransport is used.
+	Transport http.RoundTripper
+
+	// Cache stores responses. It must be non-nil.
+	Cache Cache
+
+	// MaxEntryBytes limits the size of the response bodies that are
+	// stored. Larger responses are passed through without being
+	// buffered or stored. If zero, a default limit of 10 MiB is used.
+	MaxEntryBytes int64
+}
+
+// defaultMaxEntryBytes is the body size limit of a CachingTransport
+// whose MaxEntryBytes is zero.
+const defaultMaxEntryBytes = 10 << 20
+
+func (t *CachingTransport) maxEntryBytes() int64 {
+	if t.MaxEntryBytes > 0 {
+		return t.MaxEntryBytes
+	}
+	return defaultMaxEntryBytes
+}
+
+func (t *CachingTransport) transport() http.RoundTripper {
+	if t.Transport != nil {
+		return t.Transport
+	}
+	return http.DefaultTransport
+}
+
+// RoundTrip implements the http.RoundTripper interface.
+func (t *CachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := cacheKey(req)
+	switch req.Method {
+	case "GET", "":
+	case "HEAD", "OPTIONS", "TRACE":
+		return t.transport().RoundTrip(req)
+	default:
+		// RFC 7234, section 4.4.
+		res, err := t.transport().RoundTrip(req)
+		if err == nil && res.StatusCode < 400 {
+			t.Cache.Delete(key)
+		}
+		return res, err
+	}
+
+	reqCC := parseCacheControl(req.Header)
+	if _, ok := reqCC["no-store"]; ok || bypassCache(req.Header) {
+		return t.transport().RoundTrip(req)
+	}
+	if len(reqCC) == 0 && req.Header.Get("Pragma") == "no-cache" {
+		reqCC["no-cache"] = ""
+	}
+
+	trace := httptrace.ContextClientTrace(req.Context())
+	e := t.lookup(key, req)
+	if e != nil {
+		now := time.Now()
+		if stale, ok := e.usable(reqCC, now); ok {
+			if trace != nil && trace.CacheHit != nil {
+				trace.CacheHit(httptrace.CacheHitInfo{Age: e.age(now), Stale: stale})
+			}
+			return e.response(req, now, stale), nil
+		}
+	}
+	if _, ok := reqCC["only-if-cached"]; ok {
+		return gatewayTimeout(req), nil
+	}
+
+	outreq := req
+	if e != nil {
+		etag, lastMod := e.header.Get("Etag"), e.header.Get("Last-Modified")
+		if etag != "" || lastMod != "" {
+			outreq = new(http.Request)
+			*outreq = *req // includes shallow copies of maps, but okay
+			outreq.Header = cloneHeader(req.Header)
+			if etag != "" {
+				outreq.Header.Set("If-None-Match", etag)
+			}
+			if lastMod != "" {
+				outreq.Header.Set("If-Modified-Since", lastMod)
+			}
+		}
+	}
+
+	reqTime := time.Now()
+	res, err := t.transport().RoundTrip(outreq)
+	if err != nil {
+		return nil, err
+	}
+	resTime := time.Now()
+
+	if outreq != req && res.StatusCode == http.StatusNotModified {
+		io.Copy(ioutil.Discard, res.Body)
+		res.Body.Close()
+		e.update(res.Header, reqTime, resTime)
+		t.Cache.Set(key, e.encode())
+		if trace != nil && trace.CacheHit != nil {
+			trace.CacheHit(httptrace.CacheHitInfo{Age: e.age(resTime), Revalidated: true})
+		}
+		return e.response(req, resTime, false), nil
+	}
+
+	if !storable(res) || res.ContentLength > t.maxEntryBytes() {
+		if e != nil {
+			t.Cache.Delete(key)
+		}
+		return res, nil
+	}
+	e = &cacheEntry{
+		reqTime:    reqTime,
+		resTime:    resTime,
+		vary:       varyValues(req, res.Header),
+		status:     res.Status,
+		statusCode: res.StatusCode,
+		header:     cloneHeader(res.Header),
+	}
+	res.Body = &cachingBody{
+		rc:    res.Body,
+		limit: t.maxEntryBytes(),
+		done: func(body []byte) {
+			e.body = body
+			t.Cache.Set(key, e.encode())
+		},
+	}
+	return res, nil
+}
+
+func (t *CachingTransport) lookup(key string, req *http.Request) *cacheEntry {
+	v, ok := t.Cache.Get(key)
+	if !ok {
+		return nil
+	}
+	e, err := decodeCacheEntry(v)
+	if err != nil {
+		t.Cache.Delete(key)
+		return nil
+	}
+	if !e.matchesVary(req) {
+		return nil
+	}
+	return e
+}
+
+func cacheKey(req *http.Request) string {
+	u := *req.URL
+	u.Fragment = ""
+	return u.String()
+}
+
+// bypassCache reports whether a request's headers ask for something
+// the cache cannot provide from a stored response.
+func bypassCache(h http.Header) bool {
+	for _, k := range []string{"Range", "If-None-Match", "If-Modified-Since", "If-Match", "If-Unmodified-Since", "If-Range"} {
+		if _, ok := h[k]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func gatewayTimeout(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:     "504 " + http.StatusText(http.StatusGatewayTimeout),
+		StatusCode: http.StatusGatewayTimeout,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     make(http.Header),
+		Body:       http.NoBody,
+		Request:    req,
+	}
+}
+
+// parseCacheControl returns the directives of the Cache-Control header
+// fields in h, keyed by lower-case name.
+func parseCacheControl(h http.Header) map[string]string {
+	cc := make(map[string]string)
+	for _, line := range h["Cache-Control"] {
+		for _, part := range strings.Split(line, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			name, val := part, ""
+			if i := strings.IndexByte(part, '='); i >= 0 {
+				name, val = strings.TrimSpace(part[:i]), strings.TrimSpace(part[i+1:])
+				val = strings.Trim(val, `"`)
+			}
+			cc[strings.ToLower(name)] = val
+		}
+	}
+	return cc
+}
+
+// ccSeconds returns the value of a delta-seconds directive.
+func ccSeconds(cc map[string]string, name string) (time.Duration, bool) {
+	v, ok := cc[name]
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	if n > 1<<31 {
+		n = 1 << 31 // RFC 7234, section 1.2.1
+	}
+	return time.Duration(n) * time.Second, true
+}
+
+// cacheableByDefault reports whether responses with the given status
+// code may be stored without explicit freshness information.
+// See RFC 7231, section 6.1.
+func cacheableByDefault(code int) bool {
+	switch code {
+	case 200, 203, 204, 300, 301, 404, 405, 410, 414, 501:
+		return true
+	}
+	return false
+}
+
+// storable reports whether res may be stored, per RFC 7234, section 3.
+func storable(res *http.Response) bool {
+	if res.StatusCode == http.StatusPartialContent {
+		return false
+	}
+	resCC := parseCacheControl(res.Header)
+	if _, ok := resCC["no-store"]; ok {
+		return false
+	}
+	for _, f := range headerTokens(res.Header, "Vary") {
+		if f == "*" {
+			return false
+		}
+	}
+	_, hasMaxAge := ccSeconds(resCC, "max-age")
+	explicit := hasMaxAge || res.Header.Get("Expires") != ""
+	if !explicit && !cacheableByDefault(res.StatusCode) {
+		return false
+	}
+	// Only keep responses that can be reused, or revalidated.
+	return explicit || res.Header.Get("Etag") != "" || res.Header.Get("Last-Modified") != ""
+}
+
+// headerTokens returns the comma-separated elements of the header
+// fields named key.
+func headerTokens(h http.Header, key string) []string {
+	var tokens []string
+	for _, line := range h[key] {
+		for _, t := range strings.Split(line, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				tokens = append(tokens, t)
+			}
+		}
+	}
+	return tokens
+}
+
+// varyValues returns the values of req's header fields named by the
+// Vary header in h.
+func varyValues(req *http.Request, h http.Header) http.Header {
+	vary := make(http.Header)
+	for _, f := range headerTokens(h, "Vary") {
+		f = http.CanonicalHeaderKey(f)
+		vary[f] = []string{strings.Join(req.Header[f], ", ")}
+	}
+	return vary
+}
+
+// A cacheEntry is a stored response.
+type cacheEntry struct {
+	reqTime    time.Time   // when the request that produced the response was sent
+	resTime    time.Time   // when the response was received
+	vary       http.Header // request header values selected by Vary
+	status     string
+	statusCode int
+	header     http.Header
+	body       []byte
+}
+
+func (e *cacheEntry) matchesVary(req *http.Request) bool {
+	for f, v := range e.vary {
+		if len(v) != 1 || strings.Join(req.Header[f], ", ") != v[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// freshnessLifetime implements RFC 7234, section 4.2.1.
+func (e *cacheEntry) freshnessLifetime() time.Duration {
+	cc := parseCacheControl(e.header)
+	if d, ok := ccSeconds(cc, "max-age"); ok {
+		return d
+	}
+	date := e.date()
+	if v := e.header.Get("Expires"); v != "" {
+		t, err := http.ParseTime(v)
+		if err != nil {
+			return 0 // invalid dates are in the past
+		}
+		return t.Sub(date)
+	}
+	if lm, err := http.ParseTime(e.header.Get("Last-Modified")); err == nil && cacheableByDefault(e.statusCode) {
+		// RFC 7234, section 4.2.2: a fraction of the time since
+		// the last modification.
+		if d := date.Sub(lm); d > 0 {
+			return d / 10
+		}
+	}
+	return 0
+}
+
+// date returns the value of the Date header, or the response time if
+// the header is missing or invalid.
+func (e *cacheEntry) date() time.Time {
+	if t, err := http.ParseTime(e.header.Get("Date")); err == nil {
+		return t
+	}
+	return e.resTime
+}
+
+// age implements RFC 7234, section 4.2.3.
+func (e *cacheEntry) age(now time.Time) time.Duration {
+	apparentAge := e.resTime.Sub(e.date())
+	if apparentAge < 0 {
+		apparentAge = 0
+	}
+	var ageValue time.Duration
+	if n, err := strconv.ParseInt(e.header.Get("Age"), 10, 64); err == nil && n > 0 {
+		ageValue = time.Duration(n) * time.Second
+	}
+	correctedAge := ageValue + e.resTime.Sub(e.reqTime)
+	if correctedAge < apparentAge {
+		correctedAge = apparentAge
+	}
+	return correctedAge + now.Sub(e.resTime)
+}
+
+// usable reports whether e may be served for a request with the given
+// Cache-Control directives without revalidation, and whether it is
+// stale.
+func (e *cacheEntry) usable(reqCC map[string]string, now time.Time) (stale, ok bool) {
+	resCC := parseCacheControl(e.header)
+	if _, ok := resCC["no-cache"]; ok {
+		return false, false
+	}
+	if _, ok := reqCC["no-cache"]; ok {
+		return false, false
+	}
+	lifetime, age := e.freshnessLifetime(), e.age(now)
+	if maxAge, ok := ccSeconds(reqCC, "max-age"); ok && age > maxAge {
+		return false, false
+	}
+	if minFresh, ok := ccSeconds(reqCC, "min-fresh"); ok {
+		age += minFresh
+	}
+	if lifetime > age {
+		return false, true
+	}
+	// Stale. The client may accept that, unless the server forbids it.
+	if _, ok := resCC["must-revalidate"]; ok {
+		return false, false
+	}
+	v, ok := reqCC["max-stale"]
+	if !ok {
+		return false, false
+	}
+	if v == "" {
+		return true, true
+	}
+	maxStale, ok := ccSeconds(reqCC, "max-stale")
+	return true, ok && age-lifetime <= maxStale
+}
+
+// update replaces e's header fields with those of a 304 response,
+// as described by RFC 7234, section 4.3.4.
+func (e *cacheEntry) update(h http.Header, reqTime, resTime time.Time) {
+	for k, vv := range h {
+		if k == "Content-Length" {
+			continue
+		}
+		e.header[k] = vv
+	}
+	e.reqTime, e.resTime = reqTime, resTime
+}
+
+// response returns a new Response for req with e's contents.
+func (e *cacheEntry) response(req *http.Request, now time.Time, stale bool) *http.Response {
+	h := cloneHeader(e.header)
+	h.Set("Age", strconv.FormatInt(int64(e.age(now)/time.Second), 10))
+	if stale {
+		h.Add("Warning", `110 - "Response is Stale"`)
+	}
+	return &http.Response{
+		Status:        e.status,
+		StatusCode:    e.statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        h,
+		ContentLength: int64(len(e.body)),
+		Body:          ioutil.NopCloser(bytes.NewReader(e.body)),
+		Request:       req,
+	}
+}
+
+// encode serializes e as a MIME header of metadata, followed by the
+// response header and the body.
+func (e *cacheEntry) encode() []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Request-Time: %d\r\n", e.reqTime.UnixNano())
+	fmt.Fprintf(&buf, "Response-Time: %d\r\n", e.resTime.UnixNano())
+	fmt.Fprintf(&buf, "Status: %s\r\n", e.status)
+	for f, v := range e.vary {
+		fmt.Fprintf(&buf, "Vary-Field: %s\r\nVary-Value: %s\r\n", f, v[0])
+	}
+	buf.WriteString("\r\n")
+	e.header.Write(&buf)
+	buf.WriteString("\r\n")
+	buf.Write(e.body)
+	return buf.Bytes()
+}
+
+var errBadCacheEntry = errors.New("httputil: malformed cache entry")
+
+func decodeCacheEntry(v []byte) (*cacheEntry, error) {
+	r := textproto.NewReader(bufio.NewReader(bytes.NewReader(v)))
+	meta, err := r.ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	header, err := r.ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(r.R)
+	if err != nil {
+		return nil, err
+	}
+	e := &cacheEntry{
+		header: http.Header(header),
+		body:   body,
+		status: meta.Get("Status"),
+		vary:   make(http.Header),
+	}
+	reqNanos, err1 := strconv.ParseInt(meta.Get("Request-Time"), 10, 64)
+	resNanos, err2 := strconv.ParseInt(meta.Get("Response-Time"), 10, 64)
+	code, err3 := strconv.Atoi(strings.SplitN(e.status, " ", 2)[0])
+	if err1 != nil || err2 != nil || err3 != nil {
+		return nil, errBadCacheEntry
+	}
+	e.reqTime, e.resTime, e.statusCode = time.Unix(0, reqNanos), time.Unix(0, resNanos), code
+	fields, values := meta["Vary-Field"], meta["Vary-Value"]
+	if len(fields) != len(values) {
+		return nil, errBadCacheEntry
+	}
+	for i, f := range fields {
+		e.vary[f] = []string{values[i]}
+	}
+	return e, nil
+}
+
+// cachingBody wraps a response body, passing its contents to done
+// once it has been read to the end. Bodies longer than limit are not
+// buffered and done is not called.
+type cachingBody struct {
+	rc    io.ReadCloser
+	buf   bytes.Buffer
+	limit int64
+	done  func([]byte)
+}
+
+func (b *cachingBody) Read(p []byte) (int, error) {
+	n, err := b.rc.Read(p)
+	if b.done != nil {
+		if int64(b.buf.Len()+n) > b.limit {
+			// Too large to store; stop buffering.
+			b.done = nil
+			b.buf = bytes.Buffer{}
+		} else {
+			b.buf.Write(p[:n])
+		}
+	}
+	if err == io.EOF && b.done != nil {
+		b.done(b.buf.Bytes())
+		b.done = nil
+	}
+	return n, err
+}
+
+func (b *cachingBody) Close() error {
+	b.done = nil
+	return b.rc.Close()
+}
@@ -0,0 +1,372 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package httputil
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// cacheTest runs a server with handler behind a CachingTransport.
+type cacheTest struct {
+	t      *testing.T
+	ts     *httptest.Server
+	client *http.Client
+	hits   int32 // requests that reached the server; atomic
+	cache  *MemoryCache
+	events []httptrace.CacheHitInfo
+}
+
+func newCacheTest(t *testing.T, handler http.HandlerFunc) *cacheTest {
+	ct := &cacheTest{t: t, cache: new(MemoryCache)}
+	ct.ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&ct.hits, 1)
+		handler(w, r)
+	}))
+	ct.client = &http.Client{Transport: &CachingTransport{
+		Transport: ct.ts.Client().Transport,
+		Cache:     ct.cache,
+	}}
+	return ct
+}
+
+func (ct *cacheTest) close() { ct.ts.Close() }
+
+// do performs a request with the given method and header lines
+// ("Key: value") and returns the response with its body read.
+func (ct *cacheTest) do(method string, header ...string) (*http.Response, string) {
+	ctx := httptrace.WithClientTrace(context.Background(), &httptrace.ClientTrace{
+		CacheHit: func(info httptrace.CacheHitInfo) {
+			ct.events = append(ct.events, info)
+		},
+	})
+	req, err := http.NewRequest(method, ct.ts.URL+"/x", nil)
+	if err != nil {
+		ct.t.Fatal(err)
+	}
+	for _, h := range header {
+		kv := strings.SplitN(h, ": ", 2)
+		req.Header.Add(kv[0], kv[1])
+	}
+	res, err := ct.client.Do(req.WithContext(ctx))
+	if err != nil {
+		ct.t.Fatal(err)
+	}
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		ct.t.Fatal(err)
+	}
+	return res, string(body)
+}
+
+func (ct *cacheTest) get(header ...string) (*http.Response, string) {
+	return ct.do("GET", header...)
+}
+
+func (ct *cacheTest) wantHits(n int32) {
+	ct.t.Helper()
+	if got := atomic.LoadInt32(&ct.hits); got != n {
+		ct.t.Errorf("server saw %d requests; want %d", got, n)
+	}
+}
+
+func TestCachingTransportFresh(t *testing.T) {
+	var n int32
+	ct := newCacheTest(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		fmt.Fprintf(w, "response %d", atomic.AddInt32(&n, 1))
+	})
+	defer ct.close()
+
+	_, body1 := ct.get()
+	res, body2 := ct.get()
+	ct.wantHits(1)
+	if body1 != "response 1" || body2 != body1 {
+		t.Errorf("bodies = %q, %q; want both %q", body1, body2, "response 1")
+	}
+	if res.StatusCode != 200 || res.Header.Get("Age") == "" {
+		t.Errorf("cached response: status %d, Age %q; want 200 with an Age", res.StatusCode, res.Header.Get("Age"))
+	}
+	if len(ct.events) != 1 || ct.events[0].Revalidated || ct.events[0].Stale {
+		t.Errorf("CacheHit events = %+v; want one fresh hit", ct.events)
+	}
+
+	// Requests can insist on a fresher response.
+	_, body3 := ct.get("Cache-Control: no-cache")
+	ct.wantHits(2)
+	if body3 != "response 2" {
+		t.Errorf("no-cache request body = %q; want %q", body3, "response 2")
+	}
+	ct.get("Pragma: no-cache")
+	ct.wantHits(3)
+}
+
+func TestCachingTransportExpires(t *testing.T) {
+	ct := newCacheTest(t, func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now()
+		w.Header().Set("Date", now.UTC().Format(http.TimeFormat))
+		w.Header().Set("Expires", now.Add(time.Hour).UTC().Format(http.TimeFormat))
+		w.Write([]byte("hello"))
+	})
+	defer ct.close()
+
+	ct.get()
+	ct.get()
+	ct.wantHits(1)
+
+	// A request max-age smaller than the response's age forces a new request.
+	time.Sleep(1100 * time.Millisecond)
+	ct.get("Cache-Control: max-age=0")
+	ct.wantHits(2)
+}
+
+func TestCachingTransportNoStore(t *testing.T) {
+	ct := newCacheTest(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store, max-age=60")
+		w.Write([]byte("secret"))
+	})
+	defer ct.close()
+
+	ct.get()
+	ct.get()
+	ct.wantHits(2)
+}
+
+func TestCachingTransportETag(t *testing.T) {
+	var notModified int32
+	ct := newCacheTest(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Etag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte("body v1"))
+	})
+	defer ct.close()
+
+	ct.get()
+	res, body := ct.get()
+	ct.wantHits(2)
+	if notModified != 1 {
+		t.Errorf("server sent %d 304s; want 1", notModified)
+	}
+	if res.StatusCode != 200 || body != "body v1" {
+		t.Errorf("revalidated response = %d %q; want 200 %q", res.StatusCode, body, "body v1")
+	}
+	if len(ct.events) != 1 || !ct.events[0].Revalidated {
+		t.Errorf("CacheHit events = %+v; want one revalidated hit", ct.events)
+	}
+}
+
+func TestCachingTransportLastModified(t *testing.T) {
+	lastMod := time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat)
+	ct := newCacheTest(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=0")
+		w.Header().Set("Last-Modified", lastMod)
+		if r.Header.Get("If-Modified-Since") == lastMod {
+			w.Header().Set("X-Revalidated", "yes")
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte("content"))
+	})
+	defer ct.close()
+
+	ct.get()
+	res, body := ct.get()
+	ct.wantHits(2)
+	if body != "content" {
+		t.Errorf("body = %q; want %q", body, "content")
+	}
+	// Headers of the 304 response update the stored ones.
+	if res.Header.Get("X-Revalidated") != "yes" {
+		t.Errorf("X-Revalidated = %q; want updated header from 304", res.Header.Get("X-Revalidated"))
+	}
+}
+
+func TestCachingTransportVary(t *testing.T) {
+	ct := newCacheTest(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("Vary", "Accept-Language")
+		fmt.Fprintf(w, "lang=%s", r.Header.Get("Accept-Language"))
+	})
+	defer ct.close()
+
+	ct.get("Accept-Language: en")
+	_, body := ct.get("Accept-Language: en")
+	ct.wantHits(1)
+	if body != "lang=en" {
+		t.Errorf("body = %q; want %q", body, "lang=en")
+	}
+	_, body = ct.get("Accept-Language: fr")
+	ct.wantHits(2)
+	if body != "lang=fr" {
+		t.Errorf("body = %q; want %q", body, "lang=fr")
+	}
+	ct.get()
+	ct.wantHits(3)
+}
+
+func TestCachingTransportInvalidate(t *testing.T) {
+	ct := newCacheTest(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+	})
+	defer ct.close()
+
+	ct.get()
+	ct.get()
+	ct.wantHits(1)
+	ct.do("POST")
+	ct.wantHits(2)
+	ct.get()
+	ct.wantHits(3)
+}
+
+func TestCachingTransportOnlyIfCached(t *testing.T) {
+	ct := newCacheTest(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+	})
+	defer ct.close()
+
+	res, _ := ct.get("Cache-Control: only-if-cached")
+	if res.StatusCode != http.StatusGatewayTimeout {
+		t.Errorf("status = %d; want 504", res.StatusCode)
+	}
+	ct.wantHits(0)
+	ct.get()
+	res, _ = ct.get("Cache-Control: only-if-cached")
+	if res.StatusCode != 200 {
+		t.Errorf("status = %d; want 200", res.StatusCode)
+	}
+	ct.wantHits(1)
+}
+
+func TestCachingTransportMaxStale(t *testing.T) {
+	ct := newCacheTest(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=0")
+		w.Write([]byte("old"))
+	})
+	defer ct.close()
+
+	ct.get()
+	res, body := ct.get("Cache-Control: max-stale")
+	ct.wantHits(1)
+	if body != "old" || !strings.HasPrefix(res.Header.Get("Warning"), "110") {
+		t.Errorf("got %q with Warning %q; want stale response with warning 110", body, res.Header.Get("Warning"))
+	}
+	if len(ct.events) != 1 || !ct.events[0].Stale {
+		t.Errorf("CacheHit events = %+v; want one stale hit", ct.events)
+	}
+}
+
+func TestCachingTransportPartialRead(t *testing.T) {
+	ct := newCacheTest(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Write([]byte(strings.Repeat("x", 1<<16)))
+	})
+	defer ct.close()
+
+	res, err := ct.client.Get(ct.ts.URL + "/x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Read(make([]byte, 10))
+	res.Body.Close()
+	if _, ok := ct.cache.Get(ct.ts.URL + "/x"); ok {
+		t.Error("partially read response was stored")
+	}
+}
+
+func TestCachingTransportMaxEntryBytes(t *testing.T) {
+	for _, chunked := range []bool{false, true} {
+		ct := newCacheTest(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Cache-Control", "max-age=60")
+			if chunked {
+				w.(http.Flusher).Flush()
+			}
+			w.Write([]byte(strings.Repeat("x", 1000)))
+		})
+		ct.client.Transport.(*CachingTransport).MaxEntryBytes = 999
+		ct.get()
+		if _, ok := ct.cache.Get(ct.ts.URL + "/x"); ok {
+			t.Errorf("chunked=%v: response larger than MaxEntryBytes was stored", chunked)
+		}
+		ct.client.Transport.(*CachingTransport).MaxEntryBytes = 1000
+		ct.get()
+		if _, ok := ct.cache.Get(ct.ts.URL + "/x"); !ok {
+			t.Errorf("chunked=%v: response within MaxEntryBytes was not stored", chunked)
+		}
+		ct.close()
+	}
+}
+
+func TestMemoryCacheEviction(t *testing.T) {
+	c := &MemoryCache{MaxBytes: 10}
+	c.Set("a", []byte("aaaa"))
+	c.Set("b", []byte("bbbb"))
+	c.Get("a")
+	c.Set("c", []byte("cccc")) // evicts b, the least recently used
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if _, ok := c.Get(key); ok != want {
+			t.Errorf("after eviction, %q stored = %v; want %v", key, ok, want)
+		}
+	}
+	c.Set("d", []byte("too large to store"))
+	if _, ok := c.Get("d"); ok {
+		t.Error("value larger than MaxBytes was stored")
+	}
+	c.Set("a", []byte("aaaaaaaaaa"))
+	if _, ok := c.Get("c"); ok {
+		t.Error("replacing a value did not evict to stay within MaxBytes")
+	}
+}
+
+func TestParseCacheControl(t *testing.T) {
+	h := http.Header{"Cache-Control": {`Max-Age=30, no-cache="Set-Cookie"`, "private"}}
+	cc := parseCacheControl(h)
+	want := map[string]string{"max-age": "30", "no-cache": "Set-Cookie", "private": ""}
+	if len(cc) != len(want) {
+		t.Fatalf("got %v; want %v", cc, want)
+	}
+	for k, v := range want {
+		if got, ok := cc[k]; !ok || got != v {
+			t.Errorf("%s = %q, %v; want %q", k, got, ok, v)
+		}
+	}
+}
+
+func TestCacheEntryEncoding(t *testing.T) {
+	e := &cacheEntry{
+		reqTime:    time.Unix(100, 1),
+		resTime:    time.Unix(101, 2),
+		vary:       http.Header{"Accept-Language": {""}, "Accept": {"text/html, */*"}},
+		status:     "200 OK",
+		statusCode: 200,
+		header:     http.Header{"Content-Type": {"text/plain"}, "Etag": {`"x"`}},
+		body:       []byte("line1\r\n\r\nline2"),
+	}
+	got, err := decodeCacheEntry(e.encode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, e) {
+		t.Errorf("round trip:\n got %+v\nwant %+v", got, e)
+	}
+	if _, err := decodeCacheEntry([]byte("garbage")); err == nil {
+		t.Error("decoding garbage succeeded")
+	}
+}
@@ -8,6 +8,7 @@ package httputil
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"log"
 	"net"
@@ -25,6 +26,13 @@ var onExitFlushLoop func()
 // ReverseProxy is an HTTP Handler that takes an incoming request and
 // sends it to another server, proxying the response back to the
 // client.
+//
+// A request asking to switch protocols with an "Upgrade" header,
+// such as a WebSocket handshake, is forwarded with its Upgrade header
+// intact. If the backend answers with "101 Switching Protocols",
+// ReverseProxy hijacks the client connection and copies bytes in both
+// directions between it and the backend connection until either side
+// closes. The backend must be reached over HTTP/1.x for this to work.
 type ReverseProxy struct {
 	// Director must be a function which modifies
 	// the request into a new request to be sent
@@ -34,6 +42,22 @@ type ReverseProxy struct {
 	// after returning.
 	Director func(*http.Request)
 
+	// ForwardedHeaders optionally sets the X-Forwarded-For,
+	// X-Forwarded-Host and X-Forwarded-Proto headers of the
+	// outbound request. It is called after Director and after
+	// hop-by-hop headers have been removed, with the outbound
+	// request and the inbound request the proxy received.
+	// SetXForwarded is a suitable implementation; a function that
+	// does nothing forwards the headers exactly as Director left
+	// them.
+	//
+	// If nil, the client's IP address is appended to any
+	// X-Forwarded-For header already present, and no other
+	// X-Forwarded headers are added. A Director that sets the
+	// outbound X-Forwarded-For header to a nil value suppresses
+	// that append.
+	ForwardedHeaders func(out, in *http.Request)
+
 	// The transport used to perform proxy requests.
 	// If nil, http.DefaultTransport is used.
 	Transport http.RoundTripper
@@ -55,10 +79,24 @@ type ReverseProxy struct {
 	// copying HTTP response bodies.
 	BufferPool BufferPool
 
-	// ModifyResponse is an optional function that
-	// modifies the Response from the backend.
-	// If it returns an error, the proxy returns a StatusBadGateway error.
+	// ModifyResponse is an optional function that modifies the
+	// Response from the backend. It is called if the backend
+	// returns a response at all, with any HTTP status code.
+	// If the backend is unreachable, the optional ErrorHandler is
+	// called without any call to ModifyResponse.
+	//
+	// If ModifyResponse returns an error, ErrorHandler is called
+	// with its error value. If ErrorHandler is nil, its default
+	// implementation is used.
 	ModifyResponse func(*http.Response) error
+
+	// ErrorHandler is an optional function that handles errors
+	// reaching the backend, errors from ModifyResponse and errors
+	// while switching protocols.
+	//
+	// If nil, the default is to log the provided error and return
+	// a 502 Status Bad Gateway response.
+	ErrorHandler func(http.ResponseWriter, *http.Request, error)
 }
 
 // A BufferPool is an interface for getting and returning temporary
@@ -114,6 +152,55 @@ func copyHeader(dst, src http.Header) {
 	}
 }
 
+// SetXForwarded sets the X-Forwarded-For, X-Forwarded-Host and
+// X-Forwarded-Proto headers of the outbound request out from the
+// inbound request in, replacing any values already present.
+//
+// X-Forwarded-For is set to the client's IP address,
+// X-Forwarded-Host to the Host requested by the client, and
+// X-Forwarded-Proto to "https" or "http" depending on whether in
+// arrived over TLS.
+//
+// SetXForwarded discards X-Forwarded headers sent by the client, so
+// it should be used by proxies that are the first to receive a
+// request from an untrusted client. It can be assigned to
+// ReverseProxy.ForwardedHeaders.
+func SetXForwarded(out, in *http.Request) {
+	clientIP, _, err := net.SplitHostPort(in.RemoteAddr)
+	if err == nil {
+		out.Header.Set("X-Forwarded-For", clientIP)
+	} else {
+		out.Header.Del("X-Forwarded-For")
+	}
+	out.Header.Set("X-Forwarded-Host", in.Host)
+	if in.TLS == nil {
+		out.Header.Set("X-Forwarded-Proto", "http")
+	} else {
+		out.Header.Set("X-Forwarded-Proto", "https")
+	}
+}
+
+// appendXForwardedFor is the default ForwardedHeaders behavior.
+func appendXForwardedFor(out, in *http.Request) {
+	clientIP, _, err := net.SplitHostPort(in.RemoteAddr)
+	if err != nil {
+		return
+	}
+	// If we aren't the first proxy retain prior
+	// X-Forwarded-For information as a comma+space
+	// separated list and fold multiple headers into one.
+	prior, ok := out.Header["X-Forwarded-For"]
+	if ok && prior == nil {
+		// The Director asked for the header to be omitted.
+		delete(out.Header, "X-Forwarded-For")
+		return
+	}
+	if len(prior) > 0 {
+		clientIP = strings.Join(prior, ", ") + ", " + clientIP
+	}
+	out.Header.Set("X-Forwarded-For", clientIP)
+}
+
 func cloneHeader(h http.Header) http.Header {
 	h2 := make(http.Header, len(h))
 	for k, vv := range h {
@@ -141,6 +228,32 @@ var hopHeaders = []string{
 	"Upgrade",
 }
 
+func (p *ReverseProxy) defaultErrorHandler(rw http.ResponseWriter, req *http.Request, err error) {
+	p.logf("http: proxy error: %v", err)
+	rw.WriteHeader(http.StatusBadGateway)
+}
+
+func (p *ReverseProxy) getErrorHandler() func(http.ResponseWriter, *http.Request, error) {
+	if p.ErrorHandler != nil {
+		return p.ErrorHandler
+	}
+	return p.defaultErrorHandler
+}
+
+// modifyResponse conditionally runs the optional ModifyResponse hook
+// and reports whether the request should proceed.
+func (p *ReverseProxy) modifyResponse(rw http.ResponseWriter, res *http.Response, req *http.Request) bool {
+	if p.ModifyResponse == nil {
+		return true
+	}
+	if err := p.ModifyResponse(res); err != nil {
+		res.Body.Close()
+		p.getErrorHandler()(rw, req, err)
+		return false
+	}
+	return true
+}
+
 func (p *ReverseProxy) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 	transport := p.Transport
 	if transport == nil {
@@ -172,6 +285,7 @@ func (p *ReverseProxy) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 	p.Director(outreq)
 	outreq.Close = false
 
+	reqUpType := upgradeType(outreq.Header)
 	removeConnectionHeaders(outreq.Header)
 
 	// Remove hop-by-hop headers to the backend. Especially
@@ -183,20 +297,31 @@ func (p *ReverseProxy) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 		}
 	}
 
-	if clientIP, _, err := net.SplitHostPort(req.RemoteAddr); err == nil {
-		// If we aren't the first proxy retain prior
-		// X-Forwarded-For information as a comma+space
-		// separated list and fold multiple headers into one.
-		if prior, ok := outreq.Header["X-Forwarded-For"]; ok {
-			clientIP = strings.Join(prior, ", ") + ", " + clientIP
-		}
-		outreq.Header.Set("X-Forwarded-For", clientIP)
+	// After stripping all the hop-by-hop connection headers above, add back any
+	// necessary for protocol upgrades, such as for websockets.
+	if reqUpType != "" {
+		outreq.Header.Set("Connection", "Upgrade")
+		outreq.Header.Set("Upgrade", reqUpType)
+	}
+
+	if p.ForwardedHeaders != nil {
+		p.ForwardedHeaders(outreq, req)
+	} else {
+		appendXForwardedFor(outreq, req)
 	}
 
 	res, err := transport.RoundTrip(outreq)
 	if err != nil {
-		p.logf("http: proxy error: %v", err)
-		rw.WriteHeader(http.StatusBadGateway)
+		p.getErrorHandler()(rw, outreq, err)
+		return
+	}
+
+	// Deal with 101 Switching Protocols responses: (WebSocket, h2c, etc)
+	if res.StatusCode == http.StatusSwitchingProtocols {
+		if !p.modifyResponse(rw, res, outreq) {
+			return
+		}
+		p.handleUpgradeResponse(rw, outreq, res)
 		return
 	}
 
@@ -206,13 +331,8 @@ func (p *ReverseProxy) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 		res.Header.Del(h)
 	}
 
-	if p.ModifyResponse != nil {
-		if err := p.ModifyResponse(res); err != nil {
-			p.logf("http: proxy error: %v", err)
-			rw.WriteHeader(http.StatusBadGateway)
-			res.Body.Close()
-			return
-		}
+	if !p.modifyResponse(rw, res, outreq) {
+		return
 	}
 
 	copyHeader(rw.Header(), res.Header)
@@ -265,6 +385,93 @@ func removeConnectionHeaders(h http.Header) {
 	}
 }
 
+// upgradeType returns the lower-cased protocol named by the "Upgrade"
+// header in h, or "" if h does not request a protocol switch.
+func upgradeType(h http.Header) string {
+	if !headerValuesContainToken(h["Connection"], "Upgrade") {
+		return ""
+	}
+	return strings.ToLower(h.Get("Upgrade"))
+}
+
+// headerValuesContainToken reports whether any of the comma-separated
+// values contains token, compared case-insensitively.
+func headerValuesContainToken(values []string, token string) bool {
+	for _, v := range values {
+		for _, f := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(f), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (p *ReverseProxy) handleUpgradeResponse(rw http.ResponseWriter, req *http.Request, res *http.Response) {
+	reqUpType := upgradeType(req.Header)
+	resUpType := upgradeType(res.Header)
+	if reqUpType != resUpType {
+		res.Body.Close()
+		p.getErrorHandler()(rw, req, fmt.Errorf("backend tried to switch protocol %q when %q was requested", resUpType, reqUpType))
+		return
+	}
+
+	backConn, ok := res.Body.(io.ReadWriteCloser)
+	if !ok {
+		res.Body.Close()
+		p.getErrorHandler()(rw, req, fmt.Errorf("internal error: 101 switching protocols response with non-writable body"))
+		return
+	}
+	defer backConn.Close()
+
+	hj, ok := rw.(http.Hijacker)
+	if !ok {
+		p.getErrorHandler()(rw, req, fmt.Errorf("can't switch protocols using non-Hijacker ResponseWriter type %T", rw))
+		return
+	}
+	conn, brw, err := hj.Hijack()
+	if err != nil {
+		p.getErrorHandler()(rw, req, fmt.Errorf("hijack failed on protocol switch: %v", err))
+		return
+	}
+	defer conn.Close()
+
+	copyHeader(res.Header, rw.Header())
+	res.Body = nil // so res.Write only writes the headers; we have res.Body in backConn above
+	if err := res.Write(brw); err != nil {
+		p.logf("httputil: ReverseProxy response write on protocol switch: %v", err)
+		return
+	}
+	if err := brw.Flush(); err != nil {
+		p.logf("httputil: ReverseProxy response flush on protocol switch: %v", err)
+		return
+	}
+
+	// Both copiers send on errc; it is buffered so that the one that
+	// finishes second, after the deferred Closes, does not block.
+	errc := make(chan error, 2)
+	spc := switchProtocolCopier{user: conn, backend: backConn}
+	go spc.copyToBackend(errc)
+	go spc.copyFromBackend(errc)
+	<-errc
+}
+
+// switchProtocolCopier exists so goroutines proxying data back and
+// forth have nice names in stacks.
+type switchProtocolCopier struct {
+	user, backend io.ReadWriter
+}
+
+func (c switchProtocolCopier) copyFromBackend(errc chan<- error) {
+	_, err := io.Copy(c.user, c.backend)
+	errc <- err
+}
+
+func (c switchProtocolCopier) copyToBackend(errc chan<- error) {
+	_, err := io.Copy(c.backend, c.user)
+	errc <- err
+}
+
 func (p *ReverseProxy) copyResponse(dst io.Writer, src io.Reader) {
 	if p.FlushInterval != 0 {
 		if wf, ok := dst.(writeFlusher); ok {
@@ -0,0 +1,320 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Patterns for ServeMux routing.
+
+package http
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"unicode"
+)
+
+// A pattern is a parsed ServeMux pattern of the form
+//
+//	[METHOD ][HOST]/[PATH]
+//
+// or of the form [METHOD ]HOST, which matches only an empty path.
+// See the ServeMux documentation for the syntax.
+type pattern struct {
+	str    string // original string
+	method string // "" matches every method
+	host   string
+	// segments are the slash-separated parts of the path,
+	// without the leading slash. The last one may be a multi
+	// segment, matching the rest of the path. A host-only
+	// pattern has no segments.
+	segments []segment
+}
+
+// A segment is one part of a pattern's path.
+// It is a literal, a single-segment wildcard, or a multi-segment
+// wildcard, which is always last. A trailing slash is an anonymous
+// multi-segment wildcard, and "{$}" is the empty literal.
+type segment struct {
+	s     string // literal, or wildcard name
+	wild  bool
+	multi bool
+}
+
+func (p *pattern) lastSegment() segment {
+	return p.segments[len(p.segments)-1]
+}
+
+func parsePattern(s string) (*pattern, error) {
+	if s == "" {
+		return nil, errors.New("empty pattern")
+	}
+	p := &pattern{str: s}
+	rest := s
+	if i := strings.IndexAny(s, " \t"); i >= 0 {
+		p.method = s[:i]
+		if !validMethod(p.method) {
+			return nil, errors.New("invalid method " + p.method)
+		}
+		rest = strings.TrimLeft(s[i+1:], " \t")
+	}
+	i := strings.IndexByte(rest, '/')
+	if i < 0 {
+		// A host-only pattern, such as "example.com:443",
+		// matches only requests with an empty path.
+		if rest == "" {
+			return nil, errors.New("missing host and path")
+		}
+		i = len(rest)
+	}
+	p.host = rest[:i]
+	if strings.ContainsAny(p.host, "{}") {
+		return nil, errors.New("host contains '{' or '}'")
+	}
+	if i == len(rest) {
+		return p, nil
+	}
+
+	names := make(map[string]bool)
+	raw := strings.Split(rest[i+1:], "/")
+	for i, r := range raw {
+		last := i == len(raw)-1
+		switch {
+		case last && r == "":
+			// Trailing slash: match the rest of the path.
+			p.segments = append(p.segments, segment{wild: true, multi: true})
+			continue
+		case r == "{$}":
+			if !last {
+				return nil, errors.New("{$} not at end")
+			}
+			p.segments = append(p.segments, segment{s: ""})
+			continue
+		case !strings.HasPrefix(r, "{"):
+			if strings.ContainsAny(r, "{}") {
+				return nil, errors.New("bad wildcard segment " + r + " (must be the entire segment)")
+			}
+			lit, err := url.PathUnescape(r)
+			if err != nil {
+				return nil, err
+			}
+			p.segments = append(p.segments, segment{s: lit})
+			continue
+		}
+		if !strings.HasSuffix(r, "}") {
+			return nil, errors.New("bad wildcard segment " + r + " (must end with '}')")
+		}
+		name := r[1 : len(r)-1]
+		seg := segment{wild: true}
+		if strings.HasSuffix(name, "...") {
+			if !last {
+				return nil, errors.New("{" + name + "} not at end")
+			}
+			name = name[:len(name)-len("...")]
+			seg.multi = true
+		}
+		if !isValidWildcardName(name) {
+			return nil, errors.New("bad wildcard name " + name)
+		}
+		if names[name] {
+			return nil, errors.New("duplicate wildcard name " + name)
+		}
+		names[name] = true
+		seg.s = name
+		p.segments = append(p.segments, seg)
+	}
+	return p, nil
+}
+
+// isValidWildcardName reports whether s is a Go identifier.
+func isValidWildcardName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		if !unicode.IsLetter(c) && c != '_' && (i == 0 || !unicode.IsDigit(c)) {
+			return false
+		}
+	}
+	return true
+}
+
+// splitPath splits an unescaped path, which must begin with a slash,
+// into the segments a pattern is matched against.
+func splitPath(path string) []string {
+	return strings.Split(path[1:], "/")
+}
+
+// splitEscapedPath is like splitPath, but for an escaped path.
+// Each segment is unescaped after splitting, so an escaped slash
+// does not separate segments.
+func splitEscapedPath(path string) []string {
+	segs := splitPath(path)
+	for i, s := range segs {
+		if strings.IndexByte(s, '%') < 0 {
+			continue
+		}
+		if u, err := url.PathUnescape(s); err == nil {
+			segs[i] = u
+		}
+	}
+	return segs
+}
+
+// matchMethod reports whether p applies to requests with the given method.
+// A pattern for GET also matches HEAD.
+func (p *pattern) matchMethod(method string) bool {
+	return p.method == "" || p.method == method || p.method == "GET" && method == "HEAD"
+}
+
+// matchPath reports whether p's path matches the path split into segs,
+// along with the values of p's named wildcards, in order.
+func (p *pattern) matchPath(segs []string) (matches []string, ok bool) {
+	for i, seg := range p.segments {
+		if i >= len(segs) {
+			return nil, false
+		}
+		switch {
+		case seg.multi:
+			if seg.s != "" {
+				matches = append(matches, strings.Join(segs[i:], "/"))
+			}
+			return matches, true
+		case seg.wild:
+			if segs[i] == "" {
+				return nil, false
+			}
+			matches = append(matches, segs[i])
+		case segs[i] != seg.s:
+			return nil, false
+		}
+	}
+	return matches, len(segs) == len(p.segments)
+}
+
+// exactMatch reports whether p matched path without using a
+// multi-segment wildcard to match anything beyond a trailing slash.
+// A request for "/a" that does not exactly match any pattern is
+// redirected to "/a/" when that does.
+func (p *pattern) exactMatch(path string) bool {
+	if len(p.segments) == 0 || !p.lastSegment().multi {
+		return true
+	}
+	if !strings.HasSuffix(path, "/") {
+		return false
+	}
+	return len(p.segments) == strings.Count(path, "/")
+}
+
+// wildcardNames returns the names of p's wildcards, in order.
+func (p *pattern) wildcardNames() []string {
+	var names []string
+	for _, seg := range p.segments {
+		if seg.wild && seg.s != "" {
+			names = append(names, seg.s)
+		}
+	}
+	return names
+}
+
+// A relationship describes how the sets of requests matched by two
+// patterns relate to each other.
+type relationship int
+
+const (
+	relEquivalent   relationship = iota // same requests
+	relMoreSpecific                     // a strict subset
+	relMoreGeneral                      // a strict superset
+	relOverlaps                         // neither contains the other, but they intersect
+	relDisjoint                         // no request matches both
+)
+
+// combineRelationships returns the relationship of two patterns
+// given the relationships of two independent parts of them, such as
+// their methods and their paths.
+func combineRelationships(r1, r2 relationship) relationship {
+	switch {
+	case r1 == relDisjoint || r2 == relDisjoint:
+		return relDisjoint
+	case r1 == relEquivalent:
+		return r2
+	case r2 == relEquivalent || r1 == r2:
+		return r1
+	}
+	return relOverlaps
+}
+
+// compare returns the relationship of p to q. Patterns for
+// different hosts are disjoint.
+func (p *pattern) compare(q *pattern) relationship {
+	if p.host != q.host {
+		return relDisjoint
+	}
+	return combineRelationships(p.compareMethods(q), p.comparePaths(q))
+}
+
+func (p *pattern) compareMethods(q *pattern) relationship {
+	switch {
+	case p.method == q.method:
+		return relEquivalent
+	case p.method == "":
+		return relMoreGeneral
+	case q.method == "":
+		return relMoreSpecific
+	case p.method == "GET" && q.method == "HEAD":
+		return relMoreGeneral
+	case p.method == "HEAD" && q.method == "GET":
+		return relMoreSpecific
+	}
+	return relDisjoint
+}
+
+func (p *pattern) comparePaths(q *pattern) relationship {
+	rel := relEquivalent
+	for i := 0; ; i++ {
+		pdone, qdone := i >= len(p.segments), i >= len(q.segments)
+		switch {
+		case pdone && qdone:
+			return rel
+		case pdone || qdone:
+			// One pattern has ended and the other requires
+			// more segments.
+			return relDisjoint
+		}
+		ps, qs := p.segments[i], q.segments[i]
+		switch {
+		case ps.multi && qs.multi:
+			return rel
+		case ps.multi:
+			// The rest of q is non-empty, so ps matches all of it.
+			return combineRelationships(rel, relMoreGeneral)
+		case qs.multi:
+			return combineRelationships(rel, relMoreSpecific)
+		}
+		rel = combineRelationships(rel, compareSegments(ps, qs))
+		if rel == relDisjoint {
+			return rel
+		}
+	}
+}
+
+// compareSegments compares two single-segment matchers.
+func compareSegments(s1, s2 segment) relationship {
+	switch {
+	case s1.wild && s2.wild:
+		return relEquivalent
+	case s1.wild:
+		if s2.s == "" {
+			// Wildcards don't match empty segments.
+			return relDisjoint
+		}
+		return relMoreGeneral
+	case s2.wild:
+		if s1.s == "" {
+			return relDisjoint
+		}
+		return relMoreSpecific
+	case s1.s == s2.s:
+		return relEquivalent
+	}
+	return relDisjoint
+}
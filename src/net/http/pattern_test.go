@@ -0,0 +1,175 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package http
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePattern(t *testing.T) {
+	lit := func(s string) segment { return segment{s: s} }
+	wild := func(name string) segment { return segment{s: name, wild: true} }
+	multi := func(name string) segment { return segment{s: name, wild: true, multi: true} }
+
+	tests := []struct {
+		in   string
+		want pattern
+	}{
+		{"/", pattern{segments: []segment{multi("")}}},
+		{"/a", pattern{segments: []segment{lit("a")}}},
+		{"/a/", pattern{segments: []segment{lit("a"), multi("")}}},
+		{"/a/{x}", pattern{segments: []segment{lit("a"), wild("x")}}},
+		{"/a/{x...}", pattern{segments: []segment{lit("a"), multi("x")}}},
+		{"/{$}", pattern{segments: []segment{lit("")}}},
+		{"/a/{$}", pattern{segments: []segment{lit("a"), lit("")}}},
+		{"/a%2Fb/c", pattern{segments: []segment{lit("a/b"), lit("c")}}},
+		{"example.com/", pattern{host: "example.com", segments: []segment{multi("")}}},
+		{"GET /", pattern{method: "GET", segments: []segment{multi("")}}},
+		{"POST \t example.com/{id}", pattern{method: "POST", host: "example.com", segments: []segment{wild("id")}}},
+		{"example.com", pattern{host: "example.com"}},
+		{"CONNECT example.com:443", pattern{method: "CONNECT", host: "example.com:443"}},
+	}
+	for _, tt := range tests {
+		got, err := parsePattern(tt.in)
+		if err != nil {
+			t.Errorf("parsePattern(%q): %v", tt.in, err)
+			continue
+		}
+		tt.want.str = tt.in
+		if !reflect.DeepEqual(*got, tt.want) {
+			t.Errorf("parsePattern(%q) = %+v; want %+v", tt.in, *got, tt.want)
+		}
+	}
+}
+
+func TestParsePatternError(t *testing.T) {
+	tests := []struct {
+		in      string
+		wantErr string
+	}{
+		{"", "empty pattern"},
+		{"GET ", "missing host and path"},
+		{"{x}", "host contains"},
+		{"GE(T /", "invalid method"},
+		{"/{x", "must end with '}'"},
+		{"/a{x}", "must be the entire segment"},
+		{"/{x}b", "must end with '}'"},
+		{"/{}", "bad wildcard name"},
+		{"/{1x}", "bad wildcard name"},
+		{"/{x...}/a", "not at end"},
+		{"/{$}/a", "not at end"},
+		{"/{x}/{x}", "duplicate wildcard name"},
+		{"{host}/", "host contains"},
+	}
+	for _, tt := range tests {
+		_, err := parsePattern(tt.in)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("parsePattern(%q) error = %v; want error containing %q", tt.in, err, tt.wantErr)
+		}
+	}
+}
+
+func TestPatternMatch(t *testing.T) {
+	tests := []struct {
+		pat         string
+		path        string
+		wantMatches []string // nil for no match
+	}{
+		{"/", "/", []string{}},
+		{"/", "/a/b", []string{}},
+		{"/{$}", "/", []string{}},
+		{"/{$}", "/a", nil},
+		{"/a", "/a", []string{}},
+		{"/a", "/a/", nil},
+		{"/a/", "/a", nil},
+		{"/a/", "/a/", []string{}},
+		{"/a/", "/a/b/c", []string{}},
+		{"/a/{x}", "/a/b", []string{"b"}},
+		{"/a/{x}", "/a/", nil},
+		{"/a/{x}", "/a/b/c", nil},
+		{"/a/{x}/c", "/a/b/c", []string{"b"}},
+		{"/a/{x...}", "/a/", []string{""}},
+		{"/a/{x...}", "/a/b/c", []string{"b/c"}},
+		{"/a/{x...}", "/a", nil},
+		{"/{x}/{y}/{$}", "/a/b/", []string{"a", "b"}},
+		{"/{x}/{y}/{$}", "/a/b/c", nil},
+	}
+	for _, tt := range tests {
+		p, err := parsePattern(tt.pat)
+		if err != nil {
+			t.Fatal(err)
+		}
+		matches, ok := p.matchPath(splitPath(tt.path))
+		if !ok {
+			if tt.wantMatches != nil {
+				t.Errorf("%q does not match %q; want match", tt.pat, tt.path)
+			}
+			continue
+		}
+		if tt.wantMatches == nil {
+			t.Errorf("%q matches %q; want no match", tt.pat, tt.path)
+			continue
+		}
+		if len(matches) != len(tt.wantMatches) || len(matches) > 0 && !reflect.DeepEqual(matches, tt.wantMatches) {
+			t.Errorf("%q matching %q: got %q; want %q", tt.pat, tt.path, matches, tt.wantMatches)
+		}
+	}
+}
+
+func TestPatternCompare(t *testing.T) {
+	tests := []struct {
+		p1, p2 string
+		want   relationship
+	}{
+		{"/a", "/a", relEquivalent},
+		{"/a", "/b", relDisjoint},
+		{"/a", "/{x}", relMoreSpecific},
+		{"/{x}", "/{y}", relEquivalent},
+		{"/a/", "/", relMoreSpecific},
+		{"/a/{x}", "/a/", relMoreSpecific},
+		{"/a/{x...}", "/a/", relEquivalent},
+		{"/a", "/a/", relDisjoint},
+		{"/{$}", "/", relMoreSpecific},
+		{"/{$}", "/{x}", relDisjoint},
+		{"/a/{x}", "/{y}/b", relOverlaps},
+		{"/{x}/b/", "/a/{y}/c", relOverlaps},
+		{"/a/b/c", "/a/{x}/", relMoreSpecific},
+		{"GET /a", "/a", relMoreSpecific},
+		{"GET /a", "POST /", relDisjoint},
+		{"HEAD /a", "GET /a", relMoreSpecific},
+		{"GET /", "/a", relOverlaps},
+		{"GET /a", "GET /a", relEquivalent},
+		{"a.com/", "b.com/", relDisjoint},
+		{"a.com/", "/", relDisjoint},
+		{"a.com", "a.com", relEquivalent},
+		{"a.com", "a.com/", relDisjoint},
+		{"CONNECT a.com", "a.com", relMoreSpecific},
+	}
+	inverse := map[relationship]relationship{
+		relEquivalent:   relEquivalent,
+		relMoreSpecific: relMoreGeneral,
+		relMoreGeneral:  relMoreSpecific,
+		relOverlaps:     relOverlaps,
+		relDisjoint:     relDisjoint,
+	}
+	for _, tt := range tests {
+		p1, err := parsePattern(tt.p1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p2, err := parsePattern(tt.p2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := p1.compare(p2); got != tt.want {
+			t.Errorf("compare(%q, %q) = %d; want %d", tt.p1, tt.p2, got, tt.want)
+		}
+		if got, want := p2.compare(p1), inverse[tt.want]; got != want {
+			t.Errorf("compare(%q, %q) = %d; want %d", tt.p2, tt.p1, got, want)
+		}
+	}
+}
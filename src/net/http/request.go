@@ -304,6 +304,12 @@ type Request struct {
 	// It is unexported to prevent people from using Context wrong
 	// and mutating the contexts held by callers of the same request.
 	ctx context.Context
+
+	// pathValues holds the values of the wildcards in the
+	// ServeMux pattern that matched the request.
+	// It is replaced, never modified, so copies of the
+	// Request may share it.
+	pathValues map[string]string
 }
 
 // Context returns the request's context. To change the context, use
@@ -1315,6 +1321,40 @@ func (r *Request) isReplayable() bool {
 	return false
 }
 
+// PathValue returns the value for the named path wildcard in the
+// ServeMux pattern that matched the request.
+// It returns the empty string if the request was not matched against
+// a pattern or there is no such wildcard in the pattern.
+func (r *Request) PathValue(name string) string {
+	return r.pathValues[name]
+}
+
+// SetPathValue sets name to value, so that subsequent calls to
+// r.PathValue(name) return value.
+func (r *Request) SetPathValue(name, value string) {
+	m := make(map[string]string, len(r.pathValues)+1)
+	for k, v := range r.pathValues {
+		m[k] = v
+	}
+	m[name] = value
+	r.pathValues = m
+}
+
+// setPathValues records the values of pat's wildcards, as
+// returned by pat.matchPath.
+func (r *Request) setPathValues(pat *pattern, matches []string) {
+	names := pat.wildcardNames()
+	if len(names) == 0 {
+		r.pathValues = nil
+		return
+	}
+	m := make(map[string]string, len(names))
+	for i, name := range names {
+		m[name] = matches[i]
+	}
+	r.pathValues = m
+}
+
 // outgoingLength reports the Content-Length of this outgoing (Client) request.
 // It maps 0 into -1 (unknown) when the Body is non-nil.
 func (r *Request) outgoingLength() int64 {
@@ -17,6 +17,8 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+
+	"golang_org/x/net/lex/httplex"
 )
 
 var respExcludeHeader = map[string]bool{
@@ -63,6 +65,12 @@ type Response struct {
 	//
 	// The Body is automatically dechunked if the server replied
 	// with a "chunked" Transfer-Encoding.
+	//
+	// On a successful "101 Switching Protocols" response from an
+	// HTTP/1.x Transport, the Body also implements io.Writer and
+	// reads from and writes to the underlying connection. The
+	// connection is then owned by the caller and is closed when
+	// Body is closed.
 	Body io.ReadCloser
 
 	// ContentLength records the length of the associated content. The
@@ -328,6 +336,14 @@ func (r *Response) Write(w io.Writer) error {
 	return nil
 }
 
+// isProtocolSwitch reports whether r is a response to a successful
+// protocol upgrade.
+func (r *Response) isProtocolSwitch() bool {
+	return r.StatusCode == StatusSwitchingProtocols &&
+		r.Header.Get("Upgrade") != "" &&
+		httplex.HeaderValuesContainsToken(r.Header["Connection"], "Upgrade")
+}
+
 func (r *Response) closeBody() {
 	if r.Body != nil {
 		r.Body.Close()
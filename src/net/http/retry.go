@@ -0,0 +1,202 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// HTTP client retry policy. See Client.Retry.
+
+package http
+
+import (
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http/httptrace"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default backoff bounds used when a RetryPolicy leaves them zero.
+const (
+	defaultMinRetryBackoff = 100 * time.Millisecond
+	defaultMaxRetryBackoff = 10 * time.Second
+)
+
+// A RetryPolicy controls how a Client retries a request that failed
+// or that the server asked to be retried.
+//
+// Between attempts the Client waits for an exponentially growing,
+// randomly jittered delay. If the server's response carries a
+// Retry-After header, its value is used as the delay instead.
+//
+// A request with a non-nil Body is only retried if it also has a
+// GetBody function, which is used to obtain a fresh copy of the body
+// for each attempt. NewRequest sets GetBody for common body types.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of times a request is
+	// sent, including the first attempt. Values less than 2
+	// disable retries.
+	MaxAttempts int
+
+	// MinBackoff is the delay before the first retry. Each
+	// subsequent retry doubles the delay, up to MaxBackoff.
+	// The actual delay is chosen randomly between half the
+	// computed delay and the full computed delay.
+	// If zero, 100 milliseconds is used.
+	MinBackoff time.Duration
+
+	// MaxBackoff caps the delay between attempts. A Retry-After
+	// value larger than MaxBackoff stops retrying and returns the
+	// response as is.
+	// If zero, 10 seconds is used.
+	MaxBackoff time.Duration
+
+	// ShouldRetry reports whether the outcome of an attempt
+	// should be retried. Exactly one of resp and err is non-nil.
+	// ShouldRetry must not read or close resp.Body.
+	//
+	// If ShouldRetry is nil, the Client retries transport errors
+	// for idempotent requests (GET, HEAD, OPTIONS and TRACE) that
+	// were not canceled, and responses with status 429 (Too Many
+	// Requests) or 503 (Service Unavailable).
+	ShouldRetry func(req *Request, resp *Response, err error) bool
+}
+
+func (p *RetryPolicy) shouldRetry(req *Request, resp *Response, err error) bool {
+	if p.ShouldRetry != nil {
+		return p.ShouldRetry(req, resp, err)
+	}
+	return defaultShouldRetry(req, resp, err)
+}
+
+func defaultShouldRetry(req *Request, resp *Response, err error) bool {
+	if err != nil {
+		if req.Context().Err() != nil {
+			return false
+		}
+		return req.isReplayable()
+	}
+	switch resp.StatusCode {
+	case StatusTooManyRequests, StatusServiceUnavailable:
+		return true
+	}
+	return false
+}
+
+func (p *RetryPolicy) minBackoff() time.Duration {
+	if p.MinBackoff > 0 {
+		return p.MinBackoff
+	}
+	return defaultMinRetryBackoff
+}
+
+func (p *RetryPolicy) maxBackoff() time.Duration {
+	if p.MaxBackoff > 0 {
+		return p.MaxBackoff
+	}
+	return defaultMaxRetryBackoff
+}
+
+// backoff returns the delay to wait after the given attempt (starting
+// at 1) has produced resp. It reports false if the server asked for a
+// delay longer than the policy allows.
+func (p *RetryPolicy) backoff(attempt int, resp *Response) (time.Duration, bool) {
+	max := p.maxBackoff()
+	if resp != nil {
+		if d, ok := parseRetryAfter(resp.Header.get("Retry-After"), time.Now()); ok {
+			return d, d <= max
+		}
+	}
+	d := p.minBackoff()
+	for i := 1; i < attempt && d < max; i++ {
+		d *= 2
+	}
+	if d > max || d <= 0 {
+		d = max
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(d-half)+1)), true
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which is
+// either a number of seconds or an HTTP-date, relative to now.
+func parseRetryAfter(v string, now time.Time) (time.Duration, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.ParseInt(v, 10, 64); err == nil {
+		if secs < 0 || secs > int64(1<<62/time.Second) {
+			return 0, false
+		}
+		return time.Duration(secs) * time.Second, true
+	}
+	t, err := ParseTime(v)
+	if err != nil {
+		return 0, false
+	}
+	if d := t.Sub(now); d > 0 {
+		return d, true
+	}
+	return 0, true
+}
+
+// sendWithRetry is like send, but retries according to c.Retry.
+// didTimeout is non-nil only if err != nil.
+func (c *Client) sendWithRetry(req *Request, deadline time.Time) (resp *Response, didTimeout func() bool, err error) {
+	p := c.Retry
+	if p == nil || p.MaxAttempts < 2 {
+		return c.send(req, deadline)
+	}
+	rewindable := req.Body == nil || req.Body == NoBody || req.GetBody != nil
+	for attempt := 1; ; attempt++ {
+		// Each attempt gets its own copy of the request, so the
+		// cookies added by c.send don't accumulate across attempts.
+		areq := new(Request)
+		*areq = *req
+		areq.Header = req.Header.clone()
+		if attempt > 1 && req.Body != nil && req.Body != NoBody {
+			if areq.Body, err = req.GetBody(); err != nil {
+				return nil, alwaysFalse, err
+			}
+		}
+
+		resp, didTimeout, err = c.send(areq, deadline)
+		if attempt >= p.MaxAttempts || !rewindable || !p.shouldRetry(req, resp, err) {
+			return resp, didTimeout, err
+		}
+		delay, ok := p.backoff(attempt, resp)
+		if !ok || (!deadline.IsZero() && time.Now().Add(delay).After(deadline)) {
+			return resp, didTimeout, err
+		}
+
+		if trace := httptrace.ContextClientTrace(req.Context()); trace != nil && trace.Retry != nil {
+			info := httptrace.RetryInfo{Attempt: attempt, Delay: delay, Err: err}
+			if resp != nil {
+				info.StatusCode = resp.StatusCode
+			}
+			trace.Retry(info)
+		}
+
+		if resp != nil {
+			// Read a little of the body so the connection can be
+			// reused, as is done for redirects.
+			const maxBodySlurpSize = 2 << 10
+			if resp.ContentLength == -1 || resp.ContentLength <= maxBodySlurpSize {
+				io.CopyN(ioutil.Discard, resp.Body, maxBodySlurpSize)
+			}
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, alwaysFalse, req.Context().Err()
+		case <-req.Cancel:
+			timer.Stop()
+			return nil, alwaysFalse, errRequestCanceled
+		}
+	}
+}
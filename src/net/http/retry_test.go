@@ -0,0 +1,309 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Tests for retry.go
+
+package http_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	. "net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in     string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"", 0, false},
+		{"0", 0, true},
+		{" 120 ", 120 * time.Second, true},
+		{"-1", 0, false},
+		{"1.5", 0, false},
+		{"soon", 0, false},
+		{"Fri, 01 Jun 2018 12:00:30 GMT", 30 * time.Second, true},
+		{"Fri, 01 Jun 2018 11:00:00 GMT", 0, true},
+	}
+	for _, tt := range tests {
+		got, ok := ExportParseRetryAfter(tt.in, now)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseRetryAfter(%q) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+// retryTrace returns a context whose ClientTrace records every
+// Retry hook call.
+func retryTrace(infos *[]httptrace.RetryInfo) context.Context {
+	return httptrace.WithClientTrace(context.Background(), &httptrace.ClientTrace{
+		Retry: func(info httptrace.RetryInfo) {
+			*infos = append(*infos, info)
+		},
+	})
+}
+
+func TestClientRetryStatus(t *testing.T) {
+	setParallel(t)
+	defer afterTest(t)
+	var hits int32
+	ts := httptest.NewServer(HandlerFunc(func(w ResponseWriter, r *Request) {
+		if atomic.AddInt32(&hits, 1) < 3 {
+			w.WriteHeader(StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	c := &Client{Retry: &RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond}}
+	var infos []httptrace.RetryInfo
+	req, _ := NewRequest("GET", ts.URL, nil)
+	res, err := c.Do(req.WithContext(retryTrace(&infos)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	slurp, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != 200 || string(slurp) != "ok" {
+		t.Errorf("got %d %q; want 200 \"ok\"", res.StatusCode, slurp)
+	}
+	if n := atomic.LoadInt32(&hits); n != 3 {
+		t.Errorf("server saw %d requests; want 3", n)
+	}
+	if len(infos) != 2 {
+		t.Fatalf("got %d Retry trace events; want 2", len(infos))
+	}
+	for i, info := range infos {
+		if info.Attempt != i+1 || info.StatusCode != StatusServiceUnavailable || info.Err != nil {
+			t.Errorf("trace event %d = %+v; want attempt %d with status 503", i, info, i+1)
+		}
+		if max := time.Millisecond << uint(i); info.Delay < max/2 || info.Delay > max {
+			t.Errorf("trace event %d delay = %v; want in [%v, %v]", i, info.Delay, max/2, max)
+		}
+	}
+}
+
+func TestClientRetryMaxAttempts(t *testing.T) {
+	setParallel(t)
+	defer afterTest(t)
+	var hits int32
+	ts := httptest.NewServer(HandlerFunc(func(w ResponseWriter, r *Request) {
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(StatusTooManyRequests)
+	}))
+	defer ts.Close()
+
+	c := &Client{Retry: &RetryPolicy{MaxAttempts: 2, MinBackoff: time.Millisecond}}
+	res, err := c.Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != StatusTooManyRequests {
+		t.Errorf("status = %d; want 429", res.StatusCode)
+	}
+	if n := atomic.LoadInt32(&hits); n != 2 {
+		t.Errorf("server saw %d requests; want 2", n)
+	}
+}
+
+func TestClientRetryAfter(t *testing.T) {
+	setParallel(t)
+	defer afterTest(t)
+	var hits int32
+	ts := httptest.NewServer(HandlerFunc(func(w ResponseWriter, r *Request) {
+		switch atomic.AddInt32(&hits, 1) {
+		case 1:
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(StatusServiceUnavailable)
+		case 2:
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+
+	// A long MinBackoff shows that the Retry-After value, not the
+	// computed backoff, was used for the first retry. The second
+	// Retry-After exceeds MaxBackoff, so the response is returned.
+	c := &Client{Retry: &RetryPolicy{MaxAttempts: 5, MinBackoff: 30 * time.Minute, MaxBackoff: 30 * time.Minute}}
+	var infos []httptrace.RetryInfo
+	req, _ := NewRequest("GET", ts.URL, nil)
+	res, err := c.Do(req.WithContext(retryTrace(&infos)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if n := atomic.LoadInt32(&hits); n != 2 {
+		t.Errorf("server saw %d requests; want 2", n)
+	}
+	if res.Header.Get("Retry-After") != "3600" {
+		t.Errorf("returned response has Retry-After %q; want 3600", res.Header.Get("Retry-After"))
+	}
+	if len(infos) != 1 || infos[0].Delay != 0 {
+		t.Errorf("trace events = %+v; want one with zero delay", infos)
+	}
+}
+
+func TestClientRetryRewindsBody(t *testing.T) {
+	setParallel(t)
+	defer afterTest(t)
+	var hits int32
+	ts := httptest.NewServer(HandlerFunc(func(w ResponseWriter, r *Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if string(body) != "payload" {
+			t.Errorf("attempt %d: body = %q; want \"payload\"", atomic.LoadInt32(&hits)+1, body)
+		}
+		if c := r.Header.Get("Cookie"); c != "k=v" {
+			t.Errorf("Cookie header = %q; want \"k=v\"", c)
+		}
+		if atomic.AddInt32(&hits, 1) < 3 {
+			w.WriteHeader(StatusInternalServerError)
+		}
+	}))
+	defer ts.Close()
+
+	c := &Client{Retry: &RetryPolicy{
+		MaxAttempts: 3,
+		MinBackoff:  time.Millisecond,
+		ShouldRetry: func(req *Request, res *Response, err error) bool {
+			return err == nil && res.StatusCode == StatusInternalServerError
+		},
+	}}
+	req, _ := NewRequest("POST", ts.URL, bytes.NewReader([]byte("payload")))
+	req.AddCookie(&Cookie{Name: "k", Value: "v"})
+	res, err := c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != 200 {
+		t.Errorf("status = %d; want 200", res.StatusCode)
+	}
+	if n := atomic.LoadInt32(&hits); n != 3 {
+		t.Errorf("server saw %d requests; want 3", n)
+	}
+}
+
+func TestClientRetryUnrewindableBody(t *testing.T) {
+	setParallel(t)
+	defer afterTest(t)
+	var hits int32
+	ts := httptest.NewServer(HandlerFunc(func(w ResponseWriter, r *Request) {
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	c := &Client{Retry: &RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond}}
+	req, _ := NewRequest("PUT", ts.URL, ioutil.NopCloser(strings.NewReader("x")))
+	res, err := c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if n := atomic.LoadInt32(&hits); n != 1 {
+		t.Errorf("server saw %d requests; want 1", n)
+	}
+}
+
+type errorRoundTripper struct {
+	err   error
+	calls int32
+}
+
+func (rt *errorRoundTripper) RoundTrip(req *Request) (*Response, error) {
+	atomic.AddInt32(&rt.calls, 1)
+	return nil, rt.err
+}
+
+func TestClientRetryTransportError(t *testing.T) {
+	someErr := errors.New("connection reset")
+	tests := []struct {
+		method string
+		want   int32
+	}{
+		{"GET", 3},
+		{"HEAD", 3},
+		{"POST", 1},
+	}
+	for _, tt := range tests {
+		rt := &errorRoundTripper{err: someErr}
+		var infos []httptrace.RetryInfo
+		c := &Client{
+			Transport: rt,
+			Retry:     &RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond},
+		}
+		req, _ := NewRequest(tt.method, "http://example.com/", nil)
+		_, err := c.Do(req.WithContext(retryTrace(&infos)))
+		if err == nil || !strings.Contains(err.Error(), someErr.Error()) {
+			t.Errorf("%s: err = %v; want %v", tt.method, err, someErr)
+		}
+		if rt.calls != tt.want {
+			t.Errorf("%s: %d attempts; want %d", tt.method, rt.calls, tt.want)
+		}
+		if int32(len(infos)) != tt.want-1 {
+			t.Errorf("%s: %d Retry trace events; want %d", tt.method, len(infos), tt.want-1)
+		}
+		for _, info := range infos {
+			if info.Err != someErr || info.StatusCode != 0 {
+				t.Errorf("%s: trace event = %+v; want Err %v", tt.method, info, someErr)
+			}
+		}
+	}
+}
+
+func TestClientRetryCanceledDuringBackoff(t *testing.T) {
+	rt := &errorRoundTripper{err: errors.New("connection reset")}
+	c := &Client{
+		Transport: rt,
+		Retry:     &RetryPolicy{MaxAttempts: 3, MinBackoff: time.Hour, MaxBackoff: time.Hour},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		Retry: func(httptrace.RetryInfo) { cancel() },
+	})
+	req, _ := NewRequest("GET", "http://example.com/", nil)
+	_, err := c.Do(req.WithContext(ctx))
+	if err == nil || !strings.Contains(err.Error(), context.Canceled.Error()) {
+		t.Errorf("err = %v; want context canceled", err)
+	}
+	if rt.calls != 1 {
+		t.Errorf("%d attempts; want 1", rt.calls)
+	}
+}
+
+func TestClientRetryRespectsTimeout(t *testing.T) {
+	rt := &errorRoundTripper{err: errors.New("connection reset")}
+	c := &Client{
+		Transport: rt,
+		Timeout:   time.Minute,
+		Retry:     &RetryPolicy{MaxAttempts: 3, MinBackoff: time.Hour, MaxBackoff: time.Hour},
+	}
+	start := time.Now()
+	_, err := c.Get("http://example.com/")
+	if err == nil {
+		t.Fatal("unexpected success")
+	}
+	if rt.calls != 1 {
+		t.Errorf("%d attempts; want 1", rt.calls)
+	}
+	if d := time.Since(start); d > 10*time.Second {
+		t.Errorf("Get took %v; want it to give up without waiting", d)
+	}
+}
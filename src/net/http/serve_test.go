@@ -593,6 +593,181 @@ func TestServeWithSlashRedirectForHostPatterns(t *testing.T) {
 	}
 }
 
+func TestServeMuxPatterns(t *testing.T) {
+	setParallel(t)
+	mux := NewServeMux()
+	for _, pattern := range []string{
+		"/",
+		"/{$}",
+		"GET /users/{id}",
+		"DELETE /users/{id}",
+		"/users/{id}/posts/{post}",
+		"GET /users/admin",
+		"/files/{path...}",
+		"GET example.com/users/{name}",
+		"/escaped/{seg}",
+	} {
+		pattern := pattern
+		mux.HandleFunc(pattern, func(w ResponseWriter, r *Request) {
+			w.Header().Set("Pattern", pattern)
+			for _, name := range []string{"id", "post", "path", "name", "seg"} {
+				if v := r.PathValue(name); v != "" {
+					fmt.Fprintf(w, "%s=%s;", name, v)
+				}
+			}
+		})
+	}
+
+	tests := []struct {
+		method  string
+		url     string
+		code    int
+		pattern string
+		body    string
+	}{
+		{"GET", "http://localhost/", 200, "/{$}", ""},
+		{"GET", "http://localhost/other", 200, "/", ""},
+		{"GET", "http://localhost/users/42", 200, "GET /users/{id}", "id=42;"},
+		{"HEAD", "http://localhost/users/42", 200, "GET /users/{id}", "id=42;"},
+		{"DELETE", "http://localhost/users/42", 200, "DELETE /users/{id}", "id=42;"},
+		{"GET", "http://localhost/users/admin", 200, "GET /users/admin", ""},
+		{"GET", "http://localhost/users/42/posts/7", 200, "/users/{id}/posts/{post}", "id=42;post=7;"},
+		{"GET", "http://localhost/files/", 200, "/files/{path...}", ""},
+		{"GET", "http://localhost/files/a/b.txt", 200, "/files/{path...}", "path=a/b.txt;"},
+		{"GET", "http://localhost/files", 301, "", ""},
+		{"GET", "http://example.com/users/gopher", 200, "GET example.com/users/{name}", "name=gopher;"},
+		{"POST", "http://example.com/users/gopher", 200, "/", ""},
+		{"GET", "http://localhost/escaped/a%2Fb", 200, "/escaped/{seg}", "seg=a/b;"},
+	}
+	for _, tt := range tests {
+		req, err := NewRequest(tt.method, tt.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+		if rr.Code != tt.code {
+			t.Errorf("%s %s: code = %d; want %d", tt.method, tt.url, rr.Code, tt.code)
+			continue
+		}
+		if tt.code != 200 {
+			continue
+		}
+		if got := rr.HeaderMap.Get("Pattern"); got != tt.pattern {
+			t.Errorf("%s %s: pattern = %q; want %q", tt.method, tt.url, got, tt.pattern)
+		}
+		if got := rr.Body.String(); got != tt.body {
+			t.Errorf("%s %s: body = %q; want %q", tt.method, tt.url, got, tt.body)
+		}
+	}
+}
+
+func TestServeMuxMethodNotAllowed(t *testing.T) {
+	setParallel(t)
+	mux := NewServeMux()
+	mux.HandleFunc("GET /item/{id}", func(w ResponseWriter, r *Request) {})
+	mux.HandleFunc("PUT /item/{id}", func(w ResponseWriter, r *Request) {})
+
+	req := httptest.NewRequest("POST", "/item/1", nil)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+	if rr.Code != StatusMethodNotAllowed {
+		t.Fatalf("code = %d; want %d", rr.Code, StatusMethodNotAllowed)
+	}
+	if got, want := rr.HeaderMap.Get("Allow"), "GET, HEAD, PUT"; got != want {
+		t.Errorf("Allow = %q; want %q", got, want)
+	}
+
+	req = httptest.NewRequest("POST", "/elsewhere", nil)
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+	if rr.Code != StatusNotFound {
+		t.Errorf("unmatched path: code = %d; want %d", rr.Code, StatusNotFound)
+	}
+}
+
+func TestServeMuxHostOnlyPattern(t *testing.T) {
+	setParallel(t)
+	mux := NewServeMux()
+	mux.Handle("example.com:443", serve(200))
+	mux.Handle("/", serve(404))
+
+	tests := []struct {
+		req  string
+		code int
+	}{
+		{"CONNECT example.com:443 HTTP/1.1\r\nHost: example.com:443\r\n\r\n", 200},
+		{"CONNECT other.com:443 HTTP/1.1\r\nHost: other.com:443\r\n\r\n", 404},
+		{"GET / HTTP/1.1\r\nHost: example.com:443\r\n\r\n", 404},
+	}
+	for _, tt := range tests {
+		req, err := ReadRequest(bufio.NewReader(strings.NewReader(tt.req)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+		if rr.Code != tt.code {
+			t.Errorf("%q: code = %d; want %d", tt.req, rr.Code, tt.code)
+		}
+	}
+}
+
+func TestServeMuxConflicts(t *testing.T) {
+	setParallel(t)
+	tests := []struct {
+		registered []string
+		pattern    string
+		wantPanic  string
+	}{
+		{[]string{"/a"}, "/a", "multiple registrations"},
+		{[]string{"/{x}"}, "/{y}", "conflicts with"},
+		{[]string{"/posts/{id}"}, "/{resource}/latest", "conflicts with"},
+		{[]string{"GET /"}, "/a", "conflicts with"},
+		{[]string{"/"}, "/bad{x}", "invalid pattern"},
+		{nil, "", `invalid pattern "": empty pattern`},
+		{[]string{"example.com/"}, "example.com", ""},
+		{[]string{"example.com"}, "CONNECT example.com", ""},
+		{[]string{"/images/"}, "/images/thumbnails/", ""},
+		{[]string{"/a/{x}"}, "GET /a/{x}", ""},
+		{[]string{"GET /a"}, "POST /a", ""},
+		{[]string{"/{x}"}, "example.com/{y}", ""},
+	}
+	for _, tt := range tests {
+		func() {
+			defer func() {
+				r := recover()
+				switch {
+				case r == nil && tt.wantPanic != "":
+					t.Errorf("%v then %q: no panic; want panic containing %q", tt.registered, tt.pattern, tt.wantPanic)
+				case r != nil && (tt.wantPanic == "" || !strings.Contains(fmt.Sprint(r), tt.wantPanic)):
+					t.Errorf("%v then %q: panic %q; want %q", tt.registered, tt.pattern, r, tt.wantPanic)
+				}
+			}()
+			mux := NewServeMux()
+			for _, p := range tt.registered {
+				mux.Handle(p, serve(200))
+			}
+			mux.Handle(tt.pattern, serve(200))
+		}()
+	}
+}
+
+func TestRequestSetPathValue(t *testing.T) {
+	mux := NewServeMux()
+	mux.HandleFunc("/a/{x}", func(w ResponseWriter, r *Request) {
+		r2 := r.WithContext(r.Context())
+		r2.SetPathValue("x", "changed")
+		r2.SetPathValue("y", "added")
+		fmt.Fprintf(w, "%s %s %s %s", r.PathValue("x"), r.PathValue("y"), r2.PathValue("x"), r2.PathValue("y"))
+	})
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/a/orig", nil))
+	if got, want := rr.Body.String(), "orig  changed added"; got != want {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
 func TestShouldRedirectConcurrency(t *testing.T) {
 	setParallel(t)
 	defer afterTest(t)
@@ -4192,6 +4367,223 @@ func TestServerConnState(t *testing.T) {
 	mu.Unlock()
 }
 
+func TestServerMaxRequestsPerConn(t *testing.T) {
+	setParallel(t)
+	defer afterTest(t)
+	ts := httptest.NewUnstartedServer(HandlerFunc(func(w ResponseWriter, r *Request) {
+		io.WriteString(w, r.RemoteAddr)
+	}))
+	var mu sync.Mutex
+	var states []ConnState // of the first connection
+	var first net.Conn
+	ts.Config.MaxRequestsPerConn = 2
+	ts.Config.ConnState = func(c net.Conn, state ConnState) {
+		mu.Lock()
+		defer mu.Unlock()
+		if first == nil {
+			first = c
+		}
+		if c == first {
+			states = append(states, state)
+		}
+	}
+	ts.Start()
+	defer ts.Close()
+
+	c := ts.Client()
+	var addrs []string
+	for i := 0; i < 3; i++ {
+		res, err := c.Get(ts.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := i == 1; res.Close != want {
+			t.Errorf("request %d: res.Close = %v; want %v", i, res.Close, want)
+		}
+		addrs = append(addrs, string(body))
+	}
+	if addrs[0] != addrs[1] {
+		t.Errorf("first two requests used different connections %q and %q", addrs[0], addrs[1])
+	}
+	if addrs[1] == addrs[2] {
+		t.Errorf("third request reused connection %q; want a new one", addrs[2])
+	}
+
+	want := []ConnState{StateNew, StateActive, StateIdle, StateActive, StateClosed}
+	for i := 0; i < 5; i++ {
+		time.Sleep(time.Duration(i) * 50 * time.Millisecond)
+		mu.Lock()
+		match := reflect.DeepEqual(states, want)
+		mu.Unlock()
+		if match {
+			return
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	t.Errorf("first connection states = %v; want %v", states, want)
+}
+
+func TestServerMaxConns(t *testing.T) {
+	setParallel(t)
+	defer afterTest(t)
+	ts := httptest.NewUnstartedServer(HandlerFunc(func(w ResponseWriter, r *Request) {
+		io.WriteString(w, "ok")
+	}))
+	newConn := make(chan net.Conn, 2)
+	ts.Config.MaxConns = 1
+	ts.Config.ConnState = func(c net.Conn, state ConnState) {
+		if state == StateNew {
+			newConn <- c
+		}
+	}
+	ts.Start()
+	defer ts.Close()
+
+	// Occupy the only connection slot.
+	c1, err := net.Dial("tcp", ts.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c1.Close()
+	<-newConn
+
+	// A second connection waits in the listener's backlog.
+	c2, err := net.Dial("tcp", ts.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c2.Close()
+	if _, err := io.WriteString(c2, "GET / HTTP/1.1\r\nHost: foo\r\n\r\n"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-newConn:
+		t.Fatal("second connection accepted while the first was still open")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Closing the first connection frees its slot.
+	c1.Close()
+	select {
+	case <-newConn:
+	case <-time.After(5 * time.Second):
+		t.Fatal("second connection not accepted after the first closed")
+	}
+	res, err := ReadResponse(bufio.NewReader(c2), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if string(body) != "ok" {
+		t.Errorf("body = %q; want %q", body, "ok")
+	}
+}
+
+func TestServerMaxConnsShutdown(t *testing.T) {
+	setParallel(t)
+	defer afterTest(t)
+	ln := newLocalListener(t)
+	srv := &Server{
+		Handler:  HandlerFunc(func(w ResponseWriter, r *Request) {}),
+		MaxConns: 1,
+	}
+	newConn := make(chan bool, 1)
+	srv.ConnState = func(c net.Conn, state ConnState) {
+		if state == StateNew {
+			newConn <- true
+		}
+	}
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- srv.Serve(ln) }()
+
+	c, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	<-newConn
+
+	// Serve is now waiting for a free slot; Close must stop it.
+	srv.Close()
+	select {
+	case err := <-serveErr:
+		if err != ErrServerClosed {
+			t.Errorf("Serve = %v; want ErrServerClosed", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Serve did not return after Close")
+	}
+}
+
+func TestServerMaxRequestBodyBytes_h1(t *testing.T) { testServerMaxRequestBodyBytes(t, h1Mode) }
+func TestServerMaxRequestBodyBytes_h2(t *testing.T) { testServerMaxRequestBodyBytes(t, h2Mode) }
+func testServerMaxRequestBodyBytes(t *testing.T, h2 bool) {
+	setParallel(t)
+	defer afterTest(t)
+	const limit = 10
+	cst := newClientServerTest(t, h2, HandlerFunc(func(w ResponseWriter, r *Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		fmt.Fprintf(w, "%d %v", len(body), err != nil)
+	}), func(ts *httptest.Server) {
+		ts.Config.MaxRequestBodyBytes = limit
+	})
+	defer cst.close()
+
+	for _, tt := range []struct {
+		body string
+		want string
+	}{
+		{"short", "5 false"},
+		{strings.Repeat("a", limit), "10 false"},
+		{strings.Repeat("a", limit+1), "10 true"},
+	} {
+		res, err := cst.c.Post(cst.ts.URL, "text/plain", strings.NewReader(tt.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if string(got) != tt.want {
+			t.Errorf("body of %d bytes: handler saw %q; want %q", len(tt.body), got, tt.want)
+		}
+	}
+}
+
+func TestServerHandlerTimeout_h1(t *testing.T) { testServerHandlerTimeout(t, h1Mode) }
+func TestServerHandlerTimeout_h2(t *testing.T) { testServerHandlerTimeout(t, h2Mode) }
+func testServerHandlerTimeout(t *testing.T, h2 bool) {
+	setParallel(t)
+	defer afterTest(t)
+	cst := newClientServerTest(t, h2, HandlerFunc(func(w ResponseWriter, r *Request) {
+		select {
+		case <-r.Context().Done():
+			io.WriteString(w, r.Context().Err().Error())
+		case <-time.After(5 * time.Second):
+			io.WriteString(w, "not canceled")
+		}
+	}), func(ts *httptest.Server) {
+		ts.Config.HandlerTimeout = 10 * time.Millisecond
+	})
+	defer cst.close()
+
+	res, err := cst.c.Get(cst.ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if want := context.DeadlineExceeded.Error(); string(got) != want {
+		t.Errorf("handler saw %q; want %q", got, want)
+	}
+}
+
 func TestServerKeepAlivesEnabled(t *testing.T) {
 	defer afterTest(t)
 	ts := httptest.NewUnstartedServer(HandlerFunc(func(w ResponseWriter, r *Request) {}))
@@ -22,6 +22,7 @@ import (
 	"os"
 	"path"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -281,6 +282,15 @@ type conn struct {
 
 	curState atomic.Value // of ConnState
 
+	// connSem, if non-nil, is the Server's connection semaphore,
+	// which this connection holds a slot of until it is closed or
+	// hijacked. It is only accessed by the serving goroutine.
+	connSem chan struct{}
+
+	// numRequests is the number of requests read on this
+	// connection. It is only accessed by the serving goroutine.
+	numRequests int
+
 	// mu guards hijackedv
 	mu sync.Mutex
 
@@ -1668,6 +1678,10 @@ func (c *conn) setState(nc net.Conn, state ConnState) {
 		srv.trackConn(c, true)
 	case StateHijacked, StateClosed:
 		srv.trackConn(c, false)
+		if c.connSem != nil {
+			<-c.connSem
+			c.connSem = nil
+		}
 	}
 	c.curState.Store(connStateInterface[state])
 	if hook := srv.ConnState; hook != nil {
@@ -1798,6 +1812,11 @@ func (c *conn) serve(ctx context.Context) {
 			return
 		}
 
+		c.numRequests++
+		if max := c.server.MaxRequestsPerConn; max > 0 && c.numRequests >= max {
+			w.closeAfterReply = true
+		}
+
 		// Expect 100 Continue support
 		req := w.req
 		if req.expectsContinue() {
@@ -2090,45 +2109,143 @@ func RedirectHandler(url string, code int) Handler {
 // patterns and calls the handler for the pattern that
 // most closely matches the URL.
 //
-// Patterns name fixed, rooted paths, like "/favicon.ico",
-// or rooted subtrees, like "/images/" (note the trailing slash).
-// Longer patterns take precedence over shorter ones, so that
-// if there are handlers registered for both "/images/"
-// and "/images/thumbnails/", the latter handler will be
-// called for paths beginning "/images/thumbnails/" and the
-// former will receive requests for any other paths in the
-// "/images/" subtree.
-//
-// Note that since a pattern ending in a slash names a rooted subtree,
-// the pattern "/" matches all paths not matched by other registered
-// patterns, not just the URL with Path == "/".
-//
-// If a subtree has been registered and a request is received naming the
-// subtree root without its trailing slash, ServeMux redirects that
-// request to the subtree root (adding the trailing slash). This behavior can
-// be overridden with a separate registration for the path without
-// the trailing slash. For example, registering "/images/" causes ServeMux
-// to redirect a request for "/images" to "/images/", unless "/images" has
-// been registered separately.
-//
-// Patterns may optionally begin with a host name, restricting matches to
-// URLs on that host only. Host-specific patterns take precedence over
-// general patterns, so that a handler might register for the two patterns
-// "/codesearch" and "codesearch.google.com/" without also taking over
-// requests for "http://www.google.com/".
+// Patterns
+//
+// Patterns can match the method, host and path of a request.
+// Some examples:
+//
+//	"/index.html" matches the path "/index.html" for any host and method.
+//	"GET /static/" matches a GET request whose path begins with "/static/".
+//	"example.com/" matches any request to the host "example.com".
+//	"example.com/{$}" matches requests with host "example.com" and path "/".
+//	"/b/{bucket}/o/{objectname...}" matches paths whose first segment is "b"
+//	and whose third segment is "o". The name "bucket" denotes the second
+//	segment and "objectname" denotes the remainder of the path.
+//
+// In general, a pattern looks like
+//
+//	[METHOD ][HOST]/[PATH]
+//
+// All three parts are optional; "/" is a valid pattern.
+// If METHOD is present, it must be followed by at least one space or tab.
+// A pattern may also consist of just a host (and optionally a method),
+// such as "example.com:443". It matches only requests with an empty
+// path, which in practice means CONNECT requests for that host.
+// A pattern with no method matches every method. A pattern with the
+// method GET matches both GET and HEAD requests. Otherwise, the method
+// must match exactly.
+//
+// A pattern with no host matches every host. A pattern with a host
+// matches URLs on that host only. Host-specific patterns take precedence
+// over general patterns, so that a handler might register for the two
+// patterns "/codesearch" and "codesearch.google.com/" without also
+// taking over requests for "http://www.google.com/".
+//
+// A path can include wildcard segments of the form {NAME} or {NAME...}.
+// For example, "/b/{bucket}/o/{objectname...}". The wildcard name must
+// be a valid Go identifier. Wildcards must be full path segments: they
+// must be preceded by a slash and followed by either a slash or the end
+// of the string. For example, "/b_{bucket}" is not a valid pattern.
+//
+// Normally a wildcard matches only a single, non-empty path segment,
+// ending at the next literal slash (not %2F) in the request URL. But if
+// the ... is present, then the wildcard matches the remainder of the
+// URL path, including slashes. (Therefore it is invalid for a ...
+// wildcard to appear anywhere but at the end of a pattern.) The match
+// for a wildcard can be obtained by calling Request.PathValue with the
+// wildcard's name. A trailing slash in a path acts as an anonymous ...
+// wildcard.
+//
+// The special wildcard {$} matches only the end of the URL.
+// For example, the pattern "/{$}" matches only the path "/", whereas
+// the pattern "/" matches every path.
+//
+// For matching, both pattern paths and incoming request paths are
+// unescaped segment by segment. So, for example, the path
+// "/a%2Fb/100%25" is treated as having two segments, "a/b" and "100%".
+// The pattern "/a%2fb/" matches it, but the pattern "/a/b/" does not.
+//
+// Precedence
+//
+// If two or more patterns with the same host match a request, then the
+// most specific pattern takes precedence. A pattern P1 is more specific
+// than P2 if P1 matches a strict subset of P2's requests; that is, if P2
+// matches all the requests of P1 and more. If neither is more specific,
+// then the patterns conflict. For example, "/images/thumbnails/" is
+// more specific than "/images/", so both can be registered; the former
+// matches paths beginning with "/images/thumbnails/" and the latter
+// will match any other path in the "/images/" subtree. But
+// "/posts/{id}" and "/{resource}/latest" conflict, because each matches
+// a request the other does not, and they both match "/posts/latest".
+// Registering a pattern that conflicts with one already registered
+// causes a panic.
+//
+// Trailing-slash redirection
+//
+// Consider a ServeMux with a handler for a subtree, registered using a
+// trailing slash or a ... wildcard. If the ServeMux receives a request
+// for the subtree root without a trailing slash, it redirects the
+// request by adding the trailing slash. This behavior can be overridden
+// with a separate registration for the path without the trailing slash
+// or ... wildcard. For example, registering "/images/" causes ServeMux
+// to redirect a request for "/images" to "/images/", unless "/images"
+// has been registered separately.
+//
+// Request sanitizing
 //
 // ServeMux also takes care of sanitizing the URL request path,
 // redirecting any request containing . or .. elements or repeated slashes
 // to an equivalent, cleaner URL.
+//
+// If the path of a request matches a pattern but its method does not
+// match any pattern for that path, ServeMux replies with 405 Method Not
+// Allowed and an Allow header listing the methods that do match.
 type ServeMux struct {
 	mu    sync.RWMutex
-	m     map[string]muxEntry
-	hosts bool // whether any patterns contain hostnames
+	m     map[string]*muxEntry
+	es    []*muxEntry          // in registration order
+	index map[string]*muxIndex // by pattern host
+	hosts bool                 // whether any patterns contain hostnames
 }
 
 type muxEntry struct {
 	h       Handler
 	pattern string
+	pat     *pattern
+}
+
+// A muxIndex holds the entries for one host, grouped so that a request
+// is matched only against the patterns that could match its path.
+type muxIndex struct {
+	// byLiteral holds the entries whose first path segment is a
+	// literal, keyed by that literal.
+	byLiteral map[string][]*muxEntry
+	// rest holds the entries whose first segment is a wildcard,
+	// and host-only entries, which have no segments.
+	rest []*muxEntry
+}
+
+func (ix *muxIndex) add(e *muxEntry) {
+	if len(e.pat.segments) == 0 || e.pat.segments[0].wild {
+		ix.rest = append(ix.rest, e)
+		return
+	}
+	if ix.byLiteral == nil {
+		ix.byLiteral = make(map[string][]*muxEntry)
+	}
+	lit := e.pat.segments[0].s
+	ix.byLiteral[lit] = append(ix.byLiteral[lit], e)
+}
+
+// candidates returns the entries that may match the path split into segs.
+func (ix *muxIndex) candidates(segs []string) (lit, rest []*muxEntry) {
+	if ix == nil {
+		return nil, nil
+	}
+	if len(segs) > 0 {
+		lit = ix.byLiteral[segs[0]]
+	}
+	return lit, ix.rest
 }
 
 // NewServeMux allocates and returns a new ServeMux.
@@ -2139,19 +2256,6 @@ var DefaultServeMux = &defaultServeMux
 
 var defaultServeMux ServeMux
 
-// Does path match pattern?
-func pathMatch(pattern, path string) bool {
-	if len(pattern) == 0 {
-		// should not happen
-		return false
-	}
-	n := len(pattern)
-	if pattern[n-1] != '/' {
-		return pattern == path
-	}
-	return len(path) >= n && path[0:n] == pattern
-}
-
 // Return the canonical path for p, eliminating . and .. elements.
 func cleanPath(p string) string {
 	if p == "" {
@@ -2182,25 +2286,37 @@ func stripHostPort(h string) string {
 	return host
 }
 
-// Find a handler on a handler map given a path string.
-// Most-specific (longest) pattern wins.
-func (mux *ServeMux) match(path string) (h Handler, pattern string) {
-	// Check for exact match first.
-	v, ok := mux.m[path]
-	if ok {
-		return v.h, v.pattern
+// pathSegments splits path, a possibly cleaned form of u.Path,
+// into the segments that patterns are matched against.
+// It returns nil if path is not rooted.
+func pathSegments(u *url.URL, path string) []string {
+	if path == "" || path[0] != '/' {
+		return nil
+	}
+	if u.RawPath != "" && path == u.Path {
+		return splitEscapedPath(u.EscapedPath())
 	}
+	return splitPath(path)
+}
 
-	// Check for longest valid match.
-	var n = 0
-	for k, v := range mux.m {
-		if !pathMatch(k, path) {
-			continue
-		}
-		if h == nil || len(k) > n {
-			n = len(k)
-			h = v.h
-			pattern = v.pattern
+// Find the most specific entry for host that matches method and the
+// path split into segs, along with the values of its wildcards.
+func (mux *ServeMux) match(host, method string, segs []string) (e *muxEntry, matches []string) {
+	lit, rest := mux.index[host].candidates(segs)
+	for _, es := range [...][]*muxEntry{lit, rest} {
+		for _, v := range es {
+			if !v.pat.matchMethod(method) {
+				continue
+			}
+			m, ok := v.pat.matchPath(segs)
+			if !ok {
+				continue
+			}
+			// Matching patterns never conflict, so one of e
+			// and v is more specific than the other.
+			if e == nil || v.pat.compare(e.pat) == relMoreSpecific {
+				e, matches = v, m
+			}
 		}
 	}
 	return
@@ -2210,8 +2326,8 @@ func (mux *ServeMux) match(path string) (h Handler, pattern string) {
 // This occurs when a handler for path + "/" was already registered, but
 // not for path itself. If the path needs appending to, it creates a new
 // URL, setting the path to u.Path + "/" and returning true to indicate so.
-func (mux *ServeMux) redirectToPathSlash(host, path string, u *url.URL) (*url.URL, bool) {
-	if !mux.shouldRedirect(host, path) {
+func (mux *ServeMux) redirectToPathSlash(host, method, path string, u *url.URL) (*url.URL, bool) {
+	if !mux.shouldRedirect(host, method, path) {
 		return u, false
 	}
 	path = path + "/"
@@ -2220,31 +2336,23 @@ func (mux *ServeMux) redirectToPathSlash(host, path string, u *url.URL) (*url.UR
 }
 
 // shouldRedirect reports whether the given path and host should be redirected to
-// path+"/". This should happen if a handler is registered for path+"/" but
-// not path -- see comments at ServeMux.
-func (mux *ServeMux) shouldRedirect(host, path string) bool {
+// path+"/". This should happen if a pattern matches path+"/" exactly but
+// none matches path -- see comments at ServeMux.
+func (mux *ServeMux) shouldRedirect(host, method, path string) bool {
+	n := len(path)
+	if n == 0 || path[0] != '/' || path[n-1] == '/' {
+		return false
+	}
+	segs := splitPath(path)
+
 	mux.mu.RLock()
 	defer mux.mu.RUnlock()
 
-	p := []string{path, host + path}
-
-	for _, c := range p {
-		if _, exist := mux.m[c]; exist {
-			return false
-		}
-	}
-
-	n := len(path)
-	if n == 0 {
+	if e, _ := mux.handlerLocked(host, method, segs); e != nil && e.pat.exactMatch(path) {
 		return false
 	}
-	for _, c := range p {
-		if _, exist := mux.m[c+"/"]; exist {
-			return path[n-1] != '/'
-		}
-	}
-
-	return false
+	e, _ := mux.handlerLocked(host, method, append(segs, ""))
+	return e != nil && e.pat.exactMatch(path+"/")
 }
 
 // Handler returns the handler to use for the given request,
@@ -2263,59 +2371,119 @@ func (mux *ServeMux) shouldRedirect(host, path string) bool {
 // If there is no registered handler that applies to the request,
 // Handler returns a ``page not found'' handler and an empty pattern.
 func (mux *ServeMux) Handler(r *Request) (h Handler, pattern string) {
+	h, pattern, _, _ = mux.findHandler(r)
+	return
+}
+
+// findHandler is the implementation of Handler. It also returns the
+// matched entry, if any, and the values of its wildcards.
+func (mux *ServeMux) findHandler(r *Request) (h Handler, pattern string, e *muxEntry, matches []string) {
+	method := valueOrDefault(r.Method, "GET")
+	var host, path string
 
 	// CONNECT requests are not canonicalized.
-	if r.Method == "CONNECT" {
+	if method == "CONNECT" {
 		// If r.URL.Path is /tree and its handler is not registered,
 		// the /tree -> /tree/ redirect applies to CONNECT requests
 		// but the path canonicalization does not.
-		if u, ok := mux.redirectToPathSlash(r.URL.Host, r.URL.Path, r.URL); ok {
-			return RedirectHandler(u.String(), StatusMovedPermanently), u.Path
+		if u, ok := mux.redirectToPathSlash(r.URL.Host, method, r.URL.Path, r.URL); ok {
+			return RedirectHandler(u.String(), StatusMovedPermanently), u.Path, nil, nil
 		}
 
-		return mux.handler(r.Host, r.URL.Path)
-	}
+		host, path = r.Host, r.URL.Path
+	} else {
+		// All other requests have any port stripped and path cleaned
+		// before passing to mux.handler.
+		host = stripHostPort(r.Host)
+		path = cleanPath(r.URL.Path)
 
-	// All other requests have any port stripped and path cleaned
-	// before passing to mux.handler.
-	host := stripHostPort(r.Host)
-	path := cleanPath(r.URL.Path)
+		// If the given path is /tree and its handler is not registered,
+		// redirect for /tree/.
+		if u, ok := mux.redirectToPathSlash(host, method, path, r.URL); ok {
+			return RedirectHandler(u.String(), StatusMovedPermanently), u.Path, nil, nil
+		}
 
-	// If the given path is /tree and its handler is not registered,
-	// redirect for /tree/.
-	if u, ok := mux.redirectToPathSlash(host, path, r.URL); ok {
-		return RedirectHandler(u.String(), StatusMovedPermanently), u.Path
+		if path != r.URL.Path {
+			if e, _ := mux.handler(host, method, splitPath(path)); e != nil {
+				pattern = e.pattern
+			}
+			url := *r.URL
+			url.Path = path
+			return RedirectHandler(url.String(), StatusMovedPermanently), pattern, nil, nil
+		}
 	}
 
-	if path != r.URL.Path {
-		_, pattern = mux.handler(host, path)
-		url := *r.URL
-		url.Path = path
-		return RedirectHandler(url.String(), StatusMovedPermanently), pattern
+	segs := pathSegments(r.URL, path)
+	if e, matches = mux.handler(host, method, segs); e != nil {
+		return e.h, e.pattern, e, matches
 	}
-
-	return mux.handler(host, r.URL.Path)
+	if allow := mux.allowedMethods(host, segs); len(allow) > 0 {
+		return methodNotAllowedHandler(allow), "", nil, nil
+	}
+	return NotFoundHandler(), "", nil, nil
 }
 
 // handler is the main implementation of Handler.
 // The path is known to be in canonical form, except for CONNECT methods.
-func (mux *ServeMux) handler(host, path string) (h Handler, pattern string) {
+func (mux *ServeMux) handler(host, method string, segs []string) (e *muxEntry, matches []string) {
 	mux.mu.RLock()
 	defer mux.mu.RUnlock()
+	return mux.handlerLocked(host, method, segs)
+}
 
+func (mux *ServeMux) handlerLocked(host, method string, segs []string) (e *muxEntry, matches []string) {
 	// Host-specific pattern takes precedence over generic ones
 	if mux.hosts {
-		h, pattern = mux.match(host + path)
+		e, matches = mux.match(host, method, segs)
 	}
-	if h == nil {
-		h, pattern = mux.match(path)
-	}
-	if h == nil {
-		h, pattern = NotFoundHandler(), ""
+	if e == nil {
+		e, matches = mux.match("", method, segs)
 	}
 	return
 }
 
+// allowedMethods returns the sorted methods of the patterns that
+// match the path split into segs, for the given host.
+func (mux *ServeMux) allowedMethods(host string, segs []string) []string {
+	mux.mu.RLock()
+	defer mux.mu.RUnlock()
+
+	set := make(map[string]bool)
+	add := func(es []*muxEntry) {
+		for _, e := range es {
+			if _, ok := e.pat.matchPath(segs); ok {
+				set[e.pat.method] = true
+				if e.pat.method == "GET" {
+					set["HEAD"] = true
+				}
+			}
+		}
+	}
+	lit, rest := mux.index[""].candidates(segs)
+	add(lit)
+	add(rest)
+	if host != "" {
+		lit, rest = mux.index[host].candidates(segs)
+		add(lit)
+		add(rest)
+	}
+	methods := make([]string, 0, len(set))
+	for m := range set {
+		methods = append(methods, m)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// methodNotAllowedHandler returns a handler that replies to each
+// request with a 405 Method Not Allowed error listing allow.
+func methodNotAllowedHandler(allow []string) Handler {
+	return HandlerFunc(func(w ResponseWriter, r *Request) {
+		w.Header().Set("Allow", strings.Join(allow, ", "))
+		Error(w, StatusText(StatusMethodNotAllowed), StatusMethodNotAllowed)
+	})
+}
+
 // ServeHTTP dispatches the request to the handler whose
 // pattern most closely matches the request URL.
 func (mux *ServeMux) ServeHTTP(w ResponseWriter, r *Request) {
@@ -2326,32 +2494,52 @@ func (mux *ServeMux) ServeHTTP(w ResponseWriter, r *Request) {
 		w.WriteHeader(StatusBadRequest)
 		return
 	}
-	h, _ := mux.Handler(r)
+	h, _, e, matches := mux.findHandler(r)
+	if e != nil {
+		r.setPathValues(e.pat, matches)
+	}
 	h.ServeHTTP(w, r)
 }
 
 // Handle registers the handler for the given pattern.
-// If a handler already exists for pattern, Handle panics.
+// If a handler already exists for pattern, or pattern conflicts
+// with a registered pattern, Handle panics.
 func (mux *ServeMux) Handle(pattern string, handler Handler) {
 	mux.mu.Lock()
 	defer mux.mu.Unlock()
 
-	if pattern == "" {
-		panic("http: invalid pattern")
-	}
 	if handler == nil {
 		panic("http: nil handler")
 	}
 	if _, exist := mux.m[pattern]; exist {
 		panic("http: multiple registrations for " + pattern)
 	}
+	pat, err := parsePattern(pattern)
+	if err != nil {
+		panic(fmt.Sprintf("http: invalid pattern %q: %v", pattern, err))
+	}
+	for _, e := range mux.es {
+		switch pat.compare(e.pat) {
+		case relEquivalent, relOverlaps:
+			panic(fmt.Sprintf("http: pattern %q conflicts with registered pattern %q", pattern, e.pattern))
+		}
+	}
 
 	if mux.m == nil {
-		mux.m = make(map[string]muxEntry)
+		mux.m = make(map[string]*muxEntry)
+		mux.index = make(map[string]*muxIndex)
+	}
+	e := &muxEntry{h: handler, pattern: pattern, pat: pat}
+	mux.m[pattern] = e
+	mux.es = append(mux.es, e)
+	ix := mux.index[pat.host]
+	if ix == nil {
+		ix = new(muxIndex)
+		mux.index[pat.host] = ix
 	}
-	mux.m[pattern] = muxEntry{h: handler, pattern: pattern}
+	ix.add(e)
 
-	if pattern[0] != '/' {
+	if pat.host != "" {
 		mux.hosts = true
 	}
 }
@@ -2449,6 +2637,33 @@ type Server struct {
 	// If zero, DefaultMaxHeaderBytes is used.
 	MaxHeaderBytes int
 
+	// MaxConns limits the number of connections served at once
+	// by Serve, across all of its listeners. Once the limit is
+	// reached, Serve stops calling Accept until a connection
+	// reaches StateClosed or StateHijacked, leaving new
+	// connections waiting in the listener's backlog.
+	// If zero, the number of connections is not limited.
+	MaxConns int
+
+	// MaxRequestsPerConn limits the number of requests served on
+	// a single HTTP/1.x keep-alive connection. The response to the
+	// last allowed request carries "Connection: close", and the
+	// connection then moves to StateClosed instead of StateIdle.
+	// If zero, the number of requests is not limited.
+	MaxRequestsPerConn int
+
+	// MaxRequestBodyBytes, if positive, limits the size of every
+	// request body as if by MaxBytesReader: reading past the limit
+	// returns an error, and an HTTP/1.x connection is closed after
+	// the response is sent.
+	MaxRequestBodyBytes int64
+
+	// HandlerTimeout, if positive, is the maximum duration a
+	// Handler should take to serve a request. The Request's
+	// context is canceled once it elapses; Handlers that do long
+	// work should stop when their request's context is done.
+	HandlerTimeout time.Duration
+
 	// TLSNextProto optionally specifies a function to take over
 	// ownership of the provided TLS connection when an NPN/ALPN
 	// protocol upgrade has occurred. The map key is the protocol
@@ -2481,6 +2696,21 @@ type Server struct {
 	activeConn map[*conn]struct{}
 	doneChan   chan struct{}
 	onShutdown []func()
+	connSem    chan struct{} // limits connections to MaxConns; see connSemaphore
+}
+
+// connSemaphore returns the semaphore limiting the Server to
+// MaxConns connections, or nil if the number is not limited.
+func (srv *Server) connSemaphore() chan struct{} {
+	if srv.MaxConns <= 0 {
+		return nil
+	}
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if srv.connSem == nil {
+		srv.connSem = make(chan struct{}, srv.MaxConns)
+	}
+	return srv.connSem
 }
 
 func (s *Server) getDoneChan() <-chan struct{} {
@@ -2687,6 +2917,18 @@ func (sh serverHandler) ServeHTTP(rw ResponseWriter, req *Request) {
 	if req.RequestURI == "*" && req.Method == "OPTIONS" {
 		handler = globalOptionsHandler{}
 	}
+	// The server owns req, so modify it in place rather than
+	// copying it; the server's cleanup after the handler returns,
+	// such as removing multipart form files, must see the request
+	// the handler saw.
+	if n := sh.srv.MaxRequestBodyBytes; n > 0 && req.Body != nil && req.Body != NoBody {
+		req.Body = MaxBytesReader(rw, req.Body, n)
+	}
+	if d := sh.srv.HandlerTimeout; d > 0 {
+		ctx, cancel := context.WithTimeout(req.Context(), d)
+		defer cancel()
+		req.ctx = ctx
+	}
 	handler.ServeHTTP(rw, req)
 }
 
@@ -2762,9 +3004,21 @@ func (srv *Server) Serve(l net.Listener) error {
 
 	baseCtx := context.Background() // base is always background, per Issue 16220
 	ctx := context.WithValue(baseCtx, ServerContextKey, srv)
+	sem := srv.connSemaphore()
 	for {
+		if sem != nil {
+			// Wait for a free connection slot before accepting.
+			select {
+			case sem <- struct{}{}:
+			case <-srv.getDoneChan():
+				return ErrServerClosed
+			}
+		}
 		rw, e := l.Accept()
 		if e != nil {
+			if sem != nil {
+				<-sem
+			}
 			select {
 			case <-srv.getDoneChan():
 				return ErrServerClosed
@@ -2787,6 +3041,7 @@ func (srv *Server) Serve(l net.Listener) error {
 		}
 		tempDelay = 0
 		c := srv.newConn(rw)
+		c.connSem = sem
 		c.setState(c.rwc, StateNew) // before Serve can return
 		go c.serve(ctx)
 	}
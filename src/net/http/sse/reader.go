@@ -0,0 +1,263 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sse
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Reader parses server-sent events from an event stream.
+type Reader struct {
+	r      *bufio.Reader
+	skipLF bool // the previous line ended in '\r'
+	bom    bool // the byte order mark has been checked for
+	line   bytes.Buffer
+	lastID string
+	retry  time.Duration
+}
+
+// NewReader returns a Reader that parses the event stream r,
+// such as the body of a response with Content-Type text/event-stream.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// LastEventID returns the last event ID set by the stream.
+func (r *Reader) LastEventID() string { return r.lastID }
+
+// Retry returns the last reconnection delay sent by the stream,
+// or zero if none was sent.
+func (r *Reader) Retry() time.Duration { return r.retry }
+
+// readLine returns the next line of the stream, without its end of
+// line. Lines end with "\r\n", "\n" or "\r".
+func (r *Reader) readLine() (string, error) {
+	r.line.Reset()
+	for {
+		c, err := r.r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		if r.skipLF {
+			r.skipLF = false
+			if c == '\n' {
+				continue
+			}
+		}
+		switch c {
+		case '\r':
+			r.skipLF = true
+			return r.line.String(), nil
+		case '\n':
+			return r.line.String(), nil
+		}
+		r.line.WriteByte(c)
+	}
+}
+
+// Next returns the next event in the stream. At the end of the
+// stream it returns io.EOF; any incomplete event at the end is
+// discarded.
+func (r *Reader) Next() (*Event, error) {
+	if !r.bom {
+		r.bom = true
+		if b, err := r.r.Peek(3); err == nil && string(b) == "\xef\xbb\xbf" {
+			r.r.Discard(3)
+		}
+	}
+	var (
+		data  bytes.Buffer
+		typ   string
+		retry time.Duration
+	)
+	for {
+		line, err := r.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if line == "" {
+			// Dispatch the event, if there is one.
+			if data.Len() == 0 {
+				typ, retry = "", 0
+				continue
+			}
+			if typ == "" {
+				typ = "message"
+			}
+			return &Event{
+				ID:    r.lastID,
+				Event: typ,
+				Data:  strings.TrimSuffix(data.String(), "\n"),
+				Retry: retry,
+			}, nil
+		}
+		if line[0] == ':' {
+			continue // comment
+		}
+		field, value := line, ""
+		if i := strings.IndexByte(line, ':'); i >= 0 {
+			field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+		switch field {
+		case "event":
+			typ = value
+		case "data":
+			data.WriteString(value)
+			data.WriteByte('\n')
+		case "id":
+			if strings.IndexByte(value, 0) < 0 {
+				r.lastID = value
+			}
+		case "retry":
+			if ms, err := strconv.ParseUint(value, 10, 63); err == nil {
+				retry = time.Duration(ms) * time.Millisecond
+				r.retry = retry
+			}
+		}
+	}
+}
+
+// DefaultRetry is the delay before a Stream reconnects when the
+// server has not sent a retry field.
+const DefaultRetry = 3 * time.Second
+
+// A Stream reads events from an event stream resource, reconnecting
+// whenever the connection is lost. When it reconnects, it sends the
+// last event ID it has seen in the Last-Event-ID header, so that the
+// server can resume the stream.
+type Stream struct {
+	client *http.Client
+	req    *http.Request
+	res    *http.Response
+	r      *Reader
+}
+
+// Connect sends req using client, or http.DefaultClient if client is
+// nil, and returns a Stream of the events in the response. The
+// response must have status 200 and Content-Type text/event-stream.
+//
+// The Stream reconnects using req until req's context is done. The
+// caller should call Close when done with the Stream.
+func Connect(client *http.Client, req *http.Request) (*Stream, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	s := &Stream{client: client, req: req}
+	if err := s.connect("", 0); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// errNoContent is returned by connect when the server replied with
+// 204 No Content, which tells clients to stop reconnecting.
+var errNoContent = errors.New("sse: server asked not to reconnect")
+
+func (s *Stream) connect(lastID string, retry time.Duration) error {
+	req := new(http.Request)
+	*req = *s.req
+	req.Header = make(http.Header, len(s.req.Header)+2)
+	for k, vv := range s.req.Header {
+		req.Header[k] = vv
+	}
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "text/event-stream")
+	}
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+	if s.r != nil && s.req.GetBody != nil {
+		// Reconnecting; the original body has been consumed.
+		body, err := s.req.GetBody()
+		if err != nil {
+			return err
+		}
+		req.Body = body
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode == http.StatusNoContent {
+		res.Body.Close()
+		return errNoContent
+	}
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return fmt.Errorf("sse: unexpected response status %q", res.Status)
+	}
+	if mt, _, err := mime.ParseMediaType(res.Header.Get("Content-Type")); err != nil || mt != "text/event-stream" {
+		res.Body.Close()
+		return fmt.Errorf("sse: unexpected response Content-Type %q", res.Header.Get("Content-Type"))
+	}
+	s.res = res
+	s.r = NewReader(res.Body)
+	s.r.lastID, s.r.retry = lastID, retry
+	return nil
+}
+
+// Next returns the next event. If the connection is lost, Next waits
+// for the reconnection delay and reconnects. It returns an error if
+// reconnecting fails or the request's context is done, and io.EOF if
+// the server replied to a reconnection with 204 No Content.
+func (s *Stream) Next() (*Event, error) {
+	for {
+		if s.res == nil {
+			return nil, io.EOF
+		}
+		ev, err := s.r.Next()
+		if err == nil {
+			return ev, nil
+		}
+		s.res.Body.Close()
+		s.res = nil
+
+		ctx := s.req.Context()
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		delay := s.r.Retry()
+		if delay == 0 {
+			delay = DefaultRetry
+		}
+		t := time.NewTimer(delay)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		}
+		if err := s.connect(s.r.LastEventID(), s.r.Retry()); err != nil {
+			if err == errNoContent {
+				return nil, io.EOF
+			}
+			return nil, err
+		}
+	}
+}
+
+// LastEventID returns the last event ID set by the stream.
+func (s *Stream) LastEventID() string { return s.r.LastEventID() }
+
+// Close closes the current connection. Subsequent calls to Next
+// return io.EOF. Close must not be called concurrently with Next;
+// to interrupt a blocked Next, cancel the request's context.
+func (s *Stream) Close() error {
+	if s.res == nil {
+		return nil
+	}
+	err := s.res.Body.Close()
+	s.res = nil
+	return err
+}
@@ -0,0 +1,215 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package sse implements server-sent events, the text/event-stream
+// format defined by the HTML Living Standard
+// (https://html.spec.whatwg.org/multipage/server-sent-events.html).
+//
+// On the server, a Writer streams events to an http.ResponseWriter.
+// On the client, a Reader parses events from a response body, and a
+// Stream additionally reconnects when the connection is lost,
+// resuming from the last event ID it saw.
+package sse
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// An Event is a single server-sent event.
+type Event struct {
+	// ID is the event ID. When writing, an empty ID is omitted.
+	// When reading, ID is the last event ID the stream had set
+	// when the event was dispatched, which may have been set by
+	// an earlier event.
+	ID string
+
+	// Event is the event type. When writing, an empty type is
+	// omitted, which clients interpret as "message". When
+	// reading, the default type "message" is filled in.
+	Event string
+
+	// Data is the event's payload. It may contain newlines.
+	Data string
+
+	// Retry, if positive, is the reconnection delay the server
+	// asks clients to use. It has millisecond precision.
+	Retry time.Duration
+}
+
+// ErrNotFlusher is returned by NewWriter when the ResponseWriter
+// can't flush buffered data to the client.
+var ErrNotFlusher = errors.New("sse: ResponseWriter does not implement http.Flusher")
+
+// ErrClosed is returned by Writer methods called after Close.
+var ErrClosed = errors.New("sse: Writer closed")
+
+// A Writer writes server-sent events to an HTTP response. Each event
+// is flushed to the client as soon as it is written.
+//
+// A Writer's methods may be called concurrently. All of them
+// must return before the handler that created the Writer returns.
+type Writer struct {
+	w   io.Writer
+	f   http.Flusher
+	ctx context.Context
+
+	mu     sync.Mutex // guards writes and closed
+	closed bool
+
+	hbMu sync.Mutex    // guards stop and done
+	stop chan struct{} // closes to stop the heartbeat goroutine; nil if none
+	done chan struct{} // closed when the heartbeat goroutine exits
+}
+
+// NewWriter starts an event stream in response to r. It sets the
+// Content-Type and Cache-Control headers, writes the status 200 OK
+// and flushes it.
+//
+// A client reconnecting to a stream sends the ID of the last event
+// it received in the Last-Event-ID request header.
+func NewWriter(w http.ResponseWriter, r *http.Request) (*Writer, error) {
+	f, ok := w.(http.Flusher)
+	if !ok {
+		return nil, ErrNotFlusher
+	}
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	f.Flush()
+	return &Writer{w: w, f: f, ctx: r.Context()}, nil
+}
+
+// Done returns a channel that is closed when the client goes away,
+// after which all writes fail.
+func (sw *Writer) Done() <-chan struct{} {
+	return sw.ctx.Done()
+}
+
+// Send writes ev to the stream and flushes it.
+// It returns an error if ev's ID or Event contains a newline, or if
+// the client has gone away.
+func (sw *Writer) Send(ev *Event) error {
+	if strings.ContainsAny(ev.ID, "\r\n\x00") {
+		return errors.New("sse: invalid character in event ID")
+	}
+	if strings.ContainsAny(ev.Event, "\r\n") {
+		return errors.New("sse: invalid character in event type")
+	}
+
+	var b strings.Builder
+	if ev.ID != "" {
+		b.WriteString("id: " + ev.ID + "\n")
+	}
+	if ev.Event != "" {
+		b.WriteString("event: " + ev.Event + "\n")
+	}
+	if ev.Retry > 0 {
+		b.WriteString("retry: " + strconv.FormatInt(int64(ev.Retry/time.Millisecond), 10) + "\n")
+	}
+	if ev.Data != "" || ev.Event != "" {
+		data := strings.Replace(ev.Data, "\r\n", "\n", -1)
+		data = strings.Replace(data, "\r", "\n", -1)
+		for _, line := range strings.Split(data, "\n") {
+			if line == "" {
+				b.WriteString("data\n")
+			} else {
+				b.WriteString("data: " + line + "\n")
+			}
+		}
+	}
+	b.WriteString("\n")
+	return sw.write(b.String())
+}
+
+// Comment writes a comment line, which clients ignore.
+// Any newlines in text are replaced by spaces.
+func (sw *Writer) Comment(text string) error {
+	text = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ").Replace(text)
+	return sw.write(": " + text + "\n")
+}
+
+func (sw *Writer) write(s string) error {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	if sw.closed {
+		return ErrClosed
+	}
+	if err := sw.ctx.Err(); err != nil {
+		return err
+	}
+	if _, err := io.WriteString(sw.w, s); err != nil {
+		return err
+	}
+	sw.f.Flush()
+	return nil
+}
+
+// Heartbeat starts sending a comment every interval, so that idle
+// streams are not closed by proxies and client disconnects are
+// noticed. It replaces any previous heartbeat; an interval of zero
+// stops it. Heartbeats stop when the client goes away or the Writer
+// is closed.
+//
+// A handler that uses Heartbeat must call Close before returning.
+func (sw *Writer) Heartbeat(interval time.Duration) {
+	sw.hbMu.Lock()
+	defer sw.hbMu.Unlock()
+	sw.stopHeartbeatLocked()
+	sw.mu.Lock()
+	closed := sw.closed
+	sw.mu.Unlock()
+	if interval <= 0 || closed {
+		return
+	}
+	stop, done := make(chan struct{}), make(chan struct{})
+	sw.stop, sw.done = stop, done
+	go func() {
+		defer close(done)
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				if sw.write(":\n") != nil {
+					return
+				}
+			case <-stop:
+				return
+			case <-sw.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// stopHeartbeatLocked stops the heartbeat goroutine, if any, and
+// waits for it to exit. sw.hbMu must be held.
+func (sw *Writer) stopHeartbeatLocked() {
+	if sw.stop != nil {
+		close(sw.stop)
+		<-sw.done
+		sw.stop, sw.done = nil, nil
+	}
+}
+
+// Close stops any heartbeat and prevents further writes.
+// It does not close the underlying connection; the stream ends when
+// the handler returns.
+func (sw *Writer) Close() error {
+	sw.hbMu.Lock()
+	defer sw.hbMu.Unlock()
+	sw.stopHeartbeatLocked()
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+	sw.closed = true
+	return nil
+}
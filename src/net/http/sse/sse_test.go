@@ -0,0 +1,289 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package sse
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func readAll(t *testing.T, stream string) []Event {
+	t.Helper()
+	r := NewReader(strings.NewReader(stream))
+	var evs []Event
+	for {
+		ev, err := r.Next()
+		if err == io.EOF {
+			return evs
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		evs = append(evs, *ev)
+	}
+}
+
+func TestReader(t *testing.T) {
+	tests := []struct {
+		name   string
+		stream string
+		want   []Event
+	}{
+		{
+			name:   "simple",
+			stream: "data: hello\n\n",
+			want:   []Event{{Event: "message", Data: "hello"}},
+		},
+		{
+			name:   "multiline data",
+			stream: "data: YHOO\ndata: +2\ndata: 10\n\n",
+			want:   []Event{{Event: "message", Data: "YHOO\n+2\n10"}},
+		},
+		{
+			name:   "fields",
+			stream: ": comment\nid: 7\nevent: add\nretry: 1500\ndata:no space\n\n",
+			want:   []Event{{ID: "7", Event: "add", Data: "no space", Retry: 1500 * time.Millisecond}},
+		},
+		{
+			name:   "id persists",
+			stream: "id: 1\ndata: a\n\ndata: b\n\nid\ndata: c\n\n",
+			want: []Event{
+				{ID: "1", Event: "message", Data: "a"},
+				{ID: "1", Event: "message", Data: "b"},
+				{ID: "", Event: "message", Data: "c"},
+			},
+		},
+		{
+			name:   "empty data fields",
+			stream: "data\n\ndata\ndata\n\ndata:\n",
+			want: []Event{
+				{Event: "message", Data: ""},
+				{Event: "message", Data: "\n"},
+			},
+		},
+		{
+			name:   "line endings and BOM",
+			stream: "\xef\xbb\xbfdata: a\r\n\r\ndata: b\r\rdata: c\n\n",
+			want: []Event{
+				{Event: "message", Data: "a"},
+				{Event: "message", Data: "b"},
+				{Event: "message", Data: "c"},
+			},
+		},
+		{
+			name:   "ignored",
+			stream: "event: lonely\n\nid: a\x00b\nretry: 1x\nretry: -1\nunknown: x\ndata: d\n\ndata: incomplete",
+			want:   []Event{{Event: "message", Data: "d"}},
+		},
+	}
+	for _, tt := range tests {
+		if got := readAll(t, tt.stream); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s:\n got %+v\nwant %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWriter(t *testing.T) {
+	rr := httptest.NewRecorder()
+	sw, err := NewWriter(rr, httptest.NewRequest("GET", "/", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := rr.Header().Get("Content-Type"); got != "text/event-stream" {
+		t.Errorf("Content-Type = %q", got)
+	}
+	if !rr.Flushed {
+		t.Error("headers were not flushed")
+	}
+	evs := []*Event{
+		{Data: "hello"},
+		{ID: "42", Event: "update", Data: "line1\nline2\r\n\nline4", Retry: 2 * time.Second},
+		{Event: "ping"},
+	}
+	for _, ev := range evs {
+		if err := sw.Send(ev); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sw.Comment("a\nb"); err != nil {
+		t.Fatal(err)
+	}
+	want := "data: hello\n\n" +
+		"id: 42\nevent: update\nretry: 2000\ndata: line1\ndata: line2\ndata\ndata: line4\n\n" +
+		"event: ping\ndata\n\n" +
+		": a b\n"
+	if got := rr.Body.String(); got != want {
+		t.Errorf("stream:\n got %q\nwant %q", got, want)
+	}
+
+	// What was written reads back as what was sent.
+	got := readAll(t, want)
+	wantEvs := []Event{
+		{Event: "message", Data: "hello"},
+		{ID: "42", Event: "update", Data: "line1\nline2\n\nline4", Retry: 2 * time.Second},
+		{ID: "42", Event: "ping", Data: ""},
+	}
+	if !reflect.DeepEqual(got, wantEvs) {
+		t.Errorf("read back:\n got %+v\nwant %+v", got, wantEvs)
+	}
+
+	if err := sw.Send(&Event{ID: "a\nb"}); err == nil {
+		t.Error("Send with newline in ID succeeded")
+	}
+	sw.Close()
+	if err := sw.Send(&Event{Data: "x"}); err != ErrClosed {
+		t.Errorf("Send after Close = %v; want ErrClosed", err)
+	}
+}
+
+type noFlushWriter struct{ http.ResponseWriter }
+
+func TestWriterNotFlusher(t *testing.T) {
+	_, err := NewWriter(noFlushWriter{httptest.NewRecorder()}, httptest.NewRequest("GET", "/", nil))
+	if err != ErrNotFlusher {
+		t.Errorf("err = %v; want ErrNotFlusher", err)
+	}
+}
+
+func TestWriterContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	req := httptest.NewRequest("GET", "/", nil).WithContext(ctx)
+	sw, err := NewWriter(httptest.NewRecorder(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sw.Heartbeat(time.Millisecond)
+	cancel()
+	<-sw.Done()
+	if err := sw.Send(&Event{Data: "x"}); err != context.Canceled {
+		t.Errorf("Send after cancel = %v; want context.Canceled", err)
+	}
+	sw.Close()
+}
+
+func TestWriterHeartbeat(t *testing.T) {
+	rr := httptest.NewRecorder()
+	sw, err := NewWriter(rr, httptest.NewRequest("GET", "/", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sw.Heartbeat(time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	sw.Heartbeat(time.Hour) // replaces the first one
+	sw.Close()
+	if !strings.HasPrefix(rr.Body.String(), ":\n") {
+		t.Errorf("stream = %q; want heartbeat comments", rr.Body.String())
+	}
+	if evs := readAll(t, rr.Body.String()); len(evs) != 0 {
+		t.Errorf("heartbeats produced events %+v", evs)
+	}
+}
+
+func TestStreamReconnect(t *testing.T) {
+	var conns int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&conns, 1)
+		if r.Header.Get("Accept") != "text/event-stream" {
+			t.Errorf("connection %d: Accept = %q", n, r.Header.Get("Accept"))
+		}
+		last := r.Header.Get("Last-Event-ID")
+		switch n {
+		case 1:
+			if last != "" {
+				t.Errorf("first connection has Last-Event-ID %q", last)
+			}
+			sw, err := NewWriter(w, r)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			sw.Send(&Event{ID: "1", Data: "one", Retry: time.Millisecond})
+			sw.Send(&Event{ID: "2", Data: "two"})
+		case 2:
+			if last != "2" {
+				t.Errorf("second connection: Last-Event-ID = %q; want 2", last)
+			}
+			sw, err := NewWriter(w, r)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			sw.Send(&Event{ID: "3", Data: "three"})
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer ts.Close()
+
+	req, _ := http.NewRequest("GET", ts.URL, nil)
+	s, err := Connect(ts.Client(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	var got []string
+	for {
+		ev, err := s.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, ev.ID+":"+ev.Data)
+	}
+	if want := []string{"1:one", "2:two", "3:three"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %q; want %q", got, want)
+	}
+	if n := atomic.LoadInt32(&conns); n != 3 {
+		t.Errorf("%d connections; want 3", n)
+	}
+}
+
+func TestConnectBadResponse(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("data: no\n\n"))
+	}))
+	defer ts.Close()
+
+	req, _ := http.NewRequest("GET", ts.URL, nil)
+	if _, err := Connect(ts.Client(), req); err == nil || !strings.Contains(err.Error(), "Content-Type") {
+		t.Errorf("Connect error = %v; want Content-Type error", err)
+	}
+}
+
+func TestStreamCanceled(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sw, err := NewWriter(w, r)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		sw.Send(&Event{Data: "only", Retry: time.Hour})
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequest("GET", ts.URL, nil)
+	s, err := Connect(ts.Client(), req.WithContext(ctx))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Next(); err != nil {
+		t.Fatal(err)
+	}
+	time.AfterFunc(10*time.Millisecond, cancel)
+	if _, err := s.Next(); err != context.Canceled {
+		t.Errorf("Next = %v; want context.Canceled", err)
+	}
+}
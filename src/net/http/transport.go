@@ -95,6 +95,11 @@ type Transport struct {
 	altMu    sync.Mutex   // guards changing altProto only
 	altProto atomic.Value // of nil or map[string]RoundTripper, key is URI scheme
 
+	protoMu        sync.Mutex
+	protoNames     []string                          // ALPN protocol IDs in registration order
+	protoFactories map[string]ProtocolFactory        // key is ALPN protocol ID
+	protoConns     map[connectMethodKey]RoundTripper // from protoFactories, per origin
+
 	// Proxy specifies a function to return a proxy for a given
 	// Request. If the function returns a non-nil error, the
 	// request is aborted with the provided error.
@@ -193,6 +198,12 @@ type Transport struct {
 	// must return a RoundTripper that then handles the request.
 	// If TLSNextProto is not nil, HTTP/2 support is not enabled
 	// automatically.
+	//
+	// Protocols registered with RegisterALPNProtocol take precedence
+	// over TLSNextProto and are pooled by the Transport; see
+	// ProtocolFactory. The automatically enabled HTTP/2 support is
+	// registered that way too, and still adds an "h2" entry to
+	// TLSNextProto and "h2" to TLSClientConfig.NextProtos.
 	TLSNextProto map[string]func(authority string, c *tls.Conn) RoundTripper
 
 	// ProxyConnectHeader optionally specifies headers to send to
@@ -206,6 +217,13 @@ type Transport struct {
 	// Zero means to use a default limit.
 	MaxResponseHeaderBytes int64
 
+	// ForceAttemptHTTP2 controls whether HTTP/2 is enabled when a
+	// non-nil Dial or DialTLS func or TLSClientConfig is provided.
+	// By default, use of any of those fields conservatively
+	// disables HTTP/2. To use a custom dialer or TLS config and
+	// still attempt HTTP/2 upgrades, set this to true.
+	ForceAttemptHTTP2 bool
+
 	// nextProtoOnce guards initialization of TLSNextProto and
 	// h2transport (via onceSetNextProtoDefaults)
 	nextProtoOnce sync.Once
@@ -225,7 +243,11 @@ func (t *Transport) onceSetNextProtoDefaults() {
 		// Transport.
 		return
 	}
-	if t.TLSClientConfig != nil || t.Dial != nil || t.DialTLS != nil {
+	if t.protocolFactory("h2") != nil {
+		// The user registered their own HTTP/2 implementation.
+		return
+	}
+	if !t.ForceAttemptHTTP2 && (t.TLSClientConfig != nil || t.Dial != nil || t.DialTLS != nil) {
 		// Be conservative and don't automatically enable
 		// http2 if they've specified a custom TLS config or
 		// custom dialers. Let them opt-in themselves via
@@ -239,6 +261,7 @@ func (t *Transport) onceSetNextProtoDefaults() {
 		return
 	}
 	t.h2transport = t2
+	t.registerHTTP2(t2)
 
 	// Auto-configure the http2.Transport's MaxHeaderListSize from
 	// the http.Transport's MaxResponseHeaderBytes. They don't
@@ -402,6 +425,19 @@ func (t *Transport) RoundTrip(req *Request) (*Response, error) {
 			return nil, err
 		}
 
+		// Use a pooled connection speaking a registered ALPN
+		// protocol, if there is one for this origin.
+		if rt := t.getProtoConn(cm.key()); rt != nil {
+			resp, err := rt.RoundTrip(req)
+			if err == nil {
+				return resp, nil
+			}
+			t.removeProtoConn(cm.key(), rt, err)
+			if err != ErrSkipAltProtocol {
+				return nil, err
+			}
+		}
+
 		// Get the cached or newly-created connection to either the
 		// host (for http or https), the http proxy, or the http proxy
 		// pre-CONNECTed to https server. In any case, we'll be ready
@@ -452,6 +488,11 @@ func (t *Transport) RoundTrip(req *Request) (*Response, error) {
 // HTTP request on a new connection. The non-nil input error is the
 // error from roundTrip.
 func (pc *persistConn) shouldRetryRequest(req *Request, err error) bool {
+	if err == ErrSkipAltProtocol && pc.alt != nil {
+		// A registered ALPN protocol's RoundTripper could not
+		// take the request; dial again.
+		return true
+	}
 	if http2isNoCachedConnError(err) {
 		// Issue 16582: if the user started a bunch of
 		// requests at once, they can all pick the same conn
@@ -526,6 +567,151 @@ func (t *Transport) RegisterProtocol(scheme string, rt RoundTripper) {
 	t.altProto.Store(newMap)
 }
 
+// A ProtocolFactory returns a RoundTripper that sends requests over
+// conn using an application protocol negotiated with TLS ALPN.
+// authority is the "host:port" of the origin conn was dialed for.
+// conn is usually a *tls.Conn, but may be any net.Conn returned by
+// Transport.DialTLS; its negotiated protocol is taken from its
+// ConnectionState method, if it has one. The factory takes ownership
+// of conn.
+//
+// The Transport keeps the returned RoundTripper in a pool keyed by
+// origin and proxy, and sends later requests for the same origin to
+// it, possibly concurrently, instead of dialing a new connection.
+// The RoundTripper is removed from the pool when its RoundTrip method
+// returns an error, unless it has a method
+//
+//	CanTakeNewRequest() bool
+//
+// that reports true. If RoundTrip returns ErrSkipAltProtocol, nothing
+// must have been sent, and the Transport dials a new connection for
+// the request. CloseIdleConnections empties the pool, calling the
+// CloseIdleConnections method of each RoundTripper that has one.
+type ProtocolFactory func(authority string, conn net.Conn) RoundTripper
+
+// RegisterALPNProtocol registers f to handle connections on which the
+// TLS ALPN protocol proto is negotiated. Registered protocols are
+// offered to servers during the TLS handshake, in registration order
+// and ahead of any NextProtos in TLSClientConfig, with "http/1.1" added
+// last so that servers can still fall back to HTTP/1.1.
+//
+// Unless HTTP/2 support has been disabled, the Transport registers
+// its bundled HTTP/2 implementation for "h2" before the first request.
+// Registering a factory for "h2" before that replaces it.
+//
+// RegisterALPNProtocol panics if proto is empty or already registered.
+func (t *Transport) RegisterALPNProtocol(proto string, f ProtocolFactory) {
+	if proto == "" {
+		panic("http: empty ALPN protocol")
+	}
+	t.protoMu.Lock()
+	defer t.protoMu.Unlock()
+	if _, exists := t.protoFactories[proto]; exists {
+		panic("ALPN protocol " + proto + " already registered")
+	}
+	if t.protoFactories == nil {
+		t.protoFactories = make(map[string]ProtocolFactory)
+	}
+	t.protoFactories[proto] = f
+	t.protoNames = append(t.protoNames, proto)
+}
+
+func (t *Transport) protocolFactory(proto string) ProtocolFactory {
+	t.protoMu.Lock()
+	defer t.protoMu.Unlock()
+	return t.protoFactories[proto]
+}
+
+// nextProtos returns the ALPN protocols to offer in a TLS handshake,
+// given the NextProtos of TLSClientConfig.
+func (t *Transport) nextProtos(configured []string) []string {
+	t.protoMu.Lock()
+	defer t.protoMu.Unlock()
+	if len(t.protoNames) == 0 {
+		return configured
+	}
+	var protos []string
+	for _, p := range t.protoNames {
+		if !strSliceContains(configured, p) {
+			protos = append(protos, p)
+		}
+	}
+	protos = append(protos, configured...)
+	if !strSliceContains(protos, "http/1.1") {
+		protos = append(protos, "http/1.1")
+	}
+	return protos
+}
+
+// getProtoConn returns the pooled RoundTripper for key, if any.
+func (t *Transport) getProtoConn(key connectMethodKey) RoundTripper {
+	t.protoMu.Lock()
+	defer t.protoMu.Unlock()
+	return t.protoConns[key]
+}
+
+func (t *Transport) putProtoConn(key connectMethodKey, rt RoundTripper) {
+	t.protoMu.Lock()
+	defer t.protoMu.Unlock()
+	if t.protoConns == nil {
+		t.protoConns = make(map[connectMethodKey]RoundTripper)
+	}
+	t.protoConns[key] = rt
+}
+
+// removeProtoConn removes rt from the pool for key after its RoundTrip
+// failed with err, unless rt reports that it can still be used.
+func (t *Transport) removeProtoConn(key connectMethodKey, rt RoundTripper, err error) {
+	if err != ErrSkipAltProtocol {
+		if c, ok := rt.(interface{ CanTakeNewRequest() bool }); ok && c.CanTakeNewRequest() {
+			return
+		}
+	}
+	t.protoMu.Lock()
+	defer t.protoMu.Unlock()
+	if t.protoConns[key] == rt {
+		delete(t.protoConns, key)
+	}
+}
+
+// registerHTTP2 registers the bundled HTTP/2 implementation, as
+// configured by http2configureTransport, as the factory for "h2".
+// Connections that are a *tls.Conn are handed to the TLSNextProto entry
+// that http2configureTransport installed and join t2's connection pool.
+// Other connections, such as in-memory ones returned by DialTLS, get a
+// client connection of their own.
+func (t *Transport) registerHTTP2(t2 *http2Transport) {
+	t.RegisterALPNProtocol("h2", func(authority string, c net.Conn) RoundTripper {
+		if tc, ok := c.(*tls.Conn); ok {
+			if next, ok := t.TLSNextProto["h2"]; ok {
+				if rt := next(authority, tc); rt != RoundTripper(t2) {
+					return rt
+				}
+				return http2PoolRoundTripper{t2}
+			}
+		}
+		cc, err := t2.NewClientConn(c)
+		if err != nil {
+			go c.Close()
+			return http2erringRoundTripper{err}
+		}
+		return cc
+	})
+}
+
+// http2PoolRoundTripper is the RoundTripper returned by the bundled
+// HTTP/2 protocol factory. Requests go through the HTTP/2 Transport's
+// own connection pool, so it stays usable after a failed request, and
+// it reports ErrSkipAltProtocol once that pool has no connection left
+// for the request's origin.
+type http2PoolRoundTripper struct{ t *http2Transport }
+
+func (rt http2PoolRoundTripper) RoundTrip(req *Request) (*Response, error) {
+	return http2noDialH2RoundTripper{rt.t}.RoundTrip(req)
+}
+
+func (rt http2PoolRoundTripper) CanTakeNewRequest() bool { return true }
+
 // CloseIdleConnections closes any connections which were previously
 // connected from previous requests but are now sitting idle in
 // a "keep-alive" state. It does not interrupt any connections currently
@@ -544,6 +730,15 @@ func (t *Transport) CloseIdleConnections() {
 			pconn.close(errCloseIdleConns)
 		}
 	}
+	t.protoMu.Lock()
+	pm := t.protoConns
+	t.protoConns = nil
+	t.protoMu.Unlock()
+	for _, rt := range pm {
+		if ci, ok := rt.(interface{ CloseIdleConnections() }); ok {
+			ci.CloseIdleConnections()
+		}
+	}
 	if t2 := t.h2transport; t2 != nil {
 		t2.CloseIdleConnections()
 	}
@@ -655,6 +850,7 @@ var (
 	errTooManyIdleHost    = errors.New("http: putIdleConn: too many idle connections for host")
 	errCloseIdleConns     = errors.New("http: CloseIdleConnections called")
 	errReadLoopExiting    = errors.New("http: persistConn.readLoop exiting")
+	errCallerOwnsConn     = errors.New("http: read loop ending; caller owns writable underlying conn")
 	errIdleConnTimeout    = errors.New("http: idle connection timeout")
 	errNotCachingH2Conn   = errors.New("http: not caching alternate protocol's connections")
 
@@ -1052,6 +1248,7 @@ func (pconn *persistConn) addTLS(name string, trace *httptrace.ClientTrace) erro
 	if cfg.ServerName == "" {
 		cfg.ServerName = name
 	}
+	cfg.NextProtos = pconn.t.nextProtos(cfg.NextProtos)
 	plainConn := pconn.conn
 	tlsConn := tls.Client(plainConn, cfg)
 	errc := make(chan error, 2)
@@ -1138,6 +1335,9 @@ func (t *Transport) dialConn(ctx context.Context, cm connectMethod) (*persistCon
 				trace.TLSHandshakeDone(cs, nil)
 			}
 			pconn.tlsState = &cs
+		} else if cc, ok := pconn.conn.(connectionStater); ok {
+			cs := cc.ConnectionState()
+			pconn.tlsState = &cs
 		}
 	} else {
 		conn, err := t.dial(ctx, "tcp", cm.addr())
@@ -1227,6 +1427,11 @@ func (t *Transport) dialConn(ctx context.Context, cm connectMethod) (*persistCon
 	}
 
 	if s := pconn.tlsState; s != nil && s.NegotiatedProtocolIsMutual && s.NegotiatedProtocol != "" {
+		if f := t.protocolFactory(s.NegotiatedProtocol); f != nil {
+			rt := f(cm.targetAddr, pconn.conn)
+			t.putProtoConn(cm.key(), rt)
+			return &persistConn{alt: rt}, nil
+		}
 		if next, ok := t.TLSNextProto[s.NegotiatedProtocol]; ok {
 			return &persistConn{alt: next(cm.targetAddr, pconn.conn.(*tls.Conn))}, nil
 		}
@@ -1239,6 +1444,12 @@ func (t *Transport) dialConn(ctx context.Context, cm connectMethod) (*persistCon
 	return pconn, nil
 }
 
+// connectionStater is implemented by connections, such as *tls.Conn,
+// that report the state of a TLS session.
+type connectionStater interface {
+	ConnectionState() tls.ConnectionState
+}
+
 // persistConnWriter is the io.Writer written to by pc.bw.
 // It accumulates the number of bytes written to the underlying conn,
 // so the retry logic can determine whether any bytes made it across
@@ -1638,15 +1849,16 @@ func (pc *persistConn) readLoop() {
 		pc.mu.Unlock()
 
 		hasBody := rc.req.Method != "HEAD" && resp.ContentLength != 0
+		_, bodyWritable := resp.Body.(io.Writer)
 
-		if resp.Close || rc.req.Close || resp.StatusCode <= 199 {
+		if resp.Close || rc.req.Close || resp.StatusCode <= 199 || bodyWritable {
 			// Don't do keep-alive on error if either party requested a close
 			// or we get an unexpected informational (1xx) response.
 			// StatusCode 100 is already handled above.
 			alive = false
 		}
 
-		if !hasBody {
+		if !hasBody || bodyWritable {
 			pc.t.setReqCanceler(rc.req, nil)
 
 			// Put the idle conn back into the pool before we send the response
@@ -1660,6 +1872,12 @@ func (pc *persistConn) readLoop() {
 				pc.wroteRequest() &&
 				tryPutIdleConn(trace)
 
+			if bodyWritable {
+				// The caller now owns the connection through
+				// resp.Body; don't close it when this loop exits.
+				closeErr = errCallerOwnsConn
+			}
+
 			select {
 			case rc.ch <- responseAndError{res: resp}:
 			case <-rc.callerGone:
@@ -1785,10 +2003,46 @@ func (pc *persistConn) readResponse(rc requestAndChan, trace *httptrace.ClientTr
 			return
 		}
 	}
+	if resp.isProtocolSwitch() {
+		resp.Body = newReadWriteCloserBody(pc.br, pc.conn)
+	}
 	resp.TLS = pc.tlsState
 	return
 }
 
+// newReadWriteCloserBody returns the Response.Body used for a
+// "101 Switching Protocols" response. Reads drain whatever br has
+// already buffered before going to rwc, and writes go directly to rwc.
+func newReadWriteCloserBody(br *bufio.Reader, rwc io.ReadWriteCloser) io.ReadWriteCloser {
+	body := &readWriteCloserBody{ReadWriteCloser: rwc}
+	if br.Buffered() != 0 {
+		body.br = br
+	}
+	return body
+}
+
+// readWriteCloserBody is the Response.Body type used when the caller
+// is given write access to the underlying connection, as for
+// WebSockets or other protocols negotiated with an Upgrade header.
+type readWriteCloserBody struct {
+	br *bufio.Reader // used until empty
+	io.ReadWriteCloser
+}
+
+func (b *readWriteCloserBody) Read(p []byte) (n int, err error) {
+	if b.br != nil {
+		if n := b.br.Buffered(); len(p) > n {
+			p = p[:n]
+		}
+		n, err = b.br.Read(p)
+		if b.br.Buffered() == 0 {
+			b.br = nil
+		}
+		return n, err
+	}
+	return b.ReadWriteCloser.Read(p)
+}
+
 // waitForContinue returns the function to block until
 // any response, timeout or connection close. After any of them,
 // the function returns a bool which indicates if the body should be sent.
@@ -2125,7 +2379,9 @@ func (pc *persistConn) closeLocked(err error) {
 			// freelist for http2. That's done by the
 			// alternate protocol's RoundTripper.
 		} else {
-			pc.conn.Close()
+			if err != errCallerOwnsConn {
+				pc.conn.Close()
+			}
 			close(pc.closech)
 		}
 	}
@@ -3343,6 +3343,13 @@ func TestTransportAutomaticHTTP2_TLSConfig(t *testing.T) {
 	}, false)
 }
 
+func TestTransportAutomaticHTTP2_ForceAttemptHTTP2(t *testing.T) {
+	testTransportAutoHTTP(t, &Transport{
+		TLSClientConfig:   new(tls.Config),
+		ForceAttemptHTTP2: true,
+	}, true)
+}
+
 func TestTransportAutomaticHTTP2_ExpectContinueTimeout(t *testing.T) {
 	testTransportAutoHTTP(t, &Transport{
 		ExpectContinueTimeout: 1 * time.Second,
@@ -4365,3 +4372,309 @@ func TestNoBodyOnChunked304Response(t *testing.T) {
 		t.Errorf("Unexpected body on 304 response")
 	}
 }
+
+func TestTransportResponseBodyWritableOnProtocolSwitch(t *testing.T) {
+	setParallel(t)
+	defer afterTest(t)
+	done := make(chan struct{})
+	defer close(done)
+	cst := newClientServerTest(t, h1Mode, HandlerFunc(func(w ResponseWriter, r *Request) {
+		conn, _, err := w.(Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		io.WriteString(conn, "HTTP/1.1 101 Switching Protocols Hi\r\nConnection: upgRADe\r\nUpgrade: foo\r\n\r\nSome buffered data\n")
+		bs := bufio.NewScanner(conn)
+		bs.Scan()
+		fmt.Fprintf(conn, "%s\n", strings.ToUpper(bs.Text()))
+		<-done
+	}))
+	defer cst.close()
+
+	req, _ := NewRequest("GET", cst.ts.URL, nil)
+	req.Header.Set("Upgrade", "foo")
+	req.Header.Set("Connection", "upgrade")
+	res, err := cst.c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != 101 {
+		t.Fatalf("expected 101 switching protocols; got %v, %v", res.Status, res.Header)
+	}
+	rwc, ok := res.Body.(io.ReadWriteCloser)
+	if !ok {
+		t.Fatalf("expected a ReadWriteCloser; got a %T", res.Body)
+	}
+	defer rwc.Close()
+	bs := bufio.NewScanner(rwc)
+	if !bs.Scan() {
+		t.Fatalf("expected readable input")
+	}
+	if got, want := bs.Text(), "Some buffered data"; got != want {
+		t.Errorf("read %q; want %q", got, want)
+	}
+	io.WriteString(rwc, "echo\n")
+	if !bs.Scan() {
+		t.Fatalf("expected another line")
+	}
+	if got, want := bs.Text(), "ECHO"; got != want {
+		t.Errorf("read %q; want %q", got, want)
+	}
+}
+
+// alpnConn is an in-memory connection that reports a negotiated ALPN
+// protocol, as a *tls.Conn would.
+type alpnConn struct {
+	net.Conn
+	proto string
+}
+
+func (c alpnConn) ConnectionState() tls.ConnectionState {
+	return tls.ConnectionState{
+		HandshakeComplete:          true,
+		NegotiatedProtocol:         c.proto,
+		NegotiatedProtocolIsMutual: true,
+	}
+}
+
+// lineRoundTripper speaks a toy protocol over conn: it writes the
+// request path on a line and reads a line back as the response body.
+type lineRoundTripper struct {
+	mu    sync.Mutex
+	conn  net.Conn
+	br    *bufio.Reader
+	stale bool // if set, RoundTrip returns ErrSkipAltProtocol
+}
+
+func (rt *lineRoundTripper) RoundTrip(req *Request) (*Response, error) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	if rt.stale {
+		return nil, ErrSkipAltProtocol
+	}
+	if _, err := io.WriteString(rt.conn, req.URL.Path+"\n"); err != nil {
+		return nil, err
+	}
+	line, err := rt.br.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	return &Response{
+		StatusCode: 200,
+		Proto:      "LINE/1.0",
+		Header:     make(Header),
+		Body:       ioutil.NopCloser(strings.NewReader(strings.TrimSuffix(line, "\n"))),
+		Request:    req,
+	}, nil
+}
+
+func (rt *lineRoundTripper) CloseIdleConnections() {
+	rt.conn.Close()
+}
+
+// serveLines answers each line read from c with the line prefixed
+// by "got ".
+func serveLines(c net.Conn) {
+	defer c.Close()
+	bs := bufio.NewScanner(c)
+	for bs.Scan() {
+		if _, err := fmt.Fprintf(c, "got %s\n", bs.Text()); err != nil {
+			return
+		}
+	}
+}
+
+func TestTransportRegisterALPNProtocol(t *testing.T) {
+	defer afterTest(t)
+	var (
+		mu          sync.Mutex
+		dials       int
+		authorities []string
+		rts         []*lineRoundTripper
+		servers     []net.Conn
+	)
+	tr := &Transport{
+		DialTLS: func(network, addr string) (net.Conn, error) {
+			c, s := net.Pipe()
+			go serveLines(s)
+			mu.Lock()
+			dials++
+			servers = append(servers, s)
+			mu.Unlock()
+			return alpnConn{c, "line"}, nil
+		},
+	}
+	defer func() {
+		tr.CloseIdleConnections()
+		mu.Lock()
+		defer mu.Unlock()
+		for _, s := range servers {
+			s.Close()
+		}
+	}()
+	tr.RegisterALPNProtocol("line", func(authority string, c net.Conn) RoundTripper {
+		if _, ok := c.(alpnConn); !ok {
+			t.Errorf("factory got conn of type %T; want alpnConn", c)
+		}
+		rt := &lineRoundTripper{conn: c, br: bufio.NewReader(c)}
+		mu.Lock()
+		authorities = append(authorities, authority)
+		rts = append(rts, rt)
+		mu.Unlock()
+		return rt
+	})
+	c := &Client{Transport: tr}
+
+	get := func(path string) {
+		t.Helper()
+		res, err := c.Get("https://example.com" + path)
+		if err != nil {
+			t.Fatalf("Get %s: %v", path, err)
+		}
+		defer res.Body.Close()
+		if res.Proto != "LINE/1.0" {
+			t.Errorf("Get %s: Proto = %q; want LINE/1.0", path, res.Proto)
+		}
+		body, _ := ioutil.ReadAll(res.Body)
+		if got, want := string(body), "got "+path; got != want {
+			t.Errorf("Get %s: body = %q; want %q", path, got, want)
+		}
+	}
+	wantDials := func(want int) {
+		t.Helper()
+		mu.Lock()
+		defer mu.Unlock()
+		if dials != want {
+			t.Errorf("dials = %d; want %d", dials, want)
+		}
+	}
+
+	// Requests to the same origin share one connection.
+	get("/a")
+	get("/b")
+	get("/c")
+	wantDials(1)
+	if want := []string{"example.com:443"}; !reflect.DeepEqual(authorities, want) {
+		t.Errorf("factory authorities = %q; want %q", authorities, want)
+	}
+
+	// A RoundTripper returning ErrSkipAltProtocol is replaced by a
+	// newly dialed one.
+	mu.Lock()
+	rts[0].mu.Lock()
+	rts[0].stale = true
+	rts[0].mu.Unlock()
+	mu.Unlock()
+	get("/d")
+	wantDials(2)
+	get("/e")
+	wantDials(2)
+
+	// A RoundTripper failing with another error is dropped from the
+	// pool, and the error is returned.
+	mu.Lock()
+	servers[1].Close()
+	mu.Unlock()
+	if res, err := c.Get("https://example.com/f"); err == nil {
+		res.Body.Close()
+		t.Fatal("Get on closed connection succeeded")
+	}
+	get("/g")
+	wantDials(3)
+}
+
+// pipeListener is a net.Listener whose connections are in-memory
+// pipes created by dial.
+type pipeListener struct {
+	ch     chan net.Conn
+	closed chan struct{}
+	once   sync.Once
+}
+
+func newPipeListener() *pipeListener {
+	return &pipeListener{
+		ch:     make(chan net.Conn),
+		closed: make(chan struct{}),
+	}
+}
+
+func (ln *pipeListener) Accept() (net.Conn, error) {
+	select {
+	case c := <-ln.ch:
+		return c, nil
+	case <-ln.closed:
+		return nil, errors.New("pipeListener closed")
+	}
+}
+
+func (ln *pipeListener) Close() error {
+	ln.once.Do(func() { close(ln.closed) })
+	return nil
+}
+
+func (ln *pipeListener) Addr() net.Addr { return pipeAddr{} }
+
+func (ln *pipeListener) dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	c, s := net.Pipe()
+	select {
+	case ln.ch <- s:
+		return c, nil
+	case <-ln.closed:
+		return nil, errors.New("pipeListener closed")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+type pipeAddr struct{}
+
+func (pipeAddr) Network() string { return "pipe" }
+func (pipeAddr) String() string  { return "pipe" }
+
+// Tests that the automatically enabled HTTP/2 support, registered
+// through RegisterALPNProtocol, pools its connections per origin.
+func TestTransportHTTP2ALPNProtocolInMemory(t *testing.T) {
+	defer afterTest(t)
+	cert, err := tls.X509KeyPair(internal.LocalhostCert, internal.LocalhostKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln := newPipeListener()
+	srv := &Server{Handler: HandlerFunc(func(w ResponseWriter, r *Request) {
+		io.WriteString(w, r.Proto)
+	})}
+	go srv.Serve(tls.NewListener(ln, &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   []string{"h2", "http/1.1"},
+	}))
+	defer srv.Close()
+
+	var dials int32
+	tr := &Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			atomic.AddInt32(&dials, 1)
+			return ln.dial(ctx, network, addr)
+		},
+		ForceAttemptHTTP2: true,
+	}
+	defer tr.CloseIdleConnections()
+	c := &Client{Transport: tr}
+
+	for i := 0; i < 3; i++ {
+		res, err := c.Get("https://example.com/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if res.ProtoMajor != 2 || string(body) != "HTTP/2.0" {
+			t.Fatalf("request %d: client proto %q, server proto %q; want HTTP/2.0", i, res.Proto, body)
+		}
+	}
+	if n := atomic.LoadInt32(&dials); n != 1 {
+		t.Errorf("dials = %d; want 1", n)
+	}
+}
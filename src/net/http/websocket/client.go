@@ -0,0 +1,155 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package websocket
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// A Dialer opens WebSocket connections. The zero value is usable.
+type Dialer struct {
+	// Transport sends the opening handshake request. It must
+	// return a Body implementing io.ReadWriteCloser for a
+	// "101 Switching Protocols" response, as *http.Transport does
+	// for HTTP/1.1 connections. If nil, a Transport using
+	// http.ProxyFromEnvironment and HTTP/1.1 is used.
+	Transport http.RoundTripper
+
+	// Header specifies additional request header fields, such as
+	// Origin or Cookie.
+	Header http.Header
+
+	// Subprotocols lists the subprotocols to offer, in order of
+	// preference.
+	Subprotocols []string
+
+	// EnableCompression offers the permessage-deflate extension.
+	// Connections using it have a default read limit of 16 MiB;
+	// see Conn.SetReadLimit.
+	EnableCompression bool
+}
+
+// defaultTransport is used by Dialers without a Transport. It has
+// HTTP/2 disabled, since WebSockets require HTTP/1.1.
+var defaultTransport = &http.Transport{
+	Proxy:        http.ProxyFromEnvironment,
+	TLSNextProto: make(map[string]func(string, *tls.Conn) http.RoundTripper),
+}
+
+// Dial opens a WebSocket connection to urlStr using the zero Dialer.
+func Dial(ctx context.Context, urlStr string) (*Conn, *http.Response, error) {
+	var d Dialer
+	return d.Dial(ctx, urlStr)
+}
+
+// Dial performs the client side of the opening handshake with the
+// server at urlStr, which has scheme "ws" or "wss", and returns the
+// resulting connection along with the server's response. The context
+// governs the handshake only; once Dial returns, canceling it does
+// not affect the connection.
+//
+// If the server does not complete the handshake, Dial returns
+// ErrBadHandshake along with the response, whose body the caller
+// should close.
+func (d *Dialer) Dial(ctx context.Context, urlStr string) (*Conn, *http.Response, error) {
+	u, err := url.Parse(urlStr)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch u.Scheme {
+	case "ws":
+		u.Scheme = "http"
+	case "wss":
+		u.Scheme = "https"
+	default:
+		return nil, nil, errors.New("websocket: unsupported URL scheme " + u.Scheme)
+	}
+	u.Fragment = ""
+
+	var nonce [16]byte
+	if _, err := io.ReadFull(rand.Reader, nonce[:]); err != nil {
+		return nil, nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce[:])
+
+	req, err := http.NewRequest("GET", u.String(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req = req.WithContext(ctx)
+	for k, vv := range d.Header {
+		switch k {
+		case "Connection", "Upgrade", "Sec-Websocket-Key", "Sec-Websocket-Version",
+			"Sec-Websocket-Protocol", "Sec-Websocket-Extensions":
+			return nil, nil, errors.New("websocket: duplicate header " + k)
+		}
+		req.Header[k] = vv
+	}
+	if h := d.Header.Get("Host"); h != "" {
+		req.Host = h
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	if len(d.Subprotocols) > 0 {
+		req.Header.Set("Sec-WebSocket-Protocol", strings.Join(d.Subprotocols, ", "))
+	}
+	if d.EnableCompression {
+		req.Header.Set("Sec-WebSocket-Extensions", deflateExtension)
+	}
+
+	rt := d.Transport
+	if rt == nil {
+		rt = defaultTransport
+	}
+	res, err := rt.RoundTrip(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	if res.StatusCode != http.StatusSwitchingProtocols ||
+		!headerContainsToken(res.Header, "Upgrade", "websocket") ||
+		!headerContainsToken(res.Header, "Connection", "upgrade") ||
+		res.Header.Get("Sec-WebSocket-Accept") != computeAccept(key) {
+		return nil, res, ErrBadHandshake
+	}
+	rwc, ok := res.Body.(io.ReadWriteCloser)
+	if !ok {
+		res.Body.Close()
+		return nil, res, errors.New("websocket: Transport returned a read-only response body")
+	}
+	fail := func(msg string) (*Conn, *http.Response, error) {
+		rwc.Close()
+		return nil, res, errors.New("websocket: " + msg)
+	}
+
+	subprotocol := res.Header.Get("Sec-WebSocket-Protocol")
+	if subprotocol != "" {
+		found := false
+		for _, s := range d.Subprotocols {
+			found = found || s == subprotocol
+		}
+		if !found {
+			return fail("server selected a subprotocol that was not offered")
+		}
+	}
+	compress := false
+	for _, ext := range headerTokens(res.Header, "Sec-WebSocket-Extensions") {
+		if !d.EnableCompression || extensionName(ext) != "permessage-deflate" || compress {
+			return fail("server selected an extension that was not offered: " + ext)
+		}
+		compress = true
+	}
+	res.Body = http.NoBody
+	return newConn(rwc, nil, false, subprotocol, compress), res, nil
+}
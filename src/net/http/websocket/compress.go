@@ -0,0 +1,90 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package websocket
+
+import (
+	"compress/flate"
+	"io"
+	"strings"
+	"sync"
+)
+
+// The permessage-deflate extension (RFC 7692) compresses each message
+// as a DEFLATE stream that ends with an empty stored block, whose
+// 4-byte marker 00 00 ff ff is removed before sending. Connections
+// negotiate no context takeover in both directions, so each message
+// is compressed independently and no state is kept between messages.
+
+// deflateExtension is the extension offer and response sent in the
+// Sec-WebSocket-Extensions header.
+const deflateExtension = "permessage-deflate; server_no_context_takeover; client_no_context_takeover"
+
+// flateTail is appended to a compressed message before inflating it:
+// the removed marker, then a final empty stored block so that the
+// reader reports io.EOF.
+const flateTail = "\x00\x00\xff\xff\x01\x00\x00\xff\xff"
+
+// payloadSink passes compressed data to a message writer.
+type payloadSink struct{ w *messageWriter }
+
+func (s payloadSink) Write(p []byte) (int, error) {
+	s.w.appendPayload(p)
+	if s.w.err != nil {
+		return 0, s.w.err
+	}
+	return len(p), nil
+}
+
+var flateWriterPool sync.Pool
+
+func getFlateWriter(w *messageWriter) *flate.Writer {
+	if fw, ok := flateWriterPool.Get().(*flate.Writer); ok {
+		fw.Reset(payloadSink{w})
+		return fw
+	}
+	fw, _ := flate.NewWriter(payloadSink{w}, flate.BestSpeed)
+	return fw
+}
+
+func putFlateWriter(fw *flate.Writer) {
+	fw.Reset(nil)
+	flateWriterPool.Put(fw)
+}
+
+var flateReaderPool sync.Pool
+
+// newFlateReader returns a reader that inflates the compressed
+// message payload read from r.
+func newFlateReader(r io.Reader) io.Reader {
+	src := io.MultiReader(r, strings.NewReader(flateTail))
+	fr, ok := flateReaderPool.Get().(io.ReadCloser)
+	if ok {
+		fr.(flate.Resetter).Reset(src, nil)
+	} else {
+		fr = flate.NewReader(src)
+	}
+	return &flateReader{fr: fr}
+}
+
+// A flateReader returns its decompressor to the pool at the end of
+// the message.
+type flateReader struct {
+	fr  io.ReadCloser
+	err error
+}
+
+func (r *flateReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	n, err := r.fr.Read(p)
+	if err != nil {
+		r.err = err
+		r.fr.Close()
+		flateReaderPool.Put(r.fr)
+		r.fr = nil
+	}
+	return n, err
+}
@@ -0,0 +1,229 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package websocket
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrBadHandshake is returned when the opening handshake fails.
+var ErrBadHandshake = errors.New("websocket: bad handshake")
+
+// acceptGUID is the GUID of RFC 6455, section 1.3.
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// computeAccept returns the Sec-WebSocket-Accept value for key.
+func computeAccept(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// An Upgrader upgrades HTTP requests to WebSocket connections.
+// The zero value accepts same-origin requests without a subprotocol
+// or compression.
+type Upgrader struct {
+	// Subprotocols lists the subprotocols the server supports, in
+	// order of preference. The first one the client also offers is
+	// selected. If none match, no subprotocol is selected.
+	Subprotocols []string
+
+	// CheckOrigin, if non-nil, reports whether to accept a request
+	// based on its Origin header. If nil, requests with an Origin
+	// header are accepted only if its host matches the request's
+	// Host header; requests without one are accepted.
+	CheckOrigin func(r *http.Request) bool
+
+	// EnableCompression enables the permessage-deflate extension
+	// when the client offers it.
+	// Connections using it have a default read limit of 16 MiB;
+	// see Conn.SetReadLimit.
+	EnableCompression bool
+}
+
+// Upgrade performs the server side of the opening handshake for r and
+// returns the resulting connection. Any responseHeader fields are
+// included in the 101 Switching Protocols response.
+//
+// If the handshake fails, Upgrade replies to the client with an HTTP
+// error and returns an error. The ResponseWriter must implement
+// http.Hijacker.
+func (u *Upgrader) Upgrade(w http.ResponseWriter, r *http.Request, responseHeader http.Header) (*Conn, error) {
+	fail := func(code int, msg string) (*Conn, error) {
+		http.Error(w, http.StatusText(code), code)
+		return nil, errors.New("websocket: " + msg)
+	}
+	if r.Method != "GET" {
+		w.Header().Set("Allow", "GET")
+		return fail(http.StatusMethodNotAllowed, "request method is not GET")
+	}
+	if !headerContainsToken(r.Header, "Connection", "upgrade") {
+		return fail(http.StatusBadRequest, "Connection header does not contain 'upgrade'")
+	}
+	if !headerContainsToken(r.Header, "Upgrade", "websocket") {
+		return fail(http.StatusBadRequest, "Upgrade header does not contain 'websocket'")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		return fail(http.StatusUpgradeRequired, "unsupported Sec-WebSocket-Version")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if b, err := base64.StdEncoding.DecodeString(key); err != nil || len(b) != 16 {
+		return fail(http.StatusBadRequest, "invalid Sec-WebSocket-Key")
+	}
+	checkOrigin := u.CheckOrigin
+	if checkOrigin == nil {
+		checkOrigin = sameOrigin
+	}
+	if !checkOrigin(r) {
+		return fail(http.StatusForbidden, "request origin not allowed")
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		return fail(http.StatusInternalServerError, "ResponseWriter does not implement http.Hijacker")
+	}
+
+	subprotocol := u.selectSubprotocol(r)
+	compress := false
+	if u.EnableCompression {
+		for _, ext := range headerTokens(r.Header, "Sec-WebSocket-Extensions") {
+			if acceptDeflateOffer(ext) {
+				compress = true
+				break
+			}
+		}
+	}
+
+	conn, brw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+	// Clear any deadlines set by the server for the HTTP request.
+	conn.SetDeadline(time.Time{})
+
+	var b strings.Builder
+	b.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+	b.WriteString("Sec-WebSocket-Accept: " + computeAccept(key) + "\r\n")
+	if subprotocol != "" {
+		b.WriteString("Sec-WebSocket-Protocol: " + subprotocol + "\r\n")
+	}
+	if compress {
+		b.WriteString("Sec-WebSocket-Extensions: " + deflateExtension + "\r\n")
+	}
+	for k, vv := range responseHeader {
+		if k == "Sec-Websocket-Protocol" || k == "Sec-Websocket-Extensions" {
+			continue
+		}
+		for _, v := range vv {
+			b.WriteString(k + ": " + strings.NewReplacer("\r", " ", "\n", " ").Replace(v) + "\r\n")
+		}
+	}
+	b.WriteString("\r\n")
+	if _, err := brw.WriteString(b.String()); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := brw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return newConn(conn, brw.Reader, true, subprotocol, compress), nil
+}
+
+func (u *Upgrader) selectSubprotocol(r *http.Request) string {
+	offered := headerTokens(r.Header, "Sec-WebSocket-Protocol")
+	for _, s := range u.Subprotocols {
+		for _, o := range offered {
+			if s == o {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+// sameOrigin reports whether r has no Origin header, or one whose host
+// matches r.Host.
+func sameOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Host, r.Host)
+}
+
+// IsWebSocketUpgrade reports whether r requests an upgrade to the
+// WebSocket protocol.
+func IsWebSocketUpgrade(r *http.Request) bool {
+	return headerContainsToken(r.Header, "Connection", "upgrade") &&
+		headerContainsToken(r.Header, "Upgrade", "websocket")
+}
+
+// headerTokens returns the comma-separated elements of the header
+// fields named key, with surrounding space trimmed.
+func headerTokens(h http.Header, key string) []string {
+	var tokens []string
+	for _, v := range h[http.CanonicalHeaderKey(key)] {
+		for _, t := range strings.Split(v, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				tokens = append(tokens, t)
+			}
+		}
+	}
+	return tokens
+}
+
+// headerContainsToken reports whether the header fields named key
+// contain token, compared case-insensitively.
+func headerContainsToken(h http.Header, key, token string) bool {
+	for _, t := range headerTokens(h, key) {
+		if strings.EqualFold(t, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptDeflateOffer reports whether the server can accept the
+// extension offer ext, an element of a Sec-WebSocket-Extensions header.
+func acceptDeflateOffer(ext string) bool {
+	params := strings.Split(ext, ";")
+	if strings.TrimSpace(params[0]) != "permessage-deflate" {
+		return false
+	}
+	for _, p := range params[1:] {
+		name := strings.TrimSpace(p)
+		if i := strings.IndexByte(name, '='); i >= 0 {
+			name = strings.TrimSpace(name[:i])
+		}
+		switch name {
+		case "server_no_context_takeover", "client_no_context_takeover", "client_max_window_bits":
+		default:
+			// In particular, compress/flate cannot limit
+			// its window with server_max_window_bits.
+			return false
+		}
+	}
+	return true
+}
+
+// extensionName returns the name of an extension, without its
+// parameters.
+func extensionName(ext string) string {
+	if i := strings.IndexByte(ext, ';'); i >= 0 {
+		ext = ext[:i]
+	}
+	return strings.TrimSpace(ext)
+}
@@ -0,0 +1,797 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package websocket implements the WebSocket protocol defined in
+// RFC 6455, with the permessage-deflate extension of RFC 7692.
+//
+// A server accepts WebSocket connections in an HTTP handler with an
+// Upgrader:
+//
+//	var upgrader websocket.Upgrader
+//
+//	func echo(w http.ResponseWriter, r *http.Request) {
+//		c, err := upgrader.Upgrade(w, r, nil)
+//		if err != nil {
+//			return // Upgrade has replied to the client
+//		}
+//		defer c.Close(websocket.StatusNormalClosure, "")
+//		for {
+//			typ, msg, err := c.ReadMessage()
+//			if err != nil {
+//				return
+//			}
+//			if err := c.WriteMessage(typ, msg); err != nil {
+//				return
+//			}
+//		}
+//	}
+//
+// A client connects with a Dialer, which performs the opening
+// handshake through an http.Transport.
+//
+// A Conn supports one concurrent reader and one concurrent writer.
+// Ping and Close may be called concurrently with either.
+package websocket
+
+import (
+	"bufio"
+	"compress/flate"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// A MessageType is the type of a data message.
+type MessageType int
+
+// The data message types.
+const (
+	TextMessage   MessageType = 1 // UTF-8 text
+	BinaryMessage MessageType = 2
+)
+
+// A StatusCode is the status code of a close frame.
+// See RFC 6455, section 7.4.
+type StatusCode int
+
+// Status codes defined by RFC 6455, section 7.4.1.
+const (
+	StatusNormalClosure           StatusCode = 1000
+	StatusGoingAway               StatusCode = 1001
+	StatusProtocolError           StatusCode = 1002
+	StatusUnsupportedData         StatusCode = 1003
+	StatusNoStatusReceived        StatusCode = 1005 // never sent
+	StatusAbnormalClosure         StatusCode = 1006 // never sent
+	StatusInvalidFramePayloadData StatusCode = 1007
+	StatusPolicyViolation         StatusCode = 1008
+	StatusMessageTooBig           StatusCode = 1009
+	StatusMandatoryExtension      StatusCode = 1010
+	StatusInternalError           StatusCode = 1011
+)
+
+// validSentCode reports whether code may appear in a close frame.
+func validSentCode(code StatusCode) bool {
+	switch {
+	case code >= 1000 && code <= 1003, code >= 1007 && code <= 1014:
+		return true
+	case code >= 3000 && code <= 4999:
+		return true
+	}
+	return false
+}
+
+// A CloseError is returned by read methods after the peer has closed
+// the connection with a close frame.
+type CloseError struct {
+	Code   StatusCode // StatusNoStatusReceived if the frame had no code
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	s := "websocket: close " + strconv.Itoa(int(e.Code))
+	if e.Reason != "" {
+		s += ": " + e.Reason
+	}
+	return s
+}
+
+var (
+	// ErrCloseSent is returned when writing to a connection
+	// after a close frame has been sent.
+	ErrCloseSent = errors.New("websocket: close sent")
+
+	// ErrReadLimit is returned when reading a message longer
+	// than the limit set by SetReadLimit.
+	ErrReadLimit = errors.New("websocket: message exceeds read limit")
+)
+
+// Frame opcodes. See RFC 6455, section 5.2.
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xa
+)
+
+const (
+	finBit  = 0x80
+	rsv1Bit = 0x40
+	rsv2Bit = 0x20
+	rsv3Bit = 0x10
+	maskBit = 0x80
+
+	maxControlPayload = 125
+	maxFramePayload   = 32 << 10 // written by message writers
+)
+
+// defaultCloseTimeout is how long Close waits for the peer's close frame.
+const defaultCloseTimeout = 5 * time.Second
+
+// defaultCompressedReadLimit is the read limit of connections using
+// permessage-deflate, on which a small frame can inflate to an
+// arbitrarily large message.
+const defaultCompressedReadLimit = 16 << 20
+
+// A Conn is a WebSocket connection.
+type Conn struct {
+	rwc         io.ReadWriteCloser
+	br          *bufio.Reader
+	isServer    bool
+	subprotocol string
+	compress    bool // permessage-deflate is in use
+
+	closeTimeout time.Duration
+
+	closeOnce sync.Once
+	closeErr  error
+
+	// msgLock is held by the current message writer.
+	msgLock chan struct{}
+
+	writeMu   sync.Mutex // guards the fields below and writes to rwc
+	writeBuf  []byte
+	closeSent bool
+
+	readMu         sync.Mutex // guards the fields below and reads from br
+	readErr        error      // sticky
+	readLimit      int64
+	frameRemaining int64 // unread payload bytes in the current data frame
+	frameFinal     bool  // the current data frame is the last of its message
+	masked         bool
+	maskKey        [4]byte
+	maskPos        int
+	reader         *messageReader // of the message being read, or nil
+	pingHandler    func([]byte) error
+	pongHandler    func([]byte) error
+}
+
+func newConn(rwc io.ReadWriteCloser, br *bufio.Reader, isServer bool, subprotocol string, compress bool) *Conn {
+	if br == nil {
+		br = bufio.NewReader(rwc)
+	}
+	c := &Conn{
+		rwc:         rwc,
+		br:          br,
+		isServer:    isServer,
+		subprotocol: subprotocol,
+		compress:    compress,
+		msgLock:     make(chan struct{}, 1),
+
+		closeTimeout: defaultCloseTimeout,
+	}
+	if compress {
+		c.readLimit = defaultCompressedReadLimit
+	}
+	return c
+}
+
+// Subprotocol returns the subprotocol negotiated during the opening
+// handshake, if any.
+func (c *Conn) Subprotocol() string { return c.subprotocol }
+
+// SetReadLimit limits the size of messages read from the peer,
+// after decompression. A message exceeding the limit fails the
+// connection with StatusMessageTooBig. Zero means no limit.
+//
+// Connections using compression start with a limit of 16 MiB, since
+// a small compressed frame can inflate to a very large message.
+// Other connections start with no limit.
+func (c *Conn) SetReadLimit(n int64) {
+	c.readMu.Lock()
+	c.readLimit = n
+	c.readMu.Unlock()
+}
+
+// SetPingHandler sets the function called with the payload of each
+// ping frame received. The default handler replies with a pong frame.
+// Handlers are called from read methods.
+func (c *Conn) SetPingHandler(h func(data []byte) error) {
+	c.readMu.Lock()
+	c.pingHandler = h
+	c.readMu.Unlock()
+}
+
+// SetPongHandler sets the function called with the payload of each
+// pong frame received. By default pongs are ignored.
+// Handlers are called from read methods.
+func (c *Conn) SetPongHandler(h func(data []byte) error) {
+	c.readMu.Lock()
+	c.pongHandler = h
+	c.readMu.Unlock()
+}
+
+// closeConn closes the underlying connection.
+func (c *Conn) closeConn() error {
+	c.closeOnce.Do(func() { c.closeErr = c.rwc.Close() })
+	return c.closeErr
+}
+
+// Writing.
+
+// writeFrame writes a single frame.
+func (c *Conn) writeFrame(final, rsv1 bool, opcode byte, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.closeSent {
+		return ErrCloseSent
+	}
+
+	b := c.writeBuf[:0]
+	b0 := opcode
+	if final {
+		b0 |= finBit
+	}
+	if rsv1 {
+		b0 |= rsv1Bit
+	}
+	var b1 byte
+	if !c.isServer {
+		b1 = maskBit
+	}
+	switch n := len(payload); {
+	case n <= 125:
+		b = append(b, b0, b1|byte(n))
+	case n <= 0xffff:
+		b = append(b, b0, b1|126, byte(n>>8), byte(n))
+	default:
+		b = append(b, b0, b1|127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(b[len(b)-8:], uint64(n))
+	}
+	if c.isServer {
+		b = append(b, payload...)
+	} else {
+		// Clients mask every frame with a fresh, unpredictable key.
+		var key [4]byte
+		if _, err := io.ReadFull(rand.Reader, key[:]); err != nil {
+			return err
+		}
+		b = append(b, key[:]...)
+		start := len(b)
+		b = append(b, payload...)
+		maskBytes(key, 0, b[start:])
+	}
+	c.writeBuf = b
+	if opcode == opClose {
+		c.closeSent = true
+	}
+	_, err := c.rwc.Write(b)
+	return err
+}
+
+// maskBytes applies the masking of RFC 6455, section 5.3, to b,
+// starting at position pos of the key, and returns the next position.
+func maskBytes(key [4]byte, pos int, b []byte) int {
+	for i := range b {
+		b[i] ^= key[pos&3]
+		pos++
+	}
+	return pos & 3
+}
+
+// writeControl writes a control frame.
+func (c *Conn) writeControl(opcode byte, payload []byte) error {
+	if len(payload) > maxControlPayload {
+		return errors.New("websocket: control frame payload too long")
+	}
+	return c.writeFrame(true, false, opcode, payload)
+}
+
+// Ping sends a ping frame with the given payload, which must be at
+// most 125 bytes. The peer's pong is passed to the pong handler.
+func (c *Conn) Ping(data []byte) error {
+	return c.writeControl(opPing, data)
+}
+
+func closePayload(code StatusCode, reason string) []byte {
+	if code == StatusNoStatusReceived {
+		return nil
+	}
+	p := make([]byte, 2+len(reason))
+	binary.BigEndian.PutUint16(p, uint16(code))
+	copy(p[2:], reason)
+	return p
+}
+
+// NextWriter returns a writer for the next message of the given type.
+// The message is sent in one or more frames as it is written, and is
+// complete when the writer is closed. NextWriter blocks until the
+// writer of the previous message has been closed.
+func (c *Conn) NextWriter(typ MessageType) (io.WriteCloser, error) {
+	if typ != TextMessage && typ != BinaryMessage {
+		return nil, errors.New("websocket: invalid message type")
+	}
+	c.msgLock <- struct{}{}
+	w := &messageWriter{c: c, opcode: byte(typ), compress: c.compress}
+	if w.compress {
+		w.fw = getFlateWriter(w)
+	}
+	return w, nil
+}
+
+// WriteMessage writes a complete message.
+func (c *Conn) WriteMessage(typ MessageType, data []byte) error {
+	w, err := c.NextWriter(typ)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// A messageWriter writes a message as a series of frames.
+type messageWriter struct {
+	c        *Conn
+	opcode   byte // of the next frame
+	buf      []byte
+	compress bool
+	fw       *flate.Writer
+	err      error
+	closed   bool
+}
+
+// holdback is the number of buffered bytes a compressing writer
+// keeps back, so that the final flush marker can be removed.
+func (w *messageWriter) holdback() int {
+	if w.compress {
+		return 4
+	}
+	return 0
+}
+
+// appendPayload is called with the payload to send.
+func (w *messageWriter) appendPayload(p []byte) {
+	w.buf = append(w.buf, p...)
+	for w.err == nil && len(w.buf)-w.holdback() >= maxFramePayload {
+		w.flushFrame(false, w.buf[:maxFramePayload])
+		w.buf = w.buf[:copy(w.buf, w.buf[maxFramePayload:])]
+	}
+}
+
+func (w *messageWriter) flushFrame(final bool, p []byte) {
+	// Only the first frame of a message carries RSV1.
+	rsv1 := w.compress && w.opcode != opContinuation
+	w.err = w.c.writeFrame(final, rsv1, w.opcode, p)
+	w.opcode = opContinuation
+}
+
+func (w *messageWriter) Write(p []byte) (int, error) {
+	if w.closed {
+		return 0, errors.New("websocket: write to closed message writer")
+	}
+	if w.err != nil {
+		return 0, w.err
+	}
+	if w.compress {
+		if _, err := w.fw.Write(p); err != nil {
+			return 0, err
+		}
+	} else {
+		w.appendPayload(p)
+	}
+	if w.err != nil {
+		return 0, w.err
+	}
+	return len(p), nil
+}
+
+// Close sends the last frame of the message.
+func (w *messageWriter) Close() error {
+	if w.closed {
+		return w.err
+	}
+	w.closed = true
+	defer func() { <-w.c.msgLock }()
+	if w.compress {
+		err := w.fw.Flush()
+		putFlateWriter(w.fw)
+		w.fw = nil
+		if err != nil {
+			return err
+		}
+		// Remove the empty block's marker; see RFC 7692, section 7.2.1.
+		w.buf = w.buf[:len(w.buf)-4]
+	}
+	if w.err == nil {
+		w.flushFrame(true, w.buf)
+	}
+	return w.err
+}
+
+// Reading.
+
+// failError is a connection failure detected while reading.
+type failError struct {
+	code StatusCode
+	msg  string
+}
+
+func (e *failError) Error() string { return "websocket: " + e.msg }
+
+// fail fails the connection, as described in RFC 6455, section 7.1.7.
+// c.readMu must be held.
+func (c *Conn) fail(code StatusCode, msg string) error {
+	err := &failError{code, msg}
+	c.writeControl(opClose, closePayload(code, msg))
+	c.closeConn()
+	c.readErr = err
+	return err
+}
+
+// setReadErr records a read error, which is returned by all
+// subsequent reads. c.readMu must be held.
+func (c *Conn) setReadErr(err error) error {
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if c.readErr == nil {
+		c.readErr = err
+	}
+	return c.readErr
+}
+
+// readFrameHeader reads the header of the next frame.
+// c.readMu must be held.
+func (c *Conn) readFrameHeader() (opcode byte, final, rsv1 bool, length int64, err error) {
+	var h [14]byte
+	if _, err := io.ReadFull(c.br, h[:2]); err != nil {
+		return 0, false, false, 0, c.setReadErr(err)
+	}
+	final = h[0]&finBit != 0
+	rsv1 = h[0]&rsv1Bit != 0
+	opcode = h[0] & 0xf
+	masked := h[1]&maskBit != 0
+	length = int64(h[1] & 0x7f)
+
+	switch opcode {
+	case opContinuation, opText, opBinary:
+		if rsv1 && (!c.compress || opcode == opContinuation) {
+			return 0, false, false, 0, c.fail(StatusProtocolError, "unexpected RSV1 bit")
+		}
+	case opClose, opPing, opPong:
+		if !final || rsv1 || length > maxControlPayload {
+			return 0, false, false, 0, c.fail(StatusProtocolError, "invalid control frame")
+		}
+	default:
+		return 0, false, false, 0, c.fail(StatusProtocolError, "unknown opcode "+strconv.Itoa(int(opcode)))
+	}
+	if h[0]&(rsv2Bit|rsv3Bit) != 0 {
+		return 0, false, false, 0, c.fail(StatusProtocolError, "unexpected reserved bits")
+	}
+	if masked != c.isServer {
+		return 0, false, false, 0, c.fail(StatusProtocolError, "bad frame masking")
+	}
+
+	switch length {
+	case 126:
+		if _, err := io.ReadFull(c.br, h[:2]); err != nil {
+			return 0, false, false, 0, c.setReadErr(err)
+		}
+		length = int64(binary.BigEndian.Uint16(h[:2]))
+	case 127:
+		if _, err := io.ReadFull(c.br, h[:8]); err != nil {
+			return 0, false, false, 0, c.setReadErr(err)
+		}
+		n := binary.BigEndian.Uint64(h[:8])
+		if n>>63 != 0 {
+			return 0, false, false, 0, c.fail(StatusProtocolError, "invalid frame length")
+		}
+		length = int64(n)
+	}
+	c.masked = masked
+	c.maskPos = 0
+	if masked {
+		if _, err := io.ReadFull(c.br, c.maskKey[:]); err != nil {
+			return 0, false, false, 0, c.setReadErr(err)
+		}
+	}
+	return opcode, final, rsv1, length, nil
+}
+
+// nextDataFrame reads frames until the header of a data frame,
+// handling any control frames that precede it.
+// c.readMu must be held.
+func (c *Conn) nextDataFrame() (opcode byte, rsv1 bool, err error) {
+	for {
+		if c.readErr != nil {
+			return 0, false, c.readErr
+		}
+		opcode, final, rsv1, length, err := c.readFrameHeader()
+		if err != nil {
+			return 0, false, err
+		}
+		if opcode < opClose {
+			c.frameRemaining = length
+			c.frameFinal = final
+			return opcode, rsv1, nil
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(c.br, payload); err != nil {
+			return 0, false, c.setReadErr(err)
+		}
+		if c.masked {
+			maskBytes(c.maskKey, 0, payload)
+		}
+		if err := c.handleControl(opcode, payload); err != nil {
+			return 0, false, err
+		}
+	}
+}
+
+// handleControl processes a control frame. c.readMu must be held.
+func (c *Conn) handleControl(opcode byte, payload []byte) error {
+	switch opcode {
+	case opPing:
+		if c.pingHandler != nil {
+			return c.pingHandler(payload)
+		}
+		if err := c.writeControl(opPong, payload); err != nil && err != ErrCloseSent {
+			return c.setReadErr(err)
+		}
+	case opPong:
+		if c.pongHandler != nil {
+			return c.pongHandler(payload)
+		}
+	case opClose:
+		ce := &CloseError{Code: StatusNoStatusReceived}
+		switch {
+		case len(payload) == 1:
+			return c.fail(StatusProtocolError, "invalid close payload")
+		case len(payload) >= 2:
+			ce.Code = StatusCode(binary.BigEndian.Uint16(payload))
+			ce.Reason = string(payload[2:])
+			if !validSentCode(ce.Code) {
+				return c.fail(StatusProtocolError, "invalid close code")
+			}
+			if !utf8.ValidString(ce.Reason) {
+				return c.fail(StatusInvalidFramePayloadData, "invalid UTF-8 in close reason")
+			}
+		}
+		// Echo the close frame, unless we started the closing
+		// handshake, then close the connection.
+		c.writeControl(opClose, closePayload(ce.Code, ""))
+		c.closeConn()
+		c.readErr = ce
+		return ce
+	}
+	return nil
+}
+
+// NextReader returns the type of the next data message and a reader
+// for its payload. Any unread part of the previous message is
+// discarded. Control frames are processed as they arrive.
+//
+// After the peer closes the connection, NextReader returns a
+// *CloseError.
+func (c *Conn) NextReader() (MessageType, io.Reader, error) {
+	c.readMu.Lock()
+	prev := c.reader
+	c.readMu.Unlock()
+	if prev != nil {
+		io.Copy(ioutil.Discard, prev)
+	}
+
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
+	opcode, compressed, err := c.nextDataFrame()
+	if err != nil {
+		return 0, nil, err
+	}
+	if opcode == opContinuation {
+		return 0, nil, c.fail(StatusProtocolError, "unexpected continuation frame")
+	}
+	typ := MessageType(opcode)
+	raw := &messageReader{c: c}
+	c.reader = raw
+	var r io.Reader = raw
+	if compressed {
+		r = newFlateReader(raw)
+	}
+	return typ, &checkReader{c: c, r: r, text: typ == TextMessage, compressed: compressed}, nil
+}
+
+// ReadMessage reads the next data message.
+func (c *Conn) ReadMessage() (MessageType, []byte, error) {
+	typ, r, err := c.NextReader()
+	if err != nil {
+		return 0, nil, err
+	}
+	p, err := ioutil.ReadAll(r)
+	return typ, p, err
+}
+
+// A messageReader reads the raw payload of a message, across frames.
+type messageReader struct {
+	c   *Conn
+	eof bool
+}
+
+func (r *messageReader) Read(p []byte) (int, error) {
+	c := r.c
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
+	if r.eof {
+		return 0, io.EOF
+	}
+	if c.reader != r {
+		return 0, errors.New("websocket: read from superseded message reader")
+	}
+	for c.frameRemaining == 0 {
+		if c.frameFinal {
+			r.eof = true
+			c.reader = nil
+			return 0, io.EOF
+		}
+		opcode, _, err := c.nextDataFrame()
+		if err != nil {
+			return 0, err
+		}
+		if opcode != opContinuation {
+			return 0, c.fail(StatusProtocolError, "expected continuation frame")
+		}
+	}
+	if int64(len(p)) > c.frameRemaining {
+		p = p[:c.frameRemaining]
+	}
+	n, err := c.br.Read(p)
+	if c.masked {
+		c.maskPos = maskBytes(c.maskKey, c.maskPos, p[:n])
+	}
+	c.frameRemaining -= int64(n)
+	if err != nil {
+		return n, c.setReadErr(err)
+	}
+	return n, nil
+}
+
+// A checkReader enforces the read limit and UTF-8 validity of a
+// message's (decompressed) payload.
+type checkReader struct {
+	c          *Conn
+	r          io.Reader
+	text       bool
+	compressed bool
+	n          int64
+	v          utf8Validator
+}
+
+func (r *checkReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+
+	c := r.c
+	c.readMu.Lock()
+	defer c.readMu.Unlock()
+	if c.readLimit > 0 && r.n > c.readLimit {
+		c.fail(StatusMessageTooBig, "message too big")
+		return 0, ErrReadLimit
+	}
+	if r.text && (!r.v.valid(p[:n]) || err == io.EOF && !r.v.done()) {
+		return 0, c.fail(StatusInvalidFramePayloadData, "invalid UTF-8 in text message")
+	}
+	if err != nil && err != io.EOF && r.compressed && c.readErr == nil {
+		return n, c.fail(StatusInvalidFramePayloadData, "invalid compressed data")
+	}
+	return n, err
+}
+
+// utf8Validator checks that a byte stream is valid UTF-8, possibly
+// with runes split across writes.
+type utf8Validator struct {
+	buf [utf8.UTFMax]byte // an incomplete rune
+	n   int
+}
+
+func (v *utf8Validator) valid(p []byte) bool {
+	for v.n > 0 && len(p) > 0 {
+		v.buf[v.n] = p[0]
+		v.n++
+		p = p[1:]
+		if utf8.FullRune(v.buf[:v.n]) {
+			if r, size := utf8.DecodeRune(v.buf[:v.n]); r == utf8.RuneError && size <= 1 {
+				return false
+			}
+			v.n = 0
+		}
+	}
+	if v.n > 0 {
+		return true // p was part of an incomplete rune
+	}
+	// Keep back an incomplete rune at the end of p.
+	end := len(p)
+	for i := len(p) - 1; i >= 0 && i >= len(p)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(p[i]) {
+			if !utf8.FullRune(p[i:]) {
+				end = i
+			}
+			break
+		}
+	}
+	if !utf8.Valid(p[:end]) {
+		return false
+	}
+	v.n = copy(v.buf[:], p[end:])
+	return true
+}
+
+// done reports whether the stream ended at a rune boundary.
+func (v *utf8Validator) done() bool { return v.n == 0 }
+
+// Closing.
+
+// Close performs the closing handshake: it sends a close frame with
+// the given code and reason, waits briefly for the peer's close frame,
+// and closes the underlying connection. Use StatusNoStatusReceived to
+// send a close frame without a code.
+//
+// Data messages that arrive while Close waits are discarded.
+func (c *Conn) Close(code StatusCode, reason string) error {
+	if code != StatusNoStatusReceived && !validSentCode(code) {
+		return errors.New("websocket: invalid close code")
+	}
+	if len(reason) > maxControlPayload-2 {
+		return errors.New("websocket: close reason too long")
+	}
+	err := c.writeControl(opClose, closePayload(code, reason))
+	if err == ErrCloseSent {
+		err = nil
+	}
+
+	// The peer's close frame might be read by a concurrent reader,
+	// or by us below. Either way the connection is closed once it
+	// arrives, or after c.closeTimeout.
+	t := time.AfterFunc(c.closeTimeout, func() { c.closeConn() })
+	defer t.Stop()
+	for {
+		c.readMu.Lock()
+		if c.readErr != nil {
+			c.readMu.Unlock()
+			break
+		}
+		c.reader = nil
+		_, _, rerr := c.nextDataFrame()
+		if rerr == nil {
+			_, rerr = io.CopyN(ioutil.Discard, c.br, c.frameRemaining)
+			if rerr != nil {
+				c.setReadErr(rerr)
+			}
+			c.frameRemaining = 0
+		}
+		c.readMu.Unlock()
+	}
+	if cerr := c.closeConn(); err == nil {
+		err = cerr
+	}
+	return err
+}
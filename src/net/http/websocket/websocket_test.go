@@ -0,0 +1,418 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package websocket
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newServer starts a server that upgrades every request with u and
+// passes the connection to handler. It returns the server and its
+// ws: URL.
+func newServer(t *testing.T, u *Upgrader, handler func(*Conn)) (*httptest.Server, string) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := u.Upgrade(w, r, http.Header{"X-Test": {"yes"}})
+		if err != nil {
+			t.Logf("Upgrade: %v", err)
+			return
+		}
+		handler(c)
+	}))
+	return ts, "ws" + strings.TrimPrefix(ts.URL, "http")
+}
+
+func echo(c *Conn) {
+	defer c.Close(StatusNormalClosure, "")
+	for {
+		typ, r, err := c.NextReader()
+		if err != nil {
+			return
+		}
+		w, err := c.NextWriter(typ)
+		if err != nil {
+			return
+		}
+		if _, err := io.Copy(w, r); err != nil {
+			return
+		}
+		if err := w.Close(); err != nil {
+			return
+		}
+	}
+}
+
+func TestEcho(t *testing.T) {
+	big := bytes.Repeat([]byte("0123456789abcdef"), 10000) // several frames
+	for _, compress := range []bool{false, true} {
+		ts, url := newServer(t, &Upgrader{EnableCompression: true}, echo)
+		defer ts.Close()
+
+		d := &Dialer{EnableCompression: compress}
+		c, res, err := d.Dial(context.Background(), url)
+		if err != nil {
+			t.Fatalf("compress=%v: Dial: %v", compress, err)
+		}
+		if got := res.Header.Get("X-Test"); got != "yes" {
+			t.Errorf("compress=%v: X-Test = %q; want yes", compress, got)
+		}
+		if c.compress != compress {
+			t.Errorf("compress=%v: negotiated compression = %v", compress, c.compress)
+		}
+		msgs := []struct {
+			typ  MessageType
+			data []byte
+		}{
+			{TextMessage, []byte("hello, world")},
+			{BinaryMessage, []byte{0, 1, 2, 0xff}},
+			{TextMessage, nil},
+			{BinaryMessage, big},
+			{TextMessage, []byte("héllo, 世界")},
+		}
+		for _, m := range msgs {
+			if err := c.WriteMessage(m.typ, m.data); err != nil {
+				t.Fatalf("compress=%v: WriteMessage: %v", compress, err)
+			}
+			typ, data, err := c.ReadMessage()
+			if err != nil {
+				t.Fatalf("compress=%v: ReadMessage: %v", compress, err)
+			}
+			if typ != m.typ || !bytes.Equal(data, m.data) {
+				t.Errorf("compress=%v: got message type %d of %d bytes; want type %d of %d bytes", compress, typ, len(data), m.typ, len(m.data))
+			}
+		}
+		if err := c.Close(StatusNormalClosure, ""); err != nil {
+			t.Errorf("compress=%v: Close: %v", compress, err)
+		}
+	}
+}
+
+func TestFragmentedMessage(t *testing.T) {
+	ts, url := newServer(t, &Upgrader{}, echo)
+	defer ts.Close()
+	c, _, err := Dial(context.Background(), url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close(StatusNormalClosure, "")
+
+	// Write a message in pieces that split a multi-byte rune,
+	// interleaved with a ping the server must answer.
+	w, err := c.NextWriter(TextMessage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "a€b" + strings.Repeat("x", 40000) + "€"
+	parts := []string{want[:2], want[2:3], want[3:20000], want[20000:]}
+	for i, p := range parts {
+		if _, err := io.WriteString(w, p); err != nil {
+			t.Fatal(err)
+		}
+		if i == 1 {
+			// Control frames may be interleaved with the
+			// frames of a message.
+			if err := c.Ping([]byte("mid")); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	typ, data, err := c.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if typ != TextMessage || string(data) != want {
+		t.Errorf("got type %d, %d bytes; want type %d, %d bytes", typ, len(data), TextMessage, len(want))
+	}
+}
+
+func TestPingPong(t *testing.T) {
+	ts, url := newServer(t, &Upgrader{}, echo)
+	defer ts.Close()
+	c, _, err := Dial(context.Background(), url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close(StatusNormalClosure, "")
+
+	pongs := make(chan string, 1)
+	c.SetPongHandler(func(data []byte) error {
+		pongs <- string(data)
+		return nil
+	})
+	if err := c.Ping([]byte("are you there")); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Ping(bytes.Repeat([]byte("x"), 126)); err == nil {
+		t.Error("Ping with 126-byte payload succeeded")
+	}
+	// The pong is handled by the read for the next message.
+	if err := c.WriteMessage(TextMessage, []byte("hi")); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := c.ReadMessage(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case got := <-pongs:
+		if got != "are you there" {
+			t.Errorf("pong payload = %q", got)
+		}
+	default:
+		t.Error("pong handler not called")
+	}
+}
+
+func TestCloseHandshake(t *testing.T) {
+	errc := make(chan error, 1)
+	ts, url := newServer(t, &Upgrader{}, func(c *Conn) {
+		_, _, err := c.ReadMessage()
+		errc <- err
+		_, _, err = c.ReadMessage()
+		errc <- err
+	})
+	defer ts.Close()
+	c, _, err := Dial(context.Background(), url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Close(StatusGoingAway, "bye"); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	err = <-errc
+	ce, ok := err.(*CloseError)
+	if !ok || ce.Code != StatusGoingAway || ce.Reason != "bye" {
+		t.Errorf("server read error = %v; want close 1001 with reason bye", err)
+	}
+	if err := <-errc; err != ce {
+		t.Errorf("second server read error = %v; want %v", err, ce)
+	}
+	if err := c.WriteMessage(TextMessage, []byte("x")); err != ErrCloseSent {
+		t.Errorf("WriteMessage after Close = %v; want ErrCloseSent", err)
+	}
+}
+
+func TestCloseTimeout(t *testing.T) {
+	release := make(chan bool)
+	ts, url := newServer(t, &Upgrader{}, func(c *Conn) {
+		<-release // never reads the close frame
+	})
+	defer ts.Close()
+	defer close(release)
+	c, _, err := Dial(context.Background(), url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.closeTimeout = 50 * time.Millisecond
+	done := make(chan error, 1)
+	go func() { done <- c.Close(StatusNormalClosure, "") }()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close did not time out waiting for the peer")
+	}
+}
+
+func TestSubprotocol(t *testing.T) {
+	ts, url := newServer(t, &Upgrader{Subprotocols: []string{"v2", "v1"}}, echo)
+	defer ts.Close()
+	for _, tt := range []struct {
+		offer []string
+		want  string
+	}{
+		{nil, ""},
+		{[]string{"v1"}, "v1"},
+		{[]string{"v1", "v2"}, "v2"},
+		{[]string{"v3"}, ""},
+	} {
+		d := &Dialer{Subprotocols: tt.offer}
+		c, _, err := d.Dial(context.Background(), url)
+		if err != nil {
+			t.Fatalf("offer %q: %v", tt.offer, err)
+		}
+		if got := c.Subprotocol(); got != tt.want {
+			t.Errorf("offer %q: Subprotocol = %q; want %q", tt.offer, got, tt.want)
+		}
+		c.Close(StatusNormalClosure, "")
+	}
+}
+
+func TestUpgradeErrors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var u Upgrader
+		if _, err := u.Upgrade(w, r, nil); err == nil {
+			t.Errorf("%s %v: Upgrade succeeded", r.Method, r.Header)
+		}
+	}))
+	defer ts.Close()
+
+	valid := func() http.Header {
+		return http.Header{
+			"Connection":            {"keep-alive, Upgrade"},
+			"Upgrade":               {"websocket"},
+			"Sec-Websocket-Version": {"13"},
+			"Sec-Websocket-Key":     {"dGhlIHNhbXBsZSBub25jZQ=="},
+			"Origin":                {"http://evil.example"},
+		}
+	}
+	tests := []struct {
+		name   string
+		method string
+		modify func(http.Header)
+		code   int
+	}{
+		{"method", "POST", func(h http.Header) { h.Del("Origin") }, http.StatusMethodNotAllowed},
+		{"connection", "GET", func(h http.Header) { h.Del("Connection") }, http.StatusBadRequest},
+		{"upgrade", "GET", func(h http.Header) { h.Set("Upgrade", "h2c") }, http.StatusBadRequest},
+		{"version", "GET", func(h http.Header) { h.Set("Sec-WebSocket-Version", "8") }, http.StatusUpgradeRequired},
+		{"key", "GET", func(h http.Header) { h.Set("Sec-WebSocket-Key", "short") }, http.StatusBadRequest},
+		{"origin", "GET", func(h http.Header) {}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest(tt.method, ts.URL, nil)
+		req.Header = valid()
+		tt.modify(req.Header)
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		res.Body.Close()
+		if res.StatusCode != tt.code {
+			t.Errorf("%s: status = %d; want %d", tt.name, res.StatusCode, tt.code)
+		}
+	}
+}
+
+func TestDialBadHandshake(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no websockets here", http.StatusNotFound)
+	}))
+	defer ts.Close()
+	_, res, err := Dial(context.Background(), "ws"+strings.TrimPrefix(ts.URL, "http"))
+	if err != ErrBadHandshake {
+		t.Fatalf("Dial error = %v; want ErrBadHandshake", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("status = %d; want 404", res.StatusCode)
+	}
+	if _, _, err := Dial(context.Background(), ts.URL); err == nil {
+		t.Error("Dial with http: URL succeeded")
+	}
+}
+
+func TestInvalidUTF8(t *testing.T) {
+	errc := make(chan error, 1)
+	ts, url := newServer(t, &Upgrader{}, func(c *Conn) {
+		_, _, err := c.ReadMessage()
+		errc <- err
+	})
+	defer ts.Close()
+	c, _, err := Dial(context.Background(), url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close(StatusNormalClosure, "")
+	if err := c.WriteMessage(TextMessage, []byte("ok\xff")); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errc; err == nil {
+		t.Fatal("server read invalid UTF-8 without error")
+	}
+	_, _, err = c.ReadMessage()
+	if ce, ok := err.(*CloseError); !ok || ce.Code != StatusInvalidFramePayloadData {
+		t.Errorf("client read error = %v; want close %d", err, StatusInvalidFramePayloadData)
+	}
+}
+
+func TestReadLimit(t *testing.T) {
+	errc := make(chan error, 1)
+	ts, url := newServer(t, &Upgrader{EnableCompression: true}, func(c *Conn) {
+		c.SetReadLimit(1000)
+		_, _, err := c.ReadMessage()
+		errc <- err
+	})
+	defer ts.Close()
+	d := &Dialer{EnableCompression: true}
+	c, _, err := d.Dial(context.Background(), url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close(StatusNormalClosure, "")
+	// Compresses to well under the limit.
+	if err := c.WriteMessage(BinaryMessage, make([]byte, 5000)); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errc; err != ErrReadLimit {
+		t.Errorf("server read error = %v; want ErrReadLimit", err)
+	}
+	_, _, err = c.ReadMessage()
+	if ce, ok := err.(*CloseError); !ok || ce.Code != StatusMessageTooBig {
+		t.Errorf("client read error = %v; want close %d", err, StatusMessageTooBig)
+	}
+}
+
+func TestDefaultCompressedReadLimit(t *testing.T) {
+	errc := make(chan error, 1)
+	ts, url := newServer(t, &Upgrader{EnableCompression: true}, func(c *Conn) {
+		_, _, err := c.ReadMessage()
+		errc <- err
+	})
+	defer ts.Close()
+	d := &Dialer{EnableCompression: true}
+	c, _, err := d.Dial(context.Background(), url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close(StatusNormalClosure, "")
+	if err := c.WriteMessage(BinaryMessage, make([]byte, defaultCompressedReadLimit+1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errc; err != ErrReadLimit {
+		t.Errorf("server read error = %v; want ErrReadLimit", err)
+	}
+}
+
+func TestUTF8Validator(t *testing.T) {
+	tests := []struct {
+		parts []string
+		ok    bool
+	}{
+		{[]string{"hello"}, true},
+		{[]string{"\xe2", "\x82\xac"}, true},
+		{[]string{"a\xe2\x82", "\xac", "b"}, true},
+		{[]string{"\xf0\x9f", "", "\x98", "\x80"}, true},
+		{[]string{"\xe2\x82"}, false}, // incomplete at end
+		{[]string{"\xff"}, false},
+		{[]string{"\xe2", "a"}, false},
+		{[]string{"\xed\xa0\x80"}, false}, // surrogate
+	}
+	for _, tt := range tests {
+		var v utf8Validator
+		ok := true
+		for _, p := range tt.parts {
+			ok = ok && v.valid([]byte(p))
+		}
+		ok = ok && v.done()
+		if ok != tt.ok {
+			t.Errorf("%q: valid = %v; want %v", tt.parts, ok, tt.ok)
+		}
+	}
+}
+
+func TestComputeAccept(t *testing.T) {
+	// The example from RFC 6455, section 1.3.
+	if got, want := computeAccept("dGhlIHNhbXBsZSBub25jZQ=="), "s3pPLMBiTxaQ9kYGzzhZRbK+xOo="; got != want {
+		t.Errorf("computeAccept = %q; want %q", got, want)
+	}
+}
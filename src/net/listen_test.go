@@ -7,6 +7,8 @@
 package net
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"internal/testenv"
 	"os"
@@ -729,3 +731,143 @@ func TestClosingListener(t *testing.T) {
 	}
 	ln2.Close()
 }
+
+func TestListenConfigControl(t *testing.T) {
+	switch runtime.GOOS {
+	case "nacl", "plan9":
+		t.Skipf("not supported on %s", runtime.GOOS)
+	}
+
+	t.Run("StreamListen", func(t *testing.T) {
+		for _, network := range []string{"tcp", "tcp4", "tcp6", "unix", "unixpacket"} {
+			if !testableNetwork(network) {
+				continue
+			}
+			var address string
+			switch network {
+			case "tcp", "tcp4":
+				address = "127.0.0.1:0"
+				if !supportsIPv4() {
+					continue
+				}
+			case "tcp6":
+				address = "[::1]:0"
+				if !supportsIPv6() {
+					continue
+				}
+			default:
+				address = testUnixAddr()
+				defer os.Remove(address)
+			}
+			var gotNetwork string
+			var operr error
+			lc := ListenConfig{Control: func(network, address string, c syscall.RawConn) error {
+				gotNetwork = network
+				if err := c.Control(func(uintptr) {}); err != nil {
+					operr = err
+				}
+				return nil
+			}}
+			ln, err := lc.Listen(context.Background(), network, address)
+			if err != nil {
+				t.Error(err)
+				continue
+			}
+			ln.Close()
+			if operr != nil {
+				t.Errorf("%s: %v", network, operr)
+			}
+			if !validControlNetwork(network, gotNetwork) {
+				t.Errorf("%s: got network %q", network, gotNetwork)
+			}
+		}
+	})
+	t.Run("PacketListen", func(t *testing.T) {
+		for _, network := range []string{"udp", "udp4", "udp6", "unixgram"} {
+			if !testableNetwork(network) {
+				continue
+			}
+			var address string
+			switch network {
+			case "udp", "udp4":
+				address = "127.0.0.1:0"
+				if !supportsIPv4() {
+					continue
+				}
+			case "udp6":
+				address = "[::1]:0"
+				if !supportsIPv6() {
+					continue
+				}
+			default:
+				address = testUnixAddr()
+				defer os.Remove(address)
+			}
+			var gotNetwork string
+			lc := ListenConfig{Control: func(network, address string, c syscall.RawConn) error {
+				gotNetwork = network
+				return nil
+			}}
+			c, err := lc.ListenPacket(context.Background(), network, address)
+			if err != nil {
+				t.Error(err)
+				continue
+			}
+			c.Close()
+			if !validControlNetwork(network, gotNetwork) {
+				t.Errorf("%s: got network %q", network, gotNetwork)
+			}
+		}
+	})
+	t.Run("Error", func(t *testing.T) {
+		errControl := errors.New("control failed")
+		lc := ListenConfig{Control: func(string, string, syscall.RawConn) error { return errControl }}
+		ln, err := lc.Listen(context.Background(), "tcp", "127.0.0.1:0")
+		if err == nil {
+			ln.Close()
+			t.Fatal("Listen succeeded; want error")
+		}
+		if perr, ok := err.(*OpError); !ok || perr.Err != errControl {
+			t.Errorf("got %v; want OpError wrapping %v", err, errControl)
+		}
+	})
+}
+
+func TestListenConfigKeepAlive(t *testing.T) {
+	defer func() { testHookSetKeepAlive = func() {} }()
+
+	for _, keepAlive := range []bool{false, true} {
+		var lc ListenConfig
+		if keepAlive {
+			lc.KeepAlive = 30 * time.Second
+		}
+		ln, err := lc.Listen(context.Background(), "tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got bool
+		testHookSetKeepAlive = func() { got = true }
+		done := make(chan error)
+		go func() {
+			c, err := ln.Accept()
+			if err == nil {
+				c.Close()
+			}
+			done <- err
+		}()
+		c, err := Dial("tcp", ln.Addr().String())
+		if err != nil {
+			ln.Close()
+			t.Fatal(err)
+		}
+		c.Close()
+		err = <-done
+		ln.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != keepAlive {
+			t.Errorf("ListenConfig.KeepAlive = %v: SetKeepAlive called = %v, want %v", lc.KeepAlive, got, !got)
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	encodingPkg "encoding"
 	"encoding/gob"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -1615,6 +1616,16 @@ func TestURLErrorImplementsNetError(t *testing.T) {
 	}
 }
 
+func TestURLErrorUnwrap(t *testing.T) {
+	err := &Error{"Get", "http://google.com/", io.EOF}
+	if got := errors.Unwrap(err); got != io.EOF {
+		t.Errorf("errors.Unwrap(%v) = %v, want io.EOF", err, got)
+	}
+	if !errors.Is(fmt.Errorf("fetching: %w", err), io.EOF) {
+		t.Errorf("errors.Is(wrapped %v, io.EOF) = false, want true", err)
+	}
+}
+
 func TestURLHostname(t *testing.T) {
 	tests := []struct {
 		host string // URL.Host field
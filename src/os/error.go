@@ -6,16 +6,20 @@ package os
 
 import (
 	"errors"
+	"internal/oserror"
 	"internal/poll"
 )
 
 // Portable analogs of some common system call errors.
+//
+// Errors returned from this package may be tested against these errors
+// with errors.Is.
 var (
-	ErrInvalid    = errors.New("invalid argument") // methods on File will return this error when the receiver is nil
-	ErrPermission = errors.New("permission denied")
-	ErrExist      = errors.New("file already exists")
-	ErrNotExist   = errors.New("file does not exist")
-	ErrClosed     = errors.New("file already closed")
+	ErrInvalid    = oserror.ErrInvalid // methods on File will return this error when the receiver is nil
+	ErrPermission = oserror.ErrPermission
+	ErrExist      = oserror.ErrExist
+	ErrNotExist   = oserror.ErrNotExist
+	ErrClosed     = oserror.ErrClosed
 	ErrNoDeadline = poll.ErrNoDeadline
 )
 
@@ -32,6 +36,8 @@ type PathError struct {
 
 func (e *PathError) Error() string { return e.Op + " " + e.Path + ": " + e.Err.Error() }
 
+func (e *PathError) Unwrap() error { return e.Err }
+
 // Timeout reports whether this error represents a timeout.
 func (e *PathError) Timeout() bool {
 	t, ok := e.Err.(timeout)
@@ -46,6 +52,8 @@ type SyscallError struct {
 
 func (e *SyscallError) Error() string { return e.Syscall + ": " + e.Err.Error() }
 
+func (e *SyscallError) Unwrap() error { return e.Err }
+
 // Timeout reports whether this error represents a timeout.
 func (e *SyscallError) Timeout() bool {
 	t, ok := e.Err.(timeout)
@@ -90,15 +98,15 @@ func IsTimeout(err error) bool {
 	return ok && terr.Timeout()
 }
 
-// underlyingError returns the underlying error for known os error types.
+// underlyingError returns the innermost error in err's chain. The os error
+// types and any other error with an Unwrap method are unwrapped, so the
+// predicates above see through errors wrapped by callers.
 func underlyingError(err error) error {
-	switch err := err.(type) {
-	case *PathError:
-		return err.Err
-	case *LinkError:
-		return err.Err
-	case *SyscallError:
-		return err.Err
+	for {
+		u := errors.Unwrap(err)
+		if u == nil {
+			return err
+		}
+		err = u
 	}
-	return err
 }
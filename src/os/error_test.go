@@ -5,6 +5,7 @@
 package os_test
 
 import (
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -99,6 +100,8 @@ var isExistTests = []isExistTest{
 	{&os.LinkError{Err: os.ErrClosed}, false, false},
 	{&os.SyscallError{Err: os.ErrNotExist}, false, true},
 	{&os.SyscallError{Err: os.ErrExist}, true, false},
+	{fmt.Errorf("wrapped: %w", &os.PathError{Err: os.ErrNotExist}), false, true},
+	{fmt.Errorf("wrapped: %w", fmt.Errorf("twice: %w", os.ErrExist)), true, false},
 	{nil, false, false},
 }
 
@@ -122,6 +125,7 @@ var isPermissionTests = []isPermissionTest{
 	{nil, false},
 	{&os.PathError{Err: os.ErrPermission}, true},
 	{&os.SyscallError{Err: os.ErrPermission}, true},
+	{fmt.Errorf("wrapped: %w", &os.PathError{Err: os.ErrPermission}), true},
 }
 
 func TestIsPermission(t *testing.T) {
@@ -132,6 +136,24 @@ func TestIsPermission(t *testing.T) {
 	}
 }
 
+func TestErrorsIs(t *testing.T) {
+	_, err := os.Open(filepath.Join(os.TempDir(), "_Go_ErrorsIs_does_not_exist"))
+	if err == nil {
+		t.Fatal("Open should have failed")
+	}
+	wrapped := fmt.Errorf("open config: %w", err)
+	if !errors.Is(wrapped, os.ErrNotExist) {
+		t.Errorf("errors.Is(%v, os.ErrNotExist) = false, want true", wrapped)
+	}
+	if !os.IsNotExist(wrapped) {
+		t.Errorf("os.IsNotExist(%v) = false, want true", wrapped)
+	}
+	var perr *os.PathError
+	if !errors.As(wrapped, &perr) || perr != err {
+		t.Errorf("errors.As(%v, &perr) did not find the *os.PathError", wrapped)
+	}
+}
+
 func TestErrPathNUL(t *testing.T) {
 	f, err := ioutil.TempFile("", "_Go_ErrPathNUL\x00")
 	if err == nil {
@@ -0,0 +1,556 @@
+// Copyright 2018 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package testing
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	matchFuzz        = flag.String("test.fuzz", "", "run the fuzz test matching `regexp`")
+	fuzzDuration     durationOrCountFlag
+	minimizeDuration = flag.Duration("test.fuzzminimizetime", 60*time.Second, "time to spend minimizing a value after finding a failing input; 0 disables minimization")
+	fuzzCacheDir     = flag.String("test.fuzzcachedir", "", "directory where interesting fuzzing inputs are stored (for use only by cmd/go)")
+)
+
+func init() {
+	flag.Var(&fuzzDuration, "test.fuzztime", "time to spend fuzzing, or `NNx` inputs to run; default is to run indefinitely")
+}
+
+// corpusDir is the parent directory of the fuzz test's seed corpus,
+// relative to the package directory.
+const corpusDir = "testdata/fuzz"
+
+// durationOrCountFlag is a flag value that is either a duration,
+// like 10s, or a number of iterations, like 100x.
+type durationOrCountFlag struct {
+	d time.Duration
+	n int
+}
+
+func (f *durationOrCountFlag) String() string {
+	if f.n > 0 {
+		return fmt.Sprintf("%dx", f.n)
+	}
+	return f.d.String()
+}
+
+func (f *durationOrCountFlag) Set(s string) error {
+	if strings.HasSuffix(s, "x") {
+		n, err := strconv.ParseInt(s[:len(s)-1], 10, 0)
+		if err != nil || n <= 0 {
+			return fmt.Errorf("invalid count")
+		}
+		*f = durationOrCountFlag{n: int(n)}
+		return nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return fmt.Errorf("invalid duration")
+	}
+	*f = durationOrCountFlag{d: d}
+	return nil
+}
+
+// InternalFuzzTarget is an internal type but exported because it is
+// cross-package; it is part of the implementation of the "go test" command.
+type InternalFuzzTarget struct {
+	Name string
+	Fn   func(f *F)
+}
+
+// F is a type passed to fuzz tests.
+//
+// Fuzz tests run generated inputs against a provided fuzz target, which can
+// find and report potential bugs in the code being tested.
+//
+// A fuzz test runs the seed corpus by default, which includes entries provided
+// by (*F).Add and entries in the testdata/fuzz/<FuzzTestName> directory. After
+// any necessary setup and calls to (*F).Add, the fuzz test must then call
+// (*F).Fuzz to provide the fuzz target. See the testing package documentation
+// for an example, and see the F.Fuzz and F.Add method documentation for
+// details.
+//
+// *F methods can only be called before (*F).Fuzz. Once the test is
+// executing the fuzz target, only (*T) methods can be used. The only *F methods
+// that are allowed in the (*F).Fuzz function are (*F).Failed and (*F).Name.
+type F struct {
+	common
+	fuzzContext *fuzzContext
+	testContext *testContext
+
+	// inFuzzFn is true when the fuzz function is running. Most F methods
+	// can't be called when inFuzzFn is true.
+	inFuzzFn bool
+
+	// corpus is a set of seed corpus entries, added with F.Add and loaded
+	// from testdata.
+	corpus []corpusEntry
+
+	fuzzCalled bool
+}
+
+var _ TB = (*F)(nil)
+
+// corpusEntry is an alias to the same type as internal/fuzz.CorpusEntry.
+// We use a type alias because we don't want to export this type, and we can't
+// import internal/fuzz from testing.
+type corpusEntry = struct {
+	Path   string
+	Data   []byte
+	Values []interface{}
+	IsSeed bool
+}
+
+// Helper marks the calling function as a test helper function.
+// When printing file and line information, that function will be skipped.
+// Helper may be called simultaneously from multiple goroutines.
+func (f *F) Helper() {
+	if f.inFuzzFn {
+		panic("testing: f.Helper was called inside the fuzz target, use t.Helper instead")
+	}
+	// common.Helper is inlined here.
+	// If we called it, it would mark F.Helper as the helper
+	// instead of the caller.
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.helpers == nil {
+		f.helpers = make(map[string]struct{})
+	}
+	f.helpers[callerName(1)] = struct{}{}
+}
+
+// Fail marks the function as having failed but continues execution.
+func (f *F) Fail() {
+	// (*F).Fail may be called by (*T).Fail, which we should allow. However, we
+	// shouldn't allow direct (*F).Fail calls from inside the (*F).Fuzz function.
+	if f.inFuzzFn {
+		panic("testing: f.Fail was called inside the fuzz target, use t.Fail instead")
+	}
+	f.common.Helper()
+	f.common.Fail()
+}
+
+// Skipped reports whether the test was skipped.
+func (f *F) Skipped() bool {
+	if f.inFuzzFn {
+		panic("testing: f.Skipped was called inside the fuzz target, use t.Skipped instead")
+	}
+	f.common.Helper()
+	return f.common.Skipped()
+}
+
+// supportedTypes represents all of the supported types which can be fuzzed.
+var supportedTypes = map[reflect.Type]bool{
+	reflect.TypeOf(([]byte)("")):  true,
+	reflect.TypeOf((string)("")):  true,
+	reflect.TypeOf((bool)(false)): true,
+	reflect.TypeOf((byte)(0)):     true,
+	reflect.TypeOf((rune)(0)):     true,
+	reflect.TypeOf((float32)(0)):  true,
+	reflect.TypeOf((float64)(0)):  true,
+	reflect.TypeOf((int)(0)):      true,
+	reflect.TypeOf((int8)(0)):     true,
+	reflect.TypeOf((int16)(0)):    true,
+	reflect.TypeOf((int32)(0)):    true,
+	reflect.TypeOf((int64)(0)):    true,
+	reflect.TypeOf((uint)(0)):     true,
+	reflect.TypeOf((uint8)(0)):    true,
+	reflect.TypeOf((uint16)(0)):   true,
+	reflect.TypeOf((uint32)(0)):   true,
+	reflect.TypeOf((uint64)(0)):   true,
+}
+
+// Add will add the arguments to the seed corpus for the fuzz test. This will be
+// a no-op if called after or within the fuzz target, and args must match the
+// arguments for the fuzz target.
+func (f *F) Add(args ...interface{}) {
+	var values []interface{}
+	for i := range args {
+		if t := reflect.TypeOf(args[i]); !supportedTypes[t] {
+			panic(fmt.Sprintf("testing: unsupported type to Add %v", t))
+		}
+		values = append(values, args[i])
+	}
+	f.corpus = append(f.corpus, corpusEntry{Values: values, IsSeed: true, Path: fmt.Sprintf("seed#%d", len(f.corpus))})
+}
+
+// Fuzz runs the fuzz function, ff, for fuzz testing. If ff fails for a set of
+// arguments, those arguments will be added to the seed corpus.
+//
+// ff must be a function with no return value whose first argument is *T and
+// whose remaining arguments are the types to be fuzzed.
+// For example:
+//
+//	f.Fuzz(func(t *testing.T, b []byte, i int) { ... })
+//
+// The following types are allowed: []byte, string, bool, byte, rune, float32,
+// float64, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64.
+// More types may be supported in the future.
+//
+// ff must not call any *F methods, e.g. (*F).Log, (*F).Error, (*F).Skip. Use
+// the corresponding *T method instead. The only *F methods that are allowed in
+// the (*F).Fuzz function are (*F).Failed and (*F).Name.
+//
+// This function should be fast and deterministic, and its behavior should not
+// depend on shared state. No mutable input arguments, or pointers to them,
+// should be retained between executions of the fuzz function, as the memory
+// backing them may be mutated during a subsequent invocation. ff must not
+// modify the underlying data of the arguments provided by the fuzzing engine.
+//
+// When fuzzing, F.Fuzz does not return until a problem is found, time runs out
+// (set with -fuzztime), or the test process is interrupted by a signal. F.Fuzz
+// should be called exactly once, unless F.Skip or F.Fail is called beforehand.
+func (f *F) Fuzz(ff interface{}) {
+	if f.fuzzCalled {
+		panic("testing: F.Fuzz called more than once")
+	}
+	f.fuzzCalled = true
+	if f.failed {
+		return
+	}
+	f.Helper()
+
+	// ff should be in the form func(*testing.T, ...interface{})
+	fn := reflect.ValueOf(ff)
+	fnType := fn.Type()
+	if fnType.Kind() != reflect.Func {
+		panic("testing: F.Fuzz must receive a function")
+	}
+	if fnType.NumIn() < 2 || fnType.In(0) != reflect.TypeOf((*T)(nil)) {
+		panic("testing: fuzz target must receive at least two arguments, where the first argument is a *T")
+	}
+	if fnType.NumOut() != 0 {
+		panic("testing: fuzz target must not return a value")
+	}
+
+	// Save the types of the function to compare against the corpus.
+	var types []reflect.Type
+	for i := 1; i < fnType.NumIn(); i++ {
+		t := fnType.In(i)
+		if !supportedTypes[t] {
+			panic(fmt.Sprintf("testing: unsupported type for fuzzing %v", t))
+		}
+		types = append(types, t)
+	}
+
+	// Load the testdata seed corpus. Check types of entries in the testdata
+	// corpus and entries declared with F.Add.
+	targetDir := corpusDir + "/" + f.name
+	c, err := f.fuzzContext.deps.ReadCorpus(targetDir, types)
+	if err != nil {
+		f.Fatal(err)
+	}
+	for i := range f.corpus {
+		if err := f.fuzzContext.deps.CheckCorpus(f.corpus[i].Values, types); err != nil {
+			f.Fatalf("%s: %v", f.corpus[i].Path, err)
+		}
+	}
+	f.corpus = append(f.corpus, c...)
+
+	// run calls fn on a given input, as a subtest with its own T.
+	// run is analogous to T.Run. The test filtering and cleanup works similarly.
+	// fn is called in its own goroutine.
+	run := func(parent *common, ctx *testContext, name string, e corpusEntry) (ok bool) {
+		t := &T{
+			common: common{
+				barrier: make(chan bool),
+				signal:  make(chan bool),
+				name:    name,
+				parent:  parent,
+				level:   f.level + 1,
+				chatty:  parent.chatty,
+			},
+			context: ctx,
+		}
+		t.w = indenter{&t.common}
+		if t.chatty {
+			// Print directly to root's io.Writer so there is no delay.
+			root := t.parent
+			for ; root.parent != nil; root = root.parent {
+			}
+			root.mu.Lock()
+			fmt.Fprintf(root.w, "=== RUN   %s\n", t.name)
+			root.mu.Unlock()
+		}
+		go tRunner(t, func(t *T) {
+			args := []reflect.Value{reflect.ValueOf(t)}
+			for _, v := range e.Values {
+				args = append(args, reflect.ValueOf(v))
+			}
+			if f.fuzzContext.mode == fuzzCoordinator {
+				// The fuzzing engine runs inputs in this process,
+				// so a panic must fail the input instead of the
+				// whole test binary.
+				defer func() {
+					if err := recover(); err != nil {
+						t.Errorf("panic: %v\n%s", err, debug.Stack())
+					}
+				}()
+			}
+			f.inFuzzFn = true
+			defer func() { f.inFuzzFn = false }()
+			fn.Call(args)
+		})
+		<-t.signal
+		return !t.Failed()
+	}
+
+	switch f.fuzzContext.mode {
+	case fuzzCoordinator:
+		// Fuzzing is enabled, and this is the test process started by 'go test'.
+		// Act as the coordinator process, and coordinate the fuzzing of the
+		// fuzz function.
+		cacheDir := ""
+		if *fuzzCacheDir != "" {
+			cacheDir = *fuzzCacheDir + "/" + f.name
+		}
+		minimize := *minimizeDuration
+		if minimize == 0 {
+			minimize = -1
+		}
+		err := f.fuzzContext.deps.CoordinateFuzzing(
+			fuzzDuration.d,
+			int64(fuzzDuration.n),
+			minimize,
+			f.corpus,
+			types,
+			targetDir,
+			cacheDir,
+			func(e corpusEntry) error {
+				// Run each input detached from f, collecting the report
+				// of a failure instead of printing it.
+				var buf bytes.Buffer
+				parent := &common{w: &buf}
+				ctx := newTestContext(1, newMatcher(f.fuzzContext.deps.MatchString, "", ""))
+				if run(parent, ctx, f.name, e) {
+					return nil
+				}
+				return errors.New(strings.TrimSuffix(buf.String(), "\n"))
+			})
+		if err != nil {
+			f.Fail()
+			fmt.Fprintf(f.w, "%v\n", err)
+			if crashErr, ok := err.(fuzzCrashError); ok {
+				crashPath := crashErr.CrashPath()
+				fmt.Fprintf(f.w, "\nFailing input written to %s\n", crashPath)
+				testName := crashPath[strings.LastIndexAny(crashPath, `/\`)+1:]
+				fmt.Fprintf(f.w, "To re-run:\ngo test -run=%s/%s\n", f.name, testName)
+			}
+		}
+
+	default:
+		// Fuzzing is not enabled, or will be done later. Only run the seed
+		// corpus now.
+		for _, e := range f.corpus {
+			base := e.Path[strings.LastIndexAny(e.Path, `/\`)+1:]
+			name, ok, _ := f.testContext.match.fullName(&f.common, base)
+			if !ok {
+				continue
+			}
+			atomic.StoreInt32(&f.hasSub, 1)
+			run(&f.common, f.testContext, name, e)
+		}
+	}
+}
+
+// fuzzCrashError is satisfied by a failing input detected while fuzzing.
+// These errors are written to the seed corpus and can be re-run with 'go test'.
+// Errors within the fuzzing framework (like I/O errors between coordinator
+// and worker processes) don't satisfy this interface.
+type fuzzCrashError interface {
+	error
+	Unwrap() error
+
+	// CrashPath returns the path of the subtest that corresponds to the saved
+	// crash input file in the seed corpus. The test can be re-run with go test
+	// -run=$test/$name $test is the fuzz test name, and $name is the
+	// filepath.Base of the string returned here.
+	CrashPath() string
+}
+
+func (f *F) report() {
+	if f.parent == nil {
+		return
+	}
+	dstr := fmtDuration(f.duration)
+	format := "--- %s: %s (%s)\n"
+	if f.Failed() {
+		f.flushToParent(format, "FAIL", f.name, dstr)
+	} else if f.chatty {
+		if f.Skipped() {
+			f.flushToParent(format, "SKIP", f.name, dstr)
+		} else {
+			f.flushToParent(format, "PASS", f.name, dstr)
+		}
+	}
+}
+
+// fuzzMode controls the way a fuzz test is run.
+type fuzzMode uint8
+
+const (
+	// seedCorpusOnly runs the seed corpus of each fuzz test as
+	// ordinary subtests.
+	seedCorpusOnly fuzzMode = iota
+
+	// fuzzCoordinator runs the fuzzing engine on the fuzz test
+	// selected with -test.fuzz.
+	fuzzCoordinator
+)
+
+// fuzzContext holds fields common to all fuzz tests.
+type fuzzContext struct {
+	deps testDeps
+	mode fuzzMode
+}
+
+// runFuzzTests runs the fuzz tests matching the pattern for -run. This will
+// only run the (*F).Fuzz function for each seed corpus entry.
+func runFuzzTests(deps testDeps, fuzzTests []InternalFuzzTarget) (ran, ok bool) {
+	ok = true
+	if len(fuzzTests) == 0 {
+		return ran, ok
+	}
+	for _, procs := range cpuList {
+		runtime.GOMAXPROCS(procs)
+		for i := uint(0); i < *count; i++ {
+			if shouldFailFast() {
+				break
+			}
+			r, o := runFuzzTestsOnce(deps, fuzzTests, *match, "-test.run", seedCorpusOnly)
+			ran = ran || r
+			ok = ok && o
+		}
+	}
+	return ran, ok
+}
+
+// runFuzzing runs the fuzz test matching the pattern for -fuzz. Only one such
+// fuzz test must match. This will run the fuzzing engine to generate and
+// mutate new inputs against the fuzz target.
+//
+// If fuzzing is disabled (-test.fuzz is not set), runFuzzing
+// returns immediately.
+func runFuzzing(deps testDeps, fuzzTests []InternalFuzzTarget) (ok bool) {
+	if len(fuzzTests) == 0 || *matchFuzz == "" {
+		return true
+	}
+	m := newMatcher(deps.MatchString, *matchFuzz, "-test.fuzz")
+	var fuzzTest *InternalFuzzTarget
+	var names []string
+	for i := range fuzzTests {
+		if _, ok, _ := m.fullName(nil, fuzzTests[i].Name); ok {
+			fuzzTest = &fuzzTests[i]
+			names = append(names, fuzzTests[i].Name)
+		}
+	}
+	if len(names) == 0 {
+		fmt.Fprintln(os.Stderr, "testing: warning: no fuzz tests to fuzz")
+		return true
+	}
+	if len(names) > 1 {
+		fmt.Fprintf(os.Stderr, "testing: will not fuzz, -fuzz matches more than one fuzz test: %v\n", names)
+		return false
+	}
+	_, ok = runFuzzTestsOnce(deps, []InternalFuzzTarget{*fuzzTest}, "", "-test.fuzz", fuzzCoordinator)
+	return ok
+}
+
+// runFuzzTestsOnce runs the fuzz tests matching pattern in the given mode.
+func runFuzzTestsOnce(deps testDeps, fuzzTests []InternalFuzzTarget, pattern, flagName string, mode fuzzMode) (ran, ok bool) {
+	tctx := newTestContext(1, newMatcher(deps.MatchString, pattern, flagName))
+	fctx := &fuzzContext{deps: deps, mode: mode}
+	root := common{w: os.Stdout} // gather output in one place
+	if Verbose() {
+		root.chatty = true
+	}
+	for _, ft := range fuzzTests {
+		if shouldFailFast() {
+			break
+		}
+		testName, matched, _ := tctx.match.fullName(nil, ft.Name)
+		if !matched {
+			continue
+		}
+		f := &F{
+			common: common{
+				signal: make(chan bool),
+				name:   testName,
+				parent: &root,
+				level:  root.level + 1,
+				chatty: root.chatty,
+			},
+			testContext: tctx,
+			fuzzContext: fctx,
+		}
+		f.w = indenter{&f.common}
+		if f.chatty {
+			root.mu.Lock()
+			fmt.Fprintf(root.w, "=== RUN   %s\n", f.name)
+			root.mu.Unlock()
+		}
+		go fRunner(f, ft.Fn)
+		<-f.signal
+	}
+	return root.ran, !root.Failed()
+}
+
+// fRunner wraps a call to a fuzz test and ensures that cleanup functions are
+// called and status flags are set. fRunner should be called in its own
+// goroutine. To wait for fRunner to finish, wait on f.signal.
+//
+// fRunner is analogous to tRunner, which wraps subtests started with T.Run.
+// Unit tests and fuzz tests work a little differently, so for now, these
+// functions aren't consolidated. In particular, because there are no F.Run and
+// F.Parallel methods, i.e., no fuzz sub-tests or parallel fuzz tests, a few
+// simplifications are made.
+func fRunner(f *F, fn func(*F)) {
+	f.runner = callerName(0)
+
+	// When this goroutine is done, either because fn(f) returned normally
+	// or because a test failure triggered a call to runtime.Goexit, record
+	// the duration and send a signal saying that the test is done.
+	defer func() {
+		f.duration += time.Since(f.start)
+		// If the test panicked, print any test output before dying.
+		err := recover()
+		if !f.finished && err == nil {
+			err = errNilPanicOrGoexit
+		}
+		if err != nil {
+			f.common.Fail()
+			f.report()
+			panic(err)
+		}
+		f.report()
+		f.done = true
+		f.setRan()
+		f.signal <- true
+	}()
+
+	defer f.runCleanup(normalPanic)
+
+	f.start = time.Now()
+	fn(f)
+
+	// Code beyond this point will not be executed when FailNow or SkipNow
+	// is invoked.
+	if f.failed {
+		atomic.AddUint32(&numFailed, 1)
+	}
+	f.finished = true
+}
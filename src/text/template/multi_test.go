@@ -8,6 +8,7 @@ package template
 
 import (
 	"bytes"
+	"embed"
 	"fmt"
 	"testing"
 	"text/template/parse"
@@ -153,6 +154,32 @@ func TestParseGlob(t *testing.T) {
 	testExecute(multiExecTests, template, t)
 }
 
+//go:embed testdata
+var testdataFS embed.FS
+
+func TestParseFS(t *testing.T) {
+	_, err := ParseFS(testdataFS, "testdata/DOES NOT EXIST")
+	if err == nil {
+		t.Error("expected error for non-existent file; got none")
+	}
+	_, err = New("error").ParseFS(testdataFS, "[x")
+	if err == nil {
+		t.Error("expected error for bad pattern; got none")
+	}
+	template := New("root")
+	_, err = template.ParseFS(testdataFS, "testdata/file1.tmpl", "testdata/file2.tmpl")
+	if err != nil {
+		t.Fatalf("error parsing files: %v", err)
+	}
+	testExecute(multiExecTests, template, t)
+
+	template, err = New("root").ParseFS(testdataFS, "testdata/file*.tmpl")
+	if err != nil {
+		t.Fatalf("error parsing files: %v", err)
+	}
+	testExecute(multiExecTests, template, t)
+}
+
 // In these tests, actual content (not just template definitions) comes from the parsed files.
 
 var templateFileExecTests = []execTest{